@@ -171,5 +171,6 @@ func init() {
 		cmd.Flags().BoolVar(&checkoutOurs, "ours", false, "Checkout our version of a conflicted file")
 		cmd.Flags().BoolVar(&checkoutTheirs, "theirs", false, "Checkout their version of a conflicted file")
 		cmd.Flags().BoolVar(&checkoutBase, "base", false, "Checkout the base version of a conflicted file")
+		cmd.Flags().BoolVar(&checkoutUnattributedArg, "unattributed", false, "Checkout files even if .gitattributes does not track them")
 	})
 }
@@ -78,20 +78,24 @@ func clean(gf *lfs.GitFilter, to io.Writer, from io.Reader, fileName string, fil
 		}
 		Debug("%s exists", mediafile)
 	} else {
-		if err := os.Rename(tmpfile, mediafile); err != nil {
+		if err := cfg.Filesystem().FinalizeObject(tmpfile, mediafile); err != nil {
 			Panic(err, tr.Tr.Get("Unable to move %s to %s", tmpfile, mediafile))
 		}
 
 		Debug(tr.Tr.Get("Writing %s", mediafile))
 	}
 
+	if err := gf.ExportCleaned(cleaned.Oid, mediafile); err != nil {
+		Panic(err, tr.Tr.Get("Unable to export %s", mediafile))
+	}
+
 	_, err = lfs.EncodePointer(to, cleaned.Pointer)
 	return cleaned.Pointer, err
 }
 
 func cleanCommand(cmd *cobra.Command, args []string) {
 	requireStdin(tr.Tr.Get("This command should be run by the Git 'clean' filter"))
-	setupRepository()
+	setupFilterRepository("clean")
 	installHooks(false)
 
 	var fileName string
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tr"
 
 	"github.com/git-lfs/git-lfs/v3/git"
@@ -33,7 +34,7 @@ func cloneCommand(cmd *cobra.Command, args []string) {
 			tr.Tr.Get("WARNING: `git lfs clone` is deprecated and will not be updated\n          with new flags from `git clone`"),
 			tr.Tr.Get("`git clone` has been updated in upstream Git to have comparable\nspeeds to `git lfs clone`."))
 
-		fmt.Fprintln(os.Stderr, msg)
+		warnings.Warn(warnings.DeprecatedCommand, msg)
 	}
 
 	// We pass all args to git clone
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configList       bool
+	configShowOrigin bool
+)
+
+// configCommand prints the configuration which Git LFS reads, merged from the
+// Git configuration, the .lfsconfig file, and the environment.  With
+// --show-origin, each value which is overridden or ignored is printed too,
+// along with where every value was set.
+func configCommand(cmd *cobra.Command, args []string) {
+	if !configList {
+		Exit(tr.Tr.Get("Nothing to do: use --list to print the configuration"))
+	}
+
+	settings, err := cfg.Settings()
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not read configuration")))
+	}
+
+	for _, s := range settings {
+		if !configShowOrigin {
+			if !s.Overridden && !s.Ignored {
+				Print("%s=%s", s.Key, s.Value)
+			}
+			continue
+		}
+
+		line := fmt.Sprintf("%s\t%s\t%s=%s", s.Scope, s.Origin, s.Key, s.Value)
+		if s.Ignored {
+			line += "\t" + tr.Tr.Get("(ignored)")
+		} else if s.Overridden {
+			line += "\t" + tr.Tr.Get("(overridden)")
+		}
+		Print("%s", line)
+	}
+}
+
+func init() {
+	RegisterCommand("config", configCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&configList, "list", "l", false, "Print the configuration which Git LFS reads.")
+		cmd.Flags().BoolVarP(&configShowOrigin, "show-origin", "", false, "Print where each value was set.")
+	})
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+func endpointCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if len(args) > 2 {
+		Exit(tr.Tr.Get("Usage: git lfs endpoint [<remote> [upload|download]]"))
+	}
+
+	remote := cfg.Remote()
+	if len(args) > 0 {
+		if err := cfg.SetValidRemote(args[0]); err != nil {
+			Exit(tr.Tr.Get("Invalid remote name %q: %s", args[0], err))
+		}
+		remote = args[0]
+	}
+
+	operation := "download"
+	if len(args) > 1 {
+		operation = args[1]
+	}
+	if operation != "download" && operation != "upload" {
+		Exit(tr.Tr.Get("Invalid operation %q: must be \"upload\" or \"download\"", operation))
+	}
+
+	endpoints := getAPIClient().Endpoints
+	endpoint, source := endpoints.ResolveEndpoint(operation, remote)
+	if len(endpoint.Url) == 0 {
+		Exit(tr.Tr.Get("No Git LFS endpoint found for remote %q", remote))
+	}
+
+	access := endpoints.AccessFor(endpoint.Url)
+	authScheme := "http"
+	if len(endpoint.SSHMetadata.UserAndHost) > 0 {
+		authScheme = "ssh"
+	}
+
+	Print("Remote=%s", remote)
+	Print("Operation=%s", operation)
+	Print("Endpoint=%s", endpoint.Url)
+	if len(endpoint.SSHMetadata.UserAndHost) > 0 {
+		Print("  SSH=%s:%s", endpoint.SSHMetadata.UserAndHost, endpoint.SSHMetadata.Path)
+	}
+	if strings.HasPrefix(endpoint.Url, "http://") || strings.HasPrefix(endpoint.Url, "https://") {
+		Print("BatchURL=%s", strings.TrimSuffix(endpoint.Url, "/")+"/objects/batch")
+	}
+	Print("Access=%s", access.Mode())
+	Print("AuthScheme=%s", authScheme)
+
+	switch {
+	case source.Guessed && len(source.Key) > 0:
+		Print("Source=%s", tr.Tr.Get("%s (guessed from Git remote URL)", source.Key))
+	case source.Guessed:
+		Print("Source=%s", tr.Tr.Get("remote URL (guessed from argument)"))
+	default:
+		Print("Source=%s", tr.Tr.Get("%s (configured)", source.Key))
+	}
+}
+
+func init() {
+	RegisterCommand("endpoint", endpointCommand, nil)
+}
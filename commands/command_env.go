@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"strings"
+
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
 )
@@ -45,6 +48,8 @@ func envCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	warnEndpointHostMismatches(cfg.Remotes())
+
 	for _, env := range lfs.Environ(cfg, getTransferManifest(), oldEnv) {
 		Print(env)
 	}
@@ -55,6 +60,39 @@ func envCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// warnEndpointHostMismatches warns about each of the given remotes whose Git
+// LFS endpoint was configured explicitly on a different host from the remote
+// itself, such as by a .lfsconfig file which was copied from another
+// repository.
+func warnEndpointHostMismatches(remotes []string) {
+	settings, _ := cfg.Settings()
+
+	for _, remote := range remotes {
+		seen := make(map[string]bool)
+		for _, operation := range []string{"download", "upload"} {
+			m := lfsapi.CheckEndpointHost(getAPIClient().Endpoints, operation, remote)
+			if m == nil || seen[m.Key] {
+				continue
+			}
+			seen[m.Key] = true
+
+			Error(tr.Tr.Get("warning: %s sets the Git LFS endpoint for remote %q to %s, which is on %s, but the remote is on %s",
+				settingSource(settings, m.Key), remote, m.Url, m.EndpointHost, m.RemoteHost))
+		}
+	}
+}
+
+// settingSource describes where the value of "key" in effect was set, naming
+// the .lfsconfig file if it came from there.
+func settingSource(settings []*config.Setting, key string) string {
+	for _, s := range settings {
+		if strings.EqualFold(s.Key, key) && !s.Overridden && !s.Ignored && s.Scope == ".lfsconfig" {
+			return tr.Tr.Get("%s in .lfsconfig", key)
+		}
+	}
+	return key
+}
+
 func init() {
 	RegisterCommand("env", envCommand, nil)
 }
@@ -2,7 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
@@ -19,6 +22,31 @@ var (
 	fetchRecentArg bool
 	fetchAllArg    bool
 	fetchPruneArg  bool
+
+	fetchContentFromArg string
+	fetchContentDir     *lfs.ContentDirectory
+
+	// fetchOidsFromArg is an object manifest, as written by "git lfs
+	// ls-files --manifest", listing the objects to fetch instead of
+	// those referenced by refs.
+	fetchOidsFromArg string
+
+	// fetchPointersFromArg is a file, or "-" for standard input, holding
+	// a stream of pointers to fetch instead of those referenced by refs.
+	fetchPointersFromArg string
+
+	// fetchIndexArg is set by --index, to fetch the objects referenced by
+	// the pointers staged in the index as well as those in the refs.
+	fetchIndexArg bool
+
+	// fetchVerifyArg is set by --verify, to read each fetched object back
+	// from the local store once it has been written and check its OID.
+	fetchVerifyArg bool
+
+	// fetchProfileArg and fetchProfileJSONArg are set by --profile and
+	// --json, to report the time spent in each phase of the fetch.
+	fetchProfileArg     bool
+	fetchProfileJSONArg bool
 )
 
 func getIncludeExcludeArgs(cmd *cobra.Command) (include, exclude *string) {
@@ -37,7 +65,32 @@ func getIncludeExcludeArgs(cmd *cobra.Command) (include, exclude *string) {
 func fetchCommand(cmd *cobra.Command, args []string) {
 	setupRepository()
 
+	if fetchProfileJSONArg && !fetchProfileArg {
+		Exit(tr.Tr.Get("--json requires --profile"))
+	}
+	if fetchProfileArg {
+		fetchProfiler = newFetchProfile()
+	}
+	if fetchDeadlineArg < 0 {
+		Exit(tr.Tr.Get("--deadline must not be negative"))
+	}
+	if fetchDeadlineArg > 0 {
+		fetchDeadline = time.Now().Add(fetchDeadlineArg)
+	}
+
 	var refs []*git.Ref
+	var ranges []*fetchRange
+
+	// Arguments after "--" are pathspecs limiting the objects fetched by
+	// --all to those found within them at any point in history.
+	var pathspecs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		pathspecs = args[dash:]
+		args = args[:dash]
+		if len(pathspecs) > 0 && !fetchAllArg {
+			Exit(tr.Tr.Get("Pathspecs may only be given with --all"))
+		}
+	}
 
 	if len(args) > 0 {
 		// Remote is first arg
@@ -46,13 +99,59 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if len(fetchOidsFromArg) > 0 {
+		if fetchAllArg || fetchRecentArg {
+			Exit(tr.Tr.Get("Cannot combine --oids-from with --all or --recent"))
+		}
+		if len(args) > 1 {
+			Exit(tr.Tr.Get("Cannot combine --oids-from with explicit refs"))
+		}
+	}
+
+	if len(fetchPointersFromArg) > 0 {
+		if len(fetchOidsFromArg) > 0 {
+			Exit(tr.Tr.Get("Cannot combine --pointers-from with --oids-from"))
+		}
+		if fetchAllArg || fetchRecentArg {
+			Exit(tr.Tr.Get("Cannot combine --pointers-from with --all or --recent"))
+		}
+		if len(args) > 1 {
+			Exit(tr.Tr.Get("Cannot combine --pointers-from with explicit refs"))
+		}
+		if include, exclude := getIncludeExcludeArgs(cmd); include != nil || exclude != nil {
+			Exit(tr.Tr.Get("Cannot combine --pointers-from with --include or --exclude"))
+		}
+	}
+
+	if fetchIndexArg {
+		if fetchAllArg || len(fetchOidsFromArg) > 0 || len(fetchPointersFromArg) > 0 {
+			Exit(tr.Tr.Get("Cannot combine --index with --all, --oids-from, or --pointers-from"))
+		}
+		if cfg.LocalWorkingDir() == "" {
+			Exit(tr.Tr.Get("Cannot use --index in a bare repository"))
+		}
+	}
+
 	if len(args) > 1 {
-		resolvedrefs, err := git.ResolveRefs(args[1:])
+		var refnames []string
+		for _, arg := range args[1:] {
+			r, err := parseFetchRange(arg)
+			if err != nil {
+				Panic(err, tr.Tr.Get("Invalid ref argument: %v", arg))
+			}
+			if r != nil {
+				ranges = append(ranges, r)
+			} else {
+				refnames = append(refnames, arg)
+			}
+		}
+
+		resolvedrefs, err := git.ResolveRefs(refnames)
 		if err != nil {
-			Panic(err, tr.Tr.Get("Invalid ref argument: %v", args[1:]))
+			Panic(err, tr.Tr.Get("Invalid ref argument: %v", refnames))
 		}
 		refs = resolvedrefs
-	} else if !fetchAllArg {
+	} else if !fetchAllArg && len(fetchOidsFromArg) == 0 && len(fetchPointersFromArg) == 0 {
 		ref, err := git.CurrentRef()
 		if err != nil {
 			Panic(err, tr.Tr.Get("Could not fetch"))
@@ -60,14 +159,34 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		refs = []*git.Ref{ref}
 	}
 
+	if len(fetchContentFromArg) > 0 {
+		dir, err := lfs.NewContentDirectory(fetchContentFromArg)
+		if err != nil {
+			Exit(tr.Tr.Get("Invalid content directory %q: %s", fetchContentFromArg, err))
+		}
+		fetchContentDir = dir
+	}
+
 	success := true
+	invalidPointers := 0
 	gitscanner := lfs.NewGitScanner(cfg, nil)
 	defer gitscanner.Close()
 
 	include, exclude := getIncludeExcludeArgs(cmd)
 	fetchPruneCfg := lfs.NewFetchPruneConfig(cfg.Git)
 
-	if fetchAllArg {
+	if len(fetchOidsFromArg) > 0 {
+		filter := buildFilepathFilter(cfg, include, exclude, true)
+		Print("fetch: %s", tr.Tr.Get("Fetching objects listed in %s", fetchOidsFromArg))
+		success = fetchOidsFrom(fetchOidsFromArg, filter)
+	} else if len(fetchPointersFromArg) > 0 {
+		if fetchPointersFromArg == "-" {
+			Print("fetch: %s", tr.Tr.Get("Fetching objects referenced by pointers on standard input"))
+		} else {
+			Print("fetch: %s", tr.Tr.Get("Fetching objects referenced by pointers in %s", fetchPointersFromArg))
+		}
+		success, invalidPointers = fetchPointersFrom(fetchPointersFromArg)
+	} else if fetchAllArg {
 		if fetchRecentArg {
 			Exit(tr.Tr.Get("Cannot combine --all with --recent"))
 		}
@@ -79,22 +198,56 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		}
 
 		if len(args) > 1 {
+			if len(pathspecs) > 0 {
+				Exit(tr.Tr.Get("Cannot combine pathspecs with explicit refs"))
+			}
+
 			refShas := make([]string, len(refs))
 			for _, ref := range refs {
 				refShas = append(refShas, ref.Sha)
 			}
-			success = fetchRefs(refShas)
+			success = fetchRefs(refShas, fetchPruneCfg.FetchScanBuffer)
+			for _, r := range ranges {
+				s := fetchRangeOfRefs(r, nil, fetchPruneCfg.FetchScanBuffer)
+				success = success && s
+			}
 		} else {
-			success = fetchAll()
+			success = fetchAll(fetchPruneCfg.FetchScanBuffer, pathspecs)
 		}
 
 	} else { // !all
 		filter := buildFilepathFilter(cfg, include, exclude, true)
 
-		// Fetch refs sequentially per arg order; duplicates in later refs will be ignored
-		for _, ref := range refs {
-			Print("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Refspec()))
-			s := fetchRef(ref.Sha, filter)
+		if fetchPruneCfg.FetchRefsConcurrency > 1 && len(refs) > 1 {
+			for _, ref := range refs {
+				Print("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Refspec()))
+			}
+			s := fetchRefsConcurrently(refs, filter, fetchPruneCfg.FetchRefsConcurrency)
+			success = success && s
+		} else {
+			// Fetch refs sequentially per arg order; duplicates in later refs will be ignored
+			for _, ref := range refs {
+				Print("fetch: %s", tr.Tr.Get("Fetching reference %s", ref.Refspec()))
+				s := fetchRef(ref.Sha, filter)
+				success = success && s
+			}
+		}
+
+		for _, r := range ranges {
+			Print("fetch: %s", tr.Tr.Get("Fetching range %s", r.spec))
+			s := fetchRangeOfRefs(r, filter, fetchPruneCfg.FetchScanBuffer)
+			success = success && s
+		}
+
+		if fetchIndexArg || (fetchPruneCfg.FetchIndex && cfg.LocalWorkingDir() != "") {
+			Print("fetch: %s", tr.Tr.Get("Fetching index"))
+			s := fetchIndex(filter)
+			success = success && s
+		}
+
+		if fetchPruneCfg.IncludeNotes {
+			Print("fetch: %s", tr.Tr.Get("Fetching notes"))
+			s := fetchNotes()
 			success = success && s
 		}
 
@@ -110,14 +263,97 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		prune(fetchPruneCfg, verify, false, false)
 	}
 
+	writeTransferReport()
+	writeFetchProfile(fetchProfileJSONArg)
+	deadlineExceeded := reportFetchDeadline()
+
+	if invalidPointers > 0 {
+		Error(tr.Tr.GetN(
+			"error: %d invalid pointer was not fetched",
+			"error: %d invalid pointers were not fetched",
+			invalidPointers,
+			invalidPointers))
+	}
 	if !success {
 		c := getAPIClient()
 		e := c.Endpoints.Endpoint("download", cfg.Remote())
 		Exit(tr.Tr.Get("error: failed to fetch some objects from '%s'", e.Url))
 	}
+	if invalidPointers > 0 {
+		os.Exit(2)
+	}
+	if deadlineExceeded {
+		os.Exit(fetchExitDeadlineExceeded)
+	}
+}
+
+// fetchOidsFrom fetches the objects listed in the object manifest "path", or
+// those of them at paths which "filter" allows.
+func fetchOidsFrom(path string, filter *filepathfilter.Filter) bool {
+	scanStart := time.Now()
+	f, err := os.Open(path)
+	if err != nil {
+		Exit(tr.Tr.Get("Could not open manifest %q: %s", path, err))
+	}
+	entries, err := lfs.ReadObjectManifest(f)
+	f.Close()
+	if err != nil {
+		Exit(tr.Tr.Get("Could not read manifest %q: %s", path, err))
+	}
+
+	pointers := make([]*lfs.WrappedPointer, 0, len(entries))
+	for _, e := range entries {
+		if filter != nil && !filter.Allows(e.Name) {
+			continue
+		}
+		pointers = append(pointers, &lfs.WrappedPointer{
+			Name:    e.Name,
+			Pointer: lfs.NewPointer(e.Oid, e.Size, nil),
+		})
+	}
+	fetchProfiler.scanSince(scanStart)
+	return fetchAndReportToChan(pointers, filter, nil)
+}
+
+// fetchPointersFrom fetches the objects referenced by the stream of pointers in
+// the file "path", or on standard input if it is "-", as read by
+// lfs.ReadPointerStream.  Each invalid entry is reported and skipped, and the
+// number of them is returned along with whether the rest were all fetched.
+func fetchPointersFrom(path string) (bool, int) {
+	scanStart := time.Now()
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			Exit(tr.Tr.Get("Could not open pointers %q: %s", path, err))
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var pointers []*lfs.WrappedPointer
+	invalid := 0
+	err := lfs.ReadPointerStream(r, func(p *lfs.Pointer, err error) {
+		if err != nil {
+			Error("fetch: %s", err)
+			invalid++
+			return
+		}
+		pointers = append(pointers, &lfs.WrappedPointer{
+			Name:    p.Oid,
+			Pointer: p,
+		})
+	})
+	if err != nil {
+		Exit(tr.Tr.Get("Could not read pointers %q: %s", path, err))
+	}
+	fetchProfiler.scanSince(scanStart)
+	return fetchAndReportToChan(pointers, nil, nil), invalid
 }
 
 func pointersToFetchForRef(ref string, filter *filepathfilter.Filter) ([]*lfs.WrappedPointer, error) {
+	defer fetchProfiler.scanSince(time.Now())
+
 	var pointers []*lfs.WrappedPointer
 	var multiErr error
 	tempgitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
@@ -152,7 +388,114 @@ func fetchRef(ref string, filter *filepathfilter.Filter) bool {
 	return fetchAndReportToChan(pointers, filter, nil)
 }
 
-func pointersToFetchForRefs(refs []string) ([]*lfs.WrappedPointer, error) {
+// fetchRefsConcurrently scans the trees of the given refs, up to "concurrency"
+// of them at once, and fetches the objects they reference with a single
+// transfer queue, so that an object referenced by more than one of them is
+// only transferred once.
+func fetchRefsConcurrently(refs []*git.Ref, filter *filepathfilter.Filter, concurrency int) bool {
+	found := make([][]*lfs.WrappedPointer, len(refs))
+	errs := make([]error, len(refs))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, ref *git.Ref) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			found[i], errs[i] = pointersToFetchForRef(ref.Sha, filter)
+		}(i, ref)
+	}
+	wg.Wait()
+
+	// Merge the pointers in the order in which the refs were given, so
+	// that an object referenced by more than one of them is reported
+	// under the path at which the first of them has it, as when the refs
+	// are fetched one after another.
+	var pointers []*lfs.WrappedPointer
+	for i, ref := range refs {
+		if errs[i] != nil {
+			Panic(errs[i], tr.Tr.Get("Could not scan for Git LFS files in %s", ref.Refspec()))
+		}
+		pointers = append(pointers, found[i]...)
+	}
+	return fetchAndReportToChan(pointers, filter, nil)
+}
+
+// fetchIndex fetches the objects referenced by the pointers staged in the
+// index, including those which have not been committed yet.
+func fetchIndex(filter *filepathfilter.Filter) bool {
+	scanStart := time.Now()
+	var pointers []*lfs.WrappedPointer
+	var multiErr error
+	tempgitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if multiErr != nil {
+				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
+			} else {
+				multiErr = err
+			}
+			return
+		}
+
+		pointers = append(pointers, p)
+	})
+
+	tempgitscanner.Filter = filter
+
+	// Scanning against the empty tree finds every pointer in the index,
+	// rather than only those staged since HEAD.
+	if err := tempgitscanner.ScanIndex(git.EmptyTree(), nil); err != nil {
+		Panic(err, tr.Tr.Get("Could not scan for Git LFS files"))
+	}
+	tempgitscanner.Close()
+
+	if multiErr != nil {
+		Panic(multiErr, tr.Tr.Get("Could not scan for Git LFS files"))
+	}
+	fetchProfiler.scanSince(scanStart)
+	return fetchAndReportToChan(pointers, filter, nil)
+}
+
+// fetchNotes fetches the objects referenced by the notes refs under
+// refs/notes/.  The paths of notes are named after the objects they annotate,
+// not files in the working tree, so the include and exclude paths do not apply
+// to them.
+func fetchNotes() bool {
+	scanStart := time.Now()
+	var pointers []*lfs.WrappedPointer
+	var multiErr error
+	tempgitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if multiErr != nil {
+				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
+			} else {
+				multiErr = err
+			}
+			return
+		}
+
+		pointers = append(pointers, p)
+	})
+
+	if err := tempgitscanner.ScanNotes(nil); err != nil {
+		Panic(err, tr.Tr.Get("Could not scan for Git LFS files"))
+	}
+	tempgitscanner.Close()
+
+	if multiErr != nil {
+		Panic(multiErr, tr.Tr.Get("Could not scan for Git LFS files"))
+	}
+	fetchProfiler.scanSince(scanStart)
+	return fetchAndReportToChan(pointers, nil, nil)
+}
+
+func pointersToFetchForRefs(include, exclude []string, filter *filepathfilter.Filter) ([]*lfs.WrappedPointer, error) {
+	defer fetchProfiler.scanSince(time.Now())
+
 	// This could be a long process so use the chan version & report progress
 	task := tasklog.NewSimpleTask()
 	defer task.Complete()
@@ -181,7 +524,9 @@ func pointersToFetchForRefs(refs []string) ([]*lfs.WrappedPointer, error) {
 		pointers = append(pointers, p)
 	})
 
-	if err := tempgitscanner.ScanRefs(refs, nil, nil); err != nil {
+	tempgitscanner.Filter = filter
+
+	if err := tempgitscanner.ScanRefs(include, exclude, nil); err != nil {
 		return nil, err
 	}
 
@@ -189,17 +534,101 @@ func pointersToFetchForRefs(refs []string) ([]*lfs.WrappedPointer, error) {
 	return pointers, multiErr
 }
 
-func fetchRefs(refs []string) bool {
-	pointers, err := pointersToFetchForRefs(refs)
+func fetchRefs(refs []string, scanBuffer int) bool {
+	if scanBuffer > 0 {
+		return fetchWhileScanning(nil, scanBuffer, func(s *lfs.GitScanner) error {
+			return s.ScanRefs(refs, nil, nil)
+		})
+	}
+
+	pointers, err := pointersToFetchForRefs(refs, nil, nil)
 	if err != nil {
 		Panic(err, tr.Tr.Get("Could not scan for Git LFS files"))
 	}
 	return fetchAndReportToChan(pointers, nil, nil)
 }
 
+// fetchRange is a range of commits given on the command line as either
+// "<left>..<right>", for the commits reachable from right but not left, or
+// "<left>...<right>", for the commits reachable from either side but not both.
+type fetchRange struct {
+	spec    string
+	include []string
+	exclude []string
+}
+
+// parseFetchRange parses the given command line argument as a range of
+// commits, returning nil if it is not a range.  As with Git, an omitted side
+// of the range means HEAD.
+func parseFetchRange(arg string) (*fetchRange, error) {
+	symmetric := true
+	sep := strings.Index(arg, "...")
+	if sep < 0 {
+		symmetric = false
+		if sep = strings.Index(arg, ".."); sep < 0 {
+			return nil, nil
+		}
+	}
+
+	left, right := arg[:sep], arg[sep+2:]
+	if symmetric {
+		right = arg[sep+3:]
+	}
+	if len(left) == 0 {
+		left = "HEAD"
+	}
+	if len(right) == 0 {
+		right = "HEAD"
+	}
+
+	leftRef, err := git.ResolveRef(left)
+	if err != nil {
+		return nil, err
+	}
+	rightRef, err := git.ResolveRef(right)
+	if err != nil {
+		return nil, err
+	}
+
+	if !symmetric {
+		return &fetchRange{
+			spec:    arg,
+			include: []string{rightRef.Sha},
+			exclude: []string{leftRef.Sha},
+		}, nil
+	}
+
+	bases, err := git.MergeBases(leftRef.Sha, rightRef.Sha)
+	if err != nil {
+		return nil, err
+	}
+	return &fetchRange{
+		spec:    arg,
+		include: []string{leftRef.Sha, rightRef.Sha},
+		exclude: bases,
+	}, nil
+}
+
+// fetchRangeOfRefs fetches the objects referenced by the commits in the given
+// range, but not those referenced only by commits outside it.
+func fetchRangeOfRefs(r *fetchRange, filter *filepathfilter.Filter, scanBuffer int) bool {
+	if scanBuffer > 0 {
+		return fetchWhileScanning(filter, scanBuffer, func(s *lfs.GitScanner) error {
+			return s.ScanRefs(r.include, r.exclude, nil)
+		})
+	}
+
+	pointers, err := pointersToFetchForRefs(r.include, r.exclude, filter)
+	if err != nil {
+		Panic(err, tr.Tr.Get("Could not scan for Git LFS files"))
+	}
+	return fetchAndReportToChan(pointers, filter, nil)
+}
+
 // Fetch all previous versions of objects from since to ref (not including final state at ref)
 // So this will fetch all the '-' sides of the diff from since to ref
 func fetchPreviousVersions(ref string, since time.Time, filter *filepathfilter.Filter) bool {
+	scanStart := time.Now()
 	var pointers []*lfs.WrappedPointer
 
 	tempgitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
@@ -218,6 +647,7 @@ func fetchPreviousVersions(ref string, since time.Time, filter *filepathfilter.F
 	}
 
 	tempgitscanner.Close()
+	fetchProfiler.scanSince(scanStart)
 	return fetchAndReportToChan(pointers, filter, nil)
 }
 
@@ -285,13 +715,25 @@ func fetchRecent(fetchconf lfs.FetchPruneConfig, alreadyFetchedRefs []*git.Ref,
 	return ok
 }
 
-func fetchAll() bool {
-	pointers := scanAll()
+// fetchAll fetches the objects referenced anywhere in history, or, if any
+// pathspecs are given, those found within them at any commit.
+func fetchAll(scanBuffer int, pathspecs []string) bool {
+	if scanBuffer > 0 {
+		Print("fetch: %s", tr.Tr.Get("Fetching all references..."))
+		return fetchWhileScanning(nil, scanBuffer, func(s *lfs.GitScanner) error {
+			s.Pathspecs = pathspecs
+			return s.ScanAll(nil)
+		})
+	}
+
+	pointers := scanAll(pathspecs)
 	Print("fetch: %s", tr.Tr.Get("Fetching all references..."))
 	return fetchAndReportToChan(pointers, nil, nil)
 }
 
-func scanAll() []*lfs.WrappedPointer {
+func scanAll(pathspecs []string) []*lfs.WrappedPointer {
+	defer fetchProfiler.scanSince(time.Now())
+
 	// This could be a long process so use the chan version & report progress
 	task := tasklog.NewSimpleTask()
 	defer task.Complete()
@@ -320,6 +762,8 @@ func scanAll() []*lfs.WrappedPointer {
 		pointers = append(pointers, p)
 	})
 
+	tempgitscanner.Pathspecs = pathspecs
+
 	if err := tempgitscanner.ScanAll(nil); err != nil {
 		Panic(err, tr.Tr.Get("Could not scan for Git LFS files"))
 	}
@@ -337,10 +781,24 @@ func scanAll() []*lfs.WrappedPointer {
 // Returns true if all completed with no errors, false if errors were written to stderr/log
 func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter, out chan<- *lfs.WrappedPointer) bool {
 	ready, pointers, meter := readyAndMissingPointers(allpointers, filter)
+	if len(pointers) == 0 {
+		// Every object is already present, so there is no need to
+		// set up a transfer queue, or to contact the remote at all.
+		if out != nil {
+			for _, p := range ready {
+				out <- p
+			}
+			close(out)
+		}
+		printFetchUpToDate()
+		return true
+	}
+
 	q := newDownloadQueue(
 		getTransferManifestOperationRemote("download", cfg.Remote()),
-		cfg.Remote(), tq.WithProgress(meter),
+		cfg.Remote(), tq.WithProgress(meter), tq.WithDeadline(fetchDeadline),
 	)
+	verifier := newFetchVerifier(q)
 
 	if out != nil {
 		// If we already have it, or it won't be fetched
@@ -382,13 +840,96 @@ func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfil
 	processQueue := time.Now()
 	q.Wait()
 	tracerx.PerformanceSince("process queue", processQueue)
+	recordTransferReport(q)
+	recordFetchDeadline(q)
+	fetchProfiler.record(q)
 
 	ok := true
 	for _, err := range q.Errors() {
 		ok = false
 		FullError(err)
 	}
-	return ok
+	return verifier.Verify() && ok
+}
+
+// fetchWhileScanning fetches the objects for the pointers found by "scan",
+// queueing each for transfer as soon as it is found rather than once the scan
+// has finished, with at most "scanBuffer" pointers waiting to be queued.
+// Returns true if all completed with no errors, false if errors were written
+// to stderr/log.
+func fetchWhileScanning(filter *filepathfilter.Filter, scanBuffer int, scan func(s *lfs.GitScanner) error) bool {
+	logger := tasklog.NewLogger(os.Stdout,
+		tasklog.ForceProgress(cfg.ForceProgress()),
+	)
+	meter := buildProgressMeter(false, tq.Download)
+	logger.Enqueue(meter)
+
+	// The queue is only set up once the first object which is not already
+	// present is found.
+	var q *tq.TransferQueue
+	var verifier *fetchVerifier
+
+	seen := make(map[string]bool)
+	scanStart := time.Now()
+	err := streamScannedPointers(scanBuffer, func(cb lfs.GitScannerFoundPointer) error {
+		tempgitscanner := lfs.NewGitScanner(cfg, cb)
+		tempgitscanner.Filter = filter
+		defer tempgitscanner.Close()
+
+		return scan(tempgitscanner)
+	}, func(p *lfs.WrappedPointer) {
+		// no need to download the same object multiple times
+		if seen[p.Oid] {
+			return
+		}
+		seen[p.Oid] = true
+
+		if pointerIsReady(p) {
+			return
+		}
+
+		if q == nil {
+			q = newDownloadQueue(
+				getTransferManifestOperationRemote("download", cfg.Remote()),
+				cfg.Remote(), tq.WithProgress(meter), tq.WithDeadline(fetchDeadline),
+			)
+			verifier = newFetchVerifier(q)
+		}
+
+		meter.Add(p.Size)
+		tracerx.Printf("fetch %v [%v]", p.Name, p.Oid)
+		q.Add(downloadTransfer(p))
+	})
+	if err != nil {
+		Panic(err, tr.Tr.Get("Could not scan for Git LFS files"))
+	}
+	tracerx.PerformanceSince("scan", scanStart)
+	fetchProfiler.scanSince(scanStart)
+
+	if q == nil {
+		printFetchUpToDate()
+		return true
+	}
+
+	processQueue := time.Now()
+	q.Wait()
+	tracerx.PerformanceSince("process queue", processQueue)
+	recordTransferReport(q)
+	recordFetchDeadline(q)
+	fetchProfiler.record(q)
+
+	ok := true
+	for _, err := range q.Errors() {
+		ok = false
+		FullError(err)
+	}
+	return verifier.Verify() && ok
+}
+
+// printFetchUpToDate reports that every object to be fetched was already
+// present, so that nothing was transferred.
+func printFetchUpToDate() {
+	Print("fetch: %s", tr.Tr.Get("Everything up to date"))
 }
 
 func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter) ([]*lfs.WrappedPointer, []*lfs.WrappedPointer, *tq.Meter) {
@@ -410,9 +951,7 @@ func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepath
 
 		seen[p.Oid] = true
 
-		// no need to download objects that exist locally already
-		lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
-		if cfg.LFSObjectExists(p.Oid, p.Size) {
+		if pointerIsReady(p) {
 			ready = append(ready, p)
 			continue
 		}
@@ -424,6 +963,27 @@ func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepath
 	return ready, missing, meter
 }
 
+// pointerIsReady returns true if the object for the given pointer need not be
+// downloaded.
+func pointerIsReady(p *lfs.WrappedPointer) bool {
+	// no need to download objects that exist locally already
+	lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
+	if cfg.LFSObjectExists(p.Oid, p.Size) {
+		return true
+	}
+
+	// nor objects which can be imported from a local directory
+	if fetchContentDir != nil {
+		imported, err := fetchContentDir.Import(cfg, p.Oid, p.Size)
+		if err != nil {
+			Error(tr.Tr.Get("Could not import %s from %s: %s", p.Oid, fetchContentFromArg, err))
+		} else if imported {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	RegisterCommand("fetch", fetchCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
@@ -431,5 +991,14 @@ func init() {
 		cmd.Flags().BoolVarP(&fetchRecentArg, "recent", "r", false, "Fetch recent refs & commits")
 		cmd.Flags().BoolVarP(&fetchAllArg, "all", "a", false, "Fetch all LFS files ever referenced")
 		cmd.Flags().BoolVarP(&fetchPruneArg, "prune", "p", false, "After fetching, prune old data")
+		cmd.Flags().StringVar(&fetchContentFromArg, "content-from", "", "Import objects from a local directory before fetching")
+		cmd.Flags().StringVar(&fetchOidsFromArg, "oids-from", "", "Fetch the objects listed in a manifest written by ls-files --manifest")
+		cmd.Flags().StringVar(&fetchPointersFromArg, "pointers-from", "", "Fetch the objects referenced by the pointers in a file, or - for stdin")
+		cmd.Flags().BoolVar(&fetchIndexArg, "index", false, "Also fetch the objects referenced by the pointers staged in the index")
+		cmd.Flags().BoolVar(&fetchVerifyArg, "verify", false, "Check each fetched object against its OID once it has been written")
+		cmd.Flags().StringVar(&transferReportArg, "report", "", "Write a JSON report of the transferred objects to this file")
+		cmd.Flags().BoolVar(&fetchProfileArg, "profile", false, "Report the time spent in each phase of the fetch")
+		cmd.Flags().BoolVar(&fetchProfileJSONArg, "json", false, "Give the --profile report as JSON")
+		cmd.Flags().DurationVar(&fetchDeadlineArg, "deadline", 0, "Stop fetching objects once this much time has passed")
 	})
 }
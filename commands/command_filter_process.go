@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
@@ -29,6 +30,11 @@ const (
 	// `*git.PacketWriter`'s internal buffer when the filter protocol
 	// dictates the "smudge" command.
 	smudgeFilterBufferCapacity = pktline.MaxPacketLength
+
+	// defaultSmudgeRetryDelay is the number of seconds to wait before
+	// retrying a failed smudge download, unless "lfs.smudge.retrydelay"
+	// says otherwise.
+	defaultSmudgeRetryDelay = 1
 )
 
 // filterSmudgeSkip is a command-line flag owned by the `filter-process` command
@@ -38,7 +44,7 @@ var filterSmudgeSkip bool
 
 func filterCommand(cmd *cobra.Command, args []string) {
 	requireStdin(tr.Tr.Get("This command should be run by the Git filter process"))
-	setupRepository()
+	setupFilterRepository("filter-process")
 	installHooks(false)
 
 	s := git.NewFilterProcessScanner(os.Stdin, os.Stdout)
@@ -71,6 +77,8 @@ func filterCommand(cmd *cobra.Command, args []string) {
 	var closeOnce *sync.Once
 	var available chan *tq.Transfer
 	gitfilter := lfs.NewGitFilter(cfg)
+	gitfilter.DownloadRetries = cfg.Git.Int("lfs.smudge.retries", 0)
+	gitfilter.DownloadRetryDelay = time.Duration(cfg.Git.Int("lfs.smudge.retrydelay", defaultSmudgeRetryDelay)) * time.Second
 	for s.Scan() {
 		var n int64
 		var err error
@@ -81,6 +89,11 @@ func filterCommand(cmd *cobra.Command, args []string) {
 
 		switch req.Header["command"] {
 		case "clean":
+			// Clean requests are handled one at a time: Git
+			// waits for each response before sending the next
+			// request, and only offers "can-delay" for smudge
+			// requests during checkout, so there is no second
+			// request to hash while this one is in progress.
 			s.WriteStatus(statusFromErr(nil))
 			w = pktline.NewPktlineWriter(os.Stdout, cleanFilterBufferCapacity)
 
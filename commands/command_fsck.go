@@ -3,18 +3,28 @@ package commands
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/git/gitattr"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tasklog"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tq"
 	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +32,23 @@ var (
 	fsckDryRun   bool
 	fsckObjects  bool
 	fsckPointers bool
+	fsckAttrs    bool
+	// fsckConsistency checks that each blob is a pointer exactly when
+	// .gitattributes says its path is tracked by Git LFS.
+	fsckConsistency bool
+	fsckRemote      bool
+	fsckAll         bool
+	fsckJSON        bool
+	// fsckRemoteHeadCheck checks, with --remote, that each object which
+	// the batch response offers is present with a HEAD request to its URL.
+	fsckRemoteHeadCheck bool
+	// fsckHealth, set by --head, checks, in one pass, that each file in
+	// the tree of a single revision which Git LFS tracks is a valid
+	// pointer to an object which is present locally or on the remote.
+	fsckHealth bool
+	// fsckLegacyStore checks for objects stored in the layouts of old
+	// versions of Git LFS, and moves them into the current one.
+	fsckLegacyStore bool
 )
 
 type corruptPointer struct {
@@ -37,10 +64,6 @@ func (p corruptPointer) String() string {
 	return fmt.Sprintf("%s: %s", p.kind, p.message)
 }
 
-// TODO(zeroshirts): 'git fsck' reports status (percentage, current#/total) as
-// it checks... we should do the same, as we are rehashing potentially gigs and
-// gigs of content.
-//
 // NOTE(zeroshirts): Ideally git would have hooks for fsck such that we could
 // chain a lfs-fsck, but I don't think it does.
 func fsckCommand(cmd *cobra.Command, args []string) {
@@ -51,8 +74,45 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 	start := ""
 	end := "HEAD"
 
+	// An argument after "--" is the path of a single file to check.
+	var paths []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		paths = args[dash:]
+		args = args[:dash]
+		if len(paths) != 1 {
+			Exit(tr.Tr.Get("Only one path may be given"))
+		}
+		if fsckObjects || fsckPointers || fsckAttrs || fsckConsistency || fsckRemote || fsckAll || fsckJSON || fsckRemoteHeadCheck || fsckLegacyStore || fsckHealth {
+			Exit(tr.Tr.Get("Cannot use --objects, --pointers, --attrs, --consistency, --remote, --all, --json, --remote-head-check, --legacy-store, or --head with a path"))
+		}
+	}
+
+	if fsckHealth {
+		if fsckObjects || fsckPointers || fsckAttrs || fsckConsistency || fsckRemote || fsckAll || fsckRemoteHeadCheck || fsckLegacyStore {
+			Exit(tr.Tr.Get("Cannot use --head with --objects, --pointers, --attrs, --consistency, --remote, --all, --remote-head-check, or --legacy-store"))
+		}
+	} else if !fsckRemote {
+		if fsckAll {
+			Exit(tr.Tr.Get("Cannot use --all without --remote"))
+		}
+		if fsckJSON {
+			Exit(tr.Tr.Get("Cannot use --json without --remote or --head"))
+		}
+		if fsckRemoteHeadCheck {
+			Exit(tr.Tr.Get("Cannot use --remote-head-check without --remote"))
+		}
+	} else if fsckJSON && (fsckObjects || fsckPointers || fsckAttrs || fsckConsistency || fsckLegacyStore) {
+		Exit(tr.Tr.Get("Cannot use --json with --objects, --pointers, --attrs, --consistency, or --legacy-store"))
+	}
+	if fsckAll && len(args) > 0 {
+		Exit(tr.Tr.Get("Cannot use --all with explicit revisions"))
+	}
+
 	switch len(args) {
 	case 0:
+		if fsckAll {
+			break
+		}
 		useIndex = true
 		ref, err := git.CurrentRef()
 		if err != nil {
@@ -73,12 +133,43 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	if !fsckPointers && !fsckObjects {
+	if len(paths) > 0 {
+		if len(start) > 0 {
+			Exit(tr.Tr.Get("Cannot use a range of revisions with a path"))
+		}
+		if doFsckFile(end, rootedPaths(paths)[0], fsckRevisionIsCheckedOut(end)) {
+			Print(tr.Tr.Get("Git LFS fsck OK"))
+			return
+		}
+		os.Exit(1)
+	}
+
+	if fsckHealth {
+		if len(start) > 0 {
+			Exit(tr.Tr.Get("Cannot use a range of revisions with --head"))
+		}
+		if !doFsckHealth(end) {
+			os.Exit(1)
+		}
+		if !fsckJSON {
+			Print(tr.Tr.Get("Git LFS fsck OK"))
+		}
+		return
+	}
+
+	// --remote, --attrs, --consistency, or --legacy-store on its own only
+	// performs that check.
+	if !fsckPointers && !fsckObjects && !fsckAttrs && !fsckConsistency && !fsckRemote && !fsckLegacyStore {
 		fsckPointers = true
 		fsckObjects = true
 	}
 
 	ok := true
+	// Legacy objects are moved first, so that the other checks find them.
+	if fsckLegacyStore {
+		legacy := doFsckLegacyStore()
+		ok = ok && len(legacy) == 0
+	}
 	var corruptOids []string
 	var corruptPointers []corruptPointer
 	if fsckObjects {
@@ -89,6 +180,26 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		corruptPointers = doFsckPointers(start, end)
 		ok = ok && len(corruptPointers) == 0
 	}
+	if fsckAttrs {
+		untracked := doFsckAttrs(end)
+		ok = ok && len(untracked) == 0
+	}
+	if fsckConsistency {
+		mismatched := doFsckConsistency(end)
+		ok = ok && len(mismatched) == 0
+	}
+	if fsckRemote {
+		missing := doFsckRemote(start, end, useIndex)
+		ok = ok && len(missing) == 0
+
+		if fsckJSON {
+			printFsckRemoteJSON(missing)
+			if !ok {
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
 	if ok {
 		Print(tr.Tr.Get("Git LFS fsck OK"))
@@ -120,20 +231,28 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 }
 
 // doFsckObjects checks that the objects in the given ref are correct and exist.
+// The objects are hashed in parallel, one worker per CPU, but are reported in
+// the order in which they were found.
 func doFsckObjects(start, end string, useIndex bool) []string {
-	var corruptOids []string
-	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
-		if err == nil {
-			var pointerOk bool
-			pointerOk, err = fsckPointer(p.Name, p.Oid, p.Size)
-			if !pointerOk {
-				corruptOids = append(corruptOids, p.Oid)
-			}
-		}
+	// Report progress on stderr, since the problems found are printed to
+	// stdout.
+	task := tasklog.NewSimpleTask()
+	logger := tasklog.NewLogger(os.Stderr,
+		tasklog.ForceProgress(cfg.ForceProgress()),
+	)
+	logger.Enqueue(task)
+
+	pool := newFsckObjectPool(runtime.NumCPU(), func(p *lfs.WrappedPointer) fsckObjectResult {
+		return fsckPointer(p.Name, p.Oid, p.Size)
+	}, func(n int) {
+		task.Logf("fsck: %s", tr.Tr.GetN("%d object checked", "%d objects checked", n, n))
+	})
 
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			Panic(err, tr.Tr.Get("Error checking Git LFS files"))
 		}
+		pool.Add(p)
 	})
 
 	// If 'lfs.fetchexclude' is set and 'git lfs fsck' is run after the
@@ -160,9 +279,50 @@ func doFsckObjects(start, end string, useIndex bool) []string {
 	}
 
 	gitscanner.Close()
+	results := pool.Wait()
+	task.Complete()
+
+	var corruptOids []string
+	for _, result := range results {
+		if result.Err != nil {
+			Panic(result.Err, tr.Tr.Get("Error checking Git LFS files"))
+		}
+		if len(result.Message) > 0 {
+			Print(result.Message)
+		}
+		if !result.Ok {
+			corruptOids = append(corruptOids, result.Oid)
+		}
+	}
 	return corruptOids
 }
 
+// doFsckLegacyStore reports each object stored in the layout of an old version
+// of Git LFS, and, unless --dry-run is given, moves them into the current one.
+func doFsckLegacyStore() []fs.LegacyObject {
+	f := cfg.Filesystem()
+	legacy, err := f.LegacyObjects()
+	if err != nil {
+		ExitWithError(err)
+	}
+	for _, obj := range legacy {
+		Print("legacy: legacyObject: %s", tr.Tr.Get("%s is stored in a legacy layout at %s", obj.Oid, obj.Path))
+	}
+	if fsckDryRun || len(legacy) == 0 {
+		return legacy
+	}
+
+	Print("legacy: repair: %s", tr.Tr.Get("moving legacy objects to %s", f.LFSObjectDir()))
+	n, err := f.MigrateLegacyObjects()
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not move legacy objects")))
+	}
+	if n < len(legacy) {
+		Print("legacy: repair: %s", tr.Tr.GetN("%d object was corrupt and was not moved", "%d objects were corrupt and were not moved", len(legacy)-n, len(legacy)-n))
+	}
+	return legacy
+}
+
 // doFsckPointers checks that the pointers in the given ref are correct and canonical.
 func doFsckPointers(start, end string) []corruptPointer {
 	var corruptPointers []corruptPointer
@@ -210,7 +370,305 @@ func doFsckPointers(start, end string) []corruptPointer {
 	return corruptPointers
 }
 
-func fsckPointer(name, oid string, size int64) (bool, error) {
+// doFsckAttrs checks that each pointer in the tree of the given commit is at a
+// path which the .gitattributes files in that tree say is tracked by Git LFS,
+// so that the pointer is smudged when that commit is checked out.
+func doFsckAttrs(commit string) []corruptPointer {
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	attrs, _ := fsckCommitAttrs(db, commit)
+
+	var untracked []corruptPointer
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Panic(err, tr.Tr.Get("Error checking Git LFS files"))
+		}
+
+		if !fsckIsTracked(attrs, p.Name) {
+			cp := corruptPointer{
+				blobOid: p.Sha1,
+				lfsOid:  p.Oid,
+				path:    p.Name,
+				message: tr.Tr.Get("%q (blob %s) is a pointer, but no pattern in .gitattributes tracks it", p.Name, p.Sha1),
+				kind:    "untrackedPointer",
+			}
+			untracked = append(untracked, cp)
+		}
+	})
+
+	if err := gitscanner.ScanTree(commit); err != nil {
+		ExitWithError(err)
+	}
+	gitscanner.Close()
+
+	sort.Slice(untracked, func(i, j int) bool {
+		return untracked[i].path < untracked[j].path
+	})
+	for _, cp := range untracked {
+		Print("attrs: %s", cp.String())
+	}
+	return untracked
+}
+
+// fsckCommitAttrs returns the attributes given by the .gitattributes files in
+// the tree of the given commit, and that tree.
+func fsckCommitAttrs(db *gitobj.ObjectDatabase, commit string) (*gitattr.Tree, *gitobj.Tree) {
+	sha, err := hex.DecodeString(commit)
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("could not read commit %s", commit)))
+	}
+	c, err := db.Commit(sha)
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("could not read commit %s", commit)))
+	}
+	t, err := db.Tree(c.TreeID)
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("could not read tree of commit %s", commit)))
+	}
+	attrs, err := gitattr.New(db, t)
+	if err != nil {
+		ExitWithError(err)
+	}
+	return attrs, t
+}
+
+// fsckIsTracked returns whether "attrs" says that the file at "path" is tracked
+// by Git LFS.
+func fsckIsTracked(attrs *gitattr.Tree, path string) bool {
+	tracked := false
+	for _, attr := range attrs.Applied(path) {
+		if attr.K == git.FilterAttrib {
+			tracked = !attr.Unspecified && git.IsLFSFilter(attr.V)
+		}
+	}
+	return tracked
+}
+
+// fsckMissingObject is an object which the remote does not have.
+type fsckMissingObject struct {
+	Name string `json:"name"`
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// doFsckRemote checks that the remote has the object for every pointer in the
+// given ref, or in every ref if --all was given, and returns the objects which
+// it does not have, in order of their names.  The objects are checked in batches, as by 'git lfs fetch
+// --dry-run', without downloading anything, and with --remote-head-check are then confirmed
+// with HEAD requests.
+func doFsckRemote(start, end string, useIndex bool) []*fsckMissingObject {
+	remote := cfg.Remote()
+
+	var pointers []*lfs.WrappedPointer
+	seen := tools.NewStringSet()
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Panic(err, tr.Tr.Get("Error checking Git LFS files"))
+		}
+		if seen.Add(p.Oid) {
+			pointers = append(pointers, p)
+		}
+	})
+
+	var err error
+	switch {
+	case fsckAll:
+		err = gitscanner.ScanAll(nil)
+	case start == "":
+		err = gitscanner.ScanRef(end, nil)
+	default:
+		err = gitscanner.ScanRefRange(start, end, nil)
+	}
+	if err == nil && useIndex {
+		err = gitscanner.ScanIndex("HEAD", nil)
+	}
+	gitscanner.Close()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	var verified tools.StringSet
+	if fsckRemoteHeadCheck {
+		verified = fsckRemoteWithHead(remote, pointers)
+	} else {
+		verified = fsckRemoteWithBatch(remote, pointers)
+	}
+
+	sort.Slice(pointers, func(i, j int) bool {
+		if pointers[i].Name != pointers[j].Name {
+			return pointers[i].Name < pointers[j].Name
+		}
+		return pointers[i].Oid < pointers[j].Oid
+	})
+
+	missing := make([]*fsckMissingObject, 0)
+	for _, p := range pointers {
+		if verified.Contains(p.Oid) {
+			continue
+		}
+		if !fsckJSON {
+			Print("remote: missingObject: %s", tr.Tr.Get("%s (%s) is missing on remote %q", p.Name, p.Oid, remote))
+		}
+		missing = append(missing, &fsckMissingObject{
+			Name: p.Name,
+			Oid:  p.Oid,
+			Size: p.Size,
+		})
+	}
+	return missing
+}
+
+// fsckRemoteWithBatch returns the OIDs of those of the given pointers whose
+// objects the remote has, checked in batches as by 'git lfs fetch --dry-run'.
+func fsckRemoteWithBatch(remote string, pointers []*lfs.WrappedPointer) tools.StringSet {
+	m := getTransferManifestOperationRemote("download", remote)
+	verified, err := fsckRemoteConcurrently(m.ConcurrentBatches(), pointers, func(batch []*lfs.WrappedPointer) (tools.StringSet, error) {
+		return fsckRemoteBatch(remote, batch)
+	})
+	if err != nil {
+		ExitWithError(err)
+	}
+	return verified
+}
+
+// fsckRemoteBatch is like fsckRemoteWithBatch, but returns an error, rather
+// than exiting, if the objects could not be checked at all, and checks all of
+// the given pointers with one transfer queue.
+func fsckRemoteBatch(remote string, pointers []*lfs.WrappedPointer) (tools.StringSet, error) {
+	q := newDownloadCheckQueue(getTransferManifestOperationRemote("download", remote), remote)
+	verified := tools.NewStringSetWithCapacity(len(pointers))
+	watch := q.Watch()
+	done := make(chan struct{})
+	go func() {
+		for t := range watch {
+			verified.Add(t.Oid)
+		}
+		close(done)
+	}()
+
+	for _, p := range pointers {
+		tracerx.Printf("VERIFYING: %v", p.Oid)
+		q.Add(downloadTransfer(p))
+	}
+	q.Wait()
+	<-done
+
+	// Errors for individual objects just mean that the remote does not
+	// have them, but any other error means that nothing could be checked.
+	for _, err := range q.Errors() {
+		if _, ok := errors.Cause(err).(*tq.ObjectError); !ok {
+			return nil, errors.Wrap(err, tr.Tr.Get("Could not check objects on remote %q", remote))
+		}
+	}
+
+	return verified, nil
+}
+
+// fsckRemoteWithHead returns the OIDs of those of the given pointers whose
+// objects the remote has.  Each object which a batch response offers to
+// download is confirmed with a HEAD request to its URL, unless the server
+// does not support those, in which case the batch response is relied on.
+func fsckRemoteWithHead(remote string, pointers []*lfs.WrappedPointer) tools.StringSet {
+	m := getTransferManifestOperationRemote("download", remote)
+	verified, err := fsckRemoteConcurrently(m.ConcurrentBatches(), pointers, func(batch []*lfs.WrappedPointer) (tools.StringSet, error) {
+		objects := make([]*tq.Transfer, 0, len(batch))
+		for _, p := range batch {
+			tracerx.Printf("VERIFYING: %v", p.Oid)
+			objects = append(objects, &tq.Transfer{Oid: p.Oid, Size: p.Size})
+		}
+
+		res, err := tq.Batch(m, tq.Download, remote, currentRemoteRef(), objects)
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("Could not check objects on remote %q", remote))
+		}
+
+		statuses, err := tq.VerifyWithHead(m, remote, res)
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("Could not check objects on remote %q", remote))
+		}
+
+		verified := tools.NewStringSetWithCapacity(len(statuses))
+		for oid, status := range statuses {
+			if status != tq.HeadMissing {
+				verified.Add(oid)
+			}
+		}
+		return verified, nil
+	})
+	if err != nil {
+		ExitWithError(err)
+	}
+	return verified
+}
+
+// fsckRemoteConcurrently splits the given pointers into batches of
+// fsckRemoteBatchSize and checks each with "check", running up to "workers" at
+// once.  It returns every OID which any batch verified, or the error of the
+// first batch, in order, which failed.
+func fsckRemoteConcurrently(workers int, pointers []*lfs.WrappedPointer, check func([]*lfs.WrappedPointer) (tools.StringSet, error)) (tools.StringSet, error) {
+	var batches [][]*lfs.WrappedPointer
+	for len(pointers) > 0 {
+		n := tools.MinInt(len(pointers), fsckRemoteBatchSize)
+		batches = append(batches, pointers[:n])
+		pointers = pointers[n:]
+	}
+
+	// Each batch has its own results, so that they are only combined once
+	// every batch has finished.
+	results := make([]tools.StringSet, len(batches))
+	errs := make([]error, len(batches))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, tools.MaxInt(workers, 1))
+	for i, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, batch []*lfs.WrappedPointer) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i], errs[i] = check(batch)
+		}(i, batch)
+	}
+	wg.Wait()
+
+	verified := tools.NewStringSet()
+	for i := range batches {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		for oid := range results[i] {
+			verified.Add(oid)
+		}
+	}
+	return verified, nil
+}
+
+// fsckRemoteBatchSize is the number of objects in each batch checked by
+// fsckRemoteConcurrently.
+const fsckRemoteBatchSize = 100
+
+func printFsckRemoteJSON(missing []*fsckMissingObject) {
+	ret, err := json.Marshal(struct {
+		Remote  string               `json:"remote"`
+		Missing []*fsckMissingObject `json:"missing"`
+	}{cfg.Remote(), missing})
+	if err != nil {
+		ExitWithError(err)
+	}
+	Print(string(ret))
+}
+
+// fsckPointer rehashes the object for the given pointer.  It is safe to call
+// from multiple goroutines, and leaves printing any problem it finds to the
+// caller.
+func fsckPointer(name, oid string, size int64) fsckObjectResult {
 	path := cfg.Filesystem().ObjectPathname(oid)
 
 	Debug(tr.Tr.Get("Examining %v (%v)", name, path))
@@ -219,30 +677,38 @@ func fsckPointer(name, oid string, size int64) (bool, error) {
 	if pErr, pOk := err.(*os.PathError); pOk {
 		// This is an empty file.  No problem here.
 		if size == 0 {
-			return true, nil
+			return fsckObjectResult{Oid: oid, Ok: true}
+		}
+		message := fmt.Sprintf("objects: openError: %s", tr.Tr.Get("%s (%s) could not be checked: %s", name, oid, pErr.Err))
+		if legacy := cfg.Filesystem().LegacyObjectPathname(oid); len(legacy) > 0 {
+			message += "\n" + fmt.Sprintf("objects: legacyObject: %s", tr.Tr.Get("%s is stored in a legacy layout at %s; run `git lfs fsck --legacy-store` to move it", oid, legacy))
+		}
+		return fsckObjectResult{
+			Oid:     oid,
+			Message: message,
 		}
-		Print("objects: openError: %s", tr.Tr.Get("%s (%s) could not be checked: %s", name, oid, pErr.Err))
-		return false, nil
 	}
 
 	if err != nil {
-		return false, err
+		return fsckObjectResult{Oid: oid, Err: err}
 	}
 
 	oidHash := sha256.New()
 	_, err = io.Copy(oidHash, f)
 	f.Close()
 	if err != nil {
-		return false, err
+		return fsckObjectResult{Oid: oid, Err: err}
 	}
 
 	recalculatedOid := hex.EncodeToString(oidHash.Sum(nil))
 	if recalculatedOid == oid {
-		return true, nil
+		return fsckObjectResult{Oid: oid, Ok: true}
 	}
 
-	Print(fmt.Sprintf("objects: corruptObject: %s", tr.Tr.Get("%s (%s) is corrupt", name, oid)))
-	return false, nil
+	return fsckObjectResult{
+		Oid:     oid,
+		Message: fmt.Sprintf("objects: corruptObject: %s", tr.Tr.Get("%s (%s) is corrupt", name, oid)),
+	}
 }
 
 func init() {
@@ -250,5 +716,13 @@ func init() {
 		cmd.Flags().BoolVarP(&fsckDryRun, "dry-run", "d", false, "List corrupt objects without deleting them.")
 		cmd.Flags().BoolVarP(&fsckObjects, "objects", "", false, "Fsck objects.")
 		cmd.Flags().BoolVarP(&fsckPointers, "pointers", "", false, "Fsck pointers.")
+		cmd.Flags().BoolVarP(&fsckAttrs, "attrs", "", false, "Check that each pointer is tracked by .gitattributes.")
+		cmd.Flags().BoolVarP(&fsckConsistency, "consistency", "", false, "Check that each file is a pointer exactly when .gitattributes tracks it.")
+		cmd.Flags().BoolVarP(&fsckRemote, "remote", "", false, "Check that the remote has each object.")
+		cmd.Flags().BoolVarP(&fsckAll, "all", "", false, "Check objects in all refs.")
+		cmd.Flags().BoolVarP(&fsckJSON, "json", "", false, "Print missing objects as JSON.")
+		cmd.Flags().BoolVarP(&fsckRemoteHeadCheck, "remote-head-check", "", false, "With --remote, confirm each object with a HEAD request to its URL.")
+		cmd.Flags().BoolVarP(&fsckHealth, "head", "", false, "Check that each tracked file is a pointer to an available object.")
+		cmd.Flags().BoolVarP(&fsckLegacyStore, "legacy-store", "", false, "Move objects stored in legacy layouts into the current one.")
 	})
 }
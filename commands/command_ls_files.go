@@ -1,11 +1,14 @@
 package commands
 
 import (
+	"encoding/json"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tools/humanize"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
@@ -17,12 +20,123 @@ var (
 	lsFilesScanDeleted  = false
 	lsFilesShowSize     = false
 	lsFilesShowNameOnly = false
-	debug               = false
+	// lsFilesMaxCount is the number of files after which to stop
+	// reporting, or zero to report every file.
+	lsFilesMaxCount = 0
+	// lsFilesGroupByExt reports totals for each file extension instead
+	// of listing files, as JSON if lsFilesJSON is also set.
+	lsFilesGroupByExt = false
+	lsFilesJSON       = false
+	debug             = false
+	// lsFilesResolveNames names pointers which the scan reported without
+	// a path after a path at which they appear in the current tree.
+	lsFilesResolveNames = false
+	// lsFilesLifespan reports the earliest and latest commits containing
+	// each object instead of listing files, as JSON if lsFilesJSON is
+	// also set.
+	lsFilesLifespan = false
+	// lsFilesManifest is the file to which to write a manifest of the
+	// listed objects instead of listing them, or "-" for standard output.
+	lsFilesManifest = ""
+	// lsFilesNullTerminate terminates each listed file with a NUL byte
+	// rather than a newline.
+	lsFilesNullTerminate = false
+	// lsFilesRemoteRefs is the remote whose remote-tracking refs are
+	// scanned instead of a single reference, or empty.
+	lsFilesRemoteRefs = ""
+	// lsFilesCSV is the file to which to write each listed object as a row
+	// of CSV instead of listing them, or "-" for standard output.
+	lsFilesCSV = ""
+	// lsFilesUniqueTo is the ref whose history is scanned for the objects
+	// reachable from it but from no other ref, or empty.
+	lsFilesUniqueTo = ""
+	// lsFilesShowDedup reports, for each object, the distinct paths at which
+	// it appears and the bytes saved by sharing its content instead of
+	// listing files, as JSON if lsFilesJSON is also set.
+	lsFilesShowDedup = false
+	// lsFilesWithExt limits the listed files to those whose pointers carry
+	// any of the given pointer extensions.
+	lsFilesWithExt []string
+	// lsFilesReachability reports, for each object, the refs from whose
+	// history it is reachable instead of listing files, as JSON if
+	// lsFilesJSON is also set.
+	lsFilesReachability = false
+	// lsFilesMaxRefs is the number of refs after which to stop scanning
+	// with --reachability, or zero to scan every ref.
+	lsFilesMaxRefs = 0
 )
 
 func lsFilesCommand(cmd *cobra.Command, args []string) {
 	setupRepository()
 
+	if lsFilesMaxCount < 0 {
+		Exit(tr.Tr.Get("Invalid --max-count value: %d", lsFilesMaxCount))
+	}
+	if lsFilesJSON && !lsFilesGroupByExt && !lsFilesLifespan && !lsFilesShowDedup && !lsFilesReachability {
+		Exit(tr.Tr.Get("Cannot use --json without --group-by-ext, --lifespan, --dedup, or --reachability"))
+	}
+	if lsFilesMaxRefs < 0 {
+		Exit(tr.Tr.Get("Invalid --max-refs value: %d", lsFilesMaxRefs))
+	}
+	if lsFilesReachability {
+		if lsFilesScanAll || lsFilesScanDeleted || lsFilesLifespan || lsFilesGroupByExt || lsFilesShowDedup || len(lsFilesRemoteRefs) > 0 || len(lsFilesUniqueTo) > 0 || len(lsFilesManifest) > 0 || len(lsFilesCSV) > 0 || lsFilesNullTerminate || lsFilesMaxCount > 0 || debug {
+			Exit(tr.Tr.Get("Cannot use --reachability with --all, --deleted, --lifespan, --group-by-ext, --dedup, --remote-refs, --unique-to, --manifest, --csv, -z, --max-count, or --debug"))
+		}
+	} else if lsFilesMaxRefs > 0 {
+		Exit(tr.Tr.Get("Cannot use --max-refs without --reachability"))
+	}
+	if lsFilesLifespan && (lsFilesGroupByExt || lsFilesScanDeleted) {
+		Exit(tr.Tr.Get("Cannot use --lifespan with --group-by-ext or --deleted"))
+	}
+	if len(lsFilesManifest) > 0 && (lsFilesGroupByExt || lsFilesLifespan || debug) {
+		Exit(tr.Tr.Get("Cannot use --manifest with --group-by-ext, --lifespan, or --debug"))
+	}
+	if lsFilesNullTerminate && (lsFilesGroupByExt || lsFilesJSON || len(lsFilesManifest) > 0 || debug) {
+		Exit(tr.Tr.Get("Cannot use -z with --group-by-ext, --json, --manifest, or --debug"))
+	}
+	if len(lsFilesCSV) > 0 && (lsFilesGroupByExt || lsFilesLifespan || len(lsFilesManifest) > 0 || lsFilesNullTerminate || debug) {
+		Exit(tr.Tr.Get("Cannot use --csv with --group-by-ext, --lifespan, --manifest, -z, or --debug"))
+	}
+
+	if lsFilesShowDedup {
+		if lsFilesGroupByExt || lsFilesLifespan || len(lsFilesManifest) > 0 || len(lsFilesCSV) > 0 || lsFilesNullTerminate || debug {
+			Exit(tr.Tr.Get("Cannot use --dedup with --group-by-ext, --lifespan, --manifest, --csv, -z, or --debug"))
+		}
+		// Scans of history report each object only once, so only a
+		// single tree has every path at which an object appears.
+		if lsFilesScanAll || lsFilesScanDeleted || len(lsFilesRemoteRefs) > 0 || len(lsFilesUniqueTo) > 0 || len(args) > 1 {
+			Exit(tr.Tr.Get("Cannot use --dedup with --all, --deleted, --remote-refs, --unique-to, or a reference range"))
+		}
+	}
+
+	var extFilter *lfs.PointerExtensionFilter
+	if len(lsFilesWithExt) > 0 {
+		// Lifespans are found without reading the pointers.
+		if lsFilesLifespan {
+			Exit(tr.Tr.Get("Cannot use --with-ext with --lifespan"))
+		}
+		var err error
+		if extFilter, err = lfs.ParsePointerExtensionFilter(lsFilesWithExt); err != nil {
+			Exit(tr.Tr.Get("Invalid --with-ext value: %s", err))
+		}
+	}
+
+	if len(lsFilesRemoteRefs) > 0 {
+		if lsFilesScanAll || lsFilesScanDeleted || lsFilesLifespan || len(args) > 0 {
+			Exit(tr.Tr.Get("Cannot use --remote-refs with --all, --deleted, --lifespan, or an explicit reference"))
+		}
+		// Only a named remote has remote-tracking refs.
+		if !tools.NewStringSetFromSlice(cfg.Remotes()).Contains(lsFilesRemoteRefs) {
+			Exit(tr.Tr.Get("Invalid remote name %q", lsFilesRemoteRefs))
+		}
+	}
+
+	if len(lsFilesUniqueTo) > 0 {
+		if lsFilesScanAll || lsFilesScanDeleted || lsFilesLifespan || len(lsFilesRemoteRefs) > 0 || len(args) > 0 {
+			Exit(tr.Tr.Get("Cannot use --unique-to with --all, --deleted, --lifespan, --remote-refs, or an explicit reference"))
+		}
+	}
+
 	var ref string
 	var otherRef string
 	var scanRange = false
@@ -61,25 +175,72 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 		showOidLen = 64
 	}
 
+	if lsFilesReachability {
+		lsFilesReachabilities(cmd, args, showOidLen)
+		return
+	}
+	if lsFilesLifespan {
+		lsFilesLifespans(cmd, ref, otherRef, showOidLen)
+		return
+	}
+
 	seen := make(map[string]struct{})
+	reported := 0
+	groups := newLsFilesExtGroups()
+	dedup := newLsFilesDedup()
+	var manifest []*lfs.ObjectManifestEntry
 
-	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
-		if err != nil {
-			Exit(tr.Tr.Get("Could not scan for Git LFS tree: %s", err))
-			return
+	var sink lfs.ScanRecordSink
+	var commit string
+	if len(lsFilesCSV) > 0 {
+		var closeSink func()
+		sink, closeSink = lsFilesOpenCSV()
+		defer closeSink()
+
+		// Only a single tree has a commit to which its files belong.
+		if !lsFilesScanAll && !lsFilesScanDeleted && !scanRange && len(lsFilesRemoteRefs) == 0 && len(lsFilesUniqueTo) == 0 {
+			commit = lsFilesCommit(ref)
 		}
+	}
 
-		if p.Size == 0 {
+	// unnamed holds the pointers which the scan reported without a path,
+	// if --resolve-names was given, until they can be named from the
+	// current tree.
+	var unnamed []*lfs.WrappedPointer
+
+	var gitscanner *lfs.GitScanner
+	report := func(p *lfs.WrappedPointer) {
+		if lsFilesMaxCount > 0 && reported >= lsFilesMaxCount {
 			return
 		}
 
-		if !lsFilesScanAll && !scanRange {
+		if !lsFilesScanAll && !scanRange && len(lsFilesRemoteRefs) == 0 && len(lsFilesUniqueTo) == 0 {
 			if _, ok := seen[p.Name]; ok {
 				return
 			}
 		}
 
-		if debug {
+		if lsFilesGroupByExt {
+			groups.Add(p)
+		} else if lsFilesShowDedup {
+			dedup.Add(p)
+		} else if sink != nil {
+			err := sink.Write(&lfs.ScanRecord{
+				Oid:    p.Oid,
+				Size:   p.Size,
+				Name:   p.Name,
+				Commit: commit,
+			})
+			if err != nil {
+				Exit(tr.Tr.Get("Could not write %q: %s", lsFilesCSV, err))
+			}
+		} else if len(lsFilesManifest) > 0 {
+			manifest = append(manifest, &lfs.ObjectManifestEntry{
+				Oid:  p.Oid,
+				Size: p.Size,
+				Name: p.Name,
+			})
+		} else if debug {
 			// TRANSLATORS: these strings should have the colons
 			// aligned in a column.
 			Print(
@@ -101,17 +262,44 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 				msg = append(msg, "("+size+")")
 			}
 
-			Print(strings.Join(msg, " "))
+			PrintRecord(strings.Join(msg, " "), lsFilesNullTerminate)
 		}
 
 		seen[p.Name] = struct{}{}
+
+		reported++
+		if lsFilesMaxCount > 0 && reported >= lsFilesMaxCount {
+			// Stop walking history once enough files have been
+			// reported, rather than discarding the rest.
+			gitscanner.Stop()
+		}
+	}
+
+	gitscanner = lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Exit(tr.Tr.Get("Could not scan for Git LFS tree: %s", err))
+			return
+		}
+
+		if p.Size == 0 {
+			return
+		}
+
+		if lsFilesResolveNames && len(p.Name) == 0 {
+			unnamed = append(unnamed, p)
+			return
+		}
+
+		report(p)
 	})
 	defer gitscanner.Close()
 
 	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
 	gitscanner.Filter = buildFilepathFilter(cfg, includeArg, excludeArg, false)
+	gitscanner.ExtensionFilter = extFilter
+	gitscanner.NameRefBlobs = true
 
-	if len(args) == 0 {
+	if len(args) == 0 && len(lsFilesRemoteRefs) == 0 && len(lsFilesUniqueTo) == 0 {
 		// Only scan the index when "git lfs ls-files" was invoked with
 		// no arguments.
 		//
@@ -125,6 +313,14 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 		if err := gitscanner.ScanAll(nil); err != nil {
 			Exit(tr.Tr.Get("Could not scan for Git LFS history: %s", err))
 		}
+	} else if len(lsFilesRemoteRefs) > 0 {
+		if err := gitscanner.ScanRemoteRefs(lsFilesRemoteRefs, nil); err != nil {
+			Exit(tr.Tr.Get("Could not scan for Git LFS history: %s", err))
+		}
+	} else if len(lsFilesUniqueTo) > 0 {
+		if err := gitscanner.ScanUniqueTo(lsFilesUniqueTo, nil); err != nil {
+			Exit(tr.Tr.Get("Could not scan for Git LFS history: %s", err))
+		}
 	} else {
 		var err error
 		if lsFilesScanDeleted {
@@ -139,6 +335,244 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 			Exit(tr.Tr.Get("Could not scan for Git LFS tree: %s", err))
 		}
 	}
+
+	if len(unnamed) > 0 {
+		lsFilesResolveUnnamed(unnamed)
+		for _, p := range unnamed {
+			if gitscanner.Filter.Allows(p.Name) {
+				report(p)
+			}
+		}
+	}
+
+	if lsFilesGroupByExt {
+		if lsFilesJSON {
+			groups.PrintJSON()
+		} else {
+			groups.Print()
+		}
+	}
+	if lsFilesShowDedup {
+		if lsFilesJSON {
+			dedup.PrintJSON()
+		} else {
+			dedup.Print(showOidLen)
+		}
+	}
+	if len(lsFilesManifest) > 0 {
+		lsFilesWriteManifest(manifest)
+	}
+}
+
+// lsFilesWriteManifest writes the given entries as an object manifest to the
+// file given by --manifest.
+func lsFilesWriteManifest(manifest []*lfs.ObjectManifestEntry) {
+	if lsFilesManifest == "-" {
+		if err := lfs.WriteObjectManifest(os.Stdout, manifest); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	f, err := os.Create(lsFilesManifest)
+	if err != nil {
+		Exit(tr.Tr.Get("Could not create manifest %q: %s", lsFilesManifest, err))
+	}
+	err = lfs.WriteObjectManifest(f, manifest)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		Exit(tr.Tr.Get("Could not write manifest %q: %s", lsFilesManifest, err))
+	}
+}
+
+// lsFilesOpenCSV opens the file given by --csv, and returns a sink writing CSV
+// to it, along with a function which closes both.
+func lsFilesOpenCSV() (lfs.ScanRecordSink, func()) {
+	var f *os.File
+	if lsFilesCSV == "-" {
+		f = os.Stdout
+	} else {
+		var err error
+		f, err = os.Create(lsFilesCSV)
+		if err != nil {
+			Exit(tr.Tr.Get("Could not create %q: %s", lsFilesCSV, err))
+		}
+	}
+
+	sink, err := lfs.NewCSVScanRecordSink(f)
+	if err != nil {
+		Exit(tr.Tr.Get("Could not write %q: %s", lsFilesCSV, err))
+	}
+
+	return sink, func() {
+		err := sink.Close()
+		if f != os.Stdout {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			Exit(tr.Tr.Get("Could not write %q: %s", lsFilesCSV, err))
+		}
+	}
+}
+
+// lsFilesCommit returns the SHA of the commit named by "ref", or an empty
+// string if it does not name one, such as when there is no current commit.
+func lsFilesCommit(ref string) string {
+	if ref == git.EmptyTree() {
+		return ""
+	}
+	resolved, err := git.ResolveRef(ref)
+	if err != nil {
+		return ""
+	}
+	return resolved.Sha
+}
+
+// lsFilesResolveUnnamed names each of the given pointers after a path at
+// which its blob appears in the tree of HEAD, if any.
+func lsFilesResolveUnnamed(unnamed []*lfs.WrappedPointer) {
+	if _, err := git.CurrentRef(); err != nil {
+		// There is no current tree in which to look.
+		return
+	}
+
+	shas := make(map[string]struct{}, len(unnamed))
+	for _, p := range unnamed {
+		if len(p.Sha1) > 0 {
+			shas[p.Sha1] = struct{}{}
+		}
+	}
+
+	names, err := lfs.TreeNames("HEAD", shas)
+	if err != nil {
+		Exit(tr.Tr.Get("Could not resolve names from the current tree: %s", err))
+	}
+
+	for _, p := range unnamed {
+		if name, ok := names[p.Sha1]; ok {
+			p.Name = name
+		}
+	}
+}
+
+// lsFilesLifespans prints the lifespan of each object in the history of "ref",
+// excluding that of "otherRef" if given, or in all history with --all.
+func lsFilesLifespans(cmd *cobra.Command, ref, otherRef string, showOidLen int) {
+	gitscanner := lfs.NewGitScanner(cfg, nil)
+	defer gitscanner.Close()
+
+	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
+	gitscanner.Filter = buildFilepathFilter(cfg, includeArg, excludeArg, false)
+
+	mode := lfs.ScanRefsMode
+	if lsFilesScanAll {
+		mode = lfs.ScanAllMode
+	}
+	var exclude []string
+	if len(otherRef) > 0 {
+		exclude = []string{otherRef}
+	}
+
+	var lifespans []*lfs.ObjectLifespan
+	if mode == lfs.ScanAllMode || ref != git.EmptyTree() {
+		var err error
+		lifespans, err = gitscanner.ScanLifespans([]string{ref}, exclude, mode)
+		if err != nil {
+			Exit(tr.Tr.Get("Could not scan for Git LFS history: %s", err))
+		}
+	}
+	if lsFilesMaxCount > 0 && len(lifespans) > lsFilesMaxCount {
+		lifespans = lifespans[:lsFilesMaxCount]
+	}
+
+	if lsFilesJSON {
+		if lifespans == nil {
+			lifespans = []*lfs.ObjectLifespan{}
+		}
+		ret, err := json.Marshal(struct {
+			Files []*lfs.ObjectLifespan `json:"files"`
+		}{lifespans})
+		if err != nil {
+			ExitWithError(err)
+		}
+		Print("%s", ret)
+		return
+	}
+
+	for _, l := range lifespans {
+		msg := []string{
+			l.Oid[:showOidLen],
+			l.First.Date.Format("2006-01-02"),
+			l.First.Sha[:10],
+			l.Last.Date.Format("2006-01-02"),
+			l.Last.Sha[:10],
+			l.Name,
+		}
+		if lsFilesShowSize {
+			msg = append(msg, "("+humanize.FormatBytes(uint64(l.Size))+")")
+		}
+		PrintRecord(strings.Join(msg, " "), lsFilesNullTerminate)
+	}
+}
+
+// lsFilesReachabilities prints, for each object in the history of the given
+// refs, or of every ref if none are given, the refs from which it is
+// reachable.
+func lsFilesReachabilities(cmd *cobra.Command, refs []string, showOidLen int) {
+	if len(refs) == 0 {
+		all, err := git.AllRefs()
+		if err != nil {
+			Exit(tr.Tr.Get("Could not list references: %s", err))
+		}
+		refs = make([]string, 0, len(all))
+		for _, r := range all {
+			refs = append(refs, r.Refspec())
+		}
+	}
+	if lsFilesMaxRefs > 0 && len(refs) > lsFilesMaxRefs {
+		Error(tr.Tr.Get("warning: only scanning the first %d of %d references", lsFilesMaxRefs, len(refs)))
+		refs = refs[:lsFilesMaxRefs]
+	}
+
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Exit(tr.Tr.Get("Could not scan for Git LFS history: %s", err))
+		}
+	})
+	defer gitscanner.Close()
+
+	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
+	gitscanner.Filter = buildFilepathFilter(cfg, includeArg, excludeArg, false)
+
+	reachable, err := gitscanner.ScanReachability(refs, nil)
+	if err != nil {
+		Exit(tr.Tr.Get("Could not scan for Git LFS history: %s", err))
+	}
+
+	if lsFilesJSON {
+		ret, err := json.Marshal(struct {
+			Refs    []string            `json:"refs"`
+			Objects map[string][]string `json:"objects"`
+		}{refs, reachable})
+		if err != nil {
+			ExitWithError(err)
+		}
+		Print("%s", ret)
+		return
+	}
+
+	oids := make([]string, 0, len(reachable))
+	for oid := range reachable {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+	for _, oid := range oids {
+		Print("%s %s", oid[:showOidLen], strings.Join(reachable[oid], " "))
+	}
 }
 
 // Returns true if a pointer appears to be properly smudge on checkout
@@ -163,6 +597,20 @@ func init() {
 		cmd.Flags().BoolVarP(&debug, "debug", "d", false, "")
 		cmd.Flags().BoolVarP(&lsFilesScanAll, "all", "a", false, "")
 		cmd.Flags().BoolVar(&lsFilesScanDeleted, "deleted", false, "")
+		cmd.Flags().IntVar(&lsFilesMaxCount, "max-count", 0, "")
+		cmd.Flags().BoolVar(&lsFilesGroupByExt, "group-by-ext", false, "")
+		cmd.Flags().BoolVar(&lsFilesJSON, "json", false, "")
+		cmd.Flags().BoolVar(&lsFilesResolveNames, "resolve-names", false, "")
+		cmd.Flags().BoolVar(&lsFilesLifespan, "lifespan", false, "")
+		cmd.Flags().StringVar(&lsFilesManifest, "manifest", "", "")
+		cmd.Flags().BoolVarP(&lsFilesNullTerminate, "null", "z", false, "")
+		cmd.Flags().StringVar(&lsFilesRemoteRefs, "remote-refs", "", "")
+		cmd.Flags().StringVar(&lsFilesCSV, "csv", "", "")
+		cmd.Flags().StringVar(&lsFilesUniqueTo, "unique-to", "", "")
+		cmd.Flags().BoolVar(&lsFilesShowDedup, "dedup", false, "")
+		cmd.Flags().StringArrayVar(&lsFilesWithExt, "with-ext", nil, "")
+		cmd.Flags().BoolVar(&lsFilesReachability, "reachability", false, "")
+		cmd.Flags().IntVar(&lsFilesMaxRefs, "max-refs", 0, "")
 		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
 		cmd.Flags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
 	})
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"io"
+	"os"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+func manifestDiffCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		Exit(tr.Tr.Get("Usage: git lfs manifest-diff <manifest-a> <manifest-b>"))
+	}
+	if args[0] == "-" && args[1] == "-" {
+		Exit(tr.Tr.Get("Only one manifest may be read from standard input"))
+	}
+
+	diff := lfs.DiffObjectManifests(manifestDiffRead(args[0]), manifestDiffRead(args[1]))
+	for _, e := range diff.Removed {
+		Print("-%s %d %s", e.Oid, e.Size, git.QuotePath(e.Name))
+	}
+	for _, e := range diff.Added {
+		Print("+%s %d %s", e.Oid, e.Size, git.QuotePath(e.Name))
+	}
+
+	if !diff.Empty() {
+		os.Exit(1)
+	}
+}
+
+// manifestDiffRead reads the object manifest "path", or standard input if it
+// is "-".
+func manifestDiffRead(path string) []*lfs.ObjectManifestEntry {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			Exit(tr.Tr.Get("Could not open manifest %q: %s", path, err))
+		}
+		defer f.Close()
+		r = f
+	}
+
+	entries, err := lfs.ReadObjectManifest(r)
+	if err != nil {
+		Exit(tr.Tr.Get("Could not read manifest %q: %s", path, err))
+	}
+	return entries
+}
+
+func init() {
+	RegisterCommand("manifest-diff", manifestDiffCommand, nil)
+}
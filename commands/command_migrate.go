@@ -42,6 +42,11 @@ var (
 	// and instructs 'git lfs migrate' to migrate all local references.
 	migrateEverything bool
 
+	// migrateTags indicates the presence of the --tags flag, and
+	// instructs 'git lfs migrate' to migrate only the commits reachable
+	// from local tags, leaving those reachable from any branch untouched.
+	migrateTags bool
+
 	// migrateVerbose enables verbose logging
 	migrateVerbose bool
 
@@ -63,6 +68,21 @@ var (
 	// migrateFixup is the flag indicating whether or not to infer the
 	// included and excluded filepath patterns.
 	migrateFixup bool
+
+	// migrateImportPerDirectory is the flag indicating whether 'git lfs
+	// migrate import' writes the pattern for each converted file to the
+	// .gitattributes file in that file's directory, rather than to the one
+	// at the root of the repository.
+	migrateImportPerDirectory bool
+
+	// migrateConcurrency is the number of blobs which 'git lfs migrate
+	// import' converts at once.
+	migrateConcurrency int
+
+	// migrateImportVerify is the flag indicating whether 'git lfs migrate
+	// import' checks, once it has rewritten history, that each converted
+	// file has the same contents as it had in the original history.
+	migrateImportVerify bool
 )
 
 // migrate takes the given command and arguments, *gitobj.ObjectDatabase, as well
@@ -119,9 +139,16 @@ func rewriteOptions(args []string, opts *githistory.RewriteOptions, l *tasklog.L
 		Verbose:           opts.Verbose,
 		ObjectMapFilePath: opts.ObjectMapFilePath,
 
+		ResumeFilePath: opts.ResumeFilePath,
+		ResumeKey:      opts.ResumeKey,
+		CheckpointFn:   opts.CheckpointFn,
+		RestoreFn:      opts.RestoreFn,
+
 		BlobFn:            opts.BlobFn,
 		TreePreCallbackFn: opts.TreePreCallbackFn,
 		TreeCallbackFn:    opts.TreeCallbackFn,
+
+		Concurrency: opts.Concurrency,
 	}, nil
 }
 
@@ -136,9 +163,24 @@ func rewriteOptions(args []string, opts *githistory.RewriteOptions, l *tasklog.L
 //     arguments and the --include-ref= or --exclude-ref= flag(s) aren't given.
 //   - Include all references given in --include-ref=<ref>.
 //   - Exclude all references given in --exclude-ref=<ref>.
+//   - With --tags, include all local tags and exclude all local and remote
+//     branches.
 func includeExcludeRefs(l *tasklog.Logger, args []string) (include, exclude []string, err error) {
 	hardcore := len(migrateIncludeRefs) > 0 || len(migrateExcludeRefs) > 0
 
+	if migrateTags {
+		if migrateEverything {
+			return nil, nil, errors.New(tr.Tr.Get("Cannot use --tags with --everything"))
+		}
+		if len(args) > 0 {
+			return nil, nil, errors.New(tr.Tr.Get("Cannot use --tags with explicit reference arguments"))
+		}
+		if hardcore {
+			return nil, nil, errors.New(tr.Tr.Get("Cannot use --tags with --include-ref or --exclude-ref"))
+		}
+		return tagOnlyRefs()
+	}
+
 	if len(args) == 0 && !hardcore && !migrateEverything {
 		// If no branches were given explicitly AND neither
 		// --include-ref or --exclude-ref flags were given, then add the
@@ -240,6 +282,30 @@ func includeExcludeRefs(l *tasklog.Logger, args []string) (include, exclude []st
 	return include, exclude, nil
 }
 
+// tagOnlyRefs returns every local tag to include, and every local and remote
+// branch to exclude, so that only the commits reachable from tags alone are
+// migrated.
+func tagOnlyRefs() (include, exclude []string, err error) {
+	refs, err := git.AllRefsIn("")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, ref := range refs {
+		switch ref.Type {
+		case git.RefTypeLocalTag:
+			include = append(include, ref.Refspec())
+		case git.RefTypeLocalBranch, git.RefTypeRemoteBranch:
+			exclude = append(exclude, ref.Refspec())
+		}
+	}
+
+	if len(include) == 0 {
+		return nil, nil, errors.New(tr.Tr.Get("No tags to migrate"))
+	}
+	return include, exclude, nil
+}
+
 // getRemoteRefs returns a fully qualified set of references belonging to all
 // remotes known by the currently checked-out repository, or an error if those
 // references could not be determined.
@@ -382,12 +448,20 @@ func init() {
 	importCmd.Flags().BoolVar(&migrateNoRewrite, "no-rewrite", false, "Add new history without rewriting previous")
 	importCmd.Flags().StringVarP(&migrateCommitMessage, "message", "m", "", "With --no-rewrite, an optional commit message")
 	importCmd.Flags().BoolVar(&migrateFixup, "fixup", false, "Infer filepaths based on .gitattributes")
+	importCmd.Flags().IntVar(&migrateConcurrency, "concurrency", 1, "--concurrency=<n>")
+	importCmd.Flags().BoolVar(&migrateImportPerDirectory, "per-directory-attributes", false, "Track each converted file in its own directory's .gitattributes")
+	importCmd.Flags().BoolVar(&migrateImportVerify, "verify", false, "Check that converted files have their original contents")
 
 	exportCmd := NewCommand("export", migrateExportCommand)
 	exportCmd.Flags().BoolVar(&migrateVerbose, "verbose", false, "Verbose logging")
 	exportCmd.Flags().StringVar(&objectMapFilePath, "object-map", "", "Object map file")
 	exportCmd.Flags().StringVar(&exportRemote, "remote", "", "Remote from which to download objects")
 
+	extensionsCmd := NewCommand("extensions", migrateExtensionsCommand)
+	extensionsCmd.Flags().StringArrayVar(&migrateExtensionMappings, "map", nil, "--map=<from>=<to>")
+	extensionsCmd.Flags().BoolVar(&migrateVerbose, "verbose", false, "Verbose logging")
+	extensionsCmd.Flags().StringVar(&objectMapFilePath, "object-map", "", "Object map file")
+
 	RegisterCommand("migrate", nil, func(cmd *cobra.Command) {
 		cmd.PersistentFlags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
 		cmd.PersistentFlags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
@@ -395,10 +469,11 @@ func init() {
 		cmd.PersistentFlags().StringSliceVar(&migrateIncludeRefs, "include-ref", nil, "An explicit list of refs to include")
 		cmd.PersistentFlags().StringSliceVar(&migrateExcludeRefs, "exclude-ref", nil, "An explicit list of refs to exclude")
 		cmd.PersistentFlags().BoolVar(&migrateEverything, "everything", false, "Migrate all local references")
+		cmd.PersistentFlags().BoolVar(&migrateTags, "tags", false, "Migrate only commits reachable from tags and not branches")
 		cmd.PersistentFlags().BoolVar(&migrateSkipFetch, "skip-fetch", false, "Assume up-to-date remote references.")
 
 		cmd.PersistentFlags().BoolVarP(&migrateYes, "yes", "y", false, "Don't prompt for answers.")
 
-		cmd.AddCommand(exportCmd, importCmd, info)
+		cmd.AddCommand(exportCmd, importCmd, info, extensionsCmd)
 	})
 }
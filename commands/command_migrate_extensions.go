@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git/githistory"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tasklog"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+	"github.com/spf13/cobra"
+)
+
+// migrateExtensionMappings is the list of "<from>=<to>" mappings given with
+// --map to 'git lfs migrate extensions'.
+var migrateExtensionMappings []string
+
+func migrateExtensionsCommand(cmd *cobra.Command, args []string) {
+	ensureWorkingCopyClean(os.Stdin, os.Stderr)
+
+	mapping, err := lfs.ParsePointerExtensionMap(migrateExtensionMappings)
+	if err != nil {
+		ExitWithError(err)
+	}
+	if mapping.Len() == 0 {
+		ExitWithError(errors.Errorf(tr.Tr.Get("One or more extension mappings must be specified with --map")))
+	}
+
+	l := tasklog.NewLogger(os.Stderr,
+		tasklog.ForceProgress(cfg.ForceProgress()),
+	)
+	defer l.Close()
+
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	rewriter := getHistoryRewriter(cmd, db, l)
+
+	migrate(args, rewriter, l, &githistory.RewriteOptions{
+		Verbose:           migrateVerbose,
+		ObjectMapFilePath: objectMapFilePath,
+		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+			if filepath.Base(path) == ".gitattributes" {
+				return b, nil
+			}
+
+			ptr, err := lfs.DecodePointerFromBlob(b)
+			if err != nil {
+				// Leave anything which is not a valid pointer
+				// as it is.
+				return b, nil
+			}
+
+			mapped, changed, err := mapping.Apply(ptr)
+			if err != nil {
+				return nil, errors.Wrap(err, tr.Tr.Get("Unable to rewrite pointer extensions of %q", path))
+			}
+			if !changed {
+				return b, nil
+			}
+
+			return gitobj.NewBlobFromBytes([]byte(mapped.Encoded())), nil
+		},
+
+		UpdateRefs: true,
+	})
+
+	if err := checkoutNonBare(l); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not checkout")))
+	}
+}
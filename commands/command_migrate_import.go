@@ -3,11 +3,15 @@ package commands
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
@@ -46,6 +50,12 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 		if migrateFixup {
 			ExitWithError(errors.Errorf(tr.Tr.Get("--no-rewrite and --fixup cannot be combined")))
 		}
+		if migrateImportPerDirectory {
+			ExitWithError(errors.Errorf(tr.Tr.Get("--no-rewrite and --per-directory-attributes cannot be combined")))
+		}
+		if migrateImportVerify {
+			ExitWithError(errors.Errorf(tr.Tr.Get("--no-rewrite and --verify cannot be combined")))
+		}
 
 		if len(args) == 0 {
 			ExitWithError(errors.Errorf(tr.Tr.Get("Expected one or more files with --no-rewrite")))
@@ -128,10 +138,37 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if migrateImportPerDirectory {
+		// Patterns given by --include and --exclude are relative to
+		// the root of the repository, so they belong in the root
+		// .gitattributes file.
+		include, exclude := getIncludeExcludeArgs(cmd)
+		if include != nil || exclude != nil || migrateFixup {
+			ExitWithError(errors.Errorf(tr.Tr.Get("Cannot use --per-directory-attributes with --include, --exclude, --fixup")))
+		}
+	}
+
+	if migrateConcurrency < 1 {
+		ExitWithError(errors.Errorf(tr.Tr.Get("Invalid --concurrency=%d: must be at least 1", migrateConcurrency)))
+	}
+
 	rewriter := getHistoryRewriter(cmd, db, l)
 
 	tracked := trackedFromFilter(rewriter.Filter())
 	exts := tools.NewOrderedSet()
+	// newExts holds the patterns for the files converted in the commit
+	// being rewritten, by path, until they are added to "exts" in the
+	// order in which the files appear in its tree, so that the order of
+	// the patterns does not depend on which conversion finished first.
+	var newExtsMu sync.Mutex
+	newExts := make(map[string]string)
+	// With --per-directory-attributes, dirExts holds the patterns for the
+	// files converted so far in each directory, by the path of its tree,
+	// and newDirExts holds those for the files converted in the commit
+	// being rewritten, by the path of their tree and then their own path,
+	// until they are added to "dirExts" when that tree is rewritten.
+	dirExts := make(map[string]*tools.OrderedSet)
+	newDirExts := make(map[string]map[string]string)
 	gitfilter := lfs.NewGitFilter(cfg)
 
 	var fixups *gitattr.Tree
@@ -149,12 +186,49 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 	migrate(args, rewriter, l, &githistory.RewriteOptions{
 		Verbose:           migrateVerbose,
 		ObjectMapFilePath: objectMapFilePath,
+		// An interrupted migration is resumed by running it
+		// again with the same options.
+		ResumeFilePath: filepath.Join(cfg.LFSStorageDir(), migrateImportResumeFile),
+		ResumeKey:      migrateImportResumeKey(rewriter.Filter(), above, migrateFixup, migrateImportPerDirectory),
+		CheckpointFn: func() []byte {
+			if migrateImportPerDirectory {
+				return checkpointDirExts(dirExts)
+			}
+
+			var patterns []string
+			for pattern := range exts.Iter() {
+				patterns = append(patterns, pattern)
+			}
+			return []byte(strings.Join(patterns, "\n"))
+		},
+		RestoreFn: func(state []byte) error {
+			if migrateImportPerDirectory {
+				return restoreDirExts(dirExts, state)
+			}
+
+			for _, pattern := range strings.Split(string(state), "\n") {
+				if len(pattern) > 0 {
+					exts.Add(pattern)
+				}
+			}
+			return nil
+		},
 		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
 			if filepath.Base(path) == ".gitattributes" {
 				return b, nil
 			}
-			if (above > 0) && (uint64(b.Size) < above) {
-				return b, nil
+			// A file is only converted if it is larger than
+			// --above, or if an earlier version of it was, so that
+			// it is still stored as .gitattributes says it is.
+			dir, pattern := migrateImportPattern(path, above, migrateImportPerDirectory)
+			if above > 0 && uint64(b.Size) <= above {
+				tracking := exts
+				if migrateImportPerDirectory {
+					tracking = dirExts[dir]
+				}
+				if tracking == nil || !tracking.Contains(pattern) {
+					return b, nil
+				}
 			}
 
 			if migrateFixup {
@@ -162,7 +236,7 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 				attrs := fixups.Applied(path)
 				for _, attr := range attrs {
 					if attr.K == "filter" {
-						ok = attr.V == "lfs"
+						ok = git.IsLFSFilter(attr.V)
 					}
 				}
 
@@ -177,11 +251,16 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 				return nil, err
 			}
 
-			if ext := filepath.Ext(path); len(ext) > 0 && above == 0 {
-				exts.Add(fmt.Sprintf("*%s filter=lfs diff=lfs merge=lfs -text", ext))
+			newExtsMu.Lock()
+			if migrateImportPerDirectory {
+				if newDirExts[dir] == nil {
+					newDirExts[dir] = make(map[string]string)
+				}
+				newDirExts[dir][path] = pattern
 			} else {
-				exts.Add(fmt.Sprintf("/%s filter=lfs diff=lfs merge=lfs -text", escapeGlobCharacters(path)))
+				newExts[path] = pattern
 			}
+			newExtsMu.Unlock()
 
 			return &gitobj.Blob{
 				Contents: &buf, Size: int64(buf.Len()),
@@ -201,6 +280,46 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 		},
 
 		TreeCallbackFn: func(path string, t *gitobj.Tree) (*gitobj.Tree, error) {
+			if migrateImportPerDirectory {
+				// Every file in this tree has been converted
+				// by now, as have those in its subtrees.
+				if files := newDirExts[path]; len(files) > 0 {
+					if dirExts[path] == nil {
+						dirExts[path] = tools.NewOrderedSet()
+					}
+					paths := make([]string, 0, len(files))
+					for p := range files {
+						paths = append(paths, p)
+					}
+					sort.Strings(paths)
+					for _, p := range paths {
+						dirExts[path].Add(files[p])
+					}
+					delete(newDirExts, path)
+				}
+
+				ours := dirExts[path]
+				if ours == nil || ours.Cardinality() == 0 {
+					return t, nil
+				}
+				return mergeTrackedAttrs(db, t, ours)
+			}
+
+			if path == "/" {
+				// Every file in this commit has been converted
+				// by now.  Sorting their paths visits them in
+				// the same order as a walk of the tree.
+				paths := make([]string, 0, len(newExts))
+				for p := range newExts {
+					paths = append(paths, p)
+				}
+				sort.Strings(paths)
+				for _, p := range paths {
+					exts.Add(newExts[p])
+					delete(newExts, p)
+				}
+			}
+
 			if path != "/" || migrateFixup {
 				// Avoid updating .gitattributes in non-root
 				// trees, or if --fixup is given.
@@ -228,40 +347,318 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 				}
 			}
 
-			theirs, err := trackedFromAttrs(db, t)
+			return mergeTrackedAttrs(db, t, ours)
+		},
+
+		UpdateRefs:  true,
+		Concurrency: migrateConcurrency,
+	})
+
+	if err := checkoutNonBare(l); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not checkout")))
+	}
+
+	if migrateImportVerify {
+		verifyMigrateImport(db, gitfilter, rewriter.RewrittenCommits(), l)
+	}
+}
+
+// verifyMigrateImport compares the files in each rewritten commit with those in
+// the original commit, and exits with an error if any converted file does not
+// have its original contents.  It closes "l" once it is done with it.
+func verifyMigrateImport(db *gitobj.ObjectDatabase, gf *lfs.GitFilter, commits map[string][]byte, l *tasklog.Logger) {
+	shas := make([]string, 0, len(commits))
+	for sha := range commits {
+		shas = append(shas, sha)
+	}
+	sort.Strings(shas)
+
+	v := &migrateImportVerifier{db: db, gf: gf, seen: make(map[string]bool)}
+	perc := l.Percentage(fmt.Sprintf("migrate: %s", tr.Tr.Get("Verifying commits")), uint64(len(shas)))
+
+	var problems []string
+	for _, sha := range shas {
+		from, _ := hex.DecodeString(sha)
+		p, err := v.verifyCommit(from, commits[sha])
+		if err != nil {
+			ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not verify commit %s", sha)))
+		}
+		problems = append(problems, p...)
+		perc.Count(1)
+	}
+	// Finish logging progress before reporting the result.
+	l.Close()
+
+	if len(problems) > 0 {
+		for _, p := range problems {
+			Error("migrate: %s", p)
+		}
+		Exit("migrate: %s", tr.Tr.GetN(
+			"%d converted file does not have its original contents",
+			"%d converted files do not have their original contents",
+			len(problems),
+			len(problems)))
+	}
+	Print("migrate: %s", tr.Tr.GetN(
+		"Verified %d converted file",
+		"Verified %d converted files",
+		v.files,
+		v.files))
+}
+
+// migrateImportVerifier compares the trees of original and rewritten commits,
+// reading the contents of each converted file from the Git LFS object its
+// pointer refers to.
+type migrateImportVerifier struct {
+	db *gitobj.ObjectDatabase
+	gf *lfs.GitFilter
+
+	// seen holds each pair of original and rewritten blobs which has
+	// been compared, so that each is only compared once.
+	seen map[string]bool
+	// files is the number of pairs of blobs compared.
+	files int
+}
+
+// verifyCommit returns a description of each file in the rewritten commit "to"
+// whose contents differ from those in the original commit "from".
+func (v *migrateImportVerifier) verifyCommit(from, to []byte) ([]string, error) {
+	if bytes.Equal(from, to) {
+		return nil, nil
+	}
+
+	original, err := v.db.Commit(from)
+	if err != nil {
+		return nil, err
+	}
+	rewritten, err := v.db.Commit(to)
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := v.verifyTree(original.TreeID, rewritten.TreeID, "")
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range problems {
+		problems[i] = tr.Tr.Get("commit %s: %s", hex.EncodeToString(from), p)
+	}
+	return problems, nil
+}
+
+// verifyTree returns a description of each file beneath the rewritten tree
+// "to" at "path" whose contents differ from those beneath the original tree
+// "from".  Subtrees which were not rewritten are not visited, and nor are
+// .gitattributes files, which are expected to change.
+func (v *migrateImportVerifier) verifyTree(from, to []byte, path string) ([]string, error) {
+	if bytes.Equal(from, to) {
+		return nil, nil
+	}
+
+	original, err := v.db.Tree(from)
+	if err != nil {
+		return nil, err
+	}
+	rewritten, err := v.db.Tree(to)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*gitobj.TreeEntry, len(rewritten.Entries))
+	for _, e := range rewritten.Entries {
+		entries[e.Name] = e
+	}
+
+	var problems []string
+	for _, e := range original.Entries {
+		fullpath := e.Name
+		if len(path) > 0 {
+			fullpath = strings.Join([]string{path, e.Name}, "/")
+		}
+
+		r := entries[e.Name]
+		switch {
+		case r == nil:
+			problems = append(problems, tr.Tr.Get("%s: missing from rewritten history", fullpath))
+		case bytes.Equal(e.Oid, r.Oid) || e.Name == ".gitattributes":
+		case e.Type() == gitobj.TreeObjectType && r.Type() == gitobj.TreeObjectType:
+			p, err := v.verifyTree(e.Oid, r.Oid, fullpath)
 			if err != nil {
 				return nil, err
 			}
-
-			// Create a blob of the attributes that are optionally
-			// present in the "t" tree's .gitattributes blob, and
-			// union in the patterns that we've tracked.
-			//
-			// Perform this Union() operation each time we visit a
-			// root tree such that if the underlying .gitattributes
-			// is present and has a diff between commits in the
-			// range of commits to migrate, those changes are
-			// preserved.
-			blob, err := trackedToBlob(db, theirs.Clone().Union(ours))
+			problems = append(problems, p...)
+		case e.Type() == gitobj.BlobObjectType && r.Type() == gitobj.BlobObjectType:
+			p, err := v.verifyBlob(e.Oid, r.Oid, fullpath)
 			if err != nil {
 				return nil, err
 			}
+			if len(p) > 0 {
+				problems = append(problems, p)
+			}
+		default:
+			problems = append(problems, tr.Tr.Get("%s: changed type in rewritten history", fullpath))
+		}
+	}
+	return problems, nil
+}
 
-			// Finally, return a copy of the tree "t" that has the
-			// new .gitattributes file included/replaced.
-			return t.Merge(&gitobj.TreeEntry{
-				Name:     ".gitattributes",
-				Filemode: 0100644,
-				Oid:      blob,
-			}), nil
-		},
+// verifyBlob returns a description of how the contents of the file at "path"
+// differ between the original blob "from" and the Git LFS object to which the
+// pointer in the rewritten blob "to" refers, or an empty string if they do
+// not, or if the pair of blobs has been compared before.
+func (v *migrateImportVerifier) verifyBlob(from, to []byte, path string) (string, error) {
+	key := hex.EncodeToString(from) + ":" + hex.EncodeToString(to)
+	if v.seen[key] {
+		return "", nil
+	}
+	v.seen[key] = true
+	v.files++
 
-		UpdateRefs: true,
-	})
+	original, err := v.db.Blob(from)
+	if err != nil {
+		return "", err
+	}
+	defer original.Close()
 
-	if err := checkoutNonBare(l); err != nil {
-		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not checkout")))
+	want := sha256.New()
+	if _, err := io.Copy(want, original.Contents); err != nil {
+		return "", err
+	}
+
+	rewritten, err := v.db.Blob(to)
+	if err != nil {
+		return "", err
+	}
+	defer rewritten.Close()
+
+	ptr, err := lfs.DecodePointer(rewritten.Contents)
+	if err != nil {
+		return tr.Tr.Get("%s: not converted to a Git LFS pointer", path), nil
 	}
+
+	got := sha256.New()
+	if _, err := v.gf.Smudge(got, ptr, path, false, nil, nil); err != nil {
+		return tr.Tr.Get("%s: cannot read Git LFS object %s: %s", path, ptr.Oid, err), nil
+	}
+
+	if !bytes.Equal(want.Sum(nil), got.Sum(nil)) {
+		return tr.Tr.Get("%s: contents differ from the original history", path), nil
+	}
+	return "", nil
+}
+
+// migrateImportPattern returns the path of the tree in whose .gitattributes
+// file the file at "path" is tracked once it is converted, and the pattern
+// which tracks it there.  Files are tracked by their extension, unless they have
+// none or --above was given, since then files of the same extension may not
+// all be converted; those are tracked by their own path.  With
+// --per-directory-attributes, the pattern is written to the .gitattributes file
+// in the same directory as the file, so it is relative to it.
+func migrateImportPattern(path string, above uint64, perDirectory bool) (string, string) {
+	dir, name := "/", path
+	if perDirectory {
+		dir, name = migrateAttrsDir(path)
+	}
+
+	if ext := filepath.Ext(path); len(ext) > 0 && above == 0 {
+		return dir, fmt.Sprintf("*%s filter=lfs diff=lfs merge=lfs -text", ext)
+	}
+	return dir, fmt.Sprintf("/%s filter=lfs diff=lfs merge=lfs -text", escapeGlobCharacters(name))
+}
+
+// migrateImportResumeFile is the name of the file in the LFS storage directory
+// in which "git lfs migrate import" records its progress, so that it can be
+// resumed if it is interrupted.
+const migrateImportResumeFile = "migrate-import-resume"
+
+// migrateImportResumeKey returns a key identifying the options which change
+// how "git lfs migrate import" rewrites each commit, so that a migration is
+// only resumed by one with the same options.
+func migrateImportResumeKey(filter *filepathfilter.Filter, above uint64, fixup, perDirectory bool) string {
+	return fmt.Sprintf("include=%q exclude=%q above=%d fixup=%t perdirectory=%t",
+		strings.Join(filter.Include(), ","), strings.Join(filter.Exclude(), ","), above, fixup, perDirectory)
+}
+
+// migrateAttrsDir returns the path, as given to a TreeCallbackFn, of the tree
+// holding the file at "path", and the name of the file within it.
+func migrateAttrsDir(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "/", path
+	}
+	return "/" + path[:i], path[i+1:]
+}
+
+// checkpointDirExts returns the patterns in "dirExts" in the form read by
+// restoreDirExts: a line holding the path of each tree, followed by a line for
+// each of its patterns, and then an empty line.
+func checkpointDirExts(dirExts map[string]*tools.OrderedSet) []byte {
+	dirs := make([]string, 0, len(dirExts))
+	for dir := range dirExts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var buf bytes.Buffer
+	for _, dir := range dirs {
+		fmt.Fprintf(&buf, "%s\n", dir)
+		for pattern := range dirExts[dir].Iter() {
+			fmt.Fprintf(&buf, "%s\n", pattern)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// restoreDirExts adds the patterns in "state", as written by checkpointDirExts,
+// to "dirExts".
+func restoreDirExts(dirExts map[string]*tools.OrderedSet, state []byte) error {
+	var dir string
+	for _, line := range strings.Split(string(state), "\n") {
+		switch {
+		case len(line) == 0:
+			dir = ""
+		case len(dir) == 0:
+			dir = line
+			if dirExts[dir] == nil {
+				dirExts[dir] = tools.NewOrderedSet()
+			}
+		default:
+			dirExts[dir].Add(line)
+		}
+	}
+	return nil
+}
+
+// mergeTrackedAttrs returns a copy of the tree "t" whose .gitattributes file
+// holds the patterns already in it, if any, followed by those in "ours" which
+// it does not have.
+func mergeTrackedAttrs(db *gitobj.ObjectDatabase, t *gitobj.Tree, ours *tools.OrderedSet) (*gitobj.Tree, error) {
+	theirs, err := trackedFromAttrs(db, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a blob of the attributes that are optionally present in the
+	// "t" tree's .gitattributes blob, and union in the patterns that we've
+	// tracked.
+	//
+	// Perform this Union() operation each time we visit a tree such that
+	// if the underlying .gitattributes is present and has a diff between
+	// commits in the range of commits to migrate, those changes are
+	// preserved.
+	blob, err := trackedToBlob(db, theirs.Clone().Union(ours))
+	if err != nil {
+		return nil, err
+	}
+
+	// Finally, return a copy of the tree "t" that has the new
+	// .gitattributes file included/replaced.
+	return t.Merge(&gitobj.TreeEntry{
+		Name:     ".gitattributes",
+		Filemode: 0100644,
+		Oid:      blob,
+	}), nil
 }
 
 // generateMigrateCommitMessage generates a commit message used with
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/git/gitattr"
 	"github.com/git-lfs/git-lfs/v3/git/githistory"
 	"github.com/git-lfs/git-lfs/v3/lfs"
@@ -131,7 +132,7 @@ func migrateInfoCommand(cmd *cobra.Command, args []string) {
 				attrs := fixups.Applied(path)
 				for _, attr := range attrs {
 					if attr.K == "filter" {
-						ok = attr.V == "lfs"
+						ok = git.IsLFSFilter(attr.V)
 					}
 				}
 				if !ok {
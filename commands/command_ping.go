@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/locking"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pingAdaptersArg bool
+	pingExplainArg  bool
+)
+
+func pingCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if len(args) > 1 {
+		Exit(tr.Tr.Get("Usage: git lfs ping [<remote>]"))
+	}
+
+	remote := cfg.Remote()
+	if len(args) > 0 {
+		if err := cfg.SetValidRemote(args[0]); err != nil {
+			Exit(tr.Tr.Get("Invalid remote name %q: %s", args[0], err))
+		}
+		remote = args[0]
+		cfg.SetPushRemote(remote)
+	}
+
+	endpoint := getAPIClient().Endpoints.Endpoint("download", remote)
+	if len(endpoint.Url) == 0 {
+		Exit(tr.Tr.Get("No Git LFS endpoint found for remote %q", remote))
+	}
+
+	Print("Remote=%s", remote)
+	Print("Endpoint=%s", endpoint.Url)
+
+	if pingAdaptersArg && pingExplainArg {
+		Exit(tr.Tr.Get("Cannot use --adapters with --explain"))
+	}
+
+	if pingExplainArg {
+		// A standalone transfer agent is reported as selected rather
+		// than skipped, since that is the explanation.
+		failed := 0
+		for _, dir := range []tq.Direction{tq.Download, tq.Upload} {
+			if !pingExplain(dir, remote) {
+				failed++
+			}
+		}
+		if failed == 2 {
+			Exit(tr.Tr.Get("Unable to reach the Git LFS server for remote %q", remote))
+		}
+		return
+	}
+
+	if getTransferManifestOperationRemote("download", remote).IsStandaloneTransfer() {
+		Print(tr.Tr.Get("Remote %q uses a standalone transfer agent, so there is no server to ping.", remote))
+		return
+	}
+
+	if pingAdaptersArg {
+		failed := 0
+		for _, dir := range []tq.Direction{tq.Download, tq.Upload} {
+			if !pingAdapters(dir, remote) {
+				failed++
+			}
+		}
+		if failed == 2 {
+			Exit(tr.Tr.Get("Unable to reach the Git LFS server for remote %q", remote))
+		}
+		return
+	}
+
+	ref := currentRemoteRef()
+	failed := 0
+	for _, dir := range []tq.Direction{tq.Download, tq.Upload} {
+		if !pingOperation(dir, remote) {
+			failed++
+		}
+	}
+
+	lockClient, err := locking.NewClient(remote, getAPIClient(), cfg)
+	if err == nil {
+		lockClient.RemoteRef = ref
+		var supported bool
+		if supported, err = lockClient.Probe(); err == nil {
+			if supported {
+				Print("Locking=%s", tr.Tr.Get("supported"))
+			} else {
+				Print("Locking=%s", tr.Tr.Get("not supported"))
+			}
+		}
+	}
+	if err != nil {
+		Print("Locking=%s", tr.Tr.Get("error: %s", err))
+	}
+
+	if failed == 2 {
+		Exit(tr.Tr.Get("Unable to reach the Git LFS server for remote %q", remote))
+	}
+}
+
+// pingOperation probes the server for "remote" for the operation in "dir" and
+// prints what it supports, returning whether the probe succeeded.
+func pingOperation(dir tq.Direction, remote string) bool {
+	operation := dir.String()
+	key := strings.ToUpper(operation[:1]) + operation[1:]
+	manifest := getTransferManifestOperationRemote(operation, remote)
+	ref := currentRemoteRef()
+
+	bRes, err := tq.Probe(manifest, dir, remote, ref, nil)
+	if err != nil {
+		Print("%s=%s", key, tr.Tr.Get("error: %s", err))
+		return false
+	}
+
+	Print("%s=%s", key, tr.Tr.Get("supported"))
+	Print("  Transfer=%s", bRes.TransferAdapterName)
+	Print("  HashAlgorithm=%s", bRes.HashAlgorithm)
+
+	adapters := []string{bRes.TransferAdapterName}
+	if bRes.TransferAdapterName != "ssh" {
+		if adapters, err = tq.ProbeAdapters(manifest, dir, remote, ref); err != nil {
+			Print("  Adapters=%s", tr.Tr.Get("error: %s", err))
+			return true
+		}
+	}
+	Print("  Adapters=%s", strings.Join(adapters, ","))
+	return true
+}
+
+// pingAdapters checks each transfer adapter configured for the operation in
+// "dir" against the server for "remote" and prints the outcome for each,
+// returning whether the server could be reached.
+func pingAdapters(dir tq.Direction, remote string) bool {
+	operation := dir.String()
+	key := strings.ToUpper(operation[:1]) + operation[1:]
+	manifest := getTransferManifestOperationRemote(operation, remote)
+
+	reached := false
+	for _, h := range tq.CheckAdapters(manifest, dir, remote, currentRemoteRef()) {
+		switch {
+		case h.StartErr != nil:
+			Print("%s.%s=%s", key, h.Name, tr.Tr.Get("error: %s", h.StartErr))
+		case h.ProbeErr != nil:
+			Print("%s.%s=%s", key, h.Name, tr.Tr.Get("error: %s", h.ProbeErr))
+		case h.Accepted:
+			reached = true
+			Print("%s.%s=%s", key, h.Name, tr.Tr.Get("ok"))
+		default:
+			reached = true
+			Print("%s.%s=%s", key, h.Name, tr.Tr.Get("not accepted by the server"))
+		}
+	}
+	return reached
+}
+
+// pingExplain negotiates a transfer adapter for the operation in "dir" with the
+// server for "remote" and prints which one was selected and why each of the
+// others was not, returning whether the server could be reached.
+func pingExplain(dir tq.Direction, remote string) bool {
+	operation := dir.String()
+	key := strings.ToUpper(operation[:1]) + operation[1:]
+	manifest := getTransferManifestOperationRemote(operation, remote)
+
+	sel, err := tq.ExplainAdapterSelection(manifest, dir, remote, currentRemoteRef())
+	if err != nil {
+		Print("%s=%s", key, tr.Tr.Get("error: %s", err))
+		return false
+	}
+
+	Print("%s.Selected=%s", key, sel.Selected)
+	if len(sel.Offered) > 0 {
+		Print("%s.Offered=%s", key, strings.Join(sel.Offered, ","))
+	}
+	for _, d := range sel.Decisions {
+		Print("%s.%s=%s", key, d.Name, pingSelectionReason(d, sel.Selected))
+	}
+	return true
+}
+
+// pingSelectionReason describes why the adapter in "d" was, or was not, used,
+// given that "selected" was.
+func pingSelectionReason(d *tq.AdapterDecision, selected string) string {
+	switch d.Reason {
+	case tq.SelectedByServer:
+		return tr.Tr.Get("selected: chosen by the server")
+	case tq.SelectedByDefault:
+		return tr.Tr.Get("selected: the server chose an adapter which is not configured")
+	case tq.SelectedStandalone:
+		return tr.Tr.Get("selected: set by lfs.standalonetransferagent, so the server is not asked")
+	case tq.NotOfferedBasicOnly:
+		return tr.Tr.Get("not offered: lfs.basictransfersonly is set")
+	case tq.NotOfferedDirection:
+		return tr.Tr.Get("not offered: only configured for %s", d.Direction)
+	case tq.NotSelectedPreferred:
+		return tr.Tr.Get("not selected: accepted by the server, which preferred %s", selected)
+	case tq.NotSelectedUnsupported:
+		return tr.Tr.Get("not selected: not accepted by the server")
+	case tq.NotConfigured:
+		return tr.Tr.Get("not used: chosen by the server, but not configured")
+	}
+	return ""
+}
+
+func init() {
+	RegisterCommand("ping", pingCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVar(&pingAdaptersArg, "adapters", false, "Check each configured transfer adapter")
+		cmd.Flags().BoolVar(&pingExplainArg, "explain", false, "Explain which transfer adapter is selected")
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
@@ -23,6 +24,7 @@ var (
 	pointerCheck    bool
 	pointerStrict   bool
 	pointerNoStrict bool
+	pointerWhere    string
 )
 
 func pointerCommand(cmd *cobra.Command, args []string) {
@@ -31,6 +33,14 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 	buildOid := ""
 	compareOid := ""
 
+	if len(pointerWhere) > 0 {
+		if pointerCheck || len(pointerFile) > 0 || len(pointerCompare) > 0 || pointerStdin {
+			ExitWithError(errors.New(tr.Tr.Get("Cannot combine --where with --check, --file, --pointer, or --stdin")))
+		}
+		pointerWhereCommand(pointerWhere)
+		return
+	}
+
 	if pointerCheck {
 		var r io.ReadCloser
 		var err error
@@ -152,6 +162,32 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// pointerWhereCommand prints the path at which the object with the given OID is
+// stored in the local object store, whether it is there, and if so, its size.
+func pointerWhereCommand(oid string) {
+	requireInRepo()
+
+	oid = strings.ToLower(strings.TrimPrefix(oid, "sha256:"))
+	if !lfs.ValidOid(oid) {
+		ExitWithError(errors.New(tr.Tr.Get("Invalid object ID %q: expected 64 hexadecimal characters", pointerWhere)))
+	}
+
+	path := cfg.Filesystem().ObjectPathname(oid)
+	Print("oid=%s", oid)
+	Print("path=%s", path)
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ExitWithError(err)
+		}
+		Print("exists=false")
+		return
+	}
+	Print("exists=true")
+	Print("size=%d", stat.Size())
+}
+
 func pointerReader() (io.ReadCloser, error) {
 	if len(pointerCompare) > 0 {
 		if pointerStdin {
@@ -174,5 +210,6 @@ func init() {
 		cmd.Flags().BoolVarP(&pointerCheck, "check", "", false, "Check whether the given file is a Git LFS pointer.")
 		cmd.Flags().BoolVarP(&pointerStrict, "strict", "", false, "Check whether the given Git LFS pointer is canonical.")
 		cmd.Flags().BoolVarP(&pointerNoStrict, "no-strict", "", false, "Don't check whether the given Git LFS pointer is canonical.")
+		cmd.Flags().StringVarP(&pointerWhere, "where", "", "", "Print where the object with the given OID is stored, and whether it exists.")
 	})
 }
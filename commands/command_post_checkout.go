@@ -2,9 +2,11 @@ package commands
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/locking"
+	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
@@ -19,15 +21,17 @@ import (
 //      In the case of a file being checked out, the pre/post SHA are the same
 //
 // This hook checks that files which are lockable and not locked are made read-only,
-// optimising that as best it can based on the available information.
+// optimising that as best it can based on the available information.  If
+// lfs.checkout.readonlylocked is set, it also makes files which are locked by
+// others read-only.
 func postCheckoutCommand(cmd *cobra.Command, args []string) {
 	if len(args) != 3 {
 		Print(tr.Tr.Get("This should be run through Git's post-checkout hook.  Run `git lfs update` to install it."))
 		os.Exit(1)
 	}
 
-	// Skip entire hook if lockable read only feature is disabled
-	if !cfg.SetLockableFilesReadOnly() {
+	// Skip entire hook if both read only features are disabled
+	if !cfg.SetLockableFilesReadOnly() && !cfg.CheckoutReadOnlyLocked() {
 		os.Exit(0)
 	}
 
@@ -35,8 +39,13 @@ func postCheckoutCommand(cmd *cobra.Command, args []string) {
 
 	lockClient := newLockClient()
 
-	// Skip this hook if no lockable patterns have been configured
-	if len(lockClient.GetLockablePatterns()) == 0 {
+	if cfg.CheckoutReadOnlyLocked() {
+		postCheckoutLockedByOthers(lockClient)
+	}
+
+	// Skip the rest of this hook if the lockable read only feature is
+	// disabled, or no lockable patterns have been configured
+	if !cfg.SetLockableFilesReadOnly() || len(lockClient.GetLockablePatterns()) == 0 {
 		os.Exit(0)
 	}
 
@@ -76,6 +85,28 @@ func postCheckoutFileChange(client *locking.Client) {
 	}
 }
 
+func postCheckoutLockedByOthers(client *locking.Client) {
+	client.RemoteRef = currentRemoteRef()
+	paths, err := client.CachedPathsLockedByOthers()
+	if err != nil {
+		LoggedError(err, tr.Tr.Get("Warning: post-checkout locked file check failed: %v", err))
+		return
+	}
+
+	// The paths locked by others are known, so rather than working out
+	// which files were checked out, check each of them.
+	tracerx.Printf("post-checkout: making %d file(s) locked by others read-only", len(paths))
+	for path := range paths {
+		abs := filepath.Join(cfg.LocalWorkingDir(), path)
+		if fi, err := os.Lstat(abs); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if err := tools.SetFileWriteFlag(abs, false); err != nil {
+			LoggedError(err, tr.Tr.Get("Warning: could not make %q read-only: %v", path, err))
+		}
+	}
+}
+
 func init() {
 	RegisterCommand("post-checkout", postCheckoutCommand, nil)
 }
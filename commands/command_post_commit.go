@@ -4,6 +4,8 @@ import (
 	"os"
 
 	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
@@ -11,12 +13,15 @@ import (
 
 // postCommitCommand is run through Git's post-commit hook. The hook passes
 // no arguments.
-// This hook checks that files which are lockable and not locked are made read-only,
+// This hook warns about large files added in the commit which are not tracked
+// by Git LFS, if lfs.largeuntrackedfilewarning is set.
+// It also checks that files which are lockable and not locked are made read-only,
 // optimising that based on what was added / modified in the commit.
 // This is mainly to catch added files, since modified files should already be
 // locked. If we didn't do this, any added files would remain read/write on disk
 // even without a lock unless something else checked.
 func postCommitCommand(cmd *cobra.Command, args []string) {
+	postCommitWarnLargeFiles()
 
 	// Skip entire hook if lockable read only feature is disabled
 	if !cfg.SetLockableFilesReadOnly() {
@@ -49,6 +54,39 @@ func postCommitCommand(cmd *cobra.Command, args []string) {
 
 }
 
+// postCommitWarnLargeFiles warns about each file changed at HEAD which is at
+// least the size given by lfs.largeuntrackedfilewarning but was committed
+// without being converted to a Git LFS pointer.
+func postCommitWarnLargeFiles() {
+	v, ok := cfg.Git.Get("lfs.largeuntrackedfilewarning")
+	if !ok {
+		return
+	}
+	threshold, err := humanize.ParseBytes(v)
+	if err != nil || threshold == 0 {
+		tracerx.Printf("post-commit: ignoring invalid lfs.largeuntrackedfilewarning value %q", v)
+		return
+	}
+
+	files, err := git.GetFilesChanged("HEAD", "")
+	if err != nil {
+		LoggedError(err, tr.Tr.Get("Warning: post-commit failed: %v", err))
+		return
+	}
+
+	tracerx.Printf("post-commit: checking for untracked files of at least %d bytes", threshold)
+	large, err := lfs.LargeNonPointerBlobs(cfg.Git, cfg.Os, "HEAD", files, int64(threshold))
+	if err != nil {
+		LoggedError(err, tr.Tr.Get("Warning: post-commit large file check failed: %v", err))
+		return
+	}
+
+	for _, t := range large {
+		Error(tr.Tr.Get("Warning: %s (%s) was committed without being tracked by Git LFS.", t.Filename, humanize.FormatBytes(uint64(t.Size))))
+		Error(tr.Tr.Get("To track it, run: git lfs track %q", t.Filename))
+	}
+}
+
 func init() {
 	RegisterCommand("post-commit", postCommitCommand, nil)
 }
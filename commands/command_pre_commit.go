@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/locking"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+	"github.com/spf13/cobra"
+)
+
+// preCommitCommand is meant to be run from Git's pre-commit hook, which passes
+// no arguments.  It is not installed by `git lfs install`.
+// It checks the changes staged for commit for lockable files which are not
+// locked by the current committer, and warns about them or refuses to commit
+// them, as lfs.unlockedchanges says.
+func preCommitCommand(cmd *cobra.Command, args []string) {
+	mode := unlockedChangesMode()
+	if mode == locking.UnlockedChangesAllow {
+		os.Exit(0)
+	}
+
+	requireGitVersion()
+
+	lockClient := newLockClient()
+
+	// Skip this hook if no lockable patterns have been configured
+	if len(lockClient.GetLockablePatterns()) == 0 {
+		os.Exit(0)
+	}
+
+	files, err := stagedFiles()
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not scan for staged changes")))
+	}
+
+	tracerx.Printf("pre-commit: checking locks on %v", files)
+	unlocked := lockClient.UnlockedLockableFiles(files)
+	if len(unlocked) == 0 {
+		return
+	}
+
+	for _, file := range unlocked {
+		if mode == locking.UnlockedChangesDeny {
+			Error(tr.Tr.Get("Error: %s is lockable but is not locked by you.", file))
+		} else {
+			Error(tr.Tr.Get("Warning: %s is lockable but is not locked by you.", file))
+		}
+		Error(tr.Tr.Get("To lock it, run: git lfs lock %q", file))
+	}
+
+	if mode == locking.UnlockedChangesDeny {
+		Exit(tr.Tr.Get("Commit aborted: lock these files before committing changes to them."))
+	}
+}
+
+// unlockedChangesMode returns the UnlockedChangesMode given by
+// lfs.unlockedchanges, or the default if it is unset or invalid.
+func unlockedChangesMode() locking.UnlockedChangesMode {
+	v, ok := cfg.Git.Get("lfs.unlockedchanges")
+	if !ok {
+		return locking.UnlockedChangesWarn
+	}
+
+	mode, ok := locking.ParseUnlockedChangesMode(v)
+	if !ok {
+		tracerx.Printf("ignoring invalid lfs.unlockedchanges value %q", v)
+	}
+	return mode
+}
+
+// stagedFiles returns the paths, relative to the root of the repository, of
+// the files with changes staged for commit.  A renamed or copied file is given
+// by both its old and new paths.
+func stagedFiles() ([]string, error) {
+	ref := "HEAD"
+	if r, _ := git.CurrentRef(); r == nil {
+		ref = git.EmptyTree()
+	}
+
+	scanner, err := lfs.NewDiffIndexScanner(ref, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]struct{})
+	for scanner.Scan() {
+		entry := scanner.Entry()
+		for _, name := range []string{entry.SrcName, entry.DstName} {
+			if _, ok := seen[name]; ok || len(name) == 0 {
+				continue
+			}
+			seen[name] = struct{}{}
+			files = append(files, name)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func init() {
+	RegisterCommand("pre-commit", preCommitCommand, nil)
+}
@@ -61,6 +61,15 @@ func prePushCommand(cmd *cobra.Command, args []string) {
 	if err := uploadForRefUpdates(ctx, updates, false); err != nil {
 		ExitWithError(err)
 	}
+
+	if ctx.reportOrphaned {
+		err := reportOrphaned(ctx, updates, func(update *git.RefUpdate) string {
+			return update.Right().Sha
+		})
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
 }
 
 // prePushRefs parses commit information that the pre-push git hook receives:
@@ -31,6 +31,7 @@ var (
 	pruneRecentArg      bool
 	pruneForceArg       bool
 	pruneDoNotVerifyArg bool
+	pruneCacheRecentArg bool
 )
 
 func pruneCommand(cmd *cobra.Command, args []string) {
@@ -44,6 +45,7 @@ func pruneCommand(cmd *cobra.Command, args []string) {
 		(fetchPruneConfig.PruneVerifyRemoteAlways || pruneVerifyArg)
 	fetchPruneConfig.PruneRecent = pruneRecentArg || pruneForceArg
 	fetchPruneConfig.PruneForce = pruneForceArg
+	fetchPruneConfig.PruneCacheRecent = fetchPruneConfig.PruneCacheRecent || pruneCacheRecentArg
 	prune(fetchPruneConfig, verify, pruneDryRunArg, pruneVerboseArg)
 }
 
@@ -72,6 +74,7 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	defer logger.Close()
 
 	var reachableObjects tools.StringSet
+	var reflogOnlyObjects tools.StringSet
 	var taskwait sync.WaitGroup
 
 	// Add all the base funcs to the waitgroup before starting them, in case
@@ -81,6 +84,12 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	if verifyRemote {
 		taskwait.Add(1) // 6
 	}
+	if fetchPruneConfig.IncludeNotes {
+		taskwait.Add(1) // notes
+	}
+	if verbose {
+		taskwait.Add(1) // reflog
+	}
 
 	progressChan := make(PruneProgressChan, 100)
 
@@ -106,10 +115,17 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 	go pruneTaskGetRetainedUnpushed(gitscanner, fetchPruneConfig, retainChan, errorChan, &taskwait, sem)
 	go pruneTaskGetRetainedWorktree(gitscanner, fetchPruneConfig, retainChan, errorChan, &taskwait, sem)
 	go pruneTaskGetRetainedStashed(gitscanner, retainChan, errorChan, &taskwait, sem)
+	if fetchPruneConfig.IncludeNotes {
+		go pruneTaskGetRetainedNotes(gitscanner, retainChan, errorChan, &taskwait, sem)
+	}
 	if verifyRemote {
 		reachableObjects = tools.NewStringSetWithCapacity(100)
 		go pruneTaskGetReachableObjects(gitscanner, &reachableObjects, errorChan, &taskwait, sem)
 	}
+	if verbose {
+		reflogOnlyObjects = tools.NewStringSet()
+		go pruneTaskGetReflogOnlyObjects(gitscanner, &reflogOnlyObjects, errorChan, &taskwait, sem)
+	}
 
 	// Now collect all the retained objects, on separate wait
 	var retainwait sync.WaitGroup
@@ -166,10 +182,14 @@ func prune(fetchPruneConfig lfs.FetchPruneConfig, verifyRemote, dryRun, verbose
 			totalSize += file.Size
 			if verbose {
 				// Save up verbose output for the end.
-				verboseOutput = append(verboseOutput,
-					fmt.Sprintf("%s (%s)",
-						file.Oid,
-						humanize.FormatBytes(uint64(file.Size))))
+				size := humanize.FormatBytes(uint64(file.Size))
+				if reflogOnlyObjects.Contains(file.Oid) {
+					verboseOutput = append(verboseOutput,
+						tr.Tr.Get("%s (%s, referenced only by the reflog)", file.Oid, size))
+				} else {
+					verboseOutput = append(verboseOutput,
+						fmt.Sprintf("%s (%s)", file.Oid, size))
+				}
 			}
 
 			if verifyRemote {
@@ -395,21 +415,81 @@ func pruneTaskGetPreviousVersionsOfRef(gitscanner *lfs.GitScanner, ref string, s
 func pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
 
-	// We actually increment the waitg in this func since we kick off sub-goroutines
 	// Make a list of what unique commits to keep, & search backward from
-	commits := tools.NewStringSet()
-	// Do current first
-	ref, err := git.CurrentRef()
+	current, commits, err := pruneCurrentAndRecentCommits(fetchconf)
 	if err != nil {
 		errorChan <- err
 		return
 	}
-	commits.Add(ref.Sha)
-	if !fetchconf.PruneForce {
-		waitg.Add(1)
-		go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, retainChan, errorChan, waitg, sem)
+
+	if !fetchconf.PruneCacheRecent || fetchconf.PruneForce {
+		// We actually increment the waitg in this func since we kick off sub-goroutines
+		pruneScanCurrentAndRecentCommits(gitscanner, fetchconf, current, commits, retainChan, errorChan, waitg, sem)
+		return
 	}
 
+	cache := newPruneRecentCache(cfg.LFSStorageDir(), fetchconf, cfg.FetchExcludePaths(), current, commits)
+	if oids, ok := cache.Load(); ok {
+		tracerx.Printf("PRUNE: using %d cached recent objects", len(oids))
+		for _, oid := range oids {
+			retainChan <- oid
+			tracerx.Printf("RETAIN: %v via cached recent refs", oid)
+		}
+		return
+	}
+
+	// Collect what the scans retain, so that it can be cached once they
+	// have all finished without error
+	var scanwait sync.WaitGroup
+	scanRetainChan := make(chan string, 100)
+	scanErrorChan := make(chan error, 10)
+	var forwardwait sync.WaitGroup
+	forwardwait.Add(2)
+	oids := tools.NewStringSet()
+	go func() {
+		for oid := range scanRetainChan {
+			oids.Add(oid)
+			retainChan <- oid
+		}
+		forwardwait.Done()
+	}()
+	failed := false
+	go func() {
+		for err := range scanErrorChan {
+			failed = true
+			errorChan <- err
+		}
+		forwardwait.Done()
+	}()
+
+	pruneScanCurrentAndRecentCommits(gitscanner, fetchconf, current, commits, scanRetainChan, scanErrorChan, &scanwait, sem)
+	scanwait.Wait()
+	close(scanRetainChan)
+	close(scanErrorChan)
+	forwardwait.Wait()
+
+	if failed {
+		return
+	}
+	tracerx.Printf("PRUNE: caching %d recent objects", oids.Cardinality())
+	if err := cache.Save(oids); err != nil {
+		tracerx.Printf("PRUNE: could not cache recent objects: %v", err)
+	}
+}
+
+// pruneCurrentAndRecentCommits returns the commit at the current ref, and
+// the unique commits at it and at each recent ref, from which prune retains
+// objects.
+func pruneCurrentAndRecentCommits(fetchconf lfs.FetchPruneConfig) (string, []string, error) {
+	// Do current first
+	ref, err := git.CurrentRef()
+	if err != nil {
+		return "", nil, err
+	}
+	commits := []string{ref.Sha}
+	seen := tools.NewStringSet()
+	seen.Add(ref.Sha)
+
 	// Now recent
 	if !fetchconf.PruneRecent && fetchconf.FetchRecentRefsDays > 0 {
 		pruneRefDays := fetchconf.FetchRecentRefsDays + fetchconf.PruneOffsetDays
@@ -421,19 +501,32 @@ func pruneTaskGetRetainedCurrentAndRecentRefs(gitscanner *lfs.GitScanner, fetchc
 			Panic(err, tr.Tr.Get("Could not scan for recent refs"))
 		}
 		for _, ref := range refs {
-			if commits.Add(ref.Sha) {
+			if seen.Add(ref.Sha) {
 				// A new commit
-				waitg.Add(1)
-				go pruneTaskGetRetainedAtRef(gitscanner, ref.Sha, retainChan, errorChan, waitg, sem)
+				commits = append(commits, ref.Sha)
 			}
 		}
 	}
+	return ref.Sha, commits, nil
+}
+
+// pruneScanCurrentAndRecentCommits starts the tasks which retain the objects
+// at the given commits, and those referenced by the commits shortly before
+// them, adding each of them to waitg.
+func pruneScanCurrentAndRecentCommits(gitscanner *lfs.GitScanner, fetchconf lfs.FetchPruneConfig, current string, commits []string, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+	for _, commit := range commits {
+		if commit == current && fetchconf.PruneForce {
+			continue
+		}
+		waitg.Add(1)
+		go pruneTaskGetRetainedAtRef(gitscanner, commit, retainChan, errorChan, waitg, sem)
+	}
 
 	// For every unique commit we've fetched, check recent commits too
 	// Only if we're fetching recent commits, otherwise only keep at refs
 	if !fetchconf.PruneRecent && fetchconf.FetchRecentCommitsDays > 0 {
 		pruneCommitDays := fetchconf.FetchRecentCommitsDays + fetchconf.PruneOffsetDays
-		for commit := range commits.Iter() {
+		for _, commit := range commits {
 			// We measure from the last commit at the ref
 			summ, err := git.GetCommitSummary(commit)
 			if err != nil {
@@ -474,13 +567,32 @@ func pruneTaskGetRetainedWorktree(gitscanner *lfs.GitScanner, fetchconf lfs.Fetc
 		return
 	}
 
-	// Retain other worktree HEADs too
+	// Retain objects in the indexes and HEADs of all worktrees, including
+	// the index of the current one.
 	// Working copy, branch & maybe commit is different but repo is shared
-	allWorktreeRefs, err := git.GetAllWorkTreeHEADs(cfg.LocalGitStorageDir())
+	var allWorktreeRefs []*git.Ref
+	worktrees, err := git.GetWorktrees()
 	if err != nil {
-		errorChan <- err
-		return
+		// Older versions of Git cannot list worktrees, so fall back
+		// to finding their HEADs in the repository.
+		tracerx.Printf("prune: unable to list worktrees, retaining only their HEADs: %v", err)
+		allWorktreeRefs, err = git.GetAllWorkTreeHEADs(cfg.LocalGitStorageDir())
+		if err != nil {
+			errorChan <- err
+			return
+		}
+		waitg.Add(1)
+		go pruneTaskGetRetainedIndex(gitscanner, "", retainChan, errorChan, waitg, sem)
+	}
+	for _, worktree := range worktrees {
+		if worktree.Bare || worktree.Prunable || worktree.Ref == nil {
+			continue
+		}
+		allWorktreeRefs = append(allWorktreeRefs, worktree.Ref)
+		waitg.Add(1)
+		go pruneTaskGetRetainedIndex(gitscanner, worktree.Dir, retainChan, errorChan, waitg, sem)
 	}
+
 	// Don't repeat any commits, worktrees are always on their own branches but
 	// may point to the same commit
 	commits := tools.NewStringSet()
@@ -501,6 +613,27 @@ func pruneTaskGetRetainedWorktree(gitscanner *lfs.GitScanner, fetchconf lfs.Fetc
 	}
 }
 
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetRetainedIndex(gitscanner *lfs.GitScanner, dir string, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+	sem.Acquire(context.Background(), 1)
+	defer sem.Release(1)
+	defer waitg.Done()
+
+	err := gitscanner.ScanWorktreeIndex(dir, "HEAD", func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			errorChan <- err
+			return
+		}
+
+		retainChan <- p.Oid
+		tracerx.Printf("RETAIN: %v via index of worktree %q", p.Oid, dir)
+	})
+
+	if err != nil {
+		errorChan <- err
+	}
+}
+
 // Background task, must call waitg.Done() once at end
 func pruneTaskGetRetainedStashed(gitscanner *lfs.GitScanner, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
@@ -520,6 +653,25 @@ func pruneTaskGetRetainedStashed(gitscanner *lfs.GitScanner, retainChan chan str
 	}
 }
 
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetRetainedNotes(gitscanner *lfs.GitScanner, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+	defer waitg.Done()
+
+	err := gitscanner.ScanNotes(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			errorChan <- err
+		} else {
+			retainChan <- p.Pointer.Oid
+			tracerx.Printf("RETAIN: %v in notes", p.Pointer.Oid)
+		}
+	})
+
+	if err != nil {
+		errorChan <- err
+		return
+	}
+}
+
 // Background task, must call waitg.Done() once at end
 func pruneTaskGetReachableObjects(gitscanner *lfs.GitScanner, outObjectSet *tools.StringSet, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
 	defer waitg.Done()
@@ -540,6 +692,26 @@ func pruneTaskGetReachableObjects(gitscanner *lfs.GitScanner, outObjectSet *tool
 	}
 }
 
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetReflogOnlyObjects(gitscanner *lfs.GitScanner, outObjectSet *tools.StringSet, errorChan chan error, waitg *sync.WaitGroup, sem *semaphore.Weighted) {
+	sem.Acquire(context.Background(), 1)
+	defer sem.Release(1)
+	defer waitg.Done()
+
+	err := gitscanner.ScanReflogOnly(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			errorChan <- err
+			return
+		}
+		outObjectSet.Add(p.Oid)
+		tracerx.Printf("REFLOG: %v", p.Oid)
+	})
+
+	if err != nil {
+		errorChan <- err
+	}
+}
+
 func init() {
 	RegisterCommand("prune", pruneCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&pruneDryRunArg, "dry-run", "d", false, "Don't delete anything, just report")
@@ -548,5 +720,6 @@ func init() {
 		cmd.Flags().BoolVarP(&pruneForceArg, "force", "f", false, "Prune everything that has been pushed")
 		cmd.Flags().BoolVarP(&pruneVerifyArg, "verify-remote", "c", false, "Verify that remote has LFS files before deleting")
 		cmd.Flags().BoolVar(&pruneDoNotVerifyArg, "no-verify-remote", false, "Override lfs.pruneverifyremotealways and don't verify")
+		cmd.Flags().BoolVar(&pruneCacheRecentArg, "cache-recent", false, "Cache the objects retained by current and recent refs")
 	})
 }
@@ -47,7 +47,12 @@ func pull(filter *filepathfilter.Filter) {
 	logger.Enqueue(meter)
 	remote := cfg.Remote()
 	singleCheckout := newSingleCheckout(cfg.Git, remote)
-	q := newDownloadQueue(singleCheckout.Manifest(), remote, tq.WithProgress(meter))
+
+	// The queue is only set up once the first object which is not already
+	// present is found, so that nothing is sent to the remote if every
+	// object is.
+	var q *tq.TransferQueue
+	var wg sync.WaitGroup
 	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			LoggedError(err, tr.Tr.Get("Scanner error: %s", err))
@@ -65,6 +70,21 @@ func pull(filter *filepathfilter.Filter) {
 			return
 		}
 
+		if q == nil {
+			q = newDownloadQueue(singleCheckout.Manifest(), remote, tq.WithProgress(meter))
+			dlwatch := q.Watch()
+			wg.Add(1)
+
+			go func() {
+				for t := range dlwatch {
+					for _, p := range pointers.All(t.Oid) {
+						singleCheckout.Run(p)
+					}
+				}
+				wg.Done()
+			}()
+		}
+
 		meter.Add(p.Size)
 		tracerx.Printf("fetch %v [%v]", p.Name, p.Oid)
 		pointers.Add(p)
@@ -73,37 +93,33 @@ func pull(filter *filepathfilter.Filter) {
 
 	gitscanner.Filter = filter
 
-	dlwatch := q.Watch()
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func() {
-		for t := range dlwatch {
-			for _, p := range pointers.All(t.Oid) {
-				singleCheckout.Run(p)
-			}
-		}
-		wg.Done()
-	}()
-
 	processQueue := time.Now()
 	if err := gitscanner.ScanTree(ref.Sha); err != nil {
 		singleCheckout.Close()
 		ExitWithError(err)
 	}
 
-	meter.Start()
 	gitscanner.Close()
-	q.Wait()
-	wg.Wait()
-	tracerx.PerformanceSince("process queue", processQueue)
-
-	singleCheckout.Close()
 
 	success := true
-	for _, err := range q.Errors() {
-		success = false
-		FullError(err)
+	if q == nil {
+		singleCheckout.Close()
+		writeTransferReport()
+		Print("pull: %s", tr.Tr.Get("Everything up to date"))
+	} else {
+		meter.Start()
+		q.Wait()
+		wg.Wait()
+		tracerx.PerformanceSince("process queue", processQueue)
+		recordTransferReport(q)
+
+		singleCheckout.Close()
+		writeTransferReport()
+
+		for _, err := range q.Errors() {
+			success = false
+			FullError(err)
+		}
 	}
 
 	if !success {
@@ -155,5 +171,7 @@ func init() {
 	RegisterCommand("pull", pullCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
 		cmd.Flags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
+		cmd.Flags().StringVar(&transferReportArg, "report", "", "Write a JSON report of the transferred objects to this file")
+		cmd.Flags().BoolVar(&checkoutUnattributedArg, "unattributed", false, "Checkout files even if .gitattributes does not track them")
 	})
 }
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
@@ -18,6 +19,14 @@ var (
 	pushAll       = false
 	useStdin      = false
 
+	// pushLocal is set by --local, with which --dry-run lists the objects
+	// a push would upload without contacting the remote.
+	pushLocal = false
+
+	// pushReportOrphaned is set by --report-orphaned, with which the objects
+	// left unreferenced by force-updating refs on the remote are listed.
+	pushReportOrphaned = false
+
 	// shares some global vars and functions with command_pre_push.go
 )
 
@@ -43,8 +52,26 @@ func pushCommand(cmd *cobra.Command, args []string) {
 		Exit(tr.Tr.Get("Invalid remote name %q: %s", args[0], err))
 	}
 
+	if pushLocal {
+		if !pushDryRun {
+			Exit(tr.Tr.Get("--local can only be used with --dry-run"))
+		}
+		if pushObjectIDs {
+			Exit(tr.Tr.Get("--local cannot be combined with --object-id"))
+		}
+	}
+
+	if pushReportOrphaned && pushObjectIDs {
+		Exit(tr.Tr.Get("--report-orphaned cannot be combined with --object-id"))
+	}
+
 	ctx := newUploadContext(pushDryRun)
-	if pushObjectIDs {
+	if pushReportOrphaned {
+		ctx.reportOrphaned = true
+	}
+	if pushLocal {
+		localUploadsBetweenRefAndRemote(ctx, args[1:])
+	} else if pushObjectIDs {
 		if len(args) < 2 {
 			Print(tr.Tr.Get("At least one object ID must be supplied with --object-id"))
 			return
@@ -68,6 +95,73 @@ func uploadsBetweenRefAndRemote(ctx *uploadContext, refnames []string) {
 	if err := uploadForRefUpdates(ctx, updates, pushAll); err != nil {
 		ExitWithError(err)
 	}
+	reportOrphanedFromTrackingRefs(ctx, updates)
+}
+
+// reportOrphanedFromTrackingRefs lists the objects orphaned by the given
+// updates, if requested, taking the value of each ref on the remote from its
+// remote-tracking ref.
+func reportOrphanedFromTrackingRefs(ctx *uploadContext, updates []*git.RefUpdate) {
+	if !ctx.reportOrphaned {
+		return
+	}
+
+	err := reportOrphaned(ctx, updates, func(update *git.RefUpdate) string {
+		return trackingRefSha(ctx.Remote, update.Right())
+	})
+	if err != nil {
+		ExitWithError(err)
+	}
+}
+
+// localUploadsBetweenRefAndRemote lists the objects which pushing the given
+// refs would upload, judging what the remote has only by its remote-tracking
+// refs, so that the remote is not contacted.  Each object reachable from a
+// ref, but not from any remote-tracking ref of the remote, is listed.
+func localUploadsBetweenRefAndRemote(ctx *uploadContext, refnames []string) {
+	tracerx.Printf("List uploads of refs %v to remote %v locally", refnames, ctx.Remote)
+
+	updates, err := lfsPushRefs(refnames, pushAll)
+	if err != nil {
+		Error(err.Error())
+		Exit(tr.Tr.Get("Error getting local refs."))
+	}
+
+	gitscanner := lfs.NewGitScanner(cfg, nil)
+	defer func() {
+		gitscanner.Close()
+		ctx.ReportErrors()
+	}()
+	if err := gitscanner.RemoteForLocalPush(ctx.Remote); err != nil {
+		ExitWithError(err)
+	}
+
+	cb := ctx.gitScannerCallback(nil)
+	for _, update := range updates {
+		left := update.LeftCommitish()
+		if pushAll {
+			err = gitscanner.ScanRefWithDeleted(left, cb)
+		} else {
+			err = gitscanner.ScanRangeToRemote(left, trackingRefSha(ctx.Remote, update.Right()), cb)
+		}
+		if err == nil {
+			err = ctx.scannerError()
+		}
+		if err != nil {
+			ExitWithError(errors.Wrap(err, tr.Tr.Get("ref %q:", update.Left().Name)))
+		}
+	}
+	reportOrphanedFromTrackingRefs(ctx, updates)
+}
+
+// trackingRefSha returns the object ID of the remote-tracking ref for the given
+// ref on the remote, or the empty string if there is none.
+func trackingRefSha(remote string, ref *git.Ref) string {
+	tracking, err := git.ResolveRef(fmt.Sprintf("refs/remotes/%s/%s", remote, ref.Name))
+	if err != nil || tracking == nil {
+		return ""
+	}
+	return tracking.Sha
 }
 
 func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
@@ -138,5 +232,8 @@ func init() {
 		cmd.Flags().BoolVarP(&pushDryRun, "dry-run", "d", false, "Do everything except actually send the updates")
 		cmd.Flags().BoolVarP(&pushObjectIDs, "object-id", "o", false, "Push LFS object ID(s)")
 		cmd.Flags().BoolVarP(&pushAll, "all", "a", false, "Push all objects for the current ref to the remote.")
+		cmd.Flags().BoolVarP(&pushLocal, "local", "", false, "With --dry-run, list the objects to push without contacting the remote")
+		cmd.Flags().BoolVarP(&pushReportOrphaned, "report-orphaned", "", false, "List the objects left unreferenced by force-updating refs on the remote")
+		cmd.Flags().StringVar(&transferReportArg, "report", "", "Write a JSON report of the transferred objects to this file")
 	})
 }
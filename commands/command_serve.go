@@ -0,0 +1,385 @@
+package commands
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/locking"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveJSONRPCArg bool
+)
+
+// serveProgressInterval is the least time between the progress notifications
+// sent for each object being fetched, other than the last.
+const serveProgressInterval = 100 * time.Millisecond
+
+func serveCommand(cmd *cobra.Command, args []string) {
+	if !serveJSONRPCArg {
+		Exit(tr.Tr.Get("git lfs serve requires --json-rpc"))
+	}
+	if len(args) > 0 {
+		Exit(tr.Tr.Get("Usage: git lfs serve --json-rpc"))
+	}
+
+	setupRepository()
+
+	lockClient := newLockClient()
+	lockClient.RemoteRef = currentRemoteRef()
+	defer lockClient.Close()
+
+	s := &serveMethods{lockClient: lockClient}
+	server := newRPCServer(os.Stdout, map[string]rpcHandler{
+		"scan":         s.scan,
+		"fetch":        s.fetch,
+		"locks.list":   s.listLocks,
+		"locks.lock":   s.lock,
+		"locks.unlock": s.unlock,
+	})
+	if err := server.Serve(os.Stdin); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// serveMethods implements the methods of git lfs serve --json-rpc.
+type serveMethods struct {
+	lockClient *locking.Client
+}
+
+type servePointer struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+	Name string `json:"name,omitempty"`
+}
+
+// scan reports the pointers in the tree of the "ref" parameter, HEAD by
+// default, in a "scan.pointer" notification each.
+func (s *serveMethods) scan(c *rpcCall) (interface{}, error) {
+	var params struct {
+		Ref string `json:"ref"`
+	}
+	if err := c.Decode(&params); err != nil {
+		return nil, err
+	}
+
+	var count, size int64
+	err := serveScanTree(c, params.Ref, func(p *lfs.WrappedPointer) {
+		count++
+		size += p.Size
+		c.Notify("scan.pointer", map[string]interface{}{
+			"name":    p.Name,
+			"oid":     p.Oid,
+			"size":    p.Size,
+			"present": cfg.LFSObjectExists(p.Oid, p.Size),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]int64{"pointers": count, "size": size}, nil
+}
+
+// serveScanTree calls "found" with each pointer in the tree of "ref", or of
+// HEAD if it is empty, until the request "c" is canceled.
+func serveScanTree(c *rpcCall, ref string, found func(*lfs.WrappedPointer)) error {
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+
+	var scanErr error
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if scanErr == nil {
+				scanErr = err
+			}
+			return
+		}
+		found(p)
+	})
+	defer gitscanner.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-c.Done():
+			gitscanner.Stop()
+		case <-done:
+		}
+	}()
+
+	if err := gitscanner.ScanTree(ref); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not scan %q", ref))
+	}
+	return scanErr
+}
+
+// fetch downloads the objects given by the "objects" parameter, or those in
+// the tree of the "ref" parameter, from the remote given by the "remote"
+// parameter, or the default remote.  Progress is reported in "fetch.progress"
+// notifications, and each object fetched in a "fetch.object" notification.
+func (s *serveMethods) fetch(c *rpcCall) (interface{}, error) {
+	var params struct {
+		Ref     string          `json:"ref"`
+		Objects []*servePointer `json:"objects"`
+		Remote  string          `json:"remote"`
+	}
+	if err := c.Decode(&params); err != nil {
+		return nil, err
+	}
+	if len(params.Ref) > 0 && len(params.Objects) > 0 {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("invalid params: only one of ref and objects may be given")}
+	}
+
+	objects := params.Objects
+	if len(objects) == 0 {
+		err := serveScanTree(c, params.Ref, func(p *lfs.WrappedPointer) {
+			objects = append(objects, &servePointer{Oid: p.Oid, Size: p.Size, Name: p.Name})
+		})
+		if err != nil || c.Canceled() {
+			return nil, err
+		}
+	}
+
+	remote := params.Remote
+	if len(remote) == 0 {
+		remote = cfg.Remote()
+	} else if err := git.ValidateRemote(remote); err != nil {
+		// As with cfg.SetValidRemote(), which would change the remote
+		// of the other requests, a local path is accepted too.
+		if err := git.ValidateRemote(git.RewriteLocalPathAsURL(remote)); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("Invalid remote name %q: %s", remote, err)}
+		}
+	}
+
+	// Each object is queued under its OID rather than its name, which
+	// need not be given, nor be unique, so that its progress can be told
+	// apart from that of the others.
+	names := make(map[string]string, len(objects))
+	var skipped int
+	var queued []*servePointer
+	for _, o := range objects {
+		if !lfs.ValidOid(o.Oid) {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("invalid params: invalid OID %q", o.Oid)}
+		}
+		if _, ok := names[o.Oid]; ok {
+			continue
+		}
+		names[o.Oid] = o.Name
+
+		if cfg.LFSObjectExists(o.Oid, o.Size) {
+			skipped++
+			continue
+		}
+		queued = append(queued, o)
+	}
+
+	progress := newServeProgress(c)
+	q := newDownloadQueue(getTransferManifestOperationRemote("download", remote), remote,
+		tq.WithTransferProgress(progress.Update),
+	)
+
+	var fetched int
+	watch := q.Watch()
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		for t := range watch {
+			fetched++
+			c.Notify("fetch.object", map[string]interface{}{
+				"oid":  t.Oid,
+				"name": names[t.Oid],
+				"size": t.Size,
+			})
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-c.Done():
+			q.Cancel()
+		case <-done:
+		}
+	}()
+
+	for _, o := range queued {
+		path, err := cfg.Filesystem().ObjectPath(o.Oid)
+		q.Add(o.Oid, path, o.Oid, o.Size, false, err)
+	}
+	q.Wait()
+	close(done)
+	<-watched
+
+	failed := make([]map[string]string, 0)
+	for _, r := range q.Report() {
+		if !r.Success && !r.Skipped {
+			failed = append(failed, map[string]string{
+				"oid":   r.Oid,
+				"name":  names[r.Oid],
+				"error": r.Error,
+			})
+		}
+	}
+	if len(failed) == 0 {
+		for _, err := range q.Errors() {
+			failed = append(failed, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return map[string]interface{}{
+		"fetched": fetched,
+		"skipped": skipped,
+		"failed":  failed,
+	}, nil
+}
+
+// serveProgress sends the "fetch.progress" notifications for a request, at
+// most one per object every serveProgressInterval, other than the last.
+type serveProgress struct {
+	c    *rpcCall
+	last map[string]time.Time
+	mu   sync.Mutex
+}
+
+func newServeProgress(c *rpcCall) *serveProgress {
+	return &serveProgress{c: c, last: make(map[string]time.Time)}
+}
+
+// Update is a tq.ProgressCallback, called with the OID of the object as its
+// name.
+func (p *serveProgress) Update(oid string, total, read int64, current int) error {
+	p.mu.Lock()
+	now := time.Now()
+	if read < total && now.Sub(p.last[oid]) < serveProgressInterval {
+		p.mu.Unlock()
+		return nil
+	}
+	p.last[oid] = now
+	p.mu.Unlock()
+
+	p.c.Notify("fetch.progress", map[string]interface{}{
+		"oid":   oid,
+		"bytes": read,
+		"size":  total,
+	})
+	return nil
+}
+
+// listLocks gives the locks on the server which match the "path" and "id"
+// parameters, if given, up to the number given by the "limit" parameter.
+func (s *serveMethods) listLocks(c *rpcCall) (interface{}, error) {
+	var params struct {
+		Path  string `json:"path"`
+		ID    string `json:"id"`
+		Limit int    `json:"limit"`
+	}
+	if err := c.Decode(&params); err != nil {
+		return nil, err
+	}
+
+	filters := make(map[string]string)
+	if len(params.Path) > 0 {
+		path, err := lockPath(params.Path)
+		if err != nil {
+			return nil, err
+		}
+		filters["path"] = path
+	}
+	if len(params.ID) > 0 {
+		filters["id"] = params.ID
+	}
+
+	scanner := s.lockClient.ScanLocks(filters, params.Limit)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-c.Done():
+			scanner.Close()
+		case <-done:
+		}
+	}()
+
+	locks := make([]locking.Lock, 0)
+	for scanner.Scan() {
+		locks = append(locks, scanner.Lock())
+	}
+	if err := scanner.Close(); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"locks": locks}, nil
+}
+
+// lock locks the file given by the "path" parameter, and gives the lock.
+func (s *serveMethods) lock(c *rpcCall) (interface{}, error) {
+	var params struct {
+		Path string `json:"path"`
+	}
+	if err := c.Decode(&params); err != nil {
+		return nil, err
+	}
+	if len(params.Path) == 0 {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("invalid params: a path is required")}
+	}
+
+	path, err := lockPath(params.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := s.lockClient.LockFile(path)
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("Locking %s failed: %v", path, errors.Cause(err)))
+	}
+	return lock, nil
+}
+
+// unlock removes the lock given by the "id" parameter, or that on the file
+// given by the "path" parameter, even if it is held by somebody else if the
+// "force" parameter is true.
+func (s *serveMethods) unlock(c *rpcCall) (interface{}, error) {
+	var params struct {
+		Path  string `json:"path"`
+		ID    string `json:"id"`
+		Force bool   `json:"force"`
+	}
+	if err := c.Decode(&params); err != nil {
+		return nil, err
+	}
+	if (len(params.Path) == 0) == (len(params.ID) == 0) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("invalid params: exactly one of path and id is required")}
+	}
+
+	if len(params.ID) > 0 {
+		if err := s.lockClient.UnlockFileById(params.ID, params.Force); err != nil {
+			return nil, errors.New(tr.Tr.Get("Unable to unlock %v: %v", params.ID, errors.Cause(err)))
+		}
+		return map[string]string{"id": params.ID}, nil
+	}
+
+	path, err := lockPath(params.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lockClient.UnlockFile(path, params.Force); err != nil {
+		return nil, errors.New(tr.Tr.Get("Unable to unlock %v: %v", path, errors.Cause(err)))
+	}
+	return map[string]string{"path": path}, nil
+}
+
+func init() {
+	RegisterCommand("serve", serveCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVar(&serveJSONRPCArg, "json-rpc", false, "Serve JSON-RPC requests on standard input")
+	})
+}
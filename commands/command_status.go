@@ -11,6 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/locking"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
@@ -19,6 +20,12 @@ import (
 var (
 	porcelain  = false
 	statusJson = false
+	// statusNullTerminate gives the --porcelain output with each entry
+	// terminated by a NUL byte, and implies --porcelain.
+	statusNullTerminate = false
+	// statusClassify lists the files in the working tree which are, or
+	// would be, tracked by Git LFS, with the class of each.
+	statusClassify = false
 )
 
 func statusCommand(cmd *cobra.Command, args []string) {
@@ -37,7 +44,19 @@ func statusCommand(cmd *cobra.Command, args []string) {
 		ExitWithError(err)
 	}
 
-	if porcelain {
+	if statusNullTerminate && statusJson {
+		Exit(tr.Tr.Get("Cannot use -z with --json"))
+	}
+
+	if statusClassify {
+		if porcelain || statusJson || statusNullTerminate {
+			Exit(tr.Tr.Get("Cannot use --classify with --porcelain, --json, or -z"))
+		}
+		classifiedStatus()
+		return
+	}
+
+	if porcelain || statusNullTerminate {
 		porcelainStagedPointers(scanIndexAt)
 		return
 	} else if statusJson {
@@ -57,6 +76,8 @@ func statusCommand(cmd *cobra.Command, args []string) {
 
 	wd = tools.ResolveSymlinks(wd)
 
+	unlocked := statusUnlockedFiles(append(staged, unstaged...))
+
 	Print("\n%s\n", tr.Tr.Get("Objects to be committed:"))
 	for _, entry := range staged {
 		// Find a path from the current working directory to the
@@ -66,9 +87,9 @@ func statusCommand(cmd *cobra.Command, args []string) {
 
 		switch entry.Status {
 		case lfs.StatusRename, lfs.StatusCopy:
-			Print("\t%s -> %s (%s)", src, dst, formatBlobInfo(scanner, entry))
+			Print("\t%s -> %s (%s)%s", src, dst, formatBlobInfo(scanner, entry), unlocked.marker(entry.DstName))
 		default:
-			Print("\t%s (%s)", src, formatBlobInfo(scanner, entry))
+			Print("\t%s (%s)%s", src, formatBlobInfo(scanner, entry), unlocked.marker(entry.SrcName))
 		}
 	}
 
@@ -76,7 +97,7 @@ func statusCommand(cmd *cobra.Command, args []string) {
 	for _, entry := range unstaged {
 		src := relativize(wd, filepath.Join(repo, entry.SrcName))
 
-		Print("\t%s (%s)", src, formatBlobInfo(scanner, entry))
+		Print("\t%s (%s)%s", src, formatBlobInfo(scanner, entry), unlocked.marker(entry.SrcName))
 	}
 
 	Print("")
@@ -86,6 +107,42 @@ func statusCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// unlockedFiles is the set of paths of lockable files with changes which are not
+// locked by the current committer.
+type unlockedFiles map[string]struct{}
+
+// marker returns the indicator shown after the entry for "name" if it is in
+// the set, or an empty string if not.
+func (u unlockedFiles) marker(name string) string {
+	if _, ok := u[name]; !ok {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", tr.Tr.Get("not locked"))
+}
+
+// statusUnlockedFiles returns the set of files changed by "entries" which are
+// lockable but are not locked by the current committer, unless
+// lfs.unlockedchanges is "allow".
+func statusUnlockedFiles(entries []*lfs.DiffIndexEntry) unlockedFiles {
+	unlocked := make(unlockedFiles)
+	if len(entries) == 0 || unlockedChangesMode() == locking.UnlockedChangesAllow {
+		return unlocked
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.SrcName)
+		if len(entry.DstName) > 0 {
+			names = append(names, entry.DstName)
+		}
+	}
+
+	for _, name := range newLockClient().UnlockedLockableFiles(names) {
+		unlocked[name] = struct{}{}
+	}
+	return unlocked
+}
+
 func formatBlobInfo(s *lfs.PointerScanner, entry *lfs.DiffIndexEntry) string {
 	fromSha, fromSrc, err := blobInfoFrom(s, entry)
 	if err != nil {
@@ -296,6 +353,33 @@ func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
 	Print(string(ret))
 }
 
+// classifiedStatus prints the class of each file in the working tree which is,
+// or would be, tracked by Git LFS, followed by its path relative to the current
+// directory.
+func classifiedStatus() {
+	files, err := classifyWorkingTree()
+	if err != nil {
+		ExitWithError(err)
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	wd, _ := os.Getwd()
+	wd = tools.ResolveSymlinks(wd)
+	repo := cfg.LocalWorkingDir()
+
+	classes := make([]string, 0, len(files))
+	for _, f := range files {
+		classes = append(classes, string(f.Class))
+	}
+	classes = tools.Ljust(classes)
+
+	for i, f := range files {
+		Print("%s %s", classes[i], relativize(wd, filepath.Join(repo, f.Name)))
+	}
+}
+
 func porcelainStagedPointers(ref string) {
 	staged, unstaged, err := scanIndex(ref)
 	if err != nil {
@@ -311,7 +395,11 @@ func porcelainStagedPointers(ref string) {
 		}
 
 		if _, seen := seenNames[name]; !seen {
-			Print(porcelainStatusLine(entry))
+			if statusNullTerminate {
+				PrintRecord(porcelainStatusRecord(entry), true)
+			} else {
+				Print(porcelainStatusLine(entry))
+			}
 
 			seenNames[name] = struct{}{}
 		}
@@ -329,6 +417,18 @@ func porcelainStatusLine(entry *lfs.DiffIndexEntry) string {
 	return fmt.Sprintf("%s  %s", entry.Status, entry.SrcName)
 }
 
+// porcelainStatusRecord returns the --porcelain output for the given entry as
+// given with -z, where a renamed or copied file is listed by its new path,
+// followed by a NUL byte and its old path, rather than with an arrow, as Git
+// does.
+func porcelainStatusRecord(entry *lfs.DiffIndexEntry) string {
+	switch entry.Status {
+	case lfs.StatusRename, lfs.StatusCopy:
+		return fmt.Sprintf("%s  %s\x00%s", entry.Status, entry.DstName, entry.SrcName)
+	}
+	return porcelainStatusLine(entry)
+}
+
 // relativize relatives a path from "from" to "to". For instance, note that, for
 // any paths "from" and "to", that:
 //
@@ -366,5 +466,7 @@ func init() {
 	RegisterCommand("status", statusCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&porcelain, "porcelain", "p", false, "Give the output in an easy-to-parse format for scripts.")
 		cmd.Flags().BoolVarP(&statusJson, "json", "j", false, "Give the output in a stable json format for scripts.")
+		cmd.Flags().BoolVarP(&statusNullTerminate, "null", "z", false, "Terminate each entry of the --porcelain output with a NUL byte.")
+		cmd.Flags().BoolVar(&statusClassify, "classify", false, "List the files tracked by Git LFS in the working tree by whether they are checked out.")
 	})
 }
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"strconv"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	storageReshardDepth = -1
+)
+
+// storageReshardCommand moves every object in the local store to the layout
+// given by --depth, and sets lfs.storage.sharddepth to it.  Each object is
+// stored in its new location before lfs.storage.sharddepth is changed, and only
+// removed from its old one afterwards, so objects can be found however far an
+// interrupted run got, and running the command again finishes the job.
+func storageReshardCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	if storageReshardDepth < 0 || storageReshardDepth > fs.MaxShardDepth {
+		Exit(tr.Tr.Get("Invalid shard depth: --depth must be between 0 and %d", fs.MaxShardDepth))
+	}
+
+	f := cfg.Filesystem()
+	key := fs.ShardedObjectKeyDepth(storageReshardDepth)
+
+	if _, err := f.StoreObjectsAt(key); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not reshard objects")))
+	}
+
+	if _, err := cfg.SetGitLocalKey("lfs.storage.sharddepth", strconv.Itoa(storageReshardDepth)); err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not set lfs.storage.sharddepth")))
+	}
+
+	n, err := f.RemoveObjectsNotAt(key)
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not remove resharded objects from their old locations")))
+	}
+
+	Print(tr.Tr.GetN(
+		"Moved %d object to shard depth %d",
+		"Moved %d objects to shard depth %d",
+		n,
+		n,
+		storageReshardDepth,
+	))
+}
+
+func init() {
+	reshardCmd := NewCommand("reshard", storageReshardCommand)
+	reshardCmd.Flags().IntVar(&storageReshardDepth, "depth", -1, "The number of levels of directories to store objects in.")
+
+	reportCmd := NewCommand("report", storageReportCommand)
+	reportCmd.Flags().BoolVarP(&storageReportJson, "json", "j", false, "Give the output in a stable json format for scripts.")
+
+	RegisterCommand("storage", nil, func(cmd *cobra.Command) {
+		cmd.AddCommand(reshardCmd, reportCmd)
+	})
+}
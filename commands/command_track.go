@@ -3,6 +3,7 @@ package commands
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,10 +11,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/git/gitattr"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
 	"github.com/spf13/cobra"
 )
 
@@ -29,10 +32,44 @@ var (
 	trackNoModifyAttrsFlag  bool
 	trackNoExcludedFlag     bool
 	trackFilenameFlag       bool
+	trackDiffFlag           bool
+	trackJSONFlag           bool
+
+	trackUpgradeAttributesFlag bool
+	trackWhyFlag               bool
 )
 
 func trackCommand(cmd *cobra.Command, args []string) {
 	requireGitVersion()
+
+	if trackDiffFlag {
+		if len(args) != 2 {
+			Exit(tr.Tr.Get("Usage: git lfs track --diff [--json] <ref-a> <ref-b>"))
+		}
+		setupRepository()
+		trackDiff(args[0], args[1])
+		return
+	}
+	if trackJSONFlag {
+		Exit(tr.Tr.Get("--json can only be used with --diff"))
+	}
+	if trackWhyFlag {
+		if len(args) == 0 {
+			Exit(tr.Tr.Get("Usage: git lfs track --why <path>..."))
+		}
+		setupWorkingCopy()
+		trackWhy(args)
+		return
+	}
+	if trackUpgradeAttributesFlag {
+		if len(args) > 0 {
+			Exit(tr.Tr.Get("Usage: git lfs track --upgrade-attributes [--dry-run]"))
+		}
+		setupWorkingCopy()
+		trackUpgradeAttributes()
+		return
+	}
+
 	setupWorkingCopy()
 
 	if !cfg.Os.Bool("GIT_LFS_TRACK_NO_INSTALL_HOOKS", false) {
@@ -221,6 +258,108 @@ ArgsLoop:
 	}
 }
 
+// trackUpgradeAttributes rewrites the lines of the repository's gitattributes
+// files which track patterns with legacy attributes, such as filter=media or
+// -crlf, to use the attributes "git lfs track" gives them.
+func trackUpgradeAttributes() {
+	mp := gitattr.NewMacroProcessor()
+	git.GetSystemAttributePaths(mp, cfg.Os)
+	git.GetRootAttributePaths(mp, cfg.Git)
+
+	var sources []string
+	seen := make(map[string]bool)
+	for _, p := range git.GetAttributePaths(mp, cfg.LocalWorkingDir(), cfg.LocalGitDir()) {
+		if p.Legacy && !seen[p.Source.Path] {
+			seen[p.Source.Path] = true
+			sources = append(sources, p.Source.Path)
+		}
+	}
+
+	if len(sources) == 0 {
+		Print(tr.Tr.Get("No legacy Git LFS attributes found"))
+		return
+	}
+
+	for _, source := range sources {
+		n, err := upgradeAttributesFile(filepath.Join(cfg.LocalWorkingDir(), source))
+		if err != nil {
+			ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not upgrade attributes in %q", source)))
+		}
+		if n > 0 && !trackDryRunFlag {
+			Print(tr.Tr.GetN("Upgraded %d line in %s", "Upgraded %d lines in %s", n, n, source))
+		}
+	}
+}
+
+// trackWhy reports whether each of the given paths, which are relative to the
+// current directory, is tracked by Git LFS, and which gitattributes lines
+// decide it.
+func trackWhy(paths []string) {
+	wd, _ := tools.Getwd()
+	wd = tools.ResolveSymlinks(wd)
+
+	for _, p := range paths {
+		name, err := filepath.Rel(cfg.LocalWorkingDir(), filepath.Join(wd, p))
+		if err != nil || strings.HasPrefix(name, "..") {
+			Exit(tr.Tr.Get("Path %q outside of Git working directory %q.", p, cfg.LocalWorkingDir()))
+		}
+		name = filepath.ToSlash(name)
+
+		matches := git.GetFilterAttributeMatches(cfg.Os, cfg.Git, cfg.LocalWorkingDir(), cfg.LocalGitDir(), name)
+		if len(matches) == 0 {
+			Print(tr.Tr.Get("%s is not tracked by Git LFS: no pattern gives it a filter", name))
+			continue
+		}
+
+		last := matches[len(matches)-1]
+		if last.Tracked() {
+			Print(tr.Tr.Get("%s is tracked by Git LFS: %q in %s sets %s", name, last.Pattern, last.Source, last))
+		} else {
+			Print(tr.Tr.Get("%s is not tracked by Git LFS: %q in %s sets %s", name, last.Pattern, last.Source, last))
+		}
+		for i := len(matches) - 2; i >= 0; i-- {
+			m := matches[i]
+			// TRANSLATORS: Leading spaces here should be preserved.
+			Print(tr.Tr.Get("    overriding %q in %s, which sets %s", m.Pattern, m.Source, m))
+		}
+	}
+}
+
+// upgradeAttributesFile upgrades each line of the gitattributes file at "path"
+// which has legacy Git LFS attributes, keeping its line endings, and returns
+// the number of lines upgraded.  With --dry-run, it only reports them.
+func upgradeAttributesFile(path string) (int, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var upgraded strings.Builder
+	n := 0
+	for _, line := range strings.SplitAfter(string(contents), "\n") {
+		body := strings.TrimRight(line, "\r\n")
+		eol := line[len(body):]
+
+		newBody, ok := git.UpgradeAttributeLine(body)
+		if ok {
+			n++
+			if trackVerboseLoggingFlag || trackDryRunFlag {
+				Print(tr.Tr.Get("Upgrading %q to %q", body, newBody))
+			}
+		}
+		upgraded.WriteString(newBody + eol)
+	}
+
+	if n == 0 || trackDryRunFlag {
+		return n, nil
+	}
+	return n, ioutil.WriteFile(path, []byte(upgraded.String()), stat.Mode())
+}
+
 func listPatterns() {
 	knownPatterns := getAllKnownPatterns()
 	if len(knownPatterns) < 1 {
@@ -264,6 +403,124 @@ func getAllKnownPatterns() []git.AttributePath {
 	return knownPatterns
 }
 
+// trackPattern is the state of a single pattern in the .gitattributes files
+// of a tree, as reported by `git lfs track --diff`.
+type trackPattern struct {
+	Pattern  string `json:"pattern"`
+	Source   string `json:"source"`
+	Tracked  bool   `json:"tracked"`
+	Lockable bool   `json:"lockable"`
+}
+
+func (p *trackPattern) String() string {
+	if p.Lockable {
+		return tr.Tr.Get("%s [lockable] (%s)", p.Pattern, p.Source)
+	} else if p.Tracked {
+		return fmt.Sprintf("%s (%s)", p.Pattern, p.Source)
+	}
+	return tr.Tr.Get("%s [excluded] (%s)", p.Pattern, p.Source)
+}
+
+type trackPatternChange struct {
+	Pattern string        `json:"pattern"`
+	Before  *trackPattern `json:"before"`
+	After   *trackPattern `json:"after"`
+}
+
+type trackDiffResult struct {
+	Added   []*trackPattern       `json:"added"`
+	Removed []*trackPattern       `json:"removed"`
+	Changed []*trackPatternChange `json:"changed"`
+}
+
+// trackPatternsAt returns the patterns which set the filter or lockable
+// attributes in the .gitattributes files of the tree at "ref", keyed by
+// pattern, along with the patterns in the order in which they were first seen.
+func trackPatternsAt(db *gitobj.ObjectDatabase, ref string) (map[string]*trackPattern, []string) {
+	paths, err := git.GetTreeAttributePaths(gitattr.NewMacroProcessor(), db, ref)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	patterns := make(map[string]*trackPattern)
+	var order []string
+	for _, p := range paths {
+		pattern := filepath.ToSlash(p.Path)
+		if _, ok := patterns[pattern]; !ok {
+			order = append(order, pattern)
+		}
+		// Later entries take precedence, as they do in Git.
+		patterns[pattern] = &trackPattern{
+			Pattern:  pattern,
+			Source:   filepath.ToSlash(p.Source.Path),
+			Tracked:  p.Tracked,
+			Lockable: p.Lockable,
+		}
+	}
+	return patterns, order
+}
+
+func trackDiff(refA, refB string) {
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	before, beforeOrder := trackPatternsAt(db, refA)
+	after, afterOrder := trackPatternsAt(db, refB)
+
+	res := &trackDiffResult{
+		Added:   make([]*trackPattern, 0),
+		Removed: make([]*trackPattern, 0),
+		Changed: make([]*trackPatternChange, 0),
+	}
+	for _, pattern := range beforeOrder {
+		b := before[pattern]
+		a, ok := after[pattern]
+		if !ok {
+			res.Removed = append(res.Removed, b)
+		} else if a.Tracked != b.Tracked || a.Lockable != b.Lockable {
+			res.Changed = append(res.Changed, &trackPatternChange{
+				Pattern: pattern,
+				Before:  b,
+				After:   a,
+			})
+		}
+	}
+	for _, pattern := range afterOrder {
+		if _, ok := before[pattern]; !ok {
+			res.Added = append(res.Added, after[pattern])
+		}
+	}
+
+	if trackJSONFlag {
+		if err := json.NewEncoder(os.Stdout).Encode(res); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	if len(res.Added) > 0 {
+		Print(tr.Tr.Get("Added patterns"))
+		for _, p := range res.Added {
+			Print("    %s", p)
+		}
+	}
+	if len(res.Removed) > 0 {
+		Print(tr.Tr.Get("Removed patterns"))
+		for _, p := range res.Removed {
+			Print("    %s", p)
+		}
+	}
+	if len(res.Changed) > 0 {
+		Print(tr.Tr.Get("Changed patterns"))
+		for _, c := range res.Changed {
+			Print("    %s -> %s", c.Before, c.After)
+		}
+	}
+}
+
 func getAttributeLineEnding(attribs []git.AttributePath) string {
 	for _, a := range attribs {
 		if a.Source.Path == ".gitattributes" {
@@ -337,5 +594,9 @@ func init() {
 		cmd.Flags().BoolVarP(&trackNoModifyAttrsFlag, "no-modify-attrs", "", false, "skip modifying .gitattributes file")
 		cmd.Flags().BoolVarP(&trackNoExcludedFlag, "no-excluded", "", false, "skip listing excluded paths")
 		cmd.Flags().BoolVarP(&trackFilenameFlag, "filename", "", false, "treat this pattern as a literal filename")
+		cmd.Flags().BoolVarP(&trackDiffFlag, "diff", "", false, "show how tracked patterns differ between two refs")
+		cmd.Flags().BoolVarP(&trackJSONFlag, "json", "", false, "print output of --diff in JSON")
+		cmd.Flags().BoolVarP(&trackWhyFlag, "why", "", false, "show which patterns decide whether paths are tracked")
+		cmd.Flags().BoolVarP(&trackUpgradeAttributesFlag, "upgrade-attributes", "", false, "rewrite legacy Git LFS attributes as those track gives")
 	})
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/lfs"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/locking"
+	"github.com/git-lfs/git-lfs/v3/ssh"
 	"github.com/git-lfs/git-lfs/v3/subprocess"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tq"
@@ -215,6 +216,17 @@ func Print(format string, args ...interface{}) {
 	fmt.Fprintf(OutputWriter, format+"\n", args...)
 }
 
+// PrintRecord prints a record of a command's output to Stdout, terminated by
+// a NUL byte rather than by a newline if nullTerminate is set, so that records
+// whose paths contain newlines can be told apart, as with Git's -z option.
+func PrintRecord(record string, nullTerminate bool) {
+	if nullTerminate {
+		fmt.Fprint(OutputWriter, record+"\x00")
+		return
+	}
+	fmt.Fprintln(OutputWriter, record)
+}
+
 // Exit prints a formatted message and exits.
 func Exit(format string, args ...interface{}) {
 	Error(format, args...)
@@ -279,6 +291,9 @@ func Cleanup() {
 	if err := cfg.Cleanup(); err != nil {
 		fmt.Fprintln(os.Stderr, tr.Tr.Get("Error clearing old temporary files: %s", err))
 	}
+	if err := ssh.Cleanup(); err != nil {
+		fmt.Fprintln(os.Stderr, tr.Tr.Get("Error removing SSH control socket directory: %s", err))
+	}
 }
 
 func PipeMediaCommand(name string, args ...string) error {
@@ -334,12 +349,28 @@ func setupRepository() {
 			err, tr.Tr.Get("Could not determine bareness")))
 	}
 	verifyRepositoryVersion()
+	verifyStorageDir()
+	setupPointerSize()
 
 	if !bare {
 		changeToWorkingCopy()
 	}
 }
 
+// setupFilterRepository is setupRepository for the commands which Git runs as
+// filters, which may be started from any directory of any work tree of the
+// repository, including a linked one.  Since the standard output of a filter is
+// the content of the file being filtered, one run outside of a repository says
+// so only on standard error.
+func setupFilterRepository(command string) {
+	if !cfg.InRepo() {
+		wd, _ := os.Getwd()
+		Error(tr.Tr.Get("git-lfs %s must be run within a Git repository, but %q is not in one", command, wd))
+		os.Exit(128)
+	}
+	setupRepository()
+}
+
 func verifyRepositoryVersion() {
 	key := "lfs.repositoryformatversion"
 	val := cfg.FindGitLocalKey(key)
@@ -355,9 +386,29 @@ func setupWorkingCopy() {
 	requireInRepo()
 	requireWorkingCopy()
 	verifyRepositoryVersion()
+	verifyStorageDir()
+	setupPointerSize()
 	changeToWorkingCopy()
 }
 
+// verifyStorageDir checks that the storage directory given by lfs.storage, if
+// it is set, exists or may be created, creating it along with its parent only
+// if --create-store was given.
+func verifyStorageDir() {
+	if _, ok := cfg.Git.Get("lfs.storage"); !ok {
+		return
+	}
+	if err := cfg.Filesystem().VerifyStorageDir(rootCreateStore); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// setupPointerSize sets the size beyond which data is not decoded as a pointer
+// from lfs.maxpointersize.
+func setupPointerSize() {
+	lfs.SetMaxPointerSize(cfg.Git.Int("lfs.maxpointersize", lfs.DefaultMaxPointerSize))
+}
+
 func changeToWorkingCopy() {
 	workingDir := cfg.LocalWorkingDir()
 	cwd, err := tools.Getwd()
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+var (
+	// fetchDeadlineArg is the time limit given with --deadline, if any,
+	// after which "git lfs fetch" stops fetching objects, and
+	// fetchDeadline is the time at which it is reached.
+	fetchDeadlineArg time.Duration
+	fetchDeadline    time.Time
+
+	fetchDeadlineMu sync.Mutex
+	// fetchDeadlineMissed holds the objects which were not fetched because
+	// the deadline passed first.
+	fetchDeadlineMissed []*tq.ObjectReport
+)
+
+// fetchExitDeadlineExceeded is the status with which "git lfs fetch --deadline"
+// exits if it fetched every object it could before the deadline, but not every
+// object it was asked to.
+const fetchExitDeadlineExceeded = 3
+
+// recordFetchDeadline collects the objects which the given queue, which must
+// have finished, did not fetch because the deadline passed.
+func recordFetchDeadline(q *tq.TransferQueue) {
+	if !q.DeadlineExceeded() {
+		return
+	}
+
+	fetchDeadlineMu.Lock()
+	defer fetchDeadlineMu.Unlock()
+
+	for _, o := range q.Report() {
+		if !o.Success && !o.Skipped {
+			fetchDeadlineMissed = append(fetchDeadlineMissed, o)
+		}
+	}
+}
+
+// reportFetchDeadline lists the objects which were not fetched because the
+// deadline passed, and returns whether there were any.
+func reportFetchDeadline() bool {
+	fetchDeadlineMu.Lock()
+	defer fetchDeadlineMu.Unlock()
+
+	if len(fetchDeadlineMissed) == 0 {
+		return false
+	}
+
+	sort.Slice(fetchDeadlineMissed, func(i, j int) bool {
+		return fetchDeadlineMissed[i].Name < fetchDeadlineMissed[j].Name
+	})
+
+	Error(tr.Tr.GetN(
+		"warning: deadline of %s passed before %d object was fetched:",
+		"warning: deadline of %s passed before %d objects were fetched:",
+		len(fetchDeadlineMissed),
+		fetchDeadlineArg,
+		len(fetchDeadlineMissed)))
+	for _, o := range fetchDeadlineMissed {
+		Error("  %s %s", o.Oid, o.Name)
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+)
+
+// streamScannedPointers runs "scan", passing each pointer it finds to "add" on
+// another goroutine as soon as it is found, so that the objects can be queued
+// for transfer while the scan carries on.  At most "bufferSize" pointers wait
+// between the two; once that many are waiting, the scan is held up until "add"
+// catches up, so that a fast scan does not build up an unbounded backlog.
+//
+// It returns once the scan has finished and every pointer has been passed to
+// "add", with the error returned by "scan" or otherwise any errors the scan
+// reported for individual pointers.
+func streamScannedPointers(bufferSize int, scan func(cb lfs.GitScannerFoundPointer) error, add func(p *lfs.WrappedPointer)) error {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	pointers := make(chan *lfs.WrappedPointer, bufferSize)
+	done := make(chan struct{})
+	go func() {
+		for p := range pointers {
+			add(p)
+		}
+		close(done)
+	}()
+
+	var multiErr error
+	err := scan(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if multiErr != nil {
+				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
+			} else {
+				multiErr = err
+			}
+			return
+		}
+
+		pointers <- p
+	})
+
+	close(pointers)
+	<-done
+
+	if err != nil {
+		return err
+	}
+	return multiErr
+}
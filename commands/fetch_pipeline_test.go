@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func scannedPointer(i int) *lfs.WrappedPointer {
+	return &lfs.WrappedPointer{Pointer: &lfs.Pointer{
+		Oid:  fmt.Sprintf("oid%02d", i),
+		Size: int64(i),
+	}}
+}
+
+func TestStreamScannedPointersAddsBeforeScanFinishes(t *testing.T) {
+	added := make(chan string, 10)
+	var addedBeforeScanFinished bool
+
+	err := streamScannedPointers(10, func(cb lfs.GitScannerFoundPointer) error {
+		cb(scannedPointer(0), nil)
+
+		// Keep scanning until the first pointer has been added, as a
+		// transfer queue would before the rest of history is read.
+		select {
+		case oid := <-added:
+			addedBeforeScanFinished = oid == "oid00"
+		case <-time.After(5 * time.Second):
+		}
+
+		cb(scannedPointer(1), nil)
+		return nil
+	}, func(p *lfs.WrappedPointer) {
+		added <- p.Oid
+	})
+
+	assert.Nil(t, err)
+	assert.True(t, addedBeforeScanFinished)
+	assert.Equal(t, "oid01", <-added)
+}
+
+func TestStreamScannedPointersBoundsBacklog(t *testing.T) {
+	const bufferSize = 2
+
+	var found int32
+	release := make(chan struct{})
+	var added []string
+
+	scanned := make(chan error)
+	go func() {
+		scanned <- streamScannedPointers(bufferSize, func(cb lfs.GitScannerFoundPointer) error {
+			for i := 0; i < 10; i++ {
+				cb(scannedPointer(i), nil)
+				atomic.AddInt32(&found, 1)
+			}
+			return nil
+		}, func(p *lfs.WrappedPointer) {
+			<-release
+			added = append(added, p.Oid)
+		})
+	}()
+
+	// While the first pointer is held up being added, only the buffered
+	// pointers may be found after it.
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1+bufferSize), atomic.LoadInt32(&found))
+
+	close(release)
+	assert.Nil(t, <-scanned)
+	assert.Equal(t, int32(10), atomic.LoadInt32(&found))
+	assert.Len(t, added, 10)
+	for i, oid := range added {
+		assert.Equal(t, fmt.Sprintf("oid%02d", i), oid)
+	}
+}
+
+func TestStreamScannedPointersReturnsErrors(t *testing.T) {
+	var added int
+	err := streamScannedPointers(1, func(cb lfs.GitScannerFoundPointer) error {
+		cb(scannedPointer(0), nil)
+		cb(nil, errors.New("first"))
+		cb(scannedPointer(1), nil)
+		cb(nil, errors.New("second"))
+		return nil
+	}, func(p *lfs.WrappedPointer) {
+		added++
+	})
+
+	assert.EqualError(t, err, "first\nsecond")
+	assert.Equal(t, 2, added)
+
+	err = streamScannedPointers(1, func(cb lfs.GitScannerFoundPointer) error {
+		cb(nil, errors.New("pointer"))
+		return errors.New("scan")
+	}, func(p *lfs.WrappedPointer) {})
+	assert.EqualError(t, err, "scan")
+}
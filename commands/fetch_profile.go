@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// fetchProfiler collects the time "git lfs fetch --profile" spends in each
+// phase of the fetch. It is nil unless --profile was given, in which case its
+// methods do nothing.
+var fetchProfiler *fetchProfile
+
+type fetchProfile struct {
+	mu     sync.Mutex
+	start  time.Time
+	scan   time.Duration
+	queues tq.Profile
+}
+
+type fetchProfilePhase struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+	// Count is the number of requests made in the phase, where that is
+	// meaningful.
+	Count int `json:"count,omitempty"`
+}
+
+type fetchProfileAdapter struct {
+	Name           string `json:"name"`
+	Objects        int    `json:"objects"`
+	Bytes          int64  `json:"bytes"`
+	DurationMs     int64  `json:"duration_ms"`
+	BytesPerSecond int64  `json:"bytes_per_second"`
+}
+
+type fetchProfileReport struct {
+	Phases   []*fetchProfilePhase   `json:"phases"`
+	Adapters []*fetchProfileAdapter `json:"adapters"`
+}
+
+func newFetchProfile() *fetchProfile {
+	return &fetchProfile{start: time.Now()}
+}
+
+// scanSince adds the time since "start" to the time spent scanning for
+// pointers.
+func (p *fetchProfile) scanSince(start time.Time) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scan += time.Since(start)
+}
+
+// record adds the batch requests and transfers of the given queue, which must
+// have finished, to the profile.
+func (p *fetchProfile) record(q *tq.TransferQueue) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queues.Add(q.Profile())
+}
+
+func (p *fetchProfile) report() *fetchProfileReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := &fetchProfileReport{
+		Phases: []*fetchProfilePhase{
+			{Name: "scan", DurationMs: milliseconds(p.scan)},
+			{Name: "batch", DurationMs: milliseconds(p.queues.BatchDuration), Count: p.queues.BatchRequests},
+			{Name: "transfer", DurationMs: milliseconds(p.queues.TransferDuration)},
+			{Name: "total", DurationMs: milliseconds(time.Since(p.start))},
+		},
+		Adapters: []*fetchProfileAdapter{},
+	}
+
+	names := make([]string, 0, len(p.queues.Adapters))
+	for name := range p.queues.Adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		a := p.queues.Adapters[name]
+		report.Adapters = append(report.Adapters, &fetchProfileAdapter{
+			Name:           name,
+			Objects:        a.Objects,
+			Bytes:          a.Bytes,
+			DurationMs:     milliseconds(a.Duration),
+			BytesPerSecond: int64(a.BytesPerSecond()),
+		})
+	}
+	return report
+}
+
+// writeFetchProfile writes the profile collected by fetchProfiler, if any, to
+// standard error, as JSON if "asJSON" is true.
+func writeFetchProfile(asJSON bool) {
+	if fetchProfiler == nil {
+		return
+	}
+
+	report := fetchProfiler.report()
+	if asJSON {
+		if err := json.NewEncoder(ErrorWriter).Encode(report); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	for _, phase := range report.Phases {
+		d := time.Duration(phase.DurationMs) * time.Millisecond
+		if phase.Name == "batch" {
+			Error("profile: %s", tr.Tr.GetN(
+				"%s: %v (%d request)",
+				"%s: %v (%d requests)",
+				phase.Count, phase.Name, d, phase.Count))
+			continue
+		}
+		Error("profile: %s: %v", phase.Name, d)
+	}
+	for _, a := range report.Adapters {
+		Error("profile: %s", tr.Tr.GetN(
+			"adapter %s: %d object, %s in %v (%s)",
+			"adapter %s: %d objects, %s in %v (%s)",
+			a.Objects, a.Name, a.Objects,
+			humanize.FormatBytes(uint64(a.Bytes)),
+			time.Duration(a.DurationMs)*time.Millisecond,
+			humanize.FormatByteRate(uint64(a.Bytes), time.Duration(a.DurationMs)*time.Millisecond)))
+	}
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
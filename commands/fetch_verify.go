@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"runtime"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// fetchVerifier collects the objects which a download queue fetches, so that
+// once the queue has finished they can be read back from the local store and
+// checked against their OIDs.  Downloads are already verified as they are
+// written, so this only finds objects which were corrupted on their way to
+// disk, or afterwards.
+type fetchVerifier struct {
+	pointers []*lfs.WrappedPointer
+	done     chan struct{}
+}
+
+// newFetchVerifier watches "q" for the objects it fetches, or returns nil if
+// --verify was not given.  It must be called before any transfers are added to
+// "q".
+func newFetchVerifier(q *tq.TransferQueue) *fetchVerifier {
+	if !fetchVerifyArg {
+		return nil
+	}
+
+	v := &fetchVerifier{done: make(chan struct{})}
+	watch := q.Watch()
+	go func() {
+		defer close(v.done)
+
+		seen := make(map[string]bool)
+		for t := range watch {
+			if seen[t.Oid] {
+				continue
+			}
+			seen[t.Oid] = true
+			v.pointers = append(v.pointers, &lfs.WrappedPointer{
+				Name:    t.Name,
+				Pointer: lfs.NewPointer(t.Oid, t.Size, nil),
+			})
+		}
+	}()
+	return v
+}
+
+// Verify hashes each object which the queue fetched, one worker per CPU, and
+// reports those whose contents in the local store do not match their OIDs,
+// moving them to the quarantine directory so that they are fetched again next
+// time.  It returns false if any object failed, and must be called after the
+// queue has finished.  A nil *fetchVerifier verifies nothing.
+func (v *fetchVerifier) Verify() bool {
+	if v == nil {
+		return true
+	}
+	<-v.done
+
+	pool := newFsckObjectPool(runtime.NumCPU(), func(p *lfs.WrappedPointer) fsckObjectResult {
+		return fsckPointer(p.Name, p.Oid, p.Size)
+	}, nil)
+	for _, p := range v.pointers {
+		pool.Add(p)
+	}
+
+	ok := true
+	for i, result := range pool.Wait() {
+		if result.Ok {
+			continue
+		}
+		ok = false
+
+		p := v.pointers[i]
+		if result.Err != nil {
+			Error(tr.Tr.Get("Could not verify %s (%s): %s", p.Name, p.Oid, result.Err))
+			continue
+		}
+
+		Error(tr.Tr.Get("%s (%s) does not match its OID in the local store after being fetched", p.Name, p.Oid))
+		path := cfg.Filesystem().ObjectPathname(p.Oid)
+		if dest, err := cfg.Filesystem().QuarantineObject(path, p.Oid); err == nil {
+			Error(tr.Tr.Get("Moved it to %s", dest))
+		}
+	}
+	return ok
+}
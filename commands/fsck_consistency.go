@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/git/gitattr"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+)
+
+// doFsckConsistency checks that each file in the tree of the given commit was
+// committed as the .gitattributes files in that same tree say it should have
+// been: as a pointer if Git LFS tracks its path, and as its contents if not.
+// Files of the first kind are usually committed while the clean filter is not
+// installed, and files of the second are usually pointers whose pattern has
+// since been removed.  Empty files, which are the same either way, are not
+// checked.
+func doFsckConsistency(commit string) []corruptPointer {
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	attrs, t := fsckCommitAttrs(db, commit)
+
+	var mismatched []corruptPointer
+	if err := fsckConsistencyTree(db, attrs, t, "", &mismatched); err != nil {
+		ExitWithError(err)
+	}
+
+	for _, cp := range mismatched {
+		Print("consistency: %s", cp.String())
+	}
+	return mismatched
+}
+
+// fsckConsistencyTree checks the files in the tree "t", which is at the path
+// "dir", and in its subtrees, in order of their paths, and appends those not
+// stored as "attrs" says they should be to "mismatched".
+func fsckConsistencyTree(db *gitobj.ObjectDatabase, attrs *gitattr.Tree, t *gitobj.Tree, dir string, mismatched *[]corruptPointer) error {
+	for _, entry := range t.Entries {
+		path := entry.Name
+		if len(dir) > 0 {
+			path = strings.Join([]string{dir, entry.Name}, "/")
+		}
+
+		switch entry.Type() {
+		case gitobj.TreeObjectType:
+			subtree, err := db.Tree(entry.Oid)
+			if err != nil {
+				return err
+			}
+			if err := fsckConsistencyTree(db, attrs, subtree, path, mismatched); err != nil {
+				return err
+			}
+		case gitobj.BlobObjectType:
+			if entry.IsLink() || entry.Name == ".gitattributes" {
+				continue
+			}
+
+			pointer, empty, err := fsckBlobIsPointer(db, entry.Oid)
+			if err != nil {
+				return err
+			}
+			if empty {
+				continue
+			}
+
+			blobOid := hex.EncodeToString(entry.Oid)
+			tracked := fsckIsTracked(attrs, path)
+			if tracked && !pointer {
+				*mismatched = append(*mismatched, corruptPointer{
+					blobOid: blobOid,
+					path:    path,
+					message: tr.Tr.Get("%q (blob %s) is tracked by Git LFS, but was committed as its contents rather than a pointer", path, blobOid),
+					kind:    "unconvertedFile",
+				})
+			} else if !tracked && pointer {
+				*mismatched = append(*mismatched, corruptPointer{
+					blobOid: blobOid,
+					path:    path,
+					message: tr.Tr.Get("%q (blob %s) is a pointer, but is not tracked by Git LFS", path, blobOid),
+					kind:    "unexpectedPointer",
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// fsckBlobIsPointer returns whether the blob "oid" is a Git LFS pointer, and
+// whether it is empty.
+func fsckBlobIsPointer(db *gitobj.ObjectDatabase, oid []byte) (bool, bool, error) {
+	b, err := db.Blob(oid)
+	if err != nil {
+		return false, false, err
+	}
+	defer b.Close()
+
+	if b.Size == 0 {
+		return false, true, nil
+	}
+
+	p, _, err := lfs.DecodeFrom(b.Contents)
+	return p != nil && err == nil, false, nil
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+)
+
+// fsckFileStatus is the outcome of one check which "git lfs fsck -- <path>"
+// makes of a file.
+type fsckFileStatus int
+
+const (
+	fsckFileOk fsckFileStatus = iota
+	fsckFileFailed
+	fsckFileSkipped
+)
+
+func (s fsckFileStatus) String() string {
+	switch s {
+	case fsckFileOk:
+		return tr.Tr.Get("ok")
+	case fsckFileFailed:
+		return tr.Tr.Get("failed")
+	default:
+		return tr.Tr.Get("skipped")
+	}
+}
+
+// fsckFileCheck is the outcome of one check of a file, with why it failed or
+// was skipped, if it was.
+type fsckFileCheck struct {
+	Aspect  string
+	Status  fsckFileStatus
+	Message string
+}
+
+// doFsckFile checks the file at "name", relative to the root of the
+// repository, in the tree of "commit", and prints the outcome of each check:
+// that it is a valid, canonical pointer, that .gitattributes tracks it, that
+// its object is present locally or on the remote, that the local copy of the
+// object has the right contents, and, if "commit" is checked out, that the file
+// in the working tree is that object.  It returns whether no check failed.
+func doFsckFile(commit, name string, checkedOut bool) bool {
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	attrs, tree := fsckCommitAttrs(db, commit)
+	blob := fsckBlobAt(db, tree, commit, name)
+
+	var checks []*fsckFileCheck
+	check := func(aspect string, status fsckFileStatus, message string) {
+		checks = append(checks, &fsckFileCheck{Aspect: aspect, Status: status, Message: message})
+	}
+
+	p, err := lfs.DecodePointerFromBlob(blob)
+	switch {
+	case err != nil:
+		check("pointer", fsckFileFailed, tr.Tr.Get("not a valid pointer: %v", err))
+	case !p.Canonical:
+		check("pointer", fsckFileFailed, tr.Tr.Get("pointer for %s is not canonical", p.Oid))
+	default:
+		check("pointer", fsckFileOk, "")
+	}
+
+	if fsckIsTracked(attrs, name) {
+		check("tracked", fsckFileOk, "")
+	} else {
+		check("tracked", fsckFileFailed, tr.Tr.Get("no pattern in .gitattributes tracks it"))
+	}
+
+	if p == nil {
+		noPointer := tr.Tr.Get("no valid pointer")
+		check("object", fsckFileSkipped, noPointer)
+		check("hash", fsckFileSkipped, noPointer)
+		check("worktree", fsckFileSkipped, noPointer)
+		return printFsckFileChecks(checks)
+	}
+
+	// The empty object is always present, at os.DevNull.
+	if _, err := os.Stat(cfg.Filesystem().ObjectPathname(p.Oid)); err == nil {
+		check("object", fsckFileOk, "")
+		if result := fsckPointer(name, p.Oid, p.Size); result.Err != nil {
+			check("hash", fsckFileFailed, result.Err.Error())
+		} else if !result.Ok {
+			check("hash", fsckFileFailed, tr.Tr.Get("object %s does not hash to its oid", p.Oid))
+		} else {
+			check("hash", fsckFileOk, "")
+		}
+	} else {
+		remote := cfg.Remote()
+		wp := &lfs.WrappedPointer{Name: name, Pointer: p}
+		if verified, err := fsckRemoteBatch(remote, []*lfs.WrappedPointer{wp}); err != nil {
+			check("object", fsckFileFailed, tr.Tr.Get("object %s is not present locally: %v", p.Oid, err))
+		} else if verified.Contains(p.Oid) {
+			check("object", fsckFileOk, tr.Tr.Get("object %s is not present locally, but can be fetched from remote %q", p.Oid, remote))
+		} else {
+			check("object", fsckFileFailed, tr.Tr.Get("object %s is present neither locally nor on remote %q", p.Oid, remote))
+		}
+		check("hash", fsckFileSkipped, tr.Tr.Get("object is not present locally"))
+	}
+
+	if !checkedOut {
+		check("worktree", fsckFileSkipped, tr.Tr.Get("revision is not checked out"))
+		return printFsckFileChecks(checks)
+	}
+
+	wp := &lfs.WrappedPointer{Name: name, Pointer: p}
+	class, ok, err := classifyPointerFile(filepath.Join(cfg.LocalWorkingDir(), name), wp)
+	switch {
+	case err != nil:
+		check("worktree", fsckFileFailed, err.Error())
+	case !ok:
+		check("worktree", fsckFileSkipped, tr.Tr.Get("file is not in the working tree"))
+	case class == statusClassSmudged:
+		check("worktree", fsckFileOk, "")
+	case class == statusClassModified:
+		check("worktree", fsckFileFailed, tr.Tr.Get("file does not match object %s", p.Oid))
+	default:
+		check("worktree", fsckFileSkipped, tr.Tr.Get("file is still a pointer"))
+	}
+	return printFsckFileChecks(checks)
+}
+
+// fsckBlobAt returns the blob at "name" in "tree", the tree of "commit",
+// exiting if there is no file there.
+func fsckBlobAt(db *gitobj.ObjectDatabase, tree *gitobj.Tree, commit, name string) *gitobj.Blob {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		var entry *gitobj.TreeEntry
+		for _, e := range tree.Entries {
+			if e.Name == part {
+				entry = e
+				break
+			}
+		}
+		if entry == nil {
+			Exit(tr.Tr.Get("%q is not in revision %s", name, commit))
+		}
+
+		if i < len(parts)-1 {
+			if entry.Type() != gitobj.TreeObjectType {
+				Exit(tr.Tr.Get("%q is not in revision %s", name, commit))
+			}
+			t, err := db.Tree(entry.Oid)
+			if err != nil {
+				ExitWithError(err)
+			}
+			tree = t
+			continue
+		}
+
+		if entry.Type() != gitobj.BlobObjectType || entry.IsLink() {
+			Exit(tr.Tr.Get("%q is not a file in revision %s", name, commit))
+		}
+		blob, err := db.Blob(entry.Oid)
+		if err != nil {
+			ExitWithError(err)
+		}
+		return blob
+	}
+	Exit(tr.Tr.Get("%q is not in revision %s", name, commit))
+	return nil
+}
+
+// printFsckFileChecks prints the outcome of each of "checks", and returns
+// whether none of them failed.
+func printFsckFileChecks(checks []*fsckFileCheck) bool {
+	ok := true
+	for _, c := range checks {
+		if len(c.Message) > 0 {
+			Print("%s: %s: %s", c.Aspect, c.Status, c.Message)
+		} else {
+			Print("%s: %s", c.Aspect, c.Status)
+		}
+		ok = ok && c.Status != fsckFileFailed
+	}
+	return ok
+}
+
+// fsckRevisionIsCheckedOut returns whether "commit" is the commit at HEAD.
+func fsckRevisionIsCheckedOut(commit string) bool {
+	ref, err := git.CurrentRef()
+	return err == nil && ref.Sha == commit
+}
@@ -0,0 +1,332 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/git/gitattr"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tasklog"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+)
+
+// fsckHealthProblem is a problem which "git lfs fsck --head" found with a
+// file which Git LFS tracks.
+type fsckHealthProblem struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Blob    string `json:"blob"`
+	Oid     string `json:"oid,omitempty"`
+	Message string `json:"message"`
+}
+
+// fsckHealthSummary counts the files which "git lfs fsck --head" checked,
+// and the problems it found with them.  A file may have more than one
+// problem, such as a non-canonical pointer to a missing object.
+type fsckHealthSummary struct {
+	// Tracked is the number of files which Git LFS tracks.
+	Tracked int `json:"tracked"`
+	// Ok is the number of those files with no problems.
+	Ok int `json:"ok"`
+	// Fetchable is the number of files whose objects are not present
+	// locally, but which the remote has.
+	Fetchable    int `json:"fetchable"`
+	Unconverted  int `json:"unconverted"`
+	Invalid      int `json:"invalid"`
+	NonCanonical int `json:"nonCanonical"`
+	Missing      int `json:"missing"`
+	Corrupt      int `json:"corrupt"`
+}
+
+// add records "p" and counts it towards the summary.
+func (s *fsckHealthSummary) add(problems *[]*fsckHealthProblem, p *fsckHealthProblem) {
+	switch p.Kind {
+	case "unconvertedFile":
+		s.Unconverted++
+	case "invalidPointer":
+		s.Invalid++
+	case "nonCanonicalPointer":
+		s.NonCanonical++
+	case "missingObject":
+		s.Missing++
+	case "corruptObject":
+		s.Corrupt++
+	}
+	*problems = append(*problems, p)
+}
+
+// doFsckHealth checks, in one pass over the tree of the given commit, that
+// each file which the .gitattributes files in that tree say is tracked by Git
+// LFS was committed as a valid, canonical pointer, and that its object is
+// either present and intact in the local store or, if not, that the default
+// remote has it, which is checked without downloading it.  Empty files, which
+// are the same whether or not they are tracked, need no object.  It prints
+// each problem it finds and a summary, or, with --json, both as a JSON object,
+// and returns whether there were no problems.
+func doFsckHealth(commit string) bool {
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	attrs, t := fsckCommitAttrs(db, commit)
+
+	summary := &fsckHealthSummary{}
+	problems := make([]*fsckHealthProblem, 0)
+
+	var pointers []*lfs.WrappedPointer
+	if err := fsckHealthTree(db, attrs, t, "", func(path, blobOid string, p *lfs.Pointer, problem *fsckHealthProblem) {
+		summary.Tracked++
+		if problem != nil {
+			summary.add(&problems, problem)
+		}
+		if p != nil {
+			pointers = append(pointers, &lfs.WrappedPointer{Name: path, Sha1: blobOid, Pointer: p})
+		}
+	}); err != nil {
+		ExitWithError(err)
+	}
+
+	var local, absent []*lfs.WrappedPointer
+	for _, p := range pointers {
+		if !p.Canonical {
+			summary.add(&problems, &fsckHealthProblem{
+				Kind:    "nonCanonicalPointer",
+				Path:    p.Name,
+				Blob:    p.Sha1,
+				Oid:     p.Oid,
+				Message: tr.Tr.Get("%q (blob %s) is a pointer for %s, but is not canonical", p.Name, p.Sha1, p.Oid),
+			})
+		}
+
+		// The empty object is always present, at os.DevNull.
+		if _, err := os.Stat(cfg.Filesystem().ObjectPathname(p.Oid)); err == nil {
+			local = append(local, p)
+		} else {
+			absent = append(absent, p)
+		}
+	}
+
+	for _, problem := range fsckHealthLocal(local) {
+		summary.add(&problems, problem)
+	}
+
+	remote := cfg.Remote()
+	verified, err := fsckHealthRemote(remote, absent)
+	if err != nil {
+		Error(err.Error())
+	}
+	for _, p := range absent {
+		if verified.Contains(p.Oid) {
+			summary.Fetchable++
+			continue
+		}
+
+		message := tr.Tr.Get("%q (%s) is present neither locally nor on remote %q", p.Name, p.Oid, remote)
+		if err != nil {
+			message = tr.Tr.Get("%q (%s) is not present locally, and remote %q could not be checked", p.Name, p.Oid, remote)
+		}
+		summary.add(&problems, &fsckHealthProblem{
+			Kind:    "missingObject",
+			Path:    p.Name,
+			Blob:    p.Sha1,
+			Oid:     p.Oid,
+			Message: message,
+		})
+	}
+
+	// Problems are reported in the order of the paths of their files, and
+	// then in the order in which they were found.
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Path < problems[j].Path
+	})
+
+	failed := tools.NewStringSet()
+	for _, problem := range problems {
+		failed.Add(problem.Path)
+	}
+	summary.Ok = summary.Tracked - failed.Cardinality()
+
+	if fsckJSON {
+		printFsckHealthJSON(commit, remote, summary, problems)
+	} else {
+		for _, problem := range problems {
+			Print("health: %s: %s", problem.Kind, problem.Message)
+		}
+		Print("health: summary: %s", tr.Tr.Get("tracked: %d, ok: %d, fetchable: %d, unconverted: %d, invalid: %d, non-canonical: %d, missing: %d, corrupt: %d",
+			summary.Tracked, summary.Ok, summary.Fetchable, summary.Unconverted, summary.Invalid, summary.NonCanonical, summary.Missing, summary.Corrupt))
+	}
+	return len(problems) == 0
+}
+
+// fsckHealthTree calls "visit" for each file in the tree "t", which is at the
+// path "dir", and in its subtrees, in order of their paths, which "attrs" says
+// is tracked by Git LFS.  It is given the pointer the file was committed as,
+// if it is a valid one other than an empty file, or the problem with it, if
+// it is not a pointer at all.
+func fsckHealthTree(db *gitobj.ObjectDatabase, attrs *gitattr.Tree, t *gitobj.Tree, dir string, visit func(path, blobOid string, p *lfs.Pointer, problem *fsckHealthProblem)) error {
+	for _, entry := range t.Entries {
+		path := entry.Name
+		if len(dir) > 0 {
+			path = strings.Join([]string{dir, entry.Name}, "/")
+		}
+
+		switch entry.Type() {
+		case gitobj.TreeObjectType:
+			subtree, err := db.Tree(entry.Oid)
+			if err != nil {
+				return err
+			}
+			if err := fsckHealthTree(db, attrs, subtree, path, visit); err != nil {
+				return err
+			}
+		case gitobj.BlobObjectType:
+			if entry.IsLink() || entry.Name == ".gitattributes" || !fsckIsTracked(attrs, path) {
+				continue
+			}
+
+			blobOid := hex.EncodeToString(entry.Oid)
+			p, problem, err := fsckHealthBlob(db, entry.Oid, path, blobOid)
+			if err != nil {
+				return err
+			}
+			visit(path, blobOid, p, problem)
+		}
+	}
+	return nil
+}
+
+// fsckHealthBlob returns the pointer which the blob "oid", at "path", holds,
+// or nil if it is empty, or the problem with it if it is not a valid pointer.
+func fsckHealthBlob(db *gitobj.ObjectDatabase, oid []byte, path, blobOid string) (*lfs.Pointer, *fsckHealthProblem, error) {
+	b, err := db.Blob(oid)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer b.Close()
+
+	if b.Size == 0 {
+		return nil, nil, nil
+	}
+
+	// Anything larger than the largest pointer is the file's contents.
+	if b.Size <= int64(lfs.MaxPointerSize()) {
+		data, err := ioutil.ReadAll(b.Contents)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		p, err := lfs.DecodePointer(bytes.NewReader(data))
+		if err == nil {
+			return p, nil, nil
+		}
+		if lfs.LooksLikePointer(data) {
+			return nil, &fsckHealthProblem{
+				Kind:    "invalidPointer",
+				Path:    path,
+				Blob:    blobOid,
+				Message: tr.Tr.Get("%q (blob %s) is not a valid pointer: %v", path, blobOid, err),
+			}, nil
+		}
+	}
+
+	return nil, &fsckHealthProblem{
+		Kind:    "unconvertedFile",
+		Path:    path,
+		Blob:    blobOid,
+		Message: tr.Tr.Get("%q (blob %s) is tracked by Git LFS, but was committed as its contents rather than a pointer", path, blobOid),
+	}, nil
+}
+
+// fsckHealthLocal hashes the objects of the given pointers, which are present
+// in the local store, in parallel, and returns a problem for each which does
+// not hash to its OID.
+func fsckHealthLocal(pointers []*lfs.WrappedPointer) []*fsckHealthProblem {
+	// Report progress on stderr, since the problems found are printed to
+	// stdout.
+	task := tasklog.NewSimpleTask()
+	logger := tasklog.NewLogger(os.Stderr,
+		tasklog.ForceProgress(cfg.ForceProgress()),
+	)
+	logger.Enqueue(task)
+
+	pool := newFsckObjectPool(runtime.NumCPU(), func(p *lfs.WrappedPointer) fsckObjectResult {
+		return fsckPointer(p.Name, p.Oid, p.Size)
+	}, func(n int) {
+		task.Logf("fsck: %s", tr.Tr.GetN("%d object checked", "%d objects checked", n, n))
+	})
+	for _, p := range pointers {
+		pool.Add(p)
+	}
+	results := pool.Wait()
+	task.Complete()
+
+	var problems []*fsckHealthProblem
+	for i, result := range results {
+		if result.Ok {
+			continue
+		}
+		p := pointers[i]
+		message := tr.Tr.Get("%q (%s) is corrupt", p.Name, p.Oid)
+		if result.Err != nil {
+			message = tr.Tr.Get("%q (%s) could not be checked: %v", p.Name, p.Oid, result.Err)
+		}
+		problems = append(problems, &fsckHealthProblem{
+			Kind:    "corruptObject",
+			Path:    p.Name,
+			Blob:    p.Sha1,
+			Oid:     p.Oid,
+			Message: message,
+		})
+	}
+	return problems
+}
+
+// fsckHealthRemote returns the OIDs of those of the given pointers whose
+// objects "remote" has, checking each object only once, or an error if they
+// could not be checked at all.  The remote is only contacted if there are
+// objects to check.
+func fsckHealthRemote(remote string, pointers []*lfs.WrappedPointer) (tools.StringSet, error) {
+	var unique []*lfs.WrappedPointer
+	seen := tools.NewStringSet()
+	for _, p := range pointers {
+		if seen.Add(p.Oid) {
+			unique = append(unique, p)
+		}
+	}
+	if len(unique) == 0 {
+		return tools.NewStringSet(), nil
+	}
+
+	m := getTransferManifestOperationRemote("download", remote)
+	verified, err := fsckRemoteConcurrently(m.ConcurrentBatches(), unique, func(batch []*lfs.WrappedPointer) (tools.StringSet, error) {
+		return fsckRemoteBatch(remote, batch)
+	})
+	if err != nil {
+		return tools.NewStringSet(), err
+	}
+	return verified, nil
+}
+
+func printFsckHealthJSON(commit, remote string, summary *fsckHealthSummary, problems []*fsckHealthProblem) {
+	ret, err := json.Marshal(struct {
+		Revision string               `json:"revision"`
+		Remote   string               `json:"remote"`
+		Ok       bool                 `json:"ok"`
+		Summary  *fsckHealthSummary   `json:"summary"`
+		Problems []*fsckHealthProblem `json:"problems"`
+	}{commit, remote, len(problems) == 0, summary, problems})
+	if err != nil {
+		ExitWithError(err)
+	}
+	Print(string(ret))
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+)
+
+// fsckObjectResult is the outcome of checking a single object in the local
+// store against the OID of its pointer.
+type fsckObjectResult struct {
+	Oid string
+	// Ok is false if the object is missing or corrupt.
+	Ok bool
+	// Message describes the problem with the object, if any, and is
+	// printed once every object has been checked.
+	Message string
+	Err     error
+
+	index int
+}
+
+// fsckObjectPool hashes objects on a bounded number of workers, so that
+// checking a large store is not limited to a single CPU.  Pointers are fed to
+// the workers in the order in which they are added, and results are returned
+// in that same order regardless of which worker finishes first.
+type fsckObjectPool struct {
+	jobs    chan *fsckObjectJob
+	wg      sync.WaitGroup
+	check   func(p *lfs.WrappedPointer) fsckObjectResult
+	checked func(n int)
+
+	mu      sync.Mutex
+	next    int
+	results []fsckObjectResult
+}
+
+type fsckObjectJob struct {
+	index   int
+	pointer *lfs.WrappedPointer
+}
+
+// newFsckObjectPool starts "workers" goroutines which call "check" for each
+// added pointer.  If "checked" is non-nil, it is called with the running total
+// of checked objects after each one is checked.
+func newFsckObjectPool(workers int, check func(p *lfs.WrappedPointer) fsckObjectResult, checked func(n int)) *fsckObjectPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &fsckObjectPool{
+		jobs:    make(chan *fsckObjectJob, workers),
+		check:   check,
+		checked: checked,
+	}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *fsckObjectPool) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		result := p.check(job.pointer)
+		result.index = job.index
+
+		p.mu.Lock()
+		p.results = append(p.results, result)
+		n := len(p.results)
+		p.mu.Unlock()
+
+		if p.checked != nil {
+			p.checked(n)
+		}
+	}
+}
+
+// Add queues the given pointer to be checked, blocking if every worker is
+// busy.  It must not be called concurrently with itself or with Wait.
+func (p *fsckObjectPool) Add(pointer *lfs.WrappedPointer) {
+	p.jobs <- &fsckObjectJob{index: p.next, pointer: pointer}
+	p.next++
+}
+
+// Wait waits for every added pointer to be checked and returns the results in
+// the order in which the pointers were added.
+func (p *fsckObjectPool) Wait() []fsckObjectResult {
+	close(p.jobs)
+	p.wg.Wait()
+
+	sort.Slice(p.results, func(i, j int) bool {
+		return p.results[i].index < p.results[j].index
+	})
+	return p.results
+}
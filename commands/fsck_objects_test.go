@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFsckObjectPoolChecksEveryObjectInOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	var progress []int
+
+	pool := newFsckObjectPool(4, func(p *lfs.WrappedPointer) fsckObjectResult {
+		// Finish the earliest objects last, so that results arrive
+		// out of order.
+		time.Sleep(time.Duration(20-p.Size) * time.Millisecond)
+
+		mu.Lock()
+		seen[p.Oid]++
+		mu.Unlock()
+
+		return fsckObjectResult{Oid: p.Oid, Ok: p.Size%3 != 0}
+	}, func(n int) {
+		mu.Lock()
+		progress = append(progress, n)
+		mu.Unlock()
+	})
+
+	for i := 0; i < 20; i++ {
+		pool.Add(&lfs.WrappedPointer{Pointer: &lfs.Pointer{
+			Oid:  fmt.Sprintf("oid%02d", i),
+			Size: int64(i),
+		}})
+	}
+	results := pool.Wait()
+
+	assert.Len(t, results, 20)
+	assert.Len(t, seen, 20)
+	for i, result := range results {
+		oid := fmt.Sprintf("oid%02d", i)
+		assert.Equal(t, oid, result.Oid)
+		assert.Equal(t, i%3 != 0, result.Ok)
+		assert.Equal(t, 1, seen[oid])
+	}
+	assert.Len(t, progress, 20)
+	assert.Contains(t, progress, 20)
+}
+
+func TestFsckObjectPoolRespectsWorkerLimit(t *testing.T) {
+	const workers = 3
+
+	var active, maxActive int32
+	pool := newFsckObjectPool(workers, func(p *lfs.WrappedPointer) fsckObjectResult {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+
+		return fsckObjectResult{Oid: p.Oid, Ok: true}
+	}, nil)
+
+	for i := 0; i < 30; i++ {
+		pool.Add(&lfs.WrappedPointer{Pointer: &lfs.Pointer{Oid: fmt.Sprintf("oid%02d", i)}})
+	}
+	results := pool.Wait()
+
+	assert.Len(t, results, 30)
+	assert.True(t, atomic.LoadInt32(&maxActive) <= workers, "expected at most %d concurrent checks, got %d", workers, maxActive)
+	assert.True(t, atomic.LoadInt32(&maxActive) > 1, "expected checks to run concurrently")
+}
+
+func TestFsckObjectPoolWithoutObjects(t *testing.T) {
+	pool := newFsckObjectPool(2, func(p *lfs.WrappedPointer) fsckObjectResult {
+		t.Fatal("unexpected check")
+		return fsckObjectResult{}
+	}, nil)
+
+	assert.Empty(t, pool.Wait())
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fsckRemoteTestPointers(n int) []*lfs.WrappedPointer {
+	pointers := make([]*lfs.WrappedPointer, 0, n)
+	for i := 0; i < n; i++ {
+		pointers = append(pointers, &lfs.WrappedPointer{Pointer: &lfs.Pointer{
+			Oid:  fmt.Sprintf("oid%04d", i),
+			Size: int64(i),
+		}})
+	}
+	return pointers
+}
+
+func TestFsckRemoteConcurrentlyAggregatesBatches(t *testing.T) {
+	const workers = 3
+	pointers := fsckRemoteTestPointers(fsckRemoteBatchSize*7 + 5)
+
+	var mu sync.Mutex
+	var sizes []int
+	var active, maxActive int32
+
+	verified, err := fsckRemoteConcurrently(workers, pointers, func(batch []*lfs.WrappedPointer) (tools.StringSet, error) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+
+		mu.Lock()
+		sizes = append(sizes, len(batch))
+		mu.Unlock()
+
+		// The remote lacks every object whose size is a multiple of
+		// three.
+		found := tools.NewStringSet()
+		for _, p := range batch {
+			if p.Size%3 != 0 {
+				found.Add(p.Oid)
+			}
+		}
+		return found, nil
+	})
+	require.Nil(t, err)
+
+	assert.EqualValues(t, workers, maxActive)
+	assert.Len(t, sizes, 8)
+	assert.Contains(t, sizes, 5)
+	for _, p := range pointers {
+		assert.Equal(t, p.Size%3 != 0, verified.Contains(p.Oid), p.Oid)
+	}
+}
+
+func TestFsckRemoteConcurrentlyReturnsFirstError(t *testing.T) {
+	pointers := fsckRemoteTestPointers(fsckRemoteBatchSize * 4)
+
+	_, err := fsckRemoteConcurrently(4, pointers, func(batch []*lfs.WrappedPointer) (tools.StringSet, error) {
+		switch batch[0].Oid {
+		case pointers[fsckRemoteBatchSize].Oid:
+			// Fail later than the batch after, so that the first
+			// error in order is not the first to happen.
+			time.Sleep(20 * time.Millisecond)
+			return nil, errors.New("batch 1 failed")
+		case pointers[fsckRemoteBatchSize*2].Oid:
+			return nil, errors.New("batch 2 failed")
+		}
+		return tools.NewStringSet(), nil
+	})
+	require.NotNil(t, err)
+	assert.Equal(t, "batch 1 failed", err.Error())
+}
+
+func TestFsckRemoteConcurrentlyWithNoPointers(t *testing.T) {
+	verified, err := fsckRemoteConcurrently(4, nil, func(batch []*lfs.WrappedPointer) (tools.StringSet, error) {
+		t.Fatal("unexpected batch")
+		return nil, nil
+	})
+	require.Nil(t, err)
+	assert.Empty(t, verified)
+}
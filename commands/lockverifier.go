@@ -21,6 +21,9 @@ const (
 	verifyStateUnknown verifyState = iota
 	verifyStateEnabled
 	verifyStateDisabled
+	// verifyStateWarn verifies locks, but only warns about files locked
+	// by others, or about errors checking them, rather than halting.
+	verifyStateWarn
 )
 
 func verifyLocksForUpdates(lv *lockVerifier, updates []*git.RefUpdate) {
@@ -44,6 +47,11 @@ type lockVerifier struct {
 
 	// locks from theirLocks that have been modified
 	unownedLocks []*refLock
+
+	// paths of the locks in ownedLocks and unownedLocks, so that each is
+	// listed once however often its file is checked
+	ownedPaths   map[string]bool
+	unownedPaths map[string]bool
 }
 
 func (lv *lockVerifier) Verify(ref *git.Ref) {
@@ -61,6 +69,8 @@ func (lv *lockVerifier) Verify(ref *git.Ref) {
 	if err != nil {
 		if errors.IsNotImplementedError(err) {
 			disableFor(lv.endpoint.Url)
+		} else if lv.verifyState == verifyStateWarn {
+			Error(tr.Tr.Get("warning: Unable to verify locks on remote %q: %s", cfg.PushRemote(), err))
 		} else if lv.verifyState == verifyStateUnknown || lv.verifyState == verifyStateEnabled {
 			if errors.IsAuthError(err) {
 				if lv.verifyState == verifyStateUnknown {
@@ -109,7 +119,10 @@ func (lv *lockVerifier) Contains(name string) bool {
 
 func (lv *lockVerifier) LockedByThem(name string) bool {
 	if lock, ok := lv.theirLocks[name]; ok {
-		lv.unownedLocks = append(lv.unownedLocks, lock)
+		if !lv.unownedPaths[name] {
+			lv.unownedPaths[name] = true
+			lv.unownedLocks = append(lv.unownedLocks, lock)
+		}
 		return true
 	}
 	return false
@@ -117,7 +130,10 @@ func (lv *lockVerifier) LockedByThem(name string) bool {
 
 func (lv *lockVerifier) LockedByUs(name string) bool {
 	if lock, ok := lv.ourLocks[name]; ok {
-		lv.ownedLocks = append(lv.ownedLocks, lock)
+		if !lv.ownedPaths[name] {
+			lv.ownedPaths[name] = true
+			lv.ownedLocks = append(lv.ownedLocks, lock)
+		}
 		return true
 	}
 	return false
@@ -157,6 +173,8 @@ func newLockVerifier(m *tq.Manifest) *lockVerifier {
 		verifiedRefs: make(map[string]bool),
 		ourLocks:     make(map[string]*refLock),
 		theirLocks:   make(map[string]*refLock),
+		ownedPaths:   make(map[string]bool),
+		unownedPaths: make(map[string]bool),
 	}
 
 	// Do not check locks for standalone transfer, because there is no LFS
@@ -225,12 +243,15 @@ func (r *refLock) Add(ref *git.Ref, l locking.Lock) error {
 }
 
 // getVerifyStateFor returns whether or not lock verification is enabled for the
-// given url. If no state has been explicitly set, an "unknown" state will be
-// returned instead.
+// given url. lfs.push.locksverify takes precedence over lfs.<url>.locksverify.
+// If no state has been explicitly set, an "unknown" state will be returned
+// instead.
 func getVerifyStateFor(rawurl string) verifyState {
-	uc := config.NewURLConfig(cfg.Git)
-
-	v, ok := uc.Get("lfs", rawurl, "locksverify")
+	v, ok := cfg.Git.Get("lfs.push.locksverify")
+	if !ok {
+		uc := config.NewURLConfig(cfg.Git)
+		v, ok = uc.Get("lfs", rawurl, "locksverify")
+	}
 	if !ok {
 		if supportsLockingAPI(rawurl) {
 			return verifyStateEnabled
@@ -238,6 +259,9 @@ func getVerifyStateFor(rawurl string) verifyState {
 		return verifyStateUnknown
 	}
 
+	if strings.EqualFold(v, "warn") {
+		return verifyStateWarn
+	}
 	if enabled, _ := strconv.ParseBool(v); enabled {
 		return verifyStateEnabled
 	}
@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// lsFilesDedupObject is an object reported by "git lfs ls-files --dedup",
+// along with the distinct paths at which it appears.
+type lsFilesDedupObject struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+	// Paths is the number of distinct paths at which the object appears.
+	Paths int      `json:"paths"`
+	Names []string `json:"names"`
+	// Saved is the number of bytes which the object would take up again
+	// for each path after the first, were its content not shared.
+	Saved int64 `json:"saved"`
+}
+
+// lsFilesDedupStats is the outcome of "git lfs ls-files --dedup": each object
+// found, and the sizes which all of them take up with and without sharing
+// content between paths.
+type lsFilesDedupStats struct {
+	Objects []*lsFilesDedupObject `json:"objects"`
+	// LogicalSize is the total size of every path, counting an object
+	// once for each path at which it appears.
+	LogicalSize int64 `json:"logical_size"`
+	// PhysicalSize is the total size of the objects, counting each one
+	// once.
+	PhysicalSize int64 `json:"physical_size"`
+	Saved        int64 `json:"saved"`
+}
+
+// lsFilesDedup maps the OID of each object reported to it to the distinct
+// paths at which that object appears.
+type lsFilesDedup struct {
+	sizes map[string]int64
+	names map[string]tools.StringSet
+}
+
+func newLsFilesDedup() *lsFilesDedup {
+	return &lsFilesDedup{
+		sizes: make(map[string]int64),
+		names: make(map[string]tools.StringSet),
+	}
+}
+
+// Add records the path of the given pointer as one at which its object
+// appears.
+func (d *lsFilesDedup) Add(p *lfs.WrappedPointer) {
+	names, ok := d.names[p.Oid]
+	if !ok {
+		names = tools.NewStringSet()
+		d.names[p.Oid] = names
+		d.sizes[p.Oid] = p.Size
+	}
+	names.Add(p.Name)
+}
+
+// Stats returns the objects from that saving the most bytes to that saving
+// the fewest, with ties ordered by OID, and the totals for all of them.
+func (d *lsFilesDedup) Stats() *lsFilesDedupStats {
+	stats := &lsFilesDedupStats{Objects: make([]*lsFilesDedupObject, 0, len(d.names))}
+	for oid, names := range d.names {
+		size := d.sizes[oid]
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+
+		obj := &lsFilesDedupObject{
+			Oid:   oid,
+			Size:  size,
+			Paths: len(sorted),
+			Names: sorted,
+			Saved: size * int64(len(sorted)-1),
+		}
+		stats.Objects = append(stats.Objects, obj)
+		stats.LogicalSize += size * int64(obj.Paths)
+		stats.PhysicalSize += size
+		stats.Saved += obj.Saved
+	}
+	sort.Slice(stats.Objects, func(i, j int) bool {
+		if stats.Objects[i].Saved != stats.Objects[j].Saved {
+			return stats.Objects[i].Saved > stats.Objects[j].Saved
+		}
+		return stats.Objects[i].Oid < stats.Objects[j].Oid
+	})
+	return stats
+}
+
+// Print prints a table with a line for each object, followed by the totals.
+func (d *lsFilesDedup) Print(showOidLen int) {
+	stats := d.Stats()
+	if len(stats.Objects) == 0 {
+		return
+	}
+
+	oids := make([]string, 0, len(stats.Objects))
+	paths := make([]string, 0, len(stats.Objects))
+	sizes := make([]string, 0, len(stats.Objects))
+	saved := make([]string, 0, len(stats.Objects))
+	for _, obj := range stats.Objects {
+		oids = append(oids, obj.Oid[:showOidLen])
+		// TRANSLATORS: The strings here are intended to have the same
+		// display width including spaces, so please insert trailing
+		// spaces as necessary for your language.
+		paths = append(paths, tr.Tr.GetN("%d path ", "%d paths", obj.Paths, obj.Paths))
+		sizes = append(sizes, humanize.FormatBytes(uint64(obj.Size)))
+		saved = append(saved, tr.Tr.Get("%s saved", humanize.FormatBytes(uint64(obj.Saved))))
+	}
+
+	paths = tools.Rjust(paths)
+	sizes = tools.Rjust(sizes)
+	saved = tools.Rjust(saved)
+
+	for i := range stats.Objects {
+		Print("%s", strings.Join([]string{oids[i], paths[i], sizes[i], saved[i]}, "\t"))
+	}
+
+	Print("")
+	// TRANSLATORS: these strings should have the colons aligned in a
+	// column.
+	Print(tr.Tr.Get(" logical size: %s\nphysical size: %s\n        saved: %s",
+		humanize.FormatBytes(uint64(stats.LogicalSize)),
+		humanize.FormatBytes(uint64(stats.PhysicalSize)),
+		humanize.FormatBytes(uint64(stats.Saved))))
+}
+
+// PrintJSON prints the objects and totals as a JSON object.
+func (d *lsFilesDedup) PrintJSON() {
+	ret, err := json.Marshal(d.Stats())
+	if err != nil {
+		ExitWithError(err)
+	}
+	Print("%s", ret)
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLsFilesDedupStats(t *testing.T) {
+	dedup := newLsFilesDedup()
+	for _, p := range []*lfs.WrappedPointer{
+		{Name: "a.dat", Pointer: &lfs.Pointer{Oid: "aaaa", Size: 10}},
+		{Name: "dir/a.dat", Pointer: &lfs.Pointer{Oid: "aaaa", Size: 10}},
+		{Name: "copy.dat", Pointer: &lfs.Pointer{Oid: "aaaa", Size: 10}},
+		{Name: "b.dat", Pointer: &lfs.Pointer{Oid: "bbbb", Size: 100}},
+		{Name: "dir/b.dat", Pointer: &lfs.Pointer{Oid: "bbbb", Size: 100}},
+		{Name: "c.dat", Pointer: &lfs.Pointer{Oid: "cccc", Size: 5}},
+		// The same path only counts once.
+		{Name: "c.dat", Pointer: &lfs.Pointer{Oid: "cccc", Size: 5}},
+	} {
+		dedup.Add(p)
+	}
+
+	assert.Equal(t, &lsFilesDedupStats{
+		Objects: []*lsFilesDedupObject{
+			{Oid: "bbbb", Size: 100, Paths: 2, Names: []string{"b.dat", "dir/b.dat"}, Saved: 100},
+			{Oid: "aaaa", Size: 10, Paths: 3, Names: []string{"a.dat", "copy.dat", "dir/a.dat"}, Saved: 20},
+			{Oid: "cccc", Size: 5, Paths: 1, Names: []string{"c.dat"}, Saved: 0},
+		},
+		LogicalSize:  235,
+		PhysicalSize: 115,
+		Saved:        120,
+	}, dedup.Stats())
+}
+
+func TestLsFilesDedupStatsEmpty(t *testing.T) {
+	stats := newLsFilesDedup().Stats()
+
+	assert.Empty(t, stats.Objects)
+	assert.NotNil(t, stats.Objects)
+	assert.Equal(t, int64(0), stats.Saved)
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// lsFilesExtGroup totals the files reported by "git lfs ls-files
+// --group-by-ext" which share a file extension.
+type lsFilesExtGroup struct {
+	// Extension is the extension shared by the files, including the
+	// leading dot, or empty for files without an extension.
+	Extension string `json:"extension"`
+	Count     int    `json:"count"`
+	Size      int64  `json:"size"`
+}
+
+type lsFilesExtGroups struct {
+	groups map[string]*lsFilesExtGroup
+}
+
+func newLsFilesExtGroups() *lsFilesExtGroups {
+	return &lsFilesExtGroups{groups: make(map[string]*lsFilesExtGroup)}
+}
+
+// lsFilesExtension returns the extension of the file with the given name, or
+// an empty string if it has none.  A leading dot, as in ".bashrc", does not
+// start an extension.
+func lsFilesExtension(name string) string {
+	base := path.Base(name)
+	ext := path.Ext(base)
+	if ext == base {
+		return ""
+	}
+	return ext
+}
+
+// Add counts the given pointer towards the group for its extension.
+func (g *lsFilesExtGroups) Add(p *lfs.WrappedPointer) {
+	ext := lsFilesExtension(p.Name)
+
+	group, ok := g.groups[ext]
+	if !ok {
+		group = &lsFilesExtGroup{Extension: ext}
+		g.groups[ext] = group
+	}
+	group.Count++
+	group.Size += p.Size
+}
+
+// Sorted returns the groups from largest to smallest total size, with ties
+// ordered by extension.
+func (g *lsFilesExtGroups) Sorted() []*lsFilesExtGroup {
+	sorted := make([]*lsFilesExtGroup, 0, len(g.groups))
+	for _, group := range g.groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+		return sorted[i].Extension < sorted[j].Extension
+	})
+	return sorted
+}
+
+// Print prints a table with a line for each group.
+func (g *lsFilesExtGroups) Print() {
+	sorted := g.Sorted()
+	if len(sorted) == 0 {
+		return
+	}
+
+	exts := make([]string, 0, len(sorted))
+	counts := make([]string, 0, len(sorted))
+	sizes := make([]string, 0, len(sorted))
+	for _, group := range sorted {
+		ext := "*" + group.Extension
+		if len(group.Extension) == 0 {
+			ext = tr.Tr.Get("(no extension)")
+		}
+
+		exts = append(exts, ext)
+		// TRANSLATORS: The strings here are intended to have the same
+		// display width including spaces, so please insert trailing
+		// spaces as necessary for your language.
+		counts = append(counts, tr.Tr.GetN("%d file ", "%d files", group.Count, group.Count))
+		sizes = append(sizes, humanize.FormatBytes(uint64(group.Size)))
+	}
+
+	exts = tools.Ljust(exts)
+	counts = tools.Rjust(counts)
+	sizes = tools.Rjust(sizes)
+
+	for i := range sorted {
+		Print("%s", strings.Join([]string{exts[i], counts[i], sizes[i]}, "\t"))
+	}
+}
+
+// PrintJSON prints the groups as a JSON object.
+func (g *lsFilesExtGroups) PrintJSON() {
+	ret, err := json.Marshal(struct {
+		Extensions []*lsFilesExtGroup `json:"extensions"`
+	}{g.Sorted()})
+	if err != nil {
+		ExitWithError(err)
+	}
+	Print("%s", ret)
+}
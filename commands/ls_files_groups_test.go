@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLsFilesExtension(t *testing.T) {
+	for name, expected := range map[string]string{
+		"a.dat":            ".dat",
+		"dir/b.tar.gz":     ".gz",
+		"dir.d/README":     "",
+		".bashrc":          "",
+		"dir/.hidden.psd":  ".psd",
+		"trailing-dot.":    ".",
+		"no/extension/bin": "",
+	} {
+		assert.Equal(t, expected, lsFilesExtension(name), name)
+	}
+}
+
+func TestLsFilesExtGroups(t *testing.T) {
+	groups := newLsFilesExtGroups()
+	for _, p := range []*lfs.WrappedPointer{
+		{Name: "a.dat", Pointer: &lfs.Pointer{Size: 10}},
+		{Name: "dir/b.dat", Pointer: &lfs.Pointer{Size: 20}},
+		{Name: "c.psd", Pointer: &lfs.Pointer{Size: 100}},
+		{Name: "README", Pointer: &lfs.Pointer{Size: 5}},
+		{Name: "dir/LICENSE", Pointer: &lfs.Pointer{Size: 25}},
+		{Name: "d.bin", Pointer: &lfs.Pointer{Size: 30}},
+	} {
+		groups.Add(p)
+	}
+
+	assert.Equal(t, []*lsFilesExtGroup{
+		{Extension: ".psd", Count: 1, Size: 100},
+		{Extension: "", Count: 2, Size: 30},
+		{Extension: ".bin", Count: 1, Size: 30},
+		{Extension: ".dat", Count: 2, Size: 30},
+	}, groups.Sorted())
+}
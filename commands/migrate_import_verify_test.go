@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/gitobj/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type migrateVerifyTest struct {
+	t  *testing.T
+	db *gitobj.ObjectDatabase
+	gf *lfs.GitFilter
+}
+
+func newMigrateVerifyTest(t *testing.T) *migrateVerifyTest {
+	dir := t.TempDir()
+	out, err := exec.Command("git", "init", dir).CombinedOutput()
+	require.Nil(t, err, string(out))
+
+	db, err := gitobj.FromFilesystem(filepath.Join(dir, ".git", "objects"), "")
+	require.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return &migrateVerifyTest{
+		t:  t,
+		db: db,
+		gf: lfs.NewGitFilter(config.NewIn(dir, "")),
+	}
+}
+
+func (m *migrateVerifyTest) blob(contents string) []byte {
+	oid, err := m.db.WriteBlob(gitobj.NewBlobFromBytes([]byte(contents)))
+	require.Nil(m.t, err)
+	return oid
+}
+
+// pointer stores "contents" as a Git LFS object, and returns the blob of its
+// pointer.
+func (m *migrateVerifyTest) pointer(contents string) []byte {
+	cleaned, err := m.gf.Clean(strings.NewReader(contents), "", int64(len(contents)), nil)
+	require.Nil(m.t, err)
+
+	mediafile, err := m.gf.ObjectPath(cleaned.Oid)
+	require.Nil(m.t, err)
+	require.Nil(m.t, os.Rename(cleaned.Filename, mediafile))
+
+	return m.blob(cleaned.Pointer.Encoded())
+}
+
+func (m *migrateVerifyTest) tree(entries ...*gitobj.TreeEntry) []byte {
+	oid, err := m.db.WriteTree(&gitobj.Tree{Entries: entries})
+	require.Nil(m.t, err)
+	return oid
+}
+
+func (m *migrateVerifyTest) commit(tree []byte) []byte {
+	oid, err := m.db.WriteCommit(&gitobj.Commit{
+		Author:    "A U Thor <author@example.com> 1136214245 +0000",
+		Committer: "A U Thor <author@example.com> 1136214245 +0000",
+		TreeID:    tree,
+		Message:   "commit",
+	})
+	require.Nil(m.t, err)
+	return oid
+}
+
+func (m *migrateVerifyTest) verifier() *migrateImportVerifier {
+	return &migrateImportVerifier{db: m.db, gf: m.gf, seen: make(map[string]bool)}
+}
+
+func blobEntry(name string, oid []byte) *gitobj.TreeEntry {
+	return &gitobj.TreeEntry{Name: name, Oid: oid, Filemode: 0100644}
+}
+
+func treeEntry(name string, oid []byte) *gitobj.TreeEntry {
+	return &gitobj.TreeEntry{Name: name, Oid: oid, Filemode: 040000}
+}
+
+func TestMigrateImportVerifierAcceptsIdenticalContents(t *testing.T) {
+	m := newMigrateVerifyTest(t)
+
+	readme := m.blob("readme")
+	from := m.commit(m.tree(
+		blobEntry("README.md", readme),
+		blobEntry("a.bin", m.blob("a contents")),
+		treeEntry("sub", m.tree(blobEntry("b.bin", m.blob("b contents")))),
+	))
+	to := m.commit(m.tree(
+		blobEntry(".gitattributes", m.blob("*.bin filter=lfs diff=lfs merge=lfs -text\n")),
+		blobEntry("README.md", readme),
+		blobEntry("a.bin", m.pointer("a contents")),
+		treeEntry("sub", m.tree(blobEntry("b.bin", m.pointer("b contents")))),
+	))
+
+	v := m.verifier()
+	problems, err := v.verifyCommit(from, to)
+	require.Nil(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, 2, v.files)
+
+	// Each pair of blobs is only compared once.
+	problems, err = v.verifyCommit(from, to)
+	require.Nil(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, 2, v.files)
+}
+
+func TestMigrateImportVerifierReportsDifferences(t *testing.T) {
+	m := newMigrateVerifyTest(t)
+
+	from := m.commit(m.tree(
+		blobEntry("changed.bin", m.blob("original contents")),
+		blobEntry("missing.bin", m.blob("missing contents")),
+		blobEntry("unconverted.bin", m.blob("unconverted contents")),
+	))
+	to := m.commit(m.tree(
+		blobEntry("changed.bin", m.pointer("other contents")),
+		blobEntry("unconverted.bin", m.blob("other unconverted contents")),
+	))
+
+	problems, err := m.verifier().verifyCommit(from, to)
+	require.Nil(t, err)
+
+	prefix := "commit " + hex.EncodeToString(from) + ": "
+	assert.Equal(t, []string{
+		prefix + "changed.bin: contents differ from the original history",
+		prefix + "missing.bin: missing from rewritten history",
+		prefix + "unconverted.bin: not converted to a Git LFS pointer",
+	}, problems)
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+)
+
+// pruneRecentCacheFile is the name of the file in the LFS storage directory
+// in which the objects retained by the current and recent refs are cached.
+const pruneRecentCacheFile = "prune-recent"
+
+// pruneRecentCache is the set of objects retained by the current and recent
+// refs when prune last scanned them.  It is stored along with a key made from
+// the commits at those refs and the options which decide what is retained
+// from them, so that it is only used while neither has changed.
+type pruneRecentCache struct {
+	path string
+	key  string
+}
+
+func newPruneRecentCache(storageDir string, fetchconf lfs.FetchPruneConfig, excludePaths []string, current string, commits []string) *pruneRecentCache {
+	return &pruneRecentCache{
+		path: filepath.Join(storageDir, pruneRecentCacheFile),
+		key:  pruneRecentCacheKey(fetchconf, excludePaths, current, commits),
+	}
+}
+
+// pruneRecentCacheKey returns a key which changes whenever the current or a
+// recent ref moves, or an option which changes what is retained at them does.
+// The recent commits retained are measured from the date of the commit at
+// each ref, so they cannot change unless one of these does.
+func pruneRecentCacheKey(fetchconf lfs.FetchPruneConfig, excludePaths []string, current string, commits []string) string {
+	sorted := make([]string, len(commits))
+	copy(sorted, commits)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "current %s\n", current)
+	for _, commit := range sorted {
+		fmt.Fprintf(h, "commit %s\n", commit)
+	}
+	fmt.Fprintf(h, "recentcommitsdays %d\n", fetchconf.FetchRecentCommitsDays)
+	fmt.Fprintf(h, "offsetdays %d\n", fetchconf.PruneOffsetDays)
+	fmt.Fprintf(h, "recent %t\n", fetchconf.PruneRecent)
+	fmt.Fprintf(h, "exclude %q\n", strings.Join(excludePaths, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Load returns the cached objects, and whether the cache was written with the
+// same key.
+func (c *pruneRecentCache) Load() ([]string, bool) {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || scanner.Text() != c.key {
+		return nil, false
+	}
+
+	var oids []string
+	for scanner.Scan() {
+		oids = append(oids, scanner.Text())
+	}
+	if scanner.Err() != nil {
+		return nil, false
+	}
+	return oids, true
+}
+
+// Save replaces the cache with the given objects.
+func (c *pruneRecentCache) Save(oids tools.StringSet) error {
+	sorted := make([]string, 0, oids.Cardinality())
+	for oid := range oids.Iter() {
+		sorted = append(sorted, oid)
+	}
+	sort.Strings(sorted)
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), pruneRecentCacheFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	fmt.Fprintln(w, c.key)
+	for _, oid := range sorted {
+		fmt.Fprintln(w, oid)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pruneCacheCommitA = "1111111111111111111111111111111111111111"
+	pruneCacheCommitB = "2222222222222222222222222222222222222222"
+	pruneCacheCommitC = "3333333333333333333333333333333333333333"
+)
+
+func TestPruneRecentCacheRoundTrips(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prune-cache")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	fetchconf := lfs.FetchPruneConfig{FetchRecentCommitsDays: 3}
+	cache := newPruneRecentCache(dir, fetchconf, nil, pruneCacheCommitA, []string{pruneCacheCommitA, pruneCacheCommitB})
+
+	_, ok := cache.Load()
+	assert.False(t, ok)
+
+	require.Nil(t, cache.Save(tools.NewStringSetFromSlice([]string{"b", "a"})))
+
+	oids, ok := cache.Load()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, oids)
+
+	// The order in which the recent refs are found does not matter.
+	same := newPruneRecentCache(dir, fetchconf, nil, pruneCacheCommitA, []string{pruneCacheCommitB, pruneCacheCommitA})
+	_, ok = same.Load()
+	assert.True(t, ok)
+}
+
+func TestPruneRecentCacheKeyChangesWhenRefsMove(t *testing.T) {
+	fetchconf := lfs.FetchPruneConfig{FetchRecentCommitsDays: 3}
+	commits := []string{pruneCacheCommitA, pruneCacheCommitB}
+	key := pruneRecentCacheKey(fetchconf, nil, pruneCacheCommitA, commits)
+
+	assert.NotEqual(t, key, pruneRecentCacheKey(fetchconf, nil, pruneCacheCommitB, commits))
+	assert.NotEqual(t, key, pruneRecentCacheKey(fetchconf, nil, pruneCacheCommitA, []string{pruneCacheCommitA, pruneCacheCommitC}))
+	assert.NotEqual(t, key, pruneRecentCacheKey(fetchconf, nil, pruneCacheCommitA, []string{pruneCacheCommitA}))
+	assert.NotEqual(t, key, pruneRecentCacheKey(lfs.FetchPruneConfig{FetchRecentCommitsDays: 4}, nil, pruneCacheCommitA, commits))
+	assert.NotEqual(t, key, pruneRecentCacheKey(fetchconf, []string{"*.bin"}, pruneCacheCommitA, commits))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"sort"
 	"sync"
 
 	"github.com/git-lfs/git-lfs/v3/config"
@@ -11,10 +12,16 @@ import (
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfs"
 	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tq"
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
+// checkoutUnattributedArg is the --unattributed flag of checkout and pull,
+// which checks out pointers even if their paths are not tracked by Git LFS in
+// any .gitattributes file, whatever lfs.checkoutunattributed is set to.
+var checkoutUnattributedArg bool
+
 // Handles the process of checking out a single file, and updating the git
 // index.
 func newSingleCheckout(gitEnv config.Environment, remote string) abstractCheckout {
@@ -36,7 +43,31 @@ func newSingleCheckout(gitEnv config.Environment, remote string) abstractCheckou
 		gitIndexer:    &gitIndexer{},
 		pathConverter: pathConverter,
 		manifest:      manifest,
+		attrs:         git.NewAttributeChecker(git.FilterAttrib),
+		unattributed: &unattributedPointers{
+			checkout: checkoutUnattributedArg || gitEnv.Bool("lfs.checkoutunattributed", true),
+		},
+		lockedByOthers: checkoutPathsLockedByOthers(),
+	}
+}
+
+// checkoutPathsLockedByOthers returns the paths which are made read-only when
+// they are checked out, being those locked by others according to the lock
+// cache, if lfs.checkout.readonlylocked is set, or nil if it is not.
+func checkoutPathsLockedByOthers() map[string]bool {
+	if !cfg.CheckoutReadOnlyLocked() {
+		return nil
+	}
+
+	lockClient := newLockClient()
+	defer lockClient.Close()
+	lockClient.RemoteRef = currentRemoteRef()
+
+	paths, err := lockClient.CachedPathsLockedByOthers()
+	if err != nil {
+		LoggedError(err, tr.Tr.Get("Could not read cached locks: %s", err))
 	}
+	return paths
 }
 
 type abstractCheckout interface {
@@ -51,6 +82,12 @@ type singleCheckout struct {
 	gitIndexer    *gitIndexer
 	pathConverter lfs.PathConverter
 	manifest      *tq.Manifest
+	attrs         *git.AttributeChecker
+	unattributed  *unattributedPointers
+
+	// lockedByOthers holds the paths which are made read-only when they
+	// are checked out, as set by lfs.checkout.readonlylocked.
+	lockedByOthers map[string]bool
 }
 
 func (c *singleCheckout) Manifest() *tq.Manifest {
@@ -82,6 +119,14 @@ func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
 		return
 	}
 
+	// Without filter=lfs, Git would not clean the checked out file again,
+	// so it would appear modified, and adding it to the index would stage
+	// its contents rather than the pointer.
+	attributed := c.isAttributed(cwdfilepath)
+	if !attributed && !c.unattributed.Add(p.Name) {
+		return
+	}
+
 	if err := c.RunToPath(p, cwdfilepath); err != nil {
 		if errors.IsDownloadDeclinedError(err) {
 			// acceptable error, data not local (fetch not run or include/exclude)
@@ -92,6 +137,16 @@ func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
 		return
 	}
 
+	if c.lockedByOthers[p.Name] {
+		if err := tools.SetFileWriteFlag(cwdfilepath, false); err != nil {
+			LoggedError(err, tr.Tr.Get("Could not make %q read-only: %s", p.Name, err))
+		}
+	}
+
+	if !attributed {
+		return
+	}
+
 	// errors are only returned when the gitIndexer is starting a new cmd
 	if err := c.gitIndexer.Add(cwdfilepath); err != nil {
 		Panic(err, tr.Tr.Get("Could not update the index"))
@@ -105,10 +160,78 @@ func (c *singleCheckout) RunToPath(p *lfs.WrappedPointer, path string) error {
 	return gitfilter.SmudgeToFile(path, p.Pointer, false, c.manifest, nil)
 }
 
+// isAttributed returns whether the file at "path", relative to the current
+// directory, has the filter=lfs attribute, or a legacy spelling of it.  If
+// its attributes cannot be checked, it is assumed to.
+func (c *singleCheckout) isAttributed(path string) bool {
+	filter, err := c.attrs.Value(path)
+	if err != nil {
+		LoggedError(err, tr.Tr.Get("Could not check attributes of %q: %s", path, err))
+		return true
+	}
+	return git.IsLFSFilter(filter)
+}
+
 func (c *singleCheckout) Close() {
 	if err := c.gitIndexer.Close(); err != nil {
 		LoggedError(err, "%s\n%s", tr.Tr.Get("Error updating the Git index:"), c.gitIndexer.Output())
 	}
+	c.attrs.Close()
+	c.unattributed.Warn()
+}
+
+// unattributedPointers collects the paths of pointers which are not tracked by
+// Git LFS in any .gitattributes file, typically because the .gitattributes
+// file was never committed, so that a single warning can be given about them.
+type unattributedPointers struct {
+	// checkout is whether the pointers are checked out anyway, or left
+	// as they are.
+	checkout bool
+
+	mu    sync.Mutex
+	names []string
+}
+
+// Add records the pointer at "name", and returns whether it should be checked
+// out.
+func (u *unattributedPointers) Add(name string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.names = append(u.names, name)
+	return u.checkout
+}
+
+// Warn prints a warning listing the recorded pointers, if there are any.
+func (u *unattributedPointers) Warn() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if len(u.names) == 0 {
+		return
+	}
+	sort.Strings(u.names)
+
+	if u.checkout {
+		Error(tr.Tr.GetN(
+			"warning: checked out %d Git LFS file not tracked by Git LFS in any .gitattributes file:",
+			"warning: checked out %d Git LFS files not tracked by Git LFS in any .gitattributes file:",
+			len(u.names), len(u.names)))
+	} else {
+		Error(tr.Tr.GetN(
+			"warning: skipped %d Git LFS pointer not tracked by Git LFS in any .gitattributes file:",
+			"warning: skipped %d Git LFS pointers not tracked by Git LFS in any .gitattributes file:",
+			len(u.names), len(u.names)))
+	}
+	for _, name := range u.names {
+		Error("  %s", name)
+	}
+
+	if u.checkout {
+		Error(tr.Tr.Get("hint: These files will appear modified until they are tracked again with 'git lfs track'."))
+	} else {
+		Error(tr.Tr.Get("hint: Is .gitattributes missing? Track them with 'git lfs track', or\nhint: use '--unattributed' to check them out anyway."))
+	}
 }
 
 type noOpCheckout struct {
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,9 @@ var (
 	commandMu    sync.Mutex
 
 	rootVersion bool
+	// rootCreateStore is whether a missing lfs.storage directory may be
+	// created even when its parent directory is missing too.
+	rootCreateStore bool
 )
 
 // NewCommand creates a new 'git-lfs' sub command, given a command name and
@@ -28,7 +33,7 @@ var (
 // Each command will initialize the local storage ('.git/lfs') directory when
 // run, unless the PreRun hook is set to nil.
 func NewCommand(name string, runFn func(*cobra.Command, []string)) *cobra.Command {
-	return &cobra.Command{Use: name, Run: runFn, PreRun: setupHTTPLogger}
+	return &cobra.Command{Use: name, Run: runFn, PreRun: setupCommand}
 }
 
 // RegisterCommand creates a direct 'git-lfs' subcommand, given a command name,
@@ -93,11 +98,16 @@ Simply type ` + root.Name() + ` help [path to command] for full details.`,
 	root.SetUsageFunc(usageCommand)
 
 	root.Flags().BoolVarP(&rootVersion, "version", "v", false, "")
+	root.PersistentFlags().BoolVar(&rootCreateStore, "create-store", false, "Create the lfs.storage directory and its parents if they are missing")
 
 	canonicalizeEnvironment()
 
 	cfg = config.New()
 
+	if format, _ := cfg.Os.Get("GIT_LFS_WARNINGS"); format == "json" {
+		warnings.SetFormat(warnings.JSONFormat)
+	}
+
 	for _, f := range commandFuncs {
 		if cmd := f(); cmd != nil {
 			root.AddCommand(cmd)
@@ -144,6 +154,11 @@ func printHelp(commandName string) {
 	}
 }
 
+func setupCommand(cmd *cobra.Command, args []string) {
+	subprocess.SetMaxGitProcesses(cfg.MaxSubprocesses())
+	setupHTTPLogger(cmd, args)
+}
+
 func setupHTTPLogger(cmd *cobra.Command, args []string) {
 	if len(os.Getenv("GIT_LOG_STATS")) < 1 {
 		return
@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// The error codes given in responses, as defined by JSON-RPC 2.0, along with
+// those for requests which fail or are canceled, which are in the range it
+// reserves for implementations.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcRequestFailed  = -32000
+	rpcCanceled       = -32800
+)
+
+// rpcCancelMethod is the method which cancels another request in progress.
+// It is handled by the server itself, as each request is read, rather than
+// queued behind the requests it is meant to cancel.
+const rpcCancelMethod = "cancel"
+
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	Version string                 `json:"jsonrpc"`
+	Method  string                 `json:"method"`
+	Params  map[string]interface{} `json:"params"`
+}
+
+// rpcError is the error given in a response to a request which could not be
+// carried out.  Handlers may return one to choose the code given.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// rpcHandler carries out the request "c", returning the result to give in
+// the response, which must not be nil, or an error.  Handlers run in their
+// own goroutines, and should stop early once c.Done() is closed.
+type rpcHandler func(c *rpcCall) (interface{}, error)
+
+// rpcCall is a request being handled by an rpcHandler.
+type rpcCall struct {
+	ctx    context.Context
+	id     json.RawMessage
+	params json.RawMessage
+	server *rpcServer
+}
+
+// Done returns a channel which is closed once the request is canceled.
+func (c *rpcCall) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Canceled returns whether the request has been canceled.
+func (c *rpcCall) Canceled() bool {
+	return c.ctx.Err() != nil
+}
+
+// Decode reads the parameters of the request into "v", returning an error
+// with the invalid params code if they do not fit.  A request without
+// parameters leaves "v" unchanged.
+func (c *rpcCall) Decode(v interface{}) error {
+	if len(c.params) == 0 || bytes.Equal(c.params, []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(c.params, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// Notify sends a notification named "method", with the given parameters and
+// the ID of the request as "id", so that clients can tell which request it
+// belongs to.
+func (c *rpcCall) Notify(method string, params map[string]interface{}) {
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	params["id"] = c.id
+	c.server.write(&rpcNotification{Version: "2.0", Method: method, Params: params})
+}
+
+// rpcServer reads JSON-RPC 2.0 requests, one per line, and writes a response
+// to each on a line of its own once it has been handled, along with any
+// notifications sent while handling it.  Requests are handled concurrently,
+// so their responses may be given in any order.
+type rpcServer struct {
+	methods map[string]rpcHandler
+
+	out   *json.Encoder
+	outMu sync.Mutex
+
+	// calls holds the function which cancels each request in progress,
+	// keyed by its ID.
+	calls   map[string]context.CancelFunc
+	callsMu sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func newRPCServer(w io.Writer, methods map[string]rpcHandler) *rpcServer {
+	return &rpcServer{
+		methods: methods,
+		out:     json.NewEncoder(w),
+		calls:   make(map[string]context.CancelFunc),
+	}
+}
+
+// Serve handles the requests read from "r" until it is closed, and then waits
+// for those in progress to finish.
+func (s *rpcServer) Serve(r io.Reader) error {
+	defer s.wg.Wait()
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			s.handle(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, tr.Tr.Get("could not read request"))
+		}
+	}
+}
+
+// handle starts handling the request read from "line".
+func (s *rpcServer) handle(line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.respond(nil, nil, &rpcError{Code: rpcParseError, Message: tr.Tr.Get("parse error: %v", err)})
+		return
+	}
+	if req.Version != "2.0" || len(req.Method) == 0 {
+		s.respond(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: tr.Tr.Get("invalid request")})
+		return
+	}
+
+	if req.Method == rpcCancelMethod {
+		s.handleCancel(&req)
+		return
+	}
+
+	handler, ok := s.methods[req.Method]
+	if !ok {
+		s.respond(req.ID, nil, &rpcError{Code: rpcMethodNotFound, Message: tr.Tr.Get("method not found: %s", req.Method)})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	key := rpcID(req.ID)
+	if len(key) > 0 {
+		s.callsMu.Lock()
+		if _, ok := s.calls[key]; ok {
+			s.callsMu.Unlock()
+			cancel()
+			s.respond(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: tr.Tr.Get("request %s is already in progress", key)})
+			return
+		}
+		s.calls[key] = cancel
+		s.callsMu.Unlock()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		call := &rpcCall{ctx: ctx, id: req.ID, params: req.Params, server: s}
+		result, err := handler(call)
+		if call.Canceled() {
+			result, err = nil, &rpcError{Code: rpcCanceled, Message: tr.Tr.Get("request canceled")}
+		}
+
+		if len(key) > 0 {
+			s.callsMu.Lock()
+			delete(s.calls, key)
+			s.callsMu.Unlock()
+		}
+		cancel()
+
+		s.respond(req.ID, result, err)
+	}()
+}
+
+// handleCancel cancels the request whose ID is given by the "id" parameter of
+// "req", if it is in progress.  The response gives whether it was.
+func (s *rpcServer) handleCancel(req *rpcRequest) {
+	var params struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(rpcID(params.ID)) == 0 {
+		s.respond(req.ID, nil, &rpcError{Code: rpcInvalidParams, Message: tr.Tr.Get("invalid params: an ID is required")})
+		return
+	}
+
+	s.callsMu.Lock()
+	cancel, ok := s.calls[rpcID(params.ID)]
+	s.callsMu.Unlock()
+	if ok {
+		cancel()
+	}
+
+	s.respond(req.ID, map[string]bool{"canceled": ok}, nil)
+}
+
+// respond writes the response to the request "id", giving "err" if it is not
+// nil, and "result" otherwise.  Nothing is written for a notification, which
+// has no ID, unless the request could not be read at all.
+func (s *rpcServer) respond(id json.RawMessage, result interface{}, err error) {
+	if len(id) == 0 {
+		if rerr, ok := err.(*rpcError); !ok || (rerr.Code != rpcParseError && rerr.Code != rpcInvalidRequest) {
+			return
+		}
+		id = json.RawMessage("null")
+	}
+
+	res := &rpcResponse{Version: "2.0", ID: id}
+	if err != nil {
+		rerr, ok := err.(*rpcError)
+		if !ok {
+			rerr = &rpcError{Code: rpcRequestFailed, Message: err.Error()}
+		}
+		res.Error = rerr
+	} else {
+		res.Result = result
+	}
+	s.write(res)
+}
+
+func (s *rpcServer) write(v interface{}) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	if err := s.out.Encode(v); err != nil {
+		LoggedError(err, tr.Tr.Get("Could not write JSON-RPC message: %s", err))
+	}
+}
+
+// rpcID returns the canonical form of the request ID "id", so that the same
+// ID written with different spacing is matched, or the empty string if there
+// is none.
+func rpcID(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil || buf.String() == "null" {
+		return ""
+	}
+	return buf.String()
+}
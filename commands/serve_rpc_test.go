@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRPCMessage struct {
+	ID     json.RawMessage        `json:"id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+	Result map[string]interface{} `json:"result"`
+	Error  *rpcError              `json:"error"`
+}
+
+func testRPCMethods() map[string]rpcHandler {
+	return map[string]rpcHandler{
+		"echo": func(c *rpcCall) (interface{}, error) {
+			var params struct {
+				Text string `json:"text"`
+			}
+			if err := c.Decode(&params); err != nil {
+				return nil, err
+			}
+			c.Notify("echo.progress", map[string]interface{}{"text": params.Text})
+			return map[string]string{"text": params.Text}, nil
+		},
+		"block": func(c *rpcCall) (interface{}, error) {
+			c.Notify("block.started", nil)
+			<-c.Done()
+			return map[string]string{}, nil
+		},
+	}
+}
+
+func TestRPCServerResponses(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hello"}}`,
+		`not json`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"missing"}`,
+		`{"jsonrpc":"1.0","id":3,"method":"echo"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"echo","params":{"text":4}}`,
+		`{"jsonrpc":"2.0","method":"echo","params":{"text":"no reply"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"cancel","params":{"id":1234}}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	require.Nil(t, newRPCServer(&out, testRPCMethods()).Serve(strings.NewReader(in)))
+
+	responses := make(map[string]*testRPCMessage)
+	var notifications []*testRPCMessage
+	dec := json.NewDecoder(&out)
+	for {
+		msg := &testRPCMessage{}
+		err := dec.Decode(msg)
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+
+		if len(msg.Method) > 0 {
+			notifications = append(notifications, msg)
+		} else {
+			responses[string(msg.ID)] = msg
+		}
+	}
+	require.Len(t, responses, 6)
+
+	assert.Nil(t, responses["1"].Error)
+	assert.Equal(t, "hello", responses["1"].Result["text"])
+	assert.Equal(t, rpcParseError, responses["null"].Error.Code)
+	assert.Equal(t, rpcMethodNotFound, responses["2"].Error.Code)
+	assert.Equal(t, rpcInvalidRequest, responses["3"].Error.Code)
+	assert.Equal(t, rpcInvalidParams, responses["4"].Error.Code)
+	assert.Equal(t, false, responses["5"].Result["canceled"])
+
+	// The notification sent while handling a request without an ID is
+	// still written, with a null "id" parameter.
+	require.Len(t, notifications, 2)
+	ids := make(map[string]interface{})
+	for _, n := range notifications {
+		assert.Equal(t, "echo.progress", n.Method)
+		ids[n.Params["text"].(string)] = n.Params["id"]
+	}
+	assert.Equal(t, map[string]interface{}{"hello": float64(1), "no reply": nil}, ids)
+}
+
+func TestRPCServerCancel(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	served := make(chan error)
+	go func() {
+		served <- newRPCServer(outW, testRPCMethods()).Serve(inR)
+		outW.Close()
+	}()
+
+	dec := json.NewDecoder(outR)
+	read := func() *testRPCMessage {
+		msg := &testRPCMessage{}
+		require.Nil(t, dec.Decode(msg))
+		return msg
+	}
+
+	io.WriteString(inW, `{"jsonrpc":"2.0","id":"a","method":"block"}`+"\n")
+	started := read()
+	assert.Equal(t, "block.started", started.Method)
+	assert.Equal(t, "a", started.Params["id"])
+
+	// A second request with the same ID is refused while the first is
+	// in progress.
+	io.WriteString(inW, `{"jsonrpc":"2.0","id":"a","method":"echo"}`+"\n")
+	dup := read()
+	require.NotNil(t, dup.Error)
+	assert.Equal(t, rpcInvalidRequest, dup.Error.Code)
+
+	io.WriteString(inW, `{"jsonrpc":"2.0","id":"b","method":"cancel","params":{"id": "a"}}`+"\n")
+	responses := make(map[string]*testRPCMessage)
+	for i := 0; i < 2; i++ {
+		msg := read()
+		responses[string(msg.ID)] = msg
+	}
+	assert.Equal(t, true, responses[`"b"`].Result["canceled"])
+	require.NotNil(t, responses[`"a"`].Error)
+	assert.Equal(t, rpcCanceled, responses[`"a"`].Error.Code)
+	assert.Equal(t, "request canceled", responses[`"a"`].Error.Message)
+
+	inW.Close()
+	assert.Nil(t, <-served)
+	_, err := outR.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
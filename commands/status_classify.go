@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+)
+
+// statusClass is how "git lfs status --classify" describes a file in the
+// working tree which is, or would be, tracked by Git LFS.
+type statusClass string
+
+const (
+	// statusClassMissing is a file which is still a pointer because its
+	// object is not in the local store.
+	statusClassMissing statusClass = "missing"
+	// statusClassUnsmudged is a file which is still a pointer although
+	// its object is in the local store, such as after a clone with
+	// GIT_LFS_SKIP_SMUDGE set, until "git lfs checkout" is run.
+	statusClassUnsmudged statusClass = "unsmudged"
+	// statusClassSmudged is a file whose contents are those of the
+	// object its pointer in the index names.
+	statusClassSmudged statusClass = "smudged"
+	// statusClassModified is a file whose contents, or pointer, differ
+	// from those which its pointer in the index names.
+	statusClassModified statusClass = "modified"
+	// statusClassUntracked is a file which is not in the index, but
+	// which would be tracked by Git LFS if it were added.
+	statusClassUntracked statusClass = "untracked"
+)
+
+// classifiedFile is a file in the working tree, by its path relative to the
+// root of the repository, and its class.
+type classifiedFile struct {
+	Name  string
+	Class statusClass
+}
+
+// classifyWorkingTree returns the class of each file in the working tree which
+// has a Git LFS pointer in the index, or which is not in the index but matches
+// a pattern tracked by Git LFS, in order of their paths.  Files with a pointer
+// in the index which have been deleted from the working tree are left out.
+func classifyWorkingTree() ([]*classifiedFile, error) {
+	repo := cfg.LocalWorkingDir()
+
+	pointers := make(map[string]*lfs.WrappedPointer)
+	var scanErr error
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if scanErr == nil {
+				scanErr = err
+			}
+			return
+		}
+		pointers[p.Name] = p
+	})
+
+	// Scanning against the empty tree finds every pointer in the index,
+	// rather than only those changed since HEAD.
+	err := gitscanner.ScanIndex(git.EmptyTree(), nil)
+	gitscanner.Close()
+	if err != nil {
+		return nil, err
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	var files []*classifiedFile
+	for name, p := range pointers {
+		class, ok, err := classifyPointerFile(filepath.Join(repo, name), p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			files = append(files, &classifiedFile{Name: name, Class: class})
+		}
+	}
+
+	untracked, err := untrackedLFSFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range untracked {
+		files = append(files, &classifiedFile{Name: name, Class: statusClassUntracked})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// classifyPointerFile returns the class of the file at "path", whose pointer in
+// the index is "p", and false if it has been deleted or replaced by something
+// other than a file.
+func classifyPointerFile(path string, p *lfs.WrappedPointer) (statusClass, bool, error) {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", false, nil
+	}
+
+	if fi.Size() == p.Size {
+		oid, err := fileOid(path)
+		if err != nil {
+			return "", false, err
+		}
+		if oid == p.Oid {
+			return statusClassSmudged, true, nil
+		}
+	}
+
+	if wp, err := lfs.DecodePointerFromFile(path); err == nil {
+		if wp.Oid != p.Oid {
+			return statusClassModified, true, nil
+		}
+		if cfg.LFSObjectExists(p.Oid, p.Size) {
+			return statusClassUnsmudged, true, nil
+		}
+		return statusClassMissing, true, nil
+	}
+	return statusClassModified, true, nil
+}
+
+// fileOid returns the SHA-256 of the contents of the file at "path".
+func fileOid(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// untrackedLFSFiles returns the paths, relative to "repo", of the files in the
+// working tree which are neither in the index nor ignored, and whose
+// attributes give them a Git LFS filter.
+func untrackedLFSFiles(repo string) ([]string, error) {
+	cached, err := git.NewLsFiles(repo, true, false)
+	if err != nil {
+		return nil, err
+	}
+	all, err := git.NewLsFiles(repo, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	wd, _ := os.Getwd()
+	wd = tools.ResolveSymlinks(wd)
+
+	checker := git.NewAttributeChecker("filter")
+	defer checker.Close()
+
+	var untracked []string
+	for name := range all.Files {
+		if _, ok := cached.Files[name]; ok {
+			continue
+		}
+
+		value, err := checker.Value(relativize(wd, filepath.Join(repo, name)))
+		if err != nil {
+			return nil, err
+		}
+		if git.IsLFSFilter(value) {
+			untracked = append(untracked, name)
+		}
+	}
+	return untracked, nil
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	storageReportJson bool
+)
+
+// storageReportObject is an object in a storageReportSet.
+type storageReportObject struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// storageReportSet is a set of objects, with the number of them and their
+// total size.
+type storageReportSet struct {
+	Count   int                    `json:"count"`
+	Bytes   int64                  `json:"bytes"`
+	Objects []*storageReportObject `json:"objects"`
+}
+
+func (s *storageReportSet) add(oid string, size int64) {
+	s.Count++
+	s.Bytes += size
+	s.Objects = append(s.Objects, &storageReportObject{Oid: oid, Size: size})
+}
+
+// storageReport divides the objects referenced by the history reachable from
+// any ref, and those in the local store, into those which are both, those
+// which are reachable but not stored, and those which are stored but not
+// reachable.
+type storageReport struct {
+	Stored   *storageReportSet `json:"stored"`
+	Missing  *storageReportSet `json:"missing"`
+	Orphaned *storageReportSet `json:"orphaned"`
+}
+
+// storageReportCommand reports how the objects in the local store relate to
+// those reachable from the repository's refs.
+func storageReportCommand(cmd *cobra.Command, args []string) {
+	setupRepository()
+
+	report, err := newStorageReport()
+	if err != nil {
+		ExitWithError(errors.Wrap(err, tr.Tr.Get("Could not report on the local store")))
+	}
+
+	if storageReportJson {
+		if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	for _, line := range []struct {
+		name string
+		set  *storageReportSet
+	}{
+		{tr.Tr.Get("Stored and reachable"), report.Stored},
+		{tr.Tr.Get("Reachable but missing"), report.Missing},
+		{tr.Tr.Get("Stored but unreachable"), report.Orphaned},
+	} {
+		Print("%s: %s, %s", line.name, tr.Tr.GetN(
+			"%d object",
+			"%d objects",
+			line.set.Count,
+			line.set.Count,
+		), humanize.FormatBytes(uint64(line.set.Bytes)))
+	}
+}
+
+// newStorageReport scans all history reachable from any ref, and the local
+// store, and returns the storageReport of the objects they hold.
+func newStorageReport() (*storageReport, error) {
+	reachable := make(map[string]int64)
+	var scanErr error
+	gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if scanErr == nil {
+				scanErr = err
+			}
+			return
+		}
+		reachable[p.Oid] = p.Size
+	})
+	err := gitscanner.ScanAll(nil)
+	gitscanner.Close()
+	if err != nil {
+		return nil, err
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	stored := make(map[string]int64)
+	err = cfg.EachLFSObject(func(obj fs.Object) error {
+		stored[obj.Oid] = obj.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &storageReport{
+		Stored:   &storageReportSet{Objects: []*storageReportObject{}},
+		Missing:  &storageReportSet{Objects: []*storageReportObject{}},
+		Orphaned: &storageReportSet{Objects: []*storageReportObject{}},
+	}
+	for _, oid := range sortedOids(reachable) {
+		if size, ok := stored[oid]; ok {
+			report.Stored.add(oid, size)
+		} else {
+			report.Missing.add(oid, reachable[oid])
+		}
+	}
+	for _, oid := range sortedOids(stored) {
+		if _, ok := reachable[oid]; !ok {
+			report.Orphaned.add(oid, stored[oid])
+		}
+	}
+	return report, nil
+}
+
+// sortedOids returns the keys of "objects" in order.
+func sortedOids(objects map[string]int64) []string {
+	oids := make([]string, 0, len(objects))
+	for oid := range objects {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+	return oids
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/tq"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+var (
+	// transferReportArg is the path given with --report, if any, to which
+	// push, fetch, and pull write the outcome of each object they
+	// transferred.
+	transferReportArg string
+
+	transferReportMu      sync.Mutex
+	transferReportObjects []*tq.ObjectReport
+)
+
+type transferReportFile struct {
+	Objects []*tq.ObjectReport `json:"objects"`
+}
+
+// recordTransferReport collects the per-object outcomes of the given queue,
+// which must have finished, for inclusion in the report written by
+// writeTransferReport.
+func recordTransferReport(q *tq.TransferQueue) {
+	if len(transferReportArg) == 0 {
+		return
+	}
+
+	transferReportMu.Lock()
+	defer transferReportMu.Unlock()
+
+	transferReportObjects = append(transferReportObjects, q.Report()...)
+}
+
+// writeTransferReport writes the outcomes collected so far to the file given
+// with --report, if any. It is called before reporting any transfer errors, so
+// that the report is written even if the operation only partially succeeded.
+func writeTransferReport() {
+	if len(transferReportArg) == 0 {
+		return
+	}
+
+	transferReportMu.Lock()
+	defer transferReportMu.Unlock()
+
+	report := &transferReportFile{Objects: transferReportObjects}
+	if report.Objects == nil {
+		report.Objects = []*tq.ObjectReport{}
+	}
+
+	f, err := os.Create(transferReportArg)
+	if err != nil {
+		Exit(tr.Tr.Get("Unable to write transfer report %q: %v", transferReportArg, err))
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(report); err != nil {
+		Exit(tr.Tr.Get("Unable to write transfer report %q: %v", transferReportArg, err))
+	}
+}
@@ -74,6 +74,92 @@ func uploadLeftOrAll(g *lfs.GitScanner, ctx *uploadContext, q *tq.TransferQueue,
 	return ctx.scannerError()
 }
 
+// reportOrphaned prints, for each of the updates which force-updates its ref
+// on the remote, the objects which were reachable from the ref before the update
+// but are no longer reachable from it, any other ref being pushed, or any other
+// ref of the remote as of when it was last fetched.  The server is not asked
+// to delete them; they are only listed so that they can be cleaned up.
+//
+// The value of each ref on the remote before the update is given by "remoteSha",
+// and updates for which it is empty or the zero object ID are skipped.
+func reportOrphaned(ctx *uploadContext, updates []*git.RefUpdate, remoteSha func(*git.RefUpdate) string) error {
+	updated := make(map[string]bool, len(updates))
+	keep := make([]string, 0, len(updates))
+	for _, update := range updates {
+		updated[update.Right().Name] = true
+		keep = append(keep, update.LeftCommitish())
+	}
+
+	tracking, err := git.CachedRemoteRefs(ctx.Remote)
+	if err != nil {
+		return err
+	}
+	for _, ref := range tracking {
+		if !updated[ref.Name] {
+			keep = append(keep, ref.Sha)
+		}
+	}
+
+	for _, update := range updates {
+		old := remoteSha(update)
+		if len(old) == 0 || git.IsZeroObjectID(old) {
+			continue
+		}
+
+		bases, err := git.MergeBases(old, update.LeftCommitish())
+		if err != nil {
+			Error(tr.Tr.Get("warning: Unable to check for objects orphaned by updating %q: %s", update.Right().Name, err))
+			continue
+		}
+		if isFastForward(old, bases) {
+			continue
+		}
+
+		var orphaned []*lfs.WrappedPointer
+		gitscanner := lfs.NewGitScanner(cfg, func(p *lfs.WrappedPointer, err error) {
+			if err != nil {
+				ctx.addScannerError(err)
+				return
+			}
+			orphaned = append(orphaned, p)
+		})
+		err = gitscanner.ScanOrphaned(old, keep, nil)
+		gitscanner.Close()
+		if err == nil {
+			err = ctx.scannerError()
+		}
+		if err != nil {
+			return errors.Wrap(err, tr.Tr.Get("ref %q:", update.Right().Name))
+		}
+		if len(orphaned) == 0 {
+			continue
+		}
+
+		Print(tr.Tr.GetN(
+			"Force-updating %q on %q leaves %d Git LFS object unreferenced:",
+			"Force-updating %q on %q leaves %d Git LFS objects unreferenced:",
+			len(orphaned),
+			update.Right().Name, ctx.Remote, len(orphaned),
+		))
+		for _, p := range orphaned {
+			// TRANSLATORS: Leading spaces should be preserved.
+			Print(tr.Tr.Get("  %s %s", p.Oid, p.Name))
+		}
+	}
+	return nil
+}
+
+// isFastForward returns whether a ref at "old" is fast-forwarded by an update
+// to a commit whose merge bases with it are "bases".
+func isFastForward(old string, bases []string) bool {
+	for _, base := range bases {
+		if base == old {
+			return true
+		}
+	}
+	return false
+}
+
 type uploadContext struct {
 	Remote       string
 	DryRun       bool
@@ -93,6 +179,10 @@ type uploadContext struct {
 	// pointers should allow pushing Git blobs
 	allowMissing bool
 
+	// reportOrphaned specifies whether the objects left unreferenced by
+	// force-updates of refs on the remote are listed
+	reportOrphaned bool
+
 	// tracks errors from gitscanner callbacks
 	scannerErr error
 	errMu      sync.Mutex
@@ -107,16 +197,17 @@ func newUploadContext(dryRun bool) *uploadContext {
 	remote := cfg.PushRemote()
 	manifest := getTransferManifestOperationRemote("upload", remote)
 	ctx := &uploadContext{
-		Remote:       remote,
-		Manifest:     manifest,
-		DryRun:       dryRun,
-		uploadedOids: tools.NewStringSet(),
-		gitfilter:    lfs.NewGitFilter(cfg),
-		lockVerifier: newLockVerifier(manifest),
-		allowMissing: cfg.Git.Bool("lfs.allowincompletepush", false),
-		missing:      make(map[string]string),
-		corrupt:      make(map[string]string),
-		otherErrs:    make([]error, 0),
+		Remote:         remote,
+		Manifest:       manifest,
+		DryRun:         dryRun,
+		uploadedOids:   tools.NewStringSet(),
+		gitfilter:      lfs.NewGitFilter(cfg),
+		lockVerifier:   newLockVerifier(manifest),
+		allowMissing:   cfg.Git.Bool("lfs.allowincompletepush", false),
+		reportOrphaned: cfg.Git.Bool("lfs.push.reportorphaned", false),
+		missing:        make(map[string]string),
+		corrupt:        make(map[string]string),
+		otherErrs:      make([]error, 0),
 	}
 
 	var sink io.Writer = os.Stdout
@@ -200,7 +291,7 @@ func (c *uploadContext) prepareUpload(unfiltered ...*lfs.WrappedPointer) []*lfs.
 	for _, p := range unfiltered {
 		// object already uploaded in this process, or we've already
 		// seen this OID (see above), skip!
-		if uniqOids.Contains(p.Oid) || c.HasUploaded(p.Oid) || p.Size == 0 {
+		if uniqOids.Contains(p.Oid) || c.HasUploaded(p.Oid) || p.Size == 0 && c.Manifest.EmptyObjects() == tq.EmptyObjectLocal {
 			continue
 		}
 		uniqOids.Add(p.Oid)
@@ -266,6 +357,7 @@ func (c *uploadContext) UploadPointers(q *tq.TransferQueue, unfiltered ...*lfs.W
 
 func (c *uploadContext) CollectErrors(tqueue *tq.TransferQueue) {
 	tqueue.Wait()
+	recordTransferReport(tqueue)
 
 	for _, err := range tqueue.Errors() {
 		if malformed, ok := err.(*tq.MalformedObjectError); ok {
@@ -282,6 +374,7 @@ func (c *uploadContext) CollectErrors(tqueue *tq.TransferQueue) {
 
 func (c *uploadContext) ReportErrors() {
 	c.meter.Finish()
+	writeTransferReport()
 
 	for _, err := range c.otherErrs {
 		FullError(err)
@@ -17,6 +17,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
@@ -27,6 +28,10 @@ var (
 	gitConfigWarningPrefix = "lfs."
 )
 
+// maxHashBufferSize is the largest value of lfs.hashbuffersize which is
+// accepted; larger values are ignored in favor of the default.
+const maxHashBufferSize = 64 * 1024 * 1024
+
 type Configuration struct {
 	// Os provides a `*Environment` used to access to the system's
 	// environment through os.Getenv. It is the point of entry for all
@@ -196,9 +201,22 @@ func (c *Configuration) FetchIncludePaths() []string {
 	return tools.CleanPaths(patterns, ",")
 }
 
+// FetchExcludePaths returns the patterns of paths which are not fetched, from
+// lfs.fetchexclude, followed by a pattern for each of the file extensions in
+// lfs.fetchexcludeext.
 func (c *Configuration) FetchExcludePaths() []string {
 	patterns, _ := c.Git.Get("lfs.fetchexclude")
-	return tools.CleanPaths(patterns, ",")
+	paths := tools.CleanPaths(patterns, ",")
+
+	exts, _ := c.Git.Get("lfs.fetchexcludeext")
+	for _, ext := range tools.CleanPaths(exts, ",") {
+		// Accept "psd", ".psd", and "*.psd" alike.
+		ext = strings.TrimLeft(strings.TrimPrefix(ext, "*"), ".")
+		if len(ext) > 0 {
+			paths = append(paths, "*."+ext)
+		}
+	}
+	return paths
 }
 
 func (c *Configuration) CurrentRef() *git.Ref {
@@ -327,6 +345,37 @@ func (c *Configuration) SetLockableFilesReadOnly() bool {
 	return c.Os.Bool("GIT_LFS_SET_LOCKABLE_READONLY", true) && c.Git.Bool("lfs.setlockablereadonly", true)
 }
 
+// CheckoutReadOnlyLocked returns whether files locked by others are made
+// read-only when they are checked out, as set by lfs.checkout.readonlylocked.
+func (c *Configuration) CheckoutReadOnlyLocked() bool {
+	return c.Git.Bool("lfs.checkout.readonlylocked", false)
+}
+
+// MaxSubprocesses returns the number of Git processes which may run at once,
+// as set by lfs.maxsubprocesses, or 0 if any number may.  A scan of history
+// runs a git rev-list process alongside its git cat-file processes, and each
+// ref fetched at once is scanned separately, so it is never less than enough
+// for those scans and two other processes.
+func (c *Configuration) MaxSubprocesses() int {
+	n := c.Git.Int("lfs.maxsubprocesses", 0)
+	if n < 1 {
+		return 0
+	}
+
+	scans := c.Git.Int("lfs.fetchrefsconcurrency", 1)
+	if scans < 1 {
+		scans = 1
+	}
+	workers := c.Git.Int("lfs.catfileworkers", 1)
+	if workers < 1 {
+		workers = 1
+	}
+	if min := scans*(workers+1) + 2; n < min {
+		return min
+	}
+	return n
+}
+
 func (c *Configuration) ForceProgress() bool {
 	return c.Os.Bool("GIT_LFS_FORCE_PROGRESS", false) || c.Git.Bool("lfs.forceprogress", false)
 }
@@ -434,6 +483,22 @@ func (c *Configuration) Filesystem() *fs.Filesystem {
 			lfsdir,
 			c.RepositoryPermissions(false),
 		)
+		c.fs.Fsync = c.Git.Bool("lfs.storage.fsync", false)
+		if v, ok := c.Git.Get("lfs.storage.minfreebytes"); ok {
+			if n, err := humanize.ParseBytes(v); err == nil {
+				c.fs.MinFreeBytes = n
+			}
+		}
+		if v, ok := c.Git.Get("lfs.storage.sharddepth"); ok {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= fs.MaxShardDepth {
+				c.fs.ObjectKey = fs.ShardedObjectKeyDepth(n)
+			}
+		}
+		if v, ok := c.Git.Get("lfs.hashbuffersize"); ok {
+			if n, err := humanize.ParseBytes(v); err == nil && n <= maxHashBufferSize {
+				c.fs.HashBufferSize = int(n)
+			}
+		}
 	}
 
 	return c.fs
@@ -198,6 +198,18 @@ func TestFetchIncludeExcludesAreCleaned(t *testing.T) {
 	assert.Equal(t, []string{"/other/path/to/clean"}, cfg.FetchExcludePaths())
 }
 
+func TestFetchExcludeExtensions(t *testing.T) {
+	cfg := NewFrom(Values{
+		Git: map[string][]string{
+			"lfs.fetchexclude":    []string{"docs/"},
+			"lfs.fetchexcludeext": []string{"psd, .tga,*.zip,,*."},
+		},
+	})
+
+	assert.Equal(t, []string{"docs", "*.psd", "*.tga", "*.zip"}, cfg.FetchExcludePaths())
+	assert.Empty(t, cfg.FetchIncludePaths())
+}
+
 func TestRepositoryPermissions(t *testing.T) {
 	perms := 0666 & ^umask()
 
@@ -380,3 +392,47 @@ func TestRemoteNameWithDotDefault(t *testing.T) {
 
 	assert.Equal(t, "name.with.dot", cfg.Remote())
 }
+
+func TestHashBufferSize(t *testing.T) {
+	for value, expected := range map[string]int{
+		"":        0,
+		"1048576": 1024 * 1024,
+		"1MiB":    1024 * 1024,
+		"256KB":   256 * 1000,
+		"1GiB":    0,
+		"bogus":   0,
+	} {
+		git := map[string][]string{}
+		if len(value) > 0 {
+			git["lfs.hashbuffersize"] = []string{value}
+		}
+		cfg := NewFrom(Values{Git: git})
+
+		assert.Equal(t, expected, cfg.Filesystem().HashBufferSize, "lfs.hashbuffersize=%q", value)
+	}
+}
+
+func TestMaxSubprocesses(t *testing.T) {
+	for desc, c := range map[string]struct {
+		Git      map[string][]string
+		Expected int
+	}{
+		"unset":    {map[string][]string{}, 0},
+		"zero":     {map[string][]string{"lfs.maxsubprocesses": {"0"}}, 0},
+		"negative": {map[string][]string{"lfs.maxsubprocesses": {"-1"}}, 0},
+		"set":      {map[string][]string{"lfs.maxsubprocesses": {"8"}}, 8},
+		"too low":  {map[string][]string{"lfs.maxsubprocesses": {"1"}}, 4},
+		"workers": {map[string][]string{
+			"lfs.maxsubprocesses": {"4"},
+			"lfs.catfileworkers":  {"3"},
+		}, 6},
+		"fetch refs": {map[string][]string{
+			"lfs.maxsubprocesses":      {"4"},
+			"lfs.catfileworkers":       {"2"},
+			"lfs.fetchrefsconcurrency": {"2"},
+		}, 8},
+	} {
+		cfg := NewFrom(Values{Git: c.Git})
+		assert.Equal(t, c.Expected, cfg.MaxSubprocesses(), desc)
+	}
+}
@@ -2,12 +2,12 @@ package config
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
@@ -32,22 +32,25 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 				continue
 			}
 
-			allowed := !gc.OnlySafeKeys
-
 			// We don't need to change the case of the key here,
 			// since Git will already have canonicalized it for us.
 			key, val := pieces[0], pieces[1]
 
 			if origKey, ok := uniqKeys[key]; ok {
 				if ShowConfigWarnings && len(vals[key]) > 0 && vals[key][len(vals[key])-1] != val && strings.HasPrefix(key, gitConfigWarningPrefix) {
-					fmt.Fprintln(os.Stderr, tr.Tr.Get("warning: These `git config` values clash:"))
-					fmt.Fprintf(os.Stderr, "  git config %q = %q\n", origKey, vals[key])
-					fmt.Fprintf(os.Stderr, "  git config %q = %q\n", pieces[0], val)
+					warnings.Warn(warnings.ConfigClash, fmt.Sprintf("%s\n  git config %q = %q\n  git config %q = %q",
+						tr.Tr.Get("warning: These `git config` values clash:"),
+						origKey, vals[key], pieces[0], val))
 				}
 			} else {
 				uniqKeys[key] = pieces[0]
 			}
 
+			if gc.OnlySafeKeys && !isSafeKey(key) {
+				ignored = append(ignored, key)
+				continue
+			}
+
 			parts := strings.Split(key, ".")
 			if len(parts) == 4 && parts[0] == "lfs" && parts[1] == "extension" {
 				// prop: lfs.extension.<name>.<prop>
@@ -59,19 +62,10 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 
 				switch prop {
 				case "clean":
-					if gc.OnlySafeKeys {
-						ignored = append(ignored, key)
-						continue
-					}
 					ext.Clean = val
 				case "smudge":
-					if gc.OnlySafeKeys {
-						ignored = append(ignored, key)
-						continue
-					}
 					ext.Smudge = val
 				case "priority":
-					allowed = true
 					p, err := strconv.Atoi(val)
 					if err == nil && p >= 0 {
 						ext.Priority = p
@@ -80,21 +74,8 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 
 				extensions[name] = ext
 			} else if len(parts) > 1 && parts[0] == "remote" {
-				if gc.OnlySafeKeys && (len(parts) == 3 && parts[2] != "lfsurl") {
-					ignored = append(ignored, key)
-					continue
-				}
-
-				allowed = true
 				remote := strings.Join(parts[1:len(parts)-1], ".")
 				uniqRemotes[remote] = remote == "origin"
-			} else if len(parts) > 2 && parts[len(parts)-1] == "access" {
-				allowed = true
-			}
-
-			if !allowed && keyIsUnsafe(key) {
-				ignored = append(ignored, key)
-				continue
 			}
 
 			vals[key] = append(vals[key], val)
@@ -102,10 +83,11 @@ func readGitConfig(configs ...*git.ConfigurationSource) (gf *GitFetcher, extensi
 	}
 
 	if len(ignored) > 0 {
-		fmt.Fprint(os.Stderr, tr.Tr.Get("warning: These unsafe '.lfsconfig' keys were ignored:"), "\n\n")
+		msg := tr.Tr.Get("warning: These unsafe '.lfsconfig' keys were ignored:") + "\n"
 		for _, key := range ignored {
-			fmt.Fprintf(os.Stderr, "  %s\n", key)
+			msg += fmt.Sprintf("\n  %s", key)
 		}
+		warnings.Warn(warnings.UnsafeConfigIgnored, msg)
 	}
 
 	gf = &GitFetcher{vals: vals}
@@ -174,6 +156,22 @@ func (g *GitFetcher) caseFoldKey(key string) string {
 	}, ".")
 }
 
+// isSafeKey returns whether "key" may be read from a source which is limited
+// to safe keys, such as the .lfsconfig file.
+func isSafeKey(key string) bool {
+	parts := strings.Split(key, ".")
+	if len(parts) == 4 && parts[0] == "lfs" && parts[1] == "extension" {
+		// Only the priority of an extension is safe, not the
+		// commands it runs.
+		return parts[3] == "priority"
+	} else if len(parts) > 1 && parts[0] == "remote" {
+		return len(parts) != 3 || parts[2] == "lfsurl"
+	} else if len(parts) > 2 && parts[len(parts)-1] == "access" {
+		return true
+	}
+	return !keyIsUnsafe(key)
+}
+
 func keyIsUnsafe(key string) bool {
 	for _, safe := range safeKeys {
 		if safe == key {
@@ -186,6 +184,7 @@ func keyIsUnsafe(key string) bool {
 var safeKeys = []string{
 	"lfs.allowincompletepush",
 	"lfs.fetchexclude",
+	"lfs.fetchexcludeext",
 	"lfs.fetchinclude",
 	"lfs.gitprotocol",
 	"lfs.locksverify",
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Setting is a single configuration value which Git LFS may read, along with
+// where it was set.
+type Setting struct {
+	Key   string
+	Value string
+	// Scope is where the value was set: the Git configuration scope, such
+	// as "system", "global", "local", or "worktree", or ".lfsconfig" for
+	// the .lfsconfig file, or "env" for an environment variable.  It is
+	// empty if Git is too old to report the scope of its configuration.
+	Scope string
+	// Origin is the file or blob from which the value was read, as given
+	// by "git config --show-origin", or "environment" for an environment
+	// variable.
+	Origin string
+	// Ignored is true if the value is not read at all, because it is an
+	// unsafe key set in the .lfsconfig file.
+	Ignored bool
+	// Overridden is true if another value set for the same key takes
+	// precedence.
+	Overridden bool
+}
+
+// redactedValue replaces secrets in the values of settings.
+const redactedValue = "[REDACTED]"
+
+// redactedHeaders are the HTTP headers whose values are redacted when set with
+// http.extraHeader.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Settings returns each setting relevant to Git LFS from the Git configuration,
+// the .lfsconfig file, and the environment, with any secrets redacted.  The
+// settings are ordered by key, and the settings for each key from the lowest
+// precedence to the highest.
+func (c *Configuration) Settings() ([]*Setting, error) {
+	entries, err := c.gitConfig.Entries(c.LocalWorkingDir(), ".lfsconfig")
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]*Setting, 0, len(entries))
+	for _, e := range entries {
+		if !isLFSKey(e.Key) {
+			continue
+		}
+
+		s := &Setting{Key: e.Key, Value: e.Value, Scope: e.Scope, Origin: e.Origin}
+		if e.OnlySafeKeys {
+			s.Scope = ".lfsconfig"
+			s.Ignored = !isSafeKey(e.Key)
+		}
+		settings = append(settings, s)
+	}
+
+	for _, kv := range os.Environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(key, "GIT_LFS_") {
+			continue
+		}
+		if val, ok := c.Os.Get(key); ok {
+			settings = append(settings, &Setting{Key: key, Value: val, Scope: "env", Origin: "environment"})
+		}
+	}
+
+	return resolveSettings(settings), nil
+}
+
+// resolveSettings marks each setting which is overridden by a later one for
+// the same key, redacts any secrets, and orders the settings by key, keeping
+// the order of the settings for each key.
+func resolveSettings(settings []*Setting) []*Setting {
+	effective := make(map[string]*Setting)
+	for _, s := range settings {
+		if s.Ignored {
+			continue
+		}
+		if prev, ok := effective[s.Key]; ok {
+			prev.Overridden = true
+		}
+		effective[s.Key] = s
+	}
+
+	for _, s := range settings {
+		s.Key, s.Value = redactSetting(s.Key, s.Value)
+	}
+
+	sort.SliceStable(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+	return settings
+}
+
+// isLFSKey returns whether Git LFS may read the Git configuration key "key".
+func isLFSKey(key string) bool {
+	parts := strings.Split(key, ".")
+	switch parts[0] {
+	case "lfs", "http", "credential":
+		return true
+	case "filter":
+		return len(parts) == 3 && parts[1] == "lfs"
+	case "remote":
+		switch parts[len(parts)-1] {
+		case "lfsurl", "lfspushurl", "url", "pushurl":
+			return len(parts) > 2
+		}
+	}
+	return false
+}
+
+// redactSetting returns the given key and value with any passwords in URLs,
+// credentials in HTTP headers, and values of keys which appear to hold secrets
+// redacted.
+func redactSetting(key, value string) (string, string) {
+	parts := strings.Split(key, ".")
+	if len(parts) > 2 {
+		parts[1] = redactURLString(strings.Join(parts[1:len(parts)-1], "."))
+		parts = []string{parts[0], parts[1], parts[len(parts)-1]}
+		key = strings.Join(parts, ".")
+	}
+
+	name := strings.ToLower(parts[len(parts)-1])
+	for _, secret := range []string{"password", "token", "secret"} {
+		if strings.Contains(name, secret) {
+			return key, redactedValue
+		}
+	}
+
+	if name == "extraheader" {
+		header := strings.SplitN(value, ":", 2)
+		for _, h := range redactedHeaders {
+			if len(header) == 2 && strings.EqualFold(strings.TrimSpace(header[0]), h) {
+				return key, header[0] + ": " + redactedValue
+			}
+		}
+	}
+
+	return key, redactURLString(value)
+}
+
+// redactURLString returns "s" with the user information removed if it is a
+// URL which contains any, since a username alone may be a token, or unchanged
+// otherwise.
+func redactURLString(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	// Substitute the placeholder afterwards, so that its brackets are not
+	// escaped.
+	u.User = url.User("REDACTED")
+	return strings.Replace(u.String(), "//REDACTED@", "//"+redactedValue+"@", 1)
+}
@@ -65,7 +65,7 @@ func main() {
 	out.WriteString("package commands\n\nfunc init() {\n")
 	out.WriteString("\t// THIS FILE IS GENERATED, DO NOT EDIT\n")
 	out.WriteString("\t// Use 'go generate ./commands' to update\n")
-	fileregex := regexp.MustCompile(`git-lfs(?:-([A-Za-z\-]+))?.\d.ronn`)
+	fileregex := regexp.MustCompile(`git-lfs(?:-([A-Za-z\-]+))?.(\d).ronn`)
 	headerregex := regexp.MustCompile(`^###?\s+([A-Za-z0-9\(\) ]+)`)
 	// only pick up caps in links to avoid matching optional args
 	linkregex := regexp.MustCompile(`\[([A-Z\-\(\) ]+)\]`)
@@ -80,6 +80,13 @@ func main() {
 				// This is git-lfs.1.ronn
 				cmd = "git-lfs"
 			}
+			if match[2] != "1" {
+				// Pages outside section 1, such as
+				// git-lfs-config.5.ronn, are named with their
+				// section, so as not to replace the page of a
+				// command of the same name.
+				cmd += "." + match[2]
+			}
 			out.WriteString("\tManPages[\"" + cmd + "\"] = `")
 			contentf, err := os.Open(filepath.Join(manDir, f.Name()))
 			if err != nil {
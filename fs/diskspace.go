@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// freeDiskSpace returns the number of bytes available to unprivileged users on
+// the file system containing the given directory.  It is a variable so that
+// tests can report arbitrary amounts of free space.
+var freeDiskSpace = availableDiskSpace
+
+// CheckFreeSpace returns an error if storing an object of the given size in
+// the LFS storage directory would leave less than MinFreeBytes available on
+// its file system.  If MinFreeBytes is zero, or the amount of free space
+// cannot be determined, no check is made.
+func (f *Filesystem) CheckFreeSpace(size int64) error {
+	if f.MinFreeBytes == 0 {
+		return nil
+	}
+
+	dir := f.LFSStorageDir
+	avail, err := freeDiskSpace(dir)
+	if err != nil {
+		tracerx.Printf("fs: unable to determine free space in %q: %v", dir, err)
+		return nil
+	}
+
+	var needed uint64
+	if size > 0 {
+		needed = uint64(size)
+	}
+	if avail < needed || avail-needed < f.MinFreeBytes {
+		return errors.New(tr.Tr.Get("insufficient disk space in %q: %s is available, but %s is needed to keep %s free",
+			dir,
+			humanize.FormatBytes(avail),
+			humanize.FormatBytes(needed),
+			humanize.FormatBytes(f.MinFreeBytes)))
+	}
+	return nil
+}
+
+// DiskFullError returns an error explaining that an object could not be written
+// to the LFS storage directory because its file system ran out of space, as
+// reported by "err".
+func (f *Filesystem) DiskFullError(err error) error {
+	return &diskFullError{errors.New(tr.Tr.Get("disk full: no space left to write objects to %q: %s\nFree some space, or set lfs.storage to a directory on another file system, and try again.",
+		f.LFSStorageDir, rootCause(err)))}
+}
+
+// diskFullError is the error returned by DiskFullError.
+type diskFullError struct {
+	error
+}
+
+// IsDiskFullError returns whether "err" was caused by a file system having no
+// space left, including an error returned by DiskFullError.
+func IsDiskFullError(err error) bool {
+	if _, ok := errors.Cause(err).(*diskFullError); ok {
+		return true
+	}
+	return isNoSpaceErrno(rootCause(err))
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package fs
+
+import (
+	"syscall"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+func availableDiskSpace(dir string) (uint64, error) {
+	return 0, errors.New(tr.Tr.Get("unsupported platform"))
+}
+
+func isNoSpaceErrno(err error) bool {
+	return err == syscall.ENOSPC
+}
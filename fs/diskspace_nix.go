@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package fs
+
+import "golang.org/x/sys/unix"
+
+func availableDiskSpace(dir string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+func isNoSpaceErrno(err error) bool {
+	return err == unix.ENOSPC || err == unix.EDQUOT
+}
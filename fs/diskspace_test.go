@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubFreeDiskSpace(avail uint64, err error) (dirs *[]string, restore func()) {
+	var queried []string
+
+	old := freeDiskSpace
+	freeDiskSpace = func(dir string) (uint64, error) {
+		queried = append(queried, dir)
+		return avail, err
+	}
+
+	return &queried, func() {
+		freeDiskSpace = old
+	}
+}
+
+func TestCheckFreeSpaceDisabled(t *testing.T) {
+	queried, restore := stubFreeDiskSpace(0, nil)
+	defer restore()
+
+	f := &Filesystem{LFSStorageDir: "lfs"}
+
+	assert.Nil(t, f.CheckFreeSpace(1024))
+	assert.Empty(t, *queried)
+}
+
+func TestCheckFreeSpaceEnough(t *testing.T) {
+	queried, restore := stubFreeDiskSpace(2048, nil)
+	defer restore()
+
+	f := &Filesystem{LFSStorageDir: "lfs", MinFreeBytes: 1024}
+
+	assert.Nil(t, f.CheckFreeSpace(1024))
+	assert.Equal(t, []string{"lfs"}, *queried)
+}
+
+func TestCheckFreeSpaceAccountsForObjectSize(t *testing.T) {
+	_, restore := stubFreeDiskSpace(2048, nil)
+	defer restore()
+
+	f := &Filesystem{LFSStorageDir: "lfs", MinFreeBytes: 1024}
+
+	err := f.CheckFreeSpace(1025)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "insufficient disk space")
+	}
+}
+
+func TestCheckFreeSpaceObjectLargerThanDisk(t *testing.T) {
+	_, restore := stubFreeDiskSpace(100, nil)
+	defer restore()
+
+	f := &Filesystem{LFSStorageDir: "lfs", MinFreeBytes: 1}
+
+	err := f.CheckFreeSpace(200)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "insufficient disk space")
+	}
+}
+
+func TestCheckFreeSpaceUnknown(t *testing.T) {
+	_, restore := stubFreeDiskSpace(0, assert.AnError)
+	defer restore()
+
+	f := &Filesystem{LFSStorageDir: "lfs", MinFreeBytes: 1024}
+
+	assert.Nil(t, f.CheckFreeSpace(1024))
+}
+
+func TestIsDiskFullError(t *testing.T) {
+	full := &os.PathError{Op: "write", Path: "a", Err: syscall.ENOSPC}
+	other := &os.PathError{Op: "write", Path: "a", Err: syscall.EIO}
+
+	assert.True(t, IsDiskFullError(full))
+	assert.True(t, IsDiskFullError(errors.Wrap(full, "wrapped")))
+	assert.False(t, IsDiskFullError(other))
+	assert.False(t, IsDiskFullError(errors.New("other")))
+}
+
+func TestDiskFullError(t *testing.T) {
+	f := &Filesystem{LFSStorageDir: "lfs"}
+
+	err := f.DiskFullError(&os.PathError{Op: "write", Path: "a", Err: syscall.ENOSPC})
+	assert.True(t, IsDiskFullError(err))
+	assert.True(t, IsDiskFullError(errors.Wrap(err, "wrapped")))
+	assert.Contains(t, err.Error(), `disk full: no space left to write objects to "lfs"`)
+}
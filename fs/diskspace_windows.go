@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package fs
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+func availableDiskSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var avail, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &avail, &total, &free); err != nil {
+		return 0, err
+	}
+	return avail, nil
+}
+
+func isNoSpaceErrno(err error) bool {
+	return err == windows.ERROR_DISK_FULL || err == windows.ERROR_HANDLE_DISK_FULL || err == syscall.ENOSPC
+}
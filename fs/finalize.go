@@ -0,0 +1,61 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+var (
+	// syncFile and syncDir flush a file or directory to stable storage.
+	// They are variables so that tests can observe when they are called.
+	syncFile = fsyncFile
+	syncDir  = fsyncDir
+)
+
+// FinalizeObject moves the completed temporary file at src into place at dst,
+// replacing dst if necessary and copying its permissions if it already exists.
+//
+// If Fsync is set, src is flushed to disk before it is renamed and the parent
+// directory of dst is flushed afterwards, so that the object is not lost or
+// truncated if the system crashes shortly after it is written.
+func (f *Filesystem) FinalizeObject(src, dst string) error {
+	if f.Fsync {
+		if err := syncFile(src); err != nil {
+			return errors.Wrap(err, tr.Tr.Get("cannot sync temporary file %q", src))
+		}
+	}
+
+	if err := tools.RenameFileCopyPermissions(src, dst); err != nil {
+		if isNotWritableError(err) {
+			return f.notWritableError(err)
+		}
+		if IsDiskFullError(err) {
+			return f.DiskFullError(err)
+		}
+		return err
+	}
+
+	if f.Fsync {
+		dir := filepath.Dir(dst)
+		if err := syncDir(dir); err != nil {
+			return errors.Wrap(err, tr.Tr.Get("cannot sync object directory %q", dir))
+		}
+	}
+	return nil
+}
+
+func fsyncFile(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
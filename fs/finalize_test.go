@@ -0,0 +1,93 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubSync() (*[]string, func()) {
+	var synced []string
+
+	oldFile, oldDir := syncFile, syncDir
+	syncFile = func(path string) error {
+		synced = append(synced, "file:"+path)
+		return fsyncFile(path)
+	}
+	syncDir = func(path string) error {
+		synced = append(synced, "dir:"+path)
+		return fsyncDir(path)
+	}
+
+	return &synced, func() {
+		syncFile, syncDir = oldFile, oldDir
+	}
+}
+
+func setupFinalize(t *testing.T) (dir, src, dst string) {
+	dir, err := ioutil.TempDir("", "lfs-finalize")
+	require.Nil(t, err)
+
+	src = filepath.Join(dir, "tmp")
+	require.Nil(t, ioutil.WriteFile(src, []byte("contents"), 0644))
+
+	objdir := filepath.Join(dir, "objects", "ab", "cd")
+	require.Nil(t, os.MkdirAll(objdir, 0755))
+	return dir, src, filepath.Join(objdir, "abcd")
+}
+
+func TestFinalizeObjectWithoutFsync(t *testing.T) {
+	synced, restore := stubSync()
+	defer restore()
+	dir, src, dst := setupFinalize(t)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{}
+	require.Nil(t, f.FinalizeObject(src, dst))
+
+	assert.Empty(t, *synced)
+	_, err := os.Stat(src)
+	assert.True(t, os.IsNotExist(err))
+	by, err := ioutil.ReadFile(dst)
+	require.Nil(t, err)
+	assert.Equal(t, "contents", string(by))
+}
+
+func TestFinalizeObjectWithFsync(t *testing.T) {
+	synced, restore := stubSync()
+	defer restore()
+	dir, src, dst := setupFinalize(t)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{Fsync: true}
+	require.Nil(t, f.FinalizeObject(src, dst))
+
+	assert.Equal(t, []string{
+		"file:" + src,
+		"dir:" + filepath.Dir(dst),
+	}, *synced)
+	_, err := os.Stat(src)
+	assert.True(t, os.IsNotExist(err))
+	by, err := ioutil.ReadFile(dst)
+	require.Nil(t, err)
+	assert.Equal(t, "contents", string(by))
+}
+
+func TestFinalizeObjectWithFsyncMissingSource(t *testing.T) {
+	synced, restore := stubSync()
+	defer restore()
+	dir, src, dst := setupFinalize(t)
+	defer os.RemoveAll(dir)
+	require.Nil(t, os.Remove(src))
+
+	f := &Filesystem{Fsync: true}
+	assert.NotNil(t, f.FinalizeObject(src, dst))
+
+	assert.Equal(t, []string{"file:" + src}, *synced)
+	_, err := os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -19,6 +20,14 @@ import (
 	"github.com/rubyist/tracerx"
 )
 
+const (
+	// DefaultShardDepth is the number of levels of directories in which
+	// ShardedObjectKey stores objects.
+	DefaultShardDepth = 2
+	// MaxShardDepth is the greatest depth ShardedObjectKeyDepth accepts.
+	MaxShardDepth = 4
+)
+
 var (
 	oidRE             = regexp.MustCompile(`\A[[:alnum:]]{64}`)
 	EmptyObjectSHA256 = hex.EncodeToString(sha256.New().Sum(nil))
@@ -32,6 +41,43 @@ type Environment interface {
 	Get(key string) (val string, ok bool)
 }
 
+// ObjectKeyFunc derives the key under which an object is stored from its OID.
+// The key is a slash-separated path relative to the objects directory, and
+// must not lead outside of it.  EachObject only finds objects stored in files
+// named after their OIDs.
+type ObjectKeyFunc func(oid string) string
+
+// ShardedObjectKey is the default ObjectKeyFunc, which stores each object in
+// two levels of directories named after the first four characters of its OID.
+func ShardedObjectKey(oid string) string {
+	return path.Join(oid[0:2], oid[2:4], oid)
+}
+
+// ShardedObjectKeyDepth returns an ObjectKeyFunc which stores each object in
+// "depth" levels of directories, each named after the next two characters of
+// its OID.  A depth of DefaultShardDepth gives the layout of ShardedObjectKey,
+// and a depth of zero that of FlatObjectKey.  It panics if "depth" is not
+// between zero and MaxShardDepth.
+func ShardedObjectKeyDepth(depth int) ObjectKeyFunc {
+	if depth < 0 || depth > MaxShardDepth {
+		panic(tr.Tr.Get("invalid shard depth %d", depth))
+	}
+
+	return func(oid string) string {
+		parts := make([]string, 0, depth+1)
+		for i := 0; i < depth && len(oid) >= 2*i+2; i++ {
+			parts = append(parts, oid[2*i:2*i+2])
+		}
+		return path.Join(append(parts, oid)...)
+	}
+}
+
+// FlatObjectKey is an ObjectKeyFunc which stores every object directly in the
+// objects directory.
+func FlatObjectKey(oid string) string {
+	return oid
+}
+
 // Object represents a locally stored LFS object.
 type Object struct {
 	Oid  string
@@ -39,14 +85,19 @@ type Object struct {
 }
 
 type Filesystem struct {
-	GitStorageDir string   // parent of objects/lfs (may be same as GitDir but may not)
-	LFSStorageDir string   // parent of lfs objects and tmp dirs. Default: ".git/lfs"
-	ReferenceDirs []string // alternative local media dirs (relative to clone reference repo)
-	lfsobjdir     string
-	tmpdir        string
-	logdir        string
-	repoPerms     os.FileMode
-	mu            sync.Mutex
+	GitStorageDir  string        // parent of objects/lfs (may be same as GitDir but may not)
+	LFSStorageDir  string        // parent of lfs objects and tmp dirs. Default: ".git/lfs"
+	ReferenceDirs  []string      // alternative local media dirs (relative to clone reference repo)
+	Fsync          bool          // whether to flush objects to disk when finalizing them
+	MinFreeBytes   uint64        // free space to keep when downloading objects, or zero
+	HashBufferSize int           // buffer size for hashing objects, or zero for the default
+	ObjectKey      ObjectKeyFunc // maps OIDs to where objects are stored, or nil for ShardedObjectKey
+	lfsobjdir      string
+	tmpdir         string
+	logdir         string
+	repoPerms      os.FileMode
+	writable       bool // whether CheckWritable has succeeded
+	mu             sync.Mutex
 }
 
 func (f *Filesystem) EachObject(fn func(Object) error) error {
@@ -74,24 +125,42 @@ func (f *Filesystem) ObjectExists(oid string, size int64) bool {
 }
 
 func (f *Filesystem) ObjectPath(oid string) (string, error) {
+	oid = strings.ToLower(oid)
 	if len(oid) < 4 {
 		return "", errors.New(tr.Tr.Get("too short object ID: %q", oid))
 	}
 	if oid == EmptyObjectSHA256 {
 		return os.DevNull, nil
 	}
-	dir := f.localObjectDir(oid)
+	key := f.objectKey(oid)
+	if clean := path.Clean(key); len(key) == 0 || path.IsAbs(key) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.New(tr.Tr.Get("invalid storage key %q for object %q", key, oid))
+	}
+
+	pathname := filepath.Join(f.LFSObjectDir(), filepath.FromSlash(key))
+	dir := filepath.Dir(pathname)
 	if err := tools.MkdirAll(dir, f); err != nil {
+		if isNotWritableError(err) {
+			return "", f.notWritableError(err)
+		}
 		return "", errors.New(tr.Tr.Get("error trying to create local storage directory in %q: %s", dir, err))
 	}
-	return filepath.Join(dir, oid), nil
+	return pathname, nil
 }
 
 func (f *Filesystem) ObjectPathname(oid string) string {
+	oid = strings.ToLower(oid)
 	if oid == EmptyObjectSHA256 {
 		return os.DevNull
 	}
-	return filepath.Join(f.localObjectDir(oid), oid)
+	return filepath.Join(f.LFSObjectDir(), filepath.FromSlash(f.objectKey(oid)))
+}
+
+func (f *Filesystem) objectKey(oid string) string {
+	if f.ObjectKey == nil {
+		return ShardedObjectKey(oid)
+	}
+	return f.ObjectKey(oid)
 }
 
 func (f *Filesystem) DecodePathname(path string) string {
@@ -137,18 +206,15 @@ func DecodePathBytes(path []byte) []byte {
 	return buffer.Bytes()
 }
 
-func (f *Filesystem) localObjectDir(oid string) string {
-	return filepath.Join(f.LFSObjectDir(), oid[0:2], oid[2:4])
-}
-
 func (f *Filesystem) ObjectReferencePaths(oid string) []string {
 	if len(f.ReferenceDirs) == 0 {
 		return nil
 	}
 
 	var paths []string
+	// Other repositories use the default layout, whatever this one uses.
 	for _, ref := range f.ReferenceDirs {
-		paths = append(paths, filepath.Join(ref, oid[0:2], oid[2:4], oid))
+		paths = append(paths, filepath.Join(ref, filepath.FromSlash(ShardedObjectKey(oid))))
 	}
 	return paths
 }
@@ -1,10 +1,16 @@
 package fs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecodeNone(t *testing.T) {
@@ -42,3 +48,117 @@ func TestRepositoryPermissions(t *testing.T) {
 		assert.Equal(t, v, fs.RepositoryPermissions(false))
 	}
 }
+
+func TestObjectKeyDefaultsToShardedLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-object-key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir}
+	oid := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"
+
+	expected := filepath.Join(dir, "objects", "ab", "cd", oid)
+	assert.Equal(t, expected, f.ObjectPathname(oid))
+
+	p, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	assert.Equal(t, expected, p)
+	assert.DirExists(t, filepath.Dir(expected))
+}
+
+func TestObjectPathLowersUpperCaseOid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-object-key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir}
+	oid := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"
+
+	expected := filepath.Join(dir, "objects", "ab", "cd", oid)
+	assert.Equal(t, expected, f.ObjectPathname(strings.ToUpper(oid)))
+
+	p, err := f.ObjectPath(strings.ToUpper(oid))
+	require.Nil(t, err)
+	assert.Equal(t, expected, p)
+
+	assert.Equal(t, os.DevNull, f.ObjectPathname(strings.ToUpper(EmptyObjectSHA256)))
+}
+
+func TestObjectKeyCustomRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-object-key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Store objects by a hash of their OID, as some shared caches do.
+	f := &Filesystem{
+		LFSStorageDir: dir,
+		ObjectKey: func(oid string) string {
+			sum := sha256.Sum256([]byte(oid))
+			key := hex.EncodeToString(sum[:])
+			return "by-hash/" + key[0:2] + "/" + key
+		},
+	}
+
+	contents := []byte("custom key contents")
+	sum := sha256.Sum256(contents)
+	oid := hex.EncodeToString(sum[:])
+
+	keySum := sha256.Sum256([]byte(oid))
+	key := hex.EncodeToString(keySum[:])
+	expected := filepath.Join(dir, "objects", "by-hash", key[0:2], key)
+
+	assert.False(t, f.ObjectExists(oid, int64(len(contents))))
+
+	p, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	assert.Equal(t, expected, p)
+	require.Nil(t, ioutil.WriteFile(p, contents, 0644))
+
+	assert.Equal(t, expected, f.ObjectPathname(oid))
+	assert.True(t, f.ObjectExists(oid, int64(len(contents))))
+
+	read, err := ioutil.ReadFile(f.ObjectPathname(oid))
+	require.Nil(t, err)
+	assert.Equal(t, contents, read)
+
+	// Nothing is stored at the default location.
+	_, err = os.Stat(filepath.Join(dir, "objects", oid[0:2], oid[2:4], oid))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestObjectKeyFlat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-object-key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, ObjectKey: FlatObjectKey}
+	oid := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"
+
+	p, err := f.ObjectPath(oid)
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "objects", oid), p)
+	require.Nil(t, ioutil.WriteFile(p, []byte("flat"), 0644))
+
+	var found []Object
+	require.Nil(t, f.EachObject(func(obj Object) error {
+		found = append(found, obj)
+		return nil
+	}))
+	assert.Equal(t, []Object{{Oid: oid, Size: 4}}, found)
+}
+
+func TestObjectKeyRejectsKeysOutsideObjectDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-object-key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	oid := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"
+	for _, key := range []string{"", ".", "..", "../" + oid, "a/../../" + oid, "/tmp/" + oid} {
+		f := &Filesystem{
+			LFSStorageDir: dir,
+			ObjectKey:     func(string) string { return key },
+		}
+		_, err := f.ObjectPath(oid)
+		assert.NotNil(t, err, key)
+	}
+}
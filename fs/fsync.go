@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package fs
+
+import "os"
+
+func fsyncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err := dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+	return dir.Close()
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package fs
+
+// fsyncDir is a no-op on Windows, where directories cannot be opened for
+// flushing and renames are made durable by the file system itself.
+func fsyncDir(path string) error {
+	return nil
+}
@@ -0,0 +1,168 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// legacyStoreDirs are the directories, relative to the Git storage directory,
+// in which versions of Git LFS before v0.5.2 stored objects, and which the
+// current layout never looks in.
+var legacyStoreDirs = []string{"media"}
+
+// LegacyObject is an object stored in a legacy layout, rather than in the
+// objects directory.
+type LegacyObject struct {
+	Object
+	Path string
+}
+
+// LegacyObjects returns each object stored in a legacy layout, in the order of
+// their paths.
+func (f *Filesystem) LegacyObjects() ([]LegacyObject, error) {
+	var objects []LegacyObject
+	for _, dir := range f.legacyStoreDirs() {
+		found, _, err := f.legacyObjectsIn(dir)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, found...)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Path < objects[j].Path
+	})
+	return objects, nil
+}
+
+// LegacyObjectPathname returns the path of the object "oid" if it is stored in
+// a legacy layout, or the empty string if it is not.
+func (f *Filesystem) LegacyObjectPathname(oid string) string {
+	for _, dir := range f.legacyStoreDirs() {
+		for _, key := range []ObjectKeyFunc{ShardedObjectKey, FlatObjectKey} {
+			path := filepath.Join(dir, filepath.FromSlash(key(oid)))
+			if tools.FileExists(path) {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// MigrateLegacyObjects moves each object stored in a legacy layout into the
+// objects directory, where the current layout expects it, and then removes any
+// legacy directories left empty.  Objects which do not hash to their OIDs are
+// left where they are.  It returns the number of objects which it moved.
+func (f *Filesystem) MigrateLegacyObjects() (int, error) {
+	n := 0
+	for _, dir := range f.legacyStoreDirs() {
+		objects, dirs, err := f.legacyObjectsIn(dir)
+		if err != nil {
+			return n, err
+		}
+
+		for _, obj := range objects {
+			ok, err := legacyObjectMatches(obj)
+			if err != nil {
+				return n, err
+			}
+			if !ok {
+				tracerx.Printf("fs: keeping %s, which does not match its OID", obj.Path)
+				continue
+			}
+
+			dest, err := f.ObjectPath(obj.Oid)
+			if err != nil {
+				return n, err
+			}
+			if !tools.FileExistsOfSize(dest, obj.Size) {
+				if err := f.linkObject(obj.Path, dest); err != nil {
+					return n, errors.Wrap(err, tr.Tr.Get("cannot move object %s", obj.Oid))
+				}
+			}
+			if err := os.Remove(obj.Path); err != nil && !os.IsNotExist(err) {
+				return n, errors.Wrap(err, tr.Tr.Get("cannot remove %q", obj.Path))
+			}
+			n++
+		}
+
+		// As in RemoveObjectsNotAt, remove the deepest directories
+		// first, and keep those which are not empty.
+		sort.Slice(dirs, func(i, j int) bool {
+			return len(dirs[i]) > len(dirs[j])
+		})
+		for _, d := range dirs {
+			os.Remove(d)
+		}
+		os.Remove(dir)
+	}
+	return n, nil
+}
+
+// legacyStoreDirs returns each legacy store directory which exists.
+func (f *Filesystem) legacyStoreDirs() []string {
+	var dirs []string
+	for _, name := range legacyStoreDirs {
+		dir := filepath.Join(f.GitStorageDir, name)
+		if tools.DirExists(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// legacyObjectsIn returns each file beneath "root" which is named after an
+// OID, however deeply it is nested, and each directory beneath "root".
+func (f *Filesystem) legacyObjectsIn(root string) ([]LegacyObject, []string, error) {
+	var (
+		objects []LegacyObject
+		dirs    []string
+		walkErr error
+	)
+	tools.FastWalkDir(root, func(parentDir string, info os.FileInfo, err error) {
+		if err != nil {
+			walkErr = err
+			return
+		}
+		if walkErr != nil || len(parentDir) == 0 {
+			return
+		}
+
+		path := filepath.Join(parentDir, info.Name())
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return
+		}
+		if len(info.Name()) == 64 && oidRE.MatchString(info.Name()) {
+			objects = append(objects, LegacyObject{
+				Object: Object{Oid: info.Name(), Size: info.Size()},
+				Path:   path,
+			})
+		}
+	})
+	return objects, dirs, walkErr
+}
+
+// legacyObjectMatches returns whether the contents of "obj" hash to its OID.
+func legacyObjectMatches(obj LegacyObject) (bool, error) {
+	file, err := os.Open(obj.Path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(h.Sum(nil)) == obj.Oid, nil
+}
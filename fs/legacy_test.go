@@ -0,0 +1,99 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLegacyObject(t *testing.T, dir, key string, contents []byte) string {
+	path := filepath.Join(dir, "media", filepath.FromSlash(key))
+	require.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.Nil(t, ioutil.WriteFile(path, contents, 0644))
+	return path
+}
+
+func legacyOid(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLegacyObjectsMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-legacy")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: dir, LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	sharded := []byte("sharded")
+	flat := []byte("flat")
+	shardedOid := legacyOid(sharded)
+	flatOid := legacyOid(flat)
+	shardedPath := writeLegacyObject(t, dir, ShardedObjectKey(shardedOid), sharded)
+	flatPath := writeLegacyObject(t, dir, flatOid, flat)
+
+	// A corrupt object is named after the OID of other contents.
+	corruptOid := legacyOid([]byte("other"))
+	corruptPath := writeLegacyObject(t, dir, ShardedObjectKey(corruptOid), []byte("corrupt"))
+
+	objects, err := f.LegacyObjects()
+	require.Nil(t, err)
+	assert.Len(t, objects, 3)
+
+	assert.Equal(t, shardedPath, f.LegacyObjectPathname(shardedOid))
+	assert.Equal(t, flatPath, f.LegacyObjectPathname(flatOid))
+	assert.False(t, f.ObjectExists(shardedOid, int64(len(sharded))))
+
+	n, err := f.MigrateLegacyObjects()
+	require.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	assert.True(t, f.ObjectExists(shardedOid, int64(len(sharded))))
+	assert.True(t, f.ObjectExists(flatOid, int64(len(flat))))
+	assert.False(t, f.ObjectExists(corruptOid, int64(len("corrupt"))))
+
+	assert.NoFileExists(t, shardedPath)
+	assert.NoFileExists(t, flatPath)
+	assert.FileExists(t, corruptPath)
+	assert.Empty(t, f.LegacyObjectPathname(shardedOid))
+
+	objects, err = f.LegacyObjects()
+	require.Nil(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, corruptOid, objects[0].Oid)
+}
+
+func TestLegacyObjectsEmptyStoreRemoved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-legacy")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: dir, LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	contents := []byte("object")
+	writeLegacyObject(t, dir, ShardedObjectKey(legacyOid(contents)), contents)
+
+	n, err := f.MigrateLegacyObjects()
+	require.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.NoDirExists(t, filepath.Join(dir, "media"))
+}
+
+func TestLegacyObjectsNoStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-legacy")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{GitStorageDir: dir, LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	objects, err := f.LegacyObjects()
+	require.Nil(t, err)
+	assert.Empty(t, objects)
+	assert.Empty(t, f.LegacyObjectPathname(legacyOid([]byte("object"))))
+}
@@ -0,0 +1,34 @@
+package fs
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// QuarantineDir returns the directory in which downloads whose contents did
+// not match their OID are kept, so that they can be inspected.
+func (f *Filesystem) QuarantineDir() string {
+	return filepath.Join(f.LFSStorageDir, "quarantine")
+}
+
+// QuarantineObject moves the file at "path", which was downloaded as the
+// object "oid" but failed verification, into QuarantineDir and returns its
+// new path.  Each file is given a distinct name, so that repeated bad
+// downloads of the same object are all kept.
+func (f *Filesystem) QuarantineObject(path, oid string) (string, error) {
+	dir := f.QuarantineDir()
+	if err := tools.MkdirAll(dir, f); err != nil {
+		return "", errors.Wrap(err, tr.Tr.Get("cannot create quarantine directory %q", dir))
+	}
+
+	dest := filepath.Join(dir, fmt.Sprintf("%s-%d", oid, time.Now().UnixNano()))
+	if err := tools.RobustRename(path, dest); err != nil {
+		return "", errors.Wrap(err, tr.Tr.Get("cannot quarantine %q", path))
+	}
+	return dest, nil
+}
@@ -0,0 +1,50 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineObjectKeepsEachCopy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-quarantine")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+	oid := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	var quarantined []string
+	for _, contents := range []string{"first", "second"} {
+		path := filepath.Join(dir, contents)
+		require.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+
+		dest, err := f.QuarantineObject(path, oid)
+		require.Nil(t, err)
+		assert.Equal(t, f.QuarantineDir(), filepath.Dir(dest))
+		assert.NoFileExists(t, path)
+
+		data, err := ioutil.ReadFile(dest)
+		require.Nil(t, err)
+		assert.Equal(t, contents, string(data))
+
+		quarantined = append(quarantined, dest)
+	}
+
+	assert.NotEqual(t, quarantined[0], quarantined[1])
+}
+
+func TestQuarantineObjectMissingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-quarantine")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	_, err = f.QuarantineObject(filepath.Join(dir, "missing"), "oid")
+	assert.NotNil(t, err)
+}
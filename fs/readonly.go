@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// CheckWritable returns an error naming the LFS storage directory if objects
+// cannot be written to it, for example because its file system is mounted
+// read-only, rather than letting a download fail later with a low-level error
+// from renaming the finished object into place.
+//
+// The check creates and removes a temporary file.  Once it has succeeded, it
+// is not repeated.
+func (f *Filesystem) CheckWritable() error {
+	f.mu.Lock()
+	writable := f.writable
+	f.mu.Unlock()
+	if writable {
+		return nil
+	}
+
+	dir := f.TempDir()
+	if err := tools.MkdirAll(dir, f); err != nil {
+		return f.notWritableError(err)
+	}
+
+	file, err := ioutil.TempFile(dir, "write-check")
+	if err != nil {
+		return f.notWritableError(err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	f.mu.Lock()
+	f.writable = true
+	f.mu.Unlock()
+	return nil
+}
+
+// notWritableError returns an error explaining that the LFS storage directory
+// cannot be written to because of "err".
+func (f *Filesystem) notWritableError(err error) error {
+	return errors.New(tr.Tr.Get("Git LFS object store %q is not writable: %s\nCheck that its file system is not mounted read-only, and that you have permission to write to it.",
+		f.LFSStorageDir, rootCause(err)))
+}
+
+// isNotWritableError returns whether "err" was caused by a read-only file
+// system or by a lack of permission to write.
+func isNotWritableError(err error) bool {
+	return rootCause(err) == syscall.EROFS || os.IsPermission(rootCause(err))
+}
+
+// rootCause returns the error underlying "err", with any wrapping by the
+// errors package or by *os.PathError, *os.LinkError, or *os.SyscallError
+// removed.
+func rootCause(err error) error {
+	err = errors.Cause(err)
+	for {
+		switch e := err.(type) {
+		case *os.PathError:
+			err = e.Err
+		case *os.LinkError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		default:
+			return err
+		}
+	}
+}
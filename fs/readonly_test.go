@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNotWritableError(t *testing.T) {
+	rofs := &os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EROFS}
+	perm := &os.PathError{Op: "mkdir", Path: "a", Err: syscall.EACCES}
+	other := &os.PathError{Op: "mkdir", Path: "a", Err: syscall.ENOSPC}
+
+	assert.True(t, isNotWritableError(rofs))
+	assert.True(t, isNotWritableError(errors.Wrap(rofs, "wrapped")))
+	assert.True(t, isNotWritableError(perm))
+	assert.False(t, isNotWritableError(other))
+	assert.False(t, isNotWritableError(errors.New("other")))
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-writable")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: filepath.Join(dir, "lfs"), repoPerms: 0644}
+
+	assert.Nil(t, f.CheckWritable())
+
+	files, err := ioutil.ReadDir(f.TempDir())
+	require.Nil(t, err)
+	assert.Empty(t, files)
+}
+
+func TestCheckWritableReadOnlyStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions do not prevent writes on Windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions do not prevent writes by root")
+	}
+
+	dir, err := ioutil.TempDir("", "fs-readonly")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	lfsdir := filepath.Join(dir, "lfs")
+	require.Nil(t, os.Mkdir(lfsdir, 0755))
+	require.Nil(t, os.Chmod(lfsdir, 0555))
+	defer os.Chmod(lfsdir, 0755)
+
+	f := &Filesystem{LFSStorageDir: lfsdir, repoPerms: 0644}
+
+	err = f.CheckWritable()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Git LFS object store")
+		assert.Contains(t, err.Error(), lfsdir)
+		assert.Contains(t, err.Error(), "not writable")
+	}
+
+	_, err = f.ObjectPath("3fa8b8111d2a4b547b6c3ca1a1da2d225bdc8e6e541a5a6d2a5090f4ba9c4540")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not writable")
+	}
+}
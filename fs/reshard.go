@@ -0,0 +1,185 @@
+package fs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// Moving the objects in a store to the layout of another ObjectKeyFunc is done
+// in three steps, so that every object can be found however far it has got:
+//
+//   1. StoreObjectsAt links each object into its new location, leaving it in
+//      its old one, where lookups made with the old layout still find it.
+//   2. The caller switches lookups to the new layout, such as by changing
+//      lfs.storage.sharddepth.
+//   3. RemoveObjectsNotAt removes each object from its old location.
+//
+// Both steps which change the store may be repeated, and an interrupted step
+// is finished by running it again.
+
+// storedObject is a file in the objects directory named after an OID, which
+// may or may not be where the current layout expects it.
+type storedObject struct {
+	Object
+	path string
+}
+
+// StoreObjectsAt makes sure that every object in the objects directory is also
+// stored where "key" says it should be, by hard linking it there, or copying it
+// if it cannot be linked.  It returns the number of objects which it stored.
+func (f *Filesystem) StoreObjectsAt(key ObjectKeyFunc) (int, error) {
+	objects, _, err := f.storedObjects()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, obj := range objects {
+		dest := f.objectPathAt(key, obj.Oid)
+		if dest == obj.path || tools.FileExistsOfSize(dest, obj.Size) {
+			continue
+		}
+
+		if err := f.linkObject(obj.path, dest); err != nil {
+			return n, errors.Wrap(err, tr.Tr.Get("cannot move object %s", obj.Oid))
+		}
+		n++
+	}
+	return n, nil
+}
+
+// RemoveObjectsNotAt removes each object in the objects directory which is not
+// where "key" says it should be, provided that it is also stored there, and
+// then any directories left empty.  It returns the number of objects which it
+// removed.
+func (f *Filesystem) RemoveObjectsNotAt(key ObjectKeyFunc) (int, error) {
+	objects, dirs, err := f.storedObjects()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, obj := range objects {
+		dest := f.objectPathAt(key, obj.Oid)
+		if dest == obj.path {
+			continue
+		}
+		if !tools.FileExistsOfSize(dest, obj.Size) {
+			tracerx.Printf("fs: keeping %s, which is not stored at %s", obj.path, dest)
+			continue
+		}
+
+		if err := os.Remove(obj.path); err != nil && !os.IsNotExist(err) {
+			return n, errors.Wrap(err, tr.Tr.Get("cannot remove %q", obj.path))
+		}
+		n++
+	}
+
+	// Remove the deepest directories first, so that their parents may
+	// then be empty too.  Directories which are not empty are kept.
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, dir := range dirs {
+		os.Remove(dir)
+	}
+	return n, nil
+}
+
+// storedObjects returns each file in the objects directory which is named
+// after an OID, and each directory beneath it.
+func (f *Filesystem) storedObjects() ([]storedObject, []string, error) {
+	root := f.LFSObjectDir()
+
+	var (
+		objects []storedObject
+		dirs    []string
+		walkErr error
+	)
+	tools.FastWalkDir(root, func(parentDir string, info os.FileInfo, err error) {
+		if err != nil {
+			walkErr = err
+			return
+		}
+		if walkErr != nil {
+			return
+		}
+
+		// The objects directory itself is given with no parent.
+		if len(parentDir) == 0 {
+			return
+		}
+
+		path := filepath.Join(parentDir, info.Name())
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return
+		}
+		if len(info.Name()) == 64 && oidRE.MatchString(info.Name()) {
+			objects = append(objects, storedObject{
+				Object: Object{Oid: info.Name(), Size: info.Size()},
+				path:   path,
+			})
+		}
+	})
+	return objects, dirs, walkErr
+}
+
+// objectPathAt returns where "key" says the object "oid" should be stored.
+func (f *Filesystem) objectPathAt(key ObjectKeyFunc, oid string) string {
+	return filepath.Join(f.LFSObjectDir(), filepath.FromSlash(key(oid)))
+}
+
+// linkObject stores the object at "src" at "dest" too, replacing anything
+// already there.
+func (f *Filesystem) linkObject(src, dest string) error {
+	dir := filepath.Dir(dest)
+	if err := tools.MkdirAll(dir, f); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("cannot create directory %q", dir))
+	}
+
+	// A partial copy may have been left by an earlier attempt which did
+	// not finish, and would stop the link from being made.
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	// The object could not be linked, perhaps because the filesystem does
+	// not support hard links, so copy it instead, through a temporary file
+	// so that no partial copy is ever left at "dest".
+	tmp, err := ioutil.TempFile(f.TempDir(), filepath.Base(dest)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	in, err := os.Open(src)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	_, err = io.Copy(tmp, in)
+	in.Close()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), f.RepositoryPermissions(false)); err != nil {
+		return err
+	}
+	return tools.RobustRename(tmp.Name(), dest)
+}
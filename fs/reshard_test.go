@@ -0,0 +1,148 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReshardObjects(t *testing.T, f *Filesystem, n int) []string {
+	var oids []string
+	for i := 0; i < n; i++ {
+		contents := []byte(fmt.Sprintf("object %d", i))
+		sum := sha256.Sum256(contents)
+		oid := hex.EncodeToString(sum[:])
+
+		path, err := f.ObjectPath(oid)
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(path, contents, 0644))
+		oids = append(oids, oid)
+	}
+	return oids
+}
+
+func assertObjectsResolve(t *testing.T, f *Filesystem, oids []string) {
+	for _, oid := range oids {
+		assert.True(t, f.ObjectExists(oid, int64(len("object 0"))), "object %s", oid)
+	}
+}
+
+func TestReshardBetweenDepths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-reshard")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	oids := writeReshardObjects(t, f, 5)
+
+	for _, depth := range []int{3, 0, 4, DefaultShardDepth} {
+		oldKey := f.objectKey
+		key := ShardedObjectKeyDepth(depth)
+
+		n, err := f.StoreObjectsAt(key)
+		require.Nil(t, err)
+		assert.Equal(t, len(oids), n, "depth %d", depth)
+
+		// Until lookups are switched, objects resolve where they were.
+		assertObjectsResolve(t, f, oids)
+		for _, oid := range oids {
+			assert.FileExists(t, filepath.Join(dir, "objects", filepath.FromSlash(oldKey(oid))))
+			assert.FileExists(t, filepath.Join(dir, "objects", filepath.FromSlash(key(oid))))
+		}
+
+		f.ObjectKey = key
+		n, err = f.RemoveObjectsNotAt(key)
+		require.Nil(t, err)
+		assert.Equal(t, len(oids), n, "depth %d", depth)
+
+		assertObjectsResolve(t, f, oids)
+		objects, dirs, err := f.storedObjects()
+		require.Nil(t, err)
+		assert.Len(t, objects, len(oids))
+		for _, obj := range objects {
+			assert.Equal(t, f.ObjectPathname(obj.Oid), obj.path)
+		}
+		if depth == 0 {
+			assert.Empty(t, dirs)
+		}
+	}
+
+	assert.Equal(t, filepath.Join(dir, "objects", oids[0][0:2], oids[0][2:4], oids[0]),
+		f.ObjectPathname(oids[0]))
+}
+
+func TestReshardIsIdempotent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-reshard")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	oids := writeReshardObjects(t, f, 3)
+	key := ShardedObjectKeyDepth(3)
+
+	_, err = f.StoreObjectsAt(key)
+	require.Nil(t, err)
+	_, err = f.RemoveObjectsNotAt(key)
+	require.Nil(t, err)
+
+	n, err := f.StoreObjectsAt(key)
+	require.Nil(t, err)
+	assert.Equal(t, 0, n)
+	n, err = f.RemoveObjectsNotAt(key)
+	require.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	f.ObjectKey = key
+	assertObjectsResolve(t, f, oids)
+}
+
+func TestReshardResumesAfterInterruption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-reshard")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	oids := writeReshardObjects(t, f, 3)
+	key := ShardedObjectKeyDepth(1)
+
+	// An interrupted run may leave a partial copy in the new location,
+	// which must be replaced rather than trusted.
+	partial := filepath.Join(dir, "objects", filepath.FromSlash(key(oids[0])))
+	require.Nil(t, os.MkdirAll(filepath.Dir(partial), 0755))
+	require.Nil(t, ioutil.WriteFile(partial, []byte("obj"), 0644))
+
+	// Removing objects before they are stored elsewhere keeps them.
+	n, err := f.RemoveObjectsNotAt(key)
+	require.Nil(t, err)
+	assert.Equal(t, 0, n)
+	assertObjectsResolve(t, f, oids)
+
+	n, err = f.StoreObjectsAt(key)
+	require.Nil(t, err)
+	assert.Equal(t, len(oids), n)
+
+	f.ObjectKey = key
+	_, err = f.RemoveObjectsNotAt(key)
+	require.Nil(t, err)
+	assertObjectsResolve(t, f, oids)
+
+	contents, err := ioutil.ReadFile(partial)
+	require.Nil(t, err)
+	assert.Equal(t, "object 0", string(contents))
+}
+
+func TestShardedObjectKeyDepth(t *testing.T) {
+	oid := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"
+
+	assert.Equal(t, FlatObjectKey(oid), ShardedObjectKeyDepth(0)(oid))
+	assert.Equal(t, ShardedObjectKey(oid), ShardedObjectKeyDepth(DefaultShardDepth)(oid))
+	assert.Equal(t, "ab/cd/ef/"+oid, ShardedObjectKeyDepth(3)(oid))
+	assert.Panics(t, func() { ShardedObjectKeyDepth(MaxShardDepth + 1) })
+}
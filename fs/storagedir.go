@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// VerifyStorageDir checks the LFS storage directory given by lfs.storage before
+// it is used, so that a mistyped path or a volume which is not mounted is
+// reported, rather than objects being written to a directory created in the
+// wrong place.
+//
+// If the directory does not exist but its parent does, as on first use, it is
+// created.  If its parent does not exist either, it is only created, along
+// with its parent, if "create" is true; otherwise an error is returned.
+func (f *Filesystem) VerifyStorageDir(create bool) error {
+	dir := f.LFSStorageDir
+	fi, err := os.Stat(dir)
+	if err == nil {
+		if !fi.IsDir() {
+			return errors.New(tr.Tr.Get("Git LFS storage directory %q is not a directory.\nCheck the value of lfs.storage.", dir))
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return errors.Wrap(err, tr.Tr.Get("Could not check Git LFS storage directory %q", dir))
+	}
+
+	parent := filepath.Dir(dir)
+	if _, err := os.Stat(parent); os.IsNotExist(err) && !create {
+		return errors.New(tr.Tr.Get("Git LFS storage directory %q does not exist, and neither does %q.\nIf it is on a volume which is not mounted, mount it and try again.  Otherwise, check the value of lfs.storage, or run the command again with --create-store to create both.", dir, parent))
+	}
+
+	tracerx.Printf("creating Git LFS storage directory %q", dir)
+	if err := tools.MkdirAll(dir, f); err != nil {
+		if isNotWritableError(err) {
+			return errors.New(tr.Tr.Get("Git LFS storage directory %q does not exist, and could not be created: %s\nCheck that you have permission to write to %q, or change the value of lfs.storage.", dir, rootCause(err), parent))
+		}
+		return errors.Wrap(err, tr.Tr.Get("Could not create Git LFS storage directory %q", dir))
+	}
+	return nil
+}
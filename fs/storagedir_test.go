@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyStorageDirExisting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-storage-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir, repoPerms: 0644}
+	assert.Nil(t, f.VerifyStorageDir(false))
+}
+
+func TestVerifyStorageDirFirstUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-storage-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	storage := filepath.Join(dir, "lfs")
+	f := &Filesystem{LFSStorageDir: storage, repoPerms: 0644}
+	require.Nil(t, f.VerifyStorageDir(false))
+
+	fi, err := os.Stat(storage)
+	require.Nil(t, err)
+	assert.True(t, fi.IsDir())
+}
+
+func TestVerifyStorageDirMissingParent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-storage-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	storage := filepath.Join(dir, "volume", "lfs")
+	f := &Filesystem{LFSStorageDir: storage, repoPerms: 0644}
+
+	err = f.VerifyStorageDir(false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "does not exist, and neither does")
+	assert.Contains(t, err.Error(), "--create-store")
+	_, err = os.Stat(filepath.Join(dir, "volume"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.Nil(t, f.VerifyStorageDir(true))
+	fi, err := os.Stat(storage)
+	require.Nil(t, err)
+	assert.True(t, fi.IsDir())
+}
+
+func TestVerifyStorageDirNotADirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-storage-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	storage := filepath.Join(dir, "lfs")
+	require.Nil(t, ioutil.WriteFile(storage, []byte("not a directory"), 0644))
+	f := &Filesystem{LFSStorageDir: storage, repoPerms: 0644}
+
+	err = f.VerifyStorageDir(true)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not a directory")
+}
+
+func TestVerifyStorageDirParentNotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+
+	dir, err := ioutil.TempDir("", "lfs-storage-dir")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	parent := filepath.Join(dir, "readonly")
+	require.Nil(t, os.Mkdir(parent, 0555))
+	defer os.Chmod(parent, 0755)
+
+	f := &Filesystem{LFSStorageDir: filepath.Join(parent, "lfs"), repoPerms: 0644}
+
+	err = f.VerifyStorageDir(false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "could not be created")
+}
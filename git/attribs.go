@@ -1,14 +1,20 @@
 package git
 
 import (
+	"encoding/hex"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
 	"github.com/git-lfs/git-lfs/v3/git/gitattr"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
 	"github.com/rubyist/tracerx"
 )
 
@@ -17,6 +23,18 @@ const (
 	FilterAttrib   = "filter"
 )
 
+var (
+	// legacyFilterNames are the names other than "lfs" given to the
+	// filter, diff, and merge attributes of files handled by Git LFS by
+	// its predecessors, git-media and Hawser.
+	legacyFilterNames = []string{"media", "hawser"}
+
+	// lfsAttributeLine is the set of attributes which "git lfs track"
+	// gives a pattern, and to which UpgradeAttributeLine upgrades legacy
+	// ones.
+	lfsAttributeLine = []string{"filter=lfs", "diff=lfs", "merge=lfs", "-text"}
+)
+
 // AttributePath is a path entry in a gitattributes file which has the LFS filter
 type AttributePath struct {
 	// Path entry in the attribute file
@@ -27,6 +45,9 @@ type AttributePath struct {
 	Lockable bool
 	// Path is handled by Git LFS (i.e., filter=lfs)
 	Tracked bool
+	// Path is handled by Git LFS, but with legacy attributes, such as
+	// filter=media or -crlf, which UpgradeAttributeLine would replace
+	Legacy bool
 }
 
 type AttributeSource struct {
@@ -46,24 +67,45 @@ func (s *AttributeSource) String() string {
 // GetRootAttributePaths beahves as GetRootAttributePaths, and loads information
 // only from the global gitattributes file.
 func GetRootAttributePaths(mp *gitattr.MacroProcessor, cfg Env) []AttributePath {
+	af := rootAttributesFile(cfg)
+	if len(af) == 0 {
+		return nil
+	}
+
+	// The working directory for the root gitattributes file is blank.
+	return attrPathsFromFile(mp, af, "", true)
+}
+
+// rootAttributesFile returns the path of the global gitattributes file, or ""
+// if there is none.
+func rootAttributesFile(cfg Env) string {
 	af, _ := cfg.Get("core.attributesfile")
 	af, err := tools.ExpandConfigPath(af, "git/attributes")
 	if err != nil {
-		return nil
+		return ""
 	}
 
 	if _, err := os.Stat(af); os.IsNotExist(err) {
-		return nil
+		return ""
 	}
-
-	// The working directory for the root gitattributes file is blank.
-	return attrPathsFromFile(mp, af, "", true)
+	return af
 }
 
 // GetSystemAttributePaths behaves as GetAttributePaths, and loads information
 // only from the system gitattributes file, respecting the $PREFIX environment
 // variable.
 func GetSystemAttributePaths(mp *gitattr.MacroProcessor, env Env) []AttributePath {
+	path := systemAttributesFile(env)
+	if len(path) == 0 {
+		return nil
+	}
+
+	return attrPathsFromFile(mp, path, "", true)
+}
+
+// systemAttributesFile returns the path of the system gitattributes file, or
+// "" if there is none.
+func systemAttributesFile(env Env) string {
 	prefix, _ := env.Get("PREFIX")
 	if len(prefix) == 0 {
 		prefix = string(filepath.Separator)
@@ -72,10 +114,9 @@ func GetSystemAttributePaths(mp *gitattr.MacroProcessor, env Env) []AttributePat
 	path := filepath.Join(prefix, "etc", "gitattributes")
 
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil
+		return ""
 	}
-
-	return attrPathsFromFile(mp, path, "", true)
+	return path
 }
 
 // GetAttributePaths returns a list of entries in .gitattributes which are
@@ -119,13 +160,19 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 		lockable := false
 		tracked := false
 		hasFilter := false
+		legacy := false
 
 		for _, attr := range line.Attrs {
 			if attr.K == FilterAttrib {
 				hasFilter = true
-				tracked = attr.V == "lfs"
+				tracked = IsLFSFilter(attr.V)
+				legacy = legacy || isLegacyFilterName(attr.V)
 			} else if attr.K == LockableAttrib && attr.V == "true" {
 				lockable = true
+			} else if (attr.K == "diff" || attr.K == "merge") && isLegacyFilterName(attr.V) {
+				legacy = true
+			} else if attr.K == "crlf" && attr.V == "false" {
+				legacy = true
 			}
 		}
 
@@ -143,6 +190,7 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 			Source:   source,
 			Lockable: lockable,
 			Tracked:  tracked,
+			Legacy:   tracked && legacy,
 		})
 	}
 
@@ -151,6 +199,205 @@ func AttrPathsFromReader(mp *gitattr.MacroProcessor, path, workingDir string, rd
 	return paths
 }
 
+// IsLFSFilter returns whether "value", the value of a filter attribute, marks
+// a file as handled by Git LFS, either as "lfs" or as one of the names used by
+// its predecessors.
+func IsLFSFilter(value string) bool {
+	return value == "lfs" || isLegacyFilterName(value)
+}
+
+func isLegacyFilterName(value string) bool {
+	for _, name := range legacyFilterNames {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UpgradeAttributeLine rewrites a line of a gitattributes file which marks a
+// pattern as handled by Git LFS with legacy attributes, such as filter=media
+// or -crlf, to give it the attributes "git lfs track" would, leaving any other
+// attributes in place after them.  It returns the line unchanged, and false,
+// if it does not need upgrading, including if it is a comment or defines a
+// macro.
+func UpgradeAttributeLine(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+		return line, false
+	}
+
+	tracked, legacy := false, false
+	others := make([]string, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		k, v := field, "true"
+		if i := strings.IndexByte(field, '='); i >= 0 {
+			k, v = field[:i], field[i+1:]
+		} else if strings.HasPrefix(field, "-") {
+			k, v = field[1:], "false"
+		}
+
+		switch k {
+		case FilterAttrib, "diff", "merge":
+			if k == FilterAttrib {
+				tracked = IsLFSFilter(v)
+			}
+			legacy = legacy || isLegacyFilterName(v)
+			if !IsLFSFilter(v) {
+				// Keep a driver which is not Git LFS, such as
+				// diff=astextplain.
+				others = append(others, field)
+			}
+		case "crlf":
+			legacy = legacy || v == "false"
+		case "text":
+			// Replaced by -text.
+		default:
+			others = append(others, field)
+		}
+	}
+	if !tracked || !legacy {
+		return line, false
+	}
+
+	upgraded := append([]string{fields[0]}, lfsAttributeLine...)
+	return strings.Join(append(upgraded, others...), " "), true
+}
+
+// FilterAttributeMatch is a line of a gitattributes file whose pattern matches
+// a path and which sets, unsets, or unspecifies the path's filter attribute.
+type FilterAttributeMatch struct {
+	// Pattern is the pattern of the line, as it was written.
+	Pattern string
+	// Source is the gitattributes file containing the line, relative to
+	// the root of the working tree if it is within it.
+	Source string
+	// Attr is the filter attribute the line gives, after the expansion of
+	// any macros.
+	Attr *gitattr.Attr
+}
+
+// Tracked returns whether the line marks the path as handled by Git LFS.
+func (m *FilterAttributeMatch) Tracked() bool {
+	return !m.Attr.Unspecified && IsLFSFilter(m.Attr.V)
+}
+
+// String returns the filter attribute as it would be written in a
+// gitattributes file, such as "filter=lfs" or "-filter".
+func (m *FilterAttributeMatch) String() string {
+	switch {
+	case m.Attr.Unspecified:
+		return "!" + m.Attr.K
+	case m.Attr.V == "false":
+		return "-" + m.Attr.K
+	case m.Attr.V == "true":
+		return m.Attr.K
+	default:
+		return m.Attr.K + "=" + m.Attr.V
+	}
+}
+
+// GetFilterAttributeMatches returns the lines of the system, global, and
+// repository gitattributes files which give a filter attribute to "path",
+// which is relative to the root of the working tree.  They are returned in
+// increasing order of precedence, so that the last of them, if any, is the
+// one whose filter Git uses.
+func GetFilterAttributeMatches(osEnv, gitEnv Env, workingDir, gitDir, path string) []*FilterAttributeMatch {
+	path = filepath.ToSlash(path)
+
+	// Git reads the system, global, and repository gitattributes files
+	// in that order, and then those in the working tree from its root
+	// downwards, with any in $GIT_DIR/info/attributes overriding them
+	// all.
+	var files []attrFile
+	if af := systemAttributesFile(osEnv); len(af) > 0 {
+		files = append(files, attrFile{path: af, readMacros: true})
+	}
+	if af := rootAttributesFile(gitEnv); len(af) > 0 {
+		files = append(files, attrFile{path: af, readMacros: true})
+	}
+
+	var info *attrFile
+	var tree []attrFile
+	repoAttributes := filepath.Join(gitDir, "info", "attributes")
+	for _, file := range findAttributeFiles(workingDir, gitDir) {
+		if file.path == repoAttributes {
+			info = &attrFile{path: file.path, readMacros: true}
+		} else {
+			tree = append(tree, file)
+		}
+	}
+	sort.SliceStable(tree, func(i, j int) bool {
+		return len(tree[i].path) < len(tree[j].path)
+	})
+	inTree := make(map[string]bool, len(tree))
+	for _, file := range tree {
+		inTree[file.path] = true
+	}
+	files = append(files, tree...)
+	if info != nil {
+		files = append(files, *info)
+	}
+
+	mp := gitattr.NewMacroProcessor()
+	var matches []*FilterAttributeMatch
+	for _, file := range files {
+		source, dir := file.path, ""
+		if inTree[file.path] {
+			if rel, err := filepath.Rel(workingDir, file.path); err == nil && !strings.HasPrefix(rel, "..") {
+				source = filepath.ToSlash(rel)
+				if i := strings.LastIndex(source, "/"); i >= 0 {
+					dir = source[:i+1]
+				}
+			}
+		}
+		if !strings.HasPrefix(path, dir) {
+			continue
+		}
+
+		lines, err := readAttributeLines(mp, file)
+		if err != nil {
+			tracerx.Printf("GetFilterAttributeMatches: could not read %s: %v", file.path, err)
+			continue
+		}
+
+		for _, line := range lines {
+			if !line.Pattern.Match(strings.TrimPrefix(path, dir)) {
+				continue
+			}
+
+			var filter *gitattr.Attr
+			for _, attr := range line.Attrs {
+				if attr.K == FilterAttrib {
+					filter = attr
+				}
+			}
+			if filter != nil {
+				matches = append(matches, &FilterAttributeMatch{
+					Pattern: line.Pattern.String(),
+					Source:  source,
+					Attr:    filter,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+func readAttributeLines(mp *gitattr.MacroProcessor, file attrFile) ([]*gitattr.Line, error) {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, _, err := gitattr.ParseLines(f)
+	if err != nil {
+		return nil, err
+	}
+	return mp.ProcessLines(lines, file.readMacros), nil
+}
+
 // GetAttributeFilter returns a list of entries in .gitattributes which are
 // configured with the filter=lfs attribute as a file path filter which
 // file paths can be matched against
@@ -201,3 +448,55 @@ func findAttributeFiles(workingDir, gitDir string) []attrFile {
 
 	return paths
 }
+
+// GetTreeAttributePaths behaves as GetAttributePaths, but loads information
+// from the .gitattributes files in the tree of the given ref, reading them from
+// the object database "db" rather than from the working tree.  The top-level
+// .gitattributes file is read first so that its macros are available to the
+// others.
+func GetTreeAttributePaths(mp *gitattr.MacroProcessor, db *gitobj.ObjectDatabase, ref string) ([]AttributePath, error) {
+	sha, err := gitNoLFSSimple("rev-parse", "--verify", ref+"^{tree}")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("Git can't resolve ref: %q", ref))
+	}
+	oid, err := hex.DecodeString(sha)
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("Git can't resolve ref: %q", ref))
+	}
+
+	var paths []AttributePath
+	err = walkTreeAttributes(db, oid, "", func(path string, blob *gitobj.Blob) {
+		paths = append(paths, AttrPathsFromReader(mp, path, "", blob.Contents, path == ".gitattributes")...)
+	})
+	return paths, err
+}
+
+func walkTreeAttributes(db *gitobj.ObjectDatabase, oid []byte, dir string, fn func(string, *gitobj.Blob)) error {
+	tree, err := db.Tree(oid)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries {
+		if entry.Name != ".gitattributes" || entry.Type() != gitobj.BlobObjectType || entry.IsLink() {
+			continue
+		}
+
+		blob, err := db.Blob(entry.Oid)
+		if err != nil {
+			return err
+		}
+		fn(path.Join(dir, entry.Name), blob)
+		blob.Close()
+	}
+
+	for _, entry := range tree.Entries {
+		if entry.Type() != gitobj.TreeObjectType {
+			continue
+		}
+		if err := walkTreeAttributes(db, entry.Oid, path.Join(dir, entry.Name), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
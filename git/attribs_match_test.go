@@ -0,0 +1,96 @@
+package git_test // to avoid import cycles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/git-lfs/git-lfs/v3/git"
+	test "github.com/git-lfs/git-lfs/v3/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attribsMatchEnv map[string]string
+
+func (e attribsMatchEnv) Get(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+func TestGetFilterAttributeMatches(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	global := filepath.Join(repo.Path, "global-attributes")
+	require.Nil(t, ioutil.WriteFile(global, []byte("*.iso filter=lfs\n"), 0644))
+	require.Nil(t, ioutil.WriteFile(".gitattributes", []byte("[attr]big filter=lfs diff=lfs merge=lfs -text\n*.dat filter=lfs\n*.bin big\n*.iso -filter\n"), 0644))
+	require.Nil(t, os.MkdirAll(filepath.Join("sub", "deeper"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join("sub", ".gitattributes"), []byte("*.dat !filter\nkeep.dat filter=lfs\n"), 0644))
+	require.Nil(t, os.MkdirAll(filepath.Join(repo.GitDir, "info"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(repo.GitDir, "info", "attributes"), []byte("secret.bin filter=crypt\n"), 0644))
+
+	osEnv := attribsMatchEnv{"PREFIX": filepath.Join(repo.Path, "no-prefix")}
+	gitEnv := attribsMatchEnv{"core.attributesfile": global}
+
+	type match struct {
+		Pattern, Source, Attr string
+	}
+	for path, expected := range map[string][]match{
+		"a.dat": {{"*.dat", ".gitattributes", "filter=lfs"}},
+		"b.bin": {{"*.bin", ".gitattributes", "filter=lfs"}},
+		"c.txt": nil,
+		"d.iso": {
+			{"*.iso", global, "filter=lfs"},
+			{"*.iso", ".gitattributes", "-filter"},
+		},
+		"sub/e.dat": {
+			{"*.dat", ".gitattributes", "filter=lfs"},
+			{"*.dat", "sub/.gitattributes", "!filter"},
+		},
+		"sub/deeper/keep.dat": {
+			{"*.dat", ".gitattributes", "filter=lfs"},
+			{"*.dat", "sub/.gitattributes", "!filter"},
+			{"keep.dat", "sub/.gitattributes", "filter=lfs"},
+		},
+		"sub/secret.bin": {
+			{"*.bin", ".gitattributes", "filter=lfs"},
+			{"secret.bin", filepath.Join(repo.GitDir, "info", "attributes"), "filter=crypt"},
+		},
+	} {
+		var actual []match
+		for _, m := range GetFilterAttributeMatches(osEnv, gitEnv, repo.Path, repo.GitDir, path) {
+			actual = append(actual, match{m.Pattern, m.Source, m.String()})
+		}
+		assert.Equal(t, expected, actual, path)
+	}
+}
+
+func TestFilterAttributeMatchTracked(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	require.Nil(t, ioutil.WriteFile(".gitattributes", []byte("*.dat filter=lfs\n*.psd filter=media\n*.txt filter=other\n*.bin -filter\n"), 0644))
+
+	env := attribsMatchEnv{"PREFIX": filepath.Join(repo.Path, "no-prefix")}
+	for path, tracked := range map[string]bool{
+		"a.dat": true,
+		"b.psd": true,
+		"c.txt": false,
+		"d.bin": false,
+	} {
+		matches := GetFilterAttributeMatches(env, attribsMatchEnv{}, repo.Path, repo.GitDir, path)
+		if assert.Len(t, matches, 1, path) {
+			assert.Equal(t, tracked, matches[0].Tracked(), path)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/git/gitattr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLFSFilter(t *testing.T) {
+	assert.True(t, IsLFSFilter("lfs"))
+	assert.True(t, IsLFSFilter("media"))
+	assert.True(t, IsLFSFilter("hawser"))
+	assert.False(t, IsLFSFilter("other"))
+	assert.False(t, IsLFSFilter(""))
+}
+
+func TestAttrPathsFromReaderRecognizesLegacyAttributes(t *testing.T) {
+	paths := AttrPathsFromReader(gitattr.NewMacroProcessor(), ".gitattributes", "", strings.NewReader(strings.Join([]string{
+		"*.dat filter=lfs diff=lfs merge=lfs -text",
+		"*.psd filter=media -crlf",
+		"*.ai filter=hawser diff=hawser merge=hawser -text",
+		"*.bin filter=lfs diff=lfs merge=lfs -crlf",
+		"*.txt filter=other",
+		"",
+	}, "\n")), true)
+	require.Len(t, paths, 5)
+
+	assert.True(t, paths[0].Tracked)
+	assert.False(t, paths[0].Legacy)
+
+	for _, p := range paths[1:4] {
+		assert.True(t, p.Tracked, p.Path)
+		assert.True(t, p.Legacy, p.Path)
+	}
+
+	assert.False(t, paths[4].Tracked)
+	assert.False(t, paths[4].Legacy)
+}
+
+func TestUpgradeAttributeLine(t *testing.T) {
+	for line, expected := range map[string]string{
+		"*.psd filter=media -crlf":                        "*.psd filter=lfs diff=lfs merge=lfs -text",
+		"*.psd filter=media diff=media merge=media -crlf": "*.psd filter=lfs diff=lfs merge=lfs -text",
+		"*.ai filter=hawser diff=hawser -text lockable":   "*.ai filter=lfs diff=lfs merge=lfs -text lockable",
+		"*.bin filter=lfs diff=lfs merge=lfs -crlf":       "*.bin filter=lfs diff=lfs merge=lfs -text",
+		"*.doc   filter=media   diff=astextplain   -crlf": "*.doc filter=lfs diff=lfs merge=lfs -text diff=astextplain",
+	} {
+		upgraded, ok := UpgradeAttributeLine(line)
+		assert.True(t, ok, line)
+		assert.Equal(t, expected, upgraded, line)
+	}
+
+	for _, line := range []string{
+		"",
+		"# *.psd filter=media -crlf",
+		"[attr]media filter=media -crlf",
+		"*.dat filter=lfs diff=lfs merge=lfs -text",
+		"*.dat filter=lfs diff=lfs merge=lfs -text lockable",
+		"*.txt -crlf",
+		"*.txt filter=other -crlf",
+	} {
+		upgraded, ok := UpgradeAttributeLine(line)
+		assert.False(t, ok, line)
+		assert.Equal(t, line, upgraded)
+	}
+}
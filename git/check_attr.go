@@ -0,0 +1,94 @@
+package git
+
+import (
+	"bufio"
+	"io"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// AttributeChecker looks up the value of one attribute for a series of paths
+// using a single "git check-attr --stdin" process, so that every source of
+// attributes Git itself would use is taken into account.  The process is only
+// started once the first path is checked.
+type AttributeChecker struct {
+	attr string
+
+	mu     sync.Mutex
+	cmd    *subprocess.Cmd
+	input  io.WriteCloser
+	output *bufio.Reader
+}
+
+// NewAttributeChecker returns an *AttributeChecker for the attribute "attr".
+func NewAttributeChecker(attr string) *AttributeChecker {
+	return &AttributeChecker{attr: attr}
+}
+
+// Value returns the value of the attribute for "path", which is relative to
+// the current directory.  As with git-check-attr(1), the value is "set" or
+// "unset" for attributes which are set or unset without a value, and
+// "unspecified" for those not given at all.
+func (c *AttributeChecker) Value(path string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd == nil {
+		if err := c.start(); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := io.WriteString(c.input, path+"\x00"); err != nil {
+		return "", errors.Wrap(err, tr.Tr.Get("could not check attributes of %q", path))
+	}
+
+	// Each answer is given as "<path> NUL <attribute> NUL <value> NUL".
+	var fields [3]string
+	for i := range fields {
+		field, err := c.output.ReadString('\x00')
+		if err != nil {
+			return "", errors.Wrap(err, tr.Tr.Get("could not check attributes of %q", path))
+		}
+		fields[i] = field[:len(field)-1]
+	}
+	return fields[2], nil
+}
+
+func (c *AttributeChecker) start() error {
+	cmd := gitNoLFS("check-attr", "-z", "--stdin", c.attr)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	c.cmd = cmd
+	c.input = stdin
+	c.output = bufio.NewReader(stdout)
+	return nil
+}
+
+// Close stops the "git check-attr" process, if it was started.
+func (c *AttributeChecker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd == nil {
+		return nil
+	}
+
+	c.input.Close()
+	err := c.cmd.Wait()
+	c.cmd = nil
+	return err
+}
@@ -0,0 +1,48 @@
+package git_test // to avoid import cycles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/git-lfs/git-lfs/v3/git"
+	test "github.com/git-lfs/git-lfs/v3/t/cmd/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeCheckerValue(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	require.Nil(t, ioutil.WriteFile(".gitattributes", []byte("*.dat filter=lfs\n*.bin -filter\n"), 0644))
+	require.Nil(t, os.MkdirAll("sub", 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join("sub", ".gitattributes"), []byte("*.txt filter=other\n"), 0644))
+
+	checker := NewAttributeChecker("filter")
+	defer checker.Close()
+
+	for path, expected := range map[string]string{
+		"a.dat":            "lfs",
+		"sub/b.dat":        "lfs",
+		"sub/c.txt":        "other",
+		"d.txt":            "unspecified",
+		"e.bin":            "unset",
+		"path with spaces": "unspecified",
+	} {
+		value, err := checker.Value(path)
+		assert.Nil(t, err, path)
+		assert.Equal(t, expected, value, path)
+	}
+
+	assert.Nil(t, checker.Close())
+}
+
+func TestAttributeCheckerCloseUnstarted(t *testing.T) {
+	assert.Nil(t, NewAttributeChecker("filter").Close())
+}
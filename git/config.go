@@ -143,30 +143,134 @@ func (c *Configuration) Sources(dir string, optionalFilename string) ([]*Configu
 	}
 	configs := make([]*ConfigurationSource, 0, 2)
 
+	out, ok, err := c.optionalConfig(dir, optionalFilename)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		configs = append(configs, ParseConfigLines(out, true))
+	}
+
+	return append(configs, gitconfig), nil
+}
+
+// ConfigurationEntry is a single value set in the Git configuration, along
+// with where it was set.
+type ConfigurationEntry struct {
+	// Scope is the scope in which the value was set, as given by
+	// "git config --show-scope", e.g., "global" or "local".  It is empty
+	// for values read from the optional configuration file, and for all
+	// values if Git is too old to report scopes.
+	Scope string
+	// Origin is the file or blob from which the value was read, as given
+	// by "git config --show-origin", e.g., "file:.git/config".
+	Origin string
+	Key    string
+	Value  string
+	// OnlySafeKeys is true for values read from the optional
+	// configuration file, as for a ConfigurationSource.
+	OnlySafeKeys bool
+}
+
+// Entries returns each value set in the optional configuration file
+// "optionalFilename" in "dir", or in the copy of it in the index or HEAD, and
+// then each value set in the Git configuration, in the order in which they are
+// read by Sources, so that later entries for a key take precedence.
+func (c *Configuration) Entries(dir string, optionalFilename string) ([]*ConfigurationEntry, error) {
+	scoped := IsGitVersionAtLeast("2.26.0")
+	args := []string{"-z", "--show-origin"}
+	if scoped {
+		args = append(args, "--show-scope")
+	}
+
+	out, err := c.gitConfig(append([]string{"-l"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	gitconfig := parseConfigEntries(out, scoped)
+
+	out, ok, err := c.optionalConfig(dir, optionalFilename, args...)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return gitconfig, nil
+	}
+
+	entries := parseConfigEntries(out, scoped)
+	for _, e := range entries {
+		e.Scope = ""
+		e.OnlySafeKeys = true
+	}
+	return append(entries, gitconfig...), nil
+}
+
+// parseConfigEntries parses the output of "git config -l -z --show-origin",
+// with "--show-scope" if "scoped" is true.
+func parseConfigEntries(out string, scoped bool) []*ConfigurationEntry {
+	fields := strings.Split(out, "\x00")
+	per := 2
+	if scoped {
+		per = 3
+	}
+
+	var entries []*ConfigurationEntry
+	for i := 0; i+per <= len(fields); i += per {
+		e := &ConfigurationEntry{}
+		rest := fields[i : i+per]
+		if scoped {
+			e.Scope, rest = rest[0], rest[1:]
+		}
+		e.Origin = rest[0]
+
+		// A key with no value, which Git treats as true, has no
+		// newline after it.
+		pieces := strings.SplitN(rest[1], "\n", 2)
+		e.Key = pieces[0]
+		if len(pieces) > 1 {
+			e.Value = pieces[1]
+		}
+		if len(e.Key) > 0 {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// optionalConfig runs "git config -l" with the given arguments on the optional
+// configuration file "filename" in "dir", or, if it is missing from the
+// working tree, on the copy of it in the index or in HEAD.  It returns the
+// output, and whether any copy of the file was found.
+func (c *Configuration) optionalConfig(dir, filename string, args ...string) (string, bool, error) {
 	bare, err := IsBare()
-	if err == nil {
-		// First try to read from the working directory and then the index if
-		// the file is missing from the working directory.
-		var fileconfig *ConfigurationSource
-		if !bare {
-			fileconfig, err = c.FileSource(filepath.Join(dir, optionalFilename))
+	if err != nil {
+		return "", false, nil
+	}
+
+	args = append([]string{"-l"}, args...)
+	if !bare {
+		// First try to read from the working directory and then the
+		// index if the file is missing from the working directory.
+		path := filepath.Join(dir, filename)
+		if _, err := os.Stat(path); err == nil {
+			out, err := c.gitConfig(append(args, "-f", path)...)
 			if err != nil {
-				if !os.IsNotExist(err) {
-					return nil, err
-				}
-				fileconfig, _ = c.RevisionSource(fmt.Sprintf(":%s", optionalFilename))
+				return "", false, err
 			}
-		}
-		if fileconfig == nil {
-			fileconfig, _ = c.RevisionSource(fmt.Sprintf("HEAD:%s", optionalFilename))
+			return out, true, nil
+		} else if !os.IsNotExist(err) {
+			return "", false, err
 		}
 
-		if fileconfig != nil {
-			configs = append(configs, fileconfig)
+		if out, err := c.gitConfig(append(args, "--blob", fmt.Sprintf(":%s", filename))...); err == nil {
+			return out, true, nil
 		}
 	}
 
-	return append(configs, gitconfig), nil
+	if out, err := c.gitConfig(append(args, "--blob", fmt.Sprintf("HEAD:%s", filename))...); err == nil {
+		return out, true, nil
+	}
+	return "", false, nil
 }
 
 func (c *Configuration) FileSource(filename string) (*ConfigurationSource, error) {
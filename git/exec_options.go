@@ -0,0 +1,60 @@
+package git
+
+import (
+	"os"
+	"strings"
+)
+
+// ExecOptions changes how a Git command is started, for callers which manage
+// their own Git installation or which must not be affected by the user's
+// configuration.
+type ExecOptions struct {
+	// Path is the Git executable to run.  If it is empty, "git" is looked
+	// up in PATH.
+	Path string
+	// Env, if it is not nil, is the whole environment given to the
+	// command, in place of the environment of this process.  IsolatedEnv
+	// returns one which keeps the command from reading the system and
+	// global configuration.
+	Env []string
+}
+
+// isolatedEnvKeys are the environment variables which IsolatedEnv keeps, since
+// they say where to find programs, temporary files, and the repository, rather
+// than how Git should behave.
+var isolatedEnvKeys = []string{
+	"PATH",
+	"SYSTEMROOT",
+	"TMPDIR",
+	"TEMP",
+	"TMP",
+	"GIT_DIR",
+	"GIT_WORK_TREE",
+	"GIT_COMMON_DIR",
+	"GIT_INDEX_FILE",
+	"GIT_OBJECT_DIRECTORY",
+	"GIT_ALTERNATE_OBJECT_DIRECTORIES",
+	"GIT_NAMESPACE",
+}
+
+// IsolatedEnv returns an environment for Git commands which keeps only those
+// variables of this process's environment which locate programs, temporary
+// files and the repository, and which tells Git to read neither the system
+// nor the global configuration.  Only the configuration of the repository
+// itself, and any given with "-c", then applies.
+//
+// HOME and XDG_CONFIG_HOME are left out, so that versions of Git which predate
+// GIT_CONFIG_GLOBAL find no global configuration either.
+func IsolatedEnv() []string {
+	env := make([]string, 0, len(isolatedEnvKeys)+2)
+	for _, kv := range os.Environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		for _, k := range isolatedEnvKeys {
+			if strings.EqualFold(key, k) {
+				env = append(env, kv)
+				break
+			}
+		}
+	}
+	return append(env, "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+}
@@ -0,0 +1,33 @@
+package git
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsolatedEnv(t *testing.T) {
+	for key, value := range map[string]string{
+		"HOME":            "/home/someone",
+		"XDG_CONFIG_HOME": "/home/someone/.config",
+		"GIT_DIR":         "/repo/.git",
+		"GIT_TRACE":       "1",
+	} {
+		old, ok := os.LookupEnv(key)
+		os.Setenv(key, value)
+		if ok {
+			defer os.Setenv(key, old)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+
+	env := IsolatedEnv()
+	assert.Contains(t, env, "GIT_DIR=/repo/.git")
+	assert.Contains(t, env, "GIT_CONFIG_NOSYSTEM=1")
+	assert.Contains(t, env, "GIT_CONFIG_GLOBAL="+os.DevNull)
+	assert.NotContains(t, env, "HOME=/home/someone")
+	assert.NotContains(t, env, "XDG_CONFIG_HOME=/home/someone/.config")
+	assert.NotContains(t, env, "GIT_TRACE=1")
+}
@@ -197,11 +197,45 @@ func gitConfigNoLFS(args ...string) []string {
 	}, args...)
 }
 
+// gitConfigArgs prepends a "-c" option to "args" for each of the "key=value"
+// pairs in "config", so that they override the configuration Git would
+// otherwise read.  Later pairs take precedence over earlier ones for the same
+// key.
+func gitConfigArgs(config []string, args ...string) []string {
+	if len(config) == 0 {
+		return args
+	}
+
+	configArgs := make([]string, 0, 2*len(config)+len(args))
+	for _, kv := range config {
+		configArgs = append(configArgs, "-c", kv)
+	}
+	return append(configArgs, args...)
+}
+
 // Invoke Git with disabled LFS filters
 func gitNoLFS(args ...string) *subprocess.Cmd {
 	return subprocess.ExecCommand("git", gitConfigNoLFS(args...)...)
 }
 
+// gitNoLFSWith behaves as gitNoLFS, but runs the Git executable and uses the
+// environment given by "opt", if it is not nil.
+func gitNoLFSWith(opt *ExecOptions, args ...string) *subprocess.Cmd {
+	if opt == nil {
+		return gitNoLFS(args...)
+	}
+
+	name := "git"
+	if len(opt.Path) > 0 {
+		name = opt.Path
+	}
+	cmd := subprocess.ExecCommand(name, gitConfigNoLFS(args...)...)
+	if opt.Env != nil {
+		cmd.Env = opt.Env
+	}
+	return cmd
+}
+
 func gitNoLFSSimple(args ...string) (string, error) {
 	return subprocess.SimpleExec("git", gitConfigNoLFS(args...)...)
 }
@@ -224,18 +258,47 @@ func gitBuffered(args ...string) (*subprocess.BufferedCmd, error) {
 }
 
 func CatFile() (*subprocess.BufferedCmd, error) {
-	return gitNoLFSBuffered("cat-file", "--batch-check")
+	return CatFileWithConfig(nil)
+}
+
+// CatFileWithConfig is like CatFile, but passes the "key=value" pairs in
+// "config" to Git with "-c".
+func CatFileWithConfig(config []string) (*subprocess.BufferedCmd, error) {
+	return CatFileWithOptions(nil, config)
+}
+
+// CatFileWithOptions is like CatFileWithConfig, but runs the Git executable
+// and uses the environment given by "opt", if it is not nil.
+func CatFileWithOptions(opt *ExecOptions, config []string) (*subprocess.BufferedCmd, error) {
+	return subprocess.BufferedStart(gitNoLFSWith(opt, gitConfigArgs(config, "cat-file", "--batch-check")...))
 }
 
 func DiffIndex(ref string, cached bool, refresh bool) (*bufio.Scanner, error) {
+	return DiffIndexIn("", ref, cached, refresh)
+}
+
+// DiffIndexIn is like DiffIndex, but uses the index and working tree of the
+// worktree at "dir", or of the current worktree if "dir" is empty.
+func DiffIndexIn(dir, ref string, cached bool, refresh bool) (*bufio.Scanner, error) {
+	return DiffIndexWithConfig(nil, dir, ref, cached, refresh)
+}
+
+// DiffIndexWithConfig is like DiffIndexIn, but passes the "key=value" pairs
+// in "config" to Git with "-c".
+func DiffIndexWithConfig(config []string, dir, ref string, cached bool, refresh bool) (*bufio.Scanner, error) {
+	args := gitConfigArgs(config)
+	if len(dir) > 0 {
+		args = append(args, "-C", dir)
+	}
+
 	if refresh {
-		_, err := gitSimple("update-index", "-q", "--refresh")
+		_, err := gitSimple(append(args, "update-index", "-q", "--refresh")...)
 		if err != nil {
 			return nil, lfserrors.Wrap(err, tr.Tr.Get("Failed to run `git update-index`"))
 		}
 	}
 
-	args := []string{"diff-index", "-M"}
+	args = append(args, "diff-index", "-M")
 	if cached {
 		args = append(args, "--cached")
 	}
@@ -264,7 +327,13 @@ func HashObject(r io.Reader) (string, error) {
 }
 
 func Log(args ...string) (*subprocess.BufferedCmd, error) {
-	logArgs := append([]string{"log"}, args...)
+	return LogWithConfig(nil, args...)
+}
+
+// LogWithConfig is like Log, but passes the "key=value" pairs in "config" to
+// Git with "-c".
+func LogWithConfig(config []string, args ...string) (*subprocess.BufferedCmd, error) {
+	logArgs := gitConfigArgs(config, append([]string{"log"}, args...)...)
 	return gitNoLFSBuffered(logArgs...)
 }
 
@@ -280,14 +349,27 @@ func LsRemote(remote, remoteRef string) (string, error) {
 }
 
 func LsTree(ref string) (*subprocess.BufferedCmd, error) {
-	return gitNoLFSBuffered(
+	return LsTreeWithConfig(nil, ref)
+}
+
+// LsTreeWithConfig is like LsTree, but passes the "key=value" pairs in
+// "config" to Git with "-c".
+func LsTreeWithConfig(config []string, ref string) (*subprocess.BufferedCmd, error) {
+	return gitNoLFSBuffered(gitConfigArgs(config,
 		"ls-tree",
 		"-r",          // recurse
 		"-l",          // report object size (we'll need this)
 		"-z",          // null line termination
 		"--full-tree", // start at the root regardless of where we are in it
 		ref,
-	)
+	)...)
+}
+
+// LsTreePaths is like LsTree, but lists only the entries at the given paths,
+// which are relative to the root of the tree.
+func LsTreePaths(ref string, paths []string) (*subprocess.BufferedCmd, error) {
+	args := []string{"ls-tree", "-r", "-l", "-z", "--full-tree", ref, "--"}
+	return gitNoLFSBuffered(append(args, paths...)...)
 }
 
 func ResolveRef(ref string) (*Ref, error) {
@@ -328,6 +410,29 @@ func ResolveRefs(refnames []string) ([]*Ref, error) {
 	return refs, nil
 }
 
+// MergeBases returns the SHAs of every best common ancestor of the two given
+// refs, which is empty if they share no history.
+func MergeBases(a, b string) ([]string, error) {
+	cmd := gitNoLFS("merge-base", "--all", a, b)
+	outp, err := cmd.Output()
+	if err != nil {
+		// git merge-base exits with 1 and no output if there is
+		// no common ancestor.
+		if lfserrors.ExitStatus(err) == 1 && len(outp) == 0 {
+			return nil, nil
+		}
+		return nil, errors.New(tr.Tr.Get("failed to call `git merge-base`: %v", err))
+	}
+
+	var shas []string
+	for _, line := range strings.Split(string(outp), "\n") {
+		if sha := strings.TrimSpace(line); len(sha) > 0 {
+			shas = append(shas, sha)
+		}
+	}
+	return shas, nil
+}
+
 func CurrentRef() (*Ref, error) {
 	return ResolveRef("HEAD")
 }
@@ -448,6 +553,146 @@ func MapRemoteURL(url string, push bool) (string, bool) {
 	return url, false
 }
 
+// StashShas returns the commit SHAs of every entry in the stash, newest
+// first, as recorded in the reflog of refs/stash.  Only the most recent entry
+// is reachable from refs/stash itself, so scans which must include every stash
+// need to name the older entries explicitly.  If there are no stashes, an
+// empty slice is returned.
+func StashShas() ([]string, error) {
+	if _, err := gitNoLFSSimple("rev-parse", "--verify", "--quiet", "refs/stash"); err != nil {
+		return nil, nil
+	}
+
+	outp, err := gitNoLFSSimple("log", "-g", "--format=%H", "refs/stash", "--")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to list stash entries: %v", err))
+	}
+
+	var shas []string
+	for _, line := range strings.Split(outp, "\n") {
+		if sha := strings.TrimSpace(line); len(sha) > 0 {
+			shas = append(shas, sha)
+		}
+	}
+	return shas, nil
+}
+
+// ReflogShas returns the SHAs of the commits named by every entry in the
+// reflogs of the current repository, including that of HEAD.  A branch's
+// reflog is removed along with it, but the entries which HEAD's reflog made
+// while it was checked out remain.  If there are no reflog entries, an empty
+// slice is returned.
+func ReflogShas() ([]string, error) {
+	outp, err := gitNoLFSSimple("rev-list", "--no-walk", "--reflog")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to list reflog entries: %v", err))
+	}
+
+	var shas []string
+	for _, line := range strings.Split(outp, "\n") {
+		if sha := strings.TrimSpace(line); len(sha) > 0 {
+			shas = append(shas, sha)
+		}
+	}
+	return shas, nil
+}
+
+// NotesShas returns the commit SHAs at the tips of the notes refs, those under
+// refs/notes/, in the current repository.  Notes refs are not branches or
+// tags, so scans of refs other than all of them need to name these explicitly
+// to include the objects which notes reference.  If there are no notes refs,
+// an empty slice is returned.
+func NotesShas() ([]string, error) {
+	outp, err := gitNoLFSSimple("for-each-ref", "--format=%(objectname)", "refs/notes/")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to list notes refs: %v", err))
+	}
+
+	var shas []string
+	for _, line := range strings.Split(outp, "\n") {
+		if sha := strings.TrimSpace(line); len(sha) > 0 {
+			shas = append(shas, sha)
+		}
+	}
+	return shas, nil
+}
+
+// RefBlobShas returns the SHAs of the blobs to which refs in the current
+// repository point, either directly or through an annotated tag.  "git
+// rev-list --objects" lists such blobs without a path, since it reaches them
+// before any of the trees which contain them.  If there are none, an empty
+// slice is returned.
+func RefBlobShas() ([]string, error) {
+	outp, err := gitNoLFSSimple("for-each-ref", "--format=%(objecttype) %(objectname) %(*objecttype) %(*objectname)")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to list refs: %v", err))
+	}
+
+	var shas []string
+	for _, line := range strings.Split(outp, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "blob" {
+			shas = append(shas, fields[1])
+		} else if len(fields) == 4 && fields[2] == "blob" {
+			shas = append(shas, fields[3])
+		}
+	}
+	return shas, nil
+}
+
+// shortRefRegex matches the names which may be short names for refs, rather
+// than full names, object IDs, or revision expressions.
+var shortRefRegex = regexp.MustCompile(`\A[A-Za-z0-9_][A-Za-z0-9._/-]*\z`)
+
+// FullRefNames returns the full names of the refs to which each of the given
+// short ref names, such as "main", may refer, in the order in which Git chooses
+// between them, as gitrevisions(7) describes: "refs/<name>", then
+// "refs/tags/<name>", "refs/heads/<name>", "refs/remotes/<name>", and
+// "refs/remotes/<name>/HEAD".  Names which match no ref, and those which are
+// full ref names, "HEAD", or not short ref names at all, are left out.
+func FullRefNames(names []string) (map[string][]string, error) {
+	args := []string{"for-each-ref", "--format=%(refname)"}
+	candidates := make(map[string][]string)
+	for _, name := range names {
+		if !shortRefRegex.MatchString(name) || strings.HasPrefix(name, "refs/") || name == "HEAD" {
+			continue
+		}
+		if _, ok := candidates[name]; ok {
+			continue
+		}
+		candidates[name] = []string{
+			"refs/" + name,
+			"refs/tags/" + name,
+			"refs/heads/" + name,
+			"refs/remotes/" + name,
+			"refs/remotes/" + name + "/HEAD",
+		}
+		args = append(args, candidates[name]...)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	outp, err := gitNoLFSSimple(args...)
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to call `git for-each-ref`: %v", err))
+	}
+	exists := make(map[string]bool)
+	for _, line := range strings.Split(outp, "\n") {
+		exists[strings.TrimSpace(line)] = true
+	}
+
+	full := make(map[string][]string)
+	for name, refs := range candidates {
+		for _, ref := range refs {
+			if exists[ref] {
+				full[name] = append(full[name], ref)
+			}
+		}
+	}
+	return full, nil
+}
+
 // Refs returns all of the local and remote branches and tags for the current
 // repository. Other refs (HEAD, refs/stash, git notes) are ignored.
 func LocalRefs() ([]*Ref, error) {
@@ -1302,7 +1547,8 @@ func sanitizePattern(pattern string) string {
 
 // GetFilesChanged returns a list of files which were changed, either between 2
 // commits, or at a single commit if you only supply one argument and a blank
-// string for the other
+// string for the other.  The files changed by a single commit with no parents
+// are those it adds.
 func GetFilesChanged(from, to string) ([]string, error) {
 	var files []string
 	args := []string{
@@ -1311,6 +1557,7 @@ func GetFilesChanged(from, to string) ([]string, error) {
 		"--no-commit-id",
 		"--name-only",
 		"-r",
+		"--root", // show the files added by a root commit
 	}
 
 	if len(from) > 0 {
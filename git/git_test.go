@@ -671,6 +671,10 @@ func TestGetFilesChanges(t *testing.T) {
 	changes, err = GetFilesChanged("abranch", "")
 	assert.Nil(t, err)
 	assert.Equal(t, expected1to2, changes)
+	// Test a root commit
+	changes, err = GetFilesChanged(commits[0].Sha, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"file1.txt"}, changes)
 
 }
 
@@ -805,3 +809,161 @@ func TestIsZeroObjectID(t *testing.T) {
 	assert.Equal(t, IsZeroObjectID("e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"), false)
 	assert.Equal(t, IsZeroObjectID("473a0f4c3be8a93681a267e3b1e9a7dcda1185436fe141f7749120a303721813"), false)
 }
+
+func TestMergeBases(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{ // 0
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+		},
+		{ // 1
+			NewBranch: "branch2",
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 25},
+			},
+		},
+		{ // 2
+			ParentBranches: []string{"master"},
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 30},
+			},
+		},
+	})
+
+	bases, err := MergeBases(outputs[1].Sha, outputs[2].Sha)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{outputs[0].Sha}, bases)
+
+	test.RunGitCommand(t, true, "checkout", "--orphan", "unrelated")
+	test.RunGitCommand(t, true, "commit", "--allow-empty", "-m", "unrelated")
+	unrelated, err := ResolveRef("unrelated")
+	assert.Nil(t, err)
+
+	bases, err = MergeBases(outputs[2].Sha, unrelated.Sha)
+	assert.Nil(t, err)
+	assert.Empty(t, bases)
+}
+
+func TestFullRefNames(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Tags: []string{"ambiguous", "v1.0"},
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+		},
+		{
+			NewBranch: "ambiguous",
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 25},
+			},
+		},
+	})
+
+	full, err := FullRefNames([]string{
+		"ambiguous", "master", "v1.0", "missing", "HEAD", "refs/heads/master", "master~1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{
+		"ambiguous": {"refs/tags/ambiguous", "refs/heads/ambiguous"},
+		"master":    {"refs/heads/master"},
+		"v1.0":      {"refs/tags/v1.0"},
+	}, full)
+
+	full, err = FullRefNames([]string{"HEAD", "master^"})
+	assert.Nil(t, err)
+	assert.Empty(t, full)
+}
+
+func TestLogWithConfig(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	test.RunGitCommand(t, true, "config", "core.quotePath", "true")
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "café.txt", Size: 20},
+			},
+		},
+	})
+
+	log := func(config []string) string {
+		cmd, err := LogWithConfig(config, "--format=", "--name-only")
+		assert.Nil(t, err)
+
+		out, err := ioutil.ReadAll(cmd.Stdout)
+		assert.Nil(t, err)
+		assert.Nil(t, cmd.Wait())
+		return string(out)
+	}
+
+	assert.Equal(t, "\"caf\\303\\251.txt\"\n", log(nil))
+	assert.Equal(t, "café.txt\n", log([]string{"core.quotePath=false"}))
+	assert.Equal(t, "\"caf\\303\\251.txt\"\n", log([]string{"core.quotePath=false", "core.quotePath=true"}))
+}
+
+func TestUnquotePath(t *testing.T) {
+	for quoted, path := range map[string]string{
+		`a.dat`:                        "a.dat",
+		`with space.dat`:               "with space.dat",
+		`café.dat`:                     "café.dat",
+		`"caf\303\251.dat"`:            "café.dat",
+		`"dir/\346\226\207.dat"`:       "dir/文.dat",
+		`"a \"quoted\" name.dat"`:      `a "quoted" name.dat`,
+		`"back\\slash.dat"`:            `back\slash.dat`,
+		`"tab\there\nand\rthere.dat"`:  "tab\there\nand\rthere.dat",
+		`"\a\b\v\f.dat"`:               "\a\b\v\f.dat",
+		`"short\30.dat"`:               `short\30.dat`,
+		`"`:                            `"`,
+		`"unterminated`:                `"unterminated`,
+		`unquoted "middle".dat`:        `unquoted "middle".dat`,
+		`"caf\303\251 \"s\" \\ 1.dat"`: `café "s" \ 1.dat`,
+	} {
+		assert.Equal(t, path, UnquotePath(quoted), quoted)
+	}
+}
+
+func TestQuotePath(t *testing.T) {
+	for path, quoted := range map[string]string{
+		"a.dat":                `a.dat`,
+		"with space.dat":       `with space.dat`,
+		"café.dat":             `café.dat`,
+		`a "quoted" name.dat`:  `"a \"quoted\" name.dat"`,
+		`back\slash.dat`:       `"back\\slash.dat"`,
+		"tab\there\nand\r.dat": `"tab\there\nand\r.dat"`,
+		"\x01\x7f.dat":         `"\001\177.dat"`,
+	} {
+		assert.Equal(t, quoted, QuotePath(path), path)
+		assert.Equal(t, path, UnquotePath(QuotePath(path)), path)
+	}
+}
+
+func TestQuotedPathLen(t *testing.T) {
+	assert.Equal(t, 7, QuotedPathLen(`"a/b c" "b/b c"`))
+	assert.Equal(t, 11, QuotedPathLen(`"a/\"b\" c" rest`))
+	assert.Equal(t, 8, QuotedPathLen(`"a/\\\\"`))
+	assert.Equal(t, -1, QuotedPathLen(`a/b "b/b"`))
+	assert.Equal(t, -1, QuotedPathLen(`"a/b`))
+	assert.Equal(t, -1, QuotedPathLen(``))
+}
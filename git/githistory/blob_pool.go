@@ -0,0 +1,167 @@
+package githistory
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/tasklog"
+	"github.com/git-lfs/gitobj/v2"
+	"github.com/rubyist/tracerx"
+)
+
+// blobJob is a blob entry which a *blobPool is to rewrite.
+type blobJob struct {
+	commitOID []byte
+	path      string
+	entry     *gitobj.TreeEntry
+}
+
+// blobPool rewrites blobs using a fixed number of workers, each of which calls
+// the BlobRewriteFn and caches the rewritten entry in the *Rewriter, so that a
+// later call to rewriteTree finds it there rather than calling the
+// BlobRewriteFn again.
+type blobPool struct {
+	r    *Rewriter
+	fn   BlobRewriteFn
+	perc *tasklog.PercentageTask
+
+	jobs chan *blobJob
+	// pending counts the jobs which have been added but not yet finished.
+	pending sync.WaitGroup
+	// workers counts the running workers.
+	workers sync.WaitGroup
+
+	// mu guards err.
+	mu sync.Mutex
+	// err is the first error returned while rewriting a blob.
+	err error
+}
+
+// newBlobPool starts a *blobPool of "n" workers which rewrite blobs with the
+// BlobRewriteFn "fn".
+func (r *Rewriter) newBlobPool(n int, fn BlobRewriteFn, perc *tasklog.PercentageTask) *blobPool {
+	p := &blobPool{
+		r:    r,
+		fn:   fn,
+		perc: perc,
+		jobs: make(chan *blobJob, n),
+	}
+
+	tracerx.Printf("githistory: rewriting blobs with %d workers", n)
+
+	p.workers.Add(n)
+	for i := 0; i < n; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *blobPool) work() {
+	defer p.workers.Done()
+
+	for job := range p.jobs {
+		// Once any blob has failed the rewrite will be abandoned, so
+		// there is no point in rewriting any more of them.
+		if p.Err() == nil {
+			oid, err := p.r.rewriteBlob(job.commitOID, job.entry.Oid, job.path, p.fn, p.perc)
+			if err != nil {
+				p.setErr(err)
+			} else {
+				p.r.cacheEntry(job.path, job.entry, &gitobj.TreeEntry{
+					Filemode: job.entry.Filemode,
+					Name:     job.entry.Name,
+					Oid:      oid,
+				})
+			}
+		}
+		p.pending.Done()
+	}
+}
+
+// Add queues a job, blocking while every worker is busy.
+func (p *blobPool) Add(job *blobJob) {
+	p.pending.Add(1)
+	p.jobs <- job
+}
+
+// Wait waits for the jobs added so far to finish, and returns the first error
+// returned by any of them.
+func (p *blobPool) Wait() error {
+	p.pending.Wait()
+	return p.Err()
+}
+
+// Err returns the first error returned by any job, if any.
+func (p *blobPool) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+func (p *blobPool) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// Close stops the workers once they have finished the jobs already added.
+func (p *blobPool) Close() {
+	close(p.jobs)
+	p.workers.Wait()
+}
+
+// queueBlobs walks the tree given by the ID "treeOID" and path "path" in the
+// same way as rewriteTree, calling the TreePreCallbackFn "tpfn" on each tree
+// it opens, and adds a job to the *blobPool for each blob which rewriteTree
+// would pass to the BlobRewriteFn.  Trees and blobs which have already been
+// rewritten are skipped.
+func (r *Rewriter) queueBlobs(p *blobPool, commitOID []byte, treeOID []byte, path string, tpfn TreePreCallbackFn) error {
+	tree, err := r.db.Tree(treeOID)
+	if err != nil {
+		return err
+	}
+
+	if err := tpfn("/"+path, tree); err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries {
+		var fullpath string
+		if len(path) > 0 {
+			fullpath = strings.Join([]string{path, entry.Name}, "/")
+		} else {
+			fullpath = entry.Name
+		}
+
+		if !r.allows(entry.Type(), fullpath) {
+			continue
+		}
+
+		// If this is a symlink, skip it
+		if entry.Filemode == 0120000 {
+			continue
+		}
+
+		if cached := r.uncacheEntry(fullpath, entry); cached != nil {
+			continue
+		}
+
+		switch entry.Type() {
+		case gitobj.BlobObjectType:
+			p.Add(&blobJob{
+				commitOID: commitOID,
+				path:      fullpath,
+				entry:     entry,
+			})
+		case gitobj.TreeObjectType:
+			if err := r.queueBlobs(p, commitOID, entry.Oid, fullpath, tpfn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package githistory
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// resumeFileVersion is the first word of the header of a resume file, which
+// changes whenever the format of the file does.
+const resumeFileVersion = "resume-v1"
+
+// resumeFile records the commits which a Rewrite has rewritten so far, along
+// with the state returned by its CheckpointFn after each, so that another
+// Rewrite of the same commits with the same options can continue from where it
+// stopped.
+//
+// The file starts with a header giving a key made from those commits and
+// options, and is only resumed from while they are unchanged.  Each commit is
+// then recorded on a line of its own once its rewritten commit has been
+// written, followed by the state if it has changed, so a rewrite interrupted
+// part of the way through a commit simply rewrites that commit again.
+type resumeFile struct {
+	path string
+	f    *os.File
+	w    *bufio.Writer
+
+	// commits maps the hex SHAs of the commits already rewritten to
+	// their rewritten SHAs.
+	commits map[string][]byte
+	// state is the last state recorded, or nil if there is none.
+	state []byte
+}
+
+// resumeKey returns the key of a rewrite of "commits" with the options
+// identified by "key".
+func resumeKey(key string, commits [][]byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "key %q\n", key)
+	for _, commit := range commits {
+		fmt.Fprintf(h, "commit %x\n", commit)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// openResumeFile opens the resume file at "path" for a rewrite of "commits"
+// with the options identified by "key".  If the file was written by a rewrite
+// of the same commits with the same options, the commits it records are
+// loaded; otherwise, it is started afresh.
+func openResumeFile(path, key string, commits [][]byte) (*resumeFile, error) {
+	header := fmt.Sprintf("%s %s", resumeFileVersion, resumeKey(key, commits))
+
+	rf := &resumeFile{path: path, commits: make(map[string][]byte)}
+	valid, err := rf.load(header)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("could not open resume file: %v", err))
+	}
+	rf.f = f
+	rf.w = bufio.NewWriter(f)
+
+	// Drop anything after the last complete record, so that new records
+	// are not appended to a partly written one.
+	if err := f.Truncate(valid); err == nil {
+		_, err = f.Seek(valid, io.SeekStart)
+	}
+	if err == nil && valid == 0 {
+		fmt.Fprintln(rf.w, header)
+		err = rf.w.Flush()
+	}
+	if err != nil {
+		f.Close()
+		return nil, errors.New(tr.Tr.Get("could not write resume file: %v", err))
+	}
+	return rf, nil
+}
+
+// load reads the commits and state recorded in the resume file, if it exists
+// and starts with "header", and returns the length of the part of the file
+// which holds them, or zero if it is to be started afresh.  A partly written
+// last line, as left by an interrupted rewrite, is ignored.
+func (rf *resumeFile) load(header string) (int64, error) {
+	data, err := ioutil.ReadFile(rf.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, errors.New(tr.Tr.Get("could not read resume file: %v", err))
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	if len(lines) < 2 || string(lines[0]) != header {
+		return 0, nil
+	}
+
+	valid := int64(len(lines[0]) + 1)
+
+	// Every complete line ends with a newline, so the last element is
+	// either empty or a partly written line.
+	for _, line := range lines[1 : len(lines)-1] {
+		fields := strings.Split(string(line), " ")
+		if len(fields) < 3 || len(fields) > 4 || fields[0] != "commit" {
+			break
+		}
+		to, err := hex.DecodeString(fields[2])
+		if err != nil {
+			break
+		}
+		if len(fields) == 4 {
+			state, err := base64.StdEncoding.DecodeString(fields[3])
+			if err != nil {
+				break
+			}
+			rf.state = state
+		}
+		rf.commits[fields[1]] = to
+		valid += int64(len(line) + 1)
+	}
+	return valid, nil
+}
+
+// Rewritten returns the rewritten SHA of the commit "from", and whether it was
+// recorded as rewritten.
+func (rf *resumeFile) Rewritten(from []byte) ([]byte, bool) {
+	to, ok := rf.commits[hex.EncodeToString(from)]
+	return to, ok
+}
+
+// Record records that the commit "from" was rewritten as "to", and that the
+// state afterwards was "state", unless that is nil.
+func (rf *resumeFile) Record(from, to, state []byte) error {
+	fmt.Fprintf(rf.w, "commit %x %x", from, to)
+	if state != nil && (rf.state == nil || !bytes.Equal(state, rf.state)) {
+		fmt.Fprintf(rf.w, " %s", base64.StdEncoding.EncodeToString(state))
+		rf.state = state
+	}
+	fmt.Fprintln(rf.w)
+
+	if err := rf.w.Flush(); err != nil {
+		return errors.New(tr.Tr.Get("could not write resume file: %v", err))
+	}
+	return nil
+}
+
+// Close closes the resume file, leaving it in place to be resumed from.
+func (rf *resumeFile) Close() error {
+	return rf.f.Close()
+}
+
+// Remove closes and removes the resume file, once the rewrite is finished.
+func (rf *resumeFile) Remove() error {
+	rf.f.Close()
+	if err := os.Remove(rf.path); err != nil && !os.IsNotExist(err) {
+		return errors.New(tr.Tr.Get("could not remove resume file: %v", err))
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package githistory
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/tasklog"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/git-lfs/gitobj/v2"
+	"github.com/rubyist/tracerx"
 )
 
 // Rewriter allows rewriting topologically equivalent Git histories
@@ -60,6 +62,25 @@ type RewriteOptions struct {
 	// commits
 	ObjectMapFilePath string
 
+	// ResumeFilePath, if given, is the path to a file in which each
+	// commit is recorded as it is rewritten, so that if the rewrite is
+	// interrupted, another with the same ResumeFilePath and ResumeKey
+	// continues from where it stopped rather than rewriting every commit
+	// again.  This is only done while the commits to rewrite are
+	// unchanged.  The file is removed once the rewrite is finished.
+	ResumeFilePath string
+	// ResumeKey identifies the options, other than the commits to
+	// rewrite, which change what the callbacks do, so that a rewrite is
+	// never resumed from one made with different options.
+	ResumeKey string
+	// CheckpointFn, if given along with ResumeFilePath, returns the state
+	// which the callbacks carry from one commit to the next, which is
+	// recorded after each commit.  When a rewrite is resumed, the last
+	// state recorded is given to RestoreFn before any commit is
+	// rewritten.
+	CheckpointFn func() []byte
+	RestoreFn    func(state []byte) error
+
 	// BlobFn specifies a function to rewrite blobs.
 	//
 	// It is called once per unique, unchanged path. That is to say, if
@@ -76,6 +97,14 @@ type RewriteOptions struct {
 	// been reassembled by calling the above BlobFn on all existing tree
 	// entries.
 	TreeCallbackFn TreeCallbackFn
+
+	// Concurrency is the number of blobs which may be given to the BlobFn
+	// at once. If it is greater than one, the BlobFn is called from that
+	// many goroutines and so must be safe for them to share, though the
+	// BlobFn calls for one commit always finish before the next commit is
+	// rewritten, and commits and trees are still rewritten one at a time
+	// and in order. A value of zero or one calls the BlobFn serially.
+	Concurrency int
 }
 
 // blobFn returns a usable BlobRewriteFn, either the one that was given in the
@@ -232,10 +261,50 @@ func (r *Rewriter) Rewrite(opt *RewriteOptions) ([]byte, error) {
 		defer objectMapFile.Close()
 	}
 
+	var resume *resumeFile
+	if len(opt.ResumeFilePath) > 0 {
+		resume, err = openResumeFile(opt.ResumeFilePath, opt.ResumeKey, commits)
+		if err != nil {
+			return nil, err
+		}
+		defer resume.Close()
+
+		if n := len(resume.commits); n > 0 {
+			tracerx.Printf("githistory: resuming after %d rewritten commit(s) from %s", n, opt.ResumeFilePath)
+		}
+		if resume.state != nil && opt.RestoreFn != nil {
+			if err := opt.RestoreFn(resume.state); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var pool *blobPool
+	if opt.Concurrency > 1 {
+		pool = r.newBlobPool(opt.Concurrency, opt.blobFn(), vPerc)
+		defer pool.Close()
+	}
+
 	// Keep track of the last commit that we rewrote. Callers often want
 	// this so that they can perform a git-update-ref(1).
 	var tip []byte
 	for _, oid := range commits {
+		if resume != nil {
+			// Skip the commits which an interrupted rewrite
+			// had already finished.
+			if newSha, ok := resume.Rewritten(oid); ok {
+				if objectMapFile != nil && !bytes.Equal(oid, newSha) {
+					if _, err := fmt.Fprintf(objectMapFile, "%x,%x\n", oid, newSha); err != nil {
+						return nil, err
+					}
+				}
+				r.cacheCommit(oid, newSha)
+				perc.Count(1)
+				tip = newSha
+				continue
+			}
+		}
+
 		// Load the original commit to access the data necessary in
 		// order to rewrite it.
 		original, err := r.db.Commit(oid)
@@ -243,8 +312,26 @@ func (r *Rewriter) Rewrite(opt *RewriteOptions) ([]byte, error) {
 			return nil, err
 		}
 
+		treePreFn := opt.treePreFn()
+		if pool != nil {
+			// Rewrite the blobs in this commit's tree
+			// concurrently first, so that rewriting the tree below
+			// finds each of them already cached.
+			err := r.queueBlobs(pool, oid, original.TreeID, "", treePreFn)
+			if werr := pool.Wait(); err == nil {
+				err = werr
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			// The TreePreCallbackFn has already been called on
+			// every tree which will be rewritten.
+			treePreFn = noopTreePreFn
+		}
+
 		// Rewrite the tree given at that commit.
-		rewrittenTree, err := r.rewriteTree(oid, original.TreeID, "", opt.blobFn(), opt.treePreFn(), opt.treeFn(), vPerc)
+		rewrittenTree, err := r.rewriteTree(oid, original.TreeID, "", opt.blobFn(), treePreFn, opt.treeFn(), vPerc)
 		if err != nil {
 			return nil, err
 		}
@@ -308,6 +395,16 @@ func (r *Rewriter) Rewrite(opt *RewriteOptions) ([]byte, error) {
 		// commit.
 		r.cacheCommit(oid, newSha)
 
+		if resume != nil {
+			var state []byte
+			if opt.CheckpointFn != nil {
+				state = opt.CheckpointFn()
+			}
+			if err := resume.Record(oid, newSha, state); err != nil {
+				return nil, err
+			}
+		}
+
 		// Increment the percentage displayed in the terminal.
 		perc.Count(1)
 
@@ -337,6 +434,12 @@ func (r *Rewriter) Rewrite(opt *RewriteOptions) ([]byte, error) {
 		}
 	}
 
+	if resume != nil {
+		if err := resume.Remove(); err != nil {
+			return nil, err
+		}
+	}
+
 	return tip, err
 }
 
@@ -589,6 +692,20 @@ func (r *Rewriter) Filter() *filepathfilter.Filter {
 	return r.filter
 }
 
+// RewrittenCommits returns a copy of the mapping from the hex-encoded SHA of
+// each commit visited by this *Rewriter to the SHA of the commit it was
+// rewritten as, which is the same SHA if it was left unchanged.
+func (r *Rewriter) RewrittenCommits() map[string][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	commits := make(map[string][]byte, len(r.commits))
+	for from, to := range r.commits {
+		commits[from] = to
+	}
+	return commits
+}
+
 // cacheEntry caches then given "from" entry so that it is always rewritten as
 // a *TreeEntry equivalent to "to".
 func (r *Rewriter) cacheEntry(path string, from, to *gitobj.TreeEntry) *gitobj.TreeEntry {
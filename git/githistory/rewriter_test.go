@@ -3,12 +3,18 @@ package githistory
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
@@ -77,6 +83,33 @@ func TestRewriterRewritesHistory(t *testing.T) {
 	AssertBlobContents(t, db, tree3, "hello.txt", "2")
 }
 
+func TestRewriterReturnsRewrittenCommits(t *testing.T) {
+	db := DatabaseFromFixture(t, "linear-history.git")
+	r := NewRewriter(db)
+
+	tip, err := r.Rewrite(&RewriteOptions{Include: []string{"refs/heads/master"},
+		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+			return &gitobj.Blob{
+				Contents: strings.NewReader("rewritten"),
+				Size:     int64(len("rewritten")),
+			}, nil
+		},
+	})
+	assert.Nil(t, err)
+
+	commits := r.RewrittenCommits()
+	assert.Len(t, commits, 3)
+
+	var found bool
+	for from, to := range commits {
+		assert.NotEqual(t, from, hex.EncodeToString(to))
+		if bytes.Equal(to, tip) {
+			found = true
+		}
+	}
+	assert.True(t, found, "expected the new tip among the rewritten commits")
+}
+
 func TestRewriterRewritesOctopusMerges(t *testing.T) {
 	db := DatabaseFromFixture(t, "octopus-merge.git")
 	r := NewRewriter(db)
@@ -474,6 +507,293 @@ func TestHistoryRewriterReturnsFilter(t *testing.T) {
 		"git/githistory: expected Rewriter.Filter() to return same *filepathfilter.Filter instance")
 }
 
+func TestRewriterRewritesHistoryConcurrently(t *testing.T) {
+	rewrite := func(concurrency int) (string, []string) {
+		var mu sync.Mutex
+		var paths []string
+
+		db := DatabaseFromFixture(t, "octopus-merge.git")
+		r := NewRewriter(db)
+
+		tip, err := r.Rewrite(&RewriteOptions{Include: []string{"refs/heads/master"},
+			Concurrency: concurrency,
+			BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+				mu.Lock()
+				paths = append(paths, path)
+				mu.Unlock()
+
+				return &gitobj.Blob{
+					Contents: io.MultiReader(b.Contents, strings.NewReader("_new")),
+					Size:     b.Size + int64(len("_new")),
+				}, nil
+			},
+		})
+		assert.Nil(t, err)
+
+		sort.Strings(paths)
+		return hex.EncodeToString(tip), paths
+	}
+
+	serialTip, serialPaths := rewrite(1)
+	concurrentTip, concurrentPaths := rewrite(4)
+
+	assert.Equal(t, serialTip, concurrentTip)
+	assert.Equal(t, serialPaths, concurrentPaths)
+}
+
+func TestRewriterCallsBlobFnConcurrently(t *testing.T) {
+	db := DatabaseFromFixture(t, "identical-blobs.git")
+	r := NewRewriter(db)
+
+	// Each call waits for the other, so the rewrite can only succeed if
+	// both blobs in the commit are rewritten at once.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	tip, err := r.Rewrite(&RewriteOptions{Include: []string{"refs/heads/master"},
+		Concurrency: 2,
+		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+			wg.Done()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				return nil, errors.Errorf("timed out waiting for concurrent BlobFn call")
+			}
+
+			if path == "b.txt" {
+				return b, nil
+			}
+
+			return &gitobj.Blob{
+				Contents: strings.NewReader("changed"),
+				Size:     int64(len("changed")),
+			}, nil
+		},
+	})
+
+	assert.Nil(t, err)
+
+	tree := "bbbe0a7676523ae02234bfe874784ca2380c2d4b"
+
+	AssertCommitTree(t, db, hex.EncodeToString(tip), tree)
+
+	AssertBlobContents(t, db, tree, "a.txt", "changed")
+	AssertBlobContents(t, db, tree, "b.txt", "original")
+}
+
+func TestRewriterConcurrentlyDoesntVisitUnchangedSubtrees(t *testing.T) {
+	db := DatabaseFromFixture(t, "repeated-subtrees.git")
+	r := NewRewriter(db)
+
+	var mu sync.Mutex
+	seen := make(map[string]int)
+
+	_, err := r.Rewrite(&RewriteOptions{Include: []string{"refs/heads/master"},
+		Concurrency: 4,
+		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			seen[path] = seen[path] + 1
+
+			return b, nil
+		},
+	})
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, seen["a.txt"])
+	assert.Equal(t, 1, seen["subdir/b.txt"])
+}
+
+func TestHistoryRewriterConcurrentCallbacksSubtrees(t *testing.T) {
+	var mu sync.Mutex
+	var calls []*CallbackCall
+
+	db := DatabaseFromFixture(t, "non-repeated-subtrees.git")
+	r := NewRewriter(db)
+
+	opts := collectCalls(&calls)
+	opts.Concurrency = 4
+	blobFn := opts.BlobFn
+	opts.BlobFn = func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return blobFn(path, b)
+	}
+
+	_, err := r.Rewrite(opts)
+
+	assert.Nil(t, err)
+
+	assert.Len(t, calls, 8)
+	assert.Equal(t, calls[0], &CallbackCall{Type: "tree-pre", Path: "/"})
+	assert.Equal(t, calls[1], &CallbackCall{Type: "blob", Path: "a.txt"})
+	assert.Equal(t, calls[2], &CallbackCall{Type: "tree-post", Path: "/"})
+	assert.Equal(t, calls[3], &CallbackCall{Type: "tree-pre", Path: "/"})
+	assert.Equal(t, calls[4], &CallbackCall{Type: "tree-pre", Path: "/subdir"})
+	assert.Equal(t, calls[5], &CallbackCall{Type: "blob", Path: "subdir/b.txt"})
+	assert.Equal(t, calls[6], &CallbackCall{Type: "tree-post", Path: "/subdir"})
+	assert.Equal(t, calls[7], &CallbackCall{Type: "tree-post", Path: "/"})
+}
+
+func TestHistoryRewriterConcurrentBlobFnPropagatesErrors(t *testing.T) {
+	expected := errors.Errorf("my error")
+
+	db := DatabaseFromFixture(t, "octopus-merge.git")
+	r := NewRewriter(db)
+
+	_, err := r.Rewrite(&RewriteOptions{Include: []string{"refs/heads/master"},
+		Concurrency: 4,
+		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+			return nil, expected
+		},
+	})
+
+	assert.Equal(t, err, expected)
+}
+
+// resumableRewrite returns RewriteOptions for a rewrite of the linear-history
+// fixture which increments each blob, records the paths it has rewritten in
+// "seen", checkpointing and restoring them through the resume file at "path",
+// and fails once "fail" blobs have been rewritten, if that is not zero.
+func resumableRewrite(path, key string, seen *[]string, fail int) *RewriteOptions {
+	calls := 0
+	return &RewriteOptions{Include: []string{"refs/heads/master"},
+		ResumeFilePath: path,
+		ResumeKey:      key,
+		BlobFn: func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+			calls++
+			if calls == fail {
+				return nil, errors.New("interrupted")
+			}
+
+			contents, err := ioutil.ReadAll(b.Contents)
+			if err != nil {
+				return nil, err
+			}
+			n, err := strconv.Atoi(string(contents))
+			if err != nil {
+				return nil, err
+			}
+			*seen = append(*seen, fmt.Sprintf("%s=%d", path, n))
+
+			rewritten := strconv.Itoa(n + 1)
+			return &gitobj.Blob{
+				Contents: strings.NewReader(rewritten),
+				Size:     int64(len(rewritten)),
+			}, nil
+		},
+		CheckpointFn: func() []byte {
+			return []byte(strings.Join(*seen, ","))
+		},
+		RestoreFn: func(state []byte) error {
+			*seen = strings.Split(string(state), ",")
+			return nil
+		},
+	}
+}
+
+func TestHistoryRewriterResumesInterruptedRewrite(t *testing.T) {
+	var expectedSeen []string
+	db := DatabaseFromFixture(t, "linear-history.git")
+	expected, err := NewRewriter(db).Rewrite(resumableRewrite("", "", &expectedSeen, 0))
+	assert.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "resume")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "resume")
+
+	db = DatabaseFromFixture(t, "linear-history.git")
+
+	// Interrupt the rewrite after the first commit.
+	var seen []string
+	_, err = NewRewriter(db).Rewrite(resumableRewrite(path, "key", &seen, 2))
+	assert.EqualError(t, err, "interrupted")
+	assert.FileExists(t, path)
+
+	// Only the remaining commits are rewritten when it is resumed, with
+	// the state recorded after the first.
+	seen = nil
+	opt := resumableRewrite(path, "key", &seen, 0)
+	blobFn := opt.BlobFn
+	calls := 0
+	opt.BlobFn = func(path string, b *gitobj.Blob) (*gitobj.Blob, error) {
+		calls++
+		return blobFn(path, b)
+	}
+	tip, err := NewRewriter(db).Rewrite(opt)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, expected, tip)
+	assert.Equal(t, expectedSeen, seen)
+	AssertCommitTree(t, db, hex.EncodeToString(tip), "ad0aebd16e34cf047820994ea7538a6d4a111082")
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestHistoryRewriterDoesNotResumeWithDifferentKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resume")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "resume")
+
+	db := DatabaseFromFixture(t, "linear-history.git")
+
+	var seen []string
+	_, err = NewRewriter(db).Rewrite(resumableRewrite(path, "key", &seen, 2))
+	assert.EqualError(t, err, "interrupted")
+
+	seen = nil
+	_, err = NewRewriter(db).Rewrite(resumableRewrite(path, "other", &seen, 0))
+	assert.Nil(t, err)
+	assert.Len(t, seen, 3)
+}
+
+func TestResumeFileIgnoresPartlyWrittenRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resume")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "resume")
+
+	commits := [][]byte{{0x1}, {0x2}}
+	rf, err := openResumeFile(path, "key", commits)
+	assert.Nil(t, err)
+	assert.Nil(t, rf.Record([]byte{0x1}, []byte{0xa}, []byte("one")))
+	rf.w.WriteString("commit 02 0")
+	rf.w.Flush()
+	assert.Nil(t, rf.Close())
+
+	rf, err = openResumeFile(path, "key", commits)
+	assert.Nil(t, err)
+	to, ok := rf.Rewritten([]byte{0x1})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0xa}, to)
+	_, ok = rf.Rewritten([]byte{0x2})
+	assert.False(t, ok)
+	assert.Equal(t, []byte("one"), rf.state)
+
+	// A new record starts on a line of its own.
+	assert.Nil(t, rf.Record([]byte{0x2}, []byte{0xb}, []byte("one")))
+	assert.Nil(t, rf.Close())
+
+	rf, err = openResumeFile(path, "key", commits)
+	assert.Nil(t, err)
+	to, ok = rf.Rewritten([]byte{0x2})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0xb}, to)
+	assert.Nil(t, rf.Remove())
+}
+
 // debug is meant to be called from a defer statement to aide in debugging a
 // test failure among any in this file.
 //
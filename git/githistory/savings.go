@@ -0,0 +1,188 @@
+package githistory
+
+import (
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/git-lfs/gitobj/v2"
+)
+
+// SavingsOptions is an options type given to the EstimateSavings() function.
+type SavingsOptions struct {
+	// Include is the list of refs of which commits reachable by that ref
+	// will be included.
+	Include []string
+	// Exclude is the list of refs of which commits reachable by that ref
+	// will be excluded.
+	Exclude []string
+
+	// Above is the size in bytes at or below which blobs would not be
+	// converted, as given by `git lfs migrate import --above`.  If it is
+	// zero, blobs of any size are converted.
+	Above int64
+}
+
+// Savings is the estimated result of converting the blobs matched by a
+// Rewriter's filter in some history to Git LFS objects, as by `git lfs migrate
+// import`.
+type Savings struct {
+	// Blobs is the number of distinct blobs which would be converted.
+	Blobs int64
+	// Bytes is the total size of those blobs, which is the number of
+	// bytes that would be moved to Git LFS.
+	Bytes int64
+	// CompressedBytes is the total size of those blobs once compressed,
+	// as they would be in a pack.  It does not allow for any of them being
+	// stored as deltas against each other, so it is an upper bound.
+	CompressedBytes int64
+	// PointerBytes is the total size of the pointers which would replace
+	// those blobs.
+	PointerBytes int64
+}
+
+// PackReduction returns the estimated number of bytes by which the packed size
+// of the repository would shrink if the blobs were converted.
+func (s *Savings) PackReduction() int64 {
+	if s.CompressedBytes < s.PointerBytes {
+		return 0
+	}
+	return s.CompressedBytes - s.PointerBytes
+}
+
+// EstimateSavings examines the commits given by "opt" without rewriting them,
+// and returns how much would be moved to Git LFS by converting the blobs in
+// them which the Rewriter's filter matches, other than .gitattributes files
+// and blobs which are already Git LFS pointers.  Each distinct blob is counted
+// once, no matter how many paths or commits it appears in.
+func (r *Rewriter) EstimateSavings(opt *SavingsOptions) (*Savings, error) {
+	commits, err := r.commitsToMigrate(&RewriteOptions{
+		Include: opt.Include,
+		Exclude: opt.Exclude,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e := &savingsEstimator{
+		r:       r,
+		above:   opt.Above,
+		savings: &Savings{},
+		trees:   make(map[string]struct{}),
+		blobs:   make(map[string]struct{}),
+	}
+
+	for _, oid := range commits {
+		commit, err := r.db.Commit(oid)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.tree(commit.TreeID, ""); err != nil {
+			return nil, err
+		}
+	}
+	return e.savings, nil
+}
+
+// savingsEstimator accumulates the Savings of the trees it is given.
+type savingsEstimator struct {
+	r       *Rewriter
+	above   int64
+	savings *Savings
+
+	// trees holds the trees already examined, by path and SHA, since
+	// whether the filter matches the blobs in a tree depends on both.
+	trees map[string]struct{}
+	// blobs holds the hex SHAs of the blobs already counted.
+	blobs map[string]struct{}
+}
+
+// tree examines the tree with SHA "oid" at "path", and any subtrees of it which
+// have not been examined before.
+func (e *savingsEstimator) tree(oid []byte, path string) error {
+	key := e.r.entryKey(path, &gitobj.TreeEntry{Oid: oid})
+	if _, ok := e.trees[key]; ok {
+		return nil
+	}
+	e.trees[key] = struct{}{}
+
+	tree, err := e.r.db.Tree(oid)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries {
+		var fullpath string
+		if len(path) > 0 {
+			fullpath = strings.Join([]string{path, entry.Name}, "/")
+		} else {
+			fullpath = entry.Name
+		}
+
+		switch entry.Type() {
+		case gitobj.TreeObjectType:
+			err = e.tree(entry.Oid, fullpath)
+		case gitobj.BlobObjectType:
+			if entry.IsLink() || strings.ToLower(entry.Name) == ".gitattributes" || !e.r.allows(gitobj.BlobObjectType, fullpath) {
+				continue
+			}
+			err = e.blob(entry.Oid)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blob counts the blob with SHA "oid", unless it has already been counted, is
+// too small to be converted, or is already a Git LFS pointer.
+func (e *savingsEstimator) blob(oid []byte) error {
+	key := hex.EncodeToString(oid)
+	if _, ok := e.blobs[key]; ok {
+		return nil
+	}
+	e.blobs[key] = struct{}{}
+
+	b, err := e.r.db.Blob(oid)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	if e.above > 0 && b.Size <= e.above {
+		return nil
+	}
+
+	p, contents, err := lfs.DecodeFrom(b.Contents)
+	if p != nil && err == nil {
+		return nil
+	}
+
+	var cw countingWriter
+	zw := zlib.NewWriter(&cw)
+	if _, err := io.Copy(zw, contents); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	e.savings.Blobs++
+	e.savings.Bytes += b.Size
+	e.savings.CompressedBytes += cw.n
+	e.savings.PointerBytes += int64(len(lfs.NewPointer(strings.Repeat("0", 64), b.Size, nil).Encoded()))
+	return nil
+}
+
+// countingWriter counts the bytes written to it, and discards them.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,101 @@
+package githistory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/lfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSavingsCountsEachBlobInHistory(t *testing.T) {
+	db := DatabaseFromFixture(t, "linear-history.git")
+	r := NewRewriter(db)
+
+	savings, err := r.EstimateSavings(&SavingsOptions{
+		Include: []string{"refs/heads/master"},
+	})
+	require.Nil(t, err)
+
+	// Each of the three commits has a different one-byte hello.txt.
+	assert.EqualValues(t, 3, savings.Blobs)
+	assert.EqualValues(t, 3, savings.Bytes)
+	assert.True(t, savings.CompressedBytes > 0)
+	assert.EqualValues(t, 3*len(pointerFor(1)), savings.PointerBytes)
+
+	// Pointers take more space than one-byte files.
+	assert.EqualValues(t, 0, savings.PackReduction())
+}
+
+func TestEstimateSavingsCountsIdenticalBlobsOnce(t *testing.T) {
+	db := DatabaseFromFixture(t, "identical-blobs.git")
+	r := NewRewriter(db)
+
+	savings, err := r.EstimateSavings(&SavingsOptions{
+		Include: []string{"refs/heads/master"},
+	})
+	require.Nil(t, err)
+
+	// a.txt and b.txt have the same eight-byte contents.
+	assert.EqualValues(t, 1, savings.Blobs)
+	assert.EqualValues(t, 8, savings.Bytes)
+	assert.EqualValues(t, len(pointerFor(8)), savings.PointerBytes)
+}
+
+func TestEstimateSavingsUsesFilter(t *testing.T) {
+	db := DatabaseFromFixture(t, "non-repeated-subtrees.git")
+	r := NewRewriter(db, WithFilter(filepathfilter.New(
+		[]string{"subdir/**"}, nil, filepathfilter.GitIgnore,
+	)))
+
+	savings, err := r.EstimateSavings(&SavingsOptions{
+		Include: []string{"refs/heads/master"},
+	})
+	require.Nil(t, err)
+
+	// Only subdir/b.txt is matched, and not a.txt.
+	assert.EqualValues(t, 1, savings.Blobs)
+	assert.EqualValues(t, 5, savings.Bytes)
+}
+
+func TestEstimateSavingsSkipsBlobsBelowThreshold(t *testing.T) {
+	db := DatabaseFromFixture(t, "non-repeated-subtrees.git")
+	r := NewRewriter(db)
+
+	savings, err := r.EstimateSavings(&SavingsOptions{
+		Include: []string{"refs/heads/master"},
+		Above:   5,
+	})
+	require.Nil(t, err)
+
+	assert.EqualValues(t, 0, savings.Blobs)
+	assert.EqualValues(t, 0, savings.Bytes)
+	assert.EqualValues(t, 0, savings.CompressedBytes)
+	assert.EqualValues(t, 0, savings.PointerBytes)
+}
+
+func TestEstimateSavingsCountsBlobsAboveThreshold(t *testing.T) {
+	db := DatabaseFromFixture(t, "non-repeated-subtrees.git")
+	r := NewRewriter(db)
+
+	savings, err := r.EstimateSavings(&SavingsOptions{
+		Include: []string{"refs/heads/master"},
+		Above:   4,
+	})
+	require.Nil(t, err)
+
+	// Both a.txt and subdir/b.txt are just above the threshold.
+	assert.EqualValues(t, 2, savings.Blobs)
+	assert.EqualValues(t, 10, savings.Bytes)
+}
+
+func TestSavingsPackReduction(t *testing.T) {
+	s := &Savings{CompressedBytes: 1000, PointerBytes: 300}
+	assert.EqualValues(t, 700, s.PackReduction())
+}
+
+func pointerFor(size int64) string {
+	return lfs.NewPointer(strings.Repeat("0", 64), size, nil).Encoded()
+}
@@ -0,0 +1,133 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnquotePath decodes a path as it may appear in the output of Git commands
+// such as git-diff(1) and git-diff-index(1).  Git encloses a path in double
+// quotes and escapes its characters as in C if it contains a double quote, a
+// backslash, or a control character, or, unless core.quotePath is false, any
+// byte outside of ASCII, which it gives as an octal escape.  Paths which are
+// not quoted are returned unchanged.
+func UnquotePath(path string) string {
+	if len(path) < 2 || path[0] != '"' || path[len(path)-1] != '"' {
+		return path
+	}
+
+	quoted := path[1 : len(path)-1]
+	var unquoted strings.Builder
+	unquoted.Grow(len(quoted))
+
+	for i := 0; i < len(quoted); i++ {
+		c := quoted[i]
+		if c != '\\' || i+1 == len(quoted) {
+			unquoted.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch c = quoted[i]; c {
+		case 'a':
+			unquoted.WriteByte('\a')
+		case 'b':
+			unquoted.WriteByte('\b')
+		case 't':
+			unquoted.WriteByte('\t')
+		case 'n':
+			unquoted.WriteByte('\n')
+		case 'v':
+			unquoted.WriteByte('\v')
+		case 'f':
+			unquoted.WriteByte('\f')
+		case 'r':
+			unquoted.WriteByte('\r')
+		case '0', '1', '2', '3':
+			if i+2 < len(quoted) && isOctal(quoted[i+1]) && isOctal(quoted[i+2]) {
+				unquoted.WriteByte((c-'0')<<6 | (quoted[i+1]-'0')<<3 | (quoted[i+2] - '0'))
+				i += 2
+			} else {
+				unquoted.WriteByte('\\')
+				unquoted.WriteByte(c)
+			}
+		default:
+			// This includes escaped double quotes and
+			// backslashes.
+			unquoted.WriteByte(c)
+		}
+	}
+	return unquoted.String()
+}
+
+// QuotePath encloses a path in double quotes and escapes its characters as in
+// C, so that UnquotePath returns it unchanged, if it contains a double quote, a
+// backslash, or a control character, as Git does when core.quotePath is false.
+// Other paths are returned unchanged.
+func QuotePath(path string) string {
+	needsQuoting := false
+	for i := 0; i < len(path); i++ {
+		if c := path[i]; c == '"' || c == '\\' || c < 0x20 || c == 0x7f {
+			needsQuoting = true
+			break
+		}
+	}
+	if !needsQuoting {
+		return path
+	}
+
+	var quoted strings.Builder
+	quoted.Grow(len(path) + 2)
+	quoted.WriteByte('"')
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '"', '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		case '\a':
+			quoted.WriteString(`\a`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\v':
+			quoted.WriteString(`\v`)
+		case '\f':
+			quoted.WriteString(`\f`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&quoted, "\\%03o", c)
+			} else {
+				quoted.WriteByte(c)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// QuotedPathLen returns the length of the quoted path at the start of "s",
+// including its enclosing double quotes, or -1 if "s" does not start with a
+// complete quoted path.
+func QuotedPathLen(s string) int {
+	if len(s) == 0 || s[0] != '"' {
+		return -1
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
@@ -0,0 +1,129 @@
+package git
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rubyist/tracerx"
+)
+
+var (
+	// missingParentsRegex matches the error which git-rev-list(1) gives,
+	// before Git 2.45, when it cannot read the parents of a commit, and
+	// missingCommitRegex that which names the commit it could not read.
+	missingParentsRegex = regexp.MustCompile(`Failed to traverse parents of commit (` + ObjectIDRegex + `)`)
+	missingCommitRegex  = regexp.MustCompile(`Could not read (` + ObjectIDRegex + `)`)
+)
+
+// maxRevListRestarts is the greatest number of times a revListResumer restarts
+// git-rev-list(1), after which the error which stopped it is returned.
+const maxRevListRestarts = 100
+
+// revListResumer keeps a scan with ContinueOnError going past missing commits
+// with versions of Git whose git-rev-list(1) stops at them even when given
+// --missing.  Each time git-rev-list(1) fails because it cannot read the
+// parents of a commit, it is run again with that commit excluded, so that the
+// walk stops short of the missing one.  Once the walk has finished, the
+// objects of the excluded commits themselves are listed without walking their
+// parents.  Objects already given before a restart are not given again.
+type revListResumer struct {
+	exclude []string
+	opt     *ScanRefsOptions
+	args    []string
+	input   string
+
+	// boundaries are the commits whose parents could not be read.
+	boundaries []string
+	restarts   int
+	// listedBoundaries is set once the objects of the boundaries are
+	// being listed.
+	listedBoundaries bool
+	// seen holds the OIDs already given.
+	seen map[string]struct{}
+}
+
+// firstSighting records "oid" as given, and returns whether it had not been
+// given before.
+func (r *revListResumer) firstSighting(oid []byte) bool {
+	key := string(oid)
+	if _, ok := r.seen[key]; ok {
+		return false
+	}
+	r.seen[key] = struct{}{}
+	return true
+}
+
+// resume is called once git-rev-list(1) has written all of its output, and
+// starts it again, returning true, if it failed on a missing commit or if the
+// objects of the commits at which the walk was stopped remain to be listed.
+// Otherwise, it returns false, and the scanner's Close() returns the error, if
+// any, with which git-rev-list(1) exited.
+func (r *revListResumer) resume(s *RevListScanner) bool {
+	if s.isStopped() {
+		return false
+	}
+
+	err := s.closeFn()
+	s.closeFn = func() error { return err }
+	if err != nil {
+		if r.listedBoundaries || r.restarts >= maxRevListRestarts {
+			return false
+		}
+		m := missingParentsRegex.FindStringSubmatch(err.Error())
+		if m == nil {
+			return false
+		}
+
+		boundary := m[1]
+		for _, b := range r.boundaries {
+			if b == boundary {
+				// Excluding it did not help.
+				return false
+			}
+		}
+		if mc := missingCommitRegex.FindStringSubmatch(err.Error()); mc != nil {
+			tracerx.Printf("rev-list: skipping missing commit %s", mc[1])
+			s.missing = append(s.missing, mc[1])
+		}
+
+		r.boundaries = append(r.boundaries, boundary)
+		r.restarts++
+		// An empty line would end the revisions.
+		lines := includeExcludeShas(nil, r.boundaries)
+		if len(r.input) > 0 {
+			lines = append([]string{r.input}, lines...)
+		}
+		return r.restart(s, r.args, strings.NewReader(strings.Join(lines, "\n")))
+	}
+
+	if r.listedBoundaries || len(r.boundaries) == 0 {
+		return false
+	}
+	r.listedBoundaries = true
+
+	// The exclusions of a scan in ScanRefsMode are kept, but other modes
+	// exclude refs with arguments rather than input, so they are not.
+	var exclude []string
+	if r.opt.Mode == ScanRefsMode {
+		exclude = r.exclude
+	}
+	opt := *r.opt
+	opt.Mode = ScanRefsMode
+	opt.SkipDeletedBlobs = true
+	stdin, args, err := revListArgs(r.boundaries, exclude, &opt)
+	if err != nil {
+		s.closeFn = func() error { return err }
+		return false
+	}
+	return r.restart(s, args, stdin)
+}
+
+// restart runs git-rev-list(1) again with the given arguments and input.
+func (r *revListResumer) restart(s *RevListScanner, args []string, stdin io.Reader) bool {
+	if err := s.start(r.opt, args, stdin); err != nil {
+		s.closeFn = func() error { return err }
+		return false
+	}
+	return true
+}
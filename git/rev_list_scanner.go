@@ -2,6 +2,7 @@ package git
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -98,9 +99,34 @@ type ScanRefsOptions struct {
 	// Reverse specifies whether or not to give the revisions in reverse
 	// order.
 	Reverse bool
+	// MergesOnly limits the scan to merge commits, and NoMerges to commits
+	// which are not merges.  By default, both are false: scan all commits.
+	// They may not both be set.
+	MergesOnly bool
+	NoMerges   bool
 
 	// SkippedRefs provides a list of refs to ignore.
 	SkippedRefs []string
+	// Stashes lists the commit SHAs of stash entries to scan in addition
+	// to all refs when using ScanAllMode, since git-rev-list(1)'s --all
+	// option reaches only the most recent entry in refs/stash.
+	Stashes []string
+	// Config is a list of "key=value" pairs passed to git-rev-list(1) with
+	// "-c", overriding the configuration it would otherwise read.
+	Config []string
+	// Exec, if it is not nil, gives the Git executable to run as
+	// git-rev-list(1) and the environment to run it in.
+	Exec *ExecOptions
+	// Pathspecs limits the scan to the commits which touch the given
+	// pathspecs, and the objects found to those within them.  The full
+	// history is walked, so that no commit which touches them is missed.
+	Pathspecs []string
+	// ContinueOnError skips the objects, including commits, which are
+	// missing from a corrupt repository, rather than failing the scan,
+	// so that the rest of history is still scanned.  The skipped objects
+	// are given by Missing() once the scan has finished.  Objects which
+	// are reachable only through a missing commit cannot be found.
+	ContinueOnError bool
 	// Mutex guards names.
 	Mutex *sync.Mutex
 	// Names maps Git object IDs (encoded as hex using
@@ -141,6 +167,9 @@ type RevListScanner struct {
 	// resources held by an open (running) instance of the *RevListScanner
 	// type.
 	closeFn func() error
+	// stopFn is an optional function which terminates the running
+	// git-rev-list(1) before it has written all of its output.
+	stopFn func()
 
 	// name is the name of the most recently read object.
 	name string
@@ -148,6 +177,18 @@ type RevListScanner struct {
 	oid []byte
 	// err is the most recently encountered error.
 	err error
+
+	// missing holds the hex OIDs of the objects skipped because they are
+	// missing, with ContinueOnError.
+	missing []string
+	// resumer, if not nil, restarts git-rev-list(1) when it fails on a
+	// missing commit, with ContinueOnError.
+	resumer *revListResumer
+	// mu guards stopped and stopFn, since Stop() may be called from a
+	// goroutine other than the one scanning.
+	mu sync.Mutex
+	// stopped is set once Stop() has been called.
+	stopped bool
 }
 
 var (
@@ -169,7 +210,36 @@ func NewRevListScanner(include, excluded []string, opt *ScanRefsOptions) (*RevLi
 		return nil, err
 	}
 
-	cmd := gitNoLFS(args...).Cmd
+	s := &RevListScanner{}
+	if opt.ContinueOnError {
+		// Read the input now, so that it can be given again if the
+		// command has to be restarted.
+		var input []byte
+		if stdin != nil {
+			if input, err = ioutil.ReadAll(stdin); err != nil {
+				return nil, err
+			}
+			stdin = bytes.NewReader(input)
+		}
+		s.resumer = &revListResumer{
+			exclude: excluded,
+			opt:     opt,
+			args:    args,
+			input:   string(input),
+			seen:    make(map[string]struct{}),
+		}
+	}
+
+	if err := s.start(opt, args, stdin); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// start runs git-rev-list(1) with the given arguments and input, and reads its
+// output from then on.
+func (s *RevListScanner) start(opt *ScanRefsOptions, args []string, stdin io.Reader) error {
+	cmd := gitNoLFSWith(opt.Exec, gitConfigArgs(opt.Config, args...)...).Cmd
 	if len(opt.WorkingDir) > 0 {
 		cmd.Dir = opt.WorkingDir
 	}
@@ -177,41 +247,57 @@ func NewRevListScanner(include, excluded []string, opt *ScanRefsOptions) (*RevLi
 	cmd.Stdin = stdin
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	tracerx.Printf("run_command: git %s", strings.Join(args, " "))
 	if err := cmd.Start(); err != nil {
-		return nil, err
+		return err
 	}
 
-	return &RevListScanner{
-		s: bufio.NewScanner(stdout),
-		closeFn: func() error {
-			msg, _ := ioutil.ReadAll(stderr)
+	s.s = bufio.NewScanner(stdout)
 
-			// First check if there was a non-zero exit code given
-			// when Wait()-ing on the command execution.
-			if err := cmd.Wait(); err != nil {
-				return errors.New(tr.Tr.Get("Error in `git %s`: %v %s",
-					strings.Join(args, " "), err, msg))
+	s.mu.Lock()
+	s.stopFn = func() {
+		cmd.Process.Kill()
+	}
+	if s.stopped {
+		// Stop() was called while the previous git-rev-list(1) was
+		// being restarted.
+		s.stopFn()
+	}
+	s.mu.Unlock()
+
+	s.closeFn = func() error {
+		msg, _ := ioutil.ReadAll(stderr)
+
+		// First check if there was a non-zero exit code given
+		// when Wait()-ing on the command execution.  A process
+		// which was stopped early exits abnormally as a
+		// matter of course, so do not report that.
+		if err := cmd.Wait(); err != nil {
+			if s.isStopped() {
+				return nil
 			}
+			return errors.New(tr.Tr.Get("Error in `git %s`: %v %s",
+				strings.Join(args, " "), err, msg))
+		}
 
-			// If the command exited cleanly, but found an ambiguous
-			// refname, promote that to an error and return it.
-			//
-			// `git-rev-list(1)` does not treat ambiguous refnames
-			// as fatal (non-zero exit status), but we do.
-			if am := ambiguousRegex.FindSubmatch(msg); len(am) > 1 {
-				return errors.New(tr.Tr.Get("ref %q is ambiguous", am[1]))
-			}
-			return nil
-		},
-	}, nil
+		// If the command exited cleanly, but found an ambiguous
+		// refname, promote that to an error and return it.
+		//
+		// `git-rev-list(1)` does not treat ambiguous refnames
+		// as fatal (non-zero exit status), but we do.
+		if am := ambiguousRegex.FindSubmatch(msg); len(am) > 1 {
+			return errors.New(tr.Tr.Get("ref %q is ambiguous", am[1]))
+		}
+		return nil
+	}
+	return nil
 }
 
 // revListArgs returns the arguments for a given included and excluded set of
@@ -235,6 +321,26 @@ func revListArgs(include, exclude []string, opt *ScanRefsOptions) (io.Reader, []
 		args = append(args, orderFlag)
 	}
 
+	if opt.MergesOnly && opt.NoMerges {
+		return nil, nil, errors.New(tr.Tr.Get("cannot scan only merge commits and only non-merge commits"))
+	}
+	if opt.MergesOnly {
+		args = append(args, "--merges")
+	} else if opt.NoMerges {
+		args = append(args, "--no-merges")
+	}
+
+	if len(opt.Pathspecs) > 0 {
+		args = append(args, "--full-history")
+	}
+
+	if opt.ContinueOnError && IsGitVersionAtLeast("2.16.0") {
+		// List missing objects, marked with a "?", rather than
+		// failing on them.  Before Git 2.45, this does not apply to
+		// missing commits, which revListResumer handles instead.
+		args = append(args, "--missing=print")
+	}
+
 	switch opt.Mode {
 	case ScanRefsMode:
 		if opt.SkipDeletedBlobs {
@@ -247,6 +353,9 @@ func revListArgs(include, exclude []string, opt *ScanRefsOptions) (io.Reader, []
 			includeExcludeShas(include, exclude), "\n"))
 	case ScanAllMode:
 		args = append(args, "--all")
+		if stashes := nonZeroShas(opt.Stashes); len(stashes) > 0 {
+			stdin = strings.NewReader(strings.Join(stashes, "\n"))
+		}
 	case ScanRangeToRemoteMode:
 		args = append(args, "--ignore-missing")
 		if len(opt.SkippedRefs) == 0 {
@@ -261,7 +370,8 @@ func revListArgs(include, exclude []string, opt *ScanRefsOptions) (io.Reader, []
 	default:
 		return nil, nil, errors.New(tr.Tr.Get("unknown scan type: %d", opt.Mode))
 	}
-	return stdin, append(args, "--stdin", "--"), nil
+	args = append(args, "--stdin", "--")
+	return stdin, append(args, opt.Pathspecs...), nil
 }
 
 func includeExcludeShas(include, exclude []string) []string {
@@ -315,18 +425,35 @@ func (s *RevListScanner) Err() error { return s.err }
 // Scan scans the next entry given by git-rev-list(1), and returns true/false
 // indicating if there are more results to scan.
 func (s *RevListScanner) Scan() bool {
-	var err error
-	s.oid, s.name, err = s.scan()
+	for {
+		var err error
+		s.oid, s.name, err = s.scan()
 
-	if err != nil {
-		if err != io.EOF {
-			s.err = err
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		if len(s.oid) == 0 {
+			if s.resumer != nil && s.resumer.resume(s) {
+				continue
+			}
+			return false
 		}
-		return false
+		if s.resumer != nil && !s.resumer.firstSighting(s.oid) {
+			// Already given before git-rev-list(1) was
+			// restarted.
+			continue
+		}
+		return true
 	}
-	return len(s.oid) > 0
 }
 
+// Missing returns the hex OIDs of the objects which were skipped because they
+// are missing, with ContinueOnError, once Scan() has returned false.
+func (s *RevListScanner) Missing() []string { return s.missing }
+
 // Close closes the RevListScanner by freeing any resources held by the
 // instance while running, and returns any error encountered while doing so.
 func (s *RevListScanner) Close() error {
@@ -336,6 +463,28 @@ func (s *RevListScanner) Close() error {
 	return s.closeFn()
 }
 
+// Stop terminates the running git-rev-list(1) without reading the rest of its
+// output, for callers which have seen all of the objects they need.  Scan()
+// returns false once the buffered output has been read, and Close() must still
+// be called, but it does not report the early exit as an error.
+func (s *RevListScanner) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	if s.stopFn != nil {
+		s.stopFn()
+	}
+}
+
+// isStopped returns whether Stop() has been called.
+func (s *RevListScanner) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopped
+}
+
 // scan provides the internal implementation of scanning a line of text from the
 // output of `git-rev-list(1)`.
 func (s *RevListScanner) scan() ([]byte, string, error) {
@@ -344,6 +493,17 @@ func (s *RevListScanner) scan() ([]byte, string, error) {
 	}
 
 	line := strings.TrimSpace(s.s.Text())
+	for strings.HasPrefix(line, "?") {
+		// A missing object, listed by --missing=print.
+		oid := line[1:]
+		tracerx.Printf("rev-list: skipping missing object %s", oid)
+		s.missing = append(s.missing, oid)
+
+		if !s.s.Scan() {
+			return nil, "", s.s.Err()
+		}
+		line = strings.TrimSpace(s.s.Text())
+	}
 	if len(line) < ObjectIDLengths[0] {
 		return nil, "", nil
 	}
@@ -89,6 +89,21 @@ func TestRevListArgs(t *testing.T) {
 			},
 			ExpectedArgs: []string{"rev-list", "--objects", "--all", "--stdin", "--"},
 		},
+		"scan all, with stashes": {
+			Opt: &ScanRefsOptions{
+				Mode:    ScanAllMode,
+				Stashes: []string{s1, s2},
+			},
+			ExpectedStdin: fmt.Sprintf("%s\n%s", s1, s2),
+			ExpectedArgs:  []string{"rev-list", "--objects", "--all", "--stdin", "--"},
+		},
+		"scan all, with pathspecs": {
+			Opt: &ScanRefsOptions{
+				Mode:      ScanAllMode,
+				Pathspecs: []string{"dir", "*.dat"},
+			},
+			ExpectedArgs: []string{"rev-list", "--objects", "--full-history", "--all", "--stdin", "--", "dir", "*.dat"},
+		},
 		"scan left to remote, no skipped refs": {
 			Include: []string{s1}, Opt: &ScanRefsOptions{
 				Mode:        ScanRangeToRemoteMode,
@@ -153,6 +168,30 @@ func TestRevListArgs(t *testing.T) {
 			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
 			ExpectedArgs:  []string{"rev-list", "--objects", "--reverse", "--do-walk", "--stdin", "--"},
 		},
+		"scan merges only": {
+			Include: []string{s1}, Exclude: []string{s2}, Opt: &ScanRefsOptions{
+				Mode:       ScanRefsMode,
+				MergesOnly: true,
+			},
+			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
+			ExpectedArgs:  []string{"rev-list", "--objects", "--merges", "--do-walk", "--stdin", "--"},
+		},
+		"scan no merges": {
+			Include: []string{s1}, Exclude: []string{s2}, Opt: &ScanRefsOptions{
+				Mode:     ScanRefsMode,
+				NoMerges: true,
+			},
+			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
+			ExpectedArgs:  []string{"rev-list", "--objects", "--no-merges", "--do-walk", "--stdin", "--"},
+		},
+		"scan merges only and no merges": {
+			Include: []string{s1}, Exclude: []string{s2}, Opt: &ScanRefsOptions{
+				Mode:       ScanRefsMode,
+				MergesOnly: true,
+				NoMerges:   true,
+			},
+			ExpectedErr: "cannot scan only merge commits and only non-merge commits",
+		},
 	} {
 		t.Run(desc, c.Assert)
 	}
@@ -217,3 +256,28 @@ func TestRevListScannerParsesLinesWithoutName(t *testing.T) {
 	assert.Nil(t, s.OID())
 	assert.Nil(t, s.Err())
 }
+
+func TestRevListScannerRecordsMissingObjects(t *testing.T) {
+	given := strings.Join([]string{
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"?bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"?cccccccccccccccccccccccccccccccccccccccc",
+		"dddddddddddddddddddddddddddddddddddddddd name.dat",
+	}, "\n")
+	s := &RevListScanner{
+		s: bufio.NewScanner(strings.NewReader(given)),
+	}
+
+	assert.True(t, s.Scan())
+	assert.Equal(t, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", hex.EncodeToString(s.OID()))
+	assert.True(t, s.Scan())
+	assert.Equal(t, "dddddddddddddddddddddddddddddddddddddddd", hex.EncodeToString(s.OID()))
+	assert.Equal(t, "name.dat", s.Name())
+
+	assert.False(t, s.Scan())
+	assert.Nil(t, s.Err())
+	assert.Equal(t, []string{
+		"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"cccccccccccccccccccccccccccccccccccccccc",
+	}, s.Missing())
+}
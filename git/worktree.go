@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// Worktree is one of the working trees of a repository, as listed by `git
+// worktree list`.
+type Worktree struct {
+	// Dir is the top-level directory of the worktree.
+	Dir string
+	// Ref is the commit checked out in the worktree, or nil if the
+	// worktree is bare or its HEAD is unborn.
+	Ref *Ref
+	// Bare is whether this is the repository itself, being bare.
+	Bare bool
+	// Prunable is whether Git considers the worktree to be stale, for
+	// example because its directory has been deleted.
+	Prunable bool
+}
+
+// GetWorktrees returns the main worktree of the current repository followed
+// by any linked worktrees, as listed by `git worktree list`.
+func GetWorktrees() ([]*Worktree, error) {
+	cmd, err := gitNoLFSBuffered("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to list worktrees: %v", err))
+	}
+	cmd.Stdin.Close()
+
+	worktrees, err := parseWorktrees(cmd.Stdout)
+	if err != nil {
+		cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, errors.New(tr.Tr.Get("failed to list worktrees: %v", err))
+	}
+	return worktrees, nil
+}
+
+// parseWorktrees parses the output of `git worktree list --porcelain`, in
+// which each worktree is described by a stanza of lines, the first giving its
+// directory, with stanzas separated by blank lines.
+func parseWorktrees(r io.Reader) ([]*Worktree, error) {
+	var worktrees []*Worktree
+	var current *Worktree
+	var head string
+
+	finish := func() {
+		if current != nil && current.Ref == nil && len(head) > 0 && !current.Bare {
+			current.Ref = &Ref{Name: head, Type: RefTypeOther, Sha: head}
+		}
+		current, head = nil, ""
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+
+		switch key {
+		case "worktree":
+			finish()
+			current = &Worktree{Dir: value}
+			worktrees = append(worktrees, current)
+		case "":
+			finish()
+		}
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "HEAD":
+			// An unborn HEAD is given as the null OID.
+			if strings.Trim(value, "0") != "" {
+				head = value
+			}
+		case "branch":
+			if current.Ref == nil && len(head) > 0 {
+				current.Ref = ParseRef(value, head)
+			}
+		case "bare":
+			current.Bare = true
+		case "prunable":
+			current.Prunable = true
+		}
+	}
+	finish()
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("failed to list worktrees"))
+	}
+	return worktrees, nil
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorktrees(t *testing.T) {
+	worktrees, err := parseWorktrees(strings.NewReader(`worktree /repo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /linked/detached
+HEAD 2222222222222222222222222222222222222222
+detached
+
+worktree /linked/locked
+HEAD 3333333333333333333333333333333333333333
+branch refs/heads/feature
+locked because it is on a removable disk
+
+worktree /linked/gone
+HEAD 4444444444444444444444444444444444444444
+branch refs/heads/gone
+prunable gitdir file points to non-existent location
+
+worktree /linked/unborn
+HEAD 0000000000000000000000000000000000000000
+branch refs/heads/orphan
+
+`))
+	require.Nil(t, err)
+	require.Len(t, worktrees, 5)
+
+	assert.Equal(t, "/repo", worktrees[0].Dir)
+	assert.Equal(t, &Ref{Name: "main", Type: RefTypeLocalBranch, Sha: "1111111111111111111111111111111111111111"}, worktrees[0].Ref)
+	assert.False(t, worktrees[0].Bare)
+	assert.False(t, worktrees[0].Prunable)
+
+	assert.Equal(t, "/linked/detached", worktrees[1].Dir)
+	assert.Equal(t, &Ref{Name: "2222222222222222222222222222222222222222", Type: RefTypeOther, Sha: "2222222222222222222222222222222222222222"}, worktrees[1].Ref)
+
+	assert.Equal(t, "/linked/locked", worktrees[2].Dir)
+	assert.Equal(t, "feature", worktrees[2].Ref.Name)
+	assert.False(t, worktrees[2].Prunable)
+
+	assert.Equal(t, "/linked/gone", worktrees[3].Dir)
+	assert.True(t, worktrees[3].Prunable)
+
+	assert.Equal(t, "/linked/unborn", worktrees[4].Dir)
+	assert.Nil(t, worktrees[4].Ref)
+}
+
+func TestParseWorktreesBare(t *testing.T) {
+	worktrees, err := parseWorktrees(strings.NewReader(`worktree /repo.git
+bare
+
+worktree /linked
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+`))
+	require.Nil(t, err)
+	require.Len(t, worktrees, 2)
+
+	assert.True(t, worktrees[0].Bare)
+	assert.Nil(t, worktrees[0].Ref)
+	assert.Equal(t, "/linked", worktrees[1].Dir)
+	assert.Equal(t, "main", worktrees[1].Ref.Name)
+}
@@ -14,6 +14,17 @@ type FetchPruneConfig struct {
 	FetchRecentCommitsDays int
 	// Whether to always fetch recent even without --recent
 	FetchRecentAlways bool
+	// Number of pointers found while scanning refs and ranges that may wait
+	// to be transferred, so that fetching starts before the scan finishes
+	// (default 0 = finish scanning before fetching)
+	FetchScanBuffer int
+	// Number of refs given to fetch which may be scanned at once, with
+	// the objects found in all of them fetched by one transfer queue
+	// (default 1 = fetch each ref in turn)
+	FetchRefsConcurrency int
+	// Whether to always fetch the objects referenced by the pointers
+	// staged in the index, as if --index were given (default false)
+	FetchIndex bool
 	// Number of days added to FetchRecent*; data outside combined window will be
 	// deleted when prune is run. (default 3)
 	PruneOffsetDays int
@@ -25,6 +36,13 @@ type FetchPruneConfig struct {
 	PruneRecent bool
 	// Whether to delete everything pushed.
 	PruneForce bool
+	// Whether to cache the objects retained by the current and recent refs,
+	// so that a later prune need not scan them again if no ref has moved
+	// (default false)
+	PruneCacheRecent bool
+	// Whether to fetch, and retain when pruning, objects referenced by
+	// the notes refs under refs/notes/ (default false)
+	IncludeNotes bool
 }
 
 func NewFetchPruneConfig(git config.Environment) FetchPruneConfig {
@@ -38,10 +56,15 @@ func NewFetchPruneConfig(git config.Environment) FetchPruneConfig {
 		FetchRecentRefsIncludeRemotes: git.Bool("lfs.fetchrecentremoterefs", true),
 		FetchRecentCommitsDays:        git.Int("lfs.fetchrecentcommitsdays", 0),
 		FetchRecentAlways:             git.Bool("lfs.fetchrecentalways", false),
+		FetchScanBuffer:               git.Int("lfs.fetchscanbuffer", 0),
+		FetchRefsConcurrency:          git.Int("lfs.fetchrefsconcurrency", 1),
+		FetchIndex:                    git.Bool("lfs.fetchindex", false),
 		PruneOffsetDays:               git.Int("lfs.pruneoffsetdays", 3),
 		PruneVerifyRemoteAlways:       git.Bool("lfs.pruneverifyremotealways", false),
 		PruneRemoteName:               pruneRemote,
 		PruneRecent:                   false,
 		PruneForce:                    false,
+		PruneCacheRecent:              git.Bool("lfs.prunecacherecent", false),
+		IncludeNotes:                  git.Bool("lfs.includenotes", false),
 	}
 }
@@ -13,11 +13,15 @@ func TestFetchPruneConfigDefault(t *testing.T) {
 
 	assert.Equal(t, 7, fp.FetchRecentRefsDays)
 	assert.Equal(t, 0, fp.FetchRecentCommitsDays)
+	assert.Equal(t, 0, fp.FetchScanBuffer)
+	assert.Equal(t, 1, fp.FetchRefsConcurrency)
+	assert.False(t, fp.FetchIndex)
 	assert.Equal(t, 3, fp.PruneOffsetDays)
 	assert.True(t, fp.FetchRecentRefsIncludeRemotes)
 	assert.Equal(t, 3, fp.PruneOffsetDays)
 	assert.Equal(t, "origin", fp.PruneRemoteName)
 	assert.False(t, fp.PruneVerifyRemoteAlways)
+	assert.False(t, fp.IncludeNotes)
 }
 
 func TestFetchPruneConfigCustom(t *testing.T) {
@@ -26,17 +30,25 @@ func TestFetchPruneConfigCustom(t *testing.T) {
 			"lfs.fetchrecentrefsdays":     []string{"12"},
 			"lfs.fetchrecentremoterefs":   []string{"false"},
 			"lfs.fetchrecentcommitsdays":  []string{"9"},
+			"lfs.fetchscanbuffer":         []string{"100"},
+			"lfs.fetchrefsconcurrency":    []string{"4"},
+			"lfs.fetchindex":              []string{"true"},
 			"lfs.pruneoffsetdays":         []string{"30"},
 			"lfs.pruneverifyremotealways": []string{"true"},
 			"lfs.pruneremotetocheck":      []string{"upstream"},
+			"lfs.includenotes":            []string{"true"},
 		},
 	})
 	fp := NewFetchPruneConfig(cfg.Git)
 
 	assert.Equal(t, 12, fp.FetchRecentRefsDays)
 	assert.Equal(t, 9, fp.FetchRecentCommitsDays)
+	assert.Equal(t, 100, fp.FetchScanBuffer)
+	assert.Equal(t, 4, fp.FetchRefsConcurrency)
+	assert.True(t, fp.FetchIndex)
 	assert.False(t, fp.FetchRecentRefsIncludeRemotes)
 	assert.Equal(t, 30, fp.PruneOffsetDays)
 	assert.Equal(t, "upstream", fp.PruneRemoteName)
 	assert.True(t, fp.PruneVerifyRemoteAlways)
+	assert.True(t, fp.IncludeNotes)
 }
@@ -0,0 +1,169 @@
+package lfs
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// ContentDirectory is a local directory of raw object contents, such as files
+// exported from another copy of a repository, from which objects may be
+// imported into the local object store instead of being transferred over the
+// network.
+//
+// A file in the directory provides an object if it is named after the
+// object's OID, or if its contents hash to that OID.
+type ContentDirectory struct {
+	root string
+
+	// bySize maps file sizes to the paths of all files in the directory of
+	// that size.  It is populated lazily on the first lookup.
+	bySize map[int64][]string
+	// hashes caches the OID of each file which has been hashed so far.
+	hashes map[string]string
+}
+
+// NewContentDirectory returns a *ContentDirectory reading from the given
+// directory, or an error if it is not a directory.
+func NewContentDirectory(root string) (*ContentDirectory, error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, errors.New(tr.Tr.Get("%q is not a directory", root))
+	}
+
+	return &ContentDirectory{
+		root:   root,
+		hashes: make(map[string]string),
+	}, nil
+}
+
+// Import copies the object with the given OID and size from the directory into
+// the local object store, verifying its contents on the way.  It returns true
+// if the object is present in the store afterwards, and false if the directory
+// does not contain it.
+func (d *ContentDirectory) Import(cfg *config.Configuration, oid string, size int64) (bool, error) {
+	if cfg.LFSObjectExists(oid, size) {
+		return true, nil
+	}
+
+	byName := filepath.Join(d.root, oid)
+	if tools.FileExistsOfSize(byName, size) {
+		ok, err := d.importFile(cfg, byName, oid)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+
+	if err := d.index(); err != nil {
+		return false, err
+	}
+
+	for _, path := range d.bySize[size] {
+		if path == byName {
+			continue
+		}
+
+		fileOid, ok := d.hashes[path]
+		if !ok {
+			var err error
+			if fileOid, err = hashFile(path); err != nil {
+				return false, err
+			}
+			d.hashes[path] = fileOid
+		}
+
+		if fileOid == oid {
+			return d.importFile(cfg, path, oid)
+		}
+	}
+	return false, nil
+}
+
+// index records the size of every regular file beneath the root directory.
+func (d *ContentDirectory) index() error {
+	if d.bySize != nil {
+		return nil
+	}
+
+	bySize := make(map[int64][]string)
+	err := filepath.Walk(d.root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			bySize[fi.Size()] = append(bySize[fi.Size()], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not read content directory %q", d.root))
+	}
+
+	d.bySize = bySize
+	return nil
+}
+
+// importFile copies the file at path into the object store as the given OID.
+// It returns false without an error if the contents of the file do not match
+// the OID, in which case the object store is left untouched.
+func (d *ContentDirectory) importFile(cfg *config.Configuration, path, oid string) (bool, error) {
+	mediafile, err := cfg.Filesystem().ObjectPath(oid)
+	if err != nil {
+		return false, err
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	tmp, err := TempFile(cfg, oid)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hasher := tools.NewHashingReader(in)
+	if _, err = io.Copy(tmp, hasher); err != nil {
+		return false, err
+	}
+	if err = tmp.Close(); err != nil {
+		return false, err
+	}
+
+	if actual := hasher.Hash(); actual != oid {
+		tracerx.Printf("content directory: %s has oid %s, expected %s", path, actual, oid)
+		return false, nil
+	}
+
+	tracerx.Printf("content directory: importing %s from %s", oid, path)
+	return true, cfg.Filesystem().FinalizeObject(tmp.Name(), mediafile)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := tools.NewLfsContentHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
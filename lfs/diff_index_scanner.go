@@ -129,7 +129,14 @@ type DiffIndexScanner struct {
 // that error will be returned immediately. Otherwise, a `*DiffIndexScanner`
 // will be returned with a `nil` error.
 func NewDiffIndexScanner(ref string, cached bool, refresh bool) (*DiffIndexScanner, error) {
-	scanner, err := git.DiffIndex(ref, cached, refresh)
+	return newDiffIndexScannerIn("", ref, cached, refresh, nil)
+}
+
+// newDiffIndexScannerIn is like NewDiffIndexScanner, but scans the index of
+// the worktree at "dir", or of the current worktree if "dir" is empty, passing
+// the "key=value" pairs in "gitConfig" to git-diff-index(1) with "-c".
+func newDiffIndexScannerIn(dir, ref string, cached bool, refresh bool, gitConfig []string) (*DiffIndexScanner, error) {
+	scanner, err := git.DiffIndexWithConfig(gitConfig, dir, ref, cached, refresh)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +202,7 @@ func (s *DiffIndexScanner) scan(line string) (*DiffIndexEntry, error) {
 		SrcSha:  desc[2],
 		DstSha:  desc[3],
 		Status:  DiffIndexStatus(rune(desc[4][0])),
-		SrcName: parts[1],
+		SrcName: git.UnquotePath(parts[1]),
 	}
 
 	if score, err := strconv.Atoi(desc[4][1:]); err != nil {
@@ -203,7 +210,7 @@ func (s *DiffIndexScanner) scan(line string) (*DiffIndexEntry, error) {
 	}
 
 	if len(parts) > 2 {
-		entry.DstName = parts[2]
+		entry.DstName = git.UnquotePath(parts[2])
 	}
 
 	return entry, nil
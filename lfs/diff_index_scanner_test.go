@@ -0,0 +1,24 @@
+package lfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffIndexScannerScanDecodesQuotedNames(t *testing.T) {
+	s := &DiffIndexScanner{}
+
+	entry, err := s.scan(`:000000 100644 0000000000000000000000000000000000000000 c5b3d83a7542255ec7856487baa5e83d65b1624c A` + "\t" + `"caf\303\251 \"s\".dat"`)
+	require.Nil(t, err)
+	assert.Equal(t, StatusAddition, entry.Status)
+	assert.Equal(t, `café "s".dat`, entry.SrcName)
+	assert.Empty(t, entry.DstName)
+
+	entry, err = s.scan(`:100644 100644 c5b3d83a7542255ec7856487baa5e83d65b1624c c5b3d83a7542255ec7856487baa5e83d65b1624c R100` + "\t" + `with space.dat` + "\t" + `"tab\there.dat"`)
+	require.Nil(t, err)
+	assert.Equal(t, StatusRename, entry.Status)
+	assert.Equal(t, "with space.dat", entry.SrcName)
+	assert.Equal(t, "tab\there.dat", entry.DstName)
+}
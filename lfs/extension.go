@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/errors"
@@ -33,124 +34,136 @@ type pipeExtResult struct {
 	oidOut string
 }
 
+// extCommand is a stage of the pipeline run by pipeExtensions: either the clean
+// or smudge command of an extension, or a Transform named in its place.
 type extCommand struct {
-	cmd    *subprocess.Cmd
-	out    io.WriteCloser
-	err    *bytes.Buffer
-	hasher hash.Hash
-	result *pipeExtResult
+	cmd       *subprocess.Cmd
+	transform Transform
+	err       *bytes.Buffer
+	hasher    hash.Hash
+	result    *pipeExtResult
 }
 
-func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response pipeResponse, err error) {
-	var extcmds []*extCommand
-	defer func() {
-		// In the case of an early return before the end of this
-		// function (in response to an error, etc), kill all running
-		// processes. Errors are ignored since the function has already
-		// returned.
-		//
-		// In the happy path, the commands will have already been
-		// `Wait()`-ed upon and e.cmd.Process.Kill() will return an
-		// error, but we can ignore it.
-		for _, e := range extcmds {
-			if e.cmd.Process != nil {
-				e.cmd.Process.Kill()
+// run passes "in" through the stage to "out", hashing what it writes.
+func (ec *extCommand) run(in io.Reader, out io.Writer) error {
+	out = io.MultiWriter(ec.hasher, out)
+	if ec.transform != nil {
+		if err := ec.transform(out, in); err != nil {
+			if err == io.ErrClosedPipe {
+				return err
 			}
+			return errors.Wrap(err, tr.Tr.Get("extension '%s' failed", ec.result.name))
+		}
+		return nil
+	}
+
+	ec.cmd.Stdin = in
+	ec.cmd.Stdout = out
+	ec.cmd.Stderr = ec.err
+	if err := ec.cmd.Run(); err != nil {
+		if err == io.ErrClosedPipe {
+			return err
 		}
-	}()
+		return errors.New(tr.Tr.Get("extension '%s' failed with: %s", ec.result.name, ec.err.String()))
+	}
+	return nil
+}
 
+func pipeExtensions(cfg *config.Configuration, request *pipeRequest) (response pipeResponse, err error) {
+	var extcmds []*extCommand
 	for _, e := range request.extensions {
-		var pieces []string
+		var setting string
 		switch request.action {
 		case "clean":
-			pieces = strings.Split(e.Clean, " ")
+			setting = e.Clean
 		case "smudge":
-			pieces = strings.Split(e.Smudge, " ")
+			setting = e.Smudge
 		default:
 			err = errors.New(tr.Tr.Get("Invalid action: %s", request.action))
 			return
 		}
-		name := strings.Trim(pieces[0], " ")
-		var args []string
-		for _, value := range pieces[1:] {
-			arg := strings.Replace(value, "%f", request.fileName, -1)
-			args = append(args, arg)
+
+		ec := &extCommand{
+			err:    &bytes.Buffer{},
+			hasher: sha256.New(),
+			result: &pipeExtResult{name: e.Name},
+		}
+		if t, name, ok := lookupTransform(setting); ok {
+			if t == nil {
+				err = errors.New(tr.Tr.Get("extension '%s' uses unknown transform '%s'", e.Name, name))
+				return
+			}
+			ec.transform = t
+		} else {
+			pieces := strings.Split(setting, " ")
+			name := strings.Trim(pieces[0], " ")
+			var args []string
+			for _, value := range pieces[1:] {
+				arg := strings.Replace(value, "%f", request.fileName, -1)
+				args = append(args, arg)
+			}
+			ec.cmd = subprocess.ExecCommand(name, args...)
 		}
-		cmd := subprocess.ExecCommand(name, args...)
-		ec := &extCommand{cmd: cmd, result: &pipeExtResult{name: e.Name}}
 		extcmds = append(extcmds, ec)
 	}
 
-	hasher := sha256.New()
-	pipeReader, pipeWriter := io.Pipe()
-	multiWriter := io.MultiWriter(hasher, pipeWriter)
-
-	var input io.Reader
-	var output io.WriteCloser
-	input = pipeReader
-	extcmds[0].cmd.Stdin = input
 	if response.file, err = TempFile(cfg, ""); err != nil {
 		return
 	}
 	defer response.file.Close()
-	output = response.file
 
+	// Each stage runs concurrently, reading the output of the one before
+	// it through a pipe, and the last writes to the temporary file.  A
+	// stage which stops closes both of its pipes, so that the stages
+	// around it stop too, rather than blocking.
+	hasher := sha256.New()
+	pipeReader, pipeWriter := io.Pipe()
+
+	errs := make([]error, len(extcmds))
+	var wg sync.WaitGroup
+	input := pipeReader
 	last := len(extcmds) - 1
 	for i, ec := range extcmds {
-		ec.hasher = sha256.New()
-
-		if i == last {
-			ec.cmd.Stdout = io.MultiWriter(ec.hasher, output)
-			ec.out = output
-			continue
+		var output io.Writer = response.file
+		var (
+			nextReader *io.PipeReader
+			nextWriter *io.PipeWriter
+		)
+		if i < last {
+			nextReader, nextWriter = io.Pipe()
+			output = nextWriter
 		}
 
-		nextec := extcmds[i+1]
-		var nextStdin io.WriteCloser
-		var stdout io.ReadCloser
-		if nextStdin, err = nextec.cmd.StdinPipe(); err != nil {
-			return
-		}
-		if stdout, err = ec.cmd.StdoutPipe(); err != nil {
-			return
-		}
-
-		ec.cmd.Stdin = input
-		ec.cmd.Stdout = io.MultiWriter(ec.hasher, nextStdin)
-		ec.out = nextStdin
+		wg.Add(1)
+		go func(i int, ec *extCommand, in *io.PipeReader, out io.Writer, w *io.PipeWriter) {
+			defer wg.Done()
 
-		input = stdout
+			errs[i] = ec.run(in, out)
+			in.Close()
+			if w != nil {
+				w.CloseWithError(errs[i])
+			}
+		}(i, ec, input, output, nextWriter)
 
-		var errBuff bytes.Buffer
-		ec.err = &errBuff
-		ec.cmd.Stderr = ec.err
+		input = nextReader
 	}
 
-	for _, ec := range extcmds {
-		if err = ec.cmd.Start(); err != nil {
+	_, copyErr := io.Copy(io.MultiWriter(hasher, pipeWriter), request.reader)
+	pipeWriter.CloseWithError(copyErr)
+	wg.Wait()
+
+	// A stage which failed because another closed its pipe does not
+	// report why, so report the first stage which failed for itself.
+	for _, e := range errs {
+		if e != nil && e != io.ErrClosedPipe {
+			err = e
 			return
 		}
 	}
-
-	if _, err = io.Copy(multiWriter, request.reader); err != nil {
+	if copyErr != nil {
+		err = copyErr
 		return
 	}
-	if err = pipeWriter.Close(); err != nil {
-		return
-	}
-
-	for _, ec := range extcmds {
-		if err = ec.cmd.Wait(); err != nil {
-			if ec.err != nil {
-				errStr := ec.err.String()
-				err = errors.New(tr.Tr.Get("extension '%s' failed with: %s", ec.result.name, errStr))
-			}
-			return
-		}
-		if err = ec.out.Close(); err != nil {
-			return
-		}
-	}
 
 	oid := hex.EncodeToString(hasher.Sum(nil))
 	for _, ec := range extcmds {
@@ -1,6 +1,8 @@
 package lfs
 
 import (
+	"time"
+
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
@@ -10,6 +12,15 @@ import (
 type GitFilter struct {
 	cfg *config.Configuration
 	fs  *fs.Filesystem
+
+	// DownloadRetries is the number of times Smudge starts a download
+	// again when one fails, after any retries made by the transfer queue
+	// itself.
+	DownloadRetries int
+	// DownloadRetryDelay is how long Smudge waits before its first retry
+	// of a download.  The delay doubles before each following retry, up to
+	// the transfer queue's maximum retry delay.
+	DownloadRetryDelay time.Duration
 }
 
 // NewGitFilter initializes a new *GitFilter
@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
 type cleanedAsset struct {
@@ -22,6 +25,27 @@ func (f *GitFilter) Clean(reader io.Reader, fileName string, fileSize int64, cb
 		return nil, err
 	}
 
+	// Content which is already a pointer, such as a file which was not
+	// smudged by an earlier checkout, is passed through unchanged rather
+	// than being stored as a new object.
+	if reader, err = passThroughPointer(reader); err != nil {
+		return nil, err
+	}
+
+	// Files larger than lfs.clean.maxsize are refused before any of their
+	// content is stored.  The size given by the caller may be unknown or
+	// wrong, so the content is also counted as it is read.
+	maxSize, err := f.cleanMaxSize()
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 {
+		if fileSize > maxSize {
+			return nil, newCleanMaxSizeError(fileName, fileSize, maxSize)
+		}
+		reader = &maxSizeReader{r: reader, fileName: fileName, max: maxSize}
+	}
+
 	var oid string
 	var size int64
 	var tmp *os.File
@@ -74,32 +98,159 @@ func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCa
 		cb = nil
 	}
 
-	ptr, buf, err := DecodeFrom(reader)
-
-	by := make([]byte, blobSizeCutoff)
-	n, rerr := buf.Read(by)
-	by = by[:n]
+	size, err = tools.CopyWithCallbackBuffer(writer, reader, fileSize, cb, f.fs.HashBufferSize)
 
-	if rerr != nil || (err == nil && len(by) < blobSizeCutoff) {
-		err = errors.NewCleanPointerError(ptr, by)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
 		return
 	}
 
-	var from io.Reader = bytes.NewReader(by)
-	if fileSize < 0 || int64(len(by)) < fileSize {
-		// If there is still more data to be read from the file, tack on
-		// the original reader and continue the read from there.
-		from = io.MultiReader(from, reader)
+	oid = hex.EncodeToString(oidHash.Sum(nil))
+	return
+}
+
+// cleanMaxSize returns the size of the largest file which the clean filter
+// accepts, as given by lfs.clean.maxsize, or zero if it accepts any size.
+func (f *GitFilter) cleanMaxSize() (int64, error) {
+	v, ok := f.cfg.Git.Get("lfs.clean.maxsize")
+	if !ok || len(v) == 0 {
+		return 0, nil
+	}
+
+	n, err := humanize.ParseBytes(v)
+	if err != nil {
+		return 0, errors.Wrap(err, tr.Tr.Get("invalid lfs.clean.maxsize value %q", v))
 	}
+	return int64(n), nil
+}
 
-	size, err = tools.CopyWithCallback(writer, from, fileSize, cb)
+// ExportCleaned copies the object with the given OID, which the clean filter
+// has stored at "mediafile", into the directory given by lfs.clean.exportdir,
+// if any, naming it after its OID, so that the object store can be rebuilt
+// elsewhere from the exported objects.  Objects which have already been
+// exported are left as they are.
+func (f *GitFilter) ExportCleaned(oid, mediafile string) error {
+	dir, err := f.cleanExportDir()
+	if err != nil || len(dir) == 0 {
+		return err
+	}
 
+	stat, err := os.Stat(mediafile)
 	if err != nil {
-		return
+		return err
 	}
 
-	oid = hex.EncodeToString(oidHash.Sum(nil))
-	return
+	dest := filepath.Join(dir, oid)
+	if exported, err := os.Stat(dest); err == nil && exported.Size() == stat.Size() {
+		return nil
+	}
+
+	if err := tools.MkdirAll(dir, f.cfg); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not create export directory %q", dir))
+	}
+
+	// Copy the object to a temporary file in the export directory first,
+	// so that a partly written object is never found there under its OID.
+	tmp, err := tools.TempFile(dir, oid, f.cfg)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	src, err := os.Open(mediafile)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// cleanExportDir returns the directory given by lfs.clean.exportdir, relative
+// to the root of the working tree unless it is absolute, or the empty string if
+// it is not set.
+func (f *GitFilter) cleanExportDir() (string, error) {
+	v, ok := f.cfg.Git.Get("lfs.clean.exportdir")
+	if !ok || len(v) == 0 {
+		return "", nil
+	}
+
+	dir, err := tools.ExpandPath(v, false)
+	if err != nil {
+		return "", errors.Wrap(err, tr.Tr.Get("invalid lfs.clean.exportdir value %q", v))
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(f.cfg.LocalWorkingDir(), dir)
+	}
+	return dir, nil
+}
+
+// newCleanMaxSizeError returns the error given when the file "fileName" is
+// larger than lfs.clean.maxsize allows.  Its size is given as "size", or as -1
+// if it is not known.
+func newCleanMaxSizeError(fileName string, size, max int64) error {
+	if size < 0 {
+		return errors.New(tr.Tr.Get("%s is larger than %s, the maximum size set by lfs.clean.maxsize; refusing to add it",
+			fileName, humanize.FormatBytes(uint64(max))))
+	}
+	return errors.New(tr.Tr.Get("%s is %s, which is larger than %s, the maximum size set by lfs.clean.maxsize; refusing to add it",
+		fileName, humanize.FormatBytes(uint64(size)), humanize.FormatBytes(uint64(max))))
+}
+
+// maxSizeReader reads from "r", failing as soon as more than "max" bytes have
+// been read from it.
+type maxSizeReader struct {
+	r        io.Reader
+	fileName string
+	max      int64
+	n        int64
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.n += int64(n); r.n > r.max {
+		return 0, newCleanMaxSizeError(r.fileName, -1, r.max)
+	}
+	return n, err
+}
+
+// passThroughPointer reads up to blobSizeCutoff bytes from "reader".  If they
+// are all of its content, and are empty or hold a valid pointer, it returns a
+// CleanPointerError carrying them, so that the caller writes them out as they
+// are.  Otherwise, it returns a reader which yields the entire content.
+//
+// Unlike DecodeFrom, it does not rely on a single read returning all of a
+// short file, so that pointers are recognized even when read from a pipe in
+// small pieces.
+func passThroughPointer(reader io.Reader) (io.Reader, error) {
+	by := make([]byte, blobSizeCutoff)
+	n, err := io.ReadFull(reader, by)
+	by = by[:n]
+
+	switch err {
+	case nil:
+		// There may be more data, so this cannot be a pointer.
+		return io.MultiReader(bytes.NewReader(by), reader), nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		if len(by) == 0 {
+			return nil, errors.NewCleanPointerError(EmptyPointer(), by)
+		}
+		if ptr, perr := decodeKV(bytes.TrimSpace(by)); perr == nil {
+			return nil, errors.NewCleanPointerError(ptr, by)
+		}
+		return bytes.NewReader(by), nil
+	default:
+		return nil, err
+	}
 }
 
 func (a *cleanedAsset) Teardown() error {
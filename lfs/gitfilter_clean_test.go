@@ -0,0 +1,174 @@
+package lfs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cleanTestPointer = `version https://git-lfs.github.com/spec/v1
+oid sha256:cd293be6cea034bd45a0352775a219ef5dc7825ce55d1f7dae9762d80ce64411
+size 9
+`
+
+func TestCleanPassesPointerThrough(t *testing.T) {
+	f := NewGitFilter(config.NewFrom(config.Values{}))
+
+	for desc, content := range map[string]string{
+		"canonical":     cleanTestPointer,
+		"crlf":          strings.Replace(cleanTestPointer, "\n", "\r\n", -1),
+		"no newline":    strings.TrimSuffix(cleanTestPointer, "\n"),
+		"empty content": "",
+	} {
+		for _, oneByte := range []bool{false, true} {
+			var r io.Reader = strings.NewReader(content)
+			if oneByte {
+				r = iotest.OneByteReader(strings.NewReader(content))
+			}
+
+			cleaned, err := f.Clean(r, "file.dat", int64(len(content)), nil)
+			assert.Nil(t, cleaned, "%s (one byte at a time: %t)", desc, oneByte)
+			if assert.True(t, errors.IsCleanPointerError(err), "%s (one byte at a time: %t): %v", desc, oneByte, err) {
+				assert.Equal(t, []byte(content), errors.GetContext(err, "bytes"), "%s (one byte at a time: %t)", desc, oneByte)
+			}
+		}
+	}
+}
+
+func TestCleanPassesPointerThroughWithExtensions(t *testing.T) {
+	cfg := config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.extension.foo.clean":    []string{"false"},
+			"lfs.extension.foo.smudge":   []string{"false"},
+			"lfs.extension.foo.priority": []string{"0"},
+		},
+	})
+	f := NewGitFilter(cfg)
+
+	cleaned, err := f.Clean(strings.NewReader(cleanTestPointer), "file.dat", int64(len(cleanTestPointer)), nil)
+	assert.Nil(t, cleaned)
+	require.True(t, errors.IsCleanPointerError(err), "%v", err)
+	assert.Equal(t, []byte(cleanTestPointer), errors.GetContext(err, "bytes"))
+}
+
+func TestPassThroughPointerKeepsOtherContent(t *testing.T) {
+	for desc, content := range map[string]string{
+		"short":        "whatever\n",
+		"pseudo":       cleanTestPointer + "\nThis is my test pointer.\n",
+		"long":         strings.Repeat("x", blobSizeCutoff*3),
+		"cutoff":       strings.Repeat("x", blobSizeCutoff),
+		"long pointer": cleanTestPointer + strings.Repeat("\n", blobSizeCutoff),
+	} {
+		reader, err := passThroughPointer(iotest.HalfReader(strings.NewReader(content)))
+		require.Nil(t, err, desc)
+
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(reader)
+		require.Nil(t, err, desc)
+		assert.Equal(t, content, buf.String(), desc)
+	}
+}
+
+func TestCleanMaxSize(t *testing.T) {
+	f := NewGitFilter(config.NewFrom(config.Values{
+		Git: map[string][]string{"lfs.clean.maxsize": []string{"2KB"}},
+	}))
+
+	below := strings.Repeat("x", 2000)
+	for _, size := range []int64{int64(len(below)), -1} {
+		cleaned, err := f.Clean(strings.NewReader(below), "below.dat", size, nil)
+		require.Nil(t, err, "size %d", size)
+		assert.EqualValues(t, len(below), cleaned.Size)
+		cleaned.Teardown()
+	}
+
+	above := strings.Repeat("x", 3000)
+	cleaned, err := f.Clean(strings.NewReader(above), "above.dat", int64(len(above)), nil)
+	assert.Nil(t, cleaned)
+	require.NotNil(t, err)
+	assert.Equal(t, "above.dat is 3.0 KB, which is larger than 2.0 KB, the maximum size set by lfs.clean.maxsize; refusing to add it", err.Error())
+
+	// The size is not always known in advance, so the content is also
+	// counted as it is read.
+	for _, size := range []int64{-1, 10} {
+		cleaned, err = f.Clean(strings.NewReader(above), "above.dat", size, nil)
+		assert.Nil(t, cleaned)
+		require.NotNil(t, err, "size %d", size)
+		assert.Equal(t, "above.dat is larger than 2.0 KB, the maximum size set by lfs.clean.maxsize; refusing to add it", err.Error())
+	}
+}
+
+func TestCleanMaxSizePassesPointerThrough(t *testing.T) {
+	f := NewGitFilter(config.NewFrom(config.Values{
+		Git: map[string][]string{"lfs.clean.maxsize": []string{"10"}},
+	}))
+
+	cleaned, err := f.Clean(strings.NewReader(cleanTestPointer), "file.dat", int64(len(cleanTestPointer)), nil)
+	assert.Nil(t, cleaned)
+	assert.True(t, errors.IsCleanPointerError(err), "%v", err)
+}
+
+func TestCleanMaxSizeInvalid(t *testing.T) {
+	f := NewGitFilter(config.NewFrom(config.Values{
+		Git: map[string][]string{"lfs.clean.maxsize": []string{"lots"}},
+	}))
+
+	cleaned, err := f.Clean(strings.NewReader("contents"), "file.dat", 8, nil)
+	assert.Nil(t, cleaned)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), `invalid lfs.clean.maxsize value "lots"`)
+}
+
+func TestExportCleaned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-clean-export")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	mediafile := filepath.Join(dir, "object")
+	require.Nil(t, ioutil.WriteFile(mediafile, []byte("contents"), 0644))
+
+	exportDir := filepath.Join(dir, "export", "nested")
+	f := NewGitFilter(config.NewFrom(config.Values{
+		Git: map[string][]string{"lfs.clean.exportdir": []string{exportDir}},
+	}))
+
+	oid := "d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8"
+	require.Nil(t, f.ExportCleaned(oid, mediafile))
+
+	exported := filepath.Join(exportDir, oid)
+	got, err := ioutil.ReadFile(exported)
+	require.Nil(t, err)
+	assert.Equal(t, "contents", string(got))
+
+	// Exporting the object again is harmless, and a truncated copy is
+	// replaced.
+	require.Nil(t, f.ExportCleaned(oid, mediafile))
+	require.Nil(t, ioutil.WriteFile(exported, []byte("cont"), 0644))
+	require.Nil(t, f.ExportCleaned(oid, mediafile))
+	got, err = ioutil.ReadFile(exported)
+	require.Nil(t, err)
+	assert.Equal(t, "contents", string(got))
+
+	// Only the object itself is left in the export directory.
+	entries, err := ioutil.ReadDir(exportDir)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, oid, entries[0].Name())
+}
+
+func TestExportCleanedWithoutExportDir(t *testing.T) {
+	f := NewGitFilter(config.NewFrom(config.Values{}))
+
+	// Nothing is exported, so the object need not even exist.
+	assert.Nil(t, f.ExportCleaned("d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8", "missing"))
+}
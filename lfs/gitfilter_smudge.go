@@ -5,11 +5,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tq"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
@@ -58,13 +61,28 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 		return 0, err
 	}
 
+	if ptr.HasMisleadingSize() {
+		// Neither writing no content nor removing the local copy, whose
+		// size does not match, would be right.
+		err := errors.New(tr.Tr.Get("the pointer for %s gives its size as 0, but object %s is not empty", workingfile, ptr.Oid))
+		return 0, errors.NewSmudgeError(err, ptr.Oid, mediafile)
+	}
+
 	LinkOrCopyFromReference(f.cfg, ptr.Oid, ptr.Size)
 
 	stat, statErr := os.Stat(mediafile)
 	if statErr == nil && stat != nil {
 		fileSize := stat.Size()
 		if fileSize != ptr.Size {
+			if ptr.Size > 0 && f.failOnSizeMismatch() {
+				err := errors.New(tr.Tr.Get("local object %s is %d bytes, but the pointer for %s gives its size as %d", ptr.Oid, fileSize, workingfile, ptr.Size))
+				return 0, errors.NewSmudgeError(err, ptr.Oid, mediafile)
+			}
+
 			tracerx.Printf("Removing %s, size %d is invalid", mediafile, fileSize)
+			if ptr.Size > 0 {
+				warnings.Warn(warnings.ObjectSizeMismatch, tr.Tr.Get("warning: local object %s is %d bytes, but the pointer for %s gives its size as %d; treating it as corrupt", ptr.Oid, fileSize, workingfile, ptr.Size))
+			}
 			os.RemoveAll(mediafile)
 			stat = nil
 		}
@@ -72,7 +90,7 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 
 	var n int64
 
-	if ptr.Size == 0 {
+	if ptr.Size == 0 && len(ptr.Extensions) == 0 {
 		return 0, nil
 	} else if statErr != nil || stat == nil {
 		if download {
@@ -91,15 +109,47 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 	return n, nil
 }
 
+// failOnSizeMismatch returns whether a local object whose size differs from
+// that given by its pointer should cause smudging to fail, as it does if
+// "lfs.sizeMismatch" is "fail", rather than be removed and downloaded again.
+func (f *GitFilter) failOnSizeMismatch() bool {
+	value, _ := f.cfg.Git.Get("lfs.sizemismatch")
+	return strings.EqualFold(value, "fail")
+}
+
 func (f *GitFilter) downloadFile(writer io.Writer, ptr *Pointer, workingfile, mediafile string, manifest *tq.Manifest, cb tools.CopyCallback) (int64, error) {
 	fmt.Fprintln(os.Stderr, tr.Tr.Get("Downloading %s (%s)", workingfile, humanize.FormatBytes(uint64(ptr.Size))))
 
-	// NOTE: if given, "cb" is a tools.CopyCallback which writes updates
-	// to the logpath specified by GIT_LFS_PROGRESS.
-	//
-	// Either way, forward it into the *tq.TransferQueue so that updates are
-	// sent over correctly.
+	delay := f.DownloadRetryDelay
+	for retry := 0; ; retry++ {
+		err := f.downloadObject(ptr, workingfile, mediafile, manifest, cb)
+		if err == nil {
+			break
+		}
+		// Only failures which the transfer queue would itself have
+		// retried, had it any retries left, are worth trying again.
+		if retry >= f.DownloadRetries || !errors.IsRetriableError(err) {
+			return 0, err
+		}
+
+		tracerx.Printf("smudge: retrying download of %s (%s) in %v: %v", workingfile, ptr.Oid, delay, err)
+		time.Sleep(delay)
 
+		delay *= 2
+		if manifest != nil {
+			if max := time.Duration(manifest.MaxRetryDelay()) * time.Second; delay > max {
+				delay = max
+			}
+		}
+	}
+
+	return f.readLocalFile(writer, ptr, mediafile, workingfile, nil)
+}
+
+// downloadObject downloads the object "ptr" into "mediafile" using a new
+// transfer queue, returning any errors the queue gave as one, which is
+// retriable if all of them were.
+func (f *GitFilter) downloadObject(ptr *Pointer, workingfile, mediafile string, manifest *tq.Manifest, cb tools.CopyCallback) error {
 	q := tq.NewTransferQueue(tq.Download, manifest, f.cfg.Remote(),
 		tq.WithProgressCallback(cb),
 		tq.RemoteRef(f.RemoteRef()),
@@ -109,18 +159,25 @@ func (f *GitFilter) downloadFile(writer io.Writer, ptr *Pointer, workingfile, me
 
 	if errs := q.Errors(); len(errs) > 0 {
 		var multiErr error
+		retriable := true
 		for _, e := range errs {
 			if multiErr != nil {
 				multiErr = fmt.Errorf("%v\n%v", multiErr, e)
 			} else {
 				multiErr = e
 			}
+			if _, later := errors.IsRetriableLaterError(e); !later && !errors.IsRetriableError(e) {
+				retriable = false
+			}
 		}
 
-		return 0, errors.Wrapf(multiErr, tr.Tr.Get("Error downloading %s (%s)", workingfile, ptr.Oid))
+		err := errors.Wrapf(multiErr, tr.Tr.Get("Error downloading %s (%s)", workingfile, ptr.Oid))
+		if retriable {
+			return errors.NewRetriableError(err)
+		}
+		return err
 	}
-
-	return f.readLocalFile(writer, ptr, mediafile, workingfile, nil)
+	return nil
 }
 
 func (f *GitFilter) readLocalFile(writer io.Writer, ptr *Pointer, mediafile string, workingfile string, cb tools.CopyCallback) (int64, error) {
@@ -136,6 +193,9 @@ func (f *GitFilter) readLocalFile(writer io.Writer, ptr *Pointer, mediafile stri
 		}
 	}
 
+	// The extensions come from this pointer alone, not from any other
+	// pointer to the same object, since pointers at different paths may
+	// share an OID while giving different extensions.
 	if len(ptr.Extensions) > 0 {
 		registeredExts := f.cfg.Extensions()
 		extensions := make(map[string]config.Extension)
@@ -3,10 +3,12 @@ package lfs
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
@@ -25,13 +27,69 @@ type GitScanner struct {
 	FoundPointer       GitScannerFoundPointer
 	FoundLockable      GitScannerFoundLockable
 	PotentialLockables GitScannerSet
-	remote             string
-	skippedRefs        []string
-
-	closed  bool
-	started time.Time
-	mu      sync.Mutex
-	cfg     *config.Configuration
+	SkipLockableCheck  bool
+	// FoundLockables, if set, is called instead of FoundLockable with the
+	// names of the lockable files found by a scan in batches, each of
+	// which is delivered once it holds LockableBatchSize names, or once
+	// LockableBatchInterval has passed since its first name was found,
+	// whichever is first.  Any remaining names are delivered before the
+	// scan returns.  It is never called concurrently.
+	FoundLockables        GitScannerFoundLockables
+	LockableBatchSize     int
+	LockableBatchInterval time.Duration
+	// IncludeNotes adds the notes refs to the refs walked by each scan of
+	// refs; see ScanRefsOptions.IncludeNotes.
+	IncludeNotes bool
+	// Pathspecs limits each scan of refs or of all history to the objects
+	// within the given pathspecs, as named at any commit which touches
+	// them; see ScanRefsOptions.Pathspecs.
+	Pathspecs []string
+	// CatFileWorkers is the number of git cat-file processes each scan of
+	// refs or of all history uses to read objects; see
+	// ScanRefsOptions.CatFileWorkers.  If it is not positive,
+	// lfs.catfileworkers gives it, and one is used if that is not set.
+	CatFileWorkers int
+	// MergesOnly and NoMerges limit each scan of refs or of all history
+	// to merge commits, or to commits which are not merges; see
+	// ScanRefsOptions.MergesOnly.
+	MergesOnly bool
+	NoMerges   bool
+	// GitConfig is a list of "key=value" pairs passed with "-c" to the
+	// Git commands run by each scan, after those in defaultGitConfig, so
+	// that they may override them.
+	GitConfig []string
+	// GitExec, if it is not nil, gives the Git executable to run, and the
+	// environment to run it in, as the git rev-list and git cat-file
+	// --batch-check commands run by each scan of refs or of all history;
+	// see git.ExecOptions.
+	GitExec *git.ExecOptions
+	// ContinueOnError makes each scan of refs or of all history skip the
+	// objects, including commits, which are missing from a corrupt
+	// repository, rather than failing; see ScanRefsOptions.ContinueOnError.
+	// The skipped objects are given by Missing.
+	ContinueOnError bool
+	// StrictRefs makes each scan of refs fail, rather than warn, when a
+	// ref is both included and excluded, or is ambiguous; see
+	// ScanRefsOptions.StrictRefs.
+	StrictRefs bool
+	// NameRefBlobs makes each scan of refs or of all history name the
+	// pointers to which a ref points directly; see
+	// ScanRefsOptions.NameRefBlobs.
+	NameRefBlobs bool
+	// ExtensionFilter, if it is not nil, limits the pointers found by
+	// every scan to those which carry any of its extensions.  Lockable
+	// files are found whatever their pointers carry.
+	ExtensionFilter *PointerExtensionFilter
+	remote          string
+	skippedRefs     []string
+	missing         []string
+
+	closed   bool
+	started  time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
+	cfg      *config.Configuration
 }
 
 type GitScannerFoundPointer func(*WrappedPointer, error)
@@ -44,7 +102,34 @@ type GitScannerSet interface {
 // NewGitScanner initializes a *GitScanner for a Git repository in the current
 // working directory.
 func NewGitScanner(cfg *config.Configuration, cb GitScannerFoundPointer) *GitScanner {
-	return &GitScanner{started: time.Now(), FoundPointer: cb, cfg: cfg}
+	return &GitScanner{started: time.Now(), FoundPointer: cb, cfg: cfg, stop: make(chan struct{})}
+}
+
+// Stop cancels any scan in progress, and any which are started later.  Once
+// Stop has been called, no further pointers or errors are passed to the
+// callback, and the commands feeding the scan are terminated rather than left
+// to walk the rest of history.  It is safe to call Stop from within the
+// callback, and to call it more than once.
+func (s *GitScanner) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// Stopped returns whether Stop has been called.
+func (s *GitScanner) Stopped() bool {
+	return isStopped(s.stop)
+}
+
+// isStopped returns whether the given channel, which may be nil, has been
+// closed to signal that a scan should stop.
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
 }
 
 // Close stops exits once all processing has stopped, and all resources are
@@ -76,11 +161,27 @@ func (s *GitScanner) RemoteForPush(r string) error {
 	return nil
 }
 
+// RemoteForLocalPush behaves as RemoteForPush, but does not contact the remote
+// to find which of the remote-tracking refs cached for it are still present
+// there, so that scans exclude the objects reachable from all of them.
+func (s *GitScanner) RemoteForLocalPush(r string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.remote) > 0 && s.remote != r {
+		return errors.New(tr.Tr.Get("trying to set remote to %q, already set to %q", r, s.remote))
+	}
+
+	s.remote = r
+	s.skippedRefs = nil
+	return nil
+}
+
 // ScanRangeToRemote scans through all commits starting at the left ref but not
 // including the right ref (if given)that the given remote does not have. See
 // RemoteForPush().
 func (s *GitScanner) ScanRangeToRemote(left, right string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -99,7 +200,7 @@ func (s *GitScanner) ScanRangeToRemote(left, right string, cb GitScannerFoundPoi
 // not including the right ref (if given) that the given remote does not have.
 // See RemoteForPush().
 func (s *GitScanner) ScanMultiRangeToRemote(left string, rights []string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -117,7 +218,7 @@ func (s *GitScanner) ScanMultiRangeToRemote(left string, rights []string, cb Git
 // ScanRefs through all commits reachable by refs contained in "include" and
 // not reachable by any refs included in "excluded"
 func (s *GitScanner) ScanRefs(include, exclude []string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -127,10 +228,147 @@ func (s *GitScanner) ScanRefs(include, exclude []string, cb GitScannerFoundPoint
 	return scanRefsToChan(s, callback, include, exclude, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
 }
 
+// ScanRemoteRefs scans through all commits reachable from the remote-tracking
+// refs of the given remote, as of when it was last fetched, in the same way as
+// ScanRefs.  Objects reachable only from local branches, tags, or other refs
+// are not reported.
+func (s *GitScanner) ScanRemoteRefs(remote string, cb GitScannerFoundPointer) error {
+	include, err := remoteTrackingRefs(remote)
+	if err != nil {
+		return err
+	}
+	if len(include) == 0 {
+		return nil
+	}
+	return s.ScanRefs(include, nil, cb)
+}
+
+// ScanUniqueTo scans through all commits reachable from the given ref but not
+// from any other ref in the repository, in the same way as ScanRefs, so that
+// only the objects which would become unreachable were that ref deleted are
+// reported.
+func (s *GitScanner) ScanUniqueTo(ref string, cb GitScannerFoundPointer) error {
+	callback, err := s.callback(cb)
+	if err != nil {
+		return err
+	}
+
+	left, err := git.ResolveRef(ref)
+	if err != nil {
+		return err
+	}
+	refs, err := git.AllRefs()
+	if err != nil {
+		return err
+	}
+
+	bases := make([]string, 0, len(refs))
+	for _, r := range refs {
+		if r.Refspec() == left.Refspec() {
+			continue
+		}
+		bases = append(bases, r.Refspec())
+	}
+
+	opts := s.opts(ScanRefsMode)
+	opts.SkipDeletedBlobs = false
+	return scanMultiLeftRightToChan(s, callback, left.Sha, bases, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
+}
+
+// ScanOrphaned scans for the LFS pointers reachable from the "old" ref but not
+// from any of the refs in "keep", such as the objects which a force push
+// replacing "old" with the first of "keep" leaves unreferenced.  The commits
+// only reachable from "old" are scanned first, and then, if any pointers were
+// found, all of the history of "keep", so that pointers which it also refers to,
+// even in commits before the point at which it diverged, are not reported.
+func (s *GitScanner) ScanOrphaned(old string, keep []string, cb GitScannerFoundPointer) error {
+	callback, err := s.callback(cb)
+	if err != nil {
+		return err
+	}
+
+	var orphaned []*WrappedPointer
+	seen := make(map[string]bool)
+	opts := s.opts(ScanRefsMode)
+	opts.SkipDeletedBlobs = false
+	err = scanRefsToChan(s, func(p *WrappedPointer, err error) {
+		if err != nil {
+			callback(nil, err)
+			return
+		}
+		if !seen[p.Oid] {
+			seen[p.Oid] = true
+			orphaned = append(orphaned, p)
+		}
+	}, []string{old}, keep, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
+	if err != nil {
+		return err
+	}
+	if len(orphaned) == 0 || len(keep) == 0 {
+		for _, p := range orphaned {
+			callback(p, nil)
+		}
+		return nil
+	}
+
+	kept := make(map[string]bool)
+	opts = s.opts(ScanRefsMode)
+	opts.SkipDeletedBlobs = false
+	opts.SkipLockableCheck = true
+	err = scanRefsToChan(s, func(p *WrappedPointer, err error) {
+		if err != nil {
+			callback(nil, err)
+			return
+		}
+		kept[p.Oid] = true
+	}, keep, nil, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range orphaned {
+		if !kept[p.Oid] {
+			callback(p, nil)
+		}
+	}
+	return nil
+}
+
+// ScanReflogOnly scans for the LFS pointers in the commits named by reflog
+// entries which are not reachable from any ref, such as those made on a branch
+// which has since been deleted.
+func (s *GitScanner) ScanReflogOnly(cb GitScannerFoundPointer) error {
+	callback, err := s.callback(cb)
+	if err != nil {
+		return err
+	}
+
+	shas, err := git.ReflogShas()
+	if err != nil {
+		return err
+	}
+	if len(shas) == 0 {
+		return nil
+	}
+	refs, err := git.AllRefs()
+	if err != nil {
+		return err
+	}
+
+	bases := make([]string, 0, len(refs))
+	for _, r := range refs {
+		bases = append(bases, r.Refspec())
+	}
+
+	opts := s.opts(ScanRefsMode)
+	opts.SkipDeletedBlobs = false
+	return scanRefsToChan(s, callback, shas, bases, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
+}
+
 // ScanRefRange scans through all commits from the given left and right refs,
 // including git objects that have been modified or deleted.
 func (s *GitScanner) ScanRefRange(left, right string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -143,7 +381,7 @@ func (s *GitScanner) ScanRefRange(left, right string, cb GitScannerFoundPointer)
 // ScanRefRangeByTree scans through all trees from the given left and right
 // refs.
 func (s *GitScanner) ScanRefRangeByTree(left, right string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -163,7 +401,7 @@ func (s *GitScanner) ScanRefWithDeleted(ref string, cb GitScannerFoundPointer) e
 // ScanRef scans through all objects in the current ref, excluding git objects
 // that have been modified or deleted before the ref.
 func (s *GitScanner) ScanRef(ref string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -175,7 +413,7 @@ func (s *GitScanner) ScanRef(ref string, cb GitScannerFoundPointer) error {
 
 // ScanRefByTree scans through all trees in the current ref.
 func (s *GitScanner) ScanRefByTree(ref string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -188,7 +426,7 @@ func (s *GitScanner) ScanRefByTree(ref string, cb GitScannerFoundPointer) error
 
 // ScanAll scans through all objects in the git repository.
 func (s *GitScanner) ScanAll(cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -202,26 +440,43 @@ func (s *GitScanner) ScanAll(cb GitScannerFoundPointer) error {
 // ref. Differs from ScanRefs in that multiple files in the tree with the same
 // content are all reported.
 func (s *GitScanner) ScanTree(ref string) error {
-	callback, err := firstGitScannerCallback(s.FoundPointer)
+	callback, err := s.callback(nil)
+	if err != nil {
+		return err
+	}
+	return runScanTree(callback, ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv(), s.gitConfig(), s.stop)
+}
+
+// ScanTreeForPointers scans only the tree given by "treeish", which may name a
+// commit, and reports the pointers in it, without walking any history as
+// ScanRefByTree does.  Like ScanRefByTree, it reports an error for each file
+// which the .gitattributes in the tree say should be a pointer but is not.  If
+// "opt" is nil, the scanner's own options are used.
+func (s *GitScanner) ScanTreeForPointers(treeish string, opt *ScanRefsOptions) error {
+	callback, err := s.callback(nil)
 	if err != nil {
 		return err
 	}
-	return runScanTree(callback, ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv())
+
+	if opt == nil {
+		opt = s.opts(ScanRefsMode)
+	}
+	return runScanTreeForPointers(callback, treeish, s.cfg.GitEnv(), s.cfg.OSEnv(), opt.GitConfig, opt.stop)
 }
 
 // ScanUnpushed scans history for all LFS pointers which have been added but not
 // pushed to the named remote. remote can be left blank to mean 'any remote'.
 func (s *GitScanner) ScanUnpushed(remote string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
-	return scanUnpushed(callback, remote)
+	return scanUnpushed(callback, remote, s.gitConfig())
 }
 
 // ScanStashed scans for all LFS pointers referenced solely by a stash
 func (s *GitScanner) ScanStashed(cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
@@ -229,25 +484,64 @@ func (s *GitScanner) ScanStashed(cb GitScannerFoundPointer) error {
 	return scanStashed(callback, s)
 }
 
+// ScanNotes scans for all LFS pointers in the trees of the notes refs, those
+// under refs/notes/, without walking their history, as ScanRef does for a
+// single ref.
+func (s *GitScanner) ScanNotes(cb GitScannerFoundPointer) error {
+	callback, err := s.callback(cb)
+	if err != nil {
+		return err
+	}
+
+	opts := s.opts(ScanRefsMode)
+	opts.SkipDeletedBlobs = true
+	opts.IncludeNotes = true
+	return scanRefsToChan(s, callback, nil, nil, s.cfg.GitEnv(), s.cfg.OSEnv(), opts)
+}
+
 // ScanPreviousVersions scans changes reachable from ref (commit) back to since.
 // Returns channel of pointers for *previous* versions that overlap that time.
 // Does not include pointers which were still in use at ref (use ScanRefsToChan
 // for that)
 func (s *GitScanner) ScanPreviousVersions(ref string, since time.Time, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
-	return logPreviousSHAs(callback, ref, since)
+	return logPreviousSHAs(callback, ref, since, s.gitConfig())
 }
 
 // ScanIndex scans the git index for modified LFS objects.
 func (s *GitScanner) ScanIndex(ref string, cb GitScannerFoundPointer) error {
-	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	callback, err := s.callback(cb)
+	if err != nil {
+		return err
+	}
+	return scanIndex(callback, "", ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv(), s.gitConfig())
+}
+
+// ScanWorktreeIndex is like ScanIndex, but scans the index of the worktree at
+// "dir", which may be a linked worktree other than the current one, against
+// the given ref as resolved in that worktree.
+func (s *GitScanner) ScanWorktreeIndex(dir, ref string, cb GitScannerFoundPointer) error {
+	callback, err := s.callback(cb)
 	if err != nil {
 		return err
 	}
-	return scanIndex(callback, ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv())
+	return scanIndex(callback, dir, ref, s.Filter, s.cfg.GitEnv(), s.cfg.OSEnv(), s.gitConfig())
+}
+
+// defaultGitConfig is the configuration passed to the Git commands run by every
+// scan.  Quoting is disabled so that paths containing non-ASCII characters are
+// reported as they are, rather than with those characters octal-escaped.
+var defaultGitConfig = []string{"core.quotePath=false"}
+
+// gitConfig returns the "key=value" pairs to pass to the Git commands run by a
+// scan.
+func (s *GitScanner) gitConfig() []string {
+	config := make([]string, 0, len(defaultGitConfig)+len(s.GitConfig))
+	config = append(config, defaultGitConfig...)
+	return append(config, s.GitConfig...)
 }
 
 func (s *GitScanner) opts(mode ScanningMode) *ScanRefsOptions {
@@ -257,10 +551,64 @@ func (s *GitScanner) opts(mode ScanningMode) *ScanRefsOptions {
 	opts := newScanRefsOptions()
 	opts.ScanMode = mode
 	opts.RemoteName = s.remote
+	opts.stop = s.stop
 	opts.skippedRefs = s.skippedRefs
+	opts.GitConfig = s.gitConfig()
+	opts.GitExec = s.GitExec
+	opts.IncludeNotes = s.IncludeNotes
+	opts.Pathspecs = s.Pathspecs
+	opts.MergesOnly = s.MergesOnly
+	opts.NoMerges = s.NoMerges
+	opts.CatFileWorkers = s.CatFileWorkers
+	opts.ContinueOnError = s.ContinueOnError
+	opts.StrictRefs = s.StrictRefs
+	opts.NameRefBlobs = s.NameRefBlobs
+	opts.foundMissing = s.addMissing
+	if opts.CatFileWorkers < 1 && s.cfg != nil {
+		opts.CatFileWorkers = s.cfg.Git.Int("lfs.catfileworkers", 1)
+	}
+	opts.SkipLockableCheck = s.SkipLockableCheck ||
+		(s.FoundLockable == nil && s.FoundLockables == nil) ||
+		s.PotentialLockables == nil
 	return opts
 }
 
+// addMissing records the objects skipped by a scan because they are missing.
+func (s *GitScanner) addMissing(oids []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.missing = append(s.missing, oids...)
+}
+
+// Missing returns the OIDs of the objects, including commits, which the scans
+// run so far have skipped because they are missing from the repository, with
+// ContinueOnError set.
+func (s *GitScanner) Missing() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.missing...)
+}
+
+// callback returns the first of "cb" and s.FoundPointer which is non-nil,
+// wrapped so that it is no longer called once the scanner has been stopped.
+func (s *GitScanner) callback(cb GitScannerFoundPointer) (GitScannerFoundPointer, error) {
+	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(p *WrappedPointer, err error) {
+		if p != nil && err == nil && !s.ExtensionFilter.Matches(p.Pointer) {
+			return
+		}
+		if !s.Stopped() {
+			callback(p, err)
+		}
+	}, nil
+}
+
 func firstGitScannerCallback(callbacks ...GitScannerFoundPointer) (GitScannerFoundPointer, error) {
 	for _, cb := range callbacks {
 		if cb == nil {
@@ -280,14 +628,86 @@ const (
 	ScanRangeToRemoteMode = ScanningMode(iota)
 )
 
+// ScanRefsOptions configures a single scan of history.  The options keep
+// track of the names of the blobs the scan finds, so they must not be shared
+// between scans, whether they run one after the other or at the same time: a
+// scan given options which another has already used fails with
+// errScanRefsOptionsReused.  Use a new set of options for each scan.
 type ScanRefsOptions struct {
 	ScanMode         ScanningMode
 	RemoteName       string
 	SkipDeletedBlobs bool
 	CommitsOnly      bool
-	skippedRefs      []string
-	nameMap          map[string]string
-	mutex            *sync.Mutex
+	// MergesOnly limits the commits walked to merge commits, and NoMerges
+	// to those which are not merges, by passing --merges or --no-merges to
+	// git rev-list.  By default both are false, and all commits are walked.
+	MergesOnly bool
+	NoMerges   bool
+	// SkipLockableCheck bypasses classifying blobs as lockable files, for
+	// scans whose callers do not need to know about them. It is set when
+	// the *GitScanner has SkipLockableCheck set, or has no FoundLockable
+	// or FoundLockables callback or PotentialLockables set.
+	SkipLockableCheck bool
+	// IncludeNotes adds the notes refs, those under refs/notes/, to the
+	// refs walked by a scan in ScanRefsMode, so that objects referenced
+	// only by notes are found.  Scans in ScanAllMode always include them.
+	IncludeNotes bool
+	// Pathspecs limits the commits walked to those which touch the given
+	// pathspecs, and the objects found to those within them, named by the
+	// paths at which they appear within them.
+	Pathspecs []string
+	// CatFileWorkers is the number of git cat-file processes among which
+	// the objects found by the scan are shared, both to find which are
+	// small enough to be pointers and to read those which are.  Each object
+	// is read by only one of them.  Values below one mean one.
+	CatFileWorkers int
+	// GitConfig is a list of "key=value" pairs passed with "-c" to the
+	// Git commands run by the scan.
+	GitConfig []string
+	// GitExec, if it is not nil, gives the Git executable to run, and the
+	// environment to run it in, as git-rev-list(1) and git-cat-file(1)
+	// --batch-check.
+	GitExec *git.ExecOptions
+	// ContinueOnError skips the objects, including commits, which are
+	// missing from a corrupt repository, rather than failing the scan
+	// when git-rev-list(1) reaches one.  Each of them is logged, and they
+	// are passed to foundMissing, if it is set, once the walk is over.
+	// By default, a missing object fails the scan.
+	ContinueOnError bool
+	// StrictRefs fails a scan given a ref which it both includes and
+	// excludes, and so from which it would walk nothing, rather than
+	// warning of it.  Object IDs are not checked; see overlappingRefs.
+	// It also fails a scan given a short ref name which matches more than
+	// one ref, rather than warning of the one chosen; see
+	// resolveAmbiguousRefs.
+	StrictRefs bool
+	// NameRefBlobs names the pointers to which a ref points directly, and
+	// which git-rev-list(1) therefore lists without a path, by the path at
+	// which each was introduced in the history scanned; see nameRefBlobs.
+	// This costs a git-for-each-ref(1) run, and a git-log(1) walk if any
+	// such pointers are found, so it is only done for callers which report
+	// names.
+	NameRefBlobs bool
+	foundMissing func([]string)
+	skippedRefs  []string
+	stop         <-chan struct{}
+	nameMap      map[string]string
+	mutex        *sync.Mutex
+	// started is non-zero once a scan has begun using these options.
+	started int32
+}
+
+// errScanRefsOptionsReused is returned by scans given a *ScanRefsOptions
+// which another scan has already used.
+var errScanRefsOptionsReused = errors.New(tr.Tr.Get("scan options may only be used by one scan"))
+
+// start marks the options as used by a scan, returning
+// errScanRefsOptionsReused if another scan has already used them.
+func (o *ScanRefsOptions) start() error {
+	if !atomic.CompareAndSwapInt32(&o.started, 0, 1) {
+		return errScanRefsOptionsReused
+	}
+	return nil
 }
 
 func (o *ScanRefsOptions) GetName(sha string) (string, bool) {
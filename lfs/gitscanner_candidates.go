@@ -0,0 +1,200 @@
+package lfs
+
+import (
+	"sync"
+)
+
+// ScanResult is a blob found by a CandidateScanner which either is a valid
+// pointer, or looks like one but could not be decoded.
+type ScanResult struct {
+	// Pointer is the pointer decoded from the blob, or nil if it could
+	// not be decoded.
+	Pointer *Pointer
+	// Err is why the blob could not be decoded as a pointer, or nil if it
+	// was.
+	Err error
+	// Name is the path at which the blob first appears in the history
+	// scanned, or empty if it has none.
+	Name string
+	// Sha is the hex SHA-1 of the blob.
+	Sha string
+}
+
+// Valid returns whether the blob was decoded as a pointer.
+func (r *ScanResult) Valid() bool {
+	return r.Err == nil
+}
+
+// CandidateScanner gives each blob in the history walked by a scan which is
+// small enough to be a pointer and which either is a valid one, or begins with
+// a pointer's version line but is not valid, so that callers such as
+// validators can see both in a single stream and decide what to do with each.
+//
+// Like the other scanners, it is used by calling Scan until it returns false,
+// reading each result with Result, and then checking Err.  Close must be
+// called once it is no longer needed.
+type CandidateScanner struct {
+	results <-chan *ScanResult
+	result  *ScanResult
+	// err is set before results is closed.
+	err error
+
+	stop      chan struct{}
+	stopOnce  sync.Once
+	closeOnce sync.Once
+}
+
+// ScanCandidates starts a scan of the blobs reachable from the refs in
+// "include" but not from those in "exclude", or of every blob in the
+// repository in ScanAllMode, and returns a *CandidateScanner giving those
+// which are, or look like, pointers.  Each blob is given once, by the first
+// path at which it appears, and only if the scanner's Filter allows that path.
+func (s *GitScanner) ScanCandidates(include, exclude []string, mode ScanningMode) (*CandidateScanner, error) {
+	opt := s.opts(mode)
+	opt.SkipLockableCheck = true
+	if err := opt.start(); err != nil {
+		return nil, err
+	}
+
+	c := &CandidateScanner{stop: make(chan struct{})}
+	opt.stop = c.stop
+	go func() {
+		select {
+		case <-s.stop:
+			c.stopScan()
+		case <-c.stop:
+		}
+	}()
+
+	revs, err := revListShas(include, exclude, opt)
+	if err != nil {
+		c.stopScan()
+		return nil, err
+	}
+
+	smallShas, _, err := catFileBatchCheck(revs, nil, opt.GitExec, opt.GitConfig, 1)
+	if err != nil {
+		c.stopScan()
+		return nil, err
+	}
+
+	ps, err := NewPointerScanner(s.cfg.GitEnv(), s.cfg.OSEnv())
+	if err != nil {
+		c.stopScan()
+		return nil, err
+	}
+
+	results := make(chan *ScanResult, chanBufSize)
+	c.results = results
+	go c.run(s, ps, smallShas, results, include, exclude, opt)
+	return c, nil
+}
+
+// run reads each of the blobs in "shas" with "ps", and sends those which are
+// candidates on "results", closing it once they have all been read.
+func (c *CandidateScanner) run(s *GitScanner, ps *PointerScanner, shas *StringChannelWrapper, results chan<- *ScanResult, include, exclude []string, opt *ScanRefsOptions) {
+	defer close(results)
+
+	var err error
+	var unnamed []*ScanResult
+	for sha := range shas.Results {
+		if err != nil || isStopped(c.stop) {
+			// Drain the remaining blobs so that the commands
+			// feeding them can exit.
+			continue
+		}
+
+		if !ps.Scan(sha) {
+			err = ps.Err()
+			continue
+		}
+
+		r := &ScanResult{Sha: ps.BlobSHA(), Err: ps.InvalidPointerErr()}
+		if p := ps.Pointer(); p != nil {
+			r.Pointer = p.Pointer
+		} else if r.Err == nil {
+			continue
+		}
+
+		if name, ok := opt.GetName(r.Sha); ok && len(name) > 0 {
+			r.Name = name
+		} else if !opt.CommitsOnly {
+			// Resolve the name from history once all other
+			// candidates have been given.
+			unnamed = append(unnamed, r)
+			continue
+		}
+		c.send(s, results, r)
+	}
+
+	if werr := shas.Wait(); err == nil {
+		err = werr
+	}
+	if cerr := ps.Close(); err == nil {
+		err = cerr
+	}
+
+	if len(unnamed) > 0 && err == nil && !isStopped(c.stop) {
+		unnamedShas := make(map[string]struct{}, len(unnamed))
+		for _, r := range unnamed {
+			unnamedShas[r.Sha] = struct{}{}
+		}
+
+		var names map[string]string
+		names, err = historicalNames(historicalRevs(include, exclude, opt), unnamedShas, opt.GitConfig)
+		for _, r := range unnamed {
+			r.Name = names[r.Sha]
+			c.send(s, results, r)
+		}
+	}
+	c.err = err
+}
+
+// send gives "r" on "results", unless the scanner's Filter does not allow its
+// name or the scan has been stopped.
+func (c *CandidateScanner) send(s *GitScanner, results chan<- *ScanResult, r *ScanResult) {
+	if !s.Filter.Allows(r.Name) {
+		return
+	}
+
+	select {
+	case results <- r:
+	case <-c.stop:
+	}
+}
+
+// Scan advances to the next result, returning false once there are no more,
+// or once the scan has failed or been stopped.
+func (c *CandidateScanner) Scan() bool {
+	r, ok := <-c.results
+	c.result = r
+	return ok
+}
+
+// Result returns the result which the last call to Scan advanced to.
+func (c *CandidateScanner) Result() *ScanResult {
+	return c.result
+}
+
+// Err returns the error which ended the scan, if any, once Scan has returned
+// false.  Results which are not valid pointers are not errors of the scan.
+func (c *CandidateScanner) Err() error {
+	return c.err
+}
+
+// Close stops the scan, if it has not finished, and waits for the commands
+// feeding it to exit.  It returns the same error as Err.
+func (c *CandidateScanner) Close() error {
+	c.closeOnce.Do(func() {
+		c.stopScan()
+		for range c.results {
+		}
+	})
+	return c.err
+}
+
+func (c *CandidateScanner) stopScan() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
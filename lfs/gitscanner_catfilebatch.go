@@ -69,6 +69,7 @@ type PointerScanner struct {
 	blobSha     string
 	contentsSha string
 	pointer     *WrappedPointer
+	invalidErr  error
 	err         error
 }
 
@@ -97,14 +98,22 @@ func (s *PointerScanner) Err() error {
 	return s.err
 }
 
+// InvalidPointerErr returns why the last blob scanned could not be decoded as
+// a pointer, if it was small enough to be one and began with a pointer's
+// version line, so was likely meant to be one.  Otherwise it returns nil.
+func (s *PointerScanner) InvalidPointerErr() error {
+	return s.invalidErr
+}
+
 func (s *PointerScanner) Scan(sha string) bool {
-	s.pointer, s.err = nil, nil
+	s.pointer, s.invalidErr, s.err = nil, nil, nil
 	s.blobSha, s.contentsSha = "", ""
 
-	b, c, p, err := s.next(sha)
+	b, c, p, invalidErr, err := s.next(sha)
 	s.blobSha = b
 	s.contentsSha = c
 	s.pointer = p
+	s.invalidErr = invalidErr
 
 	if err != nil {
 		if err != io.EOF {
@@ -120,12 +129,12 @@ func (s *PointerScanner) Close() error {
 	return s.scanner.Close()
 }
 
-func (s *PointerScanner) next(blob string) (string, string, *WrappedPointer, error) {
+func (s *PointerScanner) next(blob string) (string, string, *WrappedPointer, error, error) {
 	if !s.scanner.Scan(blob) {
 		if err := s.scanner.Err(); err != nil {
-			return "", "", nil, err
+			return "", "", nil, nil, err
 		}
-		return "", "", nil, io.EOF
+		return "", "", nil, nil, io.EOF
 	}
 
 	blobSha := s.scanner.Sha1()
@@ -142,19 +151,23 @@ func (s *PointerScanner) next(blob string) (string, string, *WrappedPointer, err
 
 	read, err := io.CopyN(to, s.scanner.Contents(), int64(size))
 	if err != nil {
-		return blobSha, "", nil, err
+		return blobSha, "", nil, nil, err
 	}
 
 	if int64(size) != read {
-		return blobSha, "", nil, errors.New(tr.Tr.Get("expected %d bytes, read %d bytes", size, read))
+		return blobSha, "", nil, nil, errors.New(tr.Tr.Get("expected %d bytes, read %d bytes", size, read))
 	}
 
 	var pointer *WrappedPointer
 	var contentsSha string
+	var invalidErr error
 
 	if size < blobSizeCutoff {
 		if p, err := DecodePointer(bytes.NewReader(buf.Bytes())); err != nil {
 			contentsSha = fmt.Sprintf("%x", sha.Sum(nil))
+			if LooksLikePointer(buf.Bytes()) {
+				invalidErr = err
+			}
 		} else {
 			pointer = &WrappedPointer{
 				Sha1:    blobSha,
@@ -166,5 +179,5 @@ func (s *PointerScanner) next(blob string) (string, string, *WrappedPointer, err
 		contentsSha = fmt.Sprintf("%x", sha.Sum(nil))
 	}
 
-	return blobSha, contentsSha, pointer, err
+	return blobSha, contentsSha, pointer, invalidErr, err
 }
@@ -16,8 +16,8 @@ import (
 // blobSizeCutoff will be ignored, unless it's a locked file. revs is a channel
 // over which strings containing git sha1s will be sent. It returns a channel
 // from which sha1 strings can be read.
-func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockableSet *lockableNameSet, revs *StringChannelWrapper, errCh chan error) error {
-	cmd, err := git.CatFile()
+func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockableSet *lockableNameSet, revs *StringChannelWrapper, errCh chan error, gitExec *git.ExecOptions, gitConfig []string) error {
+	cmd, err := git.CatFileWithOptions(gitExec, gitConfig)
 	if err != nil {
 		return err
 	}
@@ -54,6 +54,7 @@ func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockab
 		}
 		close(smallRevCh)
 		close(errCh)
+		close(lockableCh)
 	}()
 
 	return nil
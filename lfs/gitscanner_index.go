@@ -12,20 +12,21 @@ import (
 // it finds in the index.
 //
 // Ref is the ref at which to scan, which may be "HEAD" if there is at least one
-// commit.
-func scanIndex(cb GitScannerFoundPointer, ref string, f *filepathfilter.Filter, gitEnv, osEnv config.Environment) error {
+// commit.  Dir is the worktree whose index is scanned, or empty for the current
+// worktree.
+func scanIndex(cb GitScannerFoundPointer, dir, ref string, f *filepathfilter.Filter, gitEnv, osEnv config.Environment, gitConfig []string) error {
 	indexMap := &indexFileMap{
 		nameMap:      make(map[string][]*indexFile),
 		nameShaPairs: make(map[string]bool),
 		mutex:        &sync.Mutex{},
 	}
 
-	revs, err := revListIndex(ref, false, indexMap)
+	revs, err := revListIndex(dir, ref, false, indexMap, gitConfig)
 	if err != nil {
 		return err
 	}
 
-	cachedRevs, err := revListIndex(ref, true, indexMap)
+	cachedRevs, err := revListIndex(dir, ref, true, indexMap, gitConfig)
 	if err != nil {
 		return err
 	}
@@ -61,14 +62,14 @@ func scanIndex(cb GitScannerFoundPointer, ref string, f *filepathfilter.Filter,
 		close(allRevsErr)
 	}()
 
-	smallShas, _, err := catFileBatchCheck(allRevs, nil)
+	smallShas, _, err := catFileBatchCheck(allRevs, nil, nil, gitConfig, 1)
 	if err != nil {
 		return err
 	}
 
 	ch := make(chan gitscannerResult, chanBufSize)
 
-	barePointerCh, _, err := catFileBatch(smallShas, nil, gitEnv, osEnv)
+	barePointerCh, _, err := catFileBatch(smallShas, nil, gitEnv, osEnv, 1)
 	if err != nil {
 		return err
 	}
@@ -109,8 +110,8 @@ func scanIndex(cb GitScannerFoundPointer, ref string, f *filepathfilter.Filter,
 // revListIndex uses git diff-index to return the list of object sha1s
 // for in the indexf. It returns a channel from which sha1 strings can be read.
 // The namMap will be filled indexFile pointers mapping sha1s to indexFiles.
-func revListIndex(atRef string, cache bool, indexMap *indexFileMap) (*StringChannelWrapper, error) {
-	scanner, err := NewDiffIndexScanner(atRef, cache, false)
+func revListIndex(dir, atRef string, cache bool, indexMap *indexFileMap, gitConfig []string) (*StringChannelWrapper, error) {
+	scanner, err := newDiffIndexScannerIn(dir, atRef, cache, false, gitConfig)
 	if err != nil {
 		return nil, err
 	}
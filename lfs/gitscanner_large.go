@@ -0,0 +1,65 @@
+package lfs
+
+import (
+	"io/ioutil"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// LargeNonPointerBlobs returns the blobs at the given paths in the tree of
+// "ref" which are at least "threshold" bytes in size and are not Git LFS
+// pointers, such as files committed without being tracked.
+func LargeNonPointerBlobs(gitEnv, osEnv config.Environment, ref string, paths []string, threshold int64) ([]*git.TreeBlob, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	cmd, err := git.LsTreePaths(ref, paths)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin.Close()
+
+	var large []*git.TreeBlob
+	scanner := git.NewLsTreeScanner(cmd.Stdout)
+	for scanner.Scan() {
+		if t := scanner.TreeBlob(); t != nil && t.Size >= threshold {
+			large = append(large, t)
+		}
+	}
+
+	stderr, _ := ioutil.ReadAll(cmd.Stderr)
+	if err := cmd.Wait(); err != nil {
+		return nil, errors.New(tr.Tr.Get("error in `git ls-tree`: %v %v", err, string(stderr)))
+	}
+
+	// Pointers are always smaller than blobSizeCutoff, so only the blobs
+	// which are smaller than that need to be read to rule them out.
+	var pscanner *PointerScanner
+	ret := make([]*git.TreeBlob, 0, len(large))
+	for _, t := range large {
+		if t.Size < blobSizeCutoff {
+			if pscanner == nil {
+				pscanner, err = NewPointerScanner(gitEnv, osEnv)
+				if err != nil {
+					return nil, err
+				}
+				defer pscanner.Close()
+			}
+
+			if !pscanner.Scan(t.Oid) {
+				if err := pscanner.Err(); err != nil {
+					return nil, err
+				}
+			}
+			if pscanner.Pointer() != nil {
+				continue
+			}
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
@@ -0,0 +1,96 @@
+package lfs
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ScanRefLargest scans the objects in the given ref as ScanRef does, but
+// reports the pointers it finds largest-first, once the scan has finished.
+// Pointers of the same size are reported in order of their OIDs.  If "n" is
+// greater than zero, only the "n" largest pointers are reported, and no more
+// than that many are held in memory at once.  Errors are passed to the callback
+// as soon as they are found.
+func (s *GitScanner) ScanRefLargest(ref string, n int, cb GitScannerFoundPointer) error {
+	callback, err := s.callback(cb)
+	if err != nil {
+		return err
+	}
+
+	largest := newLargestPointers(n)
+	err = s.ScanRef(ref, func(p *WrappedPointer, err error) {
+		if err != nil {
+			callback(nil, err)
+			return
+		}
+		largest.Add(p)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range largest.Sorted() {
+		callback(p, nil)
+	}
+	return nil
+}
+
+// largestPointers selects the largest of the pointers given to Add, keeping at
+// most "n" of them in a min-heap so that the smallest kept so far can be
+// replaced cheaply.  If "n" is zero or less, every pointer is kept.
+type largestPointers struct {
+	n        int
+	pointers pointersBySize
+}
+
+func newLargestPointers(n int) *largestPointers {
+	return &largestPointers{n: n}
+}
+
+// Add offers the pointer "p" for selection.
+func (l *largestPointers) Add(p *WrappedPointer) {
+	if l.n <= 0 || len(l.pointers) < l.n {
+		heap.Push(&l.pointers, p)
+		return
+	}
+	if l.pointers.smaller(l.pointers[0], p) {
+		l.pointers[0] = p
+		heap.Fix(&l.pointers, 0)
+	}
+}
+
+// Sorted returns the selected pointers, largest-first.
+func (l *largestPointers) Sorted() []*WrappedPointer {
+	sorted := make([]*WrappedPointer, len(l.pointers))
+	copy(sorted, l.pointers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return l.pointers.smaller(sorted[j], sorted[i])
+	})
+	return sorted
+}
+
+// pointersBySize implements heap.Interface, keeping the smallest pointer, or of
+// equally small ones the one with the greatest OID, at the root.
+type pointersBySize []*WrappedPointer
+
+func (p pointersBySize) smaller(a, b *WrappedPointer) bool {
+	if a.Size != b.Size {
+		return a.Size < b.Size
+	}
+	return a.Oid > b.Oid
+}
+
+func (p pointersBySize) Len() int           { return len(p) }
+func (p pointersBySize) Less(i, j int) bool { return p.smaller(p[i], p[j]) }
+func (p pointersBySize) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (p *pointersBySize) Push(x interface{}) {
+	*p = append(*p, x.(*WrappedPointer))
+}
+
+func (p *pointersBySize) Pop() interface{} {
+	old := *p
+	x := old[len(old)-1]
+	*p = old[:len(old)-1]
+	return x
+}
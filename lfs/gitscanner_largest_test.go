@@ -0,0 +1,73 @@
+package lfs
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func largestPointerOids(l *largestPointers) []string {
+	var oids []string
+	for _, p := range l.Sorted() {
+		oids = append(oids, p.Oid)
+	}
+	return oids
+}
+
+func TestLargestPointersTopN(t *testing.T) {
+	var all []*WrappedPointer
+	for i := 0; i < 100; i++ {
+		all = append(all, &WrappedPointer{
+			Pointer: &Pointer{Oid: fmt.Sprintf("%03d", i), Size: int64(i * 7 % 100)},
+		})
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(all), func(i, j int) {
+		all[i], all[j] = all[j], all[i]
+	})
+
+	expected := make([]*WrappedPointer, len(all))
+	copy(expected, all)
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].Size > expected[j].Size
+	})
+
+	for _, n := range []int{1, 5, 99, 100, 150} {
+		l := newLargestPointers(n)
+		for _, p := range all {
+			l.Add(p)
+		}
+
+		sorted := l.Sorted()
+		want := n
+		if want > len(all) {
+			want = len(all)
+		}
+		if assert.Len(t, sorted, want, "n=%d", n) {
+			for i, p := range sorted {
+				assert.Equal(t, expected[i].Size, p.Size, "n=%d, i=%d", n, i)
+			}
+		}
+	}
+}
+
+func TestLargestPointersTies(t *testing.T) {
+	l := newLargestPointers(2)
+	for _, oid := range []string{"c", "a", "d", "b"} {
+		l.Add(&WrappedPointer{Pointer: &Pointer{Oid: oid, Size: 10}})
+	}
+	l.Add(&WrappedPointer{Pointer: &Pointer{Oid: "e", Size: 5}})
+
+	assert.Equal(t, []string{"a", "b"}, largestPointerOids(l))
+}
+
+func TestLargestPointersUnbounded(t *testing.T) {
+	l := newLargestPointers(0)
+	for i, size := range []int64{3, 1, 4, 1, 5} {
+		l.Add(&WrappedPointer{Pointer: &Pointer{Oid: fmt.Sprint(i), Size: size}})
+	}
+
+	assert.Equal(t, []string{"4", "2", "0", "1", "3"}, largestPointerOids(l))
+}
@@ -0,0 +1,246 @@
+package lfs
+
+import (
+	"bufio"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/git-lfs/gitobj/v2"
+)
+
+// LifespanCommit identifies one end of an *ObjectLifespan.
+type LifespanCommit struct {
+	Sha string `json:"sha"`
+	// Date is the committer date of the commit.
+	Date time.Time `json:"date"`
+}
+
+// ObjectLifespan describes the commits whose trees contain a pointer to one
+// Git LFS object.
+type ObjectLifespan struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+	// Name is a path at which the pointer appears in the tree of the
+	// first commit.
+	Name string `json:"name"`
+	// First and Last are the commits with the earliest and latest
+	// committer dates which contain the pointer.
+	First LifespanCommit `json:"first_commit"`
+	Last  LifespanCommit `json:"last_commit"`
+	// Commits is the number of commits which contain the pointer.
+	Commits int `json:"commits"`
+}
+
+// ScanLifespans walks the commits reachable from the refs in "include" but not
+// from those in "exclude", or every commit if the scanner is in ScanAllMode,
+// and returns the lifespan of each object whose pointer appears in any of
+// their trees, in order of the dates of the first commits.  Only pointers at
+// paths which the scanner's Filter allows are considered.
+//
+// Unlike the other scans, which report each pointer once, this reads the tree
+// of every commit, sharing the work for the subtrees which commits have in
+// common.
+func (s *GitScanner) ScanLifespans(include, exclude []string, mode ScanningMode) ([]*ObjectLifespan, error) {
+	opt := s.opts(mode)
+
+	dir, err := git.GitCommonDir()
+	if err != nil {
+		return nil, err
+	}
+	db, err := git.ObjectDatabase(s.cfg.OSEnv(), s.cfg.GitEnv(), dir, s.cfg.TempDir())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	revs := historicalRevs(include, exclude, opt)
+	if len(revs) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"--format=%H %T %ct"}, revs...)
+	args = append(args, "--")
+	cmd, err := git.LogWithConfig(opt.GitConfig, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	walker := newLifespanWalker(db)
+	lifespans := make(map[string]*ObjectLifespan)
+
+	scanner := bufio.NewScanner(cmd.Stdout)
+	for scanner.Scan() {
+		if isStopped(opt.stop) {
+			break
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		secs, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		commit := LifespanCommit{Sha: fields[0], Date: time.Unix(secs, 0).UTC()}
+
+		pointers, err := walker.Tree(fields[1])
+		if err != nil {
+			cmd.Wait()
+			return nil, err
+		}
+
+		seen := make(map[string]struct{}, len(pointers))
+		for _, p := range pointers {
+			if _, ok := seen[p.oid]; ok {
+				continue
+			}
+			if s.Filter != nil && !s.Filter.Allows(p.name) {
+				continue
+			}
+			seen[p.oid] = struct{}{}
+
+			l, ok := lifespans[p.oid]
+			if !ok {
+				lifespans[p.oid] = &ObjectLifespan{
+					Oid:     p.oid,
+					Size:    p.size,
+					Name:    p.name,
+					First:   commit,
+					Last:    commit,
+					Commits: 1,
+				}
+				continue
+			}
+
+			l.Commits++
+			if commit.Date.Before(l.First.Date) {
+				l.First = commit
+				l.Name = p.name
+			}
+			if commit.Date.After(l.Last.Date) {
+				l.Last = commit
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		cmd.Wait()
+		return nil, errors.Wrap(err, tr.Tr.Get("could not read `git log` output"))
+	}
+	if err := cmd.Wait(); err != nil && !isStopped(opt.stop) {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not list commits"))
+	}
+
+	sorted := make([]*ObjectLifespan, 0, len(lifespans))
+	for _, l := range lifespans {
+		sorted = append(sorted, l)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].First.Date.Equal(sorted[j].First.Date) {
+			return sorted[i].First.Date.Before(sorted[j].First.Date)
+		}
+		return sorted[i].Oid < sorted[j].Oid
+	})
+	return sorted, nil
+}
+
+// lifespanPointer is a pointer found at a path within a tree.
+type lifespanPointer struct {
+	oid  string
+	size int64
+	name string
+}
+
+// lifespanWalker lists the pointers in trees, remembering the pointers in
+// every tree and the contents of every blob it has read, so that each object
+// is only read once however many commits share it.
+type lifespanWalker struct {
+	db    *gitobj.ObjectDatabase
+	trees map[string][]lifespanPointer
+	blobs map[string]*Pointer
+}
+
+func newLifespanWalker(db *gitobj.ObjectDatabase) *lifespanWalker {
+	return &lifespanWalker{
+		db:    db,
+		trees: make(map[string][]lifespanPointer),
+		blobs: make(map[string]*Pointer),
+	}
+}
+
+// Tree returns the pointers in the tree with the hex object ID "sha", and in
+// its subtrees, with their paths relative to it.
+func (w *lifespanWalker) Tree(sha string) ([]lifespanPointer, error) {
+	if pointers, ok := w.trees[sha]; ok {
+		return pointers, nil
+	}
+
+	oid, err := hex.DecodeString(sha)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := w.db.Tree(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	var pointers []lifespanPointer
+	for _, entry := range tree.Entries {
+		entrySha := hex.EncodeToString(entry.Oid)
+
+		switch entry.Filemode & 0170000 {
+		case 0040000:
+			children, err := w.Tree(entrySha)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				child.name = path.Join(entry.Name, child.name)
+				pointers = append(pointers, child)
+			}
+		case 0100000:
+			p, err := w.blob(entrySha, entry.Oid)
+			if err != nil {
+				return nil, err
+			}
+			if p != nil {
+				pointers = append(pointers, lifespanPointer{oid: p.Oid, size: p.Size, name: entry.Name})
+			}
+		}
+	}
+
+	w.trees[sha] = pointers
+	return pointers, nil
+}
+
+// blob returns the pointer in the blob with the given object ID, or nil if it
+// is not a pointer.
+func (w *lifespanWalker) blob(sha string, oid []byte) (*Pointer, error) {
+	if p, ok := w.blobs[sha]; ok {
+		return p, nil
+	}
+
+	b, err := w.db.Blob(oid)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	var p *Pointer
+	if b.Size < blobSizeCutoff {
+		if decoded, err := DecodePointerFromBlob(b); err == nil && decoded.Size > 0 {
+			p = decoded
+		}
+	}
+
+	w.blobs[sha] = p
+	return p, nil
+}
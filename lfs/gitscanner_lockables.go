@@ -0,0 +1,96 @@
+package lfs
+
+import (
+	"sync"
+	"time"
+)
+
+// GitScannerFoundLockables is called with the names of lockable files found by
+// a scan in batches, rather than one at a time; see GitScanner.FoundLockables.
+type GitScannerFoundLockables func(filenames []string)
+
+// lockableCallback returns the callback to which a scan should pass the name
+// of each lockable file it finds, and a function to call once the scan has
+// finished to deliver any names which are still waiting to be batched.
+func (s *GitScanner) lockableCallback() (GitScannerFoundLockable, func()) {
+	if s.FoundLockables != nil {
+		b := newLockableBatcher(s.FoundLockables, s.LockableBatchSize, s.LockableBatchInterval)
+		return b.Add, b.Flush
+	}
+	if s.FoundLockable != nil {
+		return s.FoundLockable, func() {}
+	}
+	return noopFoundLockable, func() {}
+}
+
+// lockableBatcher collects the names of lockable files and passes them to a
+// GitScannerFoundLockables callback once "size" names have been collected, or
+// once "interval" has passed since the first of them was, whichever is first.
+// If neither is positive, each name is passed on by itself.
+//
+// The callback is never called concurrently, so it need not synchronize
+// access to any state of its own.
+type lockableBatcher struct {
+	cb       GitScannerFoundLockables
+	size     int
+	interval time.Duration
+
+	// mu guards the fields below, and is held while calling cb.
+	mu    sync.Mutex
+	names []string
+	timer *time.Timer
+	// batch counts the batches delivered, so that a timer which fires
+	// after its batch has already been delivered does nothing.
+	batch int
+}
+
+func newLockableBatcher(cb GitScannerFoundLockables, size int, interval time.Duration) *lockableBatcher {
+	return &lockableBatcher{cb: cb, size: size, interval: interval}
+}
+
+// Add collects the given name, delivering the batch if it is full.
+func (b *lockableBatcher) Add(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.names = append(b.names, name)
+	if b.size > 0 && len(b.names) >= b.size || b.size <= 0 && b.interval <= 0 {
+		b.flush()
+		return
+	}
+
+	if b.interval > 0 && b.timer == nil {
+		batch := b.batch
+		b.timer = time.AfterFunc(b.interval, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			if b.batch == batch {
+				b.flush()
+			}
+		})
+	}
+}
+
+// Flush delivers any names collected since the last batch was delivered.
+func (b *lockableBatcher) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.flush()
+}
+
+func (b *lockableBatcher) flush() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.names) == 0 {
+		return
+	}
+
+	names := b.names
+	b.names = nil
+	b.batch++
+	b.cb(names)
+}
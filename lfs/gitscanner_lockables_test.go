@@ -0,0 +1,92 @@
+package lfs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedLockables struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *recordedLockables) Found(names []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, names)
+}
+
+func (r *recordedLockables) Batches() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([][]string(nil), r.batches...)
+}
+
+func TestLockableBatcherDeliversFullBatches(t *testing.T) {
+	r := &recordedLockables{}
+	b := newLockableBatcher(r.Found, 2, 0)
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		b.Add(name)
+	}
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}}, r.Batches())
+
+	b.Flush()
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, r.Batches())
+
+	// Flushing with nothing collected delivers nothing.
+	b.Flush()
+	assert.Len(t, r.Batches(), 3)
+}
+
+func TestLockableBatcherDeliversAfterInterval(t *testing.T) {
+	r := &recordedLockables{}
+	b := newLockableBatcher(r.Found, 0, 20*time.Millisecond)
+
+	b.Add("a")
+	b.Add("b")
+	assert.Empty(t, r.Batches())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.Batches()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, [][]string{{"a", "b"}}, r.Batches())
+
+	b.Add("c")
+	b.Flush()
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, r.Batches())
+
+	// The timer for the flushed batch does not deliver a later one early.
+	b.Add("d")
+	time.Sleep(40 * time.Millisecond)
+	b.Flush()
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}, {"d"}}, r.Batches())
+}
+
+func TestLockableBatcherSizeBeforeInterval(t *testing.T) {
+	r := &recordedLockables{}
+	b := newLockableBatcher(r.Found, 2, time.Hour)
+
+	b.Add("a")
+	b.Add("b")
+	b.Add("c")
+	assert.Equal(t, [][]string{{"a", "b"}}, r.Batches())
+
+	b.Flush()
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, r.Batches())
+}
+
+func TestLockableBatcherWithoutLimitsDeliversEachName(t *testing.T) {
+	r := &recordedLockables{}
+	b := newLockableBatcher(r.Found, 0, 0)
+
+	b.Add("a")
+	b.Add("b")
+	assert.Equal(t, [][]string{{"a"}, {"b"}}, r.Batches())
+}
@@ -46,7 +46,7 @@ type gitscannerResult struct {
 	Err     error
 }
 
-func scanUnpushed(cb GitScannerFoundPointer, remote string) error {
+func scanUnpushed(cb GitScannerFoundPointer, remote string, gitConfig []string) error {
 	logArgs := []string{
 		"--branches", "--tags", // include all locally referenced commits
 		"--not"} // but exclude everything that comes after
@@ -60,7 +60,7 @@ func scanUnpushed(cb GitScannerFoundPointer, remote string) error {
 	// Add standard search args to find lfs references
 	logArgs = append(logArgs, logLfsSearchArgs...)
 
-	cmd, err := git.Log(logArgs...)
+	cmd, err := git.LogWithConfig(gitConfig, logArgs...)
 	if err != nil {
 		return err
 	}
@@ -87,9 +87,10 @@ func scanStashed(cb GitScannerFoundPointer, s *GitScanner) error {
 	// the reflog, we can't extract the parent SHAs from "Merge:" lines
 	// in the log; we can, however, use the "git log -m" option to force
 	// an individual diff with the first merge parent in a second step.
+	gitConfig := s.gitConfig()
 	logArgs := []string{"-g", "--format=%h", "refs/stash", "--"}
 
-	cmd, err := git.Log(logArgs...)
+	cmd, err := git.LogWithConfig(gitConfig, logArgs...)
 	if err != nil {
 		return err
 	}
@@ -122,7 +123,7 @@ func scanStashed(cb GitScannerFoundPointer, s *GitScanner) error {
 
 		logArgs = append(logArgs, stashMergeShas...)
 
-		cmd, err = git.Log(logArgs...)
+		cmd, err = git.LogWithConfig(gitConfig, logArgs...)
 		if err != nil {
 			return err
 		}
@@ -163,7 +164,7 @@ func parseScannerLogOutput(cb GitScannerFoundPointer, direction LogDiffDirection
 
 // logPreviousVersions scans history for all previous versions of LFS pointers
 // from 'since' up to (but not including) the final state at ref
-func logPreviousSHAs(cb GitScannerFoundPointer, ref string, since time.Time) error {
+func logPreviousSHAs(cb GitScannerFoundPointer, ref string, since time.Time, gitConfig []string) error {
 	logArgs := []string{
 		fmt.Sprintf("--since=%v", git.FormatGitDate(since)),
 	}
@@ -172,7 +173,7 @@ func logPreviousSHAs(cb GitScannerFoundPointer, ref string, since time.Time) err
 	// ending at ref
 	logArgs = append(logArgs, ref)
 
-	cmd, err := git.Log(logArgs...)
+	cmd, err := git.LogWithConfig(gitConfig, logArgs...)
 	if err != nil {
 		return err
 	}
@@ -300,15 +301,15 @@ func (s *logScanner) scan() (*WrappedPointer, bool) {
 			if p := s.finishLastPointer(); p != nil {
 				return p, true
 			}
-		} else if match := s.fileHeaderRegex.FindStringSubmatch(line); match != nil {
+		} else if a, b, ok := s.parseFileHeader(line); ok {
 			// Finding a regular file header
 			p := s.finishLastPointer()
 
 			// Pertinent file name depends on whether we're listening to additions or removals
 			if s.dir == LogDiffAdditions {
-				s.setFilename(match[2])
+				s.setFilename(b)
 			} else {
-				s.setFilename(match[1])
+				s.setFilename(a)
 			}
 
 			if p != nil {
@@ -318,7 +319,7 @@ func (s *logScanner) scan() (*WrappedPointer, bool) {
 			// Git merge file header is a little different, only one file
 			p := s.finishLastPointer()
 
-			s.setFilename(match[1])
+			s.setFilename(git.UnquotePath(match[1]))
 
 			if p != nil {
 				return p, true
@@ -352,6 +353,34 @@ func (s *logScanner) scan() (*WrappedPointer, bool) {
 	return nil, false
 }
 
+// parseFileHeader returns the paths of the old and new sides of the file whose
+// diff is introduced by the "diff --git" header "line", decoding them if Git
+// quoted them, and whether "line" was such a header.
+func (s *logScanner) parseFileHeader(line string) (string, string, bool) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if len(rest) == len(line) {
+		return "", "", false
+	}
+
+	var a, b string
+	if n := git.QuotedPathLen(rest); n > 0 {
+		a = git.UnquotePath(rest[:n])
+		b = git.UnquotePath(strings.TrimPrefix(rest[n:], " "))
+	} else if i := strings.Index(rest, ` "b/`); i >= 0 && git.QuotedPathLen(rest[i+1:]) == len(rest)-i-1 {
+		a = rest[:i]
+		b = git.UnquotePath(rest[i+1:])
+	} else if match := s.fileHeaderRegex.FindStringSubmatch(line); match != nil {
+		return match[1], match[2], true
+	} else {
+		return "", "", false
+	}
+
+	if !strings.HasPrefix(a, "a/") || !strings.HasPrefix(b, "b/") {
+		return "", "", false
+	}
+	return a[2:], b[2:], true
+}
+
 func (s *logScanner) setFilename(name string) {
 	s.currentFilename = name
 	s.currentFileIncluded = s.Filter.Allows(name)
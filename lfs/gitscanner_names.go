@@ -0,0 +1,169 @@
+package lfs
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// nameRefBlobs records, for each blob to which a ref scanned by "include" and
+// "exclude" points directly, the path at which it was first introduced in the
+// history being scanned.  "git rev-list --objects" lists such blobs without a
+// path, so without this they would be reported without a name.  History is
+// only walked if there are such blobs.
+func nameRefBlobs(include, exclude []string, opt *ScanRefsOptions) error {
+	refBlobs, err := git.RefBlobShas()
+	if err != nil {
+		return err
+	}
+
+	scanned := make(map[string]struct{}, len(include))
+	for _, ref := range include {
+		scanned[ref] = struct{}{}
+	}
+
+	shas := make(map[string]struct{}, len(refBlobs))
+	for _, sha := range refBlobs {
+		if _, ok := scanned[sha]; ok || opt.ScanMode == ScanAllMode {
+			shas[sha] = struct{}{}
+		}
+	}
+	if len(shas) == 0 {
+		return nil
+	}
+
+	names, err := historicalNames(historicalRevs(include, exclude, opt), shas, opt.GitConfig)
+	if err != nil {
+		return err
+	}
+	for sha, name := range names {
+		opt.SetName(sha, name)
+	}
+	return nil
+}
+
+// historicalRevs returns the arguments to pass to "git log" to walk the same
+// history as a rev-list scan over "include" and "exclude" in the given mode.
+func historicalRevs(include, exclude []string, opt *ScanRefsOptions) []string {
+	if opt.ScanMode == ScanAllMode {
+		return []string{"--all"}
+	}
+
+	revs := make([]string, 0, len(include)+len(exclude))
+	for _, ref := range include {
+		if len(ref) > 0 && !git.IsZeroObjectID(ref) {
+			revs = append(revs, ref)
+		}
+	}
+	for _, ref := range exclude {
+		if len(ref) > 0 && !git.IsZeroObjectID(ref) {
+			revs = append(revs, fmt.Sprintf("^%s", ref))
+		}
+	}
+	return revs
+}
+
+// historicalNames walks the commits given by "revs" from oldest to newest and
+// returns the path at which each of the blobs in "shas" was first introduced.
+//
+// This is used to name blobs which "git rev-list --objects" reports without a
+// path, as happens when a blob is also reachable directly from a ref and so is
+// listed before any of the trees in which it appears.
+func historicalNames(revs []string, shas map[string]struct{}, gitConfig []string) (map[string]string, error) {
+	names := make(map[string]string, len(shas))
+	if len(shas) == 0 || len(revs) == 0 {
+		return names, nil
+	}
+
+	args := []string{
+		"--reverse",
+		"--format=",
+		"--raw",
+		"--no-abbrev",
+		"--no-renames",
+		"-m",
+		"-z",
+	}
+	args = append(args, revs...)
+	args = append(args, "--")
+
+	cmd, err := git.LogWithConfig(gitConfig, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(cmd.Stdout)
+	scanner.Split(tools.SplitOnNul)
+
+	// Each change is reported as a header of the form ":<old mode> <new
+	// mode> <old sha> <new sha> <status>", followed by a separate path.
+	var blob string
+	for scanner.Scan() {
+		token := strings.TrimPrefix(scanner.Text(), "\n")
+		if strings.HasPrefix(token, ":") {
+			blob = ""
+			if fields := strings.Fields(token); len(fields) == 5 {
+				blob = fields[3]
+			}
+			continue
+		}
+
+		if len(blob) == 0 || len(token) == 0 {
+			continue
+		}
+		if _, ok := shas[blob]; ok {
+			if _, seen := names[blob]; !seen {
+				names[blob] = token
+			}
+		}
+		blob = ""
+	}
+
+	if err := scanner.Err(); err != nil {
+		cmd.Wait()
+		return nil, errors.Wrap(err, tr.Tr.Get("could not read `git log` output"))
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not resolve historical paths"))
+	}
+	return names, nil
+}
+
+// TreeNames returns a path in the tree of "ref" at which each of the blobs in
+// "shas" can be found, using the first such path in the order listed by "git
+// ls-tree".  Blobs which are not in the tree are omitted from the result.
+//
+// This can be used to name pointers which a scan of history reported without
+// a path, where the same blob is also present in the current tree.
+func TreeNames(ref string, shas map[string]struct{}) (map[string]string, error) {
+	names := make(map[string]string, len(shas))
+	if len(shas) == 0 {
+		return names, nil
+	}
+
+	blobs, err := lsTreeBlobs(ref, func(t *git.TreeBlob) bool {
+		if t == nil {
+			return false
+		}
+		_, ok := shas[t.Oid]
+		return ok
+	}, defaultGitConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for blob := range blobs.Results {
+		if _, seen := names[blob.Oid]; !seen {
+			names[blob.Oid] = blob.Filename
+		}
+	}
+	if err := blobs.Wait(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
@@ -0,0 +1,37 @@
+package lfs
+
+// ScanReachability scans the whole history of each of the given refs in turn,
+// as ScanRefWithDeleted does, and returns a map from the OID of each object
+// found to the refs from whose history it is reachable, in the order in which
+// they were given.  Since each ref is scanned separately, history shared by
+// several refs is scanned once for each of them, so the cost grows with the
+// number of refs as well as with the size of their history.  Errors are
+// passed to the callback as soon as they are found.
+func (s *GitScanner) ScanReachability(refs []string, cb GitScannerFoundPointer) (map[string][]string, error) {
+	callback, err := s.callback(cb)
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := make(map[string][]string)
+	for _, ref := range refs {
+		err := s.ScanRefWithDeleted(ref, func(p *WrappedPointer, err error) {
+			if err != nil {
+				callback(nil, err)
+				return
+			}
+
+			// The same object may be found more than once in the
+			// history of a ref, such as under different names.
+			found := reachable[p.Oid]
+			if n := len(found); n > 0 && found[n-1] == ref {
+				return
+			}
+			reachable[p.Oid] = append(found, ref)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reachable, nil
+}
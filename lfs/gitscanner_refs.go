@@ -2,11 +2,16 @@ package lfs
 
 import (
 	"encoding/hex"
+	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
 )
 
 type lockableNameSet struct {
@@ -41,30 +46,41 @@ func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 	if opt == nil {
 		panic(tr.Tr.Get("no scan ref options"))
 	}
+	if err := opt.start(); err != nil {
+		return err
+	}
+
+	if opt.NameRefBlobs && !opt.CommitsOnly {
+		if err := nameRefBlobs(include, exclude, opt); err != nil {
+			return err
+		}
+	}
 
 	revs, err := revListShas(include, exclude, opt)
 	if err != nil {
 		return err
 	}
 
-	lockableSet := &lockableNameSet{opt: opt, set: scanner.PotentialLockables}
-	smallShas, batchLockableCh, err := catFileBatchCheck(revs, lockableSet)
+	var lockableSet *lockableNameSet
+	if !opt.SkipLockableCheck {
+		lockableSet = &lockableNameSet{opt: opt, set: scanner.PotentialLockables}
+	}
+	smallShas, batchLockableCh, err := catFileBatchCheck(revs, lockableSet, opt.GitExec, opt.GitConfig, opt.CatFileWorkers)
 	if err != nil {
 		return err
 	}
 
-	lockableCb := scanner.FoundLockable
-	if lockableCb == nil {
-		lockableCb = noopFoundLockable
-	}
+	lockableCb, flushLockables := scanner.lockableCallback()
 
+	batchLockableDone := make(chan struct{})
 	go func(cb GitScannerFoundLockable, ch chan string) {
+		defer close(batchLockableDone)
 		for name := range ch {
 			cb(name)
 		}
 	}(lockableCb, batchLockableCh)
 
-	pointers, checkLockableCh, err := catFileBatch(smallShas, lockableSet, gitEnv, osEnv)
+	pointers, checkLockableCh, err := catFileBatch(smallShas, lockableSet, gitEnv, osEnv, opt.CatFileWorkers)
 	if err != nil {
 		return err
 	}
@@ -84,6 +100,8 @@ func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 			lockableCb(lockableName)
 		}
 	}
+	<-batchLockableDone
+	flushLockables()
 
 	if err := pointers.Wait(); err != nil {
 		pointerCb(nil, err)
@@ -115,6 +133,9 @@ func scanRefsByTree(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 	if opt == nil {
 		panic(tr.Tr.Get("no scan ref options"))
 	}
+	if err := opt.start(); err != nil {
+		return err
+	}
 
 	revs, err := revListShas(include, exclude, opt)
 	if err != nil {
@@ -128,7 +149,7 @@ func scanRefsByTree(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 		wg.Add(1)
 		go func(rev string) {
 			defer wg.Done()
-			err := runScanTreeForPointers(pointerCb, rev, gitEnv, osEnv)
+			err := runScanTreeForPointers(pointerCb, rev, gitEnv, osEnv, opt.GitConfig, opt.stop)
 			if err != nil {
 				errchan <- err
 			}
@@ -147,17 +168,54 @@ func scanRefsByTree(scanner *GitScanner, pointerCb GitScannerFoundPointer, inclu
 }
 
 // revListShas uses git rev-list to return the list of object sha1s
-// for the given ref. If all is true, ref is ignored. It returns a
-// channel from which sha1 strings can be read.
+// for the given ref. If all is true, ref is ignored, and every ref and stash
+// entry is scanned instead. If opt.IncludeNotes is set, the notes refs are
+// scanned along with the given refs. It returns a channel from which sha1 strings can
+// be read.
 func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChannelWrapper, error) {
+	if opt.ScanMode != ScanAllMode {
+		var err error
+		if include, exclude, err = resolveAmbiguousRefs(include, exclude, opt); err != nil {
+			return nil, err
+		}
+		if err := checkOverlappingRefs(include, exclude, opt); err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.IncludeNotes && opt.ScanMode == ScanRefsMode {
+		notes, err := git.NotesShas()
+		if err != nil {
+			return nil, err
+		}
+		include = append(append([]string(nil), include...), notes...)
+	}
+
+	var stashes []string
+	if opt.ScanMode == ScanAllMode {
+		// Objects referenced only by older stash entries are not
+		// reachable from any ref, so name those entries explicitly.
+		var err error
+		if stashes, err = git.StashShas(); err != nil {
+			return nil, err
+		}
+	}
+
 	scanner, err := git.NewRevListScanner(include, exclude, &git.ScanRefsOptions{
 		Mode:             git.ScanningMode(opt.ScanMode),
 		Remote:           opt.RemoteName,
 		SkipDeletedBlobs: opt.SkipDeletedBlobs,
 		SkippedRefs:      opt.skippedRefs,
+		Stashes:          stashes,
 		Mutex:            opt.mutex,
 		Names:            opt.nameMap,
 		CommitsOnly:      opt.CommitsOnly,
+		MergesOnly:       opt.MergesOnly,
+		NoMerges:         opt.NoMerges,
+		Config:           opt.GitConfig,
+		Exec:             opt.GitExec,
+		Pathspecs:        opt.Pathspecs,
+		ContinueOnError:  opt.ContinueOnError,
 	})
 
 	if err != nil {
@@ -169,6 +227,11 @@ func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChanne
 
 	go func() {
 		for scanner.Scan() {
+			if isStopped(opt.stop) {
+				scanner.Stop()
+				break
+			}
+
 			sha := hex.EncodeToString(scanner.OID())
 			if name := scanner.Name(); len(name) > 0 {
 				opt.SetName(sha, name)
@@ -184,9 +247,110 @@ func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChanne
 			errs <- err
 		}
 
+		if missing := scanner.Missing(); len(missing) > 0 {
+			tracerx.Printf("scan: skipped %d missing objects", len(missing))
+			if opt.foundMissing != nil {
+				opt.foundMissing(missing)
+			}
+		}
+
 		close(revs)
 		close(errs)
 	}()
 
 	return NewStringChannelWrapper(revs, errs), nil
 }
+
+// objectIDRegex matches a full hexadecimal object ID.
+var objectIDRegex = regexp.MustCompile(`\A` + git.ObjectIDRegex + `\z`)
+
+// overlappingRefs returns the refs in "include", in order, which also appear
+// in "exclude", and so from which git-rev-list(1) walks nothing.  Refs are
+// compared as they are named.  Object IDs are left out, since callers which
+// resolve refs, such as to compare a branch with its upstream, exclude the
+// commit they include whenever the two are the same, and expect to find
+// nothing.
+func overlappingRefs(include, exclude []string) []string {
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, ref := range exclude {
+		excluded[ref] = struct{}{}
+	}
+
+	var overlap []string
+	seen := make(map[string]struct{})
+	for _, ref := range include {
+		if len(ref) == 0 || objectIDRegex.MatchString(ref) {
+			continue
+		}
+		if _, ok := excluded[ref]; !ok {
+			continue
+		}
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		overlap = append(overlap, ref)
+	}
+	return overlap
+}
+
+// checkOverlappingRefs warns of the refs which are both included in and
+// excluded from a scan, or, if opt.StrictRefs is set, returns an error naming
+// them.
+func checkOverlappingRefs(include, exclude []string, opt *ScanRefsOptions) error {
+	overlap := overlappingRefs(include, exclude)
+	if len(overlap) == 0 {
+		return nil
+	}
+
+	refs := strings.Join(overlap, ", ")
+	if opt.StrictRefs {
+		return errors.New(tr.Tr.Get("cannot scan refs which are both included and excluded: %s", refs))
+	}
+	tracerx.Printf("scan: refs both included and excluded: %s", refs)
+	warnings.Warn(warnings.OverlappingRefs, tr.Tr.Get("warning: refs which are both included and excluded are not scanned: %s", refs))
+	return nil
+}
+
+// resolveAmbiguousRefs returns "include" and "exclude" with each short ref name
+// which matches more than one ref, such as both a branch and a tag, replaced by
+// the full name of the ref which Git would choose, warning of each, so that the
+// scan does not depend on how git-rev-list(1) breaks the tie.  If opt.StrictRefs
+// is set, an error naming the refs is returned instead.
+func resolveAmbiguousRefs(include, exclude []string, opt *ScanRefsOptions) ([]string, []string, error) {
+	full, err := git.FullRefNames(append(append([]string(nil), include...), exclude...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ambiguous []string
+	resolve := func(refs []string) []string {
+		resolved := make([]string, len(refs))
+		for i, ref := range refs {
+			resolved[i] = ref
+			if len(full[ref]) < 2 {
+				continue
+			}
+			resolved[i] = full[ref][0]
+			ambiguous = append(ambiguous, ref)
+		}
+		return resolved
+	}
+	include, exclude = resolve(include), resolve(exclude)
+
+	warned := make(map[string]bool)
+	for _, ref := range ambiguous {
+		if warned[ref] {
+			continue
+		}
+		warned[ref] = true
+
+		refs := strings.Join(full[ref], ", ")
+		if opt.StrictRefs {
+			return nil, nil, errors.New(tr.Tr.Get("cannot scan ambiguous ref %q, which may be any of: %s", ref, refs))
+		}
+		tracerx.Printf("scan: resolved ambiguous ref %q to %q (of %s)", ref, full[ref][0], refs)
+		warnings.Warn(warnings.AmbiguousRef, tr.Tr.Get("warning: ref %q is ambiguous; using %q", ref, full[ref][0]))
+	}
+	return include, exclude, nil
+}
@@ -0,0 +1,103 @@
+package lfs
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanRefsOptionsRejectsConcurrentScans(t *testing.T) {
+	opts := newScanRefsOptions()
+
+	const scans = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, scans)
+	for i := 0; i < scans; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- opts.start()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	started := 0
+	for err := range errs {
+		if err == nil {
+			started++
+		} else {
+			assert.Equal(t, errScanRefsOptionsReused, err)
+		}
+	}
+	assert.Equal(t, 1, started)
+}
+
+func TestScanRefsRejectsUsedOptions(t *testing.T) {
+	opts := newScanRefsOptions()
+	assert.Nil(t, opts.start())
+
+	cb := func(p *WrappedPointer, err error) {
+		t.Errorf("unexpected callback: %v, %v", p, err)
+	}
+
+	err := scanRefsToChan(&GitScanner{}, cb, []string{"HEAD"}, nil, nil, nil, opts)
+	assert.Equal(t, errScanRefsOptionsReused, err)
+
+	err = scanRefsByTree(&GitScanner{}, cb, []string{"HEAD"}, nil, nil, nil, opts)
+	assert.Equal(t, errScanRefsOptionsReused, err)
+}
+
+func TestGitScannerGivesEachScanNewOptions(t *testing.T) {
+	s := &GitScanner{}
+	assert.Nil(t, s.opts(ScanRefsMode).start())
+	assert.Nil(t, s.opts(ScanRefsMode).start())
+}
+
+func TestOverlappingRefs(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+
+	assert.Nil(t, overlappingRefs([]string{"main"}, []string{"origin/main"}))
+	assert.Nil(t, overlappingRefs([]string{"main"}, nil))
+	assert.Equal(t, []string{"main", "topic"}, overlappingRefs(
+		[]string{"main", "other", "topic", "main"},
+		[]string{"topic", "main"},
+	))
+
+	// Object IDs are expected to overlap when a ref is up to date.
+	assert.Nil(t, overlappingRefs([]string{sha}, []string{sha}))
+	assert.Nil(t, overlappingRefs([]string{""}, []string{""}))
+}
+
+func TestCheckOverlappingRefsWarns(t *testing.T) {
+	var buf bytes.Buffer
+	warnings.SetOutput(&buf)
+	defer warnings.SetOutput(os.Stderr)
+
+	opts := newScanRefsOptions()
+	assert.Nil(t, checkOverlappingRefs([]string{"main", "topic"}, []string{"main"}, opts))
+	assert.Equal(t, "warning: refs which are both included and excluded are not scanned: main\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, checkOverlappingRefs([]string{"topic"}, []string{"main"}, opts))
+	assert.Empty(t, buf.String())
+}
+
+func TestCheckOverlappingRefsStrict(t *testing.T) {
+	var buf bytes.Buffer
+	warnings.SetOutput(&buf)
+	defer warnings.SetOutput(os.Stderr)
+
+	opts := newScanRefsOptions()
+	opts.StrictRefs = true
+	err := checkOverlappingRefs([]string{"main", "topic"}, []string{"topic", "main"}, opts)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "cannot scan refs which are both included and excluded: main, topic", err.Error())
+	}
+	assert.Empty(t, buf.String())
+}
@@ -1,10 +1,27 @@
 package lfs
 
 import (
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
+// remoteTrackingRefs expands the given remote into the full names of its
+// remote-tracking refs, "refs/remotes/<remote>/*", other than its HEAD.
+func remoteTrackingRefs(remote string) ([]string, error) {
+	refs, err := git.CachedRemoteRefs(remote)
+	if err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not list remote-tracking refs for %q", remote))
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, "refs/remotes/"+remote+"/"+ref.Name)
+	}
+	return names, nil
+}
+
 // calcSkippedRefs checks that locally cached versions of remote refs are still
 // present on the remote before they are used as a 'from' point. If the server
 // implements garbage collection and a remote branch had been deleted since we
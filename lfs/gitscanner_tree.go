@@ -13,12 +13,12 @@ import (
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
 
-func runScanTree(cb GitScannerFoundPointer, ref string, filter *filepathfilter.Filter, gitEnv, osEnv config.Environment) error {
+func runScanTree(cb GitScannerFoundPointer, ref string, filter *filepathfilter.Filter, gitEnv, osEnv config.Environment, gitConfig []string, stop <-chan struct{}) error {
 	// We don't use the nameMap approach here since that's imprecise when >1 file
 	// can be using the same content
 	treeShas, err := lsTreeBlobs(ref, func(t *git.TreeBlob) bool {
 		return t != nil && t.Size < blobSizeCutoff && filter.Allows(t.Filename)
-	})
+	}, gitConfig, stop)
 	if err != nil {
 		return err
 	}
@@ -93,9 +93,10 @@ func catFileBatchTree(treeblobs *TreeBlobChannelWrapper, gitEnv, osEnv config.En
 
 // Use ls-tree at ref to find a list of candidate tree blobs which might be lfs files
 // The returned channel will be sent these blobs which should be sent to catFileBatchTree
-// for final check & conversion to Pointer
-func lsTreeBlobs(ref string, predicate func(*git.TreeBlob) bool) (*TreeBlobChannelWrapper, error) {
-	cmd, err := git.LsTree(ref)
+// for final check & conversion to Pointer.  If "stop" is closed, ls-tree is
+// terminated and no further blobs are sent.
+func lsTreeBlobs(ref string, predicate func(*git.TreeBlob) bool, gitConfig []string, stop <-chan struct{}) (*TreeBlobChannelWrapper, error) {
+	cmd, err := git.LsTreeWithConfig(gitConfig, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -106,8 +107,16 @@ func lsTreeBlobs(ref string, predicate func(*git.TreeBlob) bool) (*TreeBlobChann
 	errchan := make(chan error, 1)
 
 	go func() {
+		var stopped bool
+
 		scanner := git.NewLsTreeScanner(cmd.Stdout)
 		for scanner.Scan() {
+			if isStopped(stop) {
+				stopped = true
+				cmd.Process.Kill()
+				break
+			}
+
 			if t := scanner.TreeBlob(); predicate(t) {
 				blobs <- *t
 			}
@@ -115,7 +124,7 @@ func lsTreeBlobs(ref string, predicate func(*git.TreeBlob) bool) (*TreeBlobChann
 
 		stderr, _ := ioutil.ReadAll(cmd.Stderr)
 		err := cmd.Wait()
-		if err != nil {
+		if err != nil && !stopped {
 			errchan <- errors.New(tr.Tr.Get("error in `git ls-tree`: %v %v", err, string(stderr)))
 		}
 		close(blobs)
@@ -215,10 +224,10 @@ func catFileBatchTreeForPointers(treeblobs *TreeBlobChannelWrapper, gitEnv, osEn
 	return pointers, filepathfilter.NewFromPatterns(includes, excludes, filepathfilter.DefaultValue(false)), nil
 }
 
-func runScanTreeForPointers(cb GitScannerFoundPointer, tree string, gitEnv, osEnv config.Environment) error {
+func runScanTreeForPointers(cb GitScannerFoundPointer, tree string, gitEnv, osEnv config.Environment, gitConfig []string, stop <-chan struct{}) error {
 	treeShas, err := lsTreeBlobs(tree, func(t *git.TreeBlob) bool {
 		return t != nil && (t.Mode == 0100644 || t.Mode == 0100755)
-	})
+	}, gitConfig, stop)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,156 @@
+package lfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// ObjectManifestEntry is one object listed in an object manifest, along with a
+// path at which a pointer to it appears.
+type ObjectManifestEntry struct {
+	Oid  string
+	Size int64
+	Name string
+}
+
+// WriteObjectManifest writes the given entries to "w" as an object manifest,
+// which has a line for each entry giving its OID, its size, and its path,
+// separated by single spaces.  Paths which contain double quotes, backslashes,
+// or control characters are quoted as Git quotes them.
+//
+// The entries are sorted by path and then by OID, and repeated entries are
+// written only once, so that the same set of entries always produces the same
+// manifest.
+func WriteObjectManifest(w io.Writer, entries []*ObjectManifestEntry) error {
+	sorted := make([]*ObjectManifestEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Oid < sorted[j].Oid
+	})
+
+	bw := bufio.NewWriter(w)
+	var last *ObjectManifestEntry
+	for _, e := range sorted {
+		if last != nil && *last == *e {
+			continue
+		}
+		last = e
+
+		if _, err := fmt.Fprintf(bw, "%s %d %s\n", e.Oid, e.Size, git.QuotePath(e.Name)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadObjectManifest reads the entries of an object manifest written by
+// WriteObjectManifest from "r".  Empty lines, and lines starting with "#", are
+// ignored.
+func ReadObjectManifest(r io.Reader) ([]*ObjectManifestEntry, error) {
+	var entries []*ObjectManifestEntry
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(strings.TrimSpace(text)) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(text, " ", 3)
+		if len(fields) != 3 || len(fields[2]) == 0 {
+			return nil, errors.New(tr.Tr.Get("invalid object manifest line %d: %q", line, text))
+		}
+		if !oidRE.MatchString(fields[0]) {
+			return nil, errors.New(tr.Tr.Get("invalid object manifest line %d: invalid OID %q", line, fields[0]))
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || size < 0 {
+			return nil, errors.New(tr.Tr.Get("invalid object manifest line %d: invalid size %q", line, fields[1]))
+		}
+
+		entries = append(entries, &ObjectManifestEntry{
+			Oid:  fields[0],
+			Size: size,
+			Name: git.UnquotePath(fields[2]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not read object manifest"))
+	}
+	return entries, nil
+}
+
+// ObjectManifestDiff holds the objects which are listed in one object manifest
+// but not in another.  An object is identified by its OID and size, so an
+// object listed with different sizes in each manifest is in both lists.
+type ObjectManifestDiff struct {
+	// Removed holds the objects listed only in the first manifest, and
+	// Added those listed only in the second.  Each is given with the first
+	// of its paths in that manifest, and they are sorted by OID and size.
+	Removed []*ObjectManifestEntry
+	Added   []*ObjectManifestEntry
+}
+
+// Empty returns whether both manifests list the same objects.
+func (d *ObjectManifestDiff) Empty() bool {
+	return len(d.Removed) == 0 && len(d.Added) == 0
+}
+
+// DiffObjectManifests compares the objects listed in the manifests "a" and
+// "b", whatever their paths.
+func DiffObjectManifests(a, b []*ObjectManifestEntry) *ObjectManifestDiff {
+	inA := objectManifestSet(a)
+	inB := objectManifestSet(b)
+
+	return &ObjectManifestDiff{
+		Removed: objectManifestMissing(inA, inB),
+		Added:   objectManifestMissing(inB, inA),
+	}
+}
+
+type objectManifestKey struct {
+	oid  string
+	size int64
+}
+
+// objectManifestSet returns the objects listed in "entries", each with the
+// first of its paths.
+func objectManifestSet(entries []*ObjectManifestEntry) map[objectManifestKey]*ObjectManifestEntry {
+	set := make(map[objectManifestKey]*ObjectManifestEntry, len(entries))
+	for _, e := range entries {
+		key := objectManifestKey{e.Oid, e.Size}
+		if first, ok := set[key]; !ok || e.Name < first.Name {
+			set[key] = e
+		}
+	}
+	return set
+}
+
+// objectManifestMissing returns the objects in "from" which are not in "in",
+// sorted by OID and size.
+func objectManifestMissing(from, in map[objectManifestKey]*ObjectManifestEntry) []*ObjectManifestEntry {
+	var missing []*ObjectManifestEntry
+	for key, e := range from {
+		if _, ok := in[key]; !ok {
+			missing = append(missing, e)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Oid != missing[j].Oid {
+			return missing[i].Oid < missing[j].Oid
+		}
+		return missing[i].Size < missing[j].Size
+	})
+	return missing
+}
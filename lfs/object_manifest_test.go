@@ -0,0 +1,101 @@
+package lfs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	manifestOidA = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	manifestOidB = "9c1d0a6b2c8e252a0d2f0bd0b2b77e5034e0e3efe6390f5e0ad42c8a7af2a2b0"
+)
+
+func TestWriteObjectManifestIsSortedAndUnique(t *testing.T) {
+	entries := []*ObjectManifestEntry{
+		{Oid: manifestOidB, Size: 2, Name: "b.dat"},
+		{Oid: manifestOidA, Size: 1, Name: "a.dat"},
+		{Oid: manifestOidB, Size: 2, Name: "a.dat"},
+		{Oid: manifestOidA, Size: 1, Name: "a.dat"},
+	}
+
+	var buf bytes.Buffer
+	require.Nil(t, WriteObjectManifest(&buf, entries))
+	assert.Equal(t, strings.Join([]string{
+		manifestOidA + " 1 a.dat",
+		manifestOidB + " 2 a.dat",
+		manifestOidB + " 2 b.dat",
+		"",
+	}, "\n"), buf.String())
+
+	// The given entries are left in their original order.
+	assert.Equal(t, "b.dat", entries[0].Name)
+}
+
+func TestObjectManifestRoundTrips(t *testing.T) {
+	entries := []*ObjectManifestEntry{
+		{Oid: manifestOidA, Size: 1, Name: "dir/with space.dat"},
+		{Oid: manifestOidB, Size: 12345, Name: "new\nline \"quoted\".dat"},
+		{Oid: manifestOidA, Size: 1, Name: "café.dat"},
+	}
+
+	var buf bytes.Buffer
+	require.Nil(t, WriteObjectManifest(&buf, entries))
+	assert.Contains(t, buf.String(), manifestOidB+` 12345 "new\nline \"quoted\".dat"`)
+
+	read, err := ReadObjectManifest(&buf)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, entries, read)
+}
+
+func TestReadObjectManifestSkipsBlankLinesAndComments(t *testing.T) {
+	read, err := ReadObjectManifest(strings.NewReader("# objects\n\n" + manifestOidA + " 1 a.dat\r\n"))
+	require.Nil(t, err)
+	assert.Equal(t, []*ObjectManifestEntry{{Oid: manifestOidA, Size: 1, Name: "a.dat"}}, read)
+}
+
+func TestReadObjectManifestRejectsInvalidLines(t *testing.T) {
+	for input, msg := range map[string]string{
+		manifestOidA + " 1":         `invalid object manifest line 1: "` + manifestOidA + ` 1"`,
+		"abc 1 a.dat":               `invalid object manifest line 1: invalid OID "abc"`,
+		manifestOidA + " -1 a.dat":  `invalid object manifest line 1: invalid size "-1"`,
+		manifestOidA + " one a.dat": `invalid object manifest line 1: invalid size "one"`,
+	} {
+		_, err := ReadObjectManifest(strings.NewReader(input))
+		if assert.NotNil(t, err, input) {
+			assert.Equal(t, msg, err.Error(), input)
+		}
+	}
+}
+
+func TestDiffObjectManifests(t *testing.T) {
+	const manifestOidC = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	a := []*ObjectManifestEntry{
+		{Oid: manifestOidA, Size: 1, Name: "a.dat"},
+		{Oid: manifestOidB, Size: 2, Name: "z.dat"},
+		{Oid: manifestOidB, Size: 2, Name: "b.dat"},
+		{Oid: manifestOidC, Size: 3, Name: "c.dat"},
+	}
+	b := []*ObjectManifestEntry{
+		// The same object at another path is not a difference.
+		{Oid: manifestOidA, Size: 1, Name: "moved/a.dat"},
+		{Oid: manifestOidC, Size: 4, Name: "c.dat"},
+	}
+
+	diff := DiffObjectManifests(a, b)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []*ObjectManifestEntry{
+		{Oid: manifestOidC, Size: 3, Name: "c.dat"},
+		{Oid: manifestOidB, Size: 2, Name: "b.dat"},
+	}, diff.Removed)
+	assert.Equal(t, []*ObjectManifestEntry{
+		{Oid: manifestOidC, Size: 4, Name: "c.dat"},
+	}, diff.Added)
+
+	assert.True(t, DiffObjectManifests(a, a).Empty())
+	assert.True(t, DiffObjectManifests(nil, nil).Empty())
+}
@@ -31,6 +31,38 @@ var (
 	pointerKeys = []string{"version", "oid", "size"}
 )
 
+const (
+	// DefaultMaxPointerSize is the largest size, in bytes, of data which
+	// is decoded as a pointer unless lfs.maxpointersize says otherwise.
+	// It is the largest size which the scanners treat as a possible
+	// pointer, so that no valid pointer is rejected.
+	DefaultMaxPointerSize = blobSizeCutoff - 1
+)
+
+// maxPointerSize is the size, in bytes, beyond which data is never decoded as
+// a pointer.
+var maxPointerSize = DefaultMaxPointerSize
+
+// SetMaxPointerSize sets the size, in bytes, beyond which data is not a
+// pointer, and so is rejected without being parsed.  Sizes which are not
+// positive select DefaultMaxPointerSize, and sizes which are not below
+// the pointer size cutoff of the scanners are reduced to fit under it.
+func SetMaxPointerSize(size int) {
+	if size <= 0 {
+		size = DefaultMaxPointerSize
+	}
+	if size >= blobSizeCutoff {
+		size = blobSizeCutoff - 1
+	}
+	maxPointerSize = size
+}
+
+// MaxPointerSize returns the size, in bytes, beyond which data is not a
+// pointer.
+func MaxPointerSize() int {
+	return maxPointerSize
+}
+
 type Pointer struct {
 	Version    string
 	Oid        string
@@ -67,13 +99,21 @@ func (p *Pointer) Encode(writer io.Writer) (int, error) {
 }
 
 func (p *Pointer) Encoded() string {
-	if p.Size == 0 {
+	// Empty content is stored as it is, unless extensions made it empty,
+	// in which case the pointer is needed to undo them.
+	if p.Size == 0 && len(p.Extensions) == 0 {
 		return ""
 	}
 
 	var buffer bytes.Buffer
+	// Extensions are always written in order of priority, whatever the order
+	// in which they were given.
+	exts := make([]*PointerExtension, len(p.Extensions))
+	copy(exts, p.Extensions)
+	sort.Stable(ByPriority(exts))
+
 	buffer.WriteString(fmt.Sprintf("version %s\n", latest))
-	for _, ext := range p.Extensions {
+	for _, ext := range exts {
 		buffer.WriteString(fmt.Sprintf("ext-%d-%s %s:%s\n", ext.Priority, ext.Name, ext.OidType, ext.Oid))
 	}
 	buffer.WriteString(fmt.Sprintf("oid %s:%s\n", p.OidType, p.Oid))
@@ -85,13 +125,21 @@ func EmptyPointer() *Pointer {
 	return NewPointer(fs.EmptyObjectSHA256, 0, nil)
 }
 
+// HasMisleadingSize returns whether the pointer gives its size as zero but
+// names an object other than the empty one, as a malformed pointer might.  The
+// size of such an object is unknown, so it must not be taken to have no
+// content.
+func (p *Pointer) HasMisleadingSize() bool {
+	return p.Size == 0 && p.Oid != fs.EmptyObjectSHA256
+}
+
 func EncodePointer(writer io.Writer, pointer *Pointer) (int, error) {
 	return writer.Write([]byte(pointer.Encoded()))
 }
 
 func DecodePointerFromBlob(b *gitobj.Blob) (*Pointer, error) {
 	// Check size before reading
-	if b.Size >= blobSizeCutoff {
+	if b.Size > int64(maxPointerSize) {
 		return nil, errors.NewNotAPointerError(errors.New(tr.Tr.Get("blob size exceeds Git LFS pointer size cutoff")))
 	}
 	return DecodePointer(b.Contents)
@@ -103,7 +151,7 @@ func DecodePointerFromFile(file string) (*Pointer, error) {
 	if err != nil {
 		return nil, err
 	}
-	if stat.Size() >= blobSizeCutoff {
+	if stat.Size() > int64(maxPointerSize) {
 		return nil, errors.NewNotAPointerError(errors.New(tr.Tr.Get("file size exceeds Git LFS pointer size cutoff")))
 	}
 	f, err := os.OpenFile(file, os.O_RDONLY, 0644)
@@ -149,6 +197,20 @@ func DecodeFrom(reader io.Reader) (*Pointer, io.Reader, error) {
 	return p, contents, err
 }
 
+// LooksLikePointer returns whether "data" begins with a version line naming
+// one of the versions of the pointer format, as a pointer does, whether or not
+// the rest of it is valid.
+func LooksLikePointer(data []byte) bool {
+	line := bytes.TrimSpace(data)
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if !bytes.HasPrefix(line, []byte("version ")) {
+		return false
+	}
+	return matcherRE.Match(line)
+}
+
 func verifyVersion(version string) error {
 	if len(version) == 0 {
 		return errors.NewNotAPointerError(errors.New(tr.Tr.Get("Missing version")))
@@ -164,6 +226,10 @@ func verifyVersion(version string) error {
 }
 
 func decodeKV(data []byte) (*Pointer, error) {
+	if len(data) > maxPointerSize {
+		return nil, errors.NewNotAPointerError(errors.New(tr.Tr.Get("data exceeds maximum pointer size of %d bytes", maxPointerSize)))
+	}
+
 	kvps, exts, err := decodeKVData(data)
 	if err != nil {
 		if errors.IsBadPointerKeyError(err) {
@@ -218,13 +284,21 @@ func parseOid(value string) (string, error) {
 	if parts[0] != oidType {
 		return "", errors.New(tr.Tr.Get("Invalid OID type: %s", parts[0]))
 	}
-	oid := parts[1]
+	// Some tools write OIDs in upper case, but objects are stored, and
+	// compared, by their lower-case OIDs.
+	oid := strings.ToLower(parts[1])
 	if !oidRE.Match([]byte(oid)) {
 		return "", errors.New(tr.Tr.Get("Invalid OID: %s", oid))
 	}
 	return oid, nil
 }
 
+// ValidOid returns whether "oid" is a valid object ID, that is, a lower-case,
+// hex-encoded SHA-256 hash.
+func ValidOid(oid string) bool {
+	return oidRE.MatchString(oid)
+}
+
 func parsePointerExtension(key string, value string) (*PointerExtension, error) {
 	keyParts := strings.SplitN(key, "-", 3)
 	if len(keyParts) != 3 || keyParts[0] != "ext" {
@@ -296,6 +370,10 @@ func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string,
 			if exts == nil {
 				exts = make(map[string]string)
 			}
+			if _, ok := exts[key]; ok {
+				err = errors.New(tr.Tr.Get("duplicate extension: %s", key))
+				return
+			}
 			exts[key] = value
 			continue
 		}
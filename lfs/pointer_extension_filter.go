@@ -0,0 +1,43 @@
+package lfs
+
+// PointerExtensionFilter matches the pointers which carry any of a set of
+// extensions.
+type PointerExtensionFilter struct {
+	specs []pointerExtensionSpec
+}
+
+// ParsePointerExtensionFilter parses a PointerExtensionFilter from a list of
+// extensions, each given as in a PointerExtensionMap: either by name, such as
+// "foo", to match that extension at any priority, or by name and priority as
+// they appear in a pointer, such as "ext-0-foo".
+func ParsePointerExtensionFilter(names []string) (*PointerExtensionFilter, error) {
+	f := &PointerExtensionFilter{}
+	for _, name := range names {
+		spec, err := parsePointerExtensionSpec(name)
+		if err != nil {
+			return nil, err
+		}
+		f.specs = append(f.specs, spec)
+	}
+	return f, nil
+}
+
+// Matches returns whether the pointer "p" carries any of the extensions of the
+// filter.  A nil filter matches every pointer.
+func (f *PointerExtensionFilter) Matches(p *Pointer) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, ext := range p.Extensions {
+		for _, spec := range f.specs {
+			if spec.name != ext.Name {
+				continue
+			}
+			if spec.priority < 0 || spec.priority == ext.Priority {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package lfs
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+var (
+	extNameRE = regexp.MustCompile(`\A\w+\z`)
+	extSpecRE = regexp.MustCompile(`\Aext-(\d)-(\w+)\z`)
+)
+
+// pointerExtensionSpec names the extension on one side of a mapping, either
+// by name alone, or by name and priority, as in "ext-0-foo".  A priority of
+// -1 means that any priority matches.
+type pointerExtensionSpec struct {
+	name     string
+	priority int
+}
+
+func parsePointerExtensionSpec(s string) (pointerExtensionSpec, error) {
+	if m := extSpecRE.FindStringSubmatch(s); m != nil {
+		priority, _ := strconv.Atoi(m[1])
+		return pointerExtensionSpec{name: m[2], priority: priority}, nil
+	}
+	if extNameRE.MatchString(s) {
+		return pointerExtensionSpec{name: s, priority: -1}, nil
+	}
+	return pointerExtensionSpec{}, errors.New(tr.Tr.Get("invalid extension: %q", s))
+}
+
+// PointerExtensionMap rewrites the extension lines of pointers, renaming the
+// extensions and optionally changing their priorities, while leaving the OIDs
+// of both the extensions and the pointers themselves unchanged.
+type PointerExtensionMap struct {
+	renames []pointerExtensionRename
+}
+
+type pointerExtensionRename struct {
+	from, to pointerExtensionSpec
+}
+
+// ParsePointerExtensionMap parses a PointerExtensionMap from a list of
+// mappings of the form "<from>=<to>".  Each side is either the name of an
+// extension, such as "foo", or its name and priority as they appear in a
+// pointer, such as "ext-0-foo".  If "from" gives no priority, an extension of
+// that name matches whatever its priority; if "to" gives none, the priority
+// is kept.  Mappings are tried in order, and only the first to match an
+// extension is applied.
+func ParsePointerExtensionMap(mappings []string) (*PointerExtensionMap, error) {
+	m := &PointerExtensionMap{}
+	for _, mapping := range mappings {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New(tr.Tr.Get("invalid extension mapping: %q", mapping))
+		}
+
+		from, err := parsePointerExtensionSpec(parts[0])
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("invalid extension mapping: %q", mapping))
+		}
+		to, err := parsePointerExtensionSpec(parts[1])
+		if err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("invalid extension mapping: %q", mapping))
+		}
+
+		m.renames = append(m.renames, pointerExtensionRename{from: from, to: to})
+	}
+	return m, nil
+}
+
+// Len returns the number of mappings in the map.
+func (m *PointerExtensionMap) Len() int {
+	return len(m.renames)
+}
+
+// Apply returns a copy of the pointer "p" with its extensions renamed
+// according to the map, and whether any of them were changed.  If none were,
+// "p" itself is returned.  An error is returned if the renamed extensions would
+// share a priority.
+func (m *PointerExtensionMap) Apply(p *Pointer) (*Pointer, bool, error) {
+	if len(p.Extensions) == 0 {
+		return p, false, nil
+	}
+
+	changed := false
+	exts := make([]*PointerExtension, 0, len(p.Extensions))
+	for _, ext := range p.Extensions {
+		mapped := m.apply(ext)
+		if mapped != ext {
+			changed = true
+		}
+		exts = append(exts, mapped)
+	}
+
+	if !changed {
+		return p, false, nil
+	}
+
+	if err := validatePointerExtensions(exts); err != nil {
+		return nil, false, err
+	}
+	sort.Sort(ByPriority(exts))
+
+	return NewPointer(p.Oid, p.Size, exts), true, nil
+}
+
+func (m *PointerExtensionMap) apply(ext *PointerExtension) *PointerExtension {
+	for _, r := range m.renames {
+		if r.from.name != ext.Name {
+			continue
+		}
+		if r.from.priority >= 0 && r.from.priority != ext.Priority {
+			continue
+		}
+
+		priority := ext.Priority
+		if r.to.priority >= 0 {
+			priority = r.to.priority
+		}
+		if r.to.name == ext.Name && priority == ext.Priority {
+			return ext
+		}
+		return &PointerExtension{
+			Name:     r.to.name,
+			Priority: priority,
+			Oid:      ext.Oid,
+			OidType:  ext.OidType,
+		}
+	}
+	return ext
+}
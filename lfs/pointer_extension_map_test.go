@@ -0,0 +1,124 @@
+package lfs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const extensionMapPointer = `version https://git-lfs.github.com/spec/v1
+ext-0-legacy sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+ext-1-bar sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`
+
+func applyPointerExtensionMap(t *testing.T, mappings ...string) (string, bool, error) {
+	m, err := ParsePointerExtensionMap(mappings)
+	require.Nil(t, err)
+
+	p, err := DecodePointer(bytes.NewBufferString(extensionMapPointer))
+	require.Nil(t, err)
+
+	mapped, changed, err := m.Apply(p)
+	if err != nil {
+		return "", false, err
+	}
+	return mapped.Encoded(), changed, nil
+}
+
+func TestPointerExtensionMapRename(t *testing.T) {
+	encoded, changed, err := applyPointerExtensionMap(t, "legacy=foo")
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `version https://git-lfs.github.com/spec/v1
+ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+ext-1-bar sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`, encoded)
+}
+
+func TestPointerExtensionMapReprioritize(t *testing.T) {
+	encoded, changed, err := applyPointerExtensionMap(t, "ext-0-legacy=ext-2-foo")
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `version https://git-lfs.github.com/spec/v1
+ext-1-bar sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
+ext-2-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`, encoded)
+}
+
+func TestPointerExtensionMapFirstMatchWins(t *testing.T) {
+	encoded, changed, err := applyPointerExtensionMap(t, "ext-5-legacy=other", "legacy=foo", "legacy=baz", "bar=ext-1-bar")
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, encoded, "ext-0-foo sha256:ffff")
+	assert.Contains(t, encoded, "ext-1-bar sha256:bbbb")
+}
+
+func TestPointerExtensionMapUnchanged(t *testing.T) {
+	m, err := ParsePointerExtensionMap([]string{"other=foo", "bar=bar"})
+	require.Nil(t, err)
+
+	p, err := DecodePointer(bytes.NewBufferString(extensionMapPointer))
+	require.Nil(t, err)
+
+	mapped, changed, err := m.Apply(p)
+	require.Nil(t, err)
+	assert.False(t, changed)
+	assert.True(t, mapped == p)
+}
+
+func TestPointerExtensionMapDuplicatePriority(t *testing.T) {
+	_, _, err := applyPointerExtensionMap(t, "legacy=ext-1-foo")
+	assert.EqualError(t, err, "duplicate priority found: 1")
+}
+
+func TestParsePointerExtensionMapInvalid(t *testing.T) {
+	for _, mapping := range []string{
+		"legacy",
+		"=foo",
+		"legacy=",
+		"ext-10-legacy=foo",
+		"legacy=ext-x-foo",
+		"le-gacy=foo",
+	} {
+		_, err := ParsePointerExtensionMap([]string{mapping})
+		assert.NotNil(t, err, mapping)
+	}
+}
+
+func TestPointerExtensionFilter(t *testing.T) {
+	p, err := DecodePointer(bytes.NewBufferString(extensionMapPointer))
+	require.Nil(t, err)
+	plain := NewPointer(p.Oid, p.Size, nil)
+
+	for desc, c := range map[string]struct {
+		names   []string
+		matches bool
+	}{
+		"name":              {[]string{"bar"}, true},
+		"name and priority": {[]string{"ext-0-legacy"}, true},
+		"other priority":    {[]string{"ext-0-bar"}, false},
+		"missing":           {[]string{"foo"}, false},
+		"any of several":    {[]string{"foo", "ext-1-bar"}, true},
+		"none of several":   {[]string{"foo", "ext-1-legacy"}, false},
+	} {
+		f, err := ParsePointerExtensionFilter(c.names)
+		require.Nil(t, err, desc)
+		assert.Equal(t, c.matches, f.Matches(p), desc)
+		assert.False(t, f.Matches(plain), desc)
+	}
+
+	var f *PointerExtensionFilter
+	assert.True(t, f.Matches(p))
+	assert.True(t, f.Matches(plain))
+
+	_, err = ParsePointerExtensionFilter([]string{"ext-foo"})
+	assert.EqualError(t, err, `invalid extension: "ext-foo"`)
+}
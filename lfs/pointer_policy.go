@@ -0,0 +1,88 @@
+package lfs
+
+import (
+	"io"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tools/humanize"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// PointerPolicy describes the pointers which are acceptable to a caller, such
+// as a pre-receive hook rejecting commits which introduce disallowed objects.
+// The zero value accepts every pointer.
+type PointerPolicy struct {
+	// MaxSize is the largest object size which is allowed, in bytes, or
+	// zero to allow objects of any size.
+	MaxSize int64
+	// AllowedOidTypes lists the hash algorithms which are allowed for the
+	// pointer's OID and those of its extensions, e.g., "sha256".  If empty,
+	// any hash algorithm is allowed.
+	AllowedOidTypes []string
+	// RequiredExtensions lists the names of extensions which every pointer
+	// must record.
+	RequiredExtensions []string
+}
+
+// ValidatePointer checks the given pointer against the policy, returning an
+// error describing the first violation found, or nil if the pointer is
+// acceptable.
+func ValidatePointer(p *Pointer, policy PointerPolicy) error {
+	if p == nil {
+		return errors.New(tr.Tr.Get("no pointer to validate"))
+	}
+
+	if policy.MaxSize > 0 && p.Size > policy.MaxSize {
+		return errors.New(tr.Tr.Get("object %s is %s, which exceeds the maximum size of %s",
+			p.Oid,
+			humanize.FormatBytes(uint64(p.Size)),
+			humanize.FormatBytes(uint64(policy.MaxSize))))
+	}
+
+	if !policy.allowsOidType(p.OidType) {
+		return errors.New(tr.Tr.Get("object %s uses disallowed hash algorithm %q (allowed: %s)",
+			p.Oid, p.OidType, strings.Join(policy.AllowedOidTypes, ", ")))
+	}
+
+	present := make(map[string]struct{}, len(p.Extensions))
+	for _, ext := range p.Extensions {
+		if !policy.allowsOidType(ext.OidType) {
+			return errors.New(tr.Tr.Get("extension %q of object %s uses disallowed hash algorithm %q (allowed: %s)",
+				ext.Name, p.Oid, ext.OidType, strings.Join(policy.AllowedOidTypes, ", ")))
+		}
+		present[ext.Name] = struct{}{}
+	}
+
+	for _, name := range policy.RequiredExtensions {
+		if _, ok := present[name]; !ok {
+			return errors.New(tr.Tr.Get("object %s is missing required extension %q", p.Oid, name))
+		}
+	}
+
+	return nil
+}
+
+// DecodeAndValidatePointer parses a pointer from the given reader and checks
+// it against the policy.  The pointer is returned if it could be parsed, even
+// if it violates the policy.
+func DecodeAndValidatePointer(reader io.Reader, policy PointerPolicy) (*Pointer, error) {
+	p, err := DecodePointer(reader)
+	if err != nil {
+		return nil, err
+	}
+	return p, ValidatePointer(p, policy)
+}
+
+func (policy PointerPolicy) allowsOidType(oidType string) bool {
+	if len(policy.AllowedOidTypes) == 0 {
+		return true
+	}
+
+	for _, t := range policy.AllowedOidTypes {
+		if t == oidType {
+			return true
+		}
+	}
+	return false
+}
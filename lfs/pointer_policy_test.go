@@ -0,0 +1,91 @@
+package lfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policyTestOid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+func TestValidatePointerAllowsAnythingByDefault(t *testing.T) {
+	p := NewPointer(policyTestOid, 1<<40, nil)
+	assert.Nil(t, ValidatePointer(p, PointerPolicy{}))
+}
+
+func TestValidatePointerAcceptsConformingPointer(t *testing.T) {
+	p := NewPointer(policyTestOid, 1024, []*PointerExtension{
+		NewPointerExtension("foo", 0, policyTestOid),
+	})
+
+	assert.Nil(t, ValidatePointer(p, PointerPolicy{
+		MaxSize:            1024,
+		AllowedOidTypes:    []string{"sha256"},
+		RequiredExtensions: []string{"foo"},
+	}))
+}
+
+func TestValidatePointerRejectsNil(t *testing.T) {
+	assert.NotNil(t, ValidatePointer(nil, PointerPolicy{}))
+}
+
+func TestValidatePointerRejectsOversizedObject(t *testing.T) {
+	p := NewPointer(policyTestOid, 2048, nil)
+
+	err := ValidatePointer(p, PointerPolicy{MaxSize: 1024})
+	require.NotNil(t, err)
+	assert.Equal(t, "object "+policyTestOid+" is 2.0 KB, which exceeds the maximum size of 1.0 KB", err.Error())
+}
+
+func TestValidatePointerRejectsDisallowedOidType(t *testing.T) {
+	p := NewPointer(policyTestOid, 12, nil)
+	p.OidType = "sha512"
+
+	err := ValidatePointer(p, PointerPolicy{AllowedOidTypes: []string{"sha256"}})
+	require.NotNil(t, err)
+	assert.Equal(t, "object "+policyTestOid+" uses disallowed hash algorithm \"sha512\" (allowed: sha256)", err.Error())
+}
+
+func TestValidatePointerRejectsDisallowedExtensionOidType(t *testing.T) {
+	ext := NewPointerExtension("foo", 0, policyTestOid)
+	ext.OidType = "sha512"
+	p := NewPointer(policyTestOid, 12, []*PointerExtension{ext})
+
+	err := ValidatePointer(p, PointerPolicy{AllowedOidTypes: []string{"sha256"}})
+	require.NotNil(t, err)
+	assert.Equal(t, "extension \"foo\" of object "+policyTestOid+" uses disallowed hash algorithm \"sha512\" (allowed: sha256)", err.Error())
+}
+
+func TestValidatePointerRejectsMissingExtension(t *testing.T) {
+	p := NewPointer(policyTestOid, 12, []*PointerExtension{
+		NewPointerExtension("foo", 0, policyTestOid),
+	})
+
+	err := ValidatePointer(p, PointerPolicy{RequiredExtensions: []string{"foo", "bar"}})
+	require.NotNil(t, err)
+	assert.Equal(t, "object "+policyTestOid+" is missing required extension \"bar\"", err.Error())
+}
+
+func TestDecodeAndValidatePointer(t *testing.T) {
+	data := "version https://git-lfs.github.com/spec/v1\n" +
+		"oid sha256:" + policyTestOid + "\n" +
+		"size 2048\n"
+
+	p, err := DecodeAndValidatePointer(strings.NewReader(data), PointerPolicy{MaxSize: 4096})
+	assert.Nil(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, int64(2048), p.Size)
+
+	p, err = DecodeAndValidatePointer(strings.NewReader(data), PointerPolicy{MaxSize: 1024})
+	assert.NotNil(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, policyTestOid, p.Oid)
+}
+
+func TestDecodeAndValidatePointerRejectsInvalidPointer(t *testing.T) {
+	p, err := DecodeAndValidatePointer(strings.NewReader("not a pointer"), PointerPolicy{})
+	assert.NotNil(t, err)
+	assert.Nil(t, p)
+}
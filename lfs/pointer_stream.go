@@ -0,0 +1,110 @@
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// PointerStreamError is given to the callback of ReadPointerStream for each
+// entry of a pointer stream which could not be parsed.
+type PointerStreamError struct {
+	// Line is the number of the first line of the entry.
+	Line int
+	Err  error
+}
+
+func (e *PointerStreamError) Error() string {
+	return tr.Tr.Get("invalid pointer at line %d: %s", e.Line, e.Err)
+}
+
+// ReadPointerStream reads a stream of pointers from "r", calling "cb" with each
+// of them in turn, or with a *PointerStreamError for each entry which is not a
+// valid pointer.  An invalid entry does not stop the stream from being read;
+// only an error reading "r" itself is returned.
+//
+// Each entry of the stream is either the text of a pointer file, starting with
+// its "version" line and ending at its "size" line, which is always its last,
+// or failing that at a blank line, at the next "version" line, or at the end
+// of the stream, or a single line giving an OID, with or without
+// its "sha256:" prefix, and a size separated by a space.  Blank lines between
+// entries are ignored.
+func ReadPointerStream(r io.Reader, cb func(p *Pointer, err error)) error {
+	var pointer bytes.Buffer
+	start := 0
+
+	flush := func() {
+		if pointer.Len() == 0 {
+			return
+		}
+		p, err := DecodePointer(&pointer)
+		if err != nil {
+			cb(nil, &PointerStreamError{Line: start, Err: err})
+		} else {
+			cb(p, nil)
+		}
+		pointer.Reset()
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(text, "version ") {
+			flush()
+			start = line
+		} else if len(strings.TrimSpace(text)) == 0 {
+			flush()
+			continue
+		} else if pointer.Len() == 0 {
+			p, err := parsePointerStreamLine(text)
+			if err != nil {
+				cb(nil, &PointerStreamError{Line: line, Err: err})
+			} else {
+				cb(p, nil)
+			}
+			continue
+		}
+
+		pointer.WriteString(text)
+		pointer.WriteByte('\n')
+
+		// The size is always the last key of a pointer.
+		if strings.HasPrefix(text, "size ") {
+			flush()
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not read pointers"))
+	}
+	return nil
+}
+
+// parsePointerStreamLine parses a line of a pointer stream giving an OID and a
+// size.
+func parsePointerStreamLine(text string) (*Pointer, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return nil, errors.New(tr.Tr.Get("expected an OID and a size, got %q", text))
+	}
+	value := fields[0]
+	if !strings.Contains(value, ":") {
+		value = oidType + ":" + value
+	}
+	oid, err := parseOid(value)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || size < 0 {
+		return nil, errors.New(tr.Tr.Get("invalid size: %q", fields[1]))
+	}
+	return NewPointer(oid, size, nil), nil
+}
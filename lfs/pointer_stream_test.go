@@ -0,0 +1,73 @@
+package lfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPointerStreamReadsPointersAndOidLines(t *testing.T) {
+	input := strings.Join([]string{
+		"version https://git-lfs.github.com/spec/v1",
+		"oid sha256:" + manifestOidA,
+		"size 1",
+		"version https://git-lfs.github.com/spec/v1",
+		"oid sha256:" + manifestOidB,
+		"size 2",
+		"",
+		"",
+		manifestOidA + " 3",
+		"sha256:" + manifestOidB + " 4\r",
+		"",
+	}, "\n")
+
+	var pointers []*Pointer
+	err := ReadPointerStream(strings.NewReader(input), func(p *Pointer, err error) {
+		assert.Nil(t, err)
+		pointers = append(pointers, p)
+	})
+	require.Nil(t, err)
+
+	require.Len(t, pointers, 4)
+	assert.Equal(t, manifestOidA, pointers[0].Oid)
+	assert.EqualValues(t, 1, pointers[0].Size)
+	assert.Equal(t, manifestOidB, pointers[1].Oid)
+	assert.EqualValues(t, 2, pointers[1].Size)
+	assert.Equal(t, manifestOidA, pointers[2].Oid)
+	assert.EqualValues(t, 3, pointers[2].Size)
+	assert.Equal(t, manifestOidB, pointers[3].Oid)
+	assert.EqualValues(t, 4, pointers[3].Size)
+}
+
+func TestReadPointerStreamReportsInvalidEntries(t *testing.T) {
+	input := strings.Join([]string{
+		"version https://git-lfs.github.com/spec/v1",
+		"oid sha256:abc",
+		"size 1",
+		"not a pointer",
+		manifestOidA + " -1",
+		manifestOidA + " 5",
+	}, "\n")
+
+	var pointers []*Pointer
+	var errs []*PointerStreamError
+	err := ReadPointerStream(strings.NewReader(input), func(p *Pointer, err error) {
+		if err != nil {
+			errs = append(errs, err.(*PointerStreamError))
+			return
+		}
+		pointers = append(pointers, p)
+	})
+	require.Nil(t, err)
+
+	require.Len(t, pointers, 1)
+	assert.Equal(t, manifestOidA, pointers[0].Oid)
+	assert.EqualValues(t, 5, pointers[0].Size)
+
+	require.Len(t, errs, 3)
+	assert.Equal(t, "invalid pointer at line 1: Invalid OID: abc", errs[0].Error())
+	assert.Equal(t, `invalid pointer at line 4: expected an OID and a size, got "not a pointer"`, errs[1].Error())
+	assert.Equal(t, `invalid pointer at line 5: invalid size: "-1"`, errs[2].Error())
+}
@@ -3,6 +3,7 @@ package lfs
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"reflect"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncode(t *testing.T) {
@@ -68,6 +70,42 @@ func TestEncodeExtensions(t *testing.T) {
 	assert.Equal(t, "EOF", err.Error())
 }
 
+func TestEncodeExtensionsSort(t *testing.T) {
+	exts := []*PointerExtension{
+		NewPointerExtension("baz", 2, "baz_oid"),
+		NewPointerExtension("foo", 0, "foo_oid"),
+		NewPointerExtension("bar", 1, "bar_oid"),
+	}
+	pointer := NewPointer("main_oid", 12345, exts)
+
+	assert.Equal(t, "version https://git-lfs.github.com/spec/v1\n"+
+		"ext-0-foo sha256:foo_oid\n"+
+		"ext-1-bar sha256:bar_oid\n"+
+		"ext-2-baz sha256:baz_oid\n"+
+		"oid sha256:main_oid\n"+
+		"size 12345\n", pointer.Encoded())
+	assert.Equal(t, "baz", pointer.Extensions[0].Name, "the pointer's own extensions are not reordered")
+}
+
+func TestEncodeDecodeExtensionsRoundTrip(t *testing.T) {
+	ex := `version https://git-lfs.github.com/spec/v1
+ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+ext-1-bar_2 sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
+ext-9-baz sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	assert.Nil(t, err)
+	assert.True(t, p.Canonical)
+	assert.Equal(t, ex, p.Encoded())
+
+	again, err := DecodePointer(bytes.NewBufferString(p.Encoded()))
+	assert.Nil(t, err)
+	assert.Equal(t, p, again)
+}
+
 func assertLine(t *testing.T, r *bufio.Reader, expected string) {
 	actual, err := r.ReadString('\n')
 	assert.Nil(t, err)
@@ -99,6 +137,30 @@ size 12345`
 	assertEqualWithExample(t, ex, int64(12345), p.Size)
 }
 
+func TestDecodeUpperCaseOid(t *testing.T) {
+	ex := `version https://git-lfs.github.com/spec/v1
+ext-0-foo sha256:FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF
+oid sha256:4D7A214614AB2935C943F9E0FF69D22EADBB8F32B1258DAAA5E2CA24D17E2393
+size 12345
+`
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	require.Nil(t, err)
+	assert.Equal(t, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", p.Oid)
+	require.Len(t, p.Extensions, 1)
+	assert.Equal(t, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", p.Extensions[0].Oid)
+	assert.False(t, p.Canonical)
+}
+
+func TestValidOid(t *testing.T) {
+	assert.True(t, ValidOid("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"))
+	assert.False(t, ValidOid("4D7A214614AB2935C943F9E0FF69D22EADBB8F32B1258DAAA5E2CA24D17E2393"))
+	assert.False(t, ValidOid("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e239"))
+	assert.False(t, ValidOid("sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"))
+	assert.False(t, ValidOid("../../../../../../../../../../../../../../../../../../etc/passwd"))
+	assert.False(t, ValidOid(""))
+}
+
 func TestDecodeExtensions(t *testing.T) {
 	ex := `version https://git-lfs.github.com/spec/v1
 ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
@@ -168,6 +230,30 @@ size 12345`
 	assertEqualWithExample(t, ex, int64(12345), p.Size)
 }
 
+func TestDecodeLegacyVersions(t *testing.T) {
+	for _, version := range []string{
+		"http://git-media.io/v/2",
+		"https://hawser.github.com/spec/v1",
+	} {
+		ex := fmt.Sprintf(`version %s
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`, version)
+
+		p, err := DecodePointer(bytes.NewBufferString(ex))
+		require.Nil(t, err, version)
+		assert.Equal(t, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", p.Oid, version)
+		assert.Equal(t, int64(12345), p.Size, version)
+
+		// Only the current version is written.
+		assert.False(t, p.Canonical, version)
+		assert.Equal(t, `version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`, p.Encoded(), version)
+	}
+}
+
 func TestDecodeFromEmptyReader(t *testing.T) {
 	p, buf, err := DecodeFrom(strings.NewReader(""))
 	by, _ := ioutil.ReadAll(buf)
@@ -243,6 +329,16 @@ func TestDecodeInvalid(t *testing.T) {
 		// bad oid
 		`version https://git-lfs.github.com/spec/v1
 oid sha256:boom
+size 12345`,
+
+		// oid too short
+		`version https://git-lfs.github.com/spec/v1
+oid sha256:4D7A214614AB2935C943F9E0FF69D22EADBB8F32B1258DAAA5E2CA24D17E239
+size 12345`,
+
+		// oid not hex
+		`version https://git-lfs.github.com/spec/v1
+oid sha256:4G7A214614AB2935C943F9E0FF69D22EADBB8F32B1258DAAA5E2CA24D17E2393
 size 12345`,
 
 		// bad oid type
@@ -310,6 +406,13 @@ size 12345`,
 ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
 ext-0-bar sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
 oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345`,
+
+		// duplicate ext
+		`version https://git-lfs.github.com/spec/v1
+ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+ext-0-foo sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
 size 12345`,
 
 		// ext priority over 9
@@ -347,3 +450,82 @@ size 177735`,
 func assertEqualWithExample(t *testing.T, example string, expected, actual interface{}) {
 	assert.Equal(t, expected, actual, "Example:\n%s", strings.TrimSpace(example))
 }
+
+func oversizedPointer(size int) string {
+	ex := `version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+`
+	return ex + strings.Repeat(" ", size-len(ex)-1) + "x"
+}
+
+func TestDecodeRejectsOversizedPointer(t *testing.T) {
+	ex := oversizedPointer(DefaultMaxPointerSize + 1)
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	assert.Nil(t, p)
+	if assert.True(t, errors.IsNotAPointerError(err), "expected NotAPointerError. got: %v", err) {
+		assert.Contains(t, err.Error(), "exceeds maximum pointer size")
+	}
+
+	p, r, err := DecodeFrom(bytes.NewBufferString(ex))
+	assert.Nil(t, p)
+	assert.True(t, errors.IsNotAPointerError(err))
+	contents, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, ex, string(contents))
+}
+
+func TestDecodeManyExtensionsPointer(t *testing.T) {
+	var ex strings.Builder
+	ex.WriteString("version https://git-lfs.github.com/spec/v1\n")
+	for i := 0; i < 8; i++ {
+		ex.WriteString("ext-" + string(rune('0'+i)) + "-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff\n")
+	}
+	ex.WriteString("oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n")
+	assert.True(t, ex.Len() > 512)
+
+	p, err := DecodePointer(strings.NewReader(ex.String()))
+	assert.Nil(t, err)
+	assert.Len(t, p.Extensions, 8)
+	assert.Equal(t, int64(12345), p.Size)
+
+	SetMaxPointerSize(512)
+	defer SetMaxPointerSize(DefaultMaxPointerSize)
+
+	_, err = DecodePointer(strings.NewReader(ex.String()))
+	assert.True(t, errors.IsNotAPointerError(err), "expected NotAPointerError. got: %v", err)
+}
+
+func TestSetMaxPointerSize(t *testing.T) {
+	defer SetMaxPointerSize(DefaultMaxPointerSize)
+
+	SetMaxPointerSize(300)
+	assert.Equal(t, 300, MaxPointerSize())
+
+	SetMaxPointerSize(0)
+	assert.Equal(t, DefaultMaxPointerSize, MaxPointerSize())
+
+	SetMaxPointerSize(4096)
+	assert.Equal(t, blobSizeCutoff-1, MaxPointerSize())
+}
+
+func TestLooksLikePointer(t *testing.T) {
+	for data, expected := range map[string]bool{
+		"version https://git-lfs.github.com/spec/v1\noid sha256:x\n": true,
+		"version https://hawser.github.com/spec/v1\n":                true,
+		"\n  version https://git-lfs.github.com/spec/v1\nsize 1\n":   true,
+		"version 2\nsee https://git-lfs.github.com\n":                false,
+		"oid sha256:x\nversion https://git-lfs.github.com/spec/v1\n": false,
+		"uses git-lfs\n": false,
+		"":               false,
+	} {
+		assert.Equal(t, expected, LooksLikePointer([]byte(data)), data)
+	}
+}
+
+func TestHasMisleadingSize(t *testing.T) {
+	assert.False(t, EmptyPointer().HasMisleadingSize())
+	assert.False(t, NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil).HasMisleadingSize())
+	assert.True(t, NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 0, nil).HasMisleadingSize())
+}
@@ -0,0 +1,69 @@
+package lfs
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// ScanRecord is one pointer found by a scan, as given to a ScanRecordSink.
+type ScanRecord struct {
+	Oid  string
+	Size int64
+	Name string
+	// Commit is the SHA of the commit in whose tree the pointer was
+	// found, or empty if the scan does not say.
+	Commit string
+}
+
+// ScanRecordSink receives the records of a scan as it finds them, so that they
+// can be stored, such as in a file or a database, without holding all of them
+// in memory.
+type ScanRecordSink interface {
+	// Write stores one record.
+	Write(r *ScanRecord) error
+	// Close stores any records not yet stored, and releases the sink.
+	// The destination to which the sink writes is not closed.
+	Close() error
+}
+
+// scanRecordsCSVFlushEvery is the number of records after which a
+// CSVScanRecordSink flushes its output.
+const scanRecordsCSVFlushEvery = 1000
+
+// CSVScanRecordSink is a ScanRecordSink which writes each record as a row of
+// CSV, after a header row naming the columns "oid", "size", "name", and
+// "commit".
+type CSVScanRecordSink struct {
+	w       *csv.Writer
+	pending int
+}
+
+// NewCSVScanRecordSink returns a ScanRecordSink which writes CSV to "w".
+func NewCSVScanRecordSink(w io.Writer) (*CSVScanRecordSink, error) {
+	s := &CSVScanRecordSink{w: csv.NewWriter(w)}
+	if err := s.w.Write([]string{"oid", "size", "name", "commit"}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *CSVScanRecordSink) Write(r *ScanRecord) error {
+	row := []string{r.Oid, strconv.FormatInt(r.Size, 10), r.Name, r.Commit}
+	if err := s.w.Write(row); err != nil {
+		return err
+	}
+
+	s.pending++
+	if s.pending >= scanRecordsCSVFlushEvery {
+		s.pending = 0
+		s.w.Flush()
+		return s.w.Error()
+	}
+	return nil
+}
+
+func (s *CSVScanRecordSink) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
@@ -0,0 +1,45 @@
+package lfs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVScanRecordSinkWritesRecords(t *testing.T) {
+	var buf bytes.Buffer
+	sink, err := NewCSVScanRecordSink(&buf)
+	require.Nil(t, err)
+
+	require.Nil(t, sink.Write(&ScanRecord{Oid: manifestOidA, Size: 1, Name: "a.dat", Commit: "abc"}))
+	require.Nil(t, sink.Write(&ScanRecord{Oid: manifestOidB, Size: 2, Name: "dir/with, comma \"quoted\".dat"}))
+	require.Nil(t, sink.Close())
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"oid", "size", "name", "commit"},
+		{manifestOidA, "1", "a.dat", "abc"},
+		{manifestOidB, "2", "dir/with, comma \"quoted\".dat", ""},
+	}, rows)
+}
+
+func TestCSVScanRecordSinkFlushesAsItGoes(t *testing.T) {
+	var buf bytes.Buffer
+	sink, err := NewCSVScanRecordSink(&buf)
+	require.Nil(t, err)
+
+	for i := 0; i < scanRecordsCSVFlushEvery; i++ {
+		require.Nil(t, sink.Write(&ScanRecord{Oid: manifestOidA, Size: 1, Name: "a.dat"}))
+	}
+
+	// The records are written before the sink is closed.
+	rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	require.Nil(t, err)
+	assert.Len(t, rows, scanRecordsCSVFlushEvery+1)
+
+	require.Nil(t, sink.Close())
+}
@@ -1,6 +1,8 @@
 package lfs
 
 import (
+	"sync"
+
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/tools"
@@ -35,28 +37,133 @@ type WrappedPointer struct {
 // under the blobSizeCutoff will be ignored. revs is a channel over
 // which strings containing git sha1s will be sent. It returns a channel
 // from which sha1 strings can be read.
-func catFileBatchCheck(revs *StringChannelWrapper, lockableSet *lockableNameSet) (*StringChannelWrapper, chan string, error) {
-	smallRevCh := make(chan string, chanBufSize)
-	lockableCh := make(chan string, chanBufSize)
-	errCh := make(chan error, 2) // up to 2 errors, one from each goroutine
-	if err := runCatFileBatchCheck(smallRevCh, lockableCh, lockableSet, revs, errCh); err != nil {
-		return nil, nil, err
+//
+// If "workers" is more than one, that many git cat-file processes read from
+// revs, each taking the next sha1 as it becomes free, and their results are
+// merged. Each sha1 is read by exactly one of them, so none is given twice.
+func catFileBatchCheck(revs *StringChannelWrapper, lockableSet *lockableNameSet, gitExec *git.ExecOptions, gitConfig []string, workers int) (*StringChannelWrapper, chan string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	smallRevChs := make([]chan string, 0, workers)
+	lockableChs := make([]chan string, 0, workers)
+	errChs := make([]chan error, 0, workers)
+	for i := 0; i < workers; i++ {
+		smallRevCh := make(chan string, chanBufSize)
+		lockableCh := make(chan string, chanBufSize)
+		errCh := make(chan error, 2) // up to 2 errors, one from each goroutine
+		if err := runCatFileBatchCheck(smallRevCh, lockableCh, lockableSet, revs, errCh, gitExec, gitConfig); err != nil {
+			return nil, nil, err
+		}
+		smallRevChs = append(smallRevChs, smallRevCh)
+		lockableChs = append(lockableChs, lockableCh)
+		errChs = append(errChs, errCh)
 	}
-	return NewStringChannelWrapper(smallRevCh, errCh), lockableCh, nil
+	return NewStringChannelWrapper(mergeStringChannels(smallRevChs), mergeErrorChannels(errChs)), mergeStringChannels(lockableChs), nil
 }
 
 // catFileBatch uses git cat-file --batch to get the object contents
 // of a git object, given its sha1. The contents will be decoded into
 // a Git LFS pointer. revs is a channel over which strings containing Git SHA1s
 // will be sent. It returns a channel from which point.Pointers can be read.
-func catFileBatch(revs *StringChannelWrapper, lockableSet *lockableNameSet, gitEnv, osEnv config.Environment) (*PointerChannelWrapper, chan string, error) {
-	pointerCh := make(chan *WrappedPointer, chanBufSize)
-	lockableCh := make(chan string, chanBufSize)
-	errCh := make(chan error, 5) // shared by 2 goroutines & may add more detail errors?
-	if err := runCatFileBatch(pointerCh, lockableCh, lockableSet, revs, errCh, gitEnv, osEnv); err != nil {
-		return nil, nil, err
+//
+// As with catFileBatchCheck, "workers" git cat-file processes share the sha1s
+// read from revs between them.
+func catFileBatch(revs *StringChannelWrapper, lockableSet *lockableNameSet, gitEnv, osEnv config.Environment, workers int) (*PointerChannelWrapper, chan string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	pointerChs := make([]chan *WrappedPointer, 0, workers)
+	lockableChs := make([]chan string, 0, workers)
+	errChs := make([]chan error, 0, workers)
+	for i := 0; i < workers; i++ {
+		pointerCh := make(chan *WrappedPointer, chanBufSize)
+		lockableCh := make(chan string, chanBufSize)
+		errCh := make(chan error, 5) // shared by 2 goroutines & may add more detail errors?
+		if err := runCatFileBatch(pointerCh, lockableCh, lockableSet, revs, errCh, gitEnv, osEnv); err != nil {
+			return nil, nil, err
+		}
+		pointerChs = append(pointerChs, pointerCh)
+		lockableChs = append(lockableChs, lockableCh)
+		errChs = append(errChs, errCh)
+	}
+	return NewPointerChannelWrapper(mergePointerChannels(pointerChs), mergeErrorChannels(errChs)), mergeStringChannels(lockableChs), nil
+}
+
+// mergeStringChannels returns a channel which gives the strings sent on each of
+// "chs", and which is closed once they all are.  A single channel is returned
+// as it is.
+func mergeStringChannels(chs []chan string) chan string {
+	if len(chs) == 1 {
+		return chs[0]
+	}
+
+	out := make(chan string, chanBufSize)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		go func(ch chan string) {
+			defer wg.Done()
+			for s := range ch {
+				out <- s
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// mergePointerChannels behaves as mergeStringChannels, but for pointers.
+func mergePointerChannels(chs []chan *WrappedPointer) chan *WrappedPointer {
+	if len(chs) == 1 {
+		return chs[0]
+	}
+
+	out := make(chan *WrappedPointer, chanBufSize)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		go func(ch chan *WrappedPointer) {
+			defer wg.Done()
+			for p := range ch {
+				out <- p
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// mergeErrorChannels behaves as mergeStringChannels, but for errors.
+func mergeErrorChannels(chs []chan error) chan error {
+	if len(chs) == 1 {
+		return chs[0]
+	}
+
+	out := make(chan error, len(chs))
+	wg := &sync.WaitGroup{}
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		go func(ch chan error) {
+			defer wg.Done()
+			for err := range ch {
+				out <- err
+			}
+		}(ch)
 	}
-	return NewPointerChannelWrapper(pointerCh, errCh), lockableCh, nil
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
 }
 
 // ChannelWrapper for pointer Scan* functions to more easily return async error data via Wait()
@@ -5,15 +5,27 @@ package lfs_test // to avoid import cycles
 // which avoids import cycles with testutils
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/filepathfilter"
+	"github.com/git-lfs/git-lfs/v3/git"
 	. "github.com/git-lfs/git-lfs/v3/lfs"
 	test "github.com/git-lfs/git-lfs/v3/t/cmd/util"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScanUnpushed(t *testing.T) {
@@ -205,3 +217,1103 @@ func scanPreviousVersions(t *testing.T, ref string, since time.Time) ([]*Wrapped
 	err := gitscanner.ScanPreviousVersions(ref, since, nil)
 	return pointers, err
 }
+
+type countingLockableSet struct {
+	names  map[string]bool
+	checks int
+}
+
+func (s *countingLockableSet) Contains(name string) bool {
+	s.checks++
+	return s.names[name]
+}
+
+func scanRefsWithLockables(t *testing.T, skip bool) (pointers []*WrappedPointer, lockables []string, checks int) {
+	set := &countingLockableSet{names: map[string]bool{"locked.bin": true}}
+
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		assert.Nil(t, err)
+		if p != nil {
+			pointers = append(pointers, p)
+		}
+	})
+	gitscanner.PotentialLockables = set
+	gitscanner.FoundLockable = func(name string) { lockables = append(lockables, name) }
+	gitscanner.SkipLockableCheck = skip
+
+	assert.Nil(t, gitscanner.ScanRefs([]string{"master"}, nil, nil))
+	gitscanner.Close()
+
+	return pointers, lockables, set.checks
+}
+
+func TestScanRefsSkipLockableCheck(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 20},
+			},
+		},
+	})
+
+	require.Nil(t, ioutil.WriteFile("locked.bin", []byte("not a pointer"), 0644))
+	test.RunGitCommand(t, true, "add", "locked.bin")
+	test.RunGitCommand(t, true, "commit", "-m", "add locked.bin")
+
+	pointers, lockables, checks := scanRefsWithLockables(t, false)
+	assert.Len(t, pointers, 1)
+	assert.Equal(t, []string{"locked.bin"}, lockables)
+	assert.NotZero(t, checks)
+
+	pointers, lockables, checks = scanRefsWithLockables(t, true)
+	require.Len(t, pointers, 1)
+	assert.Equal(t, "file1.dat", pointers[0].Name)
+	assert.Empty(t, lockables)
+	assert.Zero(t, checks)
+}
+
+func TestScanRefsBatchesLockables(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 20},
+			},
+		},
+	})
+
+	set := &countingLockableSet{names: make(map[string]bool)}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("locked%d.bin", i)
+		set.names[name] = true
+		require.Nil(t, ioutil.WriteFile(name, []byte(name), 0644))
+		test.RunGitCommand(t, true, "add", name)
+	}
+	test.RunGitCommand(t, true, "commit", "-m", "add locked files")
+
+	var batches [][]string
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		assert.Nil(t, err)
+	})
+	gitscanner.PotentialLockables = set
+	gitscanner.FoundLockable = func(name string) {
+		t.Errorf("unexpected call to FoundLockable with %q", name)
+	}
+	gitscanner.FoundLockables = func(names []string) { batches = append(batches, names) }
+	gitscanner.LockableBatchSize = 2
+
+	assert.Nil(t, gitscanner.ScanRefs([]string{"master"}, nil, nil))
+	gitscanner.Close()
+
+	var all []string
+	for _, batch := range batches {
+		assert.True(t, len(batch) > 0 && len(batch) <= 2, "batch %v", batch)
+		all = append(all, batch...)
+	}
+	sort.Strings(all)
+	assert.Equal(t, []string{"locked0.bin", "locked1.bin", "locked2.bin", "locked3.bin", "locked4.bin"}, all)
+	assert.Len(t, batches, 3)
+}
+
+func TestScanRefsWithMultipleCatFileWorkers(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	inputs := make([]*test.CommitInput, 0, 30)
+	for i := 0; i < 30; i++ {
+		inputs = append(inputs, &test.CommitInput{
+			Files: []*test.FileInput{
+				{Filename: fmt.Sprintf("file%d.dat", i), Size: int64(20 + i)},
+				// The same content under another name is one blob.
+				{Filename: fmt.Sprintf("copy%d.dat", i), Data: fmt.Sprintf("shared %d", i%3)},
+			},
+		})
+	}
+	repo.AddCommits(inputs)
+
+	set := &countingLockableSet{names: make(map[string]bool)}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("locked%d.bin", i)
+		set.names[name] = true
+		require.Nil(t, ioutil.WriteFile(name, []byte(name), 0644))
+		test.RunGitCommand(t, true, "add", name)
+	}
+	test.RunGitCommand(t, true, "commit", "-m", "add locked files")
+
+	scan := func(workers int) ([]string, []string) {
+		var mu sync.Mutex
+		var shas, lockables []string
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			assert.Nil(t, err)
+			mu.Lock()
+			shas = append(shas, p.Sha1)
+			mu.Unlock()
+		})
+		gitscanner.CatFileWorkers = workers
+		gitscanner.PotentialLockables = set
+		gitscanner.FoundLockable = func(name string) {
+			mu.Lock()
+			lockables = append(lockables, name)
+			mu.Unlock()
+		}
+
+		assert.Nil(t, gitscanner.ScanRefs([]string{"master"}, nil, nil))
+		gitscanner.Close()
+
+		sort.Strings(shas)
+		sort.Strings(lockables)
+		return shas, lockables
+	}
+
+	expectedShas, expectedLockables := scan(1)
+	assert.Len(t, expectedShas, 33)
+	assert.Equal(t, []string{"locked0.bin", "locked1.bin", "locked2.bin", "locked3.bin", "locked4.bin"}, expectedLockables)
+
+	for _, workers := range []int{2, 4, 7} {
+		shas, lockables := scan(workers)
+		assert.Equal(t, expectedShas, shas, "workers: %d", workers)
+		assert.Equal(t, expectedLockables, lockables, "workers: %d", workers)
+
+		// Each blob is reported only once.
+		for i := 1; i < len(shas); i++ {
+			assert.NotEqual(t, shas[i-1], shas[i], "workers: %d", workers)
+		}
+	}
+}
+
+func TestScanAllStop(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	inputs := make([]*test.CommitInput, 0, 20)
+	for i := 0; i < 20; i++ {
+		inputs = append(inputs, &test.CommitInput{
+			Files: []*test.FileInput{
+				{Filename: fmt.Sprintf("file%d.dat", i), Size: int64(20 + i)},
+			},
+		})
+	}
+	repo.AddCommits(inputs)
+
+	goroutines := runtime.NumGoroutine()
+
+	var pointers []*WrappedPointer
+	var gitscanner *GitScanner
+	gitscanner = NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		assert.Nil(t, err)
+		pointers = append(pointers, p)
+		if len(pointers) == 3 {
+			gitscanner.Stop()
+		}
+	})
+
+	assert.Nil(t, gitscanner.ScanAll(nil))
+	assert.True(t, gitscanner.Stopped())
+	assert.Len(t, pointers, 3)
+
+	// A stopped scanner does not report anything from later scans.
+	assert.Nil(t, gitscanner.ScanTree("master"))
+	assert.Len(t, pointers, 3)
+	gitscanner.Close()
+
+	// The goroutines feeding the scan exit shortly after it returns.
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
+
+func TestTreeNames(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "old.dat", Size: 20},
+				{Filename: "folder/nested.dat", Data: "same contents", Size: 13},
+			},
+		},
+		{
+			Files: []*test.FileInput{
+				{Filename: "old.dat", Size: 25},
+				{Filename: "copy.dat", Data: "same contents", Size: 13},
+			},
+		},
+	})
+
+	blobSha := func(rev string) string {
+		return strings.TrimSpace(test.RunGitCommand(t, true, "rev-parse", rev))
+	}
+
+	previous := blobSha("HEAD~1:old.dat")
+	current := blobSha("HEAD:old.dat")
+	shared := blobSha("HEAD:copy.dat")
+	require.Equal(t, shared, blobSha("HEAD:folder/nested.dat"))
+
+	names, err := TreeNames("HEAD", map[string]struct{}{
+		previous: struct{}{},
+		current:  struct{}{},
+		shared:   struct{}{},
+	})
+	require.Nil(t, err)
+
+	// The previous version of old.dat is not in the tree, so it cannot
+	// be named, and a blob found at more than one path is named after
+	// the first of them.
+	assert.Equal(t, map[string]string{
+		current: "old.dat",
+		shared:  "copy.dat",
+	}, names)
+}
+
+func TestTreeNamesEmpty(t *testing.T) {
+	names, err := TreeNames("HEAD", nil)
+	assert.Nil(t, err)
+	assert.Empty(t, names)
+}
+
+func TestScanTreeForPointers(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	require.Nil(t, ioutil.WriteFile(".gitattributes", []byte("*.dat filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+	test.RunGitCommand(t, true, "add", ".gitattributes")
+	test.RunGitCommand(t, true, "commit", "-m", "track *.dat")
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 20},
+			},
+		},
+		{
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 25},
+				{Filename: "folder/b.dat", Size: 30},
+			},
+		},
+	})
+
+	scan := func(treeish string) map[string]*WrappedPointer {
+		pointers := make(map[string]*WrappedPointer)
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			if assert.Nil(t, err) {
+				pointers[p.Name] = p
+			}
+		})
+		defer gitscanner.Close()
+
+		require.Nil(t, gitscanner.ScanTreeForPointers(treeish, nil))
+		return pointers
+	}
+
+	// Only the pointers in the tree of the given commit are reported, not
+	// those from its ancestors or descendants.
+	pointers := scan(outputs[0].Sha)
+	require.Len(t, pointers, 1)
+	assert.Equal(t, outputs[0].Files[0].Oid, pointers["a.dat"].Oid)
+	assert.EqualValues(t, 20, pointers["a.dat"].Size)
+
+	// A tree may be given directly.
+	pointers = scan("HEAD^{tree}")
+	require.Len(t, pointers, 2)
+	assert.Equal(t, outputs[1].Files[0].Oid, pointers["a.dat"].Oid)
+	assert.Equal(t, outputs[1].Files[1].Oid, pointers["folder/b.dat"].Oid)
+}
+
+func TestScanRefLargest(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	require.Nil(t, ioutil.WriteFile(".gitattributes", []byte("*.dat filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+	test.RunGitCommand(t, true, "add", ".gitattributes")
+	test.RunGitCommand(t, true, "commit", "-m", "track *.dat")
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 20},
+				{Filename: "b.dat", Size: 50},
+				{Filename: "c.dat", Size: 10},
+				{Filename: "d.dat", Size: 40},
+				{Filename: "e.dat", Size: 30},
+			},
+		},
+	})
+
+	scan := func(n int) []int64 {
+		var sizes []int64
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			if assert.Nil(t, err) {
+				sizes = append(sizes, p.Size)
+			}
+		})
+		defer gitscanner.Close()
+
+		require.Nil(t, gitscanner.ScanRefLargest("HEAD", n, nil))
+		return sizes
+	}
+
+	assert.Equal(t, []int64{50, 40, 30}, scan(3))
+	assert.Equal(t, []int64{50, 40, 30, 20, 10}, scan(0))
+	assert.Equal(t, []int64{50, 40, 30, 20, 10}, scan(10))
+}
+
+func TestScanLifespans(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	require.Nil(t, ioutil.WriteFile(".gitattributes", []byte("*.dat filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+	test.RunGitCommand(t, true, "add", ".gitattributes")
+	test.RunGitCommand(t, true, "commit", "-m", "track *.dat")
+
+	now := time.Now().Truncate(time.Second).UTC()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{ // 0
+			CommitDate: now.AddDate(0, 0, -30),
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 20},
+				{Filename: "folder/b.dat", Size: 30},
+			},
+		},
+		{ // 1
+			CommitDate: now.AddDate(0, 0, -20),
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 21},
+			},
+		},
+		{ // 2
+			CommitDate: now.AddDate(0, 0, -10),
+			Files: []*test.FileInput{
+				{Filename: "folder/b.dat", Size: 31},
+			},
+		},
+	})
+
+	commit := func(i int) LifespanCommit {
+		return LifespanCommit{Sha: outputs[i].Sha, Date: now.AddDate(0, 0, -30+10*i)}
+	}
+	lifespan := func(p *Pointer, name string, first, last, commits int) *ObjectLifespan {
+		return &ObjectLifespan{
+			Oid:     p.Oid,
+			Size:    p.Size,
+			Name:    name,
+			First:   commit(first),
+			Last:    commit(last),
+			Commits: commits,
+		}
+	}
+
+	gitscanner := NewGitScanner(config.New(), nil)
+	defer gitscanner.Close()
+
+	lifespans, err := gitscanner.ScanLifespans([]string{"HEAD"}, nil, ScanRefsMode)
+	require.Nil(t, err)
+
+	expected := []*ObjectLifespan{
+		lifespan(outputs[0].Files[0], "a.dat", 0, 0, 1),
+		lifespan(outputs[0].Files[1], "folder/b.dat", 0, 1, 2),
+		lifespan(outputs[1].Files[0], "a.dat", 1, 2, 2),
+		lifespan(outputs[2].Files[0], "folder/b.dat", 2, 2, 1),
+	}
+	// Objects first seen in the same commit are ordered by OID.
+	if expected[0].Oid > expected[1].Oid {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+	assert.Equal(t, expected, lifespans)
+
+	gitscanner.Filter = filepathfilter.New([]string{"folder/**"}, nil, filepathfilter.GitIgnore)
+	lifespans, err = gitscanner.ScanLifespans([]string{"HEAD"}, nil, ScanRefsMode)
+	require.Nil(t, err)
+	assert.Equal(t, []*ObjectLifespan{
+		lifespan(outputs[0].Files[1], "folder/b.dat", 0, 1, 2),
+		lifespan(outputs[2].Files[0], "folder/b.dat", 2, 2, 1),
+	}, lifespans)
+}
+
+func TestScanNonASCIINames(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	// Git quotes paths containing non-ASCII characters in its output
+	// unless told otherwise.
+	test.RunGitCommand(t, true, "config", "core.quotePath", "true")
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "café.dat", Size: 20},
+				{Filename: "目录/文件.dat", Size: 30},
+			},
+		},
+	})
+
+	scan := func(gitConfig []string) []string {
+		var names []string
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			if assert.Nil(t, err) {
+				names = append(names, p.Name)
+			}
+		})
+		gitscanner.GitConfig = gitConfig
+		defer gitscanner.Close()
+
+		require.Nil(t, gitscanner.ScanUnpushed("", nil))
+		sort.Strings(names)
+		return names
+	}
+
+	assert.Equal(t, []string{"café.dat", "目录/文件.dat"}, scan(nil))
+
+	// Pairs given in GitConfig take precedence over the defaults, but
+	// paths which Git quotes are decoded, so the result is the same.
+	assert.Equal(t, []string{"café.dat", "目录/文件.dat"}, scan([]string{"core.quotePath=true"}))
+}
+
+func TestScanIncludesNotes(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 20},
+			},
+		},
+	})
+	fileOid := outputs[0].Files[0].Oid
+
+	noteOid := strings.Repeat("a", 64)
+	note := NewPointer(noteOid, 30, nil).Encoded()
+	require.Nil(t, ioutil.WriteFile("note.txt", []byte(note), 0644))
+	test.RunGitCommand(t, true, "notes", "add", "-F", "note.txt", "HEAD")
+
+	scan := func(includeNotes bool, fn func(*GitScanner) error) []string {
+		var oids []string
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			require.Nil(t, err)
+			oids = append(oids, p.Oid)
+		})
+		defer gitscanner.Close()
+		gitscanner.IncludeNotes = includeNotes
+
+		require.Nil(t, fn(gitscanner))
+		sort.Strings(oids)
+		return oids
+	}
+	scanRef := func(s *GitScanner) error { return s.ScanRef("master", nil) }
+	scanRefs := func(s *GitScanner) error { return s.ScanRefs([]string{"master"}, nil, nil) }
+
+	// Objects referenced only by notes are not found by default...
+	assert.Equal(t, []string{fileOid}, scan(false, scanRef))
+	assert.Equal(t, []string{fileOid}, scan(false, scanRefs))
+
+	// ...but are when the notes refs are included in the walk.
+	expected := []string{fileOid, noteOid}
+	sort.Strings(expected)
+	assert.Equal(t, expected, scan(true, scanRef))
+	assert.Equal(t, expected, scan(true, scanRefs))
+
+	// Scanning all refs always includes notes.
+	assert.Equal(t, expected, scan(false, func(s *GitScanner) error { return s.ScanAll(nil) }))
+
+	assert.Equal(t, []string{noteOid}, scan(false, func(s *GitScanner) error { return s.ScanNotes(nil) }))
+}
+
+func TestScanRefsMergesOnlyAndNoMerges(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{ // 0
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 10},
+			},
+		},
+		{ // 1
+			NewBranch: "side",
+			Files: []*test.FileInput{
+				{Filename: "file2.dat", Size: 20},
+			},
+		},
+		{ // 2
+			ParentBranches: []string{"master"},
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 30},
+			},
+		},
+		{ // 3: a merge, which changes file1.dat again
+			ParentBranches: []string{"master", "side"},
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 40},
+			},
+		},
+	})
+
+	scanSizes := func(mergesOnly, noMerges bool) []int {
+		var sizes []int
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			assert.Nil(t, err)
+			if p != nil {
+				sizes = append(sizes, int(p.Size))
+			}
+		})
+		gitscanner.MergesOnly = mergesOnly
+		gitscanner.NoMerges = noMerges
+
+		assert.Nil(t, gitscanner.ScanRefs([]string{"master"}, nil, nil))
+		gitscanner.Close()
+
+		sort.Ints(sizes)
+		return sizes
+	}
+
+	assert.Equal(t, []int{10, 20, 30, 40}, scanSizes(false, false))
+	// Only the tree of the merge is walked.
+	assert.Equal(t, []int{20, 40}, scanSizes(true, false))
+	// The merge's version of file1.dat is not in any other commit.
+	assert.Equal(t, []int{10, 20, 30}, scanSizes(false, true))
+
+	gitscanner := NewGitScanner(config.New(), nil)
+	gitscanner.MergesOnly = true
+	gitscanner.NoMerges = true
+	err := gitscanner.ScanRefs([]string{"master"}, nil, func(p *WrappedPointer, err error) {})
+	assert.NotNil(t, err)
+	gitscanner.Close()
+}
+
+func TestScanRefsUsesGitExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the wrapper is a shell script")
+	}
+
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 20},
+			},
+		},
+	})
+
+	realGit, err := exec.LookPath("git")
+	require.Nil(t, err)
+
+	// The wrapper records the arguments and environment of each command
+	// it runs, then runs the real Git.
+	dir, err := ioutil.TempDir("", "lfs-scanner-git")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	argsLog := filepath.Join(dir, "args.log")
+	envLog := filepath.Join(dir, "env.log")
+	wrapper := filepath.Join(dir, "wrapped-git")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$*\" >> %q\nenv >> %q\nexec %q \"$@\"\n", argsLog, envLog, realGit)
+	require.Nil(t, ioutil.WriteFile(wrapper, []byte(script), 0755))
+
+	var pointers []*WrappedPointer
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		assert.Nil(t, err)
+		if p != nil {
+			pointers = append(pointers, p)
+		}
+	})
+	gitscanner.GitExec = &git.ExecOptions{
+		Path: wrapper,
+		Env:  append(git.IsolatedEnv(), "LFS_TEST_SCANNER=1"),
+	}
+
+	assert.Nil(t, gitscanner.ScanRefs([]string{"master"}, nil, nil))
+	gitscanner.Close()
+
+	require.Len(t, pointers, 1)
+	assert.Equal(t, "file1.dat", pointers[0].Name)
+
+	args, err := ioutil.ReadFile(argsLog)
+	require.Nil(t, err)
+	assert.Contains(t, string(args), "rev-list")
+	assert.Contains(t, string(args), "cat-file --batch-check")
+
+	env, err := ioutil.ReadFile(envLog)
+	require.Nil(t, err)
+	vars := strings.Split(strings.TrimSpace(string(env)), "\n")
+	assert.Contains(t, vars, "LFS_TEST_SCANNER=1")
+	assert.Contains(t, vars, "GIT_CONFIG_NOSYSTEM=1")
+	for _, kv := range vars {
+		assert.False(t, strings.HasPrefix(kv, "HOME="), "unexpected %s", kv)
+	}
+}
+
+func TestScanCandidatesTagsInvalidPointers(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "valid.dat", Size: 20},
+			},
+		},
+	})
+
+	files := map[string]string{
+		"bad-oid.dat":  "version https://git-lfs.github.com/spec/v1\noid sha256:not-an-oid\nsize 12\n",
+		"bad-size.dat": fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize twelve\n", strings.Repeat("a", 64)),
+		"plain.txt":    "not a pointer at all\n",
+		"readme.txt":   "this file mentions git-lfs but is not a pointer\n",
+	}
+	for name, contents := range files {
+		require.Nil(t, ioutil.WriteFile(name, []byte(contents), 0644))
+		test.RunGitCommand(t, true, "add", name)
+	}
+	test.RunGitCommand(t, true, "commit", "-m", "add other files")
+
+	gitscanner := NewGitScanner(config.New(), nil)
+	defer gitscanner.Close()
+
+	scanner, err := gitscanner.ScanCandidates([]string{"master"}, nil, ScanRefsMode)
+	require.Nil(t, err)
+
+	results := make(map[string]*ScanResult)
+	for scanner.Scan() {
+		r := scanner.Result()
+		results[r.Name] = r
+	}
+	require.Nil(t, scanner.Err())
+	require.Nil(t, scanner.Close())
+
+	require.Len(t, results, 3)
+
+	valid := results["valid.dat"]
+	require.NotNil(t, valid)
+	assert.True(t, valid.Valid())
+	assert.Nil(t, valid.Err)
+	require.NotNil(t, valid.Pointer)
+	assert.EqualValues(t, 20, valid.Pointer.Size)
+	assert.Len(t, valid.Sha, 40)
+
+	for _, name := range []string{"bad-oid.dat", "bad-size.dat"} {
+		r := results[name]
+		require.NotNil(t, r, name)
+		assert.False(t, r.Valid(), name)
+		assert.NotNil(t, r.Err, name)
+		assert.Nil(t, r.Pointer, name)
+		assert.Len(t, r.Sha, 40)
+	}
+	assert.Contains(t, results["bad-oid.dat"].Err.Error(), "Invalid OID")
+	assert.Contains(t, results["bad-size.dat"].Err.Error(), "invalid size")
+}
+
+func TestScanCandidatesCloseStopsScan(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	inputs := make([]*test.CommitInput, 0, 10)
+	for i := 0; i < 10; i++ {
+		inputs = append(inputs, &test.CommitInput{
+			Files: []*test.FileInput{
+				{Filename: fmt.Sprintf("file%d.dat", i), Size: int64(10 + i)},
+			},
+		})
+	}
+	repo.AddCommits(inputs)
+
+	gitscanner := NewGitScanner(config.New(), nil)
+	defer gitscanner.Close()
+
+	scanner, err := gitscanner.ScanCandidates(nil, nil, ScanAllMode)
+	require.Nil(t, err)
+	require.True(t, scanner.Scan())
+	assert.True(t, scanner.Result().Valid())
+	assert.Nil(t, scanner.Close())
+	assert.False(t, scanner.Scan())
+}
+
+func TestScanReflogOnly(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 20},
+			},
+		},
+		{
+			NewBranch: "deleted",
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 30},
+			},
+		},
+	})
+	test.RunGitCommand(t, true, "checkout", "master")
+	test.RunGitCommand(t, true, "branch", "-D", "deleted")
+
+	var oids []string
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		require.Nil(t, err)
+		oids = append(oids, p.Oid)
+	})
+	defer gitscanner.Close()
+
+	require.Nil(t, gitscanner.ScanReflogOnly(nil))
+	assert.Equal(t, []string{outputs[1].Files[0].Oid}, oids)
+}
+
+func TestScanRefsContinueOnError(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{Files: []*test.FileInput{{Filename: "a.dat", Size: 10}}},
+		{Files: []*test.FileInput{{Filename: "b.dat", Size: 20}}},
+		{Files: []*test.FileInput{{Filename: "c.dat", Size: 30}}},
+	})
+
+	// Remove the middle commit, so that its parent cannot be reached.
+	missing := outputs[1].Sha
+	require.Nil(t, os.Remove(filepath.Join(repo.Path, ".git", "objects", missing[:2], missing[2:])))
+
+	scan := func(continueOnError bool, fn func(*GitScanner) error) ([]string, []string, error) {
+		var oids []string
+		var scanErr error
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			if err != nil {
+				scanErr = err
+				return
+			}
+			oids = append(oids, p.Oid)
+		})
+		defer gitscanner.Close()
+		gitscanner.ContinueOnError = continueOnError
+
+		err := fn(gitscanner)
+		if err == nil {
+			err = scanErr
+		}
+		sort.Strings(oids)
+		return oids, gitscanner.Missing(), err
+	}
+
+	scanRefs := func(s *GitScanner) error { return s.ScanRefs([]string{"master"}, nil, nil) }
+	scanAll := func(s *GitScanner) error { return s.ScanAll(nil) }
+
+	_, _, err := scan(false, scanRefs)
+	assert.NotNil(t, err)
+
+	// Every file is still in the tree of the last commit.
+	expected := []string{
+		outputs[0].Files[0].Oid,
+		outputs[1].Files[0].Oid,
+		outputs[2].Files[0].Oid,
+	}
+	sort.Strings(expected)
+
+	for _, fn := range []func(*GitScanner) error{scanRefs, scanAll} {
+		oids, skipped, err := scan(true, fn)
+		require.Nil(t, err)
+		assert.Equal(t, expected, oids)
+		assert.Equal(t, []string{missing}, skipped)
+	}
+}
+
+func TestScanRefsWithOverlappingRefs(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{Files: []*test.FileInput{{Filename: "a.dat", Size: 10}}},
+		{NewBranch: "topic", Files: []*test.FileInput{{Filename: "b.dat", Size: 20}}},
+	})
+
+	scan := func(strict bool) ([]string, error) {
+		var oids []string
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			if err == nil {
+				oids = append(oids, p.Oid)
+			}
+		})
+		defer gitscanner.Close()
+		gitscanner.StrictRefs = strict
+
+		err := gitscanner.ScanRefs([]string{"master", "topic"}, []string{"master"}, nil)
+		return oids, err
+	}
+
+	// "master" is excluded, so only what "topic" adds to it is found.
+	oids, err := scan(false)
+	require.Nil(t, err)
+	assert.Equal(t, []string{outputs[1].Files[0].Oid}, oids)
+
+	_, err = scan(true)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "both included and excluded: master")
+	}
+}
+
+func TestScanOrphaned(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{ // 0: shared by both histories
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Data: "shared"},
+			},
+		},
+		{ // 1: the old history, which is later force-updated
+			NewBranch: "old",
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Data: "old"},
+				{Filename: "file2.dat", Data: "rebased"},
+			},
+		},
+		{ // 2: restores the contents of file1.dat from commit 0
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Data: "shared"},
+				{Filename: "file3.dat", Data: "other"},
+			},
+		},
+		{ // 3: the new history, which re-adds file2.dat
+			ParentBranches: []string{"master"},
+			NewBranch:      "new",
+			Files: []*test.FileInput{
+				{Filename: "file2.dat", Data: "rebased"},
+			},
+		},
+		{ // 4: another branch, which still refers to file3.dat
+			ParentBranches: []string{"master"},
+			NewBranch:      "other",
+			Files: []*test.FileInput{
+				{Filename: "file3.dat", Data: "other"},
+			},
+		},
+	})
+
+	scan := func(keep ...string) []string {
+		var oids []string
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			require.Nil(t, err)
+			oids = append(oids, p.Oid)
+		})
+		defer gitscanner.Close()
+
+		require.Nil(t, gitscanner.ScanOrphaned("old", keep, nil))
+		sort.Strings(oids)
+		return oids
+	}
+
+	sorted := func(oids ...string) []string {
+		sort.Strings(oids)
+		return oids
+	}
+
+	// file2.dat is referred to by the new history, and the earlier
+	// contents of file1.dat by the history both share.
+	assert.Equal(t, sorted(
+		outputs[1].Files[0].Oid,
+		outputs[2].Files[1].Oid,
+	), scan("new"))
+
+	// file3.dat is still referred to by another branch.
+	assert.Equal(t, []string{outputs[1].Files[0].Oid}, scan("new", "other"))
+
+	// Nothing is orphaned by a fast-forward.
+	assert.Empty(t, scan(outputs[2].Sha))
+}
+
+func TestScanRefsResolvesAmbiguousRefs(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{
+			Tags: []string{"ambiguous"},
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 20},
+			},
+		},
+		{
+			NewBranch: "ambiguous",
+			Files: []*test.FileInput{
+				{Filename: "file1.dat", Size: 30},
+			},
+		},
+	})
+
+	scan := func(strict bool, include, exclude []string) ([]string, error) {
+		var oids []string
+		gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+			require.Nil(t, err)
+			oids = append(oids, p.Oid)
+		})
+		gitscanner.StrictRefs = strict
+		defer gitscanner.Close()
+
+		err := gitscanner.ScanRefs(include, exclude, nil)
+		return oids, err
+	}
+
+	var buf bytes.Buffer
+	warnings.SetOutput(&buf)
+	defer warnings.SetOutput(os.Stderr)
+
+	// Git chooses the tag over the branch, so that is what is scanned.
+	oids, err := scan(false, []string{"ambiguous"}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{outputs[0].Files[0].Oid}, oids)
+	assert.Equal(t, "warning: ref \"ambiguous\" is ambiguous; using \"refs/tags/ambiguous\"\n", buf.String())
+
+	oids, err = scan(false, []string{"refs/heads/ambiguous"}, []string{"ambiguous"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{outputs[1].Files[0].Oid}, oids)
+
+	_, err = scan(true, []string{"ambiguous"}, nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `cannot scan ambiguous ref "ambiguous", which may be any of: refs/tags/ambiguous, refs/heads/ambiguous`, err.Error())
+	}
+}
+
+func TestScanReachability(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	outputs := repo.AddCommits([]*test.CommitInput{
+		{ // 0
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 20},
+			},
+		},
+		{ // 1
+			NewBranch: "branch2",
+			Files: []*test.FileInput{
+				{Filename: "b.dat", Size: 25},
+			},
+		},
+		{ // 2
+			ParentBranches: []string{"master"}, // back on master
+			Files: []*test.FileInput{
+				{Filename: "a.dat", Size: 30},
+			},
+		},
+	})
+	a1 := outputs[0].Files[0].Oid
+	b := outputs[1].Files[0].Oid
+	a2 := outputs[2].Files[0].Oid
+
+	gitscanner := NewGitScanner(config.New(), func(p *WrappedPointer, err error) {
+		assert.Nil(t, err)
+	})
+	defer gitscanner.Close()
+
+	reachable, err := gitscanner.ScanReachability([]string{"refs/heads/master", "refs/heads/branch2"}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, map[string][]string{
+		// Objects replaced in a ref's history are still reachable
+		// from it.
+		a1: {"refs/heads/master", "refs/heads/branch2"},
+		b:  {"refs/heads/branch2"},
+		a2: {"refs/heads/master"},
+	}, reachable)
+
+	reachable, err = gitscanner.ScanReachability([]string{"refs/heads/branch2"}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, map[string][]string{
+		a1: {"refs/heads/branch2"},
+		b:  {"refs/heads/branch2"},
+	}, reachable)
+
+	reachable, err = gitscanner.ScanReachability(nil, nil)
+	require.Nil(t, err)
+	assert.Empty(t, reachable)
+}
@@ -305,3 +305,90 @@ func TestLogScannerDeletionsFilterExclude(t *testing.T) {
 
 	assertScannerDone(t, scanner)
 }
+
+func TestLogScannerQuotedNames(t *testing.T) {
+	log := `lfs-commit-sha: 637908bf28b38ab238e1b5e6a5bfbfb2e513a0df 07d571b413957508679042e45508af5945b3f1e5
+
+diff --git "a/caf\303\251 \"s\".png" "b/caf\303\251 \"s\".png"
+new file mode 100644
+index 0000000..2622b4a
+--- /dev/null
++++ "b/caf\303\251 \"s\".png"
+@@ -0,0 +1,3 @@
++version https://git-lfs.github.com/spec/v1
++oid sha256:f5d84da40ab1f6aa28df2b2bf1ade2cdcd4397133f903c12b4106641b10e1ed6
++size 1289
+diff --git a/with space.png "b/tab\there.png"
+similarity index 100%
+rename from with space.png
+rename to "tab\there.png"
+diff --git a/old name.png b/new name.png
+index 9daa2e5..c648385 100644
+--- a/old name.png
++++ b/new name.png
+@@ -1,3 +1,3 @@
+ version https://git-lfs.github.com/spec/v1
+-oid sha256:334c8a0a520cf9f58189dba5a9a26c7bff2769b4a3cc199650c00618bde5b9dd
+-size 16849
++oid sha256:3301b3da173d231f0f6b1f9bf075e573758cd79b3cfeff7623a953d708d6688b
++size 3152388
+diff --cc "merged \"file\".png"
+index 9daa2e5,9daa2e5..c648385
+--- "a/merged \"file\".png"
++++ "b/merged \"file\".png"
+@@@ -1,3 -1,3 +1,3 @@@
+ version https://git-lfs.github.com/spec/v1
++oid sha256:fe2c2f236b97bba4585d9909a227a8fa64897d9bbe297fa272f714302d86c908
++size 125873
+`
+
+	scanner := newLogScanner(LogDiffAdditions, strings.NewReader(log))
+
+	assertNextScan(t, scanner)
+	if p := scanner.Pointer(); assert.NotNil(t, p) {
+		assert.Equal(t, `café "s".png`, p.Name)
+		assert.Equal(t, "f5d84da40ab1f6aa28df2b2bf1ade2cdcd4397133f903c12b4106641b10e1ed6", p.Oid)
+	}
+
+	assertNextScan(t, scanner)
+	if p := scanner.Pointer(); assert.NotNil(t, p) {
+		assert.Equal(t, "new name.png", p.Name)
+		assert.Equal(t, "3301b3da173d231f0f6b1f9bf075e573758cd79b3cfeff7623a953d708d6688b", p.Oid)
+	}
+
+	assertNextScan(t, scanner)
+	if p := scanner.Pointer(); assert.NotNil(t, p) {
+		assert.Equal(t, `merged "file".png`, p.Name)
+		assert.Equal(t, "fe2c2f236b97bba4585d9909a227a8fa64897d9bbe297fa272f714302d86c908", p.Oid)
+	}
+
+	assertScannerDone(t, scanner)
+}
+
+func TestLogScannerParseFileHeader(t *testing.T) {
+	scanner := newLogScanner(LogDiffAdditions, strings.NewReader(""))
+
+	for line, names := range map[string][2]string{
+		`diff --git a/a.png b/a.png`:                         {"a.png", "a.png"},
+		`diff --git a/with space.png b/with space.png`:       {"with space.png", "with space.png"},
+		`diff --git "a/\"q\".png" "b/\"q\".png"`:             {`"q".png`, `"q".png`},
+		`diff --git "a/caf\303\251.png" b/cafe.png`:          {"café.png", "cafe.png"},
+		`diff --git a/cafe.png "b/caf\303\251.png"`:          {"cafe.png", "café.png"},
+		`diff --git "a/back\\slash.png" "b/back\\slash.png"`: {`back\slash.png`, `back\slash.png`},
+	} {
+		a, b, ok := scanner.parseFileHeader(line)
+		if assert.True(t, ok, line) {
+			assert.Equal(t, names[0], a, line)
+			assert.Equal(t, names[1], b, line)
+		}
+	}
+
+	for _, line := range []string{
+		`diff --cc a.png`,
+		`index 9daa2e5..c648385 100644`,
+		`diff --git "a/unterminated b/a.png`,
+	} {
+		_, _, ok := scanner.parseFileHeader(line)
+		assert.False(t, ok, line)
+	}
+}
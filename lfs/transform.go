@@ -0,0 +1,73 @@
+package lfs
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+// TransformPrefix marks the clean or smudge setting of an extension which names
+// a transform registered with RegisterTransform, rather than a command.
+const TransformPrefix = "builtin:"
+
+// A Transform is run in place of an extension's clean or smudge command,
+// reading the contents of a file from "src" and writing their transformed form
+// to "dst".  It must not close "dst".
+type Transform func(dst io.Writer, src io.Reader) error
+
+var (
+	transforms   = make(map[string]Transform)
+	transformsMu sync.RWMutex
+)
+
+func init() {
+	RegisterTransform("gzip", gzipTransform)
+	RegisterTransform("gunzip", gunzipTransform)
+}
+
+// RegisterTransform makes the Transform "t" available to extensions as
+// "builtin:<name>", replacing any transform already registered as "name".
+func RegisterTransform(name string, t Transform) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+
+	transforms[name] = t
+}
+
+// lookupTransform returns the Transform named by an extension's clean or smudge
+// setting, and whether that setting names one at all.  The Transform is nil if
+// no transform of that name is registered.
+func lookupTransform(setting string) (Transform, string, bool) {
+	if !strings.HasPrefix(setting, TransformPrefix) {
+		return nil, "", false
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(setting, TransformPrefix))
+
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	return transforms[name], name, true
+}
+
+// gzipTransform compresses its input.  No name or modification time is written
+// in the header, so the same input always gives the same output.
+func gzipTransform(dst io.Writer, src io.Reader) error {
+	w := gzip.NewWriter(dst)
+	if _, err := io.Copy(w, src); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// gunzipTransform decompresses the output of gzipTransform.
+func gunzipTransform(dst io.Writer, src io.Reader) error {
+	r, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(dst, r)
+	return err
+}
@@ -0,0 +1,192 @@
+package lfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xorTransform is its own inverse, so serves for both clean and smudge.
+func xorTransform(dst io.Writer, src io.Reader) error {
+	buf, err := ioutil.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	for i := range buf {
+		buf[i] ^= 0x5a
+	}
+	_, err = dst.Write(buf)
+	return err
+}
+
+func transformTestConfig(exts map[string]string) *config.Configuration {
+	git := make(map[string][]string)
+	priority := 0
+	for name, transform := range exts {
+		git["lfs.extension."+name+".clean"] = []string{transform}
+		git["lfs.extension."+name+".smudge"] = []string{transform}
+		git["lfs.extension."+name+".priority"] = []string{strconv.Itoa(priority)}
+		priority++
+	}
+	return config.NewFrom(config.Values{Git: git})
+}
+
+func transformTestOid(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTransformCleanAndSmudge(t *testing.T) {
+	RegisterTransform("test-xor", xorTransform)
+
+	cfg := transformTestConfig(map[string]string{"xor": "builtin:test-xor"})
+	f := NewGitFilter(cfg)
+
+	contents := []byte("the working tree contents")
+	cleaned, err := f.Clean(bytes.NewReader(contents), "file.dat", int64(len(contents)), nil)
+	require.Nil(t, err)
+	defer os.Remove(cleaned.Filename)
+
+	stored, err := ioutil.ReadFile(cleaned.Filename)
+	require.Nil(t, err)
+	assert.NotEqual(t, contents, stored)
+
+	// The OID refers to the stored bytes, and the extension to the
+	// contents of the working tree.
+	assert.Equal(t, transformTestOid(stored), cleaned.Oid)
+	assert.EqualValues(t, len(stored), cleaned.Size)
+	require.Len(t, cleaned.Extensions, 1)
+	assert.Equal(t, "xor", cleaned.Extensions[0].Name)
+	assert.Equal(t, transformTestOid(contents), cleaned.Extensions[0].Oid)
+
+	var out bytes.Buffer
+	_, err = f.readLocalFile(&out, cleaned.Pointer, cleaned.Filename, "file.dat", nil)
+	require.Nil(t, err)
+	assert.Equal(t, contents, out.Bytes())
+}
+
+func TestTransformGzip(t *testing.T) {
+	cfg := config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.extension.gz.clean":    []string{"builtin:gzip"},
+			"lfs.extension.gz.smudge":   []string{"builtin:gunzip"},
+			"lfs.extension.gz.priority": []string{"0"},
+		},
+	})
+	f := NewGitFilter(cfg)
+
+	contents := []byte(strings.Repeat("compressible ", 100))
+	cleaned, err := f.Clean(bytes.NewReader(contents), "file.dat", int64(len(contents)), nil)
+	require.Nil(t, err)
+	defer os.Remove(cleaned.Filename)
+	assert.Less(t, cleaned.Size, int64(len(contents)))
+
+	// Cleaning the same contents again stores the same object.
+	again, err := f.Clean(bytes.NewReader(contents), "file.dat", int64(len(contents)), nil)
+	require.Nil(t, err)
+	defer os.Remove(again.Filename)
+	assert.Equal(t, cleaned.Oid, again.Oid)
+
+	var out bytes.Buffer
+	_, err = f.readLocalFile(&out, cleaned.Pointer, cleaned.Filename, "file.dat", nil)
+	require.Nil(t, err)
+	assert.Equal(t, contents, out.Bytes())
+}
+
+func TestTransformSmudgeChecksStoredOid(t *testing.T) {
+	RegisterTransform("test-xor", xorTransform)
+
+	cfg := transformTestConfig(map[string]string{"xor": "builtin:test-xor"})
+	f := NewGitFilter(cfg)
+
+	contents := []byte("the working tree contents")
+	cleaned, err := f.Clean(bytes.NewReader(contents), "file.dat", int64(len(contents)), nil)
+	require.Nil(t, err)
+	defer os.Remove(cleaned.Filename)
+
+	corrupt := filepath.Join(filepath.Dir(cleaned.Filename), "corrupt")
+	require.Nil(t, ioutil.WriteFile(corrupt, []byte("corrupt"), 0644))
+	defer os.Remove(corrupt)
+
+	var out bytes.Buffer
+	_, err = f.readLocalFile(&out, cleaned.Pointer, corrupt, "file.dat", nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "does not match expected "+cleaned.Oid)
+	assert.Empty(t, out.Bytes())
+}
+
+func TestTransformUnknown(t *testing.T) {
+	cfg := transformTestConfig(map[string]string{"foo": "builtin:no-such-transform"})
+	f := NewGitFilter(cfg)
+
+	_, err := f.Clean(strings.NewReader("contents"), "file.dat", 8, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, "extension 'foo' uses unknown transform 'no-such-transform'", err.Error())
+}
+
+func TestTransformFailure(t *testing.T) {
+	RegisterTransform("test-fail", func(dst io.Writer, src io.Reader) error {
+		return errors.New("boom")
+	})
+	RegisterTransform("test-xor", xorTransform)
+
+	cfg := config.NewFrom(config.Values{
+		Git: map[string][]string{
+			"lfs.extension.xor.clean":     []string{"builtin:test-xor"},
+			"lfs.extension.xor.priority":  []string{"0"},
+			"lfs.extension.fail.clean":    []string{"builtin:test-fail"},
+			"lfs.extension.fail.priority": []string{"1"},
+		},
+	})
+	f := NewGitFilter(cfg)
+
+	_, err := f.Clean(strings.NewReader(strings.Repeat("x", 1<<20)), "file.dat", 1<<20, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, "extension 'fail' failed: boom", err.Error())
+}
+
+func TestTransformSmudgeSharedOidDifferentExtensions(t *testing.T) {
+	RegisterTransform("test-xor", xorTransform)
+
+	cfg := transformTestConfig(map[string]string{"xor": "builtin:test-xor"})
+	f := NewGitFilter(cfg)
+
+	contents := []byte("the working tree contents")
+	cleaned, err := f.Clean(bytes.NewReader(contents), "a.dat", int64(len(contents)), nil)
+	require.Nil(t, err)
+	defer os.Remove(cleaned.Filename)
+
+	stored, err := ioutil.ReadFile(cleaned.Filename)
+	require.Nil(t, err)
+
+	// A second pointer to the same object, but without the extension,
+	// takes the stored bytes as they are.
+	plain := NewPointer(cleaned.Oid, cleaned.Size, nil)
+
+	var a, b bytes.Buffer
+	_, err = f.readLocalFile(&a, cleaned.Pointer, cleaned.Filename, "a.dat", nil)
+	require.Nil(t, err)
+	_, err = f.readLocalFile(&b, plain, cleaned.Filename, "b.dat", nil)
+	require.Nil(t, err)
+
+	assert.Equal(t, contents, a.Bytes())
+	assert.Equal(t, stored, b.Bytes())
+
+	// Smudging the first pointer again is unaffected by the second.
+	a.Reset()
+	_, err = f.readLocalFile(&a, cleaned.Pointer, cleaned.Filename, "a.dat", nil)
+	require.Nil(t, err)
+	assert.Equal(t, contents, a.Bytes())
+}
@@ -244,7 +244,7 @@ func CopyFileContents(cfg *config.Configuration, src string, dst string) error {
 	if err != nil {
 		return err
 	}
-	return os.Rename(tmp.Name(), dst)
+	return cfg.Filesystem().FinalizeObject(tmp.Name(), dst)
 }
 
 func LinkOrCopy(cfg *config.Configuration, src string, dst string) error {
@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 
 	"github.com/git-lfs/git-lfs/v3/creds"
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
@@ -96,7 +96,7 @@ func (c *Client) doWithCreds(req *http.Request, credWrapper creds.CredentialHelp
 
 	req.Header.Set("User-Agent", lfshttp.UserAgent)
 
-	client, err := c.client.HttpClient(req.URL, access.Mode())
+	client, err := c.client.HttpClientFor(req, access.Mode())
 	if err != nil {
 		return nil, err
 	}
@@ -284,7 +284,7 @@ func setRequestAuthFromURL(req *http.Request, u *url.URL) bool {
 	}
 
 	if pass, ok := u.User.Password(); ok {
-		fmt.Fprintln(os.Stderr, tr.Tr.Get("warning: current Git remote contains credentials"))
+		warnings.Warn(warnings.RemoteCredentials, tr.Tr.Get("warning: current Git remote contains credentials"))
 		setRequestAuth(req, u.User.Username(), pass)
 		return true
 	}
@@ -53,3 +53,7 @@ func (c *Client) LogHTTPStats(w io.WriteCloser) {
 func (c *Client) Close() error {
 	return c.client.Close()
 }
+
+func (c *Client) ResetConnections() {
+	c.client.ResetConnections()
+}
@@ -0,0 +1,72 @@
+package lfsapi
+
+import (
+	"net/url"
+	"strings"
+)
+
+// EndpointHostMismatch describes an endpoint which was configured explicitly
+// for a remote, rather than derived from the remote's URL, and which is on a
+// different host from the remote itself.
+type EndpointHostMismatch struct {
+	Remote string
+	// Key is the Git configuration key from which the endpoint's URL was
+	// read.
+	Key          string
+	Url          string
+	EndpointHost string
+	RemoteHost   string
+}
+
+// CheckEndpointHost compares the host of the endpoint for the given operation
+// and remote with the host of the remote's own URL, and returns an
+// *EndpointHostMismatch if they differ, or nil if they do not.
+//
+// Endpoints which were derived from the remote's URL, remotes which are local
+// paths, and endpoints whose host is a subdomain of the remote's host, or vice
+// versa, such as "lfs.example.com" for "example.com", are not reported.
+func CheckEndpointHost(f EndpointFinder, operation, remote string) *EndpointHostMismatch {
+	if len(remote) == 0 {
+		remote = defaultRemote
+	}
+
+	ep, src := f.ResolveEndpoint(operation, remote)
+	if src.Guessed || len(src.Key) == 0 || len(ep.Url) == 0 {
+		return nil
+	}
+
+	remoteURL := f.GitRemoteURL(remote, operation == "upload")
+	if len(remoteURL) == 0 {
+		return nil
+	}
+
+	endpointHost := endpointHostname(ep.Url)
+	remoteHost := endpointHostname(f.NewEndpointFromCloneURL(operation, remoteURL).Url)
+	if len(endpointHost) == 0 || len(remoteHost) == 0 || sameHost(endpointHost, remoteHost) {
+		return nil
+	}
+
+	return &EndpointHostMismatch{
+		Remote:       remote,
+		Key:          src.Key,
+		Url:          ep.Url,
+		EndpointHost: endpointHost,
+		RemoteHost:   remoteHost,
+	}
+}
+
+// endpointHostname returns the lower-cased host name of the endpoint URL
+// "rawurl", or the empty string if it has none, such as for a local path.
+func endpointHostname(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme == "file" {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// sameHost returns whether the host names "a" and "b" are the same, or one is
+// a subdomain of the other.
+func sameHost(a, b string) bool {
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
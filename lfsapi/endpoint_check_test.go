@@ -0,0 +1,88 @@
+package lfsapi
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckEndpointHost(t *testing.T) {
+	for desc, c := range map[string]struct {
+		Config    map[string]string
+		Operation string
+		Remote    string
+		Expected  *EndpointHostMismatch
+	}{
+		"guessed from remote url": {
+			Config:    map[string]string{"remote.origin.url": "https://example.com/foo/bar.git"},
+			Operation: "download",
+		},
+		"lfs url on same host": {
+			Config: map[string]string{
+				"remote.origin.url": "https://example.com/foo/bar.git",
+				"lfs.url":           "https://EXAMPLE.com:8443/lfs/foo/bar",
+			},
+			Operation: "download",
+		},
+		"lfs url on subdomain": {
+			Config: map[string]string{
+				"remote.origin.url": "git@example.com:foo/bar.git",
+				"lfs.url":           "https://lfs.example.com/foo/bar",
+			},
+			Operation: "download",
+		},
+		"lfs url on other host": {
+			Config: map[string]string{
+				"remote.origin.url": "https://example.com/foo/bar.git",
+				"lfs.url":           "https://other.com/foo/bar",
+			},
+			Operation: "download",
+			Expected: &EndpointHostMismatch{
+				Remote:       "origin",
+				Key:          "lfs.url",
+				Url:          "https://other.com/foo/bar",
+				EndpointHost: "other.com",
+				RemoteHost:   "example.com",
+			},
+		},
+		"remote lfs url on other host for ssh remote": {
+			Config: map[string]string{
+				"remote.other.url":    "ssh://git@example.com/foo/bar.git",
+				"remote.other.lfsurl": "https://other.com/foo/bar",
+			},
+			Operation: "download",
+			Remote:    "other",
+			Expected: &EndpointHostMismatch{
+				Remote:       "other",
+				Key:          "remote.other.lfsurl",
+				Url:          "https://other.com/foo/bar",
+				EndpointHost: "other.com",
+				RemoteHost:   "example.com",
+			},
+		},
+		"lfs push url compared with remote push url": {
+			Config: map[string]string{
+				"remote.origin.url":     "https://example.com/foo/bar.git",
+				"remote.origin.pushurl": "https://write.com/foo/bar.git",
+				"lfs.pushurl":           "https://write.com/lfs",
+			},
+			Operation: "upload",
+		},
+		"local remote": {
+			Config: map[string]string{
+				"remote.origin.url": "/path/to/repo",
+				"lfs.url":           "https://other.com/foo/bar",
+			},
+			Operation: "download",
+		},
+		"no remote url": {
+			Config:    map[string]string{"lfs.url": "https://other.com/foo/bar"},
+			Operation: "download",
+		},
+	} {
+		finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, c.Config))
+
+		assert.Equal(t, c.Expected, CheckEndpointHost(finder, c.Operation, c.Remote), desc)
+	}
+}
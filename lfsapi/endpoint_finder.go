@@ -12,6 +12,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/creds"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
@@ -24,6 +25,7 @@ type EndpointFinder interface {
 	NewEndpointFromCloneURL(operation, rawurl string) lfshttp.Endpoint
 	NewEndpoint(operation, rawurl string) lfshttp.Endpoint
 	Endpoint(operation, remote string) lfshttp.Endpoint
+	ResolveEndpoint(operation, remote string) (lfshttp.Endpoint, EndpointSource)
 	RemoteEndpoint(operation, remote string) lfshttp.Endpoint
 	GitRemoteURL(remote string, forpush bool) string
 	AccessFor(rawurl string) creds.Access
@@ -31,6 +33,16 @@ type EndpointFinder interface {
 	GitProtocol() string
 }
 
+// EndpointSource describes where the URL of a resolved endpoint came from.
+type EndpointSource struct {
+	// Key is the Git configuration key from which the URL was read, or
+	// empty if the remote name was itself a URL.
+	Key string
+	// Guessed is true if the endpoint was derived from a Git remote's URL,
+	// rather than configured explicitly as an LFS URL.
+	Guessed bool
+}
+
 type endpointGitFinder struct {
 	gitConfig   *git.Configuration
 	gitEnv      config.Environment
@@ -69,38 +81,50 @@ func NewEndpointFinder(ctx lfshttp.Context) EndpointFinder {
 }
 
 func (e *endpointGitFinder) Endpoint(operation, remote string) lfshttp.Endpoint {
-	ep := e.getEndpoint(operation, remote)
-	ep.Operation = operation
+	ep, _ := e.ResolveEndpoint(operation, remote)
 	return ep
 }
 
-func (e *endpointGitFinder) getEndpoint(operation, remote string) lfshttp.Endpoint {
+// ResolveEndpoint returns the endpoint for the given operation and remote, as
+// Endpoint does, along with a description of how it was resolved.
+func (e *endpointGitFinder) ResolveEndpoint(operation, remote string) (lfshttp.Endpoint, EndpointSource) {
+	ep, src := e.getEndpoint(operation, remote)
+	ep.Operation = operation
+	return ep, src
+}
+
+func (e *endpointGitFinder) getEndpoint(operation, remote string) (lfshttp.Endpoint, EndpointSource) {
 	if e.gitEnv == nil {
-		return lfshttp.Endpoint{}
+		return lfshttp.Endpoint{}, EndpointSource{}
 	}
 
 	if operation == "upload" {
 		if url, ok := e.gitEnv.Get("lfs.pushurl"); ok {
-			return e.NewEndpoint(operation, url)
+			return e.NewEndpoint(operation, url), EndpointSource{Key: "lfs.pushurl"}
 		}
 	}
 
 	if url, ok := e.gitEnv.Get("lfs.url"); ok {
-		return e.NewEndpoint(operation, url)
+		return e.NewEndpoint(operation, url), EndpointSource{Key: "lfs.url"}
 	}
 
 	if len(remote) > 0 && remote != defaultRemote {
-		if e := e.RemoteEndpoint(operation, remote); len(e.Url) > 0 {
-			return e
+		if e, src := e.remoteEndpoint(operation, remote); len(e.Url) > 0 {
+			return e, src
 		}
 	}
 
-	return e.RemoteEndpoint(operation, defaultRemote)
+	return e.remoteEndpoint(operation, defaultRemote)
 }
 
 func (e *endpointGitFinder) RemoteEndpoint(operation, remote string) lfshttp.Endpoint {
+	ep, _ := e.remoteEndpoint(operation, remote)
+	return ep
+}
+
+func (e *endpointGitFinder) remoteEndpoint(operation, remote string) (lfshttp.Endpoint, EndpointSource) {
 	if e.gitEnv == nil {
-		return lfshttp.Endpoint{}
+		return lfshttp.Endpoint{}, EndpointSource{}
 	}
 
 	if len(remote) == 0 {
@@ -109,44 +133,67 @@ func (e *endpointGitFinder) RemoteEndpoint(operation, remote string) lfshttp.End
 
 	// Support separate push URL if specified and pushing
 	if operation == "upload" {
-		if url, ok := e.gitEnv.Get("remote." + remote + ".lfspushurl"); ok {
-			return e.NewEndpoint(operation, url)
+		key := "remote." + remote + ".lfspushurl"
+		if url, ok := e.gitEnv.Get(key); ok {
+			return e.NewEndpoint(operation, url), EndpointSource{Key: key}
 		}
 	}
-	if url, ok := e.gitEnv.Get("remote." + remote + ".lfsurl"); ok {
-		return e.NewEndpoint(operation, url)
+	key := "remote." + remote + ".lfsurl"
+	if url, ok := e.gitEnv.Get(key); ok {
+		return e.NewEndpoint(operation, url), EndpointSource{Key: key}
 	}
 
-	// finally fall back on git remote url (also supports pushurl)
-	if url := e.GitRemoteURL(remote, operation == "upload"); url != "" {
-		return e.NewEndpointFromCloneURL(operation, url)
+	// finally fall back on git remote url (also supports pushurl), which
+	// gitRemoteURL has already rewritten
+	if url, key := e.gitRemoteURL(remote, operation == "upload"); url != "" {
+		return e.endpointFromCloneURL(url), EndpointSource{Key: key, Guessed: true}
 	}
 
-	return lfshttp.Endpoint{}
+	return lfshttp.Endpoint{}, EndpointSource{}
 }
 
 func (e *endpointGitFinder) GitRemoteURL(remote string, forpush bool) string {
+	url, _ := e.gitRemoteURL(remote, forpush)
+	return url
+}
+
+// gitRemoteURL returns the URL of the given remote, along with the Git
+// configuration key it was read from, if any.  The URL is rewritten by the
+// `url.*.insteadOf` and `url.*.pushInsteadOf` settings as Git rewrites it: an
+// explicit `remote.*.pushurl` is only rewritten by `insteadOf`, while a URL
+// used for pushing which was not configured as a push URL is rewritten by a
+// matching `pushInsteadOf` in preference to `insteadOf`.
+func (e *endpointGitFinder) gitRemoteURL(remote string, forpush bool) (string, string) {
 	if e.gitEnv != nil {
 		if forpush {
-			if u, ok := e.gitEnv.Get("remote." + remote + ".pushurl"); ok {
-				return u
+			key := "remote." + remote + ".pushurl"
+			if u, ok := e.gitEnv.Get(key); ok {
+				return e.rewriteURL(u, false), key
 			}
 		}
 
-		if u, ok := e.gitEnv.Get("remote." + remote + ".url"); ok {
-			return u
+		key := "remote." + remote + ".url"
+		if u, ok := e.gitEnv.Get(key); ok {
+			return e.rewriteURL(u, forpush), key
 		}
 	}
 
 	if err := git.ValidateRemote(remote); err == nil {
-		return remote
+		return e.rewriteURL(remote, forpush), ""
 	}
 
-	return ""
+	return "", ""
 }
 
 func (e *endpointGitFinder) NewEndpointFromCloneURL(operation, rawurl string) lfshttp.Endpoint {
-	ep := e.NewEndpoint(operation, rawurl)
+	return e.endpointFromCloneURL(e.ReplaceUrlAlias(operation, rawurl))
+}
+
+// endpointFromCloneURL returns the endpoint for the given clone URL, as
+// NewEndpointFromCloneURL does, for a URL which has already been rewritten by
+// any `url.*.insteadOf` settings.
+func (e *endpointGitFinder) endpointFromCloneURL(rawurl string) lfshttp.Endpoint {
+	ep := e.endpointFromURL(rawurl)
 	if ep.Url == lfshttp.UrlUnknown {
 		return ep
 	}
@@ -170,7 +217,13 @@ func (e *endpointGitFinder) NewEndpointFromCloneURL(operation, rawurl string) lf
 }
 
 func (e *endpointGitFinder) NewEndpoint(operation, rawurl string) lfshttp.Endpoint {
-	rawurl = e.ReplaceUrlAlias(operation, rawurl)
+	return e.endpointFromURL(e.ReplaceUrlAlias(operation, rawurl))
+}
+
+// endpointFromURL returns the endpoint for the given URL, as NewEndpoint does,
+// for a URL which has already been rewritten by any `url.*.insteadOf`
+// settings.
+func (e *endpointGitFinder) endpointFromURL(rawurl string) lfshttp.Endpoint {
 	if strings.HasPrefix(rawurl, "/") {
 		return lfshttp.EndpointFromLocalPath(rawurl)
 	}
@@ -281,10 +334,17 @@ func (e *endpointGitFinder) GitProtocol() string {
 // config setting. If multiple aliases match, use the longest one.
 // See https://git-scm.com/docs/git-config for Git's docs.
 func (e *endpointGitFinder) ReplaceUrlAlias(operation, rawurl string) string {
+	return e.rewriteURL(rawurl, operation == "upload")
+}
+
+// rewriteURL returns the given URL rewritten by the longest matching
+// `url.*.insteadof` alias, or, if "push" is true, by the longest matching
+// `url.*.pushinsteadof` alias in preference to any `insteadof` alias.
+func (e *endpointGitFinder) rewriteURL(rawurl string, push bool) string {
 	e.aliasMu.Lock()
 	defer e.aliasMu.Unlock()
 
-	if operation == "upload" {
+	if push {
 		if rawurl, replaced := e.replaceUrlAlias(e.pushAliases, rawurl); replaced {
 			return rawurl
 		}
@@ -294,7 +354,7 @@ func (e *endpointGitFinder) ReplaceUrlAlias(operation, rawurl string) string {
 	return rawurl
 }
 
-// replaceUrlAlias is a helper function for ReplaceUrlAlias.  It must only be
+// replaceUrlAlias is a helper function for rewriteURL.  It must only be
 // called while the e.aliasMu mutex is held.
 func (e *endpointGitFinder) replaceUrlAlias(aliases map[string]string, rawurl string) (string, bool) {
 	var longestalias string
@@ -338,7 +398,7 @@ func storeAlias(aliases map[string]string, key string, values []string, suffix s
 	for _, value := range values {
 		url := key[len(aliasPrefix) : len(key)-len(suffix)]
 		if v, ok := aliases[value]; ok && v != url {
-			fmt.Fprintln(os.Stderr, tr.Tr.Get("warning: Multiple 'url.*.%s' keys with the same alias: %q", suffix, value))
+			warnings.Warn(warnings.DuplicateURLAlias, tr.Tr.Get("warning: Multiple 'url.*.%s' keys with the same alias: %q", suffix, value))
 		}
 		aliases[value] = url
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/creds"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/ssh"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -134,6 +135,101 @@ func TestEndpointGlobalSeparateLfsPush(t *testing.T) {
 	assert.Equal(t, "", e.SSHMetadata.Path)
 }
 
+func TestResolveEndpointSource(t *testing.T) {
+	for desc, c := range map[string]struct {
+		Config    map[string]string
+		Operation string
+		Remote    string
+		Url       string
+		SSH       string
+		Source    EndpointSource
+	}{
+		"https remote url": {
+			Config:    map[string]string{"remote.origin.url": "https://example.com/foo/bar"},
+			Operation: "download",
+			Url:       "https://example.com/foo/bar.git/info/lfs",
+			Source:    EndpointSource{Key: "remote.origin.url", Guessed: true},
+		},
+		"ssh remote url": {
+			Config:    map[string]string{"remote.origin.url": "ssh://git@example.com/foo/bar.git"},
+			Operation: "download",
+			Url:       "https://example.com/foo/bar.git/info/lfs",
+			SSH:       "git@example.com",
+			Source:    EndpointSource{Key: "remote.origin.url", Guessed: true},
+		},
+		"scp-like remote url": {
+			Config:    map[string]string{"remote.origin.url": "git@example.com:foo/bar.git"},
+			Operation: "download",
+			Url:       "https://example.com/foo/bar.git/info/lfs",
+			SSH:       "git@example.com",
+			Source:    EndpointSource{Key: "remote.origin.url", Guessed: true},
+		},
+		"push url": {
+			Config: map[string]string{
+				"remote.origin.url":     "https://example.com/foo/bar.git",
+				"remote.origin.pushurl": "https://readwrite.com/foo/bar.git",
+			},
+			Operation: "upload",
+			Url:       "https://readwrite.com/foo/bar.git/info/lfs",
+			Source:    EndpointSource{Key: "remote.origin.pushurl", Guessed: true},
+		},
+		"remote lfs url": {
+			Config: map[string]string{
+				"remote.other.url":    "https://example.com/foo/bar.git",
+				"remote.other.lfsurl": "https://lfs.example.com/foo/bar",
+			},
+			Operation: "download",
+			Remote:    "other",
+			Url:       "https://lfs.example.com/foo/bar",
+			Source:    EndpointSource{Key: "remote.other.lfsurl"},
+		},
+		"remote lfs push url": {
+			Config: map[string]string{
+				"remote.origin.lfsurl":     "https://lfs.example.com/foo/bar",
+				"remote.origin.lfspushurl": "https://write.example.com/foo/bar",
+			},
+			Operation: "upload",
+			Url:       "https://write.example.com/foo/bar",
+			Source:    EndpointSource{Key: "remote.origin.lfspushurl"},
+		},
+		"global lfs url": {
+			Config: map[string]string{
+				"lfs.url":              "https://lfs.example.com/foo/bar",
+				"remote.origin.lfsurl": "https://other.example.com/foo/bar",
+			},
+			Operation: "download",
+			Url:       "https://lfs.example.com/foo/bar",
+			Source:    EndpointSource{Key: "lfs.url"},
+		},
+		"global lfs push url": {
+			Config: map[string]string{
+				"lfs.url":     "https://lfs.example.com/foo/bar",
+				"lfs.pushurl": "https://write.example.com/foo/bar",
+			},
+			Operation: "upload",
+			Url:       "https://write.example.com/foo/bar",
+			Source:    EndpointSource{Key: "lfs.pushurl"},
+		},
+		"remote given as url": {
+			Config:    map[string]string{},
+			Operation: "download",
+			Remote:    "https://example.com/foo/bar.git",
+			Url:       "https://example.com/foo/bar.git/info/lfs",
+			Source:    EndpointSource{Guessed: true},
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, c.Config))
+
+			e, src := finder.ResolveEndpoint(c.Operation, c.Remote)
+			assert.Equal(t, c.Url, e.Url)
+			assert.Equal(t, c.SSH, e.SSHMetadata.UserAndHost)
+			assert.Equal(t, c.Operation, e.Operation)
+			assert.Equal(t, c.Source, src)
+		})
+	}
+}
+
 func TestSSHEndpointOverridden(t *testing.T) {
 	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
 		"remote.origin.url":    "git@example.com:foo/bar",
@@ -709,6 +805,52 @@ func TestInsteadOf(t *testing.T) {
 	}
 }
 
+func TestInsteadOfPushURL(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                           "ex:git-lfs/git-lfs.git",
+		"remote.explicit.url":                         "ex:git-lfs/git-lfs.git",
+		"remote.explicit.pushurl":                     "ex:git-lfs/push.git",
+		"url.https://example.com/.insteadof":          "ex:",
+		"url.https://push.example.com/.pushinsteadof": "ex:",
+	}))
+
+	// A URL used for pushing is rewritten by pushInsteadOf...
+	assert.Equal(t, "https://example.com/git-lfs/git-lfs.git", finder.GitRemoteURL("origin", false))
+	assert.Equal(t, "https://push.example.com/git-lfs/git-lfs.git", finder.GitRemoteURL("origin", true))
+	assert.Equal(t, "https://push.example.com/git-lfs/git-lfs.git/info/lfs", finder.Endpoint("upload", "origin").Url)
+
+	// ...but, as with Git, an explicit push URL is only rewritten by
+	// insteadOf.
+	assert.Equal(t, "https://example.com/git-lfs/push.git", finder.GitRemoteURL("explicit", true))
+	ep, src := finder.ResolveEndpoint("upload", "explicit")
+	assert.Equal(t, "https://example.com/git-lfs/push.git/info/lfs", ep.Url)
+	assert.Equal(t, EndpointSource{Key: "remote.explicit.pushurl", Guessed: true}, src)
+
+	ep, src = finder.ResolveEndpoint("download", "explicit")
+	assert.Equal(t, "https://example.com/git-lfs/git-lfs.git/info/lfs", ep.Url)
+	assert.Equal(t, EndpointSource{Key: "remote.explicit.url", Guessed: true}, src)
+}
+
+func TestInsteadOfAppliedOnce(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                         "https://example.com/repo.git",
+		"url.https://example.com/mirror/.insteadof": "https://example.com/",
+	}))
+
+	// Git rewrites a URL once, even though the result matches the alias
+	// again.
+	assert.Equal(t, "https://example.com/mirror/repo.git", finder.GitRemoteURL("origin", false))
+	assert.Equal(t, "https://example.com/mirror/repo.git/info/lfs", finder.Endpoint("download", "origin").Url)
+}
+
+func TestInsteadOfRemoteGivenAsURL(t *testing.T) {
+	finder := NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"url.https://example.com/.insteadof": "https://old.example.com/",
+	}))
+
+	assert.Equal(t, "https://example.com/git-lfs/git-lfs.git", finder.GitRemoteURL("https://old.example.com/git-lfs/git-lfs.git", false))
+}
+
 func TestNewEndpointFromCloneURLWithConfig(t *testing.T) {
 	expected := "https://foo/bar.git/info/lfs"
 	tests := []string{
@@ -726,3 +868,23 @@ func TestNewEndpointFromCloneURLWithConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestInsteadOfDuplicateAliasWarning(t *testing.T) {
+	warnings.SetOutput(ioutil.Discard)
+	warnings.Reset()
+	defer func() {
+		warnings.SetOutput(os.Stderr)
+		warnings.Reset()
+	}()
+
+	NewEndpointFinder(lfshttp.NewContext(nil, nil, map[string]string{
+		"url.https://a.example.com/.insteadof": "ex:",
+		"url.https://b.example.com/.insteadof": "ex:",
+	}))
+
+	all := warnings.All()
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, warnings.DuplicateURLAlias, all[0].Code)
+		assert.Contains(t, all[0].Message, `"ex:"`)
+	}
+}
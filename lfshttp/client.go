@@ -34,6 +34,36 @@ var (
 	httpRE    = regexp.MustCompile(`\Ahttps?://`)
 )
 
+// RedirectAuthPolicy describes when the Authorization header of a request is
+// sent along with it to the target of a redirect.
+type RedirectAuthPolicy string
+
+const (
+	// RedirectAuthSameHost sends the Authorization header only to
+	// redirect targets on the same host as the original request.  This
+	// is the default.
+	RedirectAuthSameHost RedirectAuthPolicy = "samehost"
+	// RedirectAuthAlways sends the Authorization header to every redirect
+	// target, regardless of host.
+	RedirectAuthAlways RedirectAuthPolicy = "always"
+	// RedirectAuthNever never sends the Authorization header to a
+	// redirect target.
+	RedirectAuthNever RedirectAuthPolicy = "never"
+)
+
+// parseRedirectAuthPolicy returns the RedirectAuthPolicy named by "value",
+// falling back to RedirectAuthSameHost if it is empty or unknown.
+func parseRedirectAuthPolicy(value string) RedirectAuthPolicy {
+	switch policy := RedirectAuthPolicy(strings.ToLower(value)); policy {
+	case RedirectAuthSameHost, RedirectAuthAlways, RedirectAuthNever:
+		return policy
+	case "":
+	default:
+		tracerx.Printf("api: unknown lfs.transfer.redirectauthorization value %q, using %q", value, RedirectAuthSameHost)
+	}
+	return RedirectAuthSameHost
+}
+
 type hostData struct {
 	host string
 	mode creds.AccessMode
@@ -52,11 +82,29 @@ type Client struct {
 	DebuggingVerbose bool
 	VerboseOut       io.Writer
 
+	// FollowRedirects is whether 3xx responses to API and object
+	// requests are followed, rather than returned as errors.
+	FollowRedirects bool
+	// RedirectAuthorization is when the Authorization header is kept
+	// for the target of a redirect.
+	RedirectAuthorization RedirectAuthPolicy
+
 	hostClients map[hostData]*http.Client
 	clientMu    sync.Mutex
 
 	httpLogger *syncLogger
 
+	// sessionRecorder and sessionReplayer record or replay the requests
+	// made by every per-host client, if GIT_LFS_RECORD_HTTP or
+	// GIT_LFS_REPLAY_HTTP is set.
+	sessionRecorder *sessionRecorder
+	sessionReplayer *sessionReplayer
+
+	// skewMu guards clockSkew and skewWarned.
+	skewMu     sync.Mutex
+	clockSkew  time.Duration
+	skewWarned bool
+
 	gitEnv config.Environment
 	osEnv  config.Environment
 	uc     *config.URLConfig
@@ -74,14 +122,7 @@ func NewClient(ctx Context) (*Client, error) {
 	gitEnv := ctx.GitEnv()
 	osEnv := ctx.OSEnv()
 
-	cacheCreds := gitEnv.Bool("lfs.cachecredentials", true)
-	var sshResolver SSHResolver = &sshAuthClient{os: osEnv, git: gitEnv}
-	if cacheCreds {
-		sshResolver = withSSHCache(sshResolver)
-	}
-
 	c := &Client{
-		SSH:                 sshResolver,
 		DialTimeout:         gitEnv.Int("lfs.dialtimeout", 0),
 		KeepaliveTimeout:    gitEnv.Int("lfs.keepalive", 0),
 		TLSTimeout:          gitEnv.Int("lfs.tlstimeout", 0),
@@ -89,6 +130,7 @@ func NewClient(ctx Context) (*Client, error) {
 		SkipSSLVerify:       !gitEnv.Bool("http.sslverify", true) || osEnv.Bool("GIT_SSL_NO_VERIFY", false),
 		Verbose:             osEnv.Bool("GIT_CURL_VERBOSE", false),
 		DebuggingVerbose:    osEnv.Bool("LFS_DEBUG_HTTP", false),
+		FollowRedirects:     gitEnv.Bool("lfs.transfer.followredirects", true),
 		gitEnv:              gitEnv,
 		osEnv:               osEnv,
 		uc:                  config.NewURLConfig(gitEnv),
@@ -96,6 +138,18 @@ func NewClient(ctx Context) (*Client, error) {
 		credHelperContext:   creds.NewCredentialHelperContext(gitEnv, osEnv),
 	}
 
+	c.SSH = &sshAuthClient{os: osEnv, git: gitEnv}
+	if gitEnv.Bool("lfs.cachecredentials", true) {
+		cache := withSSHCache(c.SSH).(*sshCache)
+		// The expiry times given by git-lfs-authenticate are
+		// according to the server's clock.
+		cache.clockSkew = c.ClockSkew
+		c.SSH = cache
+	}
+
+	redirectAuth, _ := gitEnv.Get("lfs.transfer.redirectauthorization")
+	c.RedirectAuthorization = parseRedirectAuthPolicy(redirectAuth)
+
 	return c, nil
 }
 
@@ -186,7 +240,7 @@ func (c *Client) DoWithAccess(req *http.Request, mode creds.AccessMode) (*http.R
 func (c *Client) do(req *http.Request, remote string, via []*http.Request, mode creds.AccessMode) (*http.Response, error) {
 	req.Header.Set("User-Agent", UserAgent)
 
-	client, err := c.HttpClient(req.URL, mode)
+	client, err := c.HttpClientFor(req, mode)
 	if err != nil {
 		return nil, err
 	}
@@ -201,6 +255,9 @@ func (c *Client) do(req *http.Request, remote string, via []*http.Request, mode
 
 // Close closes any resources that this client opened.
 func (c *Client) Close() error {
+	if c.sessionRecorder != nil {
+		c.sessionRecorder.Close()
+	}
 	return c.httpLogger.Close()
 }
 
@@ -214,6 +271,10 @@ func (c *Client) sshResolveWithRetries(e Endpoint, method string) (*sshAuthRespo
 		if err == nil {
 			return &sshRes, nil
 		}
+		if isSSHAuthTimeout(err) {
+			// Timed out calls have already been retried.
+			break
+		}
 
 		tracerx.Printf(
 			"ssh: %s failed, error: %s, message: %s (try: %d/%d)",
@@ -319,6 +380,7 @@ func (c *Client) DoWithRedirect(cli *http.Client, req *http.Request, remote stri
 	}
 
 	c.traceResponse(req, tracedReq, res)
+	c.observeClockSkew(req, res)
 
 	if res.StatusCode != 301 &&
 		res.StatusCode != 302 &&
@@ -340,12 +402,18 @@ func (c *Client) DoWithRedirect(cli *http.Client, req *http.Request, remote stri
 		redirectTo = locurl.String()
 	}
 
+	if !c.FollowRedirects {
+		return nil, res, errors.New(tr.Tr.Get("not following redirect from %s to %s: lfs.transfer.followredirects is disabled",
+			strings.SplitN(req.URL.String(), "?", 2)[0],
+			strings.SplitN(redirectTo, "?", 2)[0]))
+	}
+
 	via = append(via, req)
 	if len(via) >= 3 {
 		return nil, res, errors.New(tr.Tr.Get("too many redirects"))
 	}
 
-	redirectedReq, err := newRequestForRetry(req, redirectTo)
+	redirectedReq, err := newRequestForRetry(req, redirectTo, via[0].URL, c.RedirectAuthorization)
 	if err != nil {
 		return nil, res, err
 	}
@@ -487,6 +555,15 @@ func (c *Client) Transport(u *url.URL, access creds.AccessMode) (http.RoundTripp
 	return tr, nil
 }
 
+// HttpClientFor returns the *http.Client with which "req" is made, which runs
+// curl if WithHTTPStack says so, and is otherwise the same as HttpClient.
+func (c *Client) HttpClientFor(req *http.Request, access creds.AccessMode) (*http.Client, error) {
+	if HTTPStackFor(req) == HTTPStackCurl {
+		return c.curlHttpClient(access), nil
+	}
+	return c.HttpClient(req.URL, access)
+}
+
 func (c *Client) HttpClient(u *url.URL, access creds.AccessMode) (*http.Client, error) {
 	c.clientMu.Lock()
 	defer c.clientMu.Unlock()
@@ -507,6 +584,10 @@ func (c *Client) HttpClient(u *url.URL, access creds.AccessMode) (*http.Client,
 	if err != nil {
 		return nil, err
 	}
+	tr, err = c.sessionTransport(tr)
+	if err != nil {
+		return nil, err
+	}
 
 	httpClient := &http.Client{
 		Transport: tr,
@@ -532,13 +613,30 @@ func (c *Client) HttpClient(u *url.URL, access creds.AccessMode) (*http.Client,
 	return httpClient, nil
 }
 
+// ResetConnections closes any idle connections held by the per-host HTTP
+// clients and discards those clients, so that subsequent requests dial (and
+// resolve) their hosts again.
+func (c *Client) ResetConnections() {
+	c.clientMu.Lock()
+	defer c.clientMu.Unlock()
+
+	for _, client := range c.hostClients {
+		client.CloseIdleConnections()
+	}
+	c.hostClients = nil
+}
+
 func (c *Client) CurrentUser() (string, string) {
 	userName, _ := c.gitEnv.Get("user.name")
 	userEmail, _ := c.gitEnv.Get("user.email")
 	return userName, userEmail
 }
 
-func newRequestForRetry(req *http.Request, location string) (*http.Request, error) {
+// newRequestForRetry returns a copy of "req" to be sent to "location" in
+// response to a redirect.  The Authorization header is copied according to
+// "policy", where "same host" means the same host as "original", the URL of
+// the first request in the chain of redirects.
+func newRequestForRetry(req *http.Request, location string, original *url.URL, policy RedirectAuthPolicy) (*http.Request, error) {
 	newReq, err := http.NewRequest(req.Method, location, nil)
 	if err != nil {
 		return nil, err
@@ -548,10 +646,24 @@ func newRequestForRetry(req *http.Request, location string) (*http.Request, erro
 		return nil, errors.New(tr.Tr.Get("refusing insecure redirect: HTTPS to HTTP"))
 	}
 
-	sameHost := req.URL.Host == newReq.URL.Host
+	if original == nil {
+		original = req.URL
+	}
+
+	var keepAuth bool
+	switch policy {
+	case RedirectAuthAlways:
+		keepAuth = true
+	case RedirectAuthNever:
+		keepAuth = false
+	default:
+		keepAuth = original.Host == newReq.URL.Host
+	}
+
 	for key := range req.Header {
 		if key == "Authorization" {
-			if !sameHost {
+			if !keepAuth {
+				tracerx.Printf("api: not sending Authorization header to redirect target %s", newReq.URL.Host)
 				continue
 			}
 		}
@@ -176,6 +176,94 @@ func TestClientRedirect(t *testing.T) {
 	assert.EqualError(t, err, "refusing insecure redirect: HTTPS to HTTP")
 }
 
+func TestClientRedirectAuthorizationPolicy(t *testing.T) {
+	var auths []string
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, "srv2:"+r.Header.Get("Authorization"))
+		w.WriteHeader(200)
+	}))
+	defer srv2.Close()
+
+	srv1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, "srv1:"+r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/local":
+			w.Header().Set("Location", "/ok")
+			w.WriteHeader(302)
+		case "/external":
+			w.Header().Set("Location", srv2.URL+"/ok")
+			w.WriteHeader(302)
+		default:
+			w.WriteHeader(200)
+		}
+	}))
+	defer srv1.Close()
+
+	for desc, c := range map[string]struct {
+		Policy   string
+		Path     string
+		Expected []string
+	}{
+		"same host, default":   {"", "/local", []string{"srv1:auth", "srv1:auth"}},
+		"cross host, default":  {"", "/external", []string{"srv1:auth", "srv2:"}},
+		"same host, samehost":  {"samehost", "/local", []string{"srv1:auth", "srv1:auth"}},
+		"cross host, samehost": {"samehost", "/external", []string{"srv1:auth", "srv2:"}},
+		"same host, always":    {"always", "/local", []string{"srv1:auth", "srv1:auth"}},
+		"cross host, always":   {"always", "/external", []string{"srv1:auth", "srv2:auth"}},
+		"same host, never":     {"never", "/local", []string{"srv1:auth", "srv1:"}},
+		"cross host, never":    {"never", "/external", []string{"srv1:auth", "srv2:"}},
+		"cross host, unknown":  {"bogus", "/external", []string{"srv1:auth", "srv2:"}},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			auths = nil
+
+			cli, err := NewClient(NewContext(nil, nil, map[string]string{
+				"lfs.transfer.redirectauthorization": c.Policy,
+			}))
+			require.Nil(t, err)
+
+			req, err := http.NewRequest("GET", srv1.URL+c.Path, nil)
+			require.Nil(t, err)
+			req.Header.Set("Authorization", "auth")
+
+			res, err := cli.Do(req)
+			require.Nil(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, c.Expected, auths)
+		})
+	}
+}
+
+func TestClientRedirectNotFollowed(t *testing.T) {
+	var called uint32
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+		w.WriteHeader(200)
+	}))
+	defer srv2.Close()
+
+	srv1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", srv2.URL+"/ok?token=secret")
+		w.WriteHeader(302)
+	}))
+	defer srv1.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.transfer.followredirects": "false",
+	}))
+	require.Nil(t, err)
+	assert.False(t, c.FollowRedirects)
+
+	req, err := http.NewRequest("GET", srv1.URL+"/redirect", nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.NotNil(t, err)
+	assert.Equal(t, fmt.Sprintf("not following redirect from %s/redirect to %s/ok: lfs.transfer.followredirects is disabled", srv1.URL, srv2.URL), err.Error())
+	assert.Equal(t, 302, res.StatusCode)
+	assert.EqualValues(t, 0, atomic.LoadUint32(&called))
+}
+
 func TestNewClient(t *testing.T) {
 	c, err := NewClient(NewContext(nil, nil, map[string]string{
 		"lfs.dialtimeout":         "151",
@@ -409,3 +497,14 @@ func TestHttpVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestResetConnectionsDiscardsHostClients(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, nil))
+	require.Nil(t, err)
+
+	client := clientForHost(c, "git-lfs.local")
+	assert.True(t, client == clientForHost(c, "git-lfs.local"))
+
+	c.ResetConnections()
+	assert.False(t, client == clientForHost(c, "git-lfs.local"))
+}
@@ -0,0 +1,86 @@
+package lfshttp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+const (
+	// clockSkewTolerance is the difference between a server's clock and
+	// the local one below which they are taken to agree, since the Date
+	// header only has a resolution of one second and a response takes
+	// time to arrive.
+	clockSkewTolerance = 5 * time.Second
+
+	// clockSkewWarningThreshold is the difference between a server's
+	// clock and the local one above which a warning is given.
+	clockSkewWarningThreshold = time.Minute
+)
+
+// ResponseClockSkew returns how far the clock of the server which sent "res"
+// is ahead of the local clock, judging by the Date header of the response and
+// the local time "now" at which it arrived.  A negative skew means that the
+// server's clock is behind.  Zero is returned if the response has no valid
+// Date header, or if the clocks agree to within a few seconds.
+func ResponseClockSkew(res *http.Response, now time.Time) time.Duration {
+	if res == nil {
+		return 0
+	}
+
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+
+	// The Date header is truncated to the second, so the server's clock
+	// was half a second past it on average.
+	skew := date.Add(500 * time.Millisecond).Sub(now)
+	if skew > -clockSkewTolerance && skew < clockSkewTolerance {
+		return 0
+	}
+	return skew.Round(time.Second)
+}
+
+// ClockSkew returns how far the clock of the server which most recently sent
+// a response with a Date header is ahead of the local clock, as given by
+// ResponseClockSkew, so that absolute expiry times it gives can be compared
+// with the local time.
+func (c *Client) ClockSkew() time.Duration {
+	c.skewMu.Lock()
+	defer c.skewMu.Unlock()
+
+	return c.clockSkew
+}
+
+// observeClockSkew records the clock skew of the server which sent "res",
+// and warns the first time a significant skew is found.
+func (c *Client) observeClockSkew(req *http.Request, res *http.Response) {
+	if res == nil || len(res.Header.Get("Date")) == 0 {
+		return
+	}
+
+	skew := ResponseClockSkew(res, time.Now())
+
+	c.skewMu.Lock()
+	defer c.skewMu.Unlock()
+
+	if skew != c.clockSkew {
+		tracerx.Printf("http: clock skew of %s observed from %s", skew, req.URL.Host)
+	}
+	c.clockSkew = skew
+
+	if c.skewWarned || (skew > -clockSkewWarningThreshold && skew < clockSkewWarningThreshold) {
+		return
+	}
+	c.skewWarned = true
+
+	if skew > 0 {
+		warnings.Warn(warnings.ClockSkew, tr.Tr.Get("warning: the local clock is %s behind that of %s; adjusting the expiry times it gives to match", skew, req.URL.Host))
+	} else {
+		warnings.Warn(warnings.ClockSkew, tr.Tr.Get("warning: the local clock is %s ahead of that of %s; adjusting the expiry times it gives to match", -skew, req.URL.Host))
+	}
+}
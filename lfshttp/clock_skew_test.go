@@ -0,0 +1,110 @@
+package lfshttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	sshp "github.com/git-lfs/git-lfs/v3/ssh"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responseWithDate(date string) *http.Response {
+	res := &http.Response{Header: make(http.Header)}
+	if len(date) > 0 {
+		res.Header.Set("Date", date)
+	}
+	return res
+}
+
+func TestResponseClockSkew(t *testing.T) {
+	// The Date header is truncated to the second.
+	now := time.Date(2026, time.October, 14, 12, 0, 0, 400*int(time.Millisecond), time.UTC)
+
+	assert.Equal(t, time.Duration(0), ResponseClockSkew(nil, now))
+	assert.Equal(t, time.Duration(0), ResponseClockSkew(responseWithDate(""), now))
+	assert.Equal(t, time.Duration(0), ResponseClockSkew(responseWithDate("not a date"), now))
+
+	// Small differences are within the tolerance.
+	assert.Equal(t, time.Duration(0), ResponseClockSkew(responseWithDate(now.Add(3*time.Second).Format(http.TimeFormat)), now))
+	assert.Equal(t, time.Duration(0), ResponseClockSkew(responseWithDate(now.Add(-3*time.Second).Format(http.TimeFormat)), now))
+
+	assert.Equal(t, time.Hour, ResponseClockSkew(responseWithDate(now.Add(time.Hour).Format(http.TimeFormat)), now))
+	assert.Equal(t, -10*time.Minute, ResponseClockSkew(responseWithDate(now.Add(-10*time.Minute).Format(http.TimeFormat)), now))
+}
+
+func TestClientObservesClockSkew(t *testing.T) {
+	warnings.SetOutput(nil)
+	defer warnings.SetOutput(os.Stderr)
+	warnings.Reset()
+	defer warnings.Reset()
+
+	skew := -2 * time.Hour
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+	assert.Equal(t, time.Duration(0), c.ClockSkew())
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		require.Nil(t, err)
+		res, err := c.Do(req)
+		require.Nil(t, err)
+		res.Body.Close()
+	}
+
+	assert.InDelta(t, float64(skew), float64(c.ClockSkew()), float64(2*time.Second))
+
+	// Only the first significant skew is warned about.
+	all := warnings.All()
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, warnings.ClockSkew, all[0].Code)
+		assert.Contains(t, all[0].Message, "the local clock is 2h0m0s ahead of that of")
+	}
+}
+
+func TestSSHCacheResolveFromCacheWithSkewedClock(t *testing.T) {
+	e := Endpoint{
+		SSHMetadata: sshp.SSHMetadata{
+			UserAndHost: "userandhost",
+			Port:        "1",
+			Path:        "path",
+		},
+	}
+
+	for desc, c := range map[string]struct {
+		skew      time.Duration
+		expiresAt time.Duration
+		href      string
+	}{
+		// The server's clock is an hour behind, so a token it says
+		// expires in ten minutes looks to have expired already.
+		"server behind": {-time.Hour, -50 * time.Minute, "cache"},
+		// The server's clock is an hour ahead, so a token which it
+		// says expired ten minutes ago looks to be valid.
+		"server ahead": {time.Hour, 50 * time.Minute, "real"},
+	} {
+		ssh := newFakeResolver()
+		cache := withSSHCache(ssh).(*sshCache)
+		skew := c.skew
+		cache.clockSkew = func() time.Duration { return skew }
+		cache.endpoints["userandhost//1//path//post"] = &sshAuthResponse{
+			Href:      "cache",
+			ExpiresAt: time.Now().Add(c.expiresAt),
+			createdAt: time.Now(),
+		}
+		ssh.responses["userandhost"] = sshAuthResponse{Href: "real"}
+
+		res, err := cache.Resolve(e, "post")
+		assert.Nil(t, err, desc)
+		assert.Equal(t, c.href, res.Href, desc)
+	}
+}
@@ -0,0 +1,363 @@
+package lfshttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/creds"
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/subprocess"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// HTTPStack is the implementation of HTTP used to make a request.
+type HTTPStack string
+
+const (
+	// HTTPStackNative makes requests with Go's net/http package.
+	HTTPStackNative HTTPStack = "native"
+	// HTTPStackCurl makes requests by running the curl program, so that
+	// they get libcurl's handling of proxies and their authentication.
+	HTTPStackCurl HTTPStack = "curl"
+
+	// contextKeyHTTPStack is a context.Context key for storing the
+	// HTTPStack with which a given request is made.
+	contextKeyHTTPStack ckey = "httpstack"
+)
+
+// ParseHTTPStack returns the HTTPStack named by "value", and whether it names
+// one.  HTTPStackNative is returned for unknown values.
+func ParseHTTPStack(value string) (HTTPStack, bool) {
+	switch strings.ToLower(value) {
+	case string(HTTPStackNative):
+		return HTTPStackNative, true
+	case string(HTTPStackCurl):
+		return HTTPStackCurl, true
+	}
+	return HTTPStackNative, false
+}
+
+// WithHTTPStack stores the HTTPStack with which the given http.Request is made.
+func WithHTTPStack(req *http.Request, stack HTTPStack) *http.Request {
+	ctx := req.Context()
+	ctx = context.WithValue(ctx, contextKeyHTTPStack, stack)
+
+	return req.WithContext(ctx)
+}
+
+// HTTPStackFor returns the HTTPStack with which the given http.Request is made,
+// which is HTTPStackNative unless WithHTTPStack says otherwise.
+func HTTPStackFor(req *http.Request) HTTPStack {
+	if stack, ok := req.Context().Value(contextKeyHTTPStack).(HTTPStack); ok {
+		return stack
+	}
+	return HTTPStackNative
+}
+
+// curlHttpClient returns an *http.Client which makes requests by running curl.
+func (c *Client) curlHttpClient(access creds.AccessMode) *http.Client {
+	return &http.Client{
+		Transport: &curlTransport{c: c, access: access},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// curlTransport is an http.RoundTripper which makes each request by running
+// curl with the request's method, URL, and headers, streaming the request body
+// to curl's standard input and reading the response from its standard output.
+// The URL, headers, and proxy, any of which may carry credentials, are given to
+// curl in a temporary configuration file which only the current user can read,
+// rather than on its command line, where any user could see them.
+//
+// Redirects are not followed by curl, but returned to the Client as with the
+// native stack.  Any proxy found in the Git configuration or the environment is
+// passed to curl; otherwise curl is left to its own configuration, such as a
+// .curlrc file, so that it may use a proxy the native stack cannot.
+type curlTransport struct {
+	c      *Client
+	access creds.AccessMode
+}
+
+func (t *curlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	config, err := t.writeConfig(req)
+	if err != nil {
+		closeRequestBody(req)
+		return nil, err
+	}
+
+	args := t.args(req, config)
+
+	cmd := subprocess.ExecCommand("curl", args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody {
+		cmd.Stdin = req.Body
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.Remove(config)
+		return nil, err
+	}
+
+	tracerx.Printf("http: making %s request to %s with curl", req.Method, req.URL)
+	if err := cmd.Start(); err != nil {
+		os.Remove(config)
+		closeRequestBody(req)
+		return nil, errors.Wrap(err, tr.Tr.Get("failed to run curl"))
+	}
+
+	body := &curlBody{cmd: cmd, stderr: stderr, req: req, config: config}
+	br := bufio.NewReader(stdout)
+	res, err := readCurlResponse(br, req)
+	if err != nil {
+		if werr := body.wait(); werr != nil {
+			return nil, werr
+		}
+		return nil, errors.Wrap(err, tr.Tr.Get("invalid response from curl"))
+	}
+
+	body.r = br
+	res.Body = body
+	return res, nil
+}
+
+// writeConfig writes the URL and headers of "req", and the proxy, if any,
+// through which it is made, to a new temporary file, which only the current
+// user may read, in the form curl reads them with "--config", and returns its
+// name.  The caller must remove it once curl has exited.
+func (t *curlTransport) writeConfig(req *http.Request) (string, error) {
+	var buf bytes.Buffer
+	writeOption := func(name, value string) {
+		fmt.Fprintf(&buf, "%s = %s\n", name, quoteCurlConfig(value))
+	}
+	writeHeader := func(name, value string) error {
+		if strings.ContainsAny(name, "\r\n") || strings.ContainsAny(value, "\r\n") {
+			return errors.New(tr.Tr.Get("invalid header %q for curl", name))
+		}
+		if len(value) == 0 {
+			// curl sends a header with no value when it ends with
+			// a semicolon.
+			writeOption("header", name+";")
+		} else {
+			writeOption("header", name+": "+value)
+		}
+		return nil
+	}
+
+	writeOption("url", req.URL.String())
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		if err := writeHeader("Host", req.Host); err != nil {
+			return "", err
+		}
+	}
+	for name, values := range req.Header {
+		for _, value := range values {
+			if err := writeHeader(name, value); err != nil {
+				return "", err
+			}
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.ContentLength >= 0 {
+		writeHeader("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	}
+
+	proxy, err := proxyFromClient(t.c)(req)
+	if err != nil {
+		return "", err
+	}
+	if proxy != nil {
+		writeOption("proxy", proxy.String())
+	}
+
+	// ioutil.TempFile creates the file with mode 0600.
+	f, err := ioutil.TempFile("", "git-lfs-curl-config")
+	if err != nil {
+		return "", errors.Wrap(err, tr.Tr.Get("failed to write configuration for curl"))
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, tr.Tr.Get("failed to write configuration for curl"))
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, tr.Tr.Get("failed to write configuration for curl"))
+	}
+	return f.Name(), nil
+}
+
+// quoteCurlConfig returns "value" quoted as a parameter in a curl
+// configuration file, in which backslashes and double quotes are escaped.
+func quoteCurlConfig(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return `"` + value + `"`
+}
+
+// args returns the command-line arguments with which curl makes "req", whose
+// URL, headers, and proxy have been written to the file "config" by
+// writeConfig.
+func (t *curlTransport) args(req *http.Request, config string) []string {
+	args := []string{
+		"--silent", "--show-error",
+		"--globoff",
+		"--suppress-connect-headers",
+	}
+
+	if req.Method == "HEAD" {
+		// The headers are output instead of a body.
+		args = append(args, "--head")
+	} else {
+		args = append(args, "--request", req.Method, "--dump-header", "-")
+	}
+
+	args = append(args, "--config", config)
+
+	if req.Body != nil && req.Body != http.NoBody {
+		args = append(args, "--upload-file", "-")
+	}
+
+	host := req.URL.Host
+	if isCertVerificationDisabledForHost(t.c, host) {
+		args = append(args, "--insecure")
+	}
+	if cafile, ok := t.c.uc.Get("http", req.URL.String(), "sslcainfo"); ok {
+		args = append(args, "--cacert", cafile)
+	}
+	if isClientCertEnabledForHost(t.c, host) {
+		key, _ := t.c.uc.Get("http", fmt.Sprintf("https://%v/", host), "sslKey")
+		cert, _ := t.c.uc.Get("http", fmt.Sprintf("https://%v/", host), "sslCert")
+		args = append(args, "--cert", cert, "--key", key)
+	}
+
+	if t.access == creds.NegotiateAccess {
+		args = append(args, "--negotiate", "--user", ":")
+	}
+
+	return args
+}
+
+// readCurlResponse reads the response headers dumped by curl from "br",
+// skipping those of any informational responses, leaving "br" at the start of
+// the response body.
+func readCurlResponse(br *bufio.Reader, req *http.Request) (*http.Response, error) {
+	for {
+		var head bytes.Buffer
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				return nil, err
+			}
+			if head.Len() == 0 {
+				line = normalizeCurlStatusLine(line)
+			}
+			head.WriteString(line)
+			if line == "\r\n" || line == "\n" {
+				break
+			}
+		}
+
+		res, err := http.ReadResponse(bufio.NewReader(&head), req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode >= 100 && res.StatusCode < 200 {
+			continue
+		}
+
+		// curl has already decoded a chunked body.
+		res.TransferEncoding = nil
+		res.Header.Del("Transfer-Encoding")
+		res.ContentLength = -1
+		if n, err := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64); err == nil && req.Method != "HEAD" {
+			res.ContentLength = n
+		}
+		return res, nil
+	}
+}
+
+// normalizeCurlStatusLine gives the status line of an HTTP/2 or HTTP/3
+// response, which curl writes as "HTTP/2 200", the "major.minor" version which
+// http.ReadResponse expects.
+func normalizeCurlStatusLine(line string) string {
+	fields := strings.SplitN(line, " ", 2)
+	if len(fields) == 2 && strings.HasPrefix(fields[0], "HTTP/") && !strings.Contains(fields[0], ".") {
+		return fields[0] + ".0 " + fields[1]
+	}
+	return line
+}
+
+// curlBody is the body of a response read from curl.  Once it has been read to
+// the end, or closed, curl is waited for, and an error is returned if curl
+// failed.
+type curlBody struct {
+	r      io.Reader
+	cmd    *subprocess.Cmd
+	stderr *bytes.Buffer
+	req    *http.Request
+	config string
+
+	once sync.Once
+	err  error
+}
+
+func (b *curlBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		if werr := b.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (b *curlBody) Close() error {
+	b.once.Do(func() {
+		// Stop curl if the body has not been read to the end.
+		b.cmd.Process.Kill()
+		b.cmd.Wait()
+		os.Remove(b.config)
+		closeRequestBody(b.req)
+	})
+	return nil
+}
+
+// wait waits for curl to exit, and returns an error giving what curl reported
+// if it failed.
+func (b *curlBody) wait() error {
+	b.once.Do(func() {
+		if err := b.cmd.Wait(); err != nil {
+			msg := strings.TrimSpace(b.stderr.String())
+			if len(msg) == 0 {
+				msg = err.Error()
+			}
+			b.err = errors.New(tr.Tr.Get("curl failed for %s: %s", b.req.URL, msg))
+		}
+		os.Remove(b.config)
+		closeRequestBody(b.req)
+	})
+	return b.err
+}
+
+// closeRequestBody closes the body of "req", as an http.RoundTripper must.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -2,6 +2,8 @@ package lfshttp
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
 
@@ -100,6 +102,54 @@ func (e *statusCodeError) HTTPResponse() *http.Response {
 	return e.response
 }
 
+// unexpectedResponseSnippetSize is the number of bytes of the body of an
+// unexpected response which an unexpectedResponseError quotes.
+const unexpectedResponseSnippetSize = 200
+
+// unexpectedResponseError is returned for a response which is not JSON, such as
+// an HTML login page from a proxy, where JSON was expected.
+type unexpectedResponseError struct {
+	response *http.Response
+	snippet  string
+}
+
+// NewUnexpectedResponseError returns an error for a response which was
+// expected to be JSON but was not, quoting the start of its body, which it
+// reads and closes.
+func NewUnexpectedResponseError(res *http.Response) error {
+	var snippet string
+	if res.Body != nil {
+		buf, _ := ioutil.ReadAll(io.LimitReader(res.Body, unexpectedResponseSnippetSize+1))
+		res.Body.Close()
+
+		snippet = strings.Join(strings.Fields(string(buf)), " ")
+		if len(buf) > unexpectedResponseSnippetSize && len(snippet) > 0 {
+			snippet += "..."
+		}
+	}
+	return &unexpectedResponseError{response: res, snippet: snippet}
+}
+
+func IsUnexpectedResponseError(err error) bool {
+	_, ok := errors.Cause(err).(*unexpectedResponseError)
+	return ok
+}
+
+func (e *unexpectedResponseError) Error() string {
+	msg := tr.Tr.Get("unexpected response from %s, possibly an authentication or proxy issue (Content-Type %q)",
+		e.response.Request.URL.Host,
+		e.response.Header.Get("Content-Type"),
+	)
+	if len(e.snippet) == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s: %s", msg, e.snippet)
+}
+
+func (e *unexpectedResponseError) HTTPResponse() *http.Response {
+	return e.response
+}
+
 func defaultError(res *http.Response) error {
 	var msgFmt string
 
@@ -0,0 +1,504 @@
+package lfshttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// redacted replaces secrets in a recorded session.
+const redacted = "[REDACTED]"
+
+// sessionBodyLimit is the most of each request and response body which is
+// recorded.  The rest of a larger body, such as the contents of an object, is
+// passed on without being kept.
+const sessionBodyLimit = 1 << 20
+
+// recordedRequestHeaders are the request headers whose values are recorded.
+// Any other request header, such as one which the batch API gives for an
+// action, may carry credentials, and so is redacted.
+var recordedRequestHeaders = []string{
+	"Accept",
+	"Accept-Encoding",
+	"Content-Encoding",
+	"Content-Length",
+	"Content-Range",
+	"Content-Type",
+	"Range",
+	"Transfer-Encoding",
+	"User-Agent",
+}
+
+// redactedHeaders are the response headers whose values are never recorded.
+var redactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// sessionInteraction is one request made during a recorded session, along
+// with the response to it.
+type sessionInteraction struct {
+	Request  *sessionRequest  `json:"request"`
+	Response *sessionResponse `json:"response"`
+}
+
+type sessionRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	sessionBody
+}
+
+type sessionResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	sessionBody
+}
+
+// sessionBody is the body of a recorded request or response.  Bodies which
+// are text are recorded as they are, so that the recording can be read, and
+// others are encoded in base64.  Only the first sessionBodyLimit bytes of a
+// body are recorded, and Truncated is set if there were more.
+type sessionBody struct {
+	Body      string `json:"body,omitempty"`
+	Encoding  string `json:"body_encoding,omitempty"`
+	Truncated bool   `json:"body_truncated,omitempty"`
+}
+
+func newSessionBody(b []byte) sessionBody {
+	if utf8.Valid(b) {
+		return sessionBody{Body: string(b)}
+	}
+	return sessionBody{Body: base64.StdEncoding.EncodeToString(b), Encoding: "base64"}
+}
+
+func (b sessionBody) Bytes() ([]byte, error) {
+	if b.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(b.Body)
+	}
+	return []byte(b.Body), nil
+}
+
+// sessionRecorder appends each request made through it, and the response to
+// it, to a file as a line of JSON, with credentials redacted.
+type sessionRecorder struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func newSessionRecorder(path string) (*sessionRecorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not open HTTP session recording %q", path))
+	}
+	return &sessionRecorder{f: f}, nil
+}
+
+func (r *sessionRecorder) Record(i *sessionInteraction) error {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.f.Write(append(data, '\n'))
+	return err
+}
+
+func (r *sessionRecorder) Close() error {
+	return r.f.Close()
+}
+
+// sessionReplayer answers requests with the responses from a recorded
+// session.  Each recorded response is given once, to the first request with
+// the same method and URL, in the order in which they were recorded.
+type sessionReplayer struct {
+	mu           sync.Mutex
+	interactions []*sessionInteraction
+}
+
+func newSessionReplayer(path string) (*sessionReplayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not open HTTP session recording %q", path))
+	}
+	defer f.Close()
+
+	r := &sessionReplayer{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var i sessionInteraction
+		if err := json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, errors.Wrap(err, tr.Tr.Get("invalid HTTP session recording %q", path))
+		}
+		if i.Request == nil || i.Response == nil {
+			return nil, errors.New(tr.Tr.Get("invalid HTTP session recording %q: each line must have a request and a response", path))
+		}
+		r.interactions = append(r.interactions, &i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("could not read HTTP session recording %q", path))
+	}
+	return r, nil
+}
+
+// Next returns the first unused recorded response to a request with the
+// given method and URL, or nil if there is none.
+func (r *sessionReplayer) Next(method, u string) *sessionResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, i := range r.interactions {
+		if i.Request.Method == method && i.Request.URL == u {
+			r.interactions = append(r.interactions[:n], r.interactions[n+1:]...)
+			return i.Response
+		}
+	}
+	return nil
+}
+
+// sessionTransport is an http.RoundTripper which records the requests made
+// with it, if "recorder" is set, and answers them from a recording rather
+// than sending them, if "replayer" is set.
+type sessionTransport struct {
+	rt       http.RoundTripper
+	recorder *sessionRecorder
+	replayer *sessionReplayer
+}
+
+// RoundTrip sends "req", or answers it from the recording.  When recording,
+// the request and response bodies are streamed through as they are read, and
+// the interaction is recorded once the response body has been read or closed.
+func (t *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.replayer != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return t.replay(req)
+	}
+
+	sent := new(http.Request)
+	*sent = *req
+	var reqBody *sessionCapture
+	if req.Body != nil {
+		reqBody = newSessionCapture(req.Body, nil)
+		sent.Body = reqBody
+	}
+
+	res, err := t.rt.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &sessionInteraction{
+		Request: &sessionRequest{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+			Header: redactRequestHeader(req.Header),
+		},
+		Response: &sessionResponse{
+			StatusCode: res.StatusCode,
+			Header:     redactHeader(res.Header),
+		},
+	}
+
+	var resBody *sessionCapture
+	resBody = newSessionCapture(res.Body, func() {
+		i.Request.sessionBody = reqBody.SessionBody(req.Header)
+		i.Response.sessionBody = resBody.SessionBody(res.Header)
+		if err := t.recorder.Record(i); err != nil {
+			tracerx.Printf("http: could not record session: %v", err)
+		}
+	})
+	res.Body = resBody
+	return res, nil
+}
+
+func (t *sessionTransport) replay(req *http.Request) (*http.Response, error) {
+	u := redactURL(req.URL)
+	recorded := t.replayer.Next(req.Method, u)
+	if recorded == nil {
+		return nil, errors.New(tr.Tr.Get("no recorded response for %s %s", req.Method, u))
+	}
+
+	tracerx.Printf("http: replaying recorded response to %s %s", req.Method, u)
+
+	body, err := recorded.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, tr.Tr.Get("invalid recorded response for %s %s", req.Method, u))
+	}
+
+	header := recorded.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// sessionCapture is an io.ReadCloser which keeps a copy of the first
+// sessionBodyLimit bytes read from the one it wraps, and calls "done" once
+// that has been read to the end or closed.
+type sessionCapture struct {
+	r    io.ReadCloser
+	done func()
+	once sync.Once
+
+	// mu guards buf and truncated, since the transport may still be
+	// reading a request body when the response to it is closed.
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func newSessionCapture(r io.ReadCloser, done func()) *sessionCapture {
+	return &sessionCapture{r: r, done: done}
+}
+
+func (c *sessionCapture) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+
+	c.mu.Lock()
+	if room := sessionBodyLimit - c.buf.Len(); n > room {
+		c.buf.Write(p[:room])
+		c.truncated = true
+	} else {
+		c.buf.Write(p[:n])
+	}
+	c.mu.Unlock()
+
+	if err == io.EOF {
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *sessionCapture) Close() error {
+	err := c.r.Close()
+	c.finish()
+	return err
+}
+
+func (c *sessionCapture) finish() {
+	if c.done != nil {
+		c.once.Do(c.done)
+	}
+}
+
+// SessionBody returns the captured body, with any credentials in it redacted,
+// to be recorded.  A truncated JSON body cannot be parsed to find them, so
+// none of it is recorded.  A nil *sessionCapture has an empty body.
+func (c *sessionCapture) SessionBody(h http.Header) sessionBody {
+	if c == nil {
+		return sessionBody{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.truncated {
+		return newSessionBody(redactBody(c.buf.Bytes()))
+	}
+	if strings.Contains(h.Get("Content-Type"), "json") {
+		return sessionBody{Truncated: true}
+	}
+	body := newSessionBody(c.buf.Bytes())
+	body.Truncated = true
+	return body
+}
+
+// sessionTransport wraps the given http.RoundTripper so that sessions are
+// recorded to the file named by GIT_LFS_RECORD_HTTP, or replayed from the file
+// named by GIT_LFS_REPLAY_HTTP, if either is set.
+func (c *Client) sessionTransport(rt http.RoundTripper) (http.RoundTripper, error) {
+	if c.osEnv == nil {
+		return rt, nil
+	}
+
+	if c.sessionReplayer == nil {
+		if path, ok := c.osEnv.Get("GIT_LFS_REPLAY_HTTP"); ok && len(path) > 0 {
+			replayer, err := newSessionReplayer(path)
+			if err != nil {
+				return nil, err
+			}
+			c.sessionReplayer = replayer
+		}
+	}
+	if c.sessionReplayer != nil {
+		return &sessionTransport{rt: rt, replayer: c.sessionReplayer}, nil
+	}
+
+	if c.sessionRecorder == nil {
+		if path, ok := c.osEnv.Get("GIT_LFS_RECORD_HTTP"); ok && len(path) > 0 {
+			recorder, err := newSessionRecorder(path)
+			if err != nil {
+				return nil, err
+			}
+			c.sessionRecorder = recorder
+		}
+	}
+	if c.sessionRecorder != nil {
+		return &sessionTransport{rt: rt, recorder: c.sessionRecorder}, nil
+	}
+	return rt, nil
+}
+
+// redactURL returns the given URL with any user information, since a
+// username alone may be a token, and the values of any query parameters, such
+// as the signatures of pre-signed URLs, redacted.
+func redactURL(u *url.URL) string {
+	stripped := *u
+	if u.User != nil {
+		stripped.User = url.User(redacted)
+	}
+	if len(u.RawQuery) > 0 {
+		query := u.Query()
+		for _, values := range query {
+			for n := range values {
+				values[n] = redacted
+			}
+		}
+		stripped.RawQuery = query.Encode()
+	}
+	return stripped.String()
+}
+
+// redactRequestHeader returns a copy of the given request headers with the
+// values of all but recordedRequestHeaders redacted.
+func redactRequestHeader(h http.Header) http.Header {
+	redactedHeader := make(http.Header, len(h))
+	for k, v := range h {
+		redactedHeader[k] = []string{redacted}
+		for _, recorded := range recordedRequestHeaders {
+			if http.CanonicalHeaderKey(k) == recorded {
+				redactedHeader[k] = append([]string(nil), v...)
+				break
+			}
+		}
+	}
+	return redactedHeader
+}
+
+// redactHeader returns a copy of the given response headers with the values
+// of those which carry credentials redacted.
+func redactHeader(h http.Header) http.Header {
+	redactedHeader := make(http.Header, len(h))
+	for k, v := range h {
+		redactedHeader[k] = append([]string(nil), v...)
+	}
+	for _, k := range redactedHeaders {
+		if _, ok := redactedHeader[k]; ok {
+			redactedHeader.Set(k, redacted)
+		}
+	}
+	return redactedHeader
+}
+
+// redactBody returns the given body with the values of the headers which the
+// batch API gives in the "header" objects of actions, and the credentials in
+// any URLs, such as the "href" of each action, redacted, if it is JSON.
+func redactBody(body []byte) []byte {
+	if len(body) == 0 || !json.Valid(body) {
+		return body
+	}
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return body
+	}
+	if !redactJSON(v) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redactedBody
+}
+
+// redactJSON redacts the values of all the headers in the "header" objects
+// within "v", since any of them may carry credentials, and the user
+// information and query values of all the URLs within it, returning whether
+// it changed anything.
+func redactJSON(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if headers, ok := child.(map[string]interface{}); ok && strings.EqualFold(k, "header") {
+				for name := range headers {
+					headers[name] = redacted
+					changed = true
+				}
+			}
+			if s, ok := redactJSONURL(child); ok {
+				v[k] = s
+				changed = true
+			}
+			changed = redactJSON(child) || changed
+		}
+	case []interface{}:
+		for i, child := range v {
+			if s, ok := redactJSONURL(child); ok {
+				v[i] = s
+				changed = true
+			}
+			changed = redactJSON(child) || changed
+		}
+	}
+	return changed
+}
+
+// redactJSONURL returns "v" redacted as by redactURL, and true, if it is a
+// string holding an absolute URL which carries credentials or query values,
+// or false otherwise.
+func redactJSONURL(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.Contains(s, "://") {
+		return "", false
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return "", false
+	}
+	if u.User == nil && len(u.RawQuery) == 0 {
+		return "", false
+	}
+	return redactURL(u), true
+}
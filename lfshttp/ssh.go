@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/ssh"
 	"github.com/git-lfs/git-lfs/v3/subprocess"
 	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
 
@@ -27,6 +29,9 @@ func withSSHCache(ssh SSHResolver) SSHResolver {
 type sshCache struct {
 	endpoints map[string]*sshAuthResponse
 	ssh       SSHResolver
+	// clockSkew returns how far the server's clock is ahead of the local
+	// one, if known.
+	clockSkew func() time.Duration
 }
 
 func (c *sshCache) Resolve(e Endpoint, method string) (sshAuthResponse, error) {
@@ -36,7 +41,11 @@ func (c *sshCache) Resolve(e Endpoint, method string) (sshAuthResponse, error) {
 
 	key := strings.Join([]string{e.SSHMetadata.UserAndHost, e.SSHMetadata.Port, e.SSHMetadata.Path, method}, "//")
 	if res, ok := c.endpoints[key]; ok {
-		if _, expired := res.IsExpiredWithin(5 * time.Second); !expired {
+		var skew time.Duration
+		if c.clockSkew != nil {
+			skew = c.clockSkew()
+		}
+		if _, expired := res.IsExpiredWithin(5*time.Second, skew); !expired {
 			tracerx.Printf("ssh cache: %s git-lfs-authenticate %s %s",
 				e.SSHMetadata.UserAndHost, e.SSHMetadata.Path, endpointOperation(e, method))
 			return *res, nil
@@ -63,9 +72,12 @@ type sshAuthResponse struct {
 	createdAt time.Time
 }
 
-func (r *sshAuthResponse) IsExpiredWithin(d time.Duration) (time.Time, bool) {
-	return tools.IsExpiredAtOrIn(r.createdAt, d, r.ExpiresAt,
-		time.Duration(r.ExpiresIn)*time.Second)
+// IsExpiredWithin returns whether the response expires within "d", taking
+// ExpiresAt to be according to a server clock which is "skew" ahead of the
+// local one.
+func (r *sshAuthResponse) IsExpiredWithin(d, skew time.Duration) (time.Time, bool) {
+	return tools.IsExpiredAtOrInWithSkew(r.createdAt, d, r.ExpiresAt,
+		time.Duration(r.ExpiresIn)*time.Second, skew)
 }
 
 type sshAuthClient struct {
@@ -79,24 +91,44 @@ func (c *sshAuthClient) Resolve(e Endpoint, method string) (sshAuthResponse, err
 		return res, nil
 	}
 
-	exe, args := ssh.GetLFSExeAndArgs(c.os, c.git, &e.SSHMetadata, "git-lfs-authenticate", endpointOperation(e, method), false)
-	cmd := subprocess.ExecCommand(exe, args...)
+	// Multiplex the authentication request over the same control
+	// connection as any other SSH commands we run against this host,
+	// including pure SSH transfers, to avoid repeated handshakes.
+	exe, args := ssh.GetLFSExeAndArgs(c.os, c.git, &e.SSHMetadata, "git-lfs-authenticate", endpointOperation(e, method), true)
 
 	// Save stdout and stderr in separate buffers
 	var outbuf, errbuf bytes.Buffer
-	cmd.Stdout = &outbuf
-	cmd.Stderr = &errbuf
+	var now time.Time
+	var err error
 
-	now := time.Now()
+	// Retry calls which time out up to lfs.ssh.authretries times.
+	attempts := tools.MaxInt(0, c.git.Int("lfs.ssh.authretries", 1)) + 1
+	for i := 0; i < attempts; i++ {
+		outbuf.Reset()
+		errbuf.Reset()
 
-	// Execute command
-	err := cmd.Start()
-	if err == nil {
-		err = cmd.Wait()
+		cmd := subprocess.ExecCommand(exe, args...)
+		cmd.Stdout = &outbuf
+		cmd.Stderr = &errbuf
+
+		now = time.Now()
+
+		// Execute command
+		err = cmd.Start()
+		if err == nil {
+			err = c.wait(cmd, e)
+		}
+		if _, ok := err.(*sshAuthTimeoutError); !ok {
+			break
+		}
+
+		tracerx.Printf("ssh: %s (try: %d/%d)", err.Error(), i+1, attempts)
 	}
 
 	// Processing result
-	if err != nil {
+	if _, ok := err.(*sshAuthTimeoutError); ok {
+		res.Message = err.Error()
+	} else if err != nil {
 		res.Message = strings.TrimSpace(errbuf.String())
 	} else {
 		err = json.Unmarshal(outbuf.Bytes(), &res)
@@ -112,3 +144,44 @@ func (c *sshAuthClient) Resolve(e Endpoint, method string) (sshAuthResponse, err
 
 	return res, err
 }
+
+// sshAuthTimeoutError is returned when a "git-lfs-authenticate" call takes
+// longer than lfs.ssh.authtimeout allows.
+type sshAuthTimeoutError struct {
+	error
+}
+
+// isSSHAuthTimeout returns whether "err" is a "git-lfs-authenticate" call
+// which timed out, and so has already been retried as many times as
+// lfs.ssh.authretries allows.
+func isSSHAuthTimeout(err error) bool {
+	_, ok := errors.Cause(err).(*sshAuthTimeoutError)
+	return ok
+}
+
+// wait waits for the started "git-lfs-authenticate" command to exit.  If
+// lfs.ssh.authtimeout is set and the command takes longer than that many
+// seconds, it is killed and an *sshAuthTimeoutError is returned, so that a
+// hung SSH connection fails fast rather than blocking indefinitely.
+func (c *sshAuthClient) wait(cmd *subprocess.Cmd, e Endpoint) error {
+	timeout := c.git.Int("lfs.ssh.authtimeout", 0)
+	if timeout <= 0 {
+		return cmd.Wait()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Duration(timeout) * time.Second):
+		// Don't wait for the command to be reaped; anything the
+		// SSH client started may hold its output open for longer.
+		cmd.Process.Kill()
+		return &sshAuthTimeoutError{errors.New(tr.Tr.Get("git-lfs-authenticate timed out after %d seconds against %s",
+			timeout, e.SSHMetadata.UserAndHost))}
+	}
+}
@@ -1,12 +1,18 @@
 package lfshttp
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	sshp "github.com/git-lfs/git-lfs/v3/ssh"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSSHCacheResolveFromCache(t *testing.T) {
@@ -225,3 +231,123 @@ func (r *fakeResolver) Resolve(e Endpoint, method string) (sshAuthResponse, erro
 
 	return res, err
 }
+
+// writeSlowSSH writes a stub SSH command which sleeps for "delays[n]" seconds
+// on its "n"th invocation before responding, and returns its path.
+func writeSlowSSH(t *testing.T, dir string, delays ...int) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub SSH command requires a POSIX shell")
+	}
+
+	script := "#!/bin/sh\n" +
+		"n=$(cat \"$0.count\" 2>/dev/null || echo 0)\n" +
+		"echo $((n + 1)) > \"$0.count\"\n" +
+		"case $n in\n"
+	for i, delay := range delays {
+		script += fmt.Sprintf("%d) sleep %d;;\n", i, delay)
+	}
+	script += "esac\n" +
+		"echo '{\"href\":\"https://example.com/lfs\"}'\n"
+
+	path := filepath.Join(dir, "ssh")
+	require.Nil(t, ioutil.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func newSlowSSHClient(t *testing.T, ssh string, gitEnv map[string]string) *Client {
+	c, err := NewClient(NewContext(nil, map[string]string{
+		"GIT_SSH":         ssh,
+		"GIT_SSH_VARIANT": "simple",
+	}, gitEnv))
+	require.Nil(t, err)
+	return c
+}
+
+var slowSSHEndpoint = Endpoint{
+	SSHMetadata: sshp.SSHMetadata{
+		UserAndHost: "git@example.com",
+		Path:        "repo",
+	},
+	Operation: "download",
+}
+
+func TestSSHAuthClientTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-ssh-auth-timeout")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newSlowSSHClient(t, writeSlowSSH(t, dir, 10), map[string]string{
+		"lfs.ssh.authtimeout": "1",
+		"lfs.ssh.authretries": "0",
+		"lfs.ssh.retries":     "5",
+	})
+
+	start := time.Now()
+	_, err = c.sshResolveWithRetries(slowSSHEndpoint, "GET")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "git-lfs-authenticate timed out after 1 seconds against git@example.com")
+	}
+	assert.True(t, time.Since(start) < 5*time.Second, "the stub should have been killed")
+
+	// A timed out call is not retried again by lfs.ssh.retries.
+	count, err := ioutil.ReadFile(filepath.Join(dir, "ssh.count"))
+	require.Nil(t, err)
+	assert.Equal(t, "1\n", string(count))
+}
+
+func TestSSHAuthClientTimeoutRetries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-ssh-auth-timeout")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newSlowSSHClient(t, writeSlowSSH(t, dir, 10, 10), map[string]string{
+		"lfs.ssh.authtimeout": "1",
+		"lfs.ssh.authretries": "2",
+		"lfs.ssh.retries":     "0",
+	})
+
+	res, err := c.sshResolveWithRetries(slowSSHEndpoint, "GET")
+	require.Nil(t, err)
+	assert.Equal(t, "https://example.com/lfs", res.Href)
+
+	count, err := ioutil.ReadFile(filepath.Join(dir, "ssh.count"))
+	require.Nil(t, err)
+	assert.Equal(t, "3\n", string(count))
+}
+
+func TestSSHAuthClientWithinTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-ssh-auth-timeout")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newSlowSSHClient(t, writeSlowSSH(t, dir, 1), map[string]string{
+		"lfs.ssh.authtimeout": "5",
+		"lfs.ssh.authretries": "0",
+		"lfs.ssh.retries":     "0",
+	})
+
+	res, err := c.sshResolveWithRetries(slowSSHEndpoint, "GET")
+	require.Nil(t, err)
+	assert.Equal(t, "https://example.com/lfs", res.Href)
+}
+
+func TestSSHAuthClientTimeoutRetriesExhausted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-ssh-auth-timeout")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newSlowSSHClient(t, writeSlowSSH(t, dir, 10, 10, 10), map[string]string{
+		"lfs.ssh.authtimeout": "1",
+		"lfs.ssh.authretries": "1",
+		"lfs.ssh.retries":     "5",
+	})
+
+	_, err = c.sshResolveWithRetries(slowSSHEndpoint, "GET")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "git-lfs-authenticate timed out after 1 seconds against git@example.com")
+	}
+
+	count, err := ioutil.ReadFile(filepath.Join(dir, "ssh.count"))
+	require.Nil(t, err)
+	assert.Equal(t, "2\n", string(count))
+}
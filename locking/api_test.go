@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xeipuuv/gojsonschema"
@@ -276,3 +278,33 @@ func assertSchema(t *testing.T, schema *sourcedSchema, dataLoader gojsonschema.J
 		t.Errorf("Schema: %s\n%s", schema.Source, strings.Join(valErrors, "\n"))
 	}
 }
+
+func TestAPISearchWarnsOfClockSkew(t *testing.T) {
+	warnings.SetOutput(nil)
+	defer warnings.SetOutput(os.Stderr)
+	warnings.Reset()
+	defer warnings.Reset()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&lockList{Locks: []Lock{}})
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	lc := &httpLockClient{Client: c}
+	_, status, err := lc.Search("", &lockSearchRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, 200, status)
+
+	all := warnings.All()
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, warnings.ClockSkew, all[0].Code)
+		assert.Contains(t, all[0].Message, "behind")
+	}
+}
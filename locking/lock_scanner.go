@@ -0,0 +1,121 @@
+package locking
+
+import (
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// LockScanner gives the locks found by a search of the server one at a time,
+// requesting each page of results only once the locks on the page before it
+// have been read, so that callers need not hold every lock in memory at once.
+//
+// It is used by calling Scan until it returns false, reading each lock with
+// Lock, and then checking Err.  Close must be called once it is no longer
+// needed, and may be called early to stop the search without requesting the
+// remaining pages.
+type LockScanner struct {
+	locks <-chan Lock
+	lock  Lock
+	// err is set before locks is closed.
+	err error
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// ScanLocks starts a search of the server for the locks which match the given
+// name/value filter, and returns a *LockScanner giving them.  If limit > 0, the
+// search stops at that number of locks.
+func (c *Client) ScanLocks(filter map[string]string, limit int) *LockScanner {
+	apifilters := make([]lockFilter, 0, len(filter))
+	for k, v := range filter {
+		apifilters = append(apifilters, lockFilter{Property: k, Value: v})
+	}
+
+	query := &lockSearchRequest{
+		Filters: apifilters,
+		Limit:   limit,
+		Refspec: c.RemoteRef.Refspec(),
+	}
+
+	locks := make(chan Lock)
+	s := &LockScanner{locks: locks, stop: make(chan struct{})}
+	go s.run(c, query, limit, locks)
+	return s
+}
+
+// run requests each page of locks matching "query" in turn, and sends their
+// locks on "locks", closing it once there are no more, "limit" locks have been
+// sent, or the search has failed or been stopped.
+func (s *LockScanner) run(c *Client, query *lockSearchRequest, limit int, locks chan<- Lock) {
+	defer close(locks)
+
+	var n int
+	for {
+		list, _, err := c.client.Search(c.Remote, query)
+		if err != nil {
+			s.err = errors.Wrap(err, tr.Tr.Get("locking"))
+			return
+		}
+
+		if list.Message != "" {
+			if len(list.RequestID) > 0 {
+				tracerx.Printf("Server Request ID: %s", list.RequestID)
+			}
+			s.err = errors.New(tr.Tr.Get("server error searching for locks: %s", list.Message))
+			return
+		}
+
+		for _, l := range list.Locks {
+			select {
+			case locks <- l:
+			case <-s.stop:
+				return
+			}
+
+			n++
+			if limit > 0 && n >= limit {
+				return
+			}
+		}
+
+		if list.NextCursor == "" {
+			return
+		}
+		query.Cursor = list.NextCursor
+	}
+}
+
+// Scan advances to the next lock, returning false once there are no more, or
+// once the search has failed or been stopped.
+func (s *LockScanner) Scan() bool {
+	l, ok := <-s.locks
+	s.lock = l
+	return ok
+}
+
+// Lock returns the lock which the last call to Scan advanced to.
+func (s *LockScanner) Lock() Lock {
+	return s.lock
+}
+
+// Err returns the error which ended the search, if any, once Scan has returned
+// false.
+func (s *LockScanner) Err() error {
+	return s.err
+}
+
+// Close stops the search, if it has not finished, so that no further pages are
+// requested, and waits for any request in progress to finish.  It returns the
+// same error as Err.
+func (s *LockScanner) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		for range s.locks {
+		}
+	})
+	return s.err
+}
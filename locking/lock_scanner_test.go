@@ -0,0 +1,151 @@
+package locking
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagedLocksServer returns a server giving "pages" pages of "perPage" locks
+// each, and failing the request for page "failPage", if it is not zero.  The
+// number of requests it has received is counted in "requests".
+func newPagedLocksServer(t *testing.T, pages, perPage, failPage int, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/locks", r.URL.Path)
+
+		page := 1
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			var err error
+			page, err = strconv.Atoi(cursor)
+			require.Nil(t, err)
+		}
+
+		list := &lockList{}
+		if page == failPage {
+			list.Message = "something went wrong"
+		} else {
+			for i := 0; i < perPage; i++ {
+				id := fmt.Sprintf("%d", (page-1)*perPage+i)
+				list.Locks = append(list.Locks, Lock{Id: id, Path: "file" + id + ".dat", Owner: &User{Name: "Fred"}})
+			}
+			if page < pages {
+				list.NextCursor = strconv.Itoa(page + 1)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.Nil(t, json.NewEncoder(w).Encode(list))
+	}))
+}
+
+func newLockScannerTestClient(t *testing.T, srv *httptest.Server) *Client {
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":    srv.URL + "/api",
+		"user.name":  "Fred",
+		"user.email": "fred@bloggs.com",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	client.RemoteRef = &git.Ref{Name: "refs/heads/master"}
+	return client
+}
+
+func TestScanLocksFollowsEveryPage(t *testing.T) {
+	var requests int32
+	srv := newPagedLocksServer(t, 3, 4, 0, &requests)
+	defer srv.Close()
+
+	scanner := newLockScannerTestClient(t, srv).ScanLocks(nil, 0)
+
+	var ids []string
+	for scanner.Scan() {
+		ids = append(ids, scanner.Lock().Id)
+	}
+	require.Nil(t, scanner.Err())
+	require.Nil(t, scanner.Close())
+
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}, ids)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+}
+
+func TestScanLocksRequestsPagesAsTheyAreRead(t *testing.T) {
+	var requests int32
+	srv := newPagedLocksServer(t, 100, 4, 0, &requests)
+	defer srv.Close()
+
+	scanner := newLockScannerTestClient(t, srv).ScanLocks(nil, 0)
+
+	for i := 0; i < 5; i++ {
+		require.True(t, scanner.Scan())
+		assert.Equal(t, strconv.Itoa(i), scanner.Lock().Id)
+	}
+	require.Nil(t, scanner.Close())
+	require.Nil(t, scanner.Close())
+
+	// The scan was stopped part way through the second page, so no
+	// further pages were requested.
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	assert.False(t, scanner.Scan())
+}
+
+func TestScanLocksStopsAtLimit(t *testing.T) {
+	var requests int32
+	srv := newPagedLocksServer(t, 3, 4, 0, &requests)
+	defer srv.Close()
+
+	scanner := newLockScannerTestClient(t, srv).ScanLocks(nil, 6)
+
+	var n int
+	for scanner.Scan() {
+		n++
+	}
+	require.Nil(t, scanner.Close())
+
+	assert.Equal(t, 6, n)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
+
+func TestScanLocksReportsServerError(t *testing.T) {
+	var requests int32
+	srv := newPagedLocksServer(t, 3, 4, 2, &requests)
+	defer srv.Close()
+
+	scanner := newLockScannerTestClient(t, srv).ScanLocks(nil, 0)
+
+	var n int
+	for scanner.Scan() {
+		n++
+	}
+	err := scanner.Close()
+	require.NotNil(t, err)
+	assert.Equal(t, "server error searching for locks: something went wrong", err.Error())
+	assert.Equal(t, err, scanner.Err())
+	assert.Equal(t, 4, n)
+}
+
+func TestSearchLocksUsesScanner(t *testing.T) {
+	var requests int32
+	srv := newPagedLocksServer(t, 3, 4, 2, &requests)
+	defer srv.Close()
+
+	// The locks read before the error are still returned.
+	locks, err := newLockScannerTestClient(t, srv).SearchLocks(nil, 0, false, false)
+	require.NotNil(t, err)
+	assert.Len(t, locks, 4)
+}
@@ -51,6 +51,12 @@ type Client struct {
 	lockableFilter   *filepathfilter.Filter
 	lockableMutex    sync.Mutex
 
+	// remotePaths holds the paths of the locks on the server, which are
+	// searched for at most once, by the first call to remoteLockedPaths(),
+	// and reused for the rest of the operation.
+	remotePaths     map[string]bool
+	remotePathsOnce sync.Once
+
 	LocalWorkingDir          string
 	LocalGitDir              string
 	SetLockableFilesReadOnly bool
@@ -100,10 +106,20 @@ func (c *Client) Close() error {
 // path must be relative to the root of the repository
 // Returns the lock id if successful, or an error
 func (c *Client) LockFile(path string) (Lock, error) {
-	lockRes, _, err := c.client.Lock(c.Remote, &lockRequest{
+	lockRes, status, err := c.client.Lock(c.Remote, &lockRequest{
 		Path: path,
 		Ref:  &lockRef{Name: c.RemoteRef.Refspec()},
 	})
+	if status == http.StatusConflict {
+		// The path is already locked. If the lock is our own, locking
+		// it again is not an error, so report the existing lock.
+		// Otherwise fall through and report the conflict.
+		if lock, lerr := c.ownLockFor(path); lerr != nil {
+			tracerx.Printf("locking: unable to check owner of existing lock on %q: %v", path, lerr)
+		} else if lock != nil {
+			lockRes, err = &lockResponse{Lock: lock}, nil
+		}
+	}
 	if err != nil {
 		return Lock{}, errors.Wrap(err, tr.Tr.Get("locking API"))
 	}
@@ -135,6 +151,37 @@ func (c *Client) LockFile(path string) (Lock, error) {
 	return lock, nil
 }
 
+// ownLockFor returns the lock on the given path if it is held by the current
+// user, or nil if it is held by somebody else or not at all.
+func (c *Client) ownLockFor(path string) (*Lock, error) {
+	body := &lockVerifiableRequest{}
+	if c.RemoteRef != nil {
+		body.Ref = &lockRef{Name: c.RemoteRef.Refspec()}
+	}
+
+	for {
+		list, _, err := c.client.SearchVerifiable(c.Remote, body)
+		if err != nil {
+			return nil, err
+		}
+		if list.Message != "" {
+			return nil, errors.New(tr.Tr.Get("server error searching locks: %s", list.Message))
+		}
+
+		for _, l := range list.Ours {
+			if l.Path == path {
+				lock := l
+				return &lock, nil
+			}
+		}
+
+		if list.NextCursor == "" {
+			return nil, nil
+		}
+		body.Cursor = list.NextCursor
+	}
+}
+
 // getAbsolutePath takes a repository-relative path and makes it absolute.
 //
 // For instance, given a repository in /usr/local/src/my-repo and a file called
@@ -206,10 +253,36 @@ type Lock struct {
 	Path string `json:"path"`
 	// Owner is the identity of the user that created this lock.
 	Owner *User `json:"owner,omitempty"`
-	// LockedAt is the time at which this lock was acquired.
+	// LockedAt is the time at which this lock was acquired, according to
+	// the server's clock.  Locks do not expire, so it is not adjusted for
+	// any clock skew; the lock API's responses still count towards
+	// detecting it.
 	LockedAt time.Time `json:"locked_at"`
 }
 
+// Probe returns whether the remote supports the locking API, by searching for
+// at most one lock.  A server which responds with 404 Not Found or 501 Not
+// Implemented is taken not to support locking; any other failure is returned
+// as an error.
+func (c *Client) Probe() (bool, error) {
+	list, status, err := c.client.Search(c.Remote, &lockSearchRequest{
+		Limit:   1,
+		Refspec: c.RemoteRef.Refspec(),
+	})
+	switch status {
+	case http.StatusNotFound, http.StatusNotImplemented:
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+	if list != nil && list.Message != "" {
+		return false, errors.New(tr.Tr.Get("server error searching for locks: %s", list.Message))
+	}
+	return true, nil
+}
+
 // SearchLocks returns a channel of locks which match the given name/value filter
 // If limit > 0 then search stops at that number of locks
 // If localOnly = true, don't query the server & report only own local locks
@@ -346,46 +419,11 @@ func (c *Client) searchLocalLocks(filter map[string]string, limit int) ([]Lock,
 func (c *Client) searchRemoteLocks(filter map[string]string, limit int) ([]Lock, error) {
 	locks := make([]Lock, 0, limit)
 
-	apifilters := make([]lockFilter, 0, len(filter))
-	for k, v := range filter {
-		apifilters = append(apifilters, lockFilter{Property: k, Value: v})
+	scanner := c.ScanLocks(filter, limit)
+	for scanner.Scan() {
+		locks = append(locks, scanner.Lock())
 	}
-
-	query := &lockSearchRequest{
-		Filters: apifilters,
-		Limit:   limit,
-		Refspec: c.RemoteRef.Refspec(),
-	}
-
-	for {
-		list, _, err := c.client.Search(c.Remote, query)
-		if err != nil {
-			return locks, errors.Wrap(err, tr.Tr.Get("locking"))
-		}
-
-		if list.Message != "" {
-			if len(list.RequestID) > 0 {
-				tracerx.Printf("Server Request ID: %s", list.RequestID)
-			}
-			return locks, errors.New(tr.Tr.Get("server error searching for locks: %s", list.Message))
-		}
-
-		for _, l := range list.Locks {
-			locks = append(locks, l)
-			if limit > 0 && len(locks) >= limit {
-				// Exit outer loop too
-				return locks, nil
-			}
-		}
-
-		if list.NextCursor != "" {
-			query.Cursor = list.NextCursor
-		} else {
-			break
-		}
-	}
-
-	return locks, nil
+	return locks, scanner.Close()
 }
 
 // lockIdFromPath makes a call to the LFS API and resolves the ID for the locked
@@ -419,17 +457,38 @@ func (c *Client) lockIdFromPath(path string) (string, error) {
 }
 
 // IsFileLockedByCurrentCommitter returns whether a file is locked by the
-// current user, as cached locally
+// current user, as cached locally.  If the local cache cannot be searched, the
+// server is asked for its locks instead, once for all the files which are
+// checked, rather than once for each.
 func (c *Client) IsFileLockedByCurrentCommitter(path string) bool {
 	filter := map[string]string{"path": path}
 	locks, err := c.searchLocalLocks(filter, 1)
 	if err != nil {
 		tracerx.Printf("Error searching cached locks: %s\nForcing remote search", err)
-		locks, _ = c.searchRemoteLocks(filter, 1)
+		return c.remoteLockedPaths()[path]
 	}
 	return len(locks) > 0
 }
 
+// remoteLockedPaths returns the paths of the locks on the server.  The server
+// is only asked the first time it is called, and if that fails, no path is
+// reported as locked.  The local cache is left as it is.
+func (c *Client) remoteLockedPaths() map[string]bool {
+	c.remotePathsOnce.Do(func() {
+		c.remotePaths = make(map[string]bool)
+
+		locks, err := c.searchRemoteLocks(nil, 0)
+		if err != nil {
+			tracerx.Printf("locking: unable to search remote locks: %s", err)
+			return
+		}
+		for _, l := range locks {
+			c.remotePaths[l.Path] = true
+		}
+	})
+	return c.remotePaths
+}
+
 func init() {
 	kv.RegisterTypeForStorage(&Lock{})
 }
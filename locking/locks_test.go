@@ -2,6 +2,7 @@ package locking
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -314,3 +315,177 @@ func TestSearchLocksVerifiableWithCache(t *testing.T) {
 	sort.Sort(LocksById(theirLocks))
 	assert.Equal(t, expectedTheirLocks, theirLocks)
 }
+
+func newLockFileTestClient(t *testing.T, lockStatus int, lockRes *lockResponse) (*Client, func()) {
+	tempDir, err := ioutil.TempDir("", "testLockFile")
+	require.Nil(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/api/locks":
+			w.WriteHeader(lockStatus)
+			assert.Nil(t, json.NewEncoder(w).Encode(lockRes))
+		case "/api/locks/verify":
+			assert.Nil(t, json.NewEncoder(w).Encode(lockVerifiableList{
+				Ours: []Lock{
+					Lock{Id: "101", Path: "ours.dat", Owner: &User{Name: "Fred"}},
+				},
+				Theirs: []Lock{
+					Lock{Id: "102", Path: "theirs.dat", Owner: &User{Name: "Alice"}},
+				},
+			}))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":    srv.URL + "/api",
+		"user.name":  "Fred",
+		"user.email": "fred@bloggs.com",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	require.Nil(t, client.SetupFileCache(tempDir))
+	client.RemoteRef = &git.Ref{Name: "refs/heads/master"}
+
+	return client, func() {
+		srv.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestLockFileNewLock(t *testing.T) {
+	client, cleanup := newLockFileTestClient(t, http.StatusCreated, &lockResponse{
+		Lock: &Lock{Id: "100", Path: "new.dat", Owner: &User{Name: "Fred"}},
+	})
+	defer cleanup()
+
+	lock, err := client.LockFile("new.dat")
+	assert.Nil(t, err)
+	assert.Equal(t, "100", lock.Id)
+}
+
+func TestLockFileAlreadyHeldBySelf(t *testing.T) {
+	client, cleanup := newLockFileTestClient(t, http.StatusConflict, &lockResponse{
+		Lock:    &Lock{Id: "101", Path: "ours.dat", Owner: &User{Name: "Fred"}},
+		Message: "already created lock",
+	})
+	defer cleanup()
+
+	lock, err := client.LockFile("ours.dat")
+	assert.Nil(t, err)
+	assert.Equal(t, "101", lock.Id)
+	assert.Equal(t, "ours.dat", lock.Path)
+}
+
+func TestLockFileAlreadyHeldByOther(t *testing.T) {
+	client, cleanup := newLockFileTestClient(t, http.StatusConflict, &lockResponse{
+		Lock:    &Lock{Id: "102", Path: "theirs.dat", Owner: &User{Name: "Alice"}},
+		Message: "already created lock",
+	})
+	defer cleanup()
+
+	_, err := client.LockFile("theirs.dat")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "already created lock")
+}
+
+func TestIsFileLockedByCurrentCommitterWithoutCache(t *testing.T) {
+	remoteQueries := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteQueries++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, client.IsFileLockedByCurrentCommitter(fmt.Sprintf("%d.dat", i)))
+	}
+	assert.Equal(t, 0, remoteQueries)
+}
+
+func TestRemoteLockedPathsSearchesOnce(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testCacheLock")
+	require.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	remoteQueries := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteQueries++
+
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/locks", r.URL.Path)
+
+		// Give the locks in two pages.
+		list := lockList{}
+		if r.URL.Query().Get("cursor") == "1" {
+			list.Locks = []Lock{Lock{Path: "b.dat", Id: "102"}}
+		} else {
+			list.Locks = []Lock{Lock{Path: "a.dat", Id: "101"}}
+			list.NextCursor = "1"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.Nil(t, json.NewEncoder(w).Encode(&list))
+	}))
+	defer srv.Close()
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	client.RemoteRef = &git.Ref{Name: "refs/heads/main"}
+	require.Nil(t, client.SetupFileCache(tempDir))
+	require.Nil(t, client.cache.Add(Lock{Path: "cached.dat", Id: "100"}))
+
+	for i := 0; i < 100; i++ {
+		assert.False(t, client.remoteLockedPaths()[fmt.Sprintf("other/%d.dat", i)])
+	}
+	assert.True(t, client.remoteLockedPaths()["a.dat"])
+	assert.True(t, client.remoteLockedPaths()["b.dat"])
+
+	// One query for each page, however many paths are checked, and the
+	// local cache is left as it was.
+	assert.Equal(t, 2, remoteQueries)
+	assert.Equal(t, []Lock{Lock{Path: "cached.dat", Id: "100"}}, client.cache.Locks())
+}
+
+func TestRemoteLockedPathsFailure(t *testing.T) {
+	remoteQueries := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteQueries++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	client.RemoteRef = &git.Ref{Name: "refs/heads/main"}
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, client.remoteLockedPaths()[fmt.Sprintf("%d.dat", i)])
+	}
+	assert.Equal(t, 1, remoteQueries)
+}
@@ -0,0 +1,31 @@
+package locking
+
+import (
+	"os"
+)
+
+// CachedPathsLockedByOthers returns the set of paths, relative to the root of
+// the repository, which are locked by somebody other than the current
+// committer according to the verifiable locks cached by the last search for
+// them, such as by `git lfs locks --verify` or `git push`.  If no such locks
+// have been cached, the set is empty.
+func (c *Client) CachedPathsLockedByOthers() (map[string]bool, error) {
+	paths := make(map[string]bool)
+
+	cacheFile, err := c.prepareCacheDirectory("verifiable")
+	if err != nil {
+		return paths, err
+	}
+	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
+		return paths, nil
+	}
+
+	_, theirs, err := c.SearchLocksVerifiable(0, true)
+	if err != nil {
+		return paths, err
+	}
+	for _, l := range theirs {
+		paths[l.Path] = true
+	}
+	return paths, nil
+}
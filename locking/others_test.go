@@ -0,0 +1,56 @@
+package locking
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/config"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedPathsLockedByOthers(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testCachedPathsLockedByOthers")
+	require.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	lfsclient, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, nil))
+	require.Nil(t, err)
+
+	client, err := NewClient("", lfsclient, config.New())
+	require.Nil(t, err)
+	require.Nil(t, client.SetupFileCache(tempDir))
+	client.RemoteRef = &git.Ref{Name: "refs/heads/main"}
+
+	// Without any cached locks, no paths are locked by others.
+	paths, err := client.CachedPathsLockedByOthers()
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+
+	ours := []Lock{Lock{Path: "ours.dat", Id: "1"}}
+	theirs := []Lock{
+		Lock{Path: "theirs.dat", Id: "2"},
+		Lock{Path: "folder/theirs.dat", Id: "3"},
+	}
+	require.Nil(t, client.writeLocksToCacheFile("verifiable", func(w io.Writer) error {
+		return client.EncodeLocksVerifiable(ours, theirs, w)
+	}))
+
+	paths, err = client.CachedPathsLockedByOthers()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{
+		"theirs.dat":        true,
+		"folder/theirs.dat": true,
+	}, paths)
+
+	// The cache is kept separately for each remote ref.
+	client.RemoteRef = &git.Ref{Name: "refs/heads/other"}
+	paths, err = client.CachedPathsLockedByOthers()
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+}
@@ -0,0 +1,43 @@
+package locking
+
+// UnlockedChangesMode is what is done about changes to lockable files which
+// are not locked by the current committer, as set by lfs.unlockedchanges.
+type UnlockedChangesMode string
+
+const (
+	// UnlockedChangesWarn warns about each such file, but lets the changes
+	// be committed.  It is the default.
+	UnlockedChangesWarn UnlockedChangesMode = "warn"
+	// UnlockedChangesDeny refuses to commit changes to such files until
+	// they are locked.
+	UnlockedChangesDeny UnlockedChangesMode = "deny"
+	// UnlockedChangesAllow neither checks for nor reports such files.
+	UnlockedChangesAllow UnlockedChangesMode = "allow"
+)
+
+// ParseUnlockedChangesMode returns the UnlockedChangesMode named by "s", and
+// whether it is a valid one.
+func ParseUnlockedChangesMode(s string) (UnlockedChangesMode, bool) {
+	switch m := UnlockedChangesMode(s); m {
+	case UnlockedChangesWarn, UnlockedChangesDeny, UnlockedChangesAllow:
+		return m, true
+	}
+	return UnlockedChangesWarn, false
+}
+
+// UnlockedLockableFiles returns those of the given files, whose paths are
+// relative to the root of the repository, which are lockable but are not
+// locked by the current committer according to the local lock cache.
+func (c *Client) UnlockedLockableFiles(files []string) []string {
+	if len(c.GetLockablePatterns()) == 0 {
+		return nil
+	}
+
+	var unlocked []string
+	for _, file := range files {
+		if c.IsFileLockable(file) && !c.IsFileLockedByCurrentCommitter(file) {
+			unlocked = append(unlocked, file)
+		}
+	}
+	return unlocked
+}
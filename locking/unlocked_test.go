@@ -0,0 +1,23 @@
+package locking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUnlockedChangesMode(t *testing.T) {
+	for s, expected := range map[string]UnlockedChangesMode{
+		"warn":  UnlockedChangesWarn,
+		"deny":  UnlockedChangesDeny,
+		"allow": UnlockedChangesAllow,
+	} {
+		mode, ok := ParseUnlockedChangesMode(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, mode, s)
+	}
+
+	mode, ok := ParseUnlockedChangesMode("block")
+	assert.False(t, ok)
+	assert.Equal(t, UnlockedChangesWarn, mode)
+}
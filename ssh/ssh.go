@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/git-lfs/git-lfs/v3/config"
@@ -109,10 +110,48 @@ func findRuntimeDir(osEnv config.Environment) string {
 	return ""
 }
 
+var (
+	// tempControlDir is the temporary directory holding control sockets
+	// when no private runtime directory is available.  It is created once
+	// per process so that every SSH command we run for the same host can
+	// share a single control connection.
+	tempControlDir   string
+	tempControlDirMu sync.Mutex
+)
+
+func getTempControlDir() (string, error) {
+	tempControlDirMu.Lock()
+	defer tempControlDirMu.Unlock()
+
+	if tempControlDir != "" {
+		return tempControlDir, nil
+	}
+	dir, err := ioutil.TempDir("", "sock-*")
+	if err != nil {
+		return "", err
+	}
+	tempControlDir = dir
+	return dir, nil
+}
+
+// Cleanup removes the temporary directory holding control sockets, if one was
+// created, once no more SSH commands are to be run.
+func Cleanup() error {
+	tempControlDirMu.Lock()
+	defer tempControlDirMu.Unlock()
+
+	if tempControlDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(tempControlDir)
+	tempControlDir = ""
+	return err
+}
+
 func getControlDir(osEnv config.Environment) (string, error) {
 	dir := findRuntimeDir(osEnv)
 	if dir == "" {
-		return ioutil.TempDir("", "sock-*")
+		return getTempControlDir()
 	}
 	dir = filepath.Join(dir, "git-lfs")
 	err := os.Mkdir(dir, 0700)
@@ -121,7 +160,7 @@ func getControlDir(osEnv config.Environment) (string, error) {
 		// os.ErrExist, but that's not available on Go 1.11.
 		perr, ok := err.(*os.PathError)
 		if !ok || perr.Err != syscall.EEXIST {
-			return ioutil.TempDir("", "sock-*")
+			return getTempControlDir()
 		}
 	}
 	return dir, nil
@@ -158,7 +197,7 @@ func GetExeAndArgs(osEnv config.Environment, gitEnv config.Environment, meta *SS
 	multiplexEnabled := gitEnv.Bool("lfs.ssh.automultiplex", true)
 	if variant == variantSSH && multiplexDesired && multiplexEnabled {
 		controlPath, err := getControlDir(osEnv)
-		if err != nil {
+		if err == nil {
 			controlPath = filepath.Join(controlPath, "sock-%C")
 			args = append(args, "-oControlMaster=auto", fmt.Sprintf("-oControlPath=%s", controlPath))
 		}
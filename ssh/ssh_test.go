@@ -1,8 +1,11 @@
 package ssh_test
 
 import (
+	"io/ioutil"
 	"net/url"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
@@ -521,6 +524,134 @@ func TestSSHGetExeAndArgsInvalidOptionsAsPath(t *testing.T) {
 	assert.Equal(t, false, needShell)
 }
 
+func TestSSHGetLFSExeAndArgsMultiplexRuntimeDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-ssh-runtime")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND": "",
+		"GIT_SSH":         "",
+		"XDG_RUNTIME_DIR": dir,
+	}, nil))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+	meta.Path = "user/repo"
+
+	controlPath := "-oControlPath=" + filepath.Join(dir, "git-lfs", "sock-%C")
+
+	exe, args := ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-authenticate", "download", true)
+	assert.Equal(t, "ssh", exe)
+	assert.Equal(t, []string{
+		"-oControlMaster=auto",
+		controlPath,
+		"user@foo.com",
+		"git-lfs-authenticate user/repo download",
+	}, args)
+
+	exe, args = ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-transfer", "download", true)
+	assert.Equal(t, "ssh", exe)
+	assert.Equal(t, []string{
+		"-oControlMaster=auto",
+		controlPath,
+		"user@foo.com",
+		"git-lfs-transfer user/repo download",
+	}, args)
+}
+
+func TestSSHGetLFSExeAndArgsMultiplexReusesControlPath(t *testing.T) {
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND": "",
+		"GIT_SSH":         "",
+	}, nil))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+	meta.Path = "user/repo"
+
+	_, auth := ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-authenticate", "upload", true)
+	_, transfer := ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-transfer", "upload", true)
+
+	require.Len(t, auth, 4)
+	require.Len(t, transfer, 4)
+	assert.Equal(t, "-oControlMaster=auto", auth[0])
+	assert.Contains(t, auth[1], "-oControlPath=")
+	assert.Equal(t, auth[:3], transfer[:3])
+}
+
+func TestSSHCleanupRemovesTempControlDir(t *testing.T) {
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND": "",
+		"GIT_SSH":         "",
+	}, nil))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+	meta.Path = "user/repo"
+
+	_, args := ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-authenticate", "upload", true)
+	require.Len(t, args, 4)
+	dir := filepath.Dir(strings.TrimPrefix(args[1], "-oControlPath="))
+
+	_, err = os.Stat(dir)
+	require.Nil(t, err)
+
+	require.Nil(t, ssh.Cleanup())
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+
+	// A new directory is created for any later commands.
+	_, args = ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-authenticate", "upload", true)
+	require.Len(t, args, 4)
+	assert.NotEqual(t, "-oControlPath="+filepath.Join(dir, "sock-%C"), args[1])
+	require.Nil(t, ssh.Cleanup())
+}
+
+func TestSSHGetLFSExeAndArgsMultiplexDisabled(t *testing.T) {
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND": "",
+		"GIT_SSH":         "",
+	}, map[string]string{
+		"lfs.ssh.automultiplex": "false",
+	}))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+	meta.Path = "user/repo"
+
+	_, args := ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-authenticate", "upload", true)
+	assert.Equal(t, []string{
+		"user@foo.com",
+		"git-lfs-authenticate user/repo upload",
+	}, args)
+}
+
+func TestSSHGetLFSExeAndArgsMultiplexPlink(t *testing.T) {
+	plink := filepath.Join("Users", "joebloggs", "bin", "plink.exe")
+
+	cli, err := lfshttp.NewClient(lfshttp.NewContext(nil, map[string]string{
+		"GIT_SSH_COMMAND": "",
+		"GIT_SSH":         plink,
+	}, nil))
+	require.Nil(t, err)
+
+	meta := ssh.SSHMetadata{}
+	meta.UserAndHost = "user@foo.com"
+	meta.Path = "user/repo"
+
+	exe, args := ssh.GetLFSExeAndArgs(cli.OSEnv(), cli.GitEnv(), &meta, "git-lfs-authenticate", "upload", true)
+	assert.Equal(t, plink, exe)
+	assert.Equal(t, []string{
+		"user@foo.com",
+		"git-lfs-authenticate user/repo upload",
+	}, args)
+}
+
 func TestParseBareSSHUrl(t *testing.T) {
 	e := lfshttp.EndpointFromBareSshUrl("git@git-host.com:repo.git")
 	t.Logf("endpoint: %+v", e)
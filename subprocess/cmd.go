@@ -8,29 +8,47 @@ import (
 // Thin wrapper around exec.Cmd. Takes care of pipe shutdown by
 // keeping an internal reference to any created pipes. Whenever
 // Cmd.Wait() is called, all created pipes are closed.
+//
+// If the command runs Git, it counts against the number of Git processes
+// which may run at once, as set by SetMaxGitProcesses, from when it is started
+// until it has been waited for.
 type Cmd struct {
 	*exec.Cmd
 
 	pipes []io.Closer
+
+	limited  bool
+	acquired bool
 }
 
 func (c *Cmd) Run() error {
 	c.trace()
+	c.acquire()
+	defer c.release()
 	return c.Cmd.Run()
 }
 
 func (c *Cmd) Start() error {
 	c.trace()
-	return c.Cmd.Start()
+	c.acquire()
+	err := c.Cmd.Start()
+	if err != nil {
+		c.release()
+	}
+	return err
 }
 
 func (c *Cmd) Output() ([]byte, error) {
 	c.trace()
+	c.acquire()
+	defer c.release()
 	return c.Cmd.Output()
 }
 
 func (c *Cmd) CombinedOutput() ([]byte, error) {
 	c.trace()
+	c.acquire()
+	defer c.release()
 	return c.Cmd.CombinedOutput()
 }
 
@@ -57,9 +75,26 @@ func (c *Cmd) Wait() error {
 		pipe.Close()
 	}
 
+	defer c.release()
 	return c.Cmd.Wait()
 }
 
+func (c *Cmd) acquire() {
+	if c.limited {
+		gitProcesses.acquire()
+		c.acquired = true
+	}
+}
+
+// release gives up the command's place among the running Git processes, if
+// it holds one.
+func (c *Cmd) release() {
+	if c.acquired {
+		c.acquired = false
+		gitProcesses.release()
+	}
+}
+
 func (c *Cmd) trace() {
 	if len(c.Args) > 0 {
 		Trace(c.Args[0], c.Args[1:]...)
@@ -69,6 +104,6 @@ func (c *Cmd) trace() {
 }
 
 func newCmd(cmd *exec.Cmd) *Cmd {
-	wrapped := &Cmd{Cmd: cmd}
+	wrapped := &Cmd{Cmd: cmd, limited: len(cmd.Args) > 0 && isGit(cmd.Args[0])}
 	return wrapped
 }
@@ -0,0 +1,89 @@
+package subprocess
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rubyist/tracerx"
+)
+
+// gitProcesses counts the Git processes started through this package which
+// are running, and bounds how many may run at once.
+var gitProcesses = newProcessLimiter()
+
+// SetMaxGitProcesses sets the number of Git processes started through this
+// package which may run at once, across all goroutines, with any number being
+// allowed if "n" is less than 1.  Starting a Git process beyond that number
+// waits until one of the others has exited.
+func SetMaxGitProcesses(n int) {
+	gitProcesses.setMax(n)
+}
+
+// PeakGitProcesses returns the largest number of Git processes started through
+// this package which have run at once since SetMaxGitProcesses was last
+// called.
+func PeakGitProcesses() int {
+	return gitProcesses.getPeak()
+}
+
+type processLimiter struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	max     int
+	running int
+	peak    int
+}
+
+func newProcessLimiter() *processLimiter {
+	l := &processLimiter{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *processLimiter) setMax(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.max = n
+	l.peak = l.running
+	l.cond.Broadcast()
+}
+
+func (l *processLimiter) getPeak() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.peak
+}
+
+func (l *processLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.max > 0 && l.running >= l.max {
+		tracerx.Printf("exec: waiting for one of %d Git processes to exit", l.running)
+		l.cond.Wait()
+	}
+
+	l.running++
+	if l.running > l.peak {
+		l.peak = l.running
+	}
+	if l.max > 0 {
+		tracerx.Printf("exec: %d of at most %d Git processes running", l.running, l.max)
+	}
+}
+
+func (l *processLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.running--
+	l.cond.Signal()
+}
+
+// isGit returns whether "name" names the Git executable.
+func isGit(name string) bool {
+	return strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".exe") == "git"
+}
@@ -20,7 +20,12 @@ import (
 // stdout & stderr pipes, wrapped in a BufferedCmd. The stdout buffer will be
 // of stdoutBufSize bytes.
 func BufferedExec(name string, args ...string) (*BufferedCmd, error) {
-	cmd := ExecCommand(name, args...)
+	return BufferedStart(ExecCommand(name, args...))
+}
+
+// BufferedStart behaves as BufferedExec, but starts the given command, so that
+// its environment or directory may be changed first.
+func BufferedStart(cmd *Cmd) (*BufferedCmd, error) {
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -1,9 +1,12 @@
 package subprocess
 
 import (
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type ShellQuoteTestCase struct {
@@ -89,3 +92,47 @@ func TestFormatForShell(t *testing.T) {
 		t.Run(desc, c.Assert)
 	}
 }
+
+func TestMaxGitProcesses(t *testing.T) {
+	SetMaxGitProcesses(2)
+	defer SetMaxGitProcesses(0)
+
+	// Each process runs until its standard input is closed.
+	start := func() (*Cmd, io.WriteCloser) {
+		cmd := ExecCommand("git", "hash-object", "--stdin")
+		stdin, err := cmd.StdinPipe()
+		require.Nil(t, err)
+		require.Nil(t, cmd.Start())
+		return cmd, stdin
+	}
+
+	first, firstStdin := start()
+	second, secondStdin := start()
+
+	started := make(chan *Cmd)
+	go func() {
+		third, thirdStdin := start()
+		thirdStdin.Close()
+		started <- third
+	}()
+
+	select {
+	case <-started:
+		t.Fatal("expected a third Git process to wait for another to exit")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Processes other than Git are not counted.
+	other := ExecCommand("sh", "-c", "exit 0")
+	require.Nil(t, other.Run())
+
+	firstStdin.Close()
+	require.Nil(t, first.Wait())
+
+	third := <-started
+	require.Nil(t, third.Wait())
+	secondStdin.Close()
+	require.Nil(t, second.Wait())
+
+	assert.Equal(t, 2, PeakGitProcesses())
+}
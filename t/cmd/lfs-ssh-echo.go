@@ -53,14 +53,20 @@ func checkSufficientArgs(offset int) {
 
 func main() {
 	// expect args:
-	//   lfs-ssh-echo [-p PORT [--]] git@127.0.0.1 "git-lfs-authenticate REPO OPERATION"
-	//   lfs-ssh-echo [-p PORT [--]] git@127.0.0.1 "git-lfs-transfer REPO OPERATION"
+	//   lfs-ssh-echo [-oControlMaster=auto -oControlPath=PATH] [-p PORT [--]] git@127.0.0.1 "git-lfs-authenticate REPO OPERATION"
+	//   lfs-ssh-echo [-oControlMaster=auto -oControlPath=PATH] [-p PORT [--]] git@127.0.0.1 "git-lfs-transfer REPO OPERATION"
 	//   lfs-ssh-echo git@127.0.0.1 "git-upload-pack REPO"
 	//   lfs-ssh-echo git@127.0.0.1 "git-receive-pack REPO"
 	offset := 1
 
+	for len(os.Args) > offset && (strings.HasPrefix(os.Args[offset], "-oControlMaster=") || strings.HasPrefix(os.Args[offset], "-oControlPath=")) {
+		offset += 1
+	}
+
+	port := ""
 	checkSufficientArgs(offset)
 	if os.Args[offset] == "-p" {
+		port = os.Args[offset+1]
 		offset += 2
 	}
 
@@ -94,7 +100,7 @@ func main() {
 	repo := remoteCmd[1]
 
 	r := &sshResponse{
-		Href: fmt.Sprintf("http://127.0.0.1:%s/%s.git/info/lfs", os.Args[2], repo),
+		Href: fmt.Sprintf("http://127.0.0.1:%s/%s.git/info/lfs", port, repo),
 	}
 	switch repo {
 	case "/ssh-expired-absolute":
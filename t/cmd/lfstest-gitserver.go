@@ -60,6 +60,7 @@ var (
 		"status-batch-resume-206", "batch-resume-fail-fallback", "return-expired-action", "return-expired-action-forever", "return-invalid-size",
 		"object-authenticated", "storage-download-retry", "storage-upload-retry", "storage-upload-retry-later", "unknown-oid",
 		"send-verify-action", "send-deprecated-links", "redirect-storage-upload", "storage-compress", "batch-hash-algo-empty", "batch-hash-algo-invalid",
+		"storage-upload-drop-connection", "storage-download-corrupt", "storage-download-wrong-size", "status-storage-head-404", "status-storage-head-405",
 	}
 
 	reqCookieReposRE = regexp.MustCompile(`\A/require-cookie-`)
@@ -181,6 +182,7 @@ type lfsObject struct {
 	Actions       map[string]*lfsLink `json:"actions,omitempty"`
 	Links         map[string]*lfsLink `json:"_links,omitempty"`
 	Err           *lfsError           `json:"error,omitempty"`
+	Present       bool                `json:"present,omitempty"`
 }
 
 type lfsLink struct {
@@ -346,6 +348,7 @@ type batchReq struct {
 	Operation string      `json:"operation"`
 	Objects   []lfsObject `json:"objects"`
 	Ref       *Ref        `json:"ref,omitempty"`
+	Dedup     bool        `json:"dedup,omitempty"`
 }
 
 func (r *batchReq) RefName() string {
@@ -471,6 +474,12 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 			if exists {
 				// not an error but don't add an action
 				addAction = false
+			} else if by, ok := largeObjects.Find(obj.Oid); ok && objs.Dedup {
+				// the content is stored for another repository,
+				// so share it rather than asking for an upload
+				largeObjects.Set(repo, obj.Oid, by)
+				o.Present = true
+				addAction = false
 			}
 		}
 
@@ -700,6 +709,24 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 
 				return
 			}
+		case "storage-upload-drop-connection":
+			// Simulate a network change by dropping the
+			// connection without a response for the first few
+			// attempts.
+			retriesMu.Lock()
+			retryKey := strings.Join([]string{"drop", repo, oid}, ":")
+			retries[retryKey]++
+			drops := retries[retryKey]
+			retriesMu.Unlock()
+
+			if drops <= 2 {
+				if hj, ok := w.(http.Hijacker); ok {
+					if conn, _, err := hj.Hijack(); err == nil {
+						conn.Close()
+						return
+					}
+				}
+			}
 		case "storage-upload-retry-later":
 			if timeLeft, isWaiting := checkRateLimit("storage", "upload", repo, oid); isWaiting {
 				w.Header().Set("Retry-After", strconv.Itoa(timeLeft))
@@ -763,6 +790,34 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 					statusCode = 500
 					by = []byte("malformed content")
 				}
+			} else if string(by) == "storage-download-corrupt" {
+				// Send content which does not match the OID
+				// for the first couple of attempts.
+				retriesMu.Lock()
+				retryKey := strings.Join([]string{"corrupt", repo, oid}, ":")
+				retries[retryKey]++
+				attempts := retries[retryKey]
+				retriesMu.Unlock()
+
+				if attempts <= 2 {
+					by = bytes.ToUpper(by)
+				}
+			} else if string(by) == "storage-download-stall" {
+				// Send part of the object, and then stall until
+				// the client gives up on it.
+				w.Header().Set("Content-Length", strconv.Itoa(len(by)))
+				w.WriteHeader(statusCode)
+				w.Write(by[0:8])
+				w.(http.Flusher).Flush()
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Minute):
+				}
+				return
+			} else if string(by) == "storage-download-wrong-size" {
+				// Send a different object, as if the server had
+				// mixed up its storage, under this one's action.
+				by = append(by, []byte(" of another object")...)
 			} else if len(by) == len("storage-compress") && string(by) == "storage-compress" {
 				if r.Header.Get("Accept-Encoding") != "gzip" {
 					statusCode = 500
@@ -849,6 +904,26 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 
 		w.WriteHeader(404)
 	case "HEAD":
+		// Without the tus.io headers, this checks that an object can
+		// be downloaded, as by `git lfs fsck --remote --remote-head-check`.
+		if len(r.Header.Get("Tus-Resumable")) == 0 {
+			switch oidHandlers[oid] {
+			case "status-storage-head-404":
+				w.WriteHeader(404)
+				return
+			case "status-storage-head-405":
+				w.WriteHeader(405)
+				return
+			}
+			if by, ok := largeObjects.Get(repo, oid); ok {
+				w.Header().Set("Content-Length", strconv.Itoa(len(by)))
+				w.WriteHeader(200)
+				return
+			}
+			w.WriteHeader(404)
+			return
+		}
+
 		// tus.io
 		if !validateTusHeaders(r, id) {
 			w.WriteHeader(400)
@@ -1202,6 +1277,11 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 	dec := json.NewDecoder(r.Body)
 	enc := json.NewEncoder(w)
 
+	if strings.HasSuffix(repo, "locks-unsupported") {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
 	if repo == "netrctest" {
 		user, pass, err := extractAuth(r.Header.Get("Authorization"))
 		if err != nil || (user == "netrcuser" && pass == "badpassretry") {
@@ -1378,7 +1458,9 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 
 			for _, l := range getLocks(repo) {
 				if l.Path == lockRequest.Path {
-					enc.Encode(&LockResponse{Message: "lock already created"})
+					lock := l
+					w.WriteHeader(http.StatusConflict)
+					enc.Encode(&LockResponse{Lock: &lock, Message: "lock already created"})
 					return
 				}
 			}
@@ -1492,6 +1574,19 @@ func (s *lfsStorage) Has(repo, oid string) bool {
 	return ok
 }
 
+// Find returns the contents of the object with the given OID from any
+// repository which has it.
+func (s *lfsStorage) Find(oid string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, repoObjects := range s.objects {
+		if by, ok := repoObjects[oid]; ok {
+			return by, true
+		}
+	}
+	return nil, false
+}
+
 func (s *lfsStorage) Set(repo, oid string, by []byte) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
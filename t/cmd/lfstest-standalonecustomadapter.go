@@ -18,6 +18,16 @@ import (
 
 var backupDir string
 
+// corruptDownloads is set by TEST_STANDALONE_CORRUPT_DOWNLOADS, to simulate
+// objects being corrupted once they have been written to the local store.
+// Each downloaded file is hard linked before it is handed over, and when the
+// adapter is told to terminate it overwrites the start of each through its
+// link, changing the file which Git LFS moved into the store.
+var (
+	corruptDownloads bool
+	downloadLinks    []string
+)
+
 // This test custom adapter just copies the files to a folder.
 func main() {
 	scanner := bufio.NewScanner(os.Stdin)
@@ -29,6 +39,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	corruptDownloads = os.Getenv("TEST_STANDALONE_CORRUPT_DOWNLOADS") != ""
+
 	for _, arg := range os.Args {
 		writeToStderr(fmt.Sprintf("Saw argument %q\n", arg), errWriter)
 	}
@@ -54,6 +66,7 @@ func main() {
 			performUpload(req.Oid, req.Size, req.Path, writer, errWriter)
 		case "terminate":
 			writeToStderr("Terminating test custom adapter gracefully.\n", errWriter)
+			corruptDownloadLinks(errWriter)
 			break
 		}
 	}
@@ -151,6 +164,15 @@ func performDownload(oid string, size int64, writer, errWriter *bufio.Writer) {
 		return
 	}
 
+	if corruptDownloads {
+		link := dlfilename + "-link"
+		if err := os.Link(dlfilename, link); err != nil {
+			sendTransferError(oid, 3, err.Error(), writer, errWriter)
+			return
+		}
+		downloadLinks = append(downloadLinks, link)
+	}
+
 	// completed
 	complete := &transferResponse{"complete", oid, dlfilename, nil}
 	if err := sendResponse(complete, writer, errWriter); err != nil {
@@ -158,6 +180,20 @@ func performDownload(oid string, size int64, writer, errWriter *bufio.Writer) {
 	}
 }
 
+func corruptDownloadLinks(errWriter *bufio.Writer) {
+	for _, link := range downloadLinks {
+		writeToStderr(fmt.Sprintf("Corrupting downloaded file %s\n", link), errWriter)
+		f, err := os.OpenFile(link, os.O_WRONLY, 0)
+		if err != nil {
+			writeToStderr(fmt.Sprintf("Unable to corrupt %s: %v\n", link, err), errWriter)
+			continue
+		}
+		f.WriteAt([]byte("X"), 0)
+		f.Close()
+		os.Remove(link)
+	}
+}
+
 func performUpload(oid string, size int64, fromPath string, writer, errWriter *bufio.Writer) {
 	backupPath := filepath.Join(backupDir, oid)
 	if err := performCopy(oid, fromPath, backupPath, size, writer, errWriter); err != nil {
@@ -605,6 +605,45 @@ setup_single_local_branch_with_annotated_tags() {
   git tag "v1.0.0" -m "v1.0.0"
 }
 
+# setup_single_local_branch_with_tag_only_commit creates a repository as
+# follows:
+#
+#   A
+#   |\
+#   | B
+#   | |
+#   |  \
+#   |   refs/tags/v1.0.0
+#    \
+#     refs/heads/main
+#
+# - Commit 'A' has 1 byte of data in 'a.txt'
+# - Commit 'B' has 2 bytes of data in 'a.txt' and 3 bytes of data in 'b.txt',
+#     and is reachable only from the tag 'v1.0.0'.
+setup_single_local_branch_with_tag_only_commit() {
+  set -e
+
+  reponame="migrate-single-local-branch-tag-only-commit"
+
+  remove_and_create_local_repo "$reponame"
+
+  base64 < /dev/urandom | head -c 1 > a.txt
+
+  git add a.txt
+  git commit -m "initial commit"
+
+  git checkout -b release
+  base64 < /dev/urandom | head -c 2 > a.txt
+  base64 < /dev/urandom | head -c 3 > b.txt
+
+  git add a.txt b.txt
+  git commit -m "release commit"
+  git tag "v1.0.0"
+
+  git checkout main
+  git branch -D release
+}
+
 setup_multiple_remotes() {
   set -e
 
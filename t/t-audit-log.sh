@@ -0,0 +1,90 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "audit log records uploads and downloads"
+(
+  set -e
+
+  reponame="audit-log"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="audit a"
+  contents_b="audit b"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  log="$TRASHDIR/audit.log"
+  git config lfs.auditlog "$log"
+
+  git push origin main
+
+  [ 2 -eq "$(grep -c '"operation":"upload"' "$log")" ]
+  grep '"operation":"upload","oid":"'"$oid_a"'","name":"a.dat","size":7,"remote":"origin","url":"'"$GITSERVER"'/storage/'"$oid_a"'' "$log"
+  grep '"oid":"'"$oid_b"'".*"adapter":"basic","result":"success"' "$log"
+  grep '"time":"[0-9-]*T[0-9:.]*Z"' "$log"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch origin main
+
+  [ 2 -eq "$(grep -c '"operation":"download"' "$log")" ]
+  grep '"operation":"download","oid":"'"$oid_a"'".*"result":"success"' "$log"
+  grep '"operation":"download","oid":"'"$oid_b"'".*"result":"success"' "$log"
+
+  # Each line is a complete entry.
+  [ 4 -eq "$(wc -l < "$log")" ]
+  [ 4 -eq "$(grep -c '^{.*}$' "$log")" ]
+
+  # Credentials are never written.
+  [ 0 -eq "$(grep -c ":pass" "$log")" ]
+  [ 0 -eq "$(grep -c "Authorization" "$log")" ]
+)
+end_test
+
+begin_test "audit log records failed downloads"
+(
+  set -e
+
+  reponame="audit-log-failure"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="missing on server"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  delete_server_object "$reponame" "$oid"
+  rm -rf .git/lfs/objects
+
+  log="$TRASHDIR/audit-failure.log"
+  git -c lfs.auditlog="$log" lfs fetch origin main && exit 1
+
+  grep '"operation":"download","oid":"'"$oid"'".*"result":"failure","error":' "$log"
+)
+end_test
+
+begin_test "audit log is not written when unset"
+(
+  set -e
+
+  reponame="audit-log"
+  cd "$reponame"
+  git config --unset lfs.auditlog
+
+  cp "$TRASHDIR/audit.log" before.log
+  rm -rf .git/lfs/objects
+  git lfs fetch origin main
+
+  cmp before.log "$TRASHDIR/audit.log"
+)
+end_test
@@ -77,3 +77,67 @@ begin_test "batch storage download causes retries"
   popd
 )
 end_test
+
+begin_test "batch storage upload fails after dropped connections"
+(
+  set -e
+
+  reponame="batch-storage-upload-drop"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" batch-storage-repo-upload-drop
+
+  contents="storage-upload-drop-connection"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git config --local lfs.transfer.maxretries 1
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin main\` to fail ..."
+    exit 1
+  fi
+
+  grep "tq: refusing to retry \"$oid\"" push.log
+  [ "0" -eq "$(grep -c "consecutive connection failures" push.log)" ]
+
+  refute_server_object "$reponame" "$oid"
+)
+end_test
+
+begin_test "batch storage upload recovers after dropped connections"
+(
+  set -e
+
+  reponame="batch-storage-upload-drop-recovery"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" batch-storage-repo-upload-drop-recovery
+
+  contents="storage-upload-drop-connection"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git config --local lfs.transfer.maxretries 1
+  git config --local lfs.transfer.networkrecovery true
+  git config --local lfs.transfer.networkrecoveryfailures 2
+  git config --local lfs.transfer.networkrecoverydelay 0
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin main\` to succeed ..."
+    exit 1
+  fi
+
+  grep "tq: 2 consecutive connection failures, reconnecting after 0s" push.log
+
+  assert_server_object "$reponame" "$oid"
+)
+end_test
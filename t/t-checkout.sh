@@ -143,6 +143,61 @@ begin_test "checkout: without clean filter"
 )
 end_test
 
+begin_test "checkout: without .gitattributes"
+(
+  set -e
+
+  reponame="checkout-without-gitattributes"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="something something"
+  printf "%s" "$contents" > file1.dat
+  printf "%s" "$contents" > tracked.dat
+  git add .gitattributes file1.dat tracked.dat
+  git commit -m "add files"
+
+  # Keep tracking only one file, as if the .gitattributes file for the other
+  # had never been committed.
+  echo "tracked.dat filter=lfs diff=lfs merge=lfs -text" > .gitattributes
+  git add .gitattributes
+  git commit -m "untrack file1.dat"
+
+  pointer="$(git cat-file -p HEAD:file1.dat)"
+  rm file1.dat tracked.dat
+  git checkout -- file1.dat tracked.dat
+  [ "$pointer" = "$(cat file1.dat)" ]
+  [ "$contents" = "$(cat tracked.dat)" ]
+
+  git config lfs.checkoutunattributed false
+  rm tracked.dat
+  git lfs checkout 2>&1 | tee checkout.log
+  [ "$pointer" = "$(cat file1.dat)" ]
+  [ "$contents" = "$(cat tracked.dat)" ]
+  grep "warning: skipped 1 Git LFS pointer not tracked by Git LFS in any .gitattributes file:" checkout.log
+  grep "^  file1.dat$" checkout.log
+  grep "use '--unattributed' to check them out anyway" checkout.log
+  [ 0 -eq "$(grep -c "tracked.dat" checkout.log)" ]
+
+  git lfs checkout --unattributed 2>&1 | tee checkout.log
+  [ "$contents" = "$(cat file1.dat)" ]
+  grep "warning: checked out 1 Git LFS file not tracked by Git LFS in any .gitattributes file:" checkout.log
+  grep "^  file1.dat$" checkout.log
+
+  # The contents are not staged in place of the pointer.
+  [ "$pointer" = "$(git cat-file -p :file1.dat)" ]
+  git status --porcelain | tee status.log
+  grep "^ M file1.dat$" status.log
+
+  git config --unset lfs.checkoutunattributed
+  git checkout -- file1.dat
+  git lfs checkout 2>&1 | tee checkout.log
+  [ "$contents" = "$(cat file1.dat)" ]
+  grep "warning: checked out 1 Git LFS file" checkout.log
+)
+end_test
+
 begin_test "checkout: outside git repository"
 (
   set +e
@@ -272,3 +327,88 @@ begin_test "checkout: GIT_WORK_TREE"
   [ "$contents" = "$(cat "$reponame/file1.dat")" ]
 )
 end_test
+
+begin_test "checkout: lfs.checkout.readonlylocked"
+(
+  set -e
+
+  reponame="checkout-readonly-locked"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "ours" > ours.dat
+  echo "theirs" > theirs.dat
+  echo "unlocked" > unlocked.dat
+  git add .gitattributes ours.dat theirs.dat unlocked.dat
+  git commit -m "add files"
+  git push origin main
+
+  # The test server reports locks on paths containing "theirs" as held by
+  # somebody else when they are verified.
+  git lfs lock ours.dat
+  git lfs lock theirs.dat
+  git lfs locks --verify | tee locks.log
+  grep "O ours.dat" locks.log
+  grep "  theirs.dat" locks.log
+
+  git config lfs.checkout.readonlylocked true
+
+  rm ours.dat theirs.dat unlocked.dat
+  git lfs checkout
+
+  [ "theirs" = "$(cat theirs.dat)" ]
+  refute_file_writeable theirs.dat
+  assert_file_writeable ours.dat
+  assert_file_writeable unlocked.dat
+
+  # Without the setting, files locked by others are left writeable.
+  git config --unset lfs.checkout.readonlylocked
+  rm theirs.dat
+  git lfs checkout theirs.dat
+  assert_file_writeable theirs.dat
+)
+end_test
+
+begin_test "checkout: legacy pointer versions"
+(
+  set -e
+
+  reponame="checkout-legacy-pointer-versions"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_alpha="alpha"
+  contents_pre="pre-release"
+  oid_alpha="$(calc_oid "$contents_alpha")"
+  oid_pre="$(calc_oid "$contents_pre")"
+
+  # Store the objects, and commit pointers with the version lines of the
+  # alpha and pre-release versions of Git LFS.
+  printf "%s" "$contents_alpha" | git lfs clean > /dev/null
+  printf "%s" "$contents_pre" | git lfs clean > /dev/null
+  alpha="$(printf "version http://git-media.io/v/2\noid sha256:%s\nsize %d\n" "$oid_alpha" "${#contents_alpha}" | git hash-object -w --stdin)"
+  pre="$(printf "version https://hawser.github.com/spec/v1\noid sha256:%s\nsize %d\n" "$oid_pre" "${#contents_pre}" | git hash-object -w --stdin)"
+  git add .gitattributes
+  git update-index --add --cacheinfo "100644,$alpha,alpha.dat"
+  git update-index --add --cacheinfo "100644,$pre,pre.dat"
+  git commit -m "add legacy pointers"
+  git push origin main
+  assert_server_object "$reponame" "$oid_alpha"
+  assert_server_object "$reponame" "$oid_pre"
+
+  git lfs checkout
+  [ "$contents_alpha" = "$(cat alpha.dat)" ]
+  [ "$contents_pre" = "$(cat pre.dat)" ]
+
+  cd ..
+  clone_repo "$reponame" "$reponame-clone"
+  [ "$contents_alpha" = "$(cat alpha.dat)" ]
+  [ "$contents_pre" = "$(cat pre.dat)" ]
+
+  # The pointers are left as they were committed.
+  [ "$alpha" = "$(git rev-parse HEAD:alpha.dat)" ]
+  [ "$pre" = "$(git rev-parse HEAD:pre.dat)" ]
+)
+end_test
@@ -28,6 +28,33 @@ begin_test "clean a pointer"
 )
 end_test
 
+begin_test "clean a pointer read in pieces"
+(
+  set -e
+  clean_setup "pointer-pieces"
+
+  pointer cd293be6cea034bd45a0352775a219ef5dc7825ce55d1f7dae9762d80ce64411 9 > pointer.txt
+  (head -c 20 pointer.txt; sleep 1; tail -c +21 pointer.txt) | git lfs clean | tee clean.log
+  cmp pointer.txt clean.log
+  [ ! -d .git/lfs/objects ] || [ -z "$(find .git/lfs/objects -type f)" ]
+)
+end_test
+
+begin_test "clean a pointer with extensions configured"
+(
+  set -e
+  clean_setup "pointer-extensions"
+
+  git config lfs.extension.foo.clean "false"
+  git config lfs.extension.foo.smudge "false"
+  git config lfs.extension.foo.priority 0
+
+  pointer cd293be6cea034bd45a0352775a219ef5dc7825ce55d1f7dae9762d80ce64411 9 > pointer.txt
+  git lfs clean < pointer.txt | tee clean.log
+  cmp pointer.txt clean.log
+)
+end_test
+
 begin_test "clean pseudo pointer"
 (
   set -e
@@ -87,3 +114,89 @@ begin_test "clean stdin"
   fi
 )
 end_test
+
+begin_test "clean refuses files above lfs.clean.maxsize"
+(
+  set -e
+
+  reponame="clean-max-size"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git config lfs.clean.maxsize 1KB
+
+  base64 /dev/urandom | head -c 1000 > small.dat
+  base64 /dev/urandom | head -c 2000 > large.dat
+
+  git add small.dat
+  assert_local_object "$(calc_oid_file "small.dat")" 1000
+
+  git add large.dat 2>&1 | tee add.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git add large.dat' to fail"
+    exit 1
+  fi
+  grep "large.dat is 2.0 KB, which is larger than 1.0 KB, the maximum size set by lfs.clean.maxsize" add.log
+  refute_local_object "$(calc_oid_file "large.dat")"
+  [ -z "$(git ls-files large.dat)" ]
+
+  # The size of content read from stdin is not known in advance.
+  git lfs clean unknown.dat < large.dat 2>&1 | tee clean.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs clean' to fail"
+    exit 1
+  fi
+  grep "unknown.dat is larger than 1.0 KB, the maximum size set by lfs.clean.maxsize" clean.log
+
+  git config --unset lfs.clean.maxsize
+  git add large.dat
+  assert_local_object "$(calc_oid_file "large.dat")" 2000
+)
+end_test
+
+begin_test "clean exports objects to lfs.clean.exportdir"
+(
+  set -e
+
+  reponame="clean-export-dir"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git config lfs.clean.exportdir export
+
+  printf "%s" "contents" > a.dat
+  oid="$(calc_oid "contents")"
+  git add a.dat
+
+  # The object is written to both the store and the export directory, and
+  # the pointer gives its size once.
+  assert_local_object "$oid" 8
+  [ "contents" = "$(cat "export/$oid")" ]
+  git cat-file -p ":a.dat" | grep "size 8"
+  [ "1" -eq "$(ls export | wc -l)" ]
+
+  # Objects already in the store are exported too, from "git lfs clean".
+  rm -rf export
+  git lfs clean a.dat < a.dat | grep "oid sha256:$oid"
+  [ "contents" = "$(cat "export/$oid")" ]
+
+  # An absolute path may be given as well.
+  git config lfs.clean.exportdir "$(pwd)/../clean-export-abs"
+  printf "%s" "other" > b.dat
+  git add b.dat
+  [ "other" = "$(cat "../clean-export-abs/$(calc_oid "other")")" ]
+
+  # Pointers are passed through without exporting anything.
+  git config lfs.clean.exportdir pointers-export
+  git cat-file -p ":a.dat" | git lfs clean a.dat | grep "oid sha256:$oid"
+  [ ! -e pointers-export ]
+
+  git config --unset lfs.clean.exportdir
+  printf "%s" "more" > c.dat
+  git add c.dat
+  assert_local_object "$(calc_oid "more")" 4
+  [ ! -e "export/$(calc_oid "more")" ]
+)
+end_test
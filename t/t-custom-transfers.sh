@@ -285,3 +285,48 @@ begin_test "custom-transfer-standalone-urlmatch"
   git lfs fsck
 )
 end_test
+
+begin_test "custom-transfer-stderr"
+(
+  set -e
+
+  # this repo name is the indicator to the server to support custom transfer
+  reponame="test-custom-transfer-stderr"
+  setup_remote_repo "$reponame"
+
+  clone_repo "$reponame" $reponame
+
+  git config lfs.customtransfer.testcustom.path lfstest-customadapter
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.customtransfer.testcustom.stderr pass
+  git push origin main 2>push.err >push.out
+  cat push.err
+  grep "^testcustom: Initialised test custom adapter for upload$" push.err
+  [ 0 -eq "$(grep -c "Initialised test custom adapter" push.out)" ]
+
+  rm -rf .git/lfs/objects
+  git config lfs.customtransfer.testcustom.stderr discard
+  GIT_TRACE=1 GIT_TRANSFER_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  [ ${PIPESTATUS[0]} = "0" ]
+  grep "xfer: started custom adapter process" fetch.log
+  [ 0 -eq "$(grep -c "Initialised test custom adapter" fetch.log)" ]
+  assert_local_object "$(calc_oid "a")" 1
+
+  rm -rf .git/lfs/objects
+  git config lfs.customtransfer.testcustom.stderr log
+  GIT_TRACE=1 GIT_TRANSFER_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  [ ${PIPESTATUS[0]} = "0" ]
+  grep "xfer\[lfstest-customadapter\]: Initialised test custom adapter for download" fetch.log
+  [ 0 -eq "$(grep -c "^testcustom: " fetch.log)" ]
+
+  rm -rf .git/lfs/objects
+  git lfs fetch 2>&1 | tee fetch.log
+  [ ${PIPESTATUS[0]} = "0" ]
+  [ 0 -eq "$(grep -c "Initialised test custom adapter" fetch.log)" ]
+)
+end_test
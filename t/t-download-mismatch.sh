@@ -0,0 +1,149 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+# setup_mismatch_repo pushes an object which the test server sends with the
+# wrong contents for the first couple of downloads, followed by a good one,
+# and clones the repository without fetching any objects.
+setup_mismatch_repo() {
+  local reponame="$1"
+
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "storage-download-corrupt" > a.dat
+  printf "%s" "good" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+
+  git push origin main
+  assert_server_object "$reponame" "$(calc_oid "storage-download-corrupt")"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-assert"
+  cd "$reponame-assert"
+  git config credential.helper lfstest
+}
+
+begin_test "download mismatch: retry by default"
+(
+  set -e
+
+  setup_mismatch_repo "download-mismatch-retry"
+  oid="$(calc_oid "storage-download-corrupt")"
+
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch' to succeed ..."
+    exit 1
+  fi
+
+  [ "2" -eq "$(grep -c "tq: retrying object $oid: .*expected OID $oid" fetch.log)" ]
+  assert_local_object "$oid" 24
+  assert_local_object "$(calc_oid "good")" 4
+  [ ! -d .git/lfs/quarantine ]
+)
+end_test
+
+begin_test "download mismatch: quarantine"
+(
+  set -e
+
+  setup_mismatch_repo "download-mismatch-quarantine"
+  oid="$(calc_oid "storage-download-corrupt")"
+
+  git config lfs.transfer.onmismatch quarantine
+  git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch' to fail ..."
+    exit 1
+  fi
+
+  grep "expected OID $oid" fetch.log
+  grep "the data was quarantined in" fetch.log
+  refute_local_object "$oid"
+  assert_local_object "$(calc_oid "good")" 4
+
+  [ 1 -eq "$(ls .git/lfs/quarantine | wc -l)" ]
+  [ "STORAGE-DOWNLOAD-CORRUPT" = "$(cat .git/lfs/quarantine/$oid-*)" ]
+)
+end_test
+
+begin_test "download mismatch: fail"
+(
+  set -e
+
+  setup_mismatch_repo "download-mismatch-fail"
+  oid="$(calc_oid "storage-download-corrupt")"
+
+  git config lfs.transfer.onmismatch fail
+  git config lfs.concurrenttransfers 1
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch' to fail ..."
+    exit 1
+  fi
+
+  grep "expected OID $oid" fetch.log
+  grep "tq: aborting after an object failed" fetch.log
+  [ 0 -eq "$(grep -c "tq: retrying object $oid" fetch.log)" ]
+  refute_local_object "$oid"
+  [ ! -f ".git/lfs/incomplete/$oid.part" ]
+  [ ! -d .git/lfs/quarantine ]
+)
+end_test
+
+begin_test "download mismatch: wrong size"
+(
+  set -e
+
+  reponame="download-mismatch-wrong-size"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "storage-download-wrong-size" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  oid="$(calc_oid "storage-download-wrong-size")"
+  assert_server_object "$reponame" "$oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-assert"
+  cd "$reponame-assert"
+  git config credential.helper lfstest
+
+  git config lfs.transfer.onmismatch fail
+  git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch' to fail ..."
+    exit 1
+  fi
+
+  grep "expected 27 bytes of $oid, got 45" fetch.log
+  refute_local_object "$oid"
+)
+end_test
+
+begin_test "download mismatch: invalid setting"
+(
+  set -e
+
+  setup_mismatch_repo "download-mismatch-invalid"
+  oid="$(calc_oid "storage-download-corrupt")"
+
+  git config lfs.transfer.onmismatch bogus
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch' to succeed ..."
+    exit 1
+  fi
+
+  grep "warning: ignoring invalid lfs.transfer.onmismatch value \"bogus\"; using \"retry\"" fetch.log
+  [ "2" -eq "$(grep -c "tq: retrying object $oid: .*expected OID $oid" fetch.log)" ]
+  assert_local_object "$oid" 24
+)
+end_test
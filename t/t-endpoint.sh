@@ -0,0 +1,100 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "endpoint with https remote"
+(
+  set -e
+
+  reponame="endpoint-https"
+  git init "$reponame"
+  cd "$reponame"
+  git remote add origin "https://git-server.com/foo/bar"
+
+  git lfs endpoint 2>&1 | tee endpoint.log
+  grep "^Remote=origin$" endpoint.log
+  grep "^Operation=download$" endpoint.log
+  grep "^Endpoint=https://git-server.com/foo/bar.git/info/lfs$" endpoint.log
+  grep "^BatchURL=https://git-server.com/foo/bar.git/info/lfs/objects/batch$" endpoint.log
+  grep "^Access=none$" endpoint.log
+  grep "^AuthScheme=http$" endpoint.log
+  grep "^Source=remote.origin.url (guessed from Git remote URL)$" endpoint.log
+  [ 0 -eq "$(grep -c "SSH=" endpoint.log)" ]
+
+  git config lfs.https://git-server.com/foo/bar.git/info/lfs.access basic
+  git lfs endpoint origin download 2>&1 | tee endpoint.log
+  grep "^Access=basic$" endpoint.log
+)
+end_test
+
+begin_test "endpoint with ssh remote"
+(
+  set -e
+
+  reponame="endpoint-ssh"
+  git init "$reponame"
+  cd "$reponame"
+  git remote add origin "ssh://git@git-server.com:2222/foo/bar.git"
+
+  git lfs endpoint origin 2>&1 | tee endpoint.log
+  grep "^Endpoint=https://git-server.com/foo/bar.git/info/lfs$" endpoint.log
+  grep "^  SSH=git@git-server.com:/foo/bar.git$" endpoint.log
+  grep "^AuthScheme=ssh$" endpoint.log
+  grep "^Source=remote.origin.url (guessed from Git remote URL)$" endpoint.log
+)
+end_test
+
+begin_test "endpoint with scp-like remote"
+(
+  set -e
+
+  reponame="endpoint-scp"
+  git init "$reponame"
+  cd "$reponame"
+  git remote add origin "git@git-server.com:foo/bar.git"
+
+  git lfs endpoint origin 2>&1 | tee endpoint.log
+  grep "^Endpoint=https://git-server.com/foo/bar.git/info/lfs$" endpoint.log
+  grep "^  SSH=git@git-server.com:foo/bar.git$" endpoint.log
+  grep "^AuthScheme=ssh$" endpoint.log
+)
+end_test
+
+begin_test "endpoint with configured urls"
+(
+  set -e
+
+  reponame="endpoint-configured"
+  git init "$reponame"
+  cd "$reponame"
+  git remote add origin "https://git-server.com/foo/bar"
+  git config remote.origin.lfsurl "https://lfs-server.com/foo/bar"
+  git config lfs.pushurl "https://lfs-push-server.com/foo/bar"
+
+  git lfs endpoint origin download 2>&1 | tee endpoint.log
+  grep "^Endpoint=https://lfs-server.com/foo/bar$" endpoint.log
+  grep "^Source=remote.origin.lfsurl (configured)$" endpoint.log
+
+  git lfs endpoint origin upload 2>&1 | tee endpoint.log
+  grep "^Operation=upload$" endpoint.log
+  grep "^Endpoint=https://lfs-push-server.com/foo/bar$" endpoint.log
+  grep "^Source=lfs.pushurl (configured)$" endpoint.log
+)
+end_test
+
+begin_test "endpoint with invalid arguments"
+(
+  set -e
+
+  reponame="endpoint-invalid"
+  git init "$reponame"
+  cd "$reponame"
+  git remote add origin "https://git-server.com/foo/bar"
+
+  git lfs endpoint not-a-remote 2>&1 | tee endpoint.log
+  grep "Invalid remote name" endpoint.log
+
+  git lfs endpoint origin sideways 2>&1 | tee endpoint.log
+  grep "Invalid operation \"sideways\"" endpoint.log
+)
+end_test
@@ -1126,3 +1126,40 @@ EOF
   grep 'warning.*same alias' test.log
 )
 end_test
+
+begin_test "env warns when the endpoint is not on the remote's host"
+(
+  set -e
+  reponame="env-endpoint-host-mismatch"
+  unset_vars
+  git init "$reponame"
+  cd "$reponame"
+  git remote add origin "https://git.example.com/org/repo.git"
+  git remote add other "git@other.example.com:org/repo.git"
+
+  # Endpoints derived from the remotes, or configured on the same host or a
+  # subdomain of it, are expected.
+  git config remote.origin.lfsurl "https://git.example.com:8443/lfs/org/repo"
+  git config remote.other.lfsurl "https://lfs.other.example.com/org/repo"
+  git lfs env 2>env.err >env.log
+  grep "Endpoint=https://git.example.com:8443/lfs/org/repo (auth=none)" env.log
+  [ 0 -eq "$(grep -c "Git LFS endpoint" env.err)" ]
+
+  # A .lfsconfig file copied from another repository points elsewhere.
+  git config --unset remote.origin.lfsurl
+  git config --file=.lfsconfig lfs.url "https://lfs.elsewhere.test/org/repo"
+  git lfs env 2>env.err >env.log
+  cat env.err
+  grep "Endpoint=https://lfs.elsewhere.test/org/repo (auth=none)" env.log
+  grep "warning: lfs.url in .lfsconfig sets the Git LFS endpoint for remote \"origin\" to https://lfs.elsewhere.test/org/repo, which is on lfs.elsewhere.test, but the remote is on git.example.com" env.err
+  grep "warning: lfs.url in .lfsconfig sets the Git LFS endpoint for remote \"other\" to https://lfs.elsewhere.test/org/repo, which is on lfs.elsewhere.test, but the remote is on other.example.com" env.err
+  [ 2 -eq "$(grep -c "Git LFS endpoint" env.err)" ]
+
+  # The same key set in the local configuration is named as such.
+  rm .lfsconfig
+  git config remote.other.lfsurl "https://lfs.elsewhere.test/org/repo"
+  git lfs env 2>env.err >env.log
+  grep "warning: remote.other.lfsurl sets the Git LFS endpoint for remote \"other\" to https://lfs.elsewhere.test/org/repo, which is on lfs.elsewhere.test, but the remote is on other.example.com" env.err
+  [ 1 -eq "$(grep -c "Git LFS endpoint" env.err)" ]
+)
+end_test
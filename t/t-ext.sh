@@ -60,3 +60,99 @@ begin_test "ext"
   [ "$actual" = "$expected" ]
 )
 end_test
+
+begin_test "ext with builtin transforms"
+(
+  set -e
+
+  reponame="ext-builtin-transform"
+  git init "$reponame"
+  cd "$reponame"
+
+  # Mix a command with a builtin transform, which runs after it on clean and
+  # before it on smudge.
+  git config lfs.extension.upper.clean "tr a-z A-Z"
+  git config lfs.extension.upper.smudge "tr A-Z a-z"
+  git config lfs.extension.upper.priority 0
+  git config lfs.extension.gz.clean "builtin:gzip"
+  git config lfs.extension.gz.smudge "builtin:gunzip"
+  git config lfs.extension.gz.priority 1
+
+  git lfs track "*.dat"
+  contents="$(printf 'compressible %.0s' $(seq 1 100))"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  upperOid="$(calc_oid "$(printf "%s" "$contents" | tr a-z A-Z)")"
+  git cat-file -p :a.dat | tee pointer.txt
+  grep "ext-0-upper sha256:$(calc_oid "$contents")" pointer.txt
+  grep "ext-1-gz sha256:$upperOid" pointer.txt
+
+  # The object is stored compressed, under the OID of its stored bytes.
+  oid="$(grep "^oid" pointer.txt | cut -d: -f2)"
+  size="$(grep "^size" pointer.txt | cut -d" " -f2)"
+  [ "$size" -lt "${#contents}" ]
+  assert_local_object "$oid" "$size"
+
+  rm a.dat
+  git checkout -- a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+
+  git config lfs.extension.gz.smudge "builtin:no-such-transform"
+  git lfs smudge a.dat < pointer.txt 2>&1 | tee smudge.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected smudge with an unknown transform to fail"
+    exit 1
+  fi
+  grep "extension 'gz' uses unknown transform 'no-such-transform'" smudge.log
+)
+end_test
+
+begin_test "ext checkout with pointers sharing an oid but not extensions"
+(
+  set -e
+
+  reponame="ext-shared-oid"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.extension.gz.clean "builtin:gzip"
+  git config lfs.extension.gz.smudge "builtin:gunzip"
+  git config lfs.extension.gz.priority 0
+
+  git lfs track "*.dat"
+  contents="$(printf 'compressible %.0s' $(seq 1 100))"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git cat-file -p :a.dat | tee a.pointer
+  grep "ext-0-gz" a.pointer
+  oid="$(grep "^oid" a.pointer | cut -d: -f2)"
+  size="$(grep "^size" a.pointer | cut -d" " -f2)"
+  object=".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+
+  # b.dat refers to the same object as a.dat, but without the extension, so
+  # its contents are the compressed bytes of the object itself.
+  printf "version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %s\n" \
+    "$oid" "$size" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git cat-file -p :b.dat | tee b.pointer
+  [ "0" -eq "$(grep -c "^ext-" b.pointer)" ]
+
+  rm a.dat b.dat
+  git lfs checkout
+
+  [ "$contents" = "$(cat a.dat)" ]
+  cmp b.dat "$object"
+
+  # Each path is smudged with its own pointer's extensions, whichever is
+  # checked out first.
+  rm a.dat b.dat
+  git checkout -- b.dat a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+  cmp b.dat "$object"
+)
+end_test
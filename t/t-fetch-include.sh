@@ -83,3 +83,49 @@ begin_test "fetch: include second matching file"
   assert_local_object "$contents_oid" "8"
 )
 end_test
+
+begin_test "fetch: exclude by extension with lfs.fetchexcludeext"
+(
+  set -e
+
+  reponame="fetch-exclude-ext"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.psd" "*.tga" "*.dat"
+
+  mkdir -p art
+  psd_contents="layered image"
+  tga_contents="texture"
+  dat_contents="plain data"
+  printf "%s" "$psd_contents" > art/cover.psd
+  printf "%s" "$tga_contents" > art/skin.tga
+  printf "%s" "$dat_contents" > data.dat
+
+  git add .gitattributes art data.dat
+  git commit -m "add files"
+  git push origin main
+
+  psd_oid="$(calc_oid "$psd_contents")"
+  tga_oid="$(calc_oid "$tga_contents")"
+  dat_oid="$(calc_oid "$dat_contents")"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git config lfs.fetchexcludeext "psd, .tga"
+  git lfs fetch
+
+  refute_local_object "$psd_oid"
+  refute_local_object "$tga_oid"
+  assert_local_object "$dat_oid" "${#dat_contents}"
+
+  # An explicit --exclude replaces the configured exclusions.
+  git lfs fetch --exclude="*.tga"
+  assert_local_object "$psd_oid" "${#psd_contents}"
+  refute_local_object "$tga_oid"
+
+  git lfs env | grep "FetchExclude=\*.psd, \*.tga"
+)
+end_test
@@ -71,6 +71,23 @@ begin_test "fetch"
 )
 end_test
 
+begin_test "fetch: all objects present"
+(
+  set -e
+  cd clone
+
+  git lfs fetch
+  assert_local_object "$contents_oid" 1
+
+  # No batch request is needed when every object is already present.
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "fetch: Everything up to date" fetch.log
+  grep "tq: sending batch" fetch.log && exit 1
+  grep "api: batch" fetch.log && exit 1
+  true
+)
+end_test
+
 begin_test "fetch (empty file)"
 (
   set -e
@@ -239,6 +256,30 @@ begin_test "fetch with include filter in cli"
 )
 end_test
 
+begin_test "fetch with content from directory"
+(
+  set -e
+  cd clone
+  rm -rf .git/lfs/objects
+
+  # The object for b.dat was deleted from the server above, so it can only be
+  # provided by the content directory.  The file named after a.dat's OID has
+  # the wrong contents and must be ignored in favor of the server's copy.
+  mkdir -p "$TRASHDIR/content/sub"
+  printf "%s" "$b" > "$TRASHDIR/content/sub/exported.bin"
+  printf "%s" "x" > "$TRASHDIR/content/$contents_oid"
+
+  GIT_TRACE=1 git lfs fetch --content-from="$TRASHDIR/content" origin main newbranch 2>&1 | tee fetch.log
+  grep "content directory: importing $b_oid" fetch.log
+  ! grep "content directory: importing $contents_oid" fetch.log
+  assert_local_object "$contents_oid" 1
+  assert_local_object "$b_oid" 1
+
+  git lfs fetch --content-from="$TRASHDIR/content/$contents_oid" 2>&1 | tee fetch.log
+  grep "Invalid content directory" fetch.log
+)
+end_test
+
 begin_test "fetch with exclude filter in cli"
 (
   set -e
@@ -409,6 +450,19 @@ begin_test "fetch-all"
     assert_local_object "${oid[$a]}" "${#content[$a]}"
   done
 
+  # the same objects are fetched however far the scan may run ahead of the
+  # downloads, including when it must finish first
+  for scanbuffer in 100 1
+  do
+    rm -rf .git/lfs/objects
+
+    git -c lfs.fetchscanbuffer=$scanbuffer lfs fetch --all origin
+    for ((a=0; a < NUMFILES ; a++))
+    do
+      assert_local_object "${oid[$a]}" "${#content[$a]}"
+    done
+  done
+
   rm -rf .git/lfs/objects
 
   # fetch all objects reachable from the main branch only
@@ -653,6 +707,767 @@ begin_test "fetch fails when LFS directory has wrong permissions"
   chmod 400 .git/lfs/objects
 
   git lfs fetch 2>&1 | tee fetch.log
-  grep "error trying to create local storage directory" fetch.log
+  grep "Git LFS object store \".*\" is not writable" fetch.log
+)
+end_test
+
+begin_test "fetch fails clearly when the object store is read-only"
+(
+  set -e
+
+  # Windows lacks POSIX permissions.
+  [ "$IS_WINDOWS" -eq 1 ] && exit 0
+
+  # Root is exempt from permissions.
+  [ "$(id -u)" -eq 0 ] && exit 0
+
+  cd shared
+  rm -rf .git/lfs/objects .git/lfs/tmp
+  chmod 555 .git/lfs
+
+  set +e
+  git lfs fetch 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  chmod 755 .git/lfs
+
+  [ "$res" -ne 0 ]
+  grep "Git LFS object store \".*\.git/lfs\" is not writable" fetch.log
+  grep "not mounted read-only" fetch.log
+  refute_local_object "$contents_oid"
+)
+end_test
+
+begin_test "fetch with --report"
+(
+  set -e
+
+  reponame="fetch-report"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "$contents" > a.dat
+  printf "%s" "$b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+  delete_server_object "$reponame" "$b_oid"
+
+  git lfs fetch --report report.json origin main 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs fetch\` to fail ..."
+    exit 1
+  fi
+
+  cat report.json
+  grep "\"oid\":\"$contents_oid\",\"name\":\"a.dat\",\"bytes\":1,[^}]*\"adapter\":\"basic\",[^}]*\"success\":true}" report.json
+  grep "\"oid\":\"$b_oid\",\"name\":\"b.dat\",\"bytes\":0,[^}]*\"success\":false,\"error\":\"[^\"]*\"}" report.json
+
+  assert_local_object "$contents_oid" 1
+  refute_local_object "$b_oid"
+)
+end_test
+
+begin_test "fetch with several refs concurrently"
+(
+  set -e
+
+  reponame="fetch-refs-concurrently"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  c_oid="$(calc_oid "c")"
+  d_oid="$(calc_oid "d")"
+
+  git lfs track "*.dat"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b one
+  printf "%s" "$b" > b.dat
+  printf "c" > c.dat
+  git add b.dat c.dat
+  git commit -m "add b.dat and c.dat"
+
+  git checkout -b two main
+  printf "%s" "$b" > other-b.dat
+  printf "d" > d.dat
+  git add other-b.dat d.dat
+  git commit -m "add other-b.dat and d.dat"
+
+  git checkout -b three main
+  printf "c" > other-c.dat
+  printf "d" > other-d.dat
+  git add other-c.dat other-d.dat
+  git commit -m "add other-c.dat and other-d.dat"
+
+  git push origin main one two three
+
+  rm -rf .git/lfs/objects
+
+  GIT_TRACE=1 git -c lfs.fetchrefsconcurrency=3 lfs fetch --report report.json origin one two three 2>&1 | tee fetch.log
+  grep "Fetching reference refs/heads/one" fetch.log
+  grep "Fetching reference refs/heads/two" fetch.log
+  grep "Fetching reference refs/heads/three" fetch.log
+
+  for oid in "$contents_oid" "$b_oid" "$c_oid" "$d_oid"; do
+    assert_local_object "$oid" 1
+    [ 1 -eq "$(grep -c "fetch .* \[$oid\]" fetch.log)" ]
+    [ 1 -eq "$(grep -o "\"oid\":\"$oid\"" report.json | wc -l)" ]
+  done
+
+  # Each object is named by its path in the first ref which references it.
+  grep "\"oid\":\"$b_oid\",\"name\":\"b.dat\"" report.json
+  grep "\"oid\":\"$c_oid\",\"name\":\"c.dat\"" report.json
+  grep "\"oid\":\"$d_oid\",\"name\":\"d.dat\"" report.json
+)
+end_test
+
+begin_test "fetch with --profile"
+(
+  set -e
+
+  reponame="fetch-profile"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+
+  git lfs fetch --profile origin main 2>profile.log
+  cat profile.log
+  grep "profile: scan: " profile.log
+  grep "profile: batch: .* (1 request)" profile.log
+  grep "profile: transfer: " profile.log
+  grep "profile: total: " profile.log
+  grep "profile: adapter basic: 1 object, 1 B in " profile.log
+  assert_local_object "$contents_oid" 1
+
+  rm -rf .git/lfs/objects
+
+  git lfs fetch --profile --json origin main 2>profile.json
+  cat profile.json
+  for phase in scan transfer total; do
+    grep "{\"name\":\"$phase\",\"duration_ms\":[0-9]*}" profile.json
+  done
+  grep '{"name":"batch","duration_ms":[0-9]*,"count":1}' profile.json
+  grep '"adapters":\[{"name":"basic","objects":1,"bytes":1,"duration_ms":[0-9]*,"bytes_per_second":[0-9]*}\]' profile.json
+
+  git lfs fetch --json origin main 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs fetch --json\` to fail ..."
+    exit 1
+  fi
+  grep -- "--json requires --profile" fetch.log
+)
+end_test
+
+begin_test "fetch --all with objects referenced only by stashes"
+(
+  set -e
+
+  reponame="fetch-all-stashes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  stash_one="stashed one"
+  stash_one_oid="$(calc_oid "$stash_one")"
+  stash_two="stashed two"
+  stash_two_oid="$(calc_oid "$stash_two")"
+
+  printf "%s" "$stash_one" > a.dat
+  git stash
+  printf "%s" "$stash_two" > a.dat
+  git stash
+
+  git lfs push --object-id origin "$stash_one_oid" "$stash_two_oid"
+
+  rm -rf .git/lfs/objects
+
+  git lfs fetch --all origin 2>&1 | tee fetch.log
+  assert_local_object "$stash_one_oid" "${#stash_one}"
+  assert_local_object "$stash_two_oid" "${#stash_two}"
+)
+end_test
+
+begin_test "fetch --all with pathspecs"
+(
+  set -e
+
+  reponame="fetch-all-pathspecs"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p dir other
+  old="old version in dir"
+  old_oid="$(calc_oid "$old")"
+  moved="moved out of dir"
+  moved_oid="$(calc_oid "$moved")"
+  current="current version in dir"
+  current_oid="$(calc_oid "$current")"
+  outside="never in dir"
+  outside_oid="$(calc_oid "$outside")"
+
+  printf "%s" "$old" > dir/a.dat
+  printf "%s" "$moved" > dir/b.dat
+  printf "%s" "$outside" > other/c.dat
+  git add .gitattributes dir other
+  git commit -m "add files"
+
+  printf "%s" "$current" > dir/a.dat
+  git mv dir/b.dat other/b.dat
+  git commit -am "update dir/a.dat and move dir/b.dat"
+  git push origin main
+
+  for scanbuffer in 0 1; do
+    rm -rf .git/lfs/objects
+
+    git -c lfs.fetchscanbuffer=$scanbuffer lfs fetch --all origin -- dir
+    assert_local_object "$old_oid" "${#old}"
+    assert_local_object "$moved_oid" "${#moved}"
+    assert_local_object "$current_oid" "${#current}"
+    refute_local_object "$outside_oid"
+  done
+
+  git lfs fetch origin -- dir 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs fetch\` with pathspecs but no --all to fail ..."
+    exit 1
+  fi
+  grep "Pathspecs may only be given with --all" fetch.log
+
+  git lfs fetch --all origin main -- dir 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs fetch --all\` with refs and pathspecs to fail ..."
+    exit 1
+  fi
+  grep "Cannot combine pathspecs with explicit refs" fetch.log
+)
+end_test
+
+begin_test "fetch with lfs.storage.minfreebytes"
+(
+  set -e
+
+  reponame="fetch-min-free-bytes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+
+  git -c lfs.storage.minfreebytes=100PB lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs fetch\` to fail ..."
+    exit 1
+  fi
+  grep "insufficient disk space" fetch.log
+  refute_local_object "$contents_oid"
+
+  git -c lfs.storage.minfreebytes=1 lfs fetch
+  assert_local_object "$contents_oid" 1
+)
+end_test
+
+begin_test "fetch with commit range"
+(
+  set -e
+
+  reponame="fetch-commit-range"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b side
+  printf "d" > d.dat
+  git add d.dat
+  git commit -m "add d.dat"
+
+  git checkout main
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+
+  git push origin main side
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+  c_oid="$(calc_oid "c")"
+  d_oid="$(calc_oid "d")"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch origin main^..main 2>&1 | tee fetch.log
+  grep "Fetching range main\^\.\.main" fetch.log
+  refute_local_object "$a_oid"
+  refute_local_object "$b_oid"
+  assert_local_object "$c_oid" 1
+  refute_local_object "$d_oid"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch origin side..main
+  refute_local_object "$a_oid"
+  assert_local_object "$b_oid" 1
+  assert_local_object "$c_oid" 1
+  refute_local_object "$d_oid"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch origin side...main
+  refute_local_object "$a_oid"
+  assert_local_object "$b_oid" 1
+  assert_local_object "$c_oid" 1
+  assert_local_object "$d_oid" 1
+
+  git checkout side
+  rm -rf .git/lfs/objects
+  git lfs fetch origin main..
+  refute_local_object "$a_oid"
+  refute_local_object "$b_oid"
+  refute_local_object "$c_oid"
+  assert_local_object "$d_oid" 1
+)
+end_test
+
+begin_test "fetch with recorded and replayed HTTP session"
+(
+  set -e
+
+  reponame="fetch-http-session"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="session contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  recording="$TRASHDIR/$reponame.jsonl"
+
+  rm -rf .git/lfs/objects
+  GIT_LFS_RECORD_HTTP="$recording" git lfs fetch
+  assert_local_object "$contents_oid" "${#contents}"
+
+  grep "\"url\":\"$GITSERVER/$reponame.git/info/lfs/objects/batch\"" "$recording"
+  grep "REDACTED" "$recording"
+  # The credentials for "user:pass" must not be recorded.
+  [ "0" -eq "$(grep -c "dXNlcjpwYXNz" "$recording")" ]
+
+  # The replayed fetch succeeds even though the server no longer has the
+  # object, since nothing is sent to it.
+  delete_server_object "$reponame" "$contents_oid"
+  rm -rf .git/lfs/objects
+  GIT_LFS_REPLAY_HTTP="$recording" git lfs fetch
+  assert_local_object "$contents_oid" "${#contents}"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fetch to fail without the replayed session"
+    exit 1
+  fi
+  refute_local_object "$contents_oid"
+)
+end_test
+
+begin_test "fetch with --oids-from manifest"
+(
+  set -e
+
+  reponame="fetch-oids-from"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat, b.dat"
+  git push origin main
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+
+  git lfs ls-files --manifest="$TRASHDIR/$reponame.manifest" main
+
+  # Objects which are added later are not in the manifest.
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  git push origin main
+  c_oid="$(calc_oid "c")"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch --oids-from="$TRASHDIR/$reponame.manifest" 2>&1 | tee fetch.log
+  grep "Fetching objects listed in $TRASHDIR/$reponame.manifest" fetch.log
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+  refute_local_object "$c_oid"
+
+  rm -rf .git/lfs/objects
+  git lfs fetch --oids-from="$TRASHDIR/$reponame.manifest" --exclude="b.dat"
+  assert_local_object "$a_oid" 1
+  refute_local_object "$b_oid"
+
+  git lfs fetch --oids-from="$TRASHDIR/$reponame.manifest" --all 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --oids-from --all' to fail"
+    exit 1
+  fi
+  grep "Cannot combine --oids-from with --all or --recent" fetch.log
+
+  printf "not a manifest\n" > bad.manifest
+  git lfs fetch --oids-from=bad.manifest 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --oids-from' with an invalid manifest to fail"
+    exit 1
+  fi
+  grep "Could not read manifest \"bad.manifest\"" fetch.log
+)
+end_test
+
+begin_test "fetch objects referenced by notes with lfs.includenotes"
+(
+  set -e
+
+  reponame="fetch-include-notes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "file" > file.dat
+  git add .gitattributes file.dat
+  git commit -m "add file.dat"
+
+  content_note="this data is referenced only by a note"
+  oid_note="$(calc_oid "$content_note")"
+  printf "%s" "$content_note" | git lfs clean > note.txt
+  git notes add -F note.txt HEAD
+
+  git push origin main refs/notes/commits
+  git lfs push origin --object-id "$oid_note"
+  assert_server_object "$reponame" "$oid_note"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git fetch origin "refs/notes/*:refs/notes/*"
+
+  git lfs fetch
+  refute_local_object "$oid_note"
+
+  git config lfs.includenotes true
+  git lfs fetch 2>&1 | tee fetch.log
+  grep "Fetching notes" fetch.log
+  assert_local_object "$oid_note" "${#content_note}"
+)
+end_test
+
+begin_test "fetch with --pointers-from stdin"
+(
+  set -e
+
+  reponame="fetch-pointers-from"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  printf "c" > c.dat
+  git add .gitattributes a.dat b.dat c.dat
+  git commit -m "add a.dat, b.dat, c.dat"
+  git push origin main
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+  c_oid="$(calc_oid "c")"
+
+  rm -rf .git/lfs/objects
+
+  # Two pointer blobs back to back, an OID line, and some invalid entries.
+  (git cat-file -p :a.dat; git cat-file -p :b.dat
+   printf "not a pointer\n"
+   printf "version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 1\n\n"
+   printf "%s 1\n" "$c_oid") > pointers.txt
+
+  git lfs fetch --pointers-from - < pointers.txt 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --pointers-from' with invalid pointers to fail"
+    exit 1
+  fi
+  grep "Fetching objects referenced by pointers on standard input" fetch.log
+  grep "invalid pointer at line 7: expected an OID and a size, got \"not a pointer\"" fetch.log
+  grep "invalid pointer at line 8: Invalid OID: abc" fetch.log
+  grep "2 invalid pointers were not fetched" fetch.log
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+  assert_local_object "$c_oid" 1
+
+  # Only valid pointers succeed from a file.
+  rm -rf .git/lfs/objects
+  git cat-file -p :b.dat > valid.txt
+  git lfs fetch --pointers-from valid.txt 2>&1 | tee fetch.log
+  grep "Fetching objects referenced by pointers in valid.txt" fetch.log
+  refute_local_object "$a_oid"
+  assert_local_object "$b_oid" 1
+
+  git lfs fetch --pointers-from valid.txt --all 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --pointers-from --all' to fail"
+    exit 1
+  fi
+  grep "Cannot combine --pointers-from with --all or --recent" fetch.log
+)
+end_test
+
+begin_test "fetch --index"
+(
+  set -e
+
+  reponame="fetch-index"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b other
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  git push origin main other
+  git checkout main
+
+  # Stage the pointer to b.dat without committing it or fetching its object.
+  rm -rf .git/lfs/objects
+  GIT_LFS_SKIP_SMUDGE=1 git checkout other -- b.dat
+  git diff --cached --name-only | grep "^b.dat$"
+
+  git lfs fetch
+  assert_local_object "$a_oid" 1
+  refute_local_object "$b_oid"
+
+  git lfs fetch --index -X b.dat 2>&1 | tee fetch.log
+  grep "Fetching index" fetch.log
+  refute_local_object "$b_oid"
+
+  git lfs fetch --index
+  assert_local_object "$b_oid" 1
+
+  rm -rf .git/lfs/objects
+  git -c lfs.fetchindex=true lfs fetch
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+
+  git lfs fetch --index --all 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --index --all' to fail"
+    exit 1
+  fi
+  grep "Cannot combine --index with --all, --oids-from, or --pointers-from" fetch.log
+)
+end_test
+
+begin_test "fetch --verify"
+(
+  set -e
+
+  reponame="fetch-verify"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+  git lfs fetch --verify 2>&1 | tee fetch.log
+  [ "0" -eq "${PIPESTATUS[0]}" ]
+  assert_local_object "$(calc_oid "a")" 1
+  assert_local_object "$(calc_oid "b")" 1
+  [ 0 -eq "$(grep -c "does not match its OID" fetch.log)" ]
+)
+end_test
+
+begin_test "fetch --verify detects objects corrupted in the store"
+(
+  set -e
+
+  reponame="fetch-verify-corrupt"
+  setup_remote_repo "$reponame"
+
+  # clone directly, not through lfstest-gitserver
+  clone_repo_url "$REMOTEDIR/$reponame.git" "$reponame"
+
+  git config lfs.customtransfer.testcustom.path lfstest-standalonecustomadapter
+  git config lfs.customtransfer.testcustom.concurrent false
+  git config lfs.standalonetransferagent testcustom
+  export TEST_STANDALONE_BACKUP_PATH="$(pwd)/backup"
+  mkdir -p "$TEST_STANDALONE_BACKUP_PATH"
+
+  git lfs track "*.dat"
+  contents="contents"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  # Without --verify, the corruption goes unnoticed.
+  rm -rf .git/lfs/objects
+  TEST_STANDALONE_CORRUPT_DOWNLOADS=1 git lfs fetch
+  assert_local_object "$oid" 8
+  [ "Xontents" = "$(cat .git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid)" ]
+
+  rm -rf .git/lfs/objects
+  TEST_STANDALONE_CORRUPT_DOWNLOADS=1 git lfs fetch --verify 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --verify' to fail"
+    exit 1
+  fi
+  grep "a.dat ($oid) does not match its OID in the local store after being fetched" fetch.log
+  grep "Moved it to .*/lfs/quarantine/$oid-" fetch.log
+  refute_local_object "$oid"
+
+  # The object is fetched again once it has been quarantined.
+  git lfs fetch --verify
+  assert_local_object "$oid" 8
+  git lfs fsck --objects
+)
+end_test
+
+begin_test "fetch --deadline"
+(
+  set -e
+
+  reponame="fetch-deadline"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "$contents" > a.dat
+  stalled="storage-download-stall"
+  stalled_oid="$(calc_oid "$stalled")"
+  printf "%s" "$stalled" > stalled.dat
+  git add .gitattributes a.dat stalled.dat
+  git commit -m "add a.dat and stalled.dat"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+
+  # The server never finishes sending stalled.dat, so the fetch gives up on
+  # it once the deadline passes, keeping a.dat.
+  start="$(date +%s)"
+  git lfs fetch --deadline 2s origin main 2>&1 | tee fetch.log
+  status="${PIPESTATUS[0]}"
+  if [ "3" -ne "$status" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --deadline' to exit 3, got $status"
+    exit 1
+  fi
+  [ "$(($(date +%s) - start))" -lt 30 ]
+
+  grep "warning: deadline of 2s passed before 1 object was fetched:" fetch.log
+  grep "  $stalled_oid stalled.dat" fetch.log
+  [ "1" -eq "$(grep -c "^  [0-9a-f]\{64\} " fetch.log)" ]
+  assert_local_object "$contents_oid" 1
+  refute_local_object "$stalled_oid"
+
+  # Without a deadline, nothing is listed.
+  delete_server_object "$reponame" "$stalled_oid"
+  git lfs fetch origin main 2>&1 | tee fetch.log || true
+  grep "warning: deadline" fetch.log && exit 1
+
+  git lfs fetch --deadline -1s 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs fetch --deadline -1s' to fail"
+    exit 1
+  fi
+  grep -- "--deadline must not be negative" fetch.log
+)
+end_test
+
+begin_test "fetch with lfs.maxsubprocesses"
+(
+  set -e
+
+  reponame="fetch-max-subprocesses"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.maxsubprocesses 5
+  git config lfs.catfileworkers 2
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "track *.dat"
+  for branch in one two three; do
+    git checkout -b "$branch" main
+    for i in 1 2 3; do
+      printf "%s" "$branch $i" > "$branch-$i.dat"
+    done
+    git add "$branch"-*.dat
+    git commit -m "add $branch"
+  done
+
+  GIT_TRACE=1 git push origin one two three 2>&1 | tee push.log
+  grep "exec: [0-9]* of at most 5 Git processes running" push.log
+  grep "Uploading LFS objects: 100% (9/9)" push.log
+
+  rm -rf .git/lfs/objects
+
+  GIT_TRACE=1 git -c lfs.fetchrefsconcurrency=3 lfs fetch origin one two three 2>&1 | tee fetch.log
+  # Three scans at once, each with two cat-file workers, need more than 5.
+  grep "exec: [0-9]* of at most 11 Git processes running" fetch.log
+
+  for log in push.log fetch.log; do
+    over="$(sed -n "s/.*exec: \([0-9]*\) of at most \([0-9]*\) Git processes running.*/\1 \2/p" "$log" |
+      awk '$1 > $2')"
+    [ -z "$over" ]
+  done
+
+  for branch in one two three; do
+    for i in 1 2 3; do
+      assert_local_object "$(calc_oid "$branch $i")" "$((${#branch} + 2))"
+    done
+  done
 )
 end_test
@@ -137,3 +137,105 @@ begin_test "filter process: hash-object --stdin --path does not hang"
   git add .
 )
 end_test
+
+begin_test "filter process: retries failed smudge downloads"
+(
+  set -e
+
+  reponame="filter-process-smudge-retries"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  # The test server fails the first two downloads of this object.
+  contents="storage-download-retry"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+  assert_server_object "$reponame" "$oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  refute_local_object "$oid"
+
+  # Both downloads made by the transfer queue fail, so the blob can only be
+  # smudged by the filter process starting the download again.  "git cat-file
+  # --filters" does not let the filter delay the smudge.
+  git config lfs.transfer.maxretries 1
+  git config lfs.smudge.retries 2
+  git config lfs.smudge.retrydelay 0
+
+  GIT_TRACE=1 git cat-file --filters HEAD:a.dat >smudged 2>cat-file.log
+  [ "$contents" = "$(cat smudged)" ]
+  grep "smudge: retrying download of a.dat ($oid)" cat-file.log
+  assert_local_object "$oid" "${#contents}"
+)
+end_test
+
+begin_test "filter process: fails smudge download without smudge retries"
+(
+  set -e
+
+  reponame="filter-process-smudge-no-retries"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="storage-download-retry"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git config lfs.transfer.maxretries 1
+
+  git cat-file --filters HEAD:a.dat 2>&1 | tee cat-file.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected smudge to fail without smudge retries"
+    exit 1
+  fi
+  grep "Error downloading object: a.dat" cat-file.log
+  refute_local_object "$oid"
+)
+end_test
+
+begin_test "filter process: does not retry smudge downloads which cannot succeed"
+(
+  set -e
+
+  reponame="filter-process-smudge-no-retry-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="missing on the server"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+  delete_server_object "$reponame" "$oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git config lfs.smudge.retries 2
+  git config lfs.smudge.retrydelay 0
+
+  GIT_TRACE=1 git cat-file --filters HEAD:a.dat >smudged 2>cat-file.log && exit 1
+  [ 0 -eq "$(grep -c "smudge: retrying download" cat-file.log)" ]
+  refute_local_object "$oid"
+)
+end_test
@@ -98,6 +98,36 @@ begin_test "fsck dry run"
 )
 end_test
 
+begin_test "fsck reports many corrupt objects in order"
+(
+  set -e
+
+  reponame="fsck-many-objects"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  for i in $(seq 10 49); do
+    echo "test data $i" > "file$i.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add many files"
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck --objects)" ]
+
+  expected=""
+  for i in 12 23 31 47; do
+    oid=$(calc_oid_file "file$i.dat")
+    echo "CORRUPTION" >> ".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+    expected="$expected${expected:+
+}objects: corruptObject: file$i.dat ($oid) is corrupt"
+  done
+
+  [ "$expected" = "$(git lfs fsck --objects --dry-run)" ]
+  [ "$expected" = "$(git lfs fsck --objects --dry-run)" ]
+)
+end_test
+
 begin_test "fsck does not fail with shell characters in paths"
 (
   set -e
@@ -288,3 +318,592 @@ begin_test "fsck operates on specified refs"
   true
 )
 end_test
+
+begin_test "fsck --remote --all"
+(
+  set -e
+
+  reponame="fsck-remote-all"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "old" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "first commit"
+
+  printf "new" > a.dat
+  git add a.dat
+  git commit -m "second commit"
+
+  git checkout -b other
+  printf "branch" > b.dat
+  git add b.dat
+  git commit -m "branch commit"
+  git checkout main
+
+  git push origin main other
+
+  oldOid="$(calc_oid "old")"
+  newOid="$(calc_oid "new")"
+  branchOid="$(calc_oid "branch")"
+
+  git lfs fsck --remote --all 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  delete_server_object "$reponame" "$oldOid"
+  delete_server_object "$reponame" "$branchOid"
+
+  # Only HEAD is checked without --all, and it has only the new object.
+  git lfs fsck --remote 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  git lfs fsck --remote --all 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --remote --all to fail"
+    exit 1
+  fi
+  grep "remote: missingObject: a.dat ($oldOid) is missing on remote \"origin\"" fsck.log
+  grep "remote: missingObject: b.dat ($branchOid) is missing on remote \"origin\"" fsck.log
+  [ "0" -eq "$(grep -c "$newOid" fsck.log)" ]
+
+  expected="{\"remote\":\"origin\",\"missing\":[{\"name\":\"a.dat\",\"oid\":\"$oldOid\",\"size\":3},{\"name\":\"b.dat\",\"oid\":\"$branchOid\",\"size\":6}]}"
+  git lfs fsck --remote --all --json > fsck.json && exit 1
+  [ "$expected" = "$(cat fsck.json)" ]
+
+  # The local objects are all intact, so the other checks still pass.
+  git lfs fsck --objects --pointers
+)
+end_test
+
+begin_test "fsck --remote --remote-head-check"
+(
+  set -e
+
+  reponame="fsck-remote-head"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "present" > a.dat
+  printf "status-storage-head-404" > b.dat
+  printf "status-storage-head-405" > c.dat
+  printf "deleted" > d.dat
+  git add .gitattributes *.dat
+  git commit -m "add objects"
+  git push origin main
+
+  aOid="$(calc_oid "present")"
+  bOid="$(calc_oid "status-storage-head-404")"
+  cOid="$(calc_oid "status-storage-head-405")"
+  dOid="$(calc_oid "deleted")"
+
+  delete_server_object "$reponame" "$dOid"
+
+  # The batch response offers b.dat, so only the HEAD request finds that it
+  # is missing.
+  git lfs fsck --remote 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --remote to fail"
+    exit 1
+  fi
+  grep "remote: missingObject: d.dat ($dOid) is missing on remote \"origin\"" fsck.log
+  [ "0" -eq "$(grep -c "$bOid" fsck.log)" ]
+
+  # c.dat is not checked with HEAD, so the batch response is relied on.
+  GIT_TRACE=1 git lfs fsck --remote --remote-head-check 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --remote --remote-head-check to fail"
+    exit 1
+  fi
+  grep "remote: missingObject: b.dat ($bOid) is missing on remote \"origin\"" fsck.log
+  grep "remote: missingObject: d.dat ($dOid) is missing on remote \"origin\"" fsck.log
+  grep "tq: HEAD $cOid: not supported, relying on batch response" fsck.log
+  [ "0" -eq "$(grep -c "missingObject: a.dat" fsck.log)" ]
+  [ "0" -eq "$(grep -c "missingObject: c.dat" fsck.log)" ]
+
+  git lfs fsck --remote-head-check 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --remote-head-check to fail"
+    exit 1
+  fi
+  grep "Cannot use --remote-head-check without --remote" fsck.log
+)
+end_test
+
+begin_test "fsck --all and --json require --remote"
+(
+  set -e
+
+  reponame="fsck-remote-options"
+  git init "$reponame"
+  cd "$reponame"
+
+  git commit --allow-empty -m "initial commit"
+
+  git lfs fsck --all 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --all to fail"
+    exit 1
+  fi
+  grep "Cannot use --all without --remote" fsck.log
+
+  git lfs fsck --json 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --json to fail"
+    exit 1
+  fi
+  grep "Cannot use --json without --remote or --head" fsck.log
+
+  git lfs fsck --remote --all HEAD 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --remote --all HEAD to fail"
+    exit 1
+  fi
+  grep "Cannot use --all with explicit revisions" fsck.log
+)
+end_test
+
+begin_test "fsck --attrs detects pointers not tracked by .gitattributes"
+(
+  set -e
+
+  reponame="fsck-attrs"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p sub
+  printf "a" > a.dat
+  printf "b" > sub/b.dat
+  git add .gitattributes a.dat sub/b.dat
+  git commit -m "tracked pointers"
+
+  git lfs fsck --attrs 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  # Commit a pointer at a path no pattern tracks, and one at a path whose
+  # pattern is overridden by a nested .gitattributes file.
+  printf "c" | git lfs clean > c.bin
+  git add c.bin
+  printf "*.dat -filter\n" > sub/.gitattributes
+  git add sub/.gitattributes
+  git commit -m "untracked pointers"
+
+  git lfs fsck --attrs 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --attrs to fail"
+    exit 1
+  fi
+  [ "2" -eq "$(grep -c "attrs: untrackedPointer:" fsck.log)" ]
+  grep 'attrs: untrackedPointer: "c.bin" (blob .*) is a pointer, but no pattern in .gitattributes tracks it' fsck.log
+  grep 'attrs: untrackedPointer: "sub/b.dat"' fsck.log
+  [ "0" -eq "$(grep -c "a.dat" fsck.log)" ]
+
+  # Earlier revisions are checked against their own .gitattributes.
+  git lfs fsck --attrs HEAD~1 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  git lfs fsck --remote --json --attrs 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --remote --json --attrs to fail"
+    exit 1
+  fi
+  grep "Cannot use --json with --objects, --pointers, --attrs, --consistency, or --legacy-store" fsck.log
+)
+end_test
+
+begin_test "fsck --consistency detects files committed against their tracking"
+(
+  set -e
+
+  reponame="fsck-consistency"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "plain" > a.txt
+  : > empty.dat
+  git add .gitattributes a.dat a.txt empty.dat
+  git commit -m "consistent files"
+
+  git lfs fsck --consistency 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  # Commit the contents of a tracked file as if the clean filter were not
+  # installed, and a pointer at a path which is not tracked.
+  mkdir sub
+  blob="$(printf "raw contents" | git hash-object -w --no-filters --stdin)"
+  git update-index --add --cacheinfo 100644 "$blob" sub/raw.dat
+  printf "c" | git lfs clean > c.bin
+  git add c.bin
+  git commit -m "inconsistent files"
+
+  git lfs fsck --consistency 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --consistency to fail"
+    exit 1
+  fi
+  [ "2" -eq "$(grep -c "^consistency: " fsck.log)" ]
+  grep "consistency: unconvertedFile: \"sub/raw.dat\" (blob $blob) is tracked by Git LFS, but was committed as its contents rather than a pointer" fsck.log
+  grep 'consistency: unexpectedPointer: "c.bin" (blob .*) is a pointer, but is not tracked by Git LFS' fsck.log
+  [ "0" -eq "$(grep -c "a.dat\|a.txt\|empty.dat" fsck.log)" ]
+
+  # Only this check is performed unless others are asked for.
+  [ "0" -eq "$(grep -c "^pointer: " fsck.log)" ]
+
+  git lfs fsck --consistency HEAD~1 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+)
+end_test
+
+begin_test "fsck checks a single file"
+(
+  set -e
+
+  reponame="fsck-file"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir sub
+  printf "a" > sub/a.dat
+  git add .gitattributes sub/a.dat
+  git commit -m "add sub/a.dat"
+
+  oid="$(calc_oid "a")"
+  path=".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+
+  git lfs fsck -- sub/a.dat 2>&1 | tee fsck.log
+  [ "$(printf 'pointer: ok\ntracked: ok\nobject: ok\nhash: ok\nworktree: ok\nGit LFS fsck OK')" = "$(cat fsck.log)" ]
+
+  # Paths are relative to the current directory.
+  (cd sub && git lfs fsck -- a.dat) | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  # A modified file in the working tree.
+  printf "modified" > sub/a.dat
+  git lfs fsck -- sub/a.dat 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of modified file to fail"
+    exit 1
+  fi
+  grep "worktree: failed: file does not match object $oid" fsck.log
+  grep "hash: ok" fsck.log
+
+  # Other revisions are not compared with the working tree.
+  git lfs fsck HEAD -- sub/a.dat | grep "worktree: failed"
+  git commit --allow-empty -m "empty"
+  git lfs fsck HEAD~1 -- sub/a.dat 2>&1 | tee fsck.log
+  grep "worktree: skipped: revision is not checked out" fsck.log
+  grep "Git LFS fsck OK" fsck.log
+  git checkout -- sub/a.dat
+
+  # A corrupt local object.
+  printf "b" > "$path"
+  git lfs fsck --dry-run -- sub/a.dat 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of corrupt object to fail"
+    exit 1
+  fi
+  grep "hash: failed: object $oid does not hash to its oid" fsck.log
+  [ "b" = "$(cat "$path")" ]
+
+  # A missing object, with no remote to fetch it from.
+  rm "$path"
+  git lfs fsck -- sub/a.dat 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of missing object to fail"
+    exit 1
+  fi
+  grep "object: failed: object $oid is not present locally" fsck.log
+  grep "hash: skipped: object is not present locally" fsck.log
+
+  # A pointer which is not tracked, and contents which are not a pointer.
+  printf "c" | git lfs clean > c.bin
+  blob="$(printf "raw contents" | git hash-object -w --no-filters --stdin)"
+  git update-index --add --cacheinfo 100644 "$blob" raw.dat
+  git add c.bin
+  git commit -m "inconsistent files"
+
+  git lfs fsck -- c.bin 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of untracked pointer to fail"
+    exit 1
+  fi
+  grep "pointer: ok" fsck.log
+  grep "tracked: failed: no pattern in .gitattributes tracks it" fsck.log
+
+  git lfs fsck -- raw.dat 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of raw file to fail"
+    exit 1
+  fi
+  grep "pointer: failed: not a valid pointer" fsck.log
+  grep "tracked: ok" fsck.log
+  grep "object: skipped: no valid pointer" fsck.log
+
+  git lfs fsck -- missing.dat 2>&1 | tee fsck.log
+  grep "\"missing.dat\" is not in revision" fsck.log
+
+  git lfs fsck --objects -- sub/a.dat 2>&1 | tee fsck.log
+  grep "Cannot use --objects, --pointers, --attrs, --consistency, --remote, --all, --json, --remote-head-check, --legacy-store, or --head with a path" fsck.log
+  git lfs fsck HEAD~1..HEAD -- sub/a.dat 2>&1 | tee fsck.log
+  grep "Cannot use a range of revisions with a path" fsck.log
+  git lfs fsck -- sub/a.dat c.bin 2>&1 | tee fsck.log
+  grep "Only one path may be given" fsck.log
+)
+end_test
+
+begin_test "fsck checks that a single file's object can be fetched"
+(
+  set -e
+
+  reponame="fsck-file-remote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+  git push origin main
+
+  aOid="$(calc_oid "a")"
+  bOid="$(calc_oid "b")"
+  delete_server_object "$reponame" "$bOid"
+  rm -rf .git/lfs/objects
+
+  git lfs fsck -- a.dat 2>&1 | tee fsck.log
+  grep "object: ok: object $aOid is not present locally, but can be fetched from remote \"origin\"" fsck.log
+  grep "worktree: ok" fsck.log
+  grep "Git LFS fsck OK" fsck.log
+  refute_local_object "$aOid"
+
+  git lfs fsck -- b.dat 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of missing object to fail"
+    exit 1
+  fi
+  grep "object: failed: object $bOid is present neither locally nor on remote \"origin\"" fsck.log
+)
+end_test
+
+begin_test "fsck --legacy-store moves objects from legacy layouts"
+(
+  set -e
+
+  reponame="fsck-legacy-store"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  aOid="$(calc_oid "a")"
+  bOid="$(calc_oid "b")"
+
+  # Move one object into the sharded layout of ".git/media", and the other
+  # into its flat layout.
+  mkdir -p ".git/media/${aOid:0:2}/${aOid:2:2}"
+  mv ".git/lfs/objects/${aOid:0:2}/${aOid:2:2}/$aOid" ".git/media/${aOid:0:2}/${aOid:2:2}/$aOid"
+  mv ".git/lfs/objects/${bOid:0:2}/${bOid:2:2}/$bOid" ".git/media/$bOid"
+
+  git lfs fsck --objects 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of legacy objects to fail"
+    exit 1
+  fi
+  grep "objects: legacyObject: $aOid is stored in a legacy layout" fsck.log
+  grep "objects: legacyObject: $bOid is stored in a legacy layout" fsck.log
+
+  git lfs fsck --legacy-store --dry-run 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of legacy objects to fail"
+    exit 1
+  fi
+  grep "legacy: legacyObject: $aOid is stored in a legacy layout at .*media/${aOid:0:2}/${aOid:2:2}/$aOid" fsck.log
+  grep "legacy: legacyObject: $bOid is stored in a legacy layout at .*media/$bOid" fsck.log
+  [ "0" -eq "$(grep -c "legacy: repair" fsck.log)" ]
+  refute_local_object "$aOid"
+  refute_local_object "$bOid"
+
+  git lfs fsck --legacy-store --objects 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck of legacy objects to fail"
+    exit 1
+  fi
+  grep "legacy: repair: moving legacy objects to" fsck.log
+  [ "0" -eq "$(grep -c "objects:" fsck.log)" ]
+  assert_local_object "$aOid" 1
+  assert_local_object "$bOid" 1
+  [ ! -d .git/media ]
+
+  git lfs fsck --legacy-store 2>&1 | tee fsck.log
+  grep "Git LFS fsck OK" fsck.log
+)
+end_test
+
+begin_test "fsck --head passes a healthy repository"
+(
+  set -e
+
+  reponame="fsck-health-ok"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir sub
+  printf "a" > a.dat
+  printf "b" > sub/b.dat
+  : > empty.dat
+  printf "plain" > plain.txt
+  git add .gitattributes a.dat sub/b.dat empty.dat plain.txt
+  git commit -m "add files"
+
+  git lfs fsck --head 2>&1 | tee fsck.log
+  grep "health: summary: tracked: 3, ok: 3, fetchable: 0, unconverted: 0, invalid: 0, non-canonical: 0, missing: 0, corrupt: 0" fsck.log
+  grep "Git LFS fsck OK" fsck.log
+  [ "0" -eq "$(grep -c "plain.txt" fsck.log)" ]
+
+  # Objects which are only on the remote are available, and are not
+  # downloaded to check them.
+  git push origin main
+  rm -rf .git/lfs/objects
+  git lfs fsck --head 2>&1 | tee fsck.log
+  grep "health: summary: tracked: 3, ok: 3, fetchable: 2, unconverted: 0, invalid: 0, non-canonical: 0, missing: 0, corrupt: 0" fsck.log
+  grep "Git LFS fsck OK" fsck.log
+  refute_local_object "$(calc_oid "a")"
+
+  head="$(git rev-parse HEAD)"
+  expected="{\"revision\":\"$head\",\"remote\":\"origin\",\"ok\":true,\"summary\":{\"tracked\":3,\"ok\":3,\"fetchable\":2,\"unconverted\":0,\"invalid\":0,\"nonCanonical\":0,\"missing\":0,\"corrupt\":0},\"problems\":[]}"
+  git lfs fsck --head --json > fsck.json
+  [ "$expected" = "$(cat fsck.json)" ]
+)
+end_test
+
+begin_test "fsck --head detects unhealthy files"
+(
+  set -e
+
+  reponame="fsck-health-problems"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "good" > good.dat
+  printf "corrupt" > corrupt.dat
+  printf "missing" > missing.dat
+  git add .gitattributes good.dat corrupt.dat missing.dat
+  git commit -m "add files"
+  git push origin main
+
+  # Commit the contents of a tracked file as if the clean filter were not
+  # installed, a pointer which cannot be parsed, and a pointer which is not
+  # canonical, all without the filters.
+  rawBlob="$(printf "raw contents" | git hash-object -w --no-filters --stdin)"
+  invalidBlob="$(printf "version https://git-lfs.github.com/spec/v1\noid sha256:xyz\nsize 1\n" | git hash-object -w --no-filters --stdin)"
+  crlfBlob="$(git cat-file blob :good.dat | awk '{ sub(/$/, "\r"); print }' | git hash-object -w --no-filters --stdin)"
+  git update-index --add --cacheinfo 100644 "$rawBlob" raw.dat
+  git update-index --add --cacheinfo 100644 "$invalidBlob" invalid.dat
+  git update-index --add --cacheinfo 100644 "$crlfBlob" crlf.dat
+  git commit -m "add unhealthy files"
+
+  corruptOid="$(calc_oid "corrupt")"
+  missingOid="$(calc_oid "missing")"
+  printf "bad" > ".git/lfs/objects/${corruptOid:0:2}/${corruptOid:2:2}/$corruptOid"
+  rm ".git/lfs/objects/${missingOid:0:2}/${missingOid:2:2}/$missingOid"
+  delete_server_object "$reponame" "$missingOid"
+
+  git lfs fsck --head 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --head to fail"
+    exit 1
+  fi
+  [ "5" -eq "$(grep -c "^health: [a-zA-Z]*: \"" fsck.log)" ]
+  grep "health: corruptObject: \"corrupt.dat\" ($corruptOid) is corrupt" fsck.log
+  grep "health: nonCanonicalPointer: \"crlf.dat\" (blob $crlfBlob) is a pointer for $(calc_oid "good"), but is not canonical" fsck.log
+  grep "health: invalidPointer: \"invalid.dat\" (blob $invalidBlob) is not a valid pointer" fsck.log
+  grep "health: missingObject: \"missing.dat\" ($missingOid) is present neither locally nor on remote \"origin\"" fsck.log
+  grep "health: unconvertedFile: \"raw.dat\" (blob $rawBlob) is tracked by Git LFS, but was committed as its contents rather than a pointer" fsck.log
+  grep "health: summary: tracked: 6, ok: 1, fetchable: 0, unconverted: 1, invalid: 1, non-canonical: 1, missing: 1, corrupt: 1" fsck.log
+  [ "0" -eq "$(grep -c "good.dat\|Git LFS fsck OK" fsck.log)" ]
+
+  # Corrupt objects are reported, but never moved.
+  [ "bad" = "$(cat ".git/lfs/objects/${corruptOid:0:2}/${corruptOid:2:2}/$corruptOid")" ]
+
+  git lfs fsck --head --json > fsck.json && exit 1
+  grep "\"ok\":false" fsck.json
+  grep "\"summary\":{\"tracked\":6,\"ok\":1,\"fetchable\":0,\"unconverted\":1,\"invalid\":1,\"nonCanonical\":1,\"missing\":1,\"corrupt\":1}" fsck.json
+  grep "{\"kind\":\"missingObject\",\"path\":\"missing.dat\",\"blob\":\"[0-9a-f]*\",\"oid\":\"$missingOid\"," fsck.json
+  grep "{\"kind\":\"unconvertedFile\",\"path\":\"raw.dat\",\"blob\":\"$rawBlob\",\"message\":" fsck.json
+
+  # The previous revision only has the files which were pushed.
+  git lfs fsck --head HEAD~1 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --head HEAD~1 to fail"
+    exit 1
+  fi
+  grep "health: summary: tracked: 3, ok: 1, fetchable: 0, unconverted: 0, invalid: 0, non-canonical: 0, missing: 1, corrupt: 1" fsck.log
+)
+end_test
+
+begin_test "fsck --head without a remote"
+(
+  set -e
+
+  reponame="fsck-health-no-remote"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  oid="$(calc_oid "a")"
+  rm ".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+
+  git lfs fsck --head >fsck.log 2>fsck.err && exit 1
+  cat fsck.log fsck.err
+  grep "health: missingObject: \"a.dat\" ($oid) is not present locally, and remote \"origin\" could not be checked" fsck.log
+  grep "health: summary: tracked: 1, ok: 0, fetchable: 0, unconverted: 0, invalid: 0, non-canonical: 0, missing: 1, corrupt: 0" fsck.log
+  [ -s fsck.err ]
+)
+end_test
+
+begin_test "fsck --head options"
+(
+  set -e
+
+  reponame="fsck-health-options"
+  git init "$reponame"
+  cd "$reponame"
+
+  git commit --allow-empty -m "initial commit"
+  git commit --allow-empty -m "second commit"
+
+  git lfs fsck --head 2>&1 | tee fsck.log
+  grep "health: summary: tracked: 0, ok: 0" fsck.log
+  grep "Git LFS fsck OK" fsck.log
+
+  git lfs fsck --head --remote 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --head --remote to fail"
+    exit 1
+  fi
+  grep "Cannot use --head with --objects, --pointers, --attrs, --consistency, --remote, --all, --remote-head-check, or --legacy-store" fsck.log
+
+  git lfs fsck --head HEAD~1..HEAD 2>&1 | tee fsck.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fsck --head with a range to fail"
+    exit 1
+  fi
+  grep "Cannot use a range of revisions with --head" fsck.log
+)
+end_test
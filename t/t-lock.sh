@@ -190,7 +190,28 @@ begin_test "locking a previously locked file"
   id=$(assert_lock lock.json b.dat)
   assert_server_lock "$reponame" "$id"
 
-  grep "lock already created" <(git lfs lock "b.dat" 2>&1)
+  git lfs lock --json "b.dat" | tee relock.json
+  [ "$id" = "$(assert_lock relock.json b.dat)" ]
+)
+end_test
+
+begin_test "locking a file locked by another user"
+(
+  set -e
+
+  reponame="lock_create_previously_created_theirs"
+  setup_remote_repo_with_file "$reponame" "theirs.dat"
+
+  git lfs lock --json "theirs.dat" | tee lock.json
+  id=$(assert_lock lock.json theirs.dat)
+  assert_server_lock "$reponame" "$id"
+
+  git lfs lock "theirs.dat" 2>&1 | tee relock.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs lock\` to fail ..."
+    exit 1
+  fi
+  grep "lock already created" relock.log
 )
 end_test
 
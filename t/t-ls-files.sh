@@ -319,6 +319,60 @@ begin_test "ls-files: history with --all"
 )
 end_test
 
+begin_test "ls-files: history with --all names objects only reachable in old commits"
+(
+  set -e
+
+  reponame="ls-files-history-with-all-historical-names"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track '*.dat'
+  mkdir dir
+  printf "a" > a.dat
+  printf "b" > dir/b.dat
+
+  git add .gitattributes a.dat dir/b.dat
+  git commit -m "initial commit"
+
+  # Tag the pointer blob itself, so that "git rev-list --objects --all" lists
+  # it without a path before walking the commit in which it was added.
+  git tag b-blob "$(git rev-parse HEAD:dir/b.dat)"
+
+  git rm dir/b.dat
+  git commit -m "remove dir/b.dat"
+
+  git lfs ls-files --all 2>&1 | tee ls-files-all.log
+  [ 1 -eq $(grep -c "a\.dat" ls-files-all.log) ]
+  [ 1 -eq $(grep -c " dir/b\.dat$" ls-files-all.log) ]
+)
+end_test
+
+begin_test "ls-files: history with --all includes older stashes"
+(
+  set -e
+
+  reponame="ls-files-history-with-all-stashes"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track '*.dat'
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  printf "stashed one" > a.dat
+  git stash
+  printf "stashed two" > a.dat
+  git stash
+
+  git lfs ls-files --all --long 2>&1 | tee ls-files-all.log
+  grep "$(calc_oid "a")" ls-files-all.log
+  grep "$(calc_oid "stashed one")" ls-files-all.log
+  grep "$(calc_oid "stashed two")" ls-files-all.log
+)
+end_test
+
 begin_test "ls-files: --all with argument(s)"
 (
   set -e
@@ -442,6 +496,45 @@ begin_test "ls-files: --name-only"
 )
 end_test
 
+begin_test "ls-files: --max-count"
+(
+  set -e
+
+  reponame="ls-files-max-count"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  for i in 1 2 3 4 5 6; do
+    echo "contents $i" > "$i.dat"
+    git add "$i.dat"
+    git commit -m "add $i.dat"
+  done
+
+  git lfs ls-files --max-count=2 2>&1 | tee ls.log
+  [ 2 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --all --max-count=4 2>&1 | tee ls.log
+  [ 4 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --all --max-count=100 2>&1 | tee ls.log
+  [ 6 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --max-count=0 2>&1 | tee ls.log
+  [ 6 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --max-count=-1 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected ls-files to fail with a negative --max-count"
+    exit 1
+  fi
+  grep "Invalid --max-count value: -1" ls.log
+)
+end_test
+
 begin_test "ls-files: history with reference range"
 (
   set -e
@@ -516,3 +609,585 @@ begin_test "ls-files: not affected by lfs.fetchexclude"
   [ "6bbd052ab0 * missing.dat" = "$(git lfs ls-files)" ]
 )
 end_test
+
+begin_test "ls-files: --group-by-ext"
+(
+  set -e
+
+  reponame="ls-files-group-by-ext"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat" "*.psd" "README"
+  mkdir dir
+  printf "aaaaaaaaaa" > a.dat
+  printf "bbbbbbbbbbbbbbbbbbbb" > dir/b.dat
+  printf "%0100d" 0 > c.psd
+  printf "readme" > README
+  printf "another readme" > dir/README
+  git add .gitattributes a.dat dir c.psd README
+  git commit -m "initial commit"
+
+  git lfs ls-files --group-by-ext 2>&1 | tee ls.log
+  [ 3 -eq "$(wc -l < ls.log)" ]
+  [ "*.psd" = "$(sed -n 1p ls.log | cut -f1 | tr -d ' ')" ]
+  [ "*.dat" = "$(sed -n 2p ls.log | cut -f1 | tr -d ' ')" ]
+  [ "(no extension)" = "$(sed -n 3p ls.log | cut -f1 | sed -e 's/ *$//')" ]
+  grep "^\*\.psd *	 *1 file 	 *100 B$" ls.log
+  grep "^\*\.dat *	 *2 files	 *30 B$" ls.log
+  grep "^(no extension)	 *2 files	 *20 B$" ls.log
+
+  git lfs ls-files --group-by-ext --json 2>&1 | tee ls.json
+  expected='{"extensions":[{"extension":".psd","count":1,"size":100},{"extension":".dat","count":2,"size":30},{"extension":"","count":2,"size":20}]}'
+  [ "$expected" = "$(cat ls.json)" ]
+
+  git lfs ls-files --group-by-ext --include "dir/**" 2>&1 | tee ls.log
+  [ 2 -eq "$(wc -l < ls.log)" ]
+  grep "^\*\.dat *	 *1 file 	 *20 B$" ls.log
+  grep "^(no extension)	 *1 file 	 *14 B$" ls.log
+
+  git lfs ls-files --json 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --json' to fail"
+    exit 1
+  fi
+  grep "Cannot use --json without --group-by-ext" ls.log
+)
+end_test
+
+begin_test "ls-files: --dedup"
+(
+  set -e
+
+  reponame="ls-files-dedup"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir dir
+  printf "aaaaaaaaaa" > a.dat
+  cp a.dat dir/a.dat
+  cp a.dat copy.dat
+  printf "%0100d" 0 > b.dat
+  cp b.dat dir/b.dat
+  printf "ccccc" > c.dat
+  git add .gitattributes a.dat b.dat c.dat copy.dat dir
+  git commit -m "initial commit"
+
+  a_oid="$(calc_oid "aaaaaaaaaa")"
+  b_oid="$(calc_oid "$(printf "%0100d" 0)")"
+  c_oid="$(calc_oid "ccccc")"
+
+  git lfs ls-files --dedup 2>&1 | tee ls.log
+  grep "^${b_oid:0:10}	 *2 paths	 *100 B	 *100 B saved$" ls.log
+  grep "^${a_oid:0:10}	 *3 paths	 *10 B	 *20 B saved$" ls.log
+  grep "^${c_oid:0:10}	 *1 path 	 *5 B	 *0 B saved$" ls.log
+  [ "${b_oid:0:10}" = "$(sed -n 1p ls.log | cut -f1)" ]
+  grep "^ logical size: 235 B$" ls.log
+  grep "^physical size: 115 B$" ls.log
+  grep "^        saved: 120 B$" ls.log
+
+  git lfs ls-files --dedup --json 2>&1 | tee ls.json
+  expected="{\"objects\":[{\"oid\":\"$b_oid\",\"size\":100,\"paths\":2,\"names\":[\"b.dat\",\"dir/b.dat\"],\"saved\":100},{\"oid\":\"$a_oid\",\"size\":10,\"paths\":3,\"names\":[\"a.dat\",\"copy.dat\",\"dir/a.dat\"],\"saved\":20},{\"oid\":\"$c_oid\",\"size\":5,\"paths\":1,\"names\":[\"c.dat\"],\"saved\":0}],\"logical_size\":235,\"physical_size\":115,\"saved\":120}"
+  [ "$expected" = "$(cat ls.json)" ]
+
+  git lfs ls-files --dedup --include "dir/**" 2>&1 | tee ls.log
+  grep "^${b_oid:0:10}	 *1 path 	 *100 B	 *0 B saved$" ls.log
+  grep "^        saved: 0 B$" ls.log
+
+  git lfs ls-files --dedup --all 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --dedup --all' to fail"
+    exit 1
+  fi
+  grep "Cannot use --dedup with --all" ls.log
+
+  git lfs ls-files --dedup --group-by-ext 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --dedup --group-by-ext' to fail"
+    exit 1
+  fi
+  grep "Cannot use --dedup with --group-by-ext" ls.log
+)
+end_test
+
+begin_test "ls-files: --resolve-names"
+(
+  set -e
+
+  reponame="ls-files-resolve-names"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "initial commit"
+
+  git rm b.dat
+  printf "c" > a.dat
+  git add a.dat
+  git commit -m "remove b.dat, update a.dat"
+
+  git lfs ls-files --all --long 2>&1 | tee expected.log
+  git lfs ls-files --all --long --resolve-names 2>&1 | tee ls.log
+  diff -u expected.log ls.log
+
+  git lfs ls-files --resolve-names 2>&1 | tee ls.log
+  [ 1 -eq "$(wc -l < ls.log)" ]
+  grep "a.dat" ls.log
+)
+end_test
+
+begin_test "ls-files: --lifespan"
+(
+  set -e
+
+  reponame="ls-files-lifespan"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add a.dat b.dat
+  GIT_COMMITTER_DATE="2020-01-01T12:00:00Z" git commit -m "add a.dat, b.dat"
+  first="$(git rev-parse HEAD)"
+
+  printf "c" > a.dat
+  git add a.dat
+  GIT_COMMITTER_DATE="2020-02-01T12:00:00Z" git commit -m "update a.dat"
+  second="$(git rev-parse HEAD)"
+
+  git rm b.dat
+  GIT_COMMITTER_DATE="2020-03-01T12:00:00Z" git commit -m "remove b.dat"
+  third="$(git rev-parse HEAD)"
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+  c_oid="$(calc_oid "c")"
+
+  git lfs ls-files --lifespan --long 2>&1 | tee ls.log
+  [ 3 -eq "$(wc -l < ls.log)" ]
+  grep "^$a_oid 2020-01-01 ${first:0:10} 2020-01-01 ${first:0:10} a.dat$" ls.log
+  grep "^$b_oid 2020-01-01 ${first:0:10} 2020-02-01 ${second:0:10} b.dat$" ls.log
+  grep "^$c_oid 2020-02-01 ${second:0:10} 2020-03-01 ${third:0:10} a.dat$" ls.log
+
+  git lfs ls-files --lifespan --json --include="b.dat" 2>&1 | tee ls.log
+  expected="$(printf '{"files":[{"oid":"%s","size":1,"name":"b.dat","first_commit":{"sha":"%s","date":"2020-01-01T12:00:00Z"},"last_commit":{"sha":"%s","date":"2020-02-01T12:00:00Z"},"commits":2}]}' \
+    "$b_oid" "$first" "$second")"
+  [ "$expected" = "$(cat ls.log)" ]
+
+  git lfs ls-files --lifespan --group-by-ext 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --lifespan --group-by-ext' to fail"
+    exit 1
+  fi
+  grep "Cannot use --lifespan with --group-by-ext or --deleted" ls.log
+)
+end_test
+
+begin_test "ls-files: --manifest"
+(
+  set -e
+
+  reponame="ls-files-manifest"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "b" > b.dat
+  mkdir dir
+  printf "a" > "dir/with space.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat b.dat dir
+  git commit -m "add files"
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+
+  git lfs ls-files --manifest=manifest.txt 2>&1 | tee ls.log
+  [ 0 -eq "$(wc -l < ls.log)" ]
+  printf "%s 1 a.dat\n%s 1 b.dat\n%s 1 dir/with space.dat\n" \
+    "$a_oid" "$b_oid" "$a_oid" > expected.txt
+  diff -u expected.txt manifest.txt
+
+  git lfs ls-files --manifest=- --exclude="dir" 2>&1 | tee ls.log
+  [ "$(head -n 2 expected.txt)" = "$(cat ls.log)" ]
+
+  git lfs ls-files --manifest=- --group-by-ext 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --manifest --group-by-ext' to fail"
+    exit 1
+  fi
+  grep "Cannot use --manifest with --group-by-ext, --lifespan, or --debug" ls.log
+)
+end_test
+
+begin_test "ls-files: -z"
+(
+  set -e
+
+  reponame="ls-files-null"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > "with space.dat"
+  printf "b" > "$(printf "new\nline.dat")"
+  git add .gitattributes ./*.dat
+  git commit -m "add files"
+
+  git lfs ls-files -z -n > ls.out
+  printf "new\nline.dat\0with space.dat\0" > expected.out
+  cmp expected.out ls.out
+
+  # Each record can be passed as a single argument.
+  git lfs ls-files -z --name-only | xargs -0 -n 1 printf "[%s]\n" > args.log
+  [ "2" -eq "$(grep -c "^\[" args.log)" ]
+  grep "^\[with space.dat\]$" args.log
+
+  git lfs ls-files -z > ls.out
+  [ "2" -eq "$(tr -cd '\0' < ls.out | wc -c)" ]
+  grep -a "with space.dat" ls.out
+
+  git lfs ls-files -z --debug 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files -z --debug' to fail"
+    exit 1
+  fi
+  grep "Cannot use -z with --group-by-ext, --json, --manifest, or --debug" ls.log
+)
+end_test
+
+begin_test "ls-files: --remote-refs"
+(
+  set -e
+
+  reponame="ls-files-remote-refs"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  # c.dat is reachable only from the remote-tracking ref of its branch.
+  git checkout -b feature
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  git push origin feature
+  git checkout main
+  git branch -D feature
+
+  # b.dat has not been pushed.
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git tag local
+
+  git lfs ls-files --remote-refs=origin --name-only 2>&1 | tee ls.log
+  [ "2" -eq "$(wc -l < ls.log)" ]
+  grep "^a.dat$" ls.log
+  grep "^c.dat$" ls.log
+  grep "b.dat" ls.log && exit 1
+
+  git lfs ls-files --remote-refs=origin --long 2>&1 | tee ls.log
+  grep "$(calc_oid "c") - c.dat" ls.log
+
+  git lfs ls-files --remote-refs=missing 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --remote-refs=missing' to fail"
+    exit 1
+  fi
+  grep "Invalid remote name \"missing\"" ls.log
+
+  git lfs ls-files --remote-refs=origin --all 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --remote-refs --all' to fail"
+    exit 1
+  fi
+  grep "Cannot use --remote-refs with --all, --deleted, --lifespan, or an explicit reference" ls.log
+)
+end_test
+
+begin_test "ls-files: --unique-to"
+(
+  set -e
+
+  reponame="ls-files-unique-to"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # b.dat, and both versions of it, are reachable only from feature.
+  git checkout -b feature
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  printf "bb" > b.dat
+  git add b.dat
+  git commit -m "modify b.dat"
+
+  # c.dat is reachable from both other and the tag.
+  git checkout -b other main
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  git tag shared
+  git checkout main
+
+  git lfs ls-files --unique-to feature --long 2>&1 | tee ls.log
+  [ "2" -eq "$(wc -l < ls.log)" ]
+  grep "$(calc_oid "b") - b.dat" ls.log
+  grep "$(calc_oid "bb") - b.dat" ls.log
+
+  # Every commit on main is also on feature.
+  git lfs ls-files --unique-to main 2>&1 | tee ls.log
+  [ "0" -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --unique-to other 2>&1 | tee ls.log
+  [ "0" -eq "$(wc -l < ls.log)" ]
+
+  git tag -d shared
+  git lfs ls-files --unique-to other --name-only 2>&1 | tee ls.log
+  [ "c.dat" = "$(cat ls.log)" ]
+
+  git lfs ls-files --unique-to feature --all 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --unique-to --all' to fail"
+    exit 1
+  fi
+  grep "Cannot use --unique-to with --all, --deleted, --lifespan, --remote-refs, or an explicit reference" ls.log
+)
+end_test
+
+begin_test "ls-files: --csv"
+(
+  set -e
+
+  reponame="ls-files-csv"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  mkdir dir
+  printf "b" > "dir/with, comma.dat"
+  git add .gitattributes a.dat dir
+  git commit -m "add files"
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+  head="$(git rev-parse HEAD)"
+
+  git lfs ls-files --csv=files.csv 2>&1 | tee ls.log
+  [ 0 -eq "$(wc -l < ls.log)" ]
+  printf "oid,size,name,commit\n%s,1,a.dat,%s\n%s,1,\"dir/with, comma.dat\",%s\n" \
+    "$a_oid" "$head" "$b_oid" "$head" > expected.csv
+  diff -u expected.csv files.csv
+
+  # The rows match what is listed.
+  [ "$(git lfs ls-files -l -n | sort)" = "$(tail -n +2 files.csv | cut -d , -f 3- | sed -e "s/,$head\$//" -e 's/"//g' | sort)" ]
+
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c"
+  c_oid="$(calc_oid "c")"
+
+  # History scans do not say in which commit each object was found.
+  git lfs ls-files --all --csv=- 2>&1 | tee ls.log
+  grep "^$c_oid,1,c.dat,\$" ls.log
+  grep "^$a_oid,1,a.dat,\$" ls.log
+
+  git lfs ls-files --include=c.dat --csv=- HEAD~1 2>&1 | tee ls.log
+  [ "oid,size,name,commit" = "$(cat ls.log)" ]
+
+  git lfs ls-files --csv=- --manifest=- 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --csv --manifest' to fail"
+    exit 1
+  fi
+  grep "Cannot use --csv with --group-by-ext, --lifespan, --manifest, -z, or --debug" ls.log
+)
+end_test
+
+begin_test "ls-files: warns of a ref both included and excluded"
+(
+  set -e
+
+  reponame="ls-files-overlapping-refs"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  echo "some data" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs ls-files main main 2>ls.err | tee ls.log
+  [ ! -s ls.log ]
+  grep "warning: refs which are both included and excluded are not scanned: main" ls.err
+
+  # The same commit given by its object ID is not warned of.
+  sha="$(git rev-parse main)"
+  git lfs ls-files "$sha" "$sha" 2>ls.err | tee ls.log
+  [ ! -s ls.log ]
+  [ ! -s ls.err ]
+)
+end_test
+
+begin_test "ls-files: --with-ext"
+(
+  set -e
+
+  reponame="ls-files-with-ext"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "plain" > plain.dat
+  git add .gitattributes plain.dat
+  git commit -m "add plain.dat"
+
+  git config lfs.extension.gz.clean "builtin:gzip"
+  git config lfs.extension.gz.smudge "builtin:gunzip"
+  git config lfs.extension.gz.priority 0
+  printf "compressed" > compressed.dat
+  git add compressed.dat
+  git commit -m "add compressed.dat"
+  git cat-file -p :compressed.dat | grep "^ext-0-gz "
+
+  git lfs ls-files --name-only --with-ext gz 2>&1 | tee ls.log
+  [ "compressed.dat" = "$(cat ls.log)" ]
+
+  git lfs ls-files --name-only --with-ext ext-0-gz 2>&1 | tee ls.log
+  [ "compressed.dat" = "$(cat ls.log)" ]
+
+  git lfs ls-files --name-only --with-ext ext-1-gz 2>&1 | tee ls.log
+  [ "" = "$(cat ls.log)" ]
+
+  git lfs ls-files --name-only --with-ext foo --with-ext gz 2>&1 | tee ls.log
+  [ "compressed.dat" = "$(cat ls.log)" ]
+
+  # Scans of history also only report pointers carrying the extension.
+  git lfs ls-files --name-only --all --with-ext gz 2>&1 | tee ls.log
+  [ "compressed.dat" = "$(cat ls.log)" ]
+  git lfs ls-files --name-only --all 2>&1 | tee ls.log
+  [ 2 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --with-ext "ext-gz" 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --with-ext ext-gz' to fail"
+    exit 1
+  fi
+  grep "Invalid --with-ext value: invalid extension: \"ext-gz\"" ls.log
+
+  git lfs ls-files --lifespan --with-ext gz 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --lifespan --with-ext' to fail"
+    exit 1
+  fi
+  grep "Cannot use --with-ext with --lifespan" ls.log
+)
+end_test
+
+begin_test "ls-files: --deleted with ambiguous ref"
+(
+  set -e
+
+  reponame="ls-files-ambiguous-ref"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "tagged" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git tag ambiguous
+
+  printf "branch" > a.dat
+  git add a.dat
+  git commit -m "change a.dat"
+  git branch ambiguous
+
+  git lfs ls-files --long --deleted ambiguous 2>&1 | tee ls-files.log
+  grep 'warning: ref "ambiguous" is ambiguous; using "refs/tags/ambiguous"' ls-files.log
+  grep "$(calc_oid "tagged")" ls-files.log
+  grep "$(calc_oid "branch")" ls-files.log && exit 1
+  true
+)
+end_test
+
+begin_test "ls-files: --reachability"
+(
+  set -e
+
+  reponame="ls-files-reachability"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b other
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  git checkout main
+  printf "aa" > a.dat
+  git add a.dat
+  git commit -m "update a.dat"
+  git tag v1
+
+  oid_a="$(calc_oid "a")"
+  oid_aa="$(calc_oid "aa")"
+  oid_b="$(calc_oid "b")"
+
+  git lfs ls-files --reachability --json | tee ls.json
+  grep -F '{"refs":["refs/heads/main","refs/heads/other","refs/tags/v1"],"objects":{' ls.json
+  grep -F "\"$oid_a\":[\"refs/heads/main\",\"refs/heads/other\",\"refs/tags/v1\"]" ls.json
+  grep -F "\"$oid_aa\":[\"refs/heads/main\",\"refs/tags/v1\"]" ls.json
+  grep -F "\"$oid_b\":[\"refs/heads/other\"]" ls.json
+  [ 3 -eq "$(grep -o '"[0-9a-f]\{64\}"' ls.json | wc -l)" ]
+
+  git lfs ls-files --reachability --long other | tee ls.log
+  [ 2 -eq "$(wc -l < ls.log)" ]
+  grep "^$oid_a other$" ls.log
+  grep "^$oid_b other$" ls.log
+
+  git lfs ls-files --reachability --max-refs 1 2>ls.err | tee ls.log
+  grep "warning: only scanning the first 1 of 3 references" ls.err
+  [ 2 -eq "$(wc -l < ls.log)" ]
+  grep "^${oid_a:0:10} refs/heads/main$" ls.log
+  grep "^${oid_aa:0:10} refs/heads/main$" ls.log
+
+  git lfs ls-files --max-refs 1 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --max-refs' to fail"
+    exit 1
+  fi
+  grep "Cannot use --max-refs without --reachability" ls.log
+
+  git lfs ls-files --reachability --all 2>&1 | tee ls.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ls-files --reachability --all' to fail"
+    exit 1
+  fi
+  grep "Cannot use --reachability with --all" ls.log
+)
+end_test
@@ -0,0 +1,73 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "manifest-diff"
+(
+  set -e
+
+  git init manifest-diff-source
+  git init manifest-diff-mirror
+
+  oid_a="$(calc_oid "a")"
+  oid_b="$(calc_oid "bb")"
+  oid_c="$(calc_oid "ccc")"
+
+  cd manifest-diff-source
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "bb" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+  git lfs ls-files --manifest=../source.manifest
+
+  cd ../manifest-diff-mirror
+  git lfs track "*.dat"
+  mkdir dir
+  printf "a" > dir/moved.dat
+  printf "ccc" > c.dat
+  git add .gitattributes dir/moved.dat c.dat
+  git commit -m "add files"
+  git lfs ls-files --manifest=../mirror.manifest
+  cd ..
+
+  git lfs manifest-diff source.manifest mirror.manifest > diff.log 2>&1 &&
+    exit 1
+  cat diff.log
+  [ "-$oid_b 2 b.dat" = "$(grep "^-" diff.log)" ]
+  [ "+$oid_c 3 c.dat" = "$(grep "^+" diff.log)" ]
+  [ 2 -eq "$(wc -l < diff.log)" ]
+
+  # The moved object is not a difference, and the order of the manifests
+  # decides which side each object is on.
+  git lfs manifest-diff mirror.manifest - < source.manifest > diff.log 2>&1 &&
+    exit 1
+  cat diff.log
+  [ "-$oid_c 3 c.dat" = "$(grep "^-" diff.log)" ]
+  [ "+$oid_b 2 b.dat" = "$(grep "^+" diff.log)" ]
+
+  git lfs manifest-diff source.manifest source.manifest > diff.log 2>&1
+  [ ! -s diff.log ]
+)
+end_test
+
+begin_test "manifest-diff with an invalid manifest"
+(
+  set -e
+
+  echo "not a manifest" > invalid.manifest
+  : > empty.manifest
+
+  git lfs manifest-diff empty.manifest invalid.manifest 2>&1 | tee diff.log
+  [ 2 -eq "${PIPESTATUS[0]}" ]
+  grep "Could not read manifest \"invalid.manifest\"" diff.log
+
+  git lfs manifest-diff empty.manifest missing.manifest 2>&1 | tee diff.log
+  [ 2 -eq "${PIPESTATUS[0]}" ]
+  grep "Could not open manifest \"missing.manifest\"" diff.log
+
+  git lfs manifest-diff - - < empty.manifest 2>&1 | tee diff.log
+  [ 2 -eq "${PIPESTATUS[0]}" ]
+  grep "Only one manifest may be read from standard input" diff.log
+)
+end_test
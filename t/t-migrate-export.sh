@@ -192,6 +192,29 @@ begin_test "migrate export (given branch)"
 )
 end_test
 
+begin_test "migrate export (--tags)"
+(
+  set -e
+
+  setup_single_local_branch_with_tag_only_commit
+
+  git lfs migrate import --tags --include="*.txt"
+
+  main="$(git rev-parse refs/heads/main)"
+  git cat-file -p v1.0.0:a.txt | grep -q "git-lfs.github.com/spec/v1"
+  git cat-file -p v1.0.0:b.txt | grep -q "git-lfs.github.com/spec/v1"
+
+  git lfs migrate export --tags --include="*.txt"
+
+  refute_pointer "refs/tags/v1.0.0" "a.txt"
+  refute_pointer "refs/tags/v1.0.0" "b.txt"
+  refute_pointer "refs/heads/main" "a.txt"
+
+  [ "$main" = "$(git rev-parse refs/heads/main)" ]
+  [ "$main" = "$(git rev-parse refs/tags/v1.0.0^)" ]
+)
+end_test
+
 begin_test "migrate export (no filter)"
 (
   set -e
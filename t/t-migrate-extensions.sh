@@ -0,0 +1,114 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/fixtures/migrate.sh"
+. "$(dirname "$0")/testlib.sh"
+
+ext_oid_a="ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+ext_oid_b="bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+pointer_oid="4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+# setup_local_branch_with_extension_pointers creates a repository whose two
+# commits each add a pointer recorded with the "legacy" extension at priority
+# 0, and the second also one recorded with the "bar" extension at priority 1.
+# The pointers are committed without a .gitattributes file, so that they are
+# checked out as they are.
+setup_local_branch_with_extension_pointers() {
+  set -e
+
+  reponame="migrate-extensions"
+  remove_and_create_local_repo "$reponame"
+
+  printf "version https://git-lfs.github.com/spec/v1\next-0-legacy sha256:%s\noid sha256:%s\nsize 12345\n" \
+    "$ext_oid_a" "$pointer_oid" > a.dat
+  git add a.dat
+  git commit -m "add a.dat"
+
+  printf "version https://git-lfs.github.com/spec/v1\next-0-legacy sha256:%s\next-1-bar sha256:%s\noid sha256:%s\nsize 12345\n" \
+    "$ext_oid_a" "$ext_oid_b" "$pointer_oid" > b.dat
+  printf "not a pointer\n" > c.txt
+  git add b.dat c.txt
+  git commit -m "add b.dat and c.txt"
+}
+
+begin_test "migrate extensions"
+(
+  set -e
+
+  setup_local_branch_with_extension_pointers
+
+  c_txt="$(git rev-parse HEAD:c.txt)"
+
+  git lfs migrate extensions --map=legacy=foo
+
+  git cat-file -p HEAD~1:a.dat | tee a.log
+  grep "^ext-0-foo sha256:$ext_oid_a$" a.log
+  grep "^oid sha256:$pointer_oid$" a.log
+  grep "^size 12345$" a.log
+  [ 0 -eq "$(grep -c "legacy" a.log)" ]
+
+  git cat-file -p HEAD:b.dat | tee b.log
+  grep "^ext-0-foo sha256:$ext_oid_a$" b.log
+  grep "^ext-1-bar sha256:$ext_oid_b$" b.log
+
+  [ "$c_txt" = "$(git rev-parse HEAD:c.txt)" ]
+
+  # The working tree is checked out again from the rewritten history.
+  grep "^ext-0-foo" b.dat
+  [ -z "$(git status --porcelain --untracked-files=no)" ]
+)
+end_test
+
+begin_test "migrate extensions (priority)"
+(
+  set -e
+
+  setup_local_branch_with_extension_pointers
+
+  git lfs migrate extensions --map=ext-0-legacy=ext-2-foo
+
+  git cat-file -p HEAD:b.dat | tee b.log
+  [ "ext-1-bar sha256:$ext_oid_b" = "$(sed -n 2p b.log)" ]
+  [ "ext-2-foo sha256:$ext_oid_a" = "$(sed -n 3p b.log)" ]
+)
+end_test
+
+begin_test "migrate extensions (duplicate priority)"
+(
+  set -e
+
+  setup_local_branch_with_extension_pointers
+
+  original="$(git rev-parse HEAD)"
+
+  git lfs migrate extensions --yes --map=legacy=ext-1-foo 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs migrate extensions' to fail ..."
+    exit 1
+  fi
+  grep "duplicate priority found: 1" migrate.log
+
+  assert_ref_unmoved "HEAD" "$original" "$(git rev-parse HEAD)"
+)
+end_test
+
+begin_test "migrate extensions (no mappings)"
+(
+  set -e
+
+  setup_local_branch_with_extension_pointers
+
+  git lfs migrate extensions --yes 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs migrate extensions' to fail ..."
+    exit 1
+  fi
+  grep "One or more extension mappings must be specified with --map" migrate.log
+
+  git lfs migrate extensions --yes --map=legacy 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs migrate extensions' to fail ..."
+    exit 1
+  fi
+  grep "invalid extension mapping: \"legacy\"" migrate.log
+)
+end_test
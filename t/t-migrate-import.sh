@@ -487,6 +487,77 @@ begin_test "migrate import (above with multiple files)"
 )
 end_test
 
+begin_test "migrate import (above converts only larger files)"
+(
+  set -e
+
+  reponame="migrate-import-above-exact"
+  remove_and_create_local_repo "$reponame"
+
+  mkdir dir
+  printf "0123456789" > exact.bin
+  printf "012345678" > small.bin
+  printf "0123456789A" > dir/large.bin
+  printf "0123456789AB" > larger.txt
+  git add exact.bin small.bin dir larger.txt
+  git commit -m "add files around the threshold"
+
+  large_oid="$(calc_oid "0123456789A")"
+  larger_oid="$(calc_oid "0123456789AB")"
+
+  git lfs migrate import --above 10B
+
+  assert_pointer "refs/heads/main" "dir/large.bin" "$large_oid" 11
+  assert_pointer "refs/heads/main" "larger.txt" "$larger_oid" 12
+  refute_pointer "refs/heads/main" "exact.bin"
+  refute_pointer "refs/heads/main" "small.bin"
+
+  # Only the converted files are tracked, by their paths rather than their
+  # extensions, since some ".bin" files were not converted.
+  attrs="$(git cat-file -p "main:.gitattributes")"
+  [ "$(printf "%s\n%s" \
+    "/dir/large.bin filter=lfs diff=lfs merge=lfs -text" \
+    "/larger.txt filter=lfs diff=lfs merge=lfs -text")" = "$attrs" ]
+  git check-attr filter -- exact.bin | grep "filter: unspecified"
+  git check-attr filter -- small.bin | grep "filter: unspecified"
+  git status --porcelain | grep -v "^??" && exit 1
+  true
+)
+end_test
+
+begin_test "migrate import (above keeps converting a file once it is converted)"
+(
+  set -e
+
+  reponame="migrate-import-above-shrink"
+  remove_and_create_local_repo "$reponame"
+
+  printf "small" > a.dat
+  git add a.dat
+  git commit -m "add small a.dat"
+
+  printf "large contents" > a.dat
+  git add a.dat
+  git commit -m "grow a.dat"
+
+  printf "tiny" > a.dat
+  git add a.dat
+  git commit -m "shrink a.dat"
+
+  git lfs migrate import --above 10B
+
+  # The first version is not converted, since a.dat was not yet tracked, but
+  # every later one is, so that .gitattributes matches its contents.
+  refute_pointer "refs/heads/main~2" "a.dat"
+  git cat-file -e "main~2:.gitattributes" 2>/dev/null && exit 1
+  assert_pointer "refs/heads/main~1" "a.dat" "$(calc_oid "large contents")" 14
+  assert_pointer "refs/heads/main" "a.dat" "$(calc_oid "tiny")" 4
+  [ "/a.dat filter=lfs diff=lfs merge=lfs -text" = "$(git cat-file -p "main:.gitattributes")" ]
+  git status --porcelain | grep -v "^??" && exit 1
+  true
+)
+end_test
+
 begin_test "migrate import (above with include or exclude)"
 (
   set -e
@@ -855,6 +926,77 @@ begin_test "migrate import (--everything with tag pointing to tag)"
 )
 end_test
 
+begin_test "migrate import (--tags)"
+(
+  set -e
+
+  setup_single_local_branch_with_tag_only_commit
+
+  main="$(git rev-parse refs/heads/main)"
+  main_txt="$(git cat-file -p main:a.txt)"
+  tag_txt_oid="$(calc_oid "$(git cat-file -p v1.0.0:a.txt)")"
+  tag_txt_size="$(git cat-file -p v1.0.0:a.txt | wc -c | awk '{ print $1 }')"
+  tag_b_oid="$(calc_oid "$(git cat-file -p v1.0.0:b.txt)")"
+  tag_b_size="$(git cat-file -p v1.0.0:b.txt | wc -c | awk '{ print $1 }')"
+
+  git lfs migrate import --tags --include="*.txt"
+
+  [ "$main" = "$(git rev-parse refs/heads/main)" ]
+  [ "$main_txt" = "$(git cat-file -p main:a.txt)" ]
+
+  assert_pointer "refs/tags/v1.0.0" "a.txt" "$tag_txt_oid" "$tag_txt_size"
+  assert_pointer "refs/tags/v1.0.0" "b.txt" "$tag_b_oid" "$tag_b_size"
+  assert_local_object "$tag_txt_oid" "$tag_txt_size"
+  assert_local_object "$tag_b_oid" "$tag_b_size"
+
+  # The tagged commit's parent is on main, and so is left alone.
+  [ "$main" = "$(git rev-parse refs/tags/v1.0.0^)" ]
+)
+end_test
+
+begin_test "migrate import (--tags with --everything)"
+(
+  set -e
+
+  setup_single_local_branch_with_tag_only_commit
+
+  [ "$(git lfs migrate import --tags --everything 2>&1)" = \
+    "Cannot use --tags with --everything" ]
+)
+end_test
+
+begin_test "migrate import (--tags with args)"
+(
+  set -e
+
+  setup_single_local_branch_with_tag_only_commit
+
+  [ "$(git lfs migrate import --tags main 2>&1)" = \
+    "Cannot use --tags with explicit reference arguments" ]
+)
+end_test
+
+begin_test "migrate import (--tags with --include-ref)"
+(
+  set -e
+
+  setup_single_local_branch_with_tag_only_commit
+
+  [ "$(git lfs migrate import --tags --include-ref=refs/heads/main 2>&1)" = \
+    "Cannot use --tags with --include-ref or --exclude-ref" ]
+)
+end_test
+
+begin_test "migrate import (--tags without tags)"
+(
+  set -e
+
+  setup_single_local_branch_untracked
+
+  [ "$(git lfs migrate import --tags 2>&1)" = "No tags to migrate" ]
+)
+end_test
+
 begin_test "migrate import (nested sub-trees and --include with wildcard)"
 (
   set -e
@@ -1155,3 +1297,259 @@ begin_test "migrate import (filename special characters)"
   fi
 )
 end_test
+
+begin_test "migrate import (--concurrency)"
+(
+  set -e
+
+  reponame="migrate-import-concurrency"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir -p dir/sub other
+  for f in a.txt b.md dir/c.bin dir/sub/d.txt dir/sub/e.dat other/f.md g.png; do
+    base64 < /dev/urandom | head -c 120 > "$f"
+  done
+  git add .
+  git commit -m "initial commit"
+
+  base64 < /dev/urandom | head -c 140 > dir/sub/d.txt
+  base64 < /dev/urandom | head -c 140 > dir/h.gif
+  git add .
+  git commit -m "update dir/sub/d.txt, add dir/h.gif"
+
+  git checkout -b my-feature
+  base64 < /dev/urandom | head -c 160 > other/f.md
+  git add .
+  git commit -m "update other/f.md"
+  git checkout main
+
+  cd ..
+  git clone "$reponame" "$reponame-serial"
+  git clone "$reponame" "$reponame-concurrent"
+
+  cd "$reponame-serial"
+  git branch my-feature origin/my-feature
+  git lfs migrate import --everything
+  serial_main="$(git rev-parse refs/heads/main)"
+  serial_feature="$(git rev-parse refs/heads/my-feature)"
+
+  cd "../$reponame-concurrent"
+  git branch my-feature origin/my-feature
+  GIT_TRACE=1 git lfs migrate import --everything --concurrency=4 2>&1 | tee ../migrate.log
+  grep "githistory: rewriting blobs with 4 workers" ../migrate.log
+  [ "$serial_main" = "$(git rev-parse refs/heads/main)" ]
+  [ "$serial_feature" = "$(git rev-parse refs/heads/my-feature)" ]
+
+  for f in a.txt b.md dir/c.bin dir/sub/d.txt dir/sub/e.dat dir/h.gif other/f.md g.png; do
+    git cat-file -p "refs/heads/main:$f" | grep -q "^oid sha256:"
+  done
+
+  git lfs migrate import --yes --everything --concurrency=0 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs migrate import --concurrency=0' to fail"
+    exit 1
+  fi
+  grep "Invalid --concurrency=0: must be at least 1" migrate.log
+)
+end_test
+
+begin_test "migrate import (resume after interruption)"
+(
+  set -e
+
+  reponame="migrate-import-resume"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "a" > a.txt
+  git add a.txt
+  git commit -m "add a.txt"
+
+  git rm a.txt
+  printf "interrupt me" > b.md
+  git add b.md
+  git commit -m "remove a.txt, add b.md"
+
+  printf "c" > c.bin
+  git add c.bin
+  git commit -m "add c.bin"
+
+  cd ..
+  git clone "$reponame" "$reponame-uninterrupted"
+
+  # Fail to clean b.md while the marker file exists, interrupting the
+  # migration after its first commit.
+  cat > "$TRASHDIR/interrupting-clean.sh" <<EOS
+#!/bin/sh
+contents="\$(cat)"
+if [ -e "$TRASHDIR/interrupt" ] && [ "\$contents" = "interrupt me" ]; then
+  exit 1
+fi
+printf "%s" "\$contents"
+EOS
+  chmod +x "$TRASHDIR/interrupting-clean.sh"
+  git config --global lfs.extension.interrupt.clean "$TRASHDIR/interrupting-clean.sh"
+  git config --global lfs.extension.interrupt.smudge "cat"
+  git config --global lfs.extension.interrupt.priority 0
+
+  cd "$reponame-uninterrupted"
+  git lfs migrate import --everything
+  expected="$(git rev-parse refs/heads/main)"
+
+  cd "../$reponame"
+  original="$(git rev-parse refs/heads/main)"
+
+  touch "$TRASHDIR/interrupt"
+  git lfs migrate import --yes --everything 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected interrupted 'git lfs migrate import' to fail"
+    exit 1
+  fi
+  [ "$original" = "$(git rev-parse refs/heads/main)" ]
+  [ -f .git/lfs/migrate-import-resume ]
+
+  rm "$TRASHDIR/interrupt"
+  GIT_TRACE=1 git lfs migrate import --yes --everything 2>&1 | tee migrate.log
+  grep "githistory: resuming after 1 rewritten commit(s)" migrate.log
+  [ "$expected" = "$(git rev-parse refs/heads/main)" ]
+  [ ! -e .git/lfs/migrate-import-resume ]
+
+  # The patterns of files converted before the interruption are kept.
+  git cat-file -p "refs/heads/main~1:.gitattributes" | grep "^\*.txt filter=lfs"
+  git cat-file -p "refs/heads/main~1:.gitattributes" | grep "^\*.md filter=lfs"
+)
+end_test
+
+begin_test "migrate import (--per-directory-attributes)"
+(
+  set -e
+
+  reponame="migrate-import-per-directory-attributes"
+  remove_and_create_local_repo "$reponame"
+
+  mkdir -p sub other
+  printf "a" > a.bin
+  printf "b" > sub/b.dat
+  git add a.bin sub/b.dat
+  git commit -m "add a.bin and sub/b.dat"
+
+  mkdir -p sub/deep
+  printf "c" > sub/deep/c.dat
+  printf "d" > sub/d.md
+  git add sub
+  git commit -m "add sub/deep/c.dat and sub/d.md"
+
+  printf "e" > other/e.dat
+  git add other
+  git commit -m "add other/e.dat"
+
+  git lfs migrate import --per-directory-attributes
+
+  bin_attrs="*.bin filter=lfs diff=lfs merge=lfs -text"
+  dat_attrs="*.dat filter=lfs diff=lfs merge=lfs -text"
+  md_attrs="*.md filter=lfs diff=lfs merge=lfs -text"
+
+  [ "$bin_attrs" = "$(git cat-file -p "main~2:.gitattributes")" ]
+  [ "$dat_attrs" = "$(git cat-file -p "main~2:sub/.gitattributes")" ]
+
+  [ "$bin_attrs" = "$(git cat-file -p "main~1:.gitattributes")" ]
+  [ "$(printf "%s\n%s" "$dat_attrs" "$md_attrs")" = "$(git cat-file -p "main~1:sub/.gitattributes")" ]
+  [ "$dat_attrs" = "$(git cat-file -p "main~1:sub/deep/.gitattributes")" ]
+  git cat-file -e "main~1:other" 2>&1 && exit 1
+
+  [ "$bin_attrs" = "$(git cat-file -p "main:.gitattributes")" ]
+  [ "$(printf "%s\n%s" "$dat_attrs" "$md_attrs")" = "$(git cat-file -p "main:sub/.gitattributes")" ]
+  [ "$dat_attrs" = "$(git cat-file -p "main:sub/deep/.gitattributes")" ]
+  [ "$dat_attrs" = "$(git cat-file -p "main:other/.gitattributes")" ]
+
+  assert_pointer "refs/heads/main" "sub/deep/c.dat" "$(calc_oid "c")" 1
+  assert_pointer "refs/heads/main" "other/e.dat" "$(calc_oid "e")" 1
+  git check-attr filter -- sub/x.dat | grep "filter: lfs"
+  git check-attr filter -- x.dat | grep "filter: unspecified"
+  git check-attr filter -- other/x.md | grep "filter: unspecified"
+)
+end_test
+
+begin_test "migrate import (--per-directory-attributes with --above)"
+(
+  set -e
+
+  reponame="migrate-import-per-directory-attributes-above"
+  remove_and_create_local_repo "$reponame"
+
+  mkdir -p sub
+  printf "small" > sub/small.dat
+  printf "large contents" > sub/large.dat
+  git add sub
+  git commit -m "add sub/small.dat and sub/large.dat"
+
+  git lfs migrate import --per-directory-attributes --above 10B
+
+  [ "/large.dat filter=lfs diff=lfs merge=lfs -text" = "$(git cat-file -p "main:sub/.gitattributes")" ]
+  git cat-file -e "main:.gitattributes" 2>&1 && exit 1
+  git check-attr filter -- sub/large.dat | grep "filter: lfs"
+  git check-attr filter -- sub/small.dat | grep "filter: unspecified"
+)
+end_test
+
+begin_test "migrate import (--per-directory-attributes with include, exclude, fixup or no-rewrite)"
+(
+  set -e
+
+  setup_single_local_branch_untracked
+
+  git lfs migrate import --per-directory-attributes --include "*.md" && exit 1
+  git lfs migrate import --per-directory-attributes --exclude "*.txt" && exit 1
+  git lfs migrate import --per-directory-attributes --fixup && exit 1
+  git lfs migrate import --per-directory-attributes --no-rewrite a.md && exit 1
+  true
+)
+end_test
+
+begin_test "migrate import (--verify)"
+(
+  set -e
+
+  reponame="migrate-import-verify"
+  remove_and_create_local_repo "$reponame"
+
+  mkdir -p sub
+  printf "a" > a.bin
+  printf "b" > sub/b.bin
+  printf "readme" > README.md
+  git add a.bin sub/b.bin README.md
+  git commit -m "add a.bin, sub/b.bin and README.md"
+
+  printf "changed a" > a.bin
+  git add a.bin
+  git commit -m "change a.bin"
+
+  original="$(git rev-parse main)"
+
+  git lfs migrate import --yes --include "*.bin" --verify 2>&1 | tee migrate.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs migrate import --verify' to succeed"
+    exit 1
+  fi
+  grep "Verified 3 converted files" migrate.log
+
+  assert_pointer "refs/heads/main" "a.bin" "$(calc_oid "changed a")" 9
+  assert_pointer "refs/heads/main~1" "sub/b.bin" "$(calc_oid "b")" 1
+
+  for rev in "" "~1"; do
+    for file in a.bin sub/b.bin; do
+      [ "$(git cat-file -p "$original$rev:$file")" = \
+        "$(git cat-file -p "main$rev:$file" | git lfs smudge)" ]
+    done
+  done
+  [ "$(git rev-parse "$original:README.md")" = "$(git rev-parse "main:README.md")" ]
+
+  git lfs migrate import --yes --verify --no-rewrite a.bin 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected --verify with --no-rewrite to fail"
+    exit 1
+  fi
+  grep -- "--no-rewrite and --verify cannot be combined" migrate.log
+)
+end_test
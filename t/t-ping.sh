@@ -0,0 +1,180 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "ping with basic server"
+(
+  set -e
+
+  reponame="ping-basic"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs ping 2>&1 | tee ping.log
+  grep "^Remote=origin$" ping.log
+  grep "^Endpoint=$GITSERVER/$reponame.git/info/lfs$" ping.log
+  grep "^Download=supported$" ping.log
+  grep "^Upload=supported$" ping.log
+  [ 2 -eq "$(grep -c "^  Transfer=basic$" ping.log)" ]
+  [ 2 -eq "$(grep -c "^  HashAlgorithm=sha256$" ping.log)" ]
+  [ 2 -eq "$(grep -c "^  Adapters=basic$" ping.log)" ]
+  grep "^Locking=supported$" ping.log
+)
+end_test
+
+begin_test "ping with server advertising tus uploads"
+(
+  set -e
+
+  reponame="test-tus-upload-ping"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.tustransfers true
+
+  git lfs ping origin 2>&1 | tee ping.log
+  grep "^Download=supported$" ping.log
+  grep "^Upload=supported$" ping.log
+  grep "^  Transfer=tus$" ping.log
+  grep "^  Adapters=basic,tus$" ping.log
+  grep "^  Adapters=basic$" ping.log
+)
+end_test
+
+begin_test "ping with server without locking"
+(
+  set -e
+
+  reponame="ping-locks-unsupported"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs ping 2>&1 | tee ping.log
+  grep "^Download=supported$" ping.log
+  grep "^Locking=not supported$" ping.log
+)
+end_test
+
+begin_test "ping with server without batch API"
+(
+  set -e
+
+  reponame="batchunsupported"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame-ping"
+
+  git lfs ping 2>&1 | tee ping.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ping' to fail"
+    exit 1
+  fi
+
+  grep "^Download=error: " ping.log
+  grep "^Upload=error: " ping.log
+  grep "Unable to reach the Git LFS server for remote \"origin\"" ping.log
+)
+end_test
+
+begin_test "ping with invalid remote"
+(
+  set -e
+
+  reponame="ping-invalid-remote"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs ping not-a-remote 2>&1 | tee ping.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ping' to fail"
+    exit 1
+  fi
+
+  grep "Invalid remote name \"not-a-remote\"" ping.log
+)
+end_test
+
+begin_test "ping --adapters"
+(
+  set -e
+
+  reponame="test-custom-transfer-ping-adapters"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.customtransfer.testcustom.path lfstest-customadapter
+  git config lfs.customtransfer.broken.path path-to-nothing
+  git config lfs.tustransfers true
+
+  git lfs ping --adapters 2>&1 | tee ping.log
+  grep "^Remote=origin$" ping.log
+  grep "^Download.basic=ok$" ping.log
+  grep "^Upload.basic=ok$" ping.log
+  grep "^Download.testcustom=ok$" ping.log
+  grep "^Upload.testcustom=ok$" ping.log
+  grep "^Upload.tus=not accepted by the server$" ping.log
+  [ 0 -eq "$(grep -c "^Download.tus=" ping.log)" ]
+  [ 0 -eq "$(grep -c "\.ssh=" ping.log)" ]
+  [ 0 -eq "$(grep -c "\.lfs-standalone-file=" ping.log)" ]
+  grep "^Download.broken=error: failed to start transfer adapter \"broken\"" ping.log
+  grep "^Upload.broken=error: failed to start transfer adapter \"broken\"" ping.log
+  [ 0 -eq "$(grep -c "^Locking=" ping.log)" ]
+)
+end_test
+
+begin_test "ping --explain"
+(
+  set -e
+
+  reponame="test-custom-transfer-ping-explain"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.customtransfer.testcustom.path lfstest-customadapter
+  git config lfs.customtransfer.testcustom.direction upload
+  git config lfs.customtransfer.other.path path-to-nothing
+
+  git lfs ping --explain 2>&1 | tee ping.log
+  grep "^Download.Selected=basic$" ping.log
+  grep "^Download.Offered=basic,other$" ping.log
+  grep "^Download.basic=selected: chosen by the server$" ping.log
+  grep "^Download.other=not selected: not accepted by the server$" ping.log
+  grep "^Download.testcustom=not offered: only configured for upload$" ping.log
+  grep "^Upload.Selected=testcustom$" ping.log
+  grep "^Upload.Offered=basic,other,testcustom$" ping.log
+  grep "^Upload.testcustom=selected: chosen by the server$" ping.log
+  grep "^Upload.basic=not selected: accepted by the server, which preferred testcustom$" ping.log
+  grep "^Upload.other=not selected: not accepted by the server$" ping.log
+  [ 0 -eq "$(grep -c "^Locking=" ping.log)" ]
+
+  git -c lfs.basictransfersonly=true lfs ping --explain 2>&1 | tee ping.log
+  grep "^Upload.Selected=basic$" ping.log
+  grep "^Upload.Offered=basic$" ping.log
+  grep "^Upload.testcustom=not offered: lfs.basictransfersonly is set$" ping.log
+
+  git lfs ping --explain --adapters 2>&1 | tee ping.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ping --explain --adapters' to fail"
+    exit 1
+  fi
+  grep "Cannot use --adapters with --explain" ping.log
+)
+end_test
+
+begin_test "ping --adapters with server without batch API"
+(
+  set -e
+
+  # The "batchunsupported" remote was created by an earlier test.
+  clone_repo "batchunsupported" "batchunsupported-ping-adapters"
+
+  git lfs ping --adapters 2>&1 | tee ping.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs ping --adapters' to fail"
+    exit 1
+  fi
+
+  grep "^Download.basic=error: " ping.log
+  grep "^Upload.basic=error: " ping.log
+  grep "Unable to reach the Git LFS server for remote \"origin\"" ping.log
+)
+end_test
@@ -437,3 +437,50 @@ begin_test "pointer --check (with invalid arguments)"
   true
 )
 end_test
+
+begin_test "pointer --where"
+(
+  set -e
+
+  reponame="pointer-where"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "stored" > a.dat
+  git add a.dat
+
+  stored="$(calc_oid "stored")"
+  missing="$(calc_oid "missing")"
+  objects="$(git lfs env | grep LocalMediaDir | cut -d = -f 2)"
+
+  git lfs pointer --where "$stored" | tee where.log
+  grep "^oid=$stored$" where.log
+  grep "^path=$objects/${stored:0:2}/${stored:2:2}/$stored$" where.log
+  grep "^exists=true$" where.log
+  grep "^size=6$" where.log
+
+  # The OID may be given in upper case, or with its "sha256:" prefix.
+  [ "$(cat where.log)" = "$(git lfs pointer --where "sha256:$(echo "$stored" | tr a-f A-F)")" ]
+
+  git lfs pointer --where "$missing" | tee where.log
+  grep "^path=$objects/${missing:0:2}/${missing:2:2}/$missing$" where.log
+  grep "^exists=false$" where.log
+  grep "^size=" where.log && exit 1
+  [ ! -d "$objects/${missing:0:2}" ]
+
+  # The path follows the shard depth of the object store.
+  git config lfs.storage.sharddepth 1
+  git lfs pointer --where "$missing" | grep "^path=$objects/${missing:0:2}/$missing$"
+
+  git lfs pointer --where "not-an-oid" 2>&1 | tee where.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs pointer --where' to fail"
+    exit 1
+  fi
+  grep 'Invalid object ID "not-an-oid"' where.log
+
+  git lfs pointer --where "$stored" --file a.dat && exit 1
+  true
+)
+end_test
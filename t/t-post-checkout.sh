@@ -209,3 +209,34 @@ begin_test "post-checkout with subdirectories"
   [ "$(cat file3.big)" == "file 3 updated in branch2" ]
 )
 end_test
+
+begin_test "post-checkout with lfs.checkout.readonlylocked"
+(
+  set -e
+
+  reponame="post-checkout-readonly-locked"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "theirs" > theirs.dat
+  echo "unlocked" > unlocked.dat
+  git add .gitattributes theirs.dat unlocked.dat
+  git commit -m "add files"
+  git push origin main
+
+  # The test server reports locks on paths containing "theirs" as held by
+  # somebody else when they are verified.
+  git lfs lock theirs.dat
+  git lfs locks --verify
+
+  git config lfs.checkout.readonlylocked true
+
+  rm theirs.dat unlocked.dat
+  git checkout -- theirs.dat unlocked.dat
+
+  [ "theirs" = "$(cat theirs.dat)" ]
+  refute_file_writeable theirs.dat
+  assert_file_writeable unlocked.dat
+)
+end_test
@@ -104,3 +104,49 @@ begin_test "post-commit does not enter submodules"
   ! grep -E 'filepathfilter:.*submodule/foo' output
 )
 end_test
+
+begin_test "post-commit: lfs.largeuntrackedfilewarning"
+(
+  set -e
+
+  reponame="post-commit-large-untracked"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs install --local
+  git config lfs.largeuntrackedfilewarning 2KB
+
+  git lfs track "*.dat"
+  base64 /dev/urandom | head -c 4096 > tracked.dat
+  base64 /dev/urandom | head -c 4096 > big.bin
+  base64 /dev/urandom | head -c 1024 > small.bin
+  git add .gitattributes tracked.dat big.bin small.bin
+  git commit -m "add files" 2>&1 | tee commit.log
+
+  grep "Warning: big.bin (4.1 KB) was committed without being tracked by Git LFS." commit.log
+  grep 'To track it, run: git lfs track "big.bin"' commit.log
+  grep "Warning: small.bin" commit.log && exit 1
+  grep "Warning: tracked.dat" commit.log && exit 1
+
+  # Only the files changed by the commit are checked.
+  echo "change" >> small.bin
+  git add small.bin
+  git commit -m "change small.bin" 2>&1 | tee commit.log
+  grep "Warning:" commit.log && exit 1
+
+  # Pointers are never reported, even when they are above the threshold.
+  git config lfs.largeuntrackedfilewarning 100B
+  base64 /dev/urandom | head -c 500 > other.dat
+  base64 /dev/urandom | head -c 500 > other.bin
+  git add other.dat other.bin
+  git commit -m "add other files" 2>&1 | tee commit.log
+  grep "Warning: other.bin (500 B) was committed without being tracked by Git LFS." commit.log
+  grep "Warning: other.dat" commit.log && exit 1
+
+  git config --unset lfs.largeuntrackedfilewarning
+  base64 /dev/urandom | head -c 4096 > unwarned.bin
+  git add unwarned.bin
+  git commit -m "add unwarned.bin" 2>&1 | tee commit.log
+  [ 0 -eq "$(grep -c "Warning:" commit.log)" ]
+)
+end_test
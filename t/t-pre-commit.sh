@@ -0,0 +1,99 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "pre-commit: unlocked lockable file"
+(
+  set -e
+
+  reponame="pre-commit-unlocked"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.setlockablereadonly false
+
+  git lfs track --lockable "*.dat"
+  git lfs track "*.big" # not lockable
+  echo "lockable" > a.dat
+  echo "not lockable" > a.big
+  git add .gitattributes a.dat a.big
+  git commit -m "initial commit"
+  git push origin main
+
+  echo "changed" > a.dat
+  echo "changed" > a.big
+  git add a.dat a.big
+
+  git lfs status 2>&1 | tee status.log
+  grep "a.dat (.*) \[not locked\]" status.log
+  grep "a.big (.*)" status.log
+  [ 0 -eq "$(grep -c "a.big .*not locked" status.log)" ]
+
+  git lfs pre-commit 2>&1 | tee pre-commit.log
+  grep "Warning: a.dat is lockable but is not locked by you." pre-commit.log
+  grep 'To lock it, run: git lfs lock "a.dat"' pre-commit.log
+  [ 0 -eq "$(grep -c "a.big" pre-commit.log)" ]
+
+  git config lfs.unlockedchanges deny
+  git lfs pre-commit 2>&1 | tee pre-commit.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected pre-commit to fail ..."
+    exit 1
+  fi
+  grep "Error: a.dat is lockable but is not locked by you." pre-commit.log
+  grep "Commit aborted" pre-commit.log
+
+  printf '#!/bin/sh\ngit lfs pre-commit\n' > .git/hooks/pre-commit
+  chmod +x .git/hooks/pre-commit
+  old="$(git rev-parse HEAD)"
+  git commit -m "change unlocked file" 2>&1 | tee commit.log
+  [ "$old" = "$(git rev-parse HEAD)" ]
+
+  git config lfs.unlockedchanges allow
+  git lfs pre-commit 2>&1 | tee pre-commit.log
+  [ 0 -eq "$(grep -c "a.dat" pre-commit.log)" ]
+  git lfs status 2>&1 | tee status.log
+  [ 0 -eq "$(grep -c "not locked" status.log)" ]
+
+  git commit -m "change unlocked file"
+  [ "$old" != "$(git rev-parse HEAD)" ]
+)
+end_test
+
+begin_test "pre-commit: locked lockable file"
+(
+  set -e
+
+  reponame="pre-commit-locked"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.setlockablereadonly false
+
+  git lfs track --lockable "*.dat"
+  echo "lockable" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  git lfs lock a.dat
+
+  git config lfs.unlockedchanges deny
+  printf '#!/bin/sh\ngit lfs pre-commit\n' > .git/hooks/pre-commit
+  chmod +x .git/hooks/pre-commit
+
+  echo "changed" > a.dat
+  git add a.dat
+
+  git lfs status 2>&1 | tee status.log
+  grep "a.dat (.*)" status.log
+  [ 0 -eq "$(grep -c "not locked" status.log)" ]
+
+  git lfs pre-commit 2>&1 | tee pre-commit.log
+  [ 0 -eq "$(grep -c "a.dat" pre-commit.log)" ]
+
+  old="$(git rev-parse HEAD)"
+  git commit -m "change locked file"
+  [ "$old" != "$(git rev-parse HEAD)" ]
+)
+end_test
@@ -107,3 +107,72 @@ begin_test "prune worktree"
 )
 end_test
 
+
+begin_test "prune worktree index"
+(
+  set -e
+
+  reponame="prune_worktree_index"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "$reponame"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \"\*.dat\"" track.log
+
+  content_head="Main checkout HEAD"
+  content_worktreehead="Worktree HEAD"
+  content_staged="Staged only in worktree"
+  content_oldcommit="Always pruned"
+
+  oid_head=$(calc_oid "$content_head")
+  oid_worktreehead=$(calc_oid "$content_worktreehead")
+  oid_staged=$(calc_oid "$content_staged")
+
+  echo "[
+  {
+    \"CommitDate\":\"$(get_date -40d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_oldcommit}, \"Data\":\"$content_oldcommit\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -30d)\",
+    \"NewBranch\":\"branch1\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_worktreehead}, \"Data\":\"$content_worktreehead\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -20d)\",
+    \"ParentBranches\":[\"main\"],
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"}]
+  }
+  ]" | lfstest-testutils addcommits
+
+  git push origin main:main branch1:branch1
+
+  # don't keep any recent, just checkouts
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentremoterefs true
+  git config lfs.fetchrecentcommitsdays 0
+
+  # stage an object in a linked worktree without committing it, so that
+  # only that worktree's index refers to it
+  git worktree add "../w_$reponame" "branch1"
+  pushd "../w_$reponame"
+    git lfs track "*.dat"
+    printf "%s" "$content_staged" > staged.dat
+    git add .gitattributes staged.dat
+  popd
+  assert_local_object "$oid_staged" "${#content_staged}"
+
+  git lfs prune --dry-run --verbose 2>&1 | tee prune.log
+  grep "prune: 4 local objects, 3 retained, done." prune.log
+  grep "prune: 1 file would be pruned" prune.log
+
+  git lfs prune 2>&1 | tee prune.log
+  assert_local_object "$oid_head" "${#content_head}"
+  assert_local_object "$oid_worktreehead" "${#content_worktreehead}"
+  assert_local_object "$oid_staged" "${#content_staged}"
+)
+end_test
@@ -1133,3 +1133,153 @@ begin_test "prune doesn't hang on long lines in stash diff"
     git lfs prune
 )
 end_test
+
+begin_test "prune keep objects referenced by notes with lfs.includenotes"
+(
+  set -e
+
+  reponame="prune-include-notes"
+  setup_remote_repo "remote-$reponame"
+
+  clone_repo "remote-$reponame" "clone-$reponame"
+
+  git lfs track "*.dat"
+  printf "file" > file.dat
+  git add .gitattributes file.dat
+  git commit -m "add file.dat"
+  git push origin main
+
+  content_note="this data is referenced only by a note"
+  oid_note="$(calc_oid "$content_note")"
+  printf "%s" "$content_note" | git lfs clean > note.txt
+  git notes add -F note.txt HEAD
+  assert_local_object "$oid_note" "${#content_note}"
+
+  git config lfs.includenotes true
+  git lfs prune --force
+  assert_local_object "$oid_note" "${#content_note}"
+
+  git config --unset lfs.includenotes
+  git lfs prune --force
+  refute_local_object "$oid_note"
+)
+end_test
+
+begin_test "prune caches recent objects with --cache-recent"
+(
+  set -e
+
+  reponame="prune_cache_recent"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+
+  content_old="this is the old data"
+  oid_old=$(calc_oid "$content_old")
+  content_head="this is the data at HEAD"
+  oid_head=$(calc_oid "$content_head")
+  content_new="this is the data in a new commit"
+  oid_new=$(calc_oid "$content_new")
+
+  echo "[
+  {
+    \"CommitDate\":\"$(get_date -1d)\",
+    \"Files\":[
+      {\"Filename\":\"file.dat\",\"Size\":${#content_old}, \"Data\":\"$content_old\"}]
+  }
+  ]" | lfstest-testutils addcommits
+
+  printf '%s' "$content_head" > file.dat
+  git add file.dat
+  git commit -m 'Update file.dat'
+  git push origin main
+
+  git config lfs.fetchrecentcommitsdays 3
+
+  GIT_TRACE=1 git lfs prune --cache-recent 2>&1 | tee prune.log
+  grep "PRUNE: caching 2 recent objects" prune.log
+  [ -f .git/lfs/prune-recent ]
+  assert_local_object "$oid_old" "${#content_old}"
+  assert_local_object "$oid_head" "${#content_head}"
+
+  # Nothing has moved, so the cache is used.
+  GIT_TRACE=1 git lfs prune --cache-recent 2>&1 | tee prune.log
+  grep "PRUNE: using 2 cached recent objects" prune.log
+  grep "PRUNE: caching" prune.log && exit 1
+  assert_local_object "$oid_old" "${#content_old}"
+  assert_local_object "$oid_head" "${#content_head}"
+
+  # The cache is only used when asked for.
+  GIT_TRACE=1 git lfs prune 2>&1 | tee prune.log
+  grep "PRUNE: using" prune.log && exit 1
+
+  # Moving HEAD invalidates the cache.
+  printf '%s' "$content_new" > file.dat
+  git add file.dat
+  git commit -m 'Update file.dat again'
+  git push origin main
+
+  git config lfs.prunecacherecent true
+  GIT_TRACE=1 git lfs prune 2>&1 | tee prune.log
+  grep "PRUNE: using" prune.log && exit 1
+  grep "PRUNE: caching 3 recent objects" prune.log
+  assert_local_object "$oid_old" "${#content_old}"
+  assert_local_object "$oid_head" "${#content_head}"
+  assert_local_object "$oid_new" "${#content_new}"
+
+  GIT_TRACE=1 git lfs prune 2>&1 | tee prune.log
+  grep "PRUNE: using 3 cached recent objects" prune.log
+)
+end_test
+
+begin_test "prune reports objects referenced only by the reflog"
+(
+  set -e
+
+  reponame="prune_reflog_only"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+
+  content_old="this is the old data"
+  oid_old=$(calc_oid "$content_old")
+  content_head="this is the data at HEAD"
+  oid_head=$(calc_oid "$content_head")
+  content_deleted="this is the data on a deleted branch"
+  oid_deleted=$(calc_oid "$content_deleted")
+
+  printf '%s' "$content_old" > file.dat
+  git add .gitattributes file.dat
+  git commit -m 'Add file.dat'
+  printf '%s' "$content_head" > file.dat
+  git add file.dat
+  git commit -m 'Update file.dat'
+  git push origin main
+
+  git checkout -b deleted
+  printf '%s' "$content_deleted" > file.dat
+  git add file.dat
+  git commit -m 'Update file.dat on a branch'
+  git checkout main
+  git branch -D deleted
+
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 0
+
+  git lfs prune --dry-run --verbose 2>&1 | tee prune.log
+  grep "prune: 2 files would be pruned" prune.log
+  grep "$oid_deleted (.*, referenced only by the reflog)" prune.log
+  grep "$oid_old (.*)" prune.log
+  grep "$oid_old (.*reflog)" prune.log && exit 1
+
+  # The reflog does not retain objects, so they are pruned regardless.
+  git lfs prune
+  refute_local_object "$oid_deleted"
+  refute_local_object "$oid_old"
+  assert_local_object "$oid_head" "${#content_head}"
+)
+end_test
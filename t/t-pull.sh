@@ -318,7 +318,7 @@ begin_test "pull with merge conflict"
   # This will exit nonzero because of the merge conflict.
   GIT_LFS_SKIP_SMUDGE=1 git merge def || true
   git lfs pull > pull.log 2>&1
-  [ ! -s pull.log ]
+  [ "pull: Everything up to date" = "$(cat pull.log)" ]
 )
 end_test
 
@@ -350,6 +350,32 @@ begin_test "pull: with missing object"
 )
 end_test
 
+begin_test "pull: all objects present"
+(
+  set -e
+
+  reponame="pull-all-objects-present"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  rm a.dat
+
+  # No batch request is needed when every object is already present, but
+  # the files are still checked out.
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "pull: Everything up to date" pull.log
+  grep "tq: sending batch" pull.log && exit 1
+  grep "api: batch" pull.log && exit 1
+  [ "a" = "$(cat a.dat)" ]
+)
+end_test
+
 begin_test "pull: outside git repository"
 (
   set +e
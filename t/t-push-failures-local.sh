@@ -194,3 +194,48 @@ begin_test "push reject corrupt objects (lfs.allowincompletepush default)"
   assert_server_object "$reponame" "$present_oid"
 )
 end_test
+
+begin_test "push reject objects whose content does not match (lfs.upload.verifylocal)"
+(
+  set -e
+
+  reponame="push-verify-local-objects"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  corrupt="corrupt"
+  corrupt_oid="$(calc_oid "$corrupt")"
+  printf "%s" "$corrupt" > corrupt.dat
+  git add corrupt.dat
+  git commit -m "add corrupt.dat"
+
+  present="present"
+  present_oid="$(calc_oid "$present")"
+  printf "%s" "$present" > present.dat
+  git add present.dat
+  git commit -m "add present.dat"
+
+  # Replace the object with other content of the same size, which only
+  # hashing it reveals.
+  mediadir="$(git lfs env | grep LocalMediaDir)"
+  printf "%s" "garbage" > "${mediadir:14}/${corrupt_oid:0:2}/${corrupt_oid:2:2}/$corrupt_oid"
+
+  git config lfs.upload.verifylocal true
+  git push origin main 2>&1 | tee push.log
+
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git push origin main' to exit with non-zero code"
+    exit 1
+  fi
+
+  grep "LFS upload failed:" push.log
+  grep "  (corrupt) corrupt.dat ($corrupt_oid)" push.log
+
+  refute_server_object "$reponame" "$corrupt_oid"
+  assert_server_object "$reponame" "$present_oid"
+)
+end_test
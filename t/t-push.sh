@@ -112,6 +112,67 @@ begin_test "push"
 )
 end_test
 
+begin_test "push --dry-run --local"
+(
+  set -e
+
+  reponame="push-dry-run-local"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "pushed" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+  pushed_oid="$(calc_oid "pushed")"
+
+  printf "local one" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  one_oid="$(calc_oid "local one")"
+
+  git checkout -b other
+  printf "local two" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  two_oid="$(calc_oid "local two")"
+  git checkout main
+
+  # Point the remote somewhere which cannot be reached, so that any attempt to
+  # contact it fails.
+  git remote set-url origin "$TRASHDIR/missing-remote"
+  git config lfs.url "http://127.0.0.1:1/missing"
+
+  git lfs push --dry-run --local origin main 2>&1 | tee push.log
+  grep "push $one_oid => b.dat" push.log
+  [ $(grep -c "^push " push.log) -eq 1 ]
+
+  # A branch without a remote-tracking ref is compared against all of those
+  # of the remote.
+  git lfs push --dry-run --local origin other 2>&1 | tee push.log
+  grep "push $one_oid => b.dat" push.log
+  grep "push $two_oid => c.dat" push.log
+  [ $(grep -c "^push " push.log) -eq 2 ]
+
+  git lfs push --dry-run --local --all origin main 2>&1 | tee push.log
+  grep "push $pushed_oid => a.dat" push.log
+  grep "push $one_oid => b.dat" push.log
+  [ $(grep -c "^push " push.log) -eq 2 ]
+
+  git update-ref refs/remotes/origin/main main
+  git lfs push --dry-run --local origin main 2>&1 | tee push.log
+  [ $(grep -c "^push " push.log) -eq 0 ]
+
+  git lfs push --local origin main 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs push --local\` without --dry-run to fail ..."
+    exit 1
+  fi
+  grep -- "--local can only be used with --dry-run" push.log
+)
+end_test
+
 # helper used by the next few push --all tests to set up their repos
 push_all_setup() {
   suffix="$1"
@@ -788,6 +849,50 @@ begin_test 'push with multiple refs and data the server already has'
 )
 end_test
 
+begin_test 'push with dedup and data the server has for another repository'
+(
+  set -e
+
+  reponame="push-dedup-server-data"
+  setup_remote_repo "$reponame-other"
+  clone_repo "$reponame-other" "$reponame-other"
+
+  git lfs track "*.dat"
+  contents="abc123"
+  contents_oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git push origin main
+  assert_server_object "$reponame-other" "$contents_oid"
+
+  cd ..
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # Without dedup, the server asks for the object again.
+  git lfs push --dry-run origin main 2>&1 | tee push.log
+  grep "push $contents_oid => a.dat" push.log
+
+  # With dedup, the server reports it already has the content, so we can
+  # push even without a local copy of the object.
+  delete_local_object "$contents_oid"
+  git config lfs.transfer.dedup true
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  grep "tq: server already has $contents_oid, skipping upload" push.log
+  grep "PUT .*/storage/$contents_oid" push.log && exit 1
+
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
 begin_test "push custom reference"
 (
   set -e
@@ -872,3 +977,159 @@ begin_test "storage upload with compression"
   popd
 )
 end_test
+
+begin_test "push with --report"
+(
+  set -e
+
+  reponame="push-report"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+
+  good="good"
+  good_oid="$(calc_oid "$good")"
+  printf "%s" "$good" > good.dat
+
+  bad="status-storage-404"
+  bad_oid="$(calc_oid "$bad")"
+  printf "%s" "$bad" > bad.dat
+
+  git add .gitattributes good.dat bad.dat
+  git commit -m "initial commit"
+
+  git config --local lfs.transfer.maxretries 1
+
+  git lfs push --report report.json origin main 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git lfs push\` to fail ..."
+    exit 1
+  fi
+
+  cat report.json
+  grep "\"oid\":\"$good_oid\",\"name\":\"good.dat\",\"bytes\":4,\"duration_ms\":[0-9]*,\"adapter\":\"basic\",\"host\":\"127.0.0.1:[0-9]*\",\"success\":true}" report.json
+  grep "\"oid\":\"$bad_oid\",\"name\":\"bad.dat\",\"bytes\":0,[^}]*\"success\":false,\"error\":\"[^\"]*not found[^}]*}" report.json
+
+  assert_server_object "$reponame" "$good_oid"
+  refute_server_object "$reponame" "$bad_oid"
+)
+end_test
+
+begin_test "push with their lock and lfs.push.locksverify"
+(
+  set -e
+
+  reponame="push-locksverify-modes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  # any lock path with "theirs" is returned as "their" lock by /locks/verify
+  printf "locked contents" > locked_theirs.dat
+  git add locked_theirs.dat
+  git commit -m "add locked_theirs.dat"
+  git push origin main
+
+  git lfs lock --json "locked_theirs.dat" | tee lock.log
+  id=$(assert_lock lock.log locked_theirs.dat)
+  assert_server_lock $id
+
+  pushd "$TRASHDIR" >/dev/null
+    clone_repo "$reponame" "$reponame-assert"
+
+    printf "unauthorized changes" >> locked_theirs.dat
+    git add locked_theirs.dat
+    git commit --no-verify -m "add unauthorized changes"
+    oid="$(calc_oid_file locked_theirs.dat)"
+
+    git config lfs.push.locksverify true
+    git lfs push origin main 2>&1 | tee push.log
+    if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+      echo >&2 "fatal: expected push with lfs.push.locksverify=true to fail"
+      exit 1
+    fi
+    [ "1" -eq "$(grep -c "\* locked_theirs.dat - Git LFS Tests" push.log)" ]
+    grep "Cannot update locked files." push.log
+    refute_server_object "$reponame" "$oid"
+
+    # lfs.push.locksverify takes precedence over lfs.<url>.locksverify.
+    endpoint="$(repo_endpoint $GITSERVER $reponame)"
+    git config "lfs.$endpoint.locksverify" true
+    git config lfs.push.locksverify warn
+    git lfs push origin main 2>&1 | tee push.log
+    grep "Unable to push locked files" push.log
+    [ "1" -eq "$(grep -c "\* locked_theirs.dat - Git LFS Tests" push.log)" ]
+    grep "warning: The above files would have halted this push." push.log
+    grep "Cannot update locked files." push.log && exit 1
+    grep "Consider enabling it" push.log && exit 1
+    assert_server_object "$reponame" "$oid"
+
+    git config lfs.push.locksverify false
+    git lfs push origin main 2>&1 | tee push.log
+    grep "locked_theirs.dat" push.log && exit 1
+    true
+  popd >/dev/null
+)
+end_test
+
+begin_test "push --report-orphaned"
+(
+  set -e
+
+  reponame="push-report-orphaned"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "shared" > shared.dat
+  git add .gitattributes shared.dat
+  git commit -m "initial commit"
+  git push origin main
+
+  printf "dropped" > dropped.dat
+  printf "kept" > kept.dat
+  git add dropped.dat kept.dat
+  git commit -m "add dropped.dat and kept.dat"
+  git push origin main
+
+  dropped_oid="$(calc_oid "dropped")"
+  kept_oid="$(calc_oid "kept")"
+  shared_oid="$(calc_oid "shared")"
+
+  # Rewrite the history so that only kept.dat remains.
+  git reset --hard HEAD^
+  printf "kept" > kept.dat
+  git add kept.dat
+  git commit -m "add kept.dat"
+
+  git lfs push --report-orphaned origin main 2>&1 | tee push.log
+  grep "Force-updating \"main\" on \"origin\" leaves 1 Git LFS object unreferenced:" push.log
+  grep "  $dropped_oid dropped.dat" push.log
+  grep "$kept_oid" push.log && exit 1
+  grep "$shared_oid" push.log && exit 1
+
+  # Without the option, nothing is reported.
+  git lfs push origin main 2>&1 | tee push.log
+  grep "unreferenced" push.log && exit 1
+
+  # The pre-push hook reports the objects when lfs.push.reportOrphaned is set.
+  git config lfs.push.reportOrphaned true
+  git push --force origin main 2>&1 | tee push.log
+  grep "Force-updating \"main\" on \"origin\" leaves 1 Git LFS object unreferenced:" push.log
+  grep "  $dropped_oid dropped.dat" push.log
+
+  # A fast-forward orphans nothing.
+  printf "more" > more.dat
+  git add more.dat
+  git commit -m "add more.dat"
+  git push origin main 2>&1 | tee push.log
+  grep "unreferenced" push.log && exit 1
+
+  git lfs push --report-orphaned --object-id origin "$kept_oid" 2>&1 | tee push.log
+  grep -- "--report-orphaned cannot be combined with --object-id" push.log
+)
+end_test
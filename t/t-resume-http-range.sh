@@ -28,15 +28,12 @@ begin_test "resume-http-range"
   assert_server_object "$reponame" "$contents_oid"
 
   # delete local copy then fetch it back
-  # server will abort the transfer mid way (so will error) when not resuming
-  # then we can restart it
+  # server will abort the transfer mid way when not resuming, and the retry
+  # should try to resume and server should send remainder this time (it does
+  # not cut short when Range is requested)
   rm -rf .git/lfs/objects
-  git lfs fetch 2>&1 | tee fetchinterrupted.log
-  refute_local_object "$contents_oid"
-
-  # now fetch again, this should try to resume and server should send remainder
-  # this time (it does not cut short when Range is requested)
   GIT_TRACE=1 git lfs fetch 2>&1 | tee fetchresume.log
+  grep "xfer: incomplete download of \"$contents_oid\"" fetchresume.log
   grep "xfer: server accepted resume" fetchresume.log
   assert_local_object "$contents_oid" "${#contents}"
 
@@ -69,15 +66,12 @@ begin_test "resume-http-range-fallback"
   assert_server_object "$reponame" "$contents_oid"
 
   # delete local copy then fetch it back
-  # server will abort the transfer mid way (so will error) when not resuming
-  # then we can restart it
+  # server will abort the transfer mid way when not resuming, and the retry
+  # should try to resume but server should reject the Range header, which
+  # should cause client to re-download
   rm -rf .git/lfs/objects
-  git lfs fetch 2>&1 | tee fetchinterrupted.log
-  refute_local_object "$contents_oid"
-
-  # now fetch again, this should try to resume but server should reject the Range
-  # header, which should cause client to re-download
   GIT_TRACE=1 git lfs fetch 2>&1 | tee fetchresumefallback.log
+  grep "xfer: incomplete download of \"$contents_oid\"" fetchresumefallback.log
   grep "xfer: server rejected resume" fetchresumefallback.log
   # re-download should still have worked
   assert_local_object "$contents_oid" "${#contents}"
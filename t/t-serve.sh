@@ -0,0 +1,134 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "serve --json-rpc scan and fetch"
+(
+  set -e
+
+  reponame="serve-json-rpc-scan-fetch"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="a"
+  contents_b="bb"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+  git push origin main
+
+  rm -rf .git/lfs/objects
+  refute_local_object "$oid_a"
+  refute_local_object "$oid_b"
+
+  printf '%s\n' \
+    '{"jsonrpc":"2.0","id":1,"method":"scan","params":{"ref":"HEAD"}}' \
+    "{\"jsonrpc\":\"2.0\",\"id\":2,\"method\":\"fetch\",\"params\":{\"objects\":[{\"oid\":\"$oid_a\",\"size\":1,\"name\":\"a.dat\"}]}}" |
+    git lfs serve --json-rpc | tee serve.log
+
+  [ 2 -eq "$(grep -c '"method":"scan.pointer"' serve.log)" ]
+  grep '"method":"scan.pointer","params":{"id":1,"name":"a.dat","oid":"'"$oid_a"'","present":false,"size":1}' serve.log
+  grep '"method":"scan.pointer","params":{"id":1,"name":"b.dat","oid":"'"$oid_b"'","present":false,"size":2}' serve.log
+  grep '{"jsonrpc":"2.0","id":1,"result":{"pointers":2,"size":3}}' serve.log
+
+  grep '"method":"fetch.progress","params":{"bytes":1,"id":2,"oid":"'"$oid_a"'","size":1}' serve.log
+  grep '"method":"fetch.object","params":{"id":2,"name":"a.dat","oid":"'"$oid_a"'","size":1}' serve.log
+  grep '{"jsonrpc":"2.0","id":2,"result":{"failed":\[\],"fetched":1,"skipped":0}}' serve.log
+
+  assert_local_object "$oid_a" 1
+  refute_local_object "$oid_b"
+
+  # Fetching a ref skips the objects which are already present.
+  echo '{"jsonrpc":"2.0","id":"ref","method":"fetch","params":{"ref":"main"}}' |
+    git lfs serve --json-rpc | tee serve.log
+  grep '{"jsonrpc":"2.0","id":"ref","result":{"failed":\[\],"fetched":1,"skipped":1}}' serve.log
+  assert_local_object "$oid_b" 2
+)
+end_test
+
+begin_test "serve --json-rpc locks"
+(
+  set -e
+
+  reponame="serve-json-rpc-locks"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+
+  echo '{"jsonrpc":"2.0","id":1,"method":"locks.lock","params":{"path":"a.dat"}}' |
+    git lfs serve --json-rpc | tee serve.log
+  id="$(grep -o '"result":{"id":"[^"]*"' serve.log | cut -d'"' -f6)"
+  [ -n "$id" ]
+  grep '"path":"a.dat"' serve.log
+  assert_server_lock "$reponame" "$id" "refs/heads/main"
+
+  echo '{"jsonrpc":"2.0","id":2,"method":"locks.list","params":{"path":"a.dat"}}' |
+    git lfs serve --json-rpc | tee serve.log
+  grep '{"jsonrpc":"2.0","id":2,"result":{"locks":\[{"id":"'"$id"'","path":"a.dat"' serve.log
+
+  echo '{"jsonrpc":"2.0","id":3,"method":"locks.unlock","params":{"id":"'"$id"'"}}' |
+    git lfs serve --json-rpc | tee serve.log
+  grep '{"jsonrpc":"2.0","id":3,"result":{"id":"'"$id"'"}}' serve.log
+  refute_server_lock "$reponame" "$id" "refs/heads/main"
+
+  echo '{"jsonrpc":"2.0","id":4,"method":"locks.unlock","params":{}}' |
+    git lfs serve --json-rpc | tee serve.log
+  grep '{"jsonrpc":"2.0","id":4,"error":{"code":-32602,' serve.log
+)
+end_test
+
+begin_test "serve --json-rpc cancel"
+(
+  set -e
+
+  reponame="serve-json-rpc-cancel"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="storage-download-stall"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin main
+  rm -rf .git/lfs/objects
+
+  # The server sends part of the object and then stalls, so the fetch is
+  # still in progress when it is canceled.
+  (
+    echo "{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"fetch\",\"params\":{\"objects\":[{\"oid\":\"$oid\",\"size\":${#contents}}]}}"
+    sleep 2
+    echo '{"jsonrpc":"2.0","id":2,"method":"cancel","params":{"id":1}}'
+  ) | git lfs serve --json-rpc | tee serve.log
+
+  grep '{"jsonrpc":"2.0","id":2,"result":{"canceled":true}}' serve.log
+  grep '{"jsonrpc":"2.0","id":1,"error":{"code":-32800,"message":"request canceled"}}' serve.log
+  refute_local_object "$oid"
+)
+end_test
+
+begin_test "serve requires --json-rpc"
+(
+  set -e
+
+  git init serve-no-json-rpc
+  cd serve-no-json-rpc
+
+  git lfs serve < /dev/null 2>&1 | tee serve.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs serve' to fail"
+    exit 1
+  fi
+  grep "git lfs serve requires --json-rpc" serve.log
+)
+end_test
@@ -57,6 +57,88 @@ begin_test "smudge with invalid pointer"
 )
 end_test
 
+begin_test "smudge with size-mismatched local object"
+(
+  set -e
+
+  cd repo
+
+  oid="fcf5015df7a9089a7aa7fe74139d4b8f7d62e52d5a34f9a87aeffc8e8c668254"
+  path=".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+  git lfs fetch origin main
+  printf "smudge" > "$path"
+
+  pointer "$oid" 9 | git lfs smudge >smudge.log 2>smudge.err
+  [ "smudge a" = "$(cat smudge.log)" ]
+  grep "warning: local object $oid is 6 bytes, but the pointer for <unknown file> gives its size as 9; treating it as corrupt" smudge.err
+  [ "smudge a" = "$(cat "$path")" ]
+
+  printf "smudge" > "$path"
+  git config lfs.sizemismatch fail
+  pointer "$oid" 9 | git lfs smudge 2>&1 | tee smudge.log
+  if [ "0" -eq "${PIPESTATUS[1]}" ]; then
+    echo >&2 "fatal: expected smudge of size-mismatched object to fail"
+    exit 1
+  fi
+  grep "local object $oid is 6 bytes, but the pointer for <unknown file> gives its size as 9" smudge.log
+  [ "smudge" = "$(cat "$path")" ]
+
+  git config --unset lfs.sizemismatch
+  rm -f "$path"
+)
+end_test
+
+begin_test "smudge with upper-case oid"
+(
+  set -e
+
+  cd repo
+
+  oid="fcf5015df7a9089a7aa7fe74139d4b8f7d62e52d5a34f9a87aeffc8e8c668254"
+  upper="$(echo "$oid" | tr a-f A-F)"
+
+  # The object is downloaded and stored under its lower-case oid...
+  rm -rf .git/lfs/objects
+  [ "smudge a" = "$(pointer "$upper" 9 | git lfs smudge)" ]
+  assert_local_object "$oid" 9
+
+  # ...and then found there.
+  [ "smudge a" = "$(pointer "$upper" 9 | git -c lfs.url=http://127.0.0.1:1 lfs smudge)" ]
+
+  # An oid which is too short is not mistaken for the object's.
+  [ "$(pointer "${upper:0:63}" 9)" = "$(pointer "${upper:0:63}" 9 | git lfs smudge)" ]
+)
+end_test
+
+begin_test "smudge with zero size for non-empty object"
+(
+  set -e
+
+  cd repo
+
+  oid="fcf5015df7a9089a7aa7fe74139d4b8f7d62e52d5a34f9a87aeffc8e8c668254"
+  path=".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid"
+  git lfs fetch origin main
+
+  pointer "$oid" 0 | git lfs smudge 2>&1 | tee smudge.log
+  if [ "0" -eq "${PIPESTATUS[1]}" ]; then
+    echo >&2 "fatal: expected smudge of zero-size pointer to fail"
+    exit 1
+  fi
+  grep "the pointer for <unknown file> gives its size as 0, but object $oid is not empty" smudge.log
+  [ "smudge a" = "$(cat "$path")" ]
+
+  rm -f "$path"
+  pointer "$oid" 0 | git lfs smudge 2>&1 | tee smudge.log
+  if [ "0" -eq "${PIPESTATUS[1]}" ]; then
+    echo >&2 "fatal: expected smudge of zero-size pointer to fail"
+    exit 1
+  fi
+  grep "the pointer for <unknown file> gives its size as 0, but object $oid is not empty" smudge.log
+  refute_local_object "$oid"
+)
+end_test
+
 begin_test "smudge include/exclude"
 (
   set -e
@@ -250,3 +332,64 @@ begin_test "smudge no ref, non-origin"
   [ "smudge a" = "$(cat a.dat)" ]
 )
 end_test
+
+begin_test "smudge and clean from a subdirectory and a linked worktree"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-subdir-worktree"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p dir/sub
+  contents="subdir contents"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > dir/sub/a.dat
+  git add .gitattributes dir
+  git commit -m "add dir/sub/a.dat"
+
+  cd dir/sub
+  [ "$contents" = "$(pointer "$oid" 15 | git lfs smudge dir/sub/a.dat)" ]
+  [ "$(pointer "$oid" 15)" = "$(printf "%s" "$contents" | git lfs clean dir/sub/a.dat)" ]
+  cd ../..
+
+  git worktree add ../"$reponame-worktree"
+  cd ../"$reponame-worktree"
+  [ "$contents" = "$(cat dir/sub/a.dat)" ]
+
+  cd dir/sub
+  [ "$contents" = "$(pointer "$oid" 15 | git lfs smudge dir/sub/a.dat)" ]
+
+  other="worktree contents"
+  other_oid="$(calc_oid "$other")"
+  [ "$(pointer "$other_oid" 17)" = "$(printf "%s" "$other" | git lfs clean dir/sub/b.dat)" ]
+  cd ../..
+
+  # Objects made in a linked worktree are stored with those of the
+  # repository, not in the worktree's own Git directory.
+  cd ../"$reponame"
+  assert_local_object "$other_oid" 17
+  [ ! -e "$(git rev-parse --git-dir)/worktrees/$reponame-worktree/lfs" ]
+)
+end_test
+
+begin_test "smudge and clean outside a repository"
+(
+  set -e
+
+  mkdir not-a-repo
+  cd not-a-repo
+
+  contents="outside"
+  oid="$(calc_oid "$contents")"
+
+  pointer "$oid" 7 | git lfs smudge a.dat >smudge.out 2>smudge.err && exit 1
+  [ ! -s smudge.out ]
+  grep "git-lfs smudge must be run within a Git repository" smudge.err
+
+  printf "%s" "$contents" | git lfs clean a.dat >clean.out 2>clean.err && exit 1
+  [ ! -s clean.out ]
+  grep "git-lfs clean must be run within a Git repository" clean.err
+)
+end_test
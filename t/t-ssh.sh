@@ -28,7 +28,7 @@ begin_test "ssh with proxy command in lfs.url (default variant)"
   fi
 
   grep 'expected.*git@127.0.0.1' push.log
-  grep "lfs-ssh-echo -- -oProxyCommand" push.log
+  grep "lfs-ssh-echo.* -- -oProxyCommand" push.log
 )
 end_test
 
@@ -61,3 +61,44 @@ begin_test "ssh with proxy command in lfs.url (custom variant)"
   grep "lfs-ssh-echo oProxyCommand" push.log
 )
 end_test
+
+begin_test "ssh authentication shares a control connection"
+(
+  set -e
+
+  reponame="ssh-multiplex-authenticate"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  sshurl="${GITSERVER/http:\/\//ssh://git@}/$reponame"
+  git config lfs.url "$sshurl"
+
+  contents="test"
+  git lfs track "*.dat"
+  printf "%s" "$contents" > test.dat
+  git add .gitattributes test.dat
+  git commit -m "initial commit"
+
+  mkdir -p "$TRASHDIR/runtime"
+  chmod 700 "$TRASHDIR/runtime"
+  export XDG_RUNTIME_DIR="$TRASHDIR/runtime"
+  unset GIT_SSH_VARIANT
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  rm -rf .git/lfs/objects
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+
+  controlpath="-oControlPath=$XDG_RUNTIME_DIR/git-lfs/sock-%C"
+  grep "lfs-ssh-echo -oControlMaster=auto $controlpath .*git-lfs-authenticate .* upload" push.log
+  grep "lfs-ssh-echo -oControlMaster=auto $controlpath .*git-lfs-authenticate .* download" fetch.log
+  [ "0" -eq "$(cat push.log fetch.log | grep "git-lfs-authenticate" | grep "run_command" | grep -cv -- "$controlpath")" ]
+  [ -d "$XDG_RUNTIME_DIR/git-lfs" ]
+
+  git config lfs.ssh.automultiplex false
+  rm -rf .git/lfs/objects
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "lfs-ssh-echo .*git-lfs-authenticate .* download" fetch.log
+  grep "ControlMaster" fetch.log && exit 1
+  true
+)
+end_test
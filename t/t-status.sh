@@ -593,3 +593,86 @@ Objects not staged for commit:"
   [ "$expected" = "$actual" ]
 )
 end_test
+
+begin_test "status -z"
+(
+  set -e
+
+  mkdir repo-null
+  cd repo-null
+  git init
+  git lfs track "*.dat"
+  echo "some data" > "with space.dat"
+  echo "other data" > "$(printf "new\nline.dat")"
+  git add .gitattributes ./*.dat
+  git commit -m "add files"
+
+  echo "changed data" > "with space.dat"
+  git mv "$(printf "new\nline.dat")" "renamed file.dat"
+
+  git lfs status -z > status.out
+  printf " M with space.dat\0R  renamed file.dat\0new\nline.dat\0" > expected.out
+  cmp expected.out status.out
+
+  git lfs status -z --json 2>&1 | tee status.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs status -z --json' to fail"
+    exit 1
+  fi
+  grep "Cannot use -z with --json" status.log
+)
+end_test
+
+begin_test "status --classify"
+(
+  set -e
+
+  reponame="status-classify"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "missing" > missing.dat
+  printf "unsmudged" > unsmudged.dat
+  printf "smudged" > smudged.dat
+  printf "modified" > modified.dat
+  printf "deleted" > deleted.dat
+  git add .gitattributes ./*.dat
+  git commit -m "add files"
+  git push origin main
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+
+  git lfs pull --include="smudged.dat,modified.dat"
+  git lfs fetch --include="unsmudged.dat"
+  printf "changed" > modified.dat
+  rm deleted.dat
+  printf "untracked" > untracked.dat
+  printf "not lfs" > other.txt
+
+  git lfs status --classify > status.log
+  cat status.log
+
+  cat > expected.log <<-\EOM
+	missing   missing.dat
+	modified  modified.dat
+	smudged   smudged.dat
+	unsmudged unsmudged.dat
+	untracked untracked.dat
+EOM
+  diff -u expected.log status.log
+
+  mkdir dir
+  cd dir
+  git lfs status --classify > status.log
+  grep "^smudged   ../smudged.dat$" status.log
+
+  git lfs status --classify --porcelain 2>&1 | tee status.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs status --classify --porcelain' to fail"
+    exit 1
+  fi
+  grep "Cannot use --classify with --porcelain, --json, or -z" status.log
+)
+end_test
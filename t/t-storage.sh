@@ -0,0 +1,245 @@
+#!/usr/bin/env bash
+
+. "$(dirname "$0")/testlib.sh"
+
+begin_test "storage reshard"
+(
+  set -e
+
+  reponame="storage-reshard"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="a"
+  contents_b="b"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  objects="$(git lfs env | grep LocalMediaDir | cut -d= -f2)"
+
+  git lfs storage reshard --depth 3 2>&1 | tee reshard.log
+  grep "Moved 2 objects to shard depth 3" reshard.log
+  [ "3" = "$(git config lfs.storage.sharddepth)" ]
+  [ -f "$objects/${oid_a:0:2}/${oid_a:2:2}/${oid_a:4:2}/$oid_a" ]
+  [ ! -e "$objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" ]
+
+  rm a.dat b.dat
+  git checkout -- a.dat b.dat
+  [ "$contents_a" = "$(cat a.dat)" ]
+  [ "$contents_b" = "$(cat b.dat)" ]
+
+  git lfs storage reshard --depth 3 2>&1 | tee reshard.log
+  grep "Moved 0 objects to shard depth 3" reshard.log
+
+  git lfs storage reshard --depth 0 2>&1 | tee reshard.log
+  grep "Moved 2 objects to shard depth 0" reshard.log
+  [ -f "$objects/$oid_b" ]
+  [ 0 -eq "$(find "$objects" -mindepth 1 -type d | wc -l)" ]
+
+  git lfs storage reshard --depth 2
+  [ "2" = "$(git config lfs.storage.sharddepth)" ]
+  assert_local_object "$oid_a" 1
+  assert_local_object "$oid_b" 1
+  git lfs fsck --objects
+)
+end_test
+
+begin_test "storage reshard resumes after interruption"
+(
+  set -e
+
+  reponame="storage-reshard-resume"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="contents"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  objects="$(git lfs env | grep LocalMediaDir | cut -d= -f2)"
+
+  # Leave the object in both layouts, with a partial copy in the new one, as
+  # an interrupted run which was copying it would.
+  mkdir -p "$objects/${oid:0:2}"
+  printf "con" > "$objects/${oid:0:2}/$oid"
+
+  git lfs storage reshard --depth 1
+  [ "1" = "$(git config lfs.storage.sharddepth)" ]
+  [ "$contents" = "$(cat "$objects/${oid:0:2}/$oid")" ]
+  [ ! -e "$objects/${oid:0:2}/${oid:2:2}" ]
+
+  rm a.dat
+  git checkout -- a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "storage reshard with invalid depth"
+(
+  set -e
+
+  reponame="storage-reshard-invalid"
+  git init "$reponame"
+  cd "$reponame"
+
+  for depth in -1 5; do
+    git lfs storage reshard --depth "$depth" 2>&1 | tee reshard.log
+    if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+      echo >&2 "fatal: expected 'git lfs storage reshard --depth $depth' to fail"
+      exit 1
+    fi
+    grep "Invalid shard depth" reshard.log
+  done
+
+  [ -z "$(git config lfs.storage.sharddepth)" ]
+)
+end_test
+
+begin_test "storage report"
+(
+  set -e
+
+  reponame="storage-report"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="a"
+  contents_b="bb"
+  contents_c="ccc"
+  contents_d="dddd"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+  oid_c="$(calc_oid "$contents_c")"
+  oid_d="$(calc_oid "$contents_d")"
+  printf "%s" "$contents_a" > a.dat
+  printf "%s" "$contents_b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  git checkout -b other
+  printf "%s" "$contents_d" > d.dat
+  git add d.dat
+  git commit -m "add d.dat"
+  git checkout main
+
+  # b.dat is reachable but missing, and c.dat is stored but unreachable.
+  delete_local_object "$oid_b"
+  printf "%s" "$contents_c" | git lfs clean >/dev/null
+
+  git lfs storage report 2>&1 | tee report.log
+  grep "Stored and reachable: 2 objects, 5 B" report.log
+  grep "Reachable but missing: 1 object, 2 B" report.log
+  grep "Stored but unreachable: 1 object, 3 B" report.log
+
+  if [[ "$oid_a" < "$oid_d" ]]; then
+    stored="{\"oid\":\"$oid_a\",\"size\":1},{\"oid\":\"$oid_d\",\"size\":4}"
+  else
+    stored="{\"oid\":\"$oid_d\",\"size\":4},{\"oid\":\"$oid_a\",\"size\":1}"
+  fi
+  expected="{\"stored\":{\"count\":2,\"bytes\":5,\"objects\":[$stored]},\"missing\":{\"count\":1,\"bytes\":2,\"objects\":[{\"oid\":\"$oid_b\",\"size\":2}]},\"orphaned\":{\"count\":1,\"bytes\":3,\"objects\":[{\"oid\":\"$oid_c\",\"size\":3}]}}"
+  [ "$expected" = "$(git lfs storage report --json)" ]
+)
+end_test
+
+begin_test "storage report with an empty store"
+(
+  set -e
+
+  reponame="storage-report-empty"
+  git init "$reponame"
+  cd "$reponame"
+
+  expected="{\"stored\":{\"count\":0,\"bytes\":0,\"objects\":[]},\"missing\":{\"count\":0,\"bytes\":0,\"objects\":[]},\"orphaned\":{\"count\":0,\"bytes\":0,\"objects\":[]}}"
+  [ "$expected" = "$(git lfs storage report --json)" ]
+)
+end_test
+
+begin_test "lfs.storage created on first use"
+(
+  set -e
+
+  reponame="storage-first-use"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir ../"$reponame-volume"
+  storage="$(cd ../"$reponame-volume" && pwd)/lfs"
+  git config lfs.storage "$storage"
+
+  git lfs track "*.dat"
+  contents="a"
+  oid="$(calc_oid "$contents")"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  [ -f "$storage/objects/${oid:0:2}/${oid:2:2}/$oid" ]
+)
+end_test
+
+begin_test "lfs.storage with a missing parent directory"
+(
+  set -e
+
+  reponame="storage-missing-parent"
+  git init "$reponame"
+  cd "$reponame"
+
+  volume="$(cd .. && pwd)/$reponame-volume"
+  git config lfs.storage "$volume/lfs"
+
+  git lfs storage report 2>&1 | tee report.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs storage report' to fail"
+    exit 1
+  fi
+  grep "Git LFS storage directory \"$volume/lfs\" does not exist, and neither does \"$volume\"" report.log
+  grep "If it is on a volume which is not mounted, mount it and try again." report.log
+  [ ! -e "$volume" ]
+
+  printf "*.dat filter=lfs diff=lfs merge=lfs -text\n" > .gitattributes
+  printf "a" > a.dat
+  git add a.dat 2>&1 | tee add.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git add' to fail"
+    exit 1
+  fi
+  [ ! -e "$volume" ]
+
+  git lfs storage report --create-store
+  [ -d "$volume/lfs" ]
+
+  git add .gitattributes a.dat
+  assert_local_object "$(calc_oid "a")" 1
+)
+end_test
+
+begin_test "lfs.storage which is not a directory"
+(
+  set -e
+
+  reponame="storage-not-a-directory"
+  git init "$reponame"
+  cd "$reponame"
+
+  storage="$(cd .. && pwd)/$reponame-file"
+  printf "not a directory" > "$storage"
+  git config lfs.storage "$storage"
+
+  git lfs storage report --create-store 2>&1 | tee report.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs storage report' to fail"
+    exit 1
+  fi
+  grep "Git LFS storage directory \"$storage\" is not a directory." report.log
+)
+end_test
@@ -711,3 +711,161 @@ begin_test "track: escaped glob pattern with spaces in .gitattributes"
   assert_pointer "main" "$filename" "$contents_oid" 15
 )
 end_test
+
+begin_test "track --diff: compares patterns between refs"
+(
+  set -e
+
+  reponame="track-diff"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.bin" "*.zip"
+  git add .gitattributes
+  git commit -m "initial patterns"
+  git tag before
+
+  git lfs untrack "*.bin"
+  git lfs track --lockable "*.zip"
+  git lfs track "*.psd"
+  echo "*.txt !filter" >> .gitattributes
+  mkdir sub
+  printf '*.dat filter=lfs diff=lfs merge=lfs -text\n' > sub/.gitattributes
+  git add .gitattributes sub/.gitattributes
+  git commit -m "change patterns"
+
+  # Changes in the working tree are not considered.
+  git lfs track "*.iso"
+
+  git lfs track --diff before main 2>&1 | tee diff.log
+  cat > expected.log <<-EOF
+Added patterns
+    *.psd (.gitattributes)
+    *.txt [excluded] (.gitattributes)
+    sub/*.dat (sub/.gitattributes)
+Removed patterns
+    *.bin (.gitattributes)
+Changed patterns
+    *.zip (.gitattributes) -> *.zip [lockable] (.gitattributes)
+EOF
+  diff -u expected.log diff.log
+
+  git lfs track --diff --json before main > diff.json
+  grep -F '"added":[{"pattern":"*.psd","source":".gitattributes","tracked":true,"lockable":false},{"pattern":"*.txt","source":".gitattributes","tracked":false,"lockable":false},{"pattern":"sub/*.dat","source":"sub/.gitattributes","tracked":true,"lockable":false}]' diff.json
+  grep -F '"removed":[{"pattern":"*.bin","source":".gitattributes","tracked":true,"lockable":false}]' diff.json
+  grep -F '"changed":[{"pattern":"*.zip","before":{"pattern":"*.zip","source":".gitattributes","tracked":true,"lockable":false},"after":{"pattern":"*.zip","source":".gitattributes","tracked":true,"lockable":true}}]' diff.json
+
+  # Comparing in the other direction swaps additions and removals.
+  git lfs track --diff main before 2>&1 | tee diff.log
+  [ "$(sed -n '/^Added/,/^Removed/p' diff.log | grep -c '^    ')" -eq 1 ]
+  [ "$(sed -n '/^Removed/,/^Changed/p' diff.log | grep -c '^    ')" -eq 3 ]
+  grep -F "*.zip [lockable] (.gitattributes) -> *.zip (.gitattributes)" diff.log
+
+  [ -z "$(git lfs track --diff main main)" ]
+  [ "$(git lfs track --diff --json main main)" = '{"added":[],"removed":[],"changed":[]}' ]
+)
+end_test
+
+begin_test "track --diff: invalid arguments"
+(
+  set -e
+
+  reponame="track-diff-invalid"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.bin"
+  git add .gitattributes
+  git commit -m "initial patterns"
+
+  git lfs track --diff main 2>&1 | tee diff.log
+  [ "${PIPESTATUS[0]}" -ne 0 ]
+  grep "Usage: git lfs track --diff" diff.log
+
+  git lfs track --diff main missing 2>&1 | tee diff.log
+  [ "${PIPESTATUS[0]}" -ne 0 ]
+  grep "Git can't resolve ref: \"missing\"" diff.log
+
+  git lfs track --json 2>&1 | tee diff.log
+  [ "${PIPESTATUS[0]}" -ne 0 ]
+  grep -- "--json can only be used with --diff" diff.log
+)
+end_test
+
+begin_test "track --upgrade-attributes"
+(
+  set -e
+
+  reponame="track-upgrade-attributes"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir sub
+  printf '*.psd filter=media -crlf\r\n*.ai filter=hawser diff=hawser merge=hawser -text lockable\r\n*.txt text\r\n' > .gitattributes
+  printf '*.bin filter=lfs diff=lfs merge=lfs -crlf\n*.dat filter=lfs diff=lfs merge=lfs -text\n' > sub/.gitattributes
+  git add .gitattributes sub/.gitattributes
+  git commit -m "legacy attributes"
+
+  # Patterns with legacy attributes are reported as tracked.
+  git lfs track --no-excluded 2>&1 | tee track.log
+  grep "\*.psd (.gitattributes)" track.log
+  grep "\*.ai \[lockable\] (.gitattributes)" track.log
+  grep "sub/\*.bin (sub/.gitattributes)" track.log
+
+  git lfs track --upgrade-attributes --dry-run 2>&1 | tee track.log
+  grep 'Upgrading "\*.psd filter=media -crlf" to "\*.psd filter=lfs diff=lfs merge=lfs -text"' track.log
+  grep -q "filter=media" .gitattributes
+
+  git lfs track --upgrade-attributes 2>&1 | tee track.log
+  grep "Upgraded 2 lines in .gitattributes" track.log
+  grep "Upgraded 1 line in sub/.gitattributes" track.log
+
+  printf '*.psd filter=lfs diff=lfs merge=lfs -text\r\n*.ai filter=lfs diff=lfs merge=lfs -text lockable\r\n*.txt text\r\n' > expected
+  cmp expected .gitattributes
+  printf '*.bin filter=lfs diff=lfs merge=lfs -text\n*.dat filter=lfs diff=lfs merge=lfs -text\n' > expected
+  cmp expected sub/.gitattributes
+
+  git lfs track --upgrade-attributes 2>&1 | tee track.log
+  grep "No legacy Git LFS attributes found" track.log
+
+  git lfs track --upgrade-attributes "*.psd" 2>&1 | tee track.log
+  [ "${PIPESTATUS[0]}" -ne 0 ]
+  grep "Usage: git lfs track --upgrade-attributes" track.log
+)
+end_test
+
+begin_test "track --why"
+(
+  set -e
+
+  reponame="track-why"
+  git init "$reponame"
+  cd "$reponame"
+
+  mkdir -p sub/deeper
+  printf '*.dat filter=lfs diff=lfs merge=lfs -text\n*.bin filter=lfs diff=lfs merge=lfs -text\n' > .gitattributes
+  printf '*.bin -filter\nkeep.bin filter=lfs\n' > sub/.gitattributes
+
+  git lfs track --why a.dat 2>&1 | tee track.log
+  grep 'a.dat is tracked by Git LFS: "\*.dat" in .gitattributes sets filter=lfs' track.log
+
+  git lfs track --why a.txt 2>&1 | tee track.log
+  grep "a.txt is not tracked by Git LFS: no pattern gives it a filter" track.log
+
+  git lfs track --why sub/b.bin 2>&1 | tee track.log
+  grep 'sub/b.bin is not tracked by Git LFS: "\*.bin" in sub/.gitattributes sets -filter' track.log
+  grep '    overriding "\*.bin" in .gitattributes, which sets filter=lfs' track.log
+
+  # Paths are relative to the current directory.
+  cd sub/deeper
+  git lfs track --why keep.bin 2>&1 | tee track.log
+  grep 'sub/deeper/keep.bin is tracked by Git LFS: "keep.bin" in sub/.gitattributes sets filter=lfs' track.log
+  grep '    overriding "\*.bin" in sub/.gitattributes, which sets -filter' track.log
+  grep '    overriding "\*.bin" in .gitattributes, which sets filter=lfs' track.log
+  cd ../..
+
+  git lfs track --why 2>&1 | tee track.log
+  [ "${PIPESTATUS[0]}" -ne 0 ]
+  grep "Usage: git lfs track --why <path>..." track.log
+)
+end_test
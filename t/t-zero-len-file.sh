@@ -62,3 +62,63 @@ begin_test "pull zero len file"
   [ "full" = "$(cat full.dat)" ]
 )
 end_test
+
+begin_test "fetch and push zero len pointer"
+(
+  set -e
+
+  reponame="zero-len-pointer"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  empty_oid="$(calc_oid "")"
+
+  # A pointer for the empty object, as written by other tools, rather than
+  # the empty blob which "git add" stores.
+  git lfs track "*.dat"
+  printf "version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize 0\n" "$empty_oid" > pointer
+  git update-index --add --cacheinfo 100644,"$(git hash-object -w pointer)",empty.dat
+  git add .gitattributes
+  git commit -m "add empty pointer"
+
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  grep "api: batch" push.log && exit 1
+
+  GIT_TRACE=1 git lfs push --object-id origin "$empty_oid" 2>&1 | tee push.log
+  grep "api: batch" push.log && exit 1
+  refute_server_object "$reponame" "$empty_oid"
+
+  cd ..
+  clone_repo "$reponame" "$reponame-clone"
+
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "api: batch" fetch.log && exit 1
+
+  git lfs fsck --objects 2>&1 | tee fsck.log
+  grep "objects: openError" fsck.log && exit 1
+  [ ! -s empty.dat ]
+)
+end_test
+
+begin_test "push zero len pointer with lfs.transfer.emptyobjects=transfer"
+(
+  set -e
+
+  reponame="zero-len-pointer-transfer"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  empty_oid="$(calc_oid "")"
+
+  git lfs track "*.dat"
+  printf "version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize 0\n" "$empty_oid" > pointer
+  git update-index --add --cacheinfo 100644,"$(git hash-object -w pointer)",empty.dat
+  git add .gitattributes
+  git commit -m "add empty pointer"
+
+  git config lfs.transfer.emptyobjects transfer
+  GIT_TRACE=1 git push origin main 2>&1 | tee push.log
+  grep "api: batch" push.log
+  assert_server_object "$reponame" "$empty_oid"
+)
+end_test
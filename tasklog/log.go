@@ -44,6 +44,8 @@ type Logger struct {
 	// wg is a WaitGroup that is incremented when new tasks are enqueued,
 	// and decremented when tasks finish.
 	wg *sync.WaitGroup
+	// closeOnce ensures that the queue is only closed once.
+	closeOnce sync.Once
 }
 
 // Option is the type for
@@ -104,13 +106,16 @@ func tty(writer io.Writer) bool {
 }
 
 // Close closes the queue and does not allow new Tasks to be `enqueue()`'d. It
-// waits until the currently running Task has completed.
+// waits until the currently running Task has completed.  It may be called more
+// than once.
 func (l *Logger) Close() {
 	if l == nil {
 		return
 	}
 
-	close(l.queue)
+	l.closeOnce.Do(func() {
+		close(l.queue)
+	})
 
 	l.wg.Wait()
 }
@@ -207,3 +207,13 @@ func TestLoggerHandlesSilentTasks(t *testing.T) {
 
 	assert.Equal(t, "", buf.String())
 }
+
+func TestLoggerCloseTwice(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewLogger(&buf, ForceProgress(true))
+	l.Close()
+	l.Close()
+
+	assert.Equal(t, "", buf.String())
+}
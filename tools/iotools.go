@@ -18,26 +18,51 @@ const (
 	// spooling the contents of an `io.Reader` in `Spool()` to a temporary
 	// file on disk.
 	memoryBufferLimit = 1024
+
+	// DefaultCopyBufferSize is the size of the buffer used to copy data
+	// by CopyWithCallback, which is the same as that used by io.Copy.
+	DefaultCopyBufferSize = 32 * 1024
 )
 
 // CopyWithCallback copies reader to writer while performing a progress callback
 func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb CopyCallback) (int64, error) {
+	return CopyWithCallbackBuffer(writer, reader, totalSize, cb, 0)
+}
+
+// CopyWithCallbackBuffer is like CopyWithCallback, but copies the data through
+// a buffer of bufferSize bytes.  If bufferSize is not positive, it behaves
+// exactly like CopyWithCallback.
+func CopyWithCallbackBuffer(writer io.Writer, reader io.Reader, totalSize int64, cb CopyCallback, bufferSize int) (int64, error) {
 	if success, _ := CloneFile(writer, reader); success {
 		if cb != nil {
 			cb(totalSize, totalSize, 0)
 		}
 		return totalSize, nil
 	}
-	if cb == nil {
-		return io.Copy(writer, reader)
+	if cb != nil {
+		reader = &CallbackReader{
+			C:         cb,
+			TotalSize: totalSize,
+			Reader:    reader,
+		}
 	}
+	return CopyBuffer(writer, reader, bufferSize)
+}
 
-	cbReader := &CallbackReader{
-		C:         cb,
-		TotalSize: totalSize,
-		Reader:    reader,
+// CopyBuffer copies reader to writer through a buffer of bufferSize bytes.
+// Unlike io.CopyBuffer, the buffer is always used, even if reader implements
+// io.WriterTo or writer implements io.ReaderFrom, so that the size of the
+// reads and writes is under the caller's control.  If bufferSize is not
+// positive, it behaves exactly like io.Copy.
+func CopyBuffer(writer io.Writer, reader io.Reader, bufferSize int) (int64, error) {
+	if bufferSize <= 0 {
+		return io.Copy(writer, reader)
 	}
-	return io.Copy(writer, cbReader)
+
+	return io.CopyBuffer(
+		struct{ io.Writer }{writer},
+		struct{ io.Reader }{reader},
+		make([]byte, bufferSize))
 }
 
 // Get a new Hash instance of the type used to hash LFS content
@@ -2,7 +2,11 @@ package tools_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"testing"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
@@ -74,3 +78,68 @@ type ErrReader struct {
 func (e *ErrReader) Read(p []byte) (n int, err error) {
 	return 0, e.err
 }
+
+type maxWriteRecorder struct {
+	max int
+}
+
+func (w *maxWriteRecorder) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		w.max = len(p)
+	}
+	return len(p), nil
+}
+
+func TestCopyWithCallbackBufferHashesRegardlessOfBufferSize(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 10000)
+	sum := sha256.Sum256(data)
+	expected := hex.EncodeToString(sum[:])
+
+	for _, size := range []int{0, 1, 7, 4096, tools.DefaultCopyBufferSize, 1024 * 1024} {
+		var calls int
+		cb := func(total, read int64, current int) error {
+			calls++
+			return nil
+		}
+
+		hasher := tools.NewHashingReader(bytes.NewReader(data))
+		recorder := &maxWriteRecorder{}
+
+		n, err := tools.CopyWithCallbackBuffer(recorder, hasher, int64(len(data)), cb, size)
+		assert.Nil(t, err, "buffer size %d", size)
+		assert.EqualValues(t, len(data), n, "buffer size %d", size)
+		assert.Equal(t, expected, hasher.Hash(), "buffer size %d", size)
+		assert.NotZero(t, calls, "buffer size %d", size)
+
+		if size > 0 {
+			assert.True(t, recorder.max <= size, "buffer size %d: wrote %d bytes at once", size, recorder.max)
+		}
+	}
+}
+
+func TestCopyBufferUsesBufferWithReaderFrom(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 100)
+
+	var buf bytes.Buffer
+	n, err := tools.CopyBuffer(&buf, bytes.NewReader(data), 10)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 100, n)
+	assert.Equal(t, data, buf.Bytes())
+}
+
+func BenchmarkCopyWithCallbackBuffer(b *testing.B) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4*1024*1024/16)
+
+	for _, size := range []int{0, 128 * 1024, 1024 * 1024, 4 * 1024 * 1024} {
+		b.Run(fmt.Sprintf("buffer=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for n := 0; n < b.N; n++ {
+				hasher := tools.NewHashingReader(bytes.NewReader(data))
+				_, err := tools.CopyWithCallbackBuffer(ioutil.Discard, hasher, int64(len(data)), nil, size)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,17 @@ func IsExpiredAtOrIn(from time.Time, until time.Duration, at time.Time, in time.
 	return expiration, expiration.Before(time.Now().Add(until))
 }
 
+// IsExpiredAtOrInWithSkew is like IsExpiredAtOrIn, except that "at" is taken
+// to be a time according to a clock which is "skew" ahead of the local one, as
+// is the case for an expiry time given by a server whose clock differs from
+// ours.  The expiration time returned is according to the local clock.
+func IsExpiredAtOrInWithSkew(from time.Time, until time.Duration, at time.Time, in time.Duration, skew time.Duration) (time.Time, bool) {
+	if in == 0 && !at.IsZero() {
+		at = at.Add(-skew)
+	}
+	return IsExpiredAtOrIn(from, until, at, in)
+}
+
 // TimeAtOrIn returns either "at", or the "in" duration added to the current
 // time. TimeAtOrIn prefers to add a duration rather than return the "at"
 // parameter.
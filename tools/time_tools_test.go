@@ -94,3 +94,49 @@ func TestIsExpiredAtOrInWithAmbiguousTime(t *testing.T) {
 	assert.Equal(t, now.Add(in), expired)
 	assert.False(t, ok)
 }
+
+func TestIsExpiredAtOrInWithSkewServerBehind(t *testing.T) {
+	now := time.Now()
+	within := 5 * time.Minute
+	// The server's clock is an hour behind, and it gave an expiry time ten
+	// minutes after its current time.
+	skew := -time.Hour
+	at := now.Add(skew).Add(10 * time.Minute)
+
+	_, ok := IsExpiredAtOrIn(now, within, at, 0)
+	assert.True(t, ok)
+
+	expired, ok := IsExpiredAtOrInWithSkew(now, within, at, 0, skew)
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(10*time.Minute), expired)
+}
+
+func TestIsExpiredAtOrInWithSkewServerAhead(t *testing.T) {
+	now := time.Now()
+	within := 5 * time.Minute
+	// The server's clock is an hour ahead, and it gave an expiry time ten
+	// minutes before its current time.
+	skew := time.Hour
+	at := now.Add(skew).Add(-10 * time.Minute)
+
+	_, ok := IsExpiredAtOrIn(now, within, at, 0)
+	assert.False(t, ok)
+
+	expired, ok := IsExpiredAtOrInWithSkew(now, within, at, 0, skew)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-10*time.Minute), expired)
+}
+
+func TestIsExpiredAtOrInWithSkewIgnoresSkewForDuration(t *testing.T) {
+	now := time.Now()
+	within := 5 * time.Minute
+	in := 10 * time.Minute
+
+	expired, ok := IsExpiredAtOrInWithSkew(now, within, time.Time{}, in, time.Hour)
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(in), expired)
+
+	expired, ok = IsExpiredAtOrInWithSkew(now, within, time.Time{}, 0, time.Hour)
+	assert.False(t, ok)
+	assert.True(t, expired.IsZero())
+}
@@ -0,0 +1,136 @@
+// Package warnings collects the warnings which Git LFS emits when it falls
+// back on older behavior or ignores deprecated, conflicting, or unsafe
+// configuration, so that tools wrapping Git LFS can surface them.
+package warnings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Codes identifying the kinds of warnings which Git LFS emits.
+const (
+	// ConfigClash is emitted when the same `git config` key is set to
+	// different values with different capitalization.
+	ConfigClash = "config-clash"
+	// UnsafeConfigIgnored is emitted when keys in a `.lfsconfig` file are
+	// ignored because they are not safe to read from a repository.
+	UnsafeConfigIgnored = "unsafe-config-ignored"
+	// DuplicateURLAlias is emitted when more than one `url.*.insteadOf`
+	// or `url.*.pushInsteadOf` key gives the same alias.
+	DuplicateURLAlias = "duplicate-url-alias"
+	// RemoteCredentials is emitted when credentials embedded in a remote's
+	// URL are used for authentication.
+	RemoteCredentials = "remote-credentials"
+	// DeprecatedCommand is emitted when a deprecated command is run.
+	DeprecatedCommand = "deprecated-command"
+	// ClockSkew is emitted when the clock of a server differs
+	// significantly from the local clock.
+	ClockSkew = "clock-skew"
+	// ObjectSizeMismatch is emitted when a local object's size differs
+	// from the size given by its pointer, and it is treated as corrupt.
+	ObjectSizeMismatch = "object-size-mismatch"
+	// OverlappingRefs is emitted when a scan is given refs which it both
+	// includes and excludes, and so does not scan.
+	OverlappingRefs = "overlapping-refs"
+	// AmbiguousRef is emitted when a ref name given to a scan matches
+	// more than one ref, and the one which Git chooses is scanned.
+	AmbiguousRef = "ambiguous-ref"
+	// InvalidConfigValue is emitted when a `git config` key is set to a
+	// value which Git LFS does not recognize, and its default is used.
+	InvalidConfigValue = "invalid-config-value"
+	// AuditLogFailed is emitted when an entry cannot be written to the
+	// audit log given by `lfs.auditlog`.
+	AuditLogFailed = "audit-log-failed"
+)
+
+// Format is the format in which warnings are written as they are emitted.
+type Format int
+
+const (
+	// TextFormat writes each warning's message as is.
+	TextFormat Format = iota
+	// JSONFormat writes each warning as a JSON object on a line of its
+	// own.
+	JSONFormat
+)
+
+// Warning is a single warning emitted by Git LFS.
+type Warning struct {
+	// Code identifies the kind of warning, and is one of the constants
+	// above.
+	Code string `json:"code"`
+	// Message is the human-readable (and translated) text of the warning.
+	Message string `json:"message"`
+}
+
+var (
+	mu      sync.Mutex
+	emitted []*Warning
+	output  io.Writer = os.Stderr
+	format            = TextFormat
+)
+
+// SetOutput changes where warnings are written as they are emitted, which is
+// standard error by default.  A nil writer causes warnings to only be
+// collected.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	output = w
+}
+
+// SetFormat changes the format in which warnings are written as they are
+// emitted.
+func SetFormat(f Format) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	format = f
+}
+
+// Warn records a warning with the given code and message and writes it to the
+// configured output.
+func Warn(code, message string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	w := &Warning{Code: code, Message: message}
+	emitted = append(emitted, w)
+
+	if output == nil {
+		return
+	}
+
+	switch format {
+	case JSONFormat:
+		json.NewEncoder(output).Encode(w)
+	default:
+		fmt.Fprintln(output, message)
+	}
+}
+
+// All returns a copy of every warning emitted so far, in order.
+func All() []*Warning {
+	mu.Lock()
+	defer mu.Unlock()
+
+	all := make([]*Warning, 0, len(emitted))
+	for _, w := range emitted {
+		c := *w
+		all = append(all, &c)
+	}
+	return all
+}
+
+// Reset discards the warnings emitted so far.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	emitted = nil
+}
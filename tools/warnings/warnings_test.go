@@ -0,0 +1,79 @@
+package warnings
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withOutput(f Format) (*bytes.Buffer, func()) {
+	var buf bytes.Buffer
+
+	mu.Lock()
+	oldOutput, oldFormat := output, format
+	output, format = &buf, f
+	emitted = nil
+	mu.Unlock()
+
+	return &buf, func() {
+		mu.Lock()
+		output, format = oldOutput, oldFormat
+		emitted = nil
+		mu.Unlock()
+	}
+}
+
+func TestWarnText(t *testing.T) {
+	buf, restore := withOutput(TextFormat)
+	defer restore()
+
+	Warn(ConfigClash, "warning: first\n  detail")
+	Warn(RemoteCredentials, "warning: second")
+
+	assert.Equal(t, "warning: first\n  detail\nwarning: second\n", buf.String())
+	assert.Equal(t, []*Warning{
+		{Code: ConfigClash, Message: "warning: first\n  detail"},
+		{Code: RemoteCredentials, Message: "warning: second"},
+	}, All())
+}
+
+func TestWarnJSON(t *testing.T) {
+	buf, restore := withOutput(JSONFormat)
+	defer restore()
+
+	Warn(DeprecatedCommand, "warning: first\n  detail")
+
+	assert.Equal(t, `{"code":"deprecated-command","message":"warning: first\n  detail"}`+"\n", buf.String())
+	assert.Len(t, All(), 1)
+}
+
+func TestWarnWithoutOutput(t *testing.T) {
+	_, restore := withOutput(TextFormat)
+	defer restore()
+
+	SetOutput(nil)
+	Warn(DuplicateURLAlias, "warning: collected only")
+
+	assert.Equal(t, []*Warning{{Code: DuplicateURLAlias, Message: "warning: collected only"}}, All())
+}
+
+func TestReset(t *testing.T) {
+	_, restore := withOutput(TextFormat)
+	defer restore()
+
+	Warn(ConfigClash, "warning: discarded")
+	Reset()
+
+	assert.Empty(t, All())
+}
+
+func TestAllReturnsCopies(t *testing.T) {
+	_, restore := withOutput(TextFormat)
+	defer restore()
+
+	Warn(ConfigClash, "warning: original")
+	All()[0].Message = "changed"
+
+	assert.Equal(t, "warning: original", All()[0].Message)
+}
@@ -0,0 +1,118 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionHookRewritesDownload(t *testing.T) {
+	contents := "hooked contents"
+	sum := sha256.Sum256([]byte(contents))
+	oid := hex.EncodeToString(sum[:])
+
+	var original, cdn uint32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/objects/batch":
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects": []interface{}{map[string]interface{}{
+					"oid":  oid,
+					"size": len(contents),
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href":   srv.URL + "/original/" + oid,
+							"header": map[string]string{"X-Original": "1"},
+						},
+					},
+				}},
+			})
+		case strings.HasPrefix(r.URL.Path, "/original/"):
+			atomic.AddUint32(&original, 1)
+			w.WriteHeader(403)
+		case r.URL.Path == "/cdn/"+oid:
+			atomic.AddUint32(&cdn, 1)
+			assert.Equal(t, "secret", r.URL.Query().Get("token"))
+			assert.Equal(t, "1", r.Header.Get("X-Original"))
+			assert.Equal(t, "cdn", r.Header.Get("X-Via"))
+			w.Write([]byte(contents))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "tq-action-hook")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                 srv.URL + "/api",
+		"lfs.transfer.maxretries": "1",
+	}))
+	require.Nil(t, err)
+	m := NewManifest(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), c, "download", "origin")
+
+	var hooked []string
+	q := NewTransferQueue(Download, m, "origin",
+		RemoteRef(&git.Ref{Name: "main"}),
+		WithActionHook(func(oid string, action *Action) *Action {
+			hooked = append(hooked, oid)
+			action.Href = strings.Replace(action.Href, "/original/", "/cdn/", 1) + "?token=secret"
+			action.Header["X-Via"] = "cdn"
+			return action
+		}),
+	)
+
+	path := filepath.Join(dir, "object")
+	q.Add("a.dat", path, oid, int64(len(contents)), false, nil)
+	q.Wait()
+
+	require.Empty(t, q.Errors())
+	assert.Equal(t, []string{oid}, hooked)
+	assert.EqualValues(t, 0, original)
+	assert.EqualValues(t, 1, cdn)
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, contents, string(data))
+
+	report := q.Report()
+	require.Len(t, report, 1)
+	assert.Equal(t, oid, report[0].Oid)
+}
+
+func TestActionHookReturningNilKeepsAction(t *testing.T) {
+	q := &TransferQueue{
+		direction: Download,
+		actionHook: func(oid string, action *Action) *Action {
+			// Changes to the copy given are discarded.
+			action.Href = "https://example.com/changed"
+			return nil
+		},
+	}
+
+	a := &Action{Href: "https://example.com/original", Header: map[string]string{"X-A": "1"}}
+	tr := &Transfer{Oid: "abc", Actions: ActionSet{"download": a}}
+
+	assert.Same(t, a, q.hookAction(tr, a))
+	assert.Equal(t, "https://example.com/original", tr.Actions["download"].Href)
+}
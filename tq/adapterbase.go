@@ -1,6 +1,7 @@
 package tq
 
 import (
+	"context"
 	"net/http"
 	"regexp"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
@@ -32,6 +34,20 @@ type adapterBase struct {
 	jobWait *sync.WaitGroup
 	// WaitGroup to serialise the first transfer response to perform login if needed
 	authWait sync.WaitGroup
+	// ramp limits how many workers may process jobs at once, or is nil if
+	// all workers may do so immediately
+	ramp *rampUp
+	// onMismatch is how downloads which fail verification are handled
+	onMismatch MismatchMode
+	// httpStack is the implementation of HTTP with which objects are
+	// transferred
+	httpStack lfshttp.HTTPStack
+	// aborted is non-zero once abort() has been called
+	aborted int32
+	// requestCtx is the context of the HTTP requests with which objects
+	// are transferred, which cancelRequests cancels
+	requestCtx     context.Context
+	cancelRequests context.CancelFunc
 }
 
 // transferImplementation must be implemented to provide the actual upload/download
@@ -58,12 +74,16 @@ const (
 )
 
 func newAdapterBase(f *fs.Filesystem, name string, dir Direction, ti transferImplementation) *adapterBase {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &adapterBase{
 		fs:           f,
 		name:         name,
 		direction:    dir,
 		transferImpl: ti,
 		jobWait:      new(sync.WaitGroup),
+
+		requestCtx:     ctx,
+		cancelRequests: cancel,
 	}
 }
 
@@ -79,6 +99,8 @@ func (a *adapterBase) Begin(cfg AdapterConfig, cb ProgressCallback) error {
 	a.apiClient = cfg.APIClient()
 	a.remote = cfg.Remote()
 	a.cb = cb
+	a.onMismatch = cfg.OnMismatch()
+	a.httpStack = cfg.HTTPStack()
 	a.jobChan = make(chan *job, 100)
 	a.debugging = a.apiClient.OSEnv().Bool("GIT_TRANSFER_TRACE", false) ||
 		a.apiClient.OSEnv().Bool("GIT_CURL_VERBOSE", false)
@@ -86,6 +108,11 @@ func (a *adapterBase) Begin(cfg AdapterConfig, cb ProgressCallback) error {
 
 	a.Trace("xfer: adapter %q Begin() with %d workers", a.Name(), maxConcurrency)
 
+	a.ramp = newRampUp(maxConcurrency, cfg.RampUpStep(), cfg.RampUpInterval())
+	if a.ramp != nil {
+		a.Trace("xfer: adapter %q ramping up by %d workers every %v", a.Name(), cfg.RampUpStep(), cfg.RampUpInterval())
+	}
+
 	a.workerWait.Add(maxConcurrency)
 	a.authWait.Add(1)
 	for i := 0; i < maxConcurrency; i++ {
@@ -135,6 +162,7 @@ func (a *adapterBase) End() {
 	close(a.jobChan)
 
 	// wait for all transfers to complete
+	a.ramp.Stop()
 	a.workerWait.Wait()
 
 	a.Trace("xfer: adapter %q stopped", a.Name())
@@ -163,6 +191,7 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 		a.Trace("xfer: adapter %q worker %d auth signal received", a.Name(), workerNum)
 	}
 
+	a.ramp.Wait(workerNum)
 	for job := range a.jobChan {
 		t := job.T
 
@@ -177,16 +206,31 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 
 		// Actual transfer happens here
 		var err error
-		if t.Size < 0 {
+		if a.isAborted() {
+			err = errTransferAborted
+		} else if t.Size < 0 {
 			err = errors.New(tr.Tr.Get("object %q has invalid size (got: %d)", t.Oid, t.Size))
-		} else {
+		} else if a.direction == Download && a.fs != nil {
+			// Refuse to start a download which could not be
+			// stored, or which would fill the disk.
+			err = a.fs.CheckWritable()
+			if err == nil {
+				err = a.fs.CheckFreeSpace(t.Size)
+			}
+		}
+		if err == nil {
 			err = a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
 		}
 
+		if err != nil {
+			a.ramp.Backoff()
+		}
+
 		// Mark the job as completed, and alter all listeners
 		job.Done(err)
 
 		a.Trace("xfer: adapter %q worker %d finished job for %q", a.Name(), workerNum, t.Oid)
+		a.ramp.Wait(workerNum)
 	}
 	// This will only happen if no jobs were submitted; just wake up all workers to finish
 	if signalAuthOnResponse {
@@ -216,6 +260,7 @@ func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request,
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(a.requestCtx)
 
 	for key, value := range rel.Header {
 		req.Header.Set(key, value)
@@ -225,6 +270,9 @@ func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request,
 }
 
 func (a *adapterBase) doHTTP(t *Transfer, req *http.Request) (*http.Response, error) {
+	if a.httpStack != lfshttp.HTTPStackNative {
+		req = lfshttp.WithHTTPStack(req, a.httpStack)
+	}
 	if t.Authenticated {
 		return a.apiClient.Do(req)
 	}
@@ -26,6 +26,14 @@ type batchRequest struct {
 	TransferAdapterNames []string    `json:"transfers,omitempty"`
 	Ref                  *batchRef   `json:"ref"`
 	HashAlgorithm        string      `json:"hash_algo"`
+	// Dedup asks the server to check whether it already stores the
+	// content of each object, such as for another repository, before
+	// issuing an upload action for it.
+	Dedup bool `json:"dedup,omitempty"`
+
+	// probe is set for requests made by Probe(), which are sent even
+	// though they contain no objects.
+	probe bool
 }
 
 type BatchResponse struct {
@@ -36,6 +44,12 @@ type BatchResponse struct {
 }
 
 func Batch(m *Manifest, dir Direction, remote string, remoteRef *git.Ref, objects []*Transfer) (*BatchResponse, error) {
+	return batchOffering(m, dir, remote, remoteRef, objects, m.GetAdapterNames(dir))
+}
+
+// batchOffering makes a batch request as Batch does, but offers the server the
+// transfer adapters named by "adapterNames", in that order.
+func batchOffering(m *Manifest, dir Direction, remote string, remoteRef *git.Ref, objects []*Transfer, adapterNames []string) (*BatchResponse, error) {
 	if len(objects) == 0 {
 		return &BatchResponse{}, nil
 	}
@@ -43,9 +57,10 @@ func Batch(m *Manifest, dir Direction, remote string, remoteRef *git.Ref, object
 	return m.batchClient().Batch(remote, &batchRequest{
 		Operation:            dir.String(),
 		Objects:              objects,
-		TransferAdapterNames: m.GetAdapterNames(dir),
+		TransferAdapterNames: adapterNames,
 		Ref:                  &batchRef{Name: remoteRef.Refspec()},
 		HashAlgorithm:        "sha256",
+		Dedup:                dir == Upload && m.dedup,
 	})
 }
 
@@ -65,7 +80,7 @@ func (c *tqClient) SetMaxRetries(n int) {
 
 func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, error) {
 	bRes := &BatchResponse{}
-	if len(bReq.Objects) == 0 {
+	if len(bReq.Objects) == 0 && !bReq.probe {
 		return bRes, nil
 	}
 
@@ -94,8 +109,14 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 		tracerx.Printf("api error: %s", err)
 		return nil, errors.Wrap(err, tr.Tr.Get("batch response"))
 	}
+	receivedAt := time.Now()
 
 	if err := lfshttp.DecodeJSON(res, bRes); err != nil {
+		// A proxy may answer with a login page instead of passing
+		// the request on to the server.
+		if lfshttp.IsDecodeTypeError(err) {
+			err = lfshttp.NewUnexpectedResponseError(res)
+		}
 		return bRes, errors.Wrap(err, tr.Tr.Get("batch response"))
 	}
 
@@ -107,10 +128,13 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 		return nil, lfshttp.NewStatusCodeError(res)
 	}
 
+	// Expiry times are given according to the server's clock.
+	skew := lfshttp.ResponseClockSkew(res, receivedAt)
 	for _, obj := range bRes.Objects {
 		obj.Missing = missing[obj.Oid]
 		for _, a := range obj.Actions {
 			a.createdAt = requestedAt
+			a.clockSkew = skew
 		}
 	}
 
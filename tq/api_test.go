@@ -9,9 +9,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xeipuuv/gojsonschema"
@@ -75,6 +78,32 @@ func TestAPIBatch(t *testing.T) {
 	}
 }
 
+func TestAPIBatchUnexpectedContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html>\n  <body>Please log in</body>\n</html>\n%s", strings.Repeat("x", 300))
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/api",
+	}))
+	require.Nil(t, err)
+
+	tqc := &tqClient{Client: c}
+	_, err = tqc.Batch("remote", &batchRequest{
+		Objects: []*Transfer{{Oid: "a", Size: 1}},
+	})
+	require.NotNil(t, err)
+	assert.True(t, lfshttp.IsUnexpectedResponseError(err))
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	assert.Contains(t, err.Error(), "unexpected response from "+host+", possibly an authentication or proxy issue")
+	assert.Contains(t, err.Error(), `(Content-Type "text/html; charset=utf-8"): <html> <body>Please log in</body> </html> xxx`)
+	assert.True(t, strings.HasSuffix(err.Error(), "x..."))
+	assert.NotContains(t, err.Error(), strings.Repeat("x", 300))
+}
+
 func TestAPIBatchOnlyBasic(t *testing.T) {
 	require.NotNil(t, batchReqSchema, batchReqSchema.Source)
 	require.NotNil(t, batchResSchema, batchResSchema.Source)
@@ -128,6 +157,65 @@ func TestAPIBatchOnlyBasic(t *testing.T) {
 	assert.Equal(t, "basic", bRes.TransferAdapterName)
 }
 
+func TestAPIBatchDedup(t *testing.T) {
+	require.NotNil(t, batchReqSchema, batchReqSchema.Source)
+	require.NotNil(t, batchResSchema, batchResSchema.Source)
+
+	var dedup []bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/objects/batch" {
+			w.WriteHeader(404)
+			return
+		}
+
+		bodyLoader, body := gojsonschema.NewReaderLoader(r.Body)
+		bReq := &batchRequest{}
+		err := json.NewDecoder(body).Decode(bReq)
+		r.Body.Close()
+		assert.Nil(t, err)
+		assertSchema(t, batchReqSchema, bodyLoader)
+		dedup = append(dedup, bReq.Dedup)
+
+		for _, obj := range bReq.Objects {
+			obj.Present = bReq.Dedup
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeLoader, resWriter := gojsonschema.NewWriterLoader(w)
+		err = json.NewEncoder(resWriter).Encode(&BatchResponse{
+			TransferAdapterName: "basic",
+			Objects:             bReq.Objects,
+		})
+
+		assert.Nil(t, err)
+		assertSchema(t, batchResSchema, writeLoader)
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":            srv.URL + "/api",
+		"lfs.transfer.dedup": "true",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, c, "", "")
+	objects := []*Transfer{&Transfer{Oid: "a", Size: 1}}
+
+	bRes, err := Batch(m, Upload, "remote", nil, objects)
+	require.Nil(t, err)
+	if assert.Equal(t, 1, len(bRes.Objects)) {
+		assert.True(t, bRes.Objects[0].Present)
+	}
+
+	bRes, err = Batch(m, Download, "remote", nil, objects)
+	require.Nil(t, err)
+	if assert.Equal(t, 1, len(bRes.Objects)) {
+		assert.False(t, bRes.Objects[0].Present)
+	}
+
+	assert.Equal(t, []bool{true, false}, dedup)
+}
+
 func TestAPIBatchEmptyObjects(t *testing.T) {
 	c, err := lfsapi.NewClient(nil)
 	require.Nil(t, err)
@@ -187,3 +275,67 @@ func assertSchema(t *testing.T, schema *sourcedSchema, dataLoader gojsonschema.J
 		t.Errorf("Schema: %s\n%s", schema.Source, strings.Join(valErrors, "\n"))
 	}
 }
+
+func TestAPIBatchAdjustsExpiryForClockSkew(t *testing.T) {
+	warnings.SetOutput(nil)
+	defer warnings.SetOutput(os.Stderr)
+
+	for desc, c := range map[string]struct {
+		skew    time.Duration
+		expires time.Duration
+		expired bool
+	}{
+		"server behind, unexpired": {-time.Hour, 10 * time.Minute, false},
+		"server behind, expired":   {-time.Hour, -10 * time.Minute, true},
+		"server ahead, unexpired":  {time.Hour, 10 * time.Minute, false},
+		"server ahead, expired":    {time.Hour, -10 * time.Minute, true},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				bReq := &batchRequest{}
+				assert.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+				r.Body.Close()
+
+				serverNow := time.Now().Add(c.skew)
+				for _, obj := range bReq.Objects {
+					obj.Actions = ActionSet{
+						"download": &Action{
+							Href:      "https://example.com/" + obj.Oid,
+							ExpiresAt: serverNow.Add(c.expires),
+						},
+					}
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+				assert.Nil(t, json.NewEncoder(w).Encode(&BatchResponse{
+					TransferAdapterName: "basic",
+					Objects:             bReq.Objects,
+				}))
+			}))
+			defer srv.Close()
+
+			client, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+				"lfs.url": srv.URL + "/api",
+			}))
+			require.Nil(t, err)
+
+			tqc := &tqClient{Client: client}
+			bRes, err := tqc.Batch("remote", &batchRequest{
+				Operation: "download",
+				Objects:   []*Transfer{&Transfer{Oid: "a", Size: 1}},
+			})
+			require.Nil(t, err)
+			require.Len(t, bRes.Objects, 1)
+
+			a, err := bRes.Objects[0].Actions.Get("download")
+			if c.expired {
+				assert.Nil(t, a)
+				assert.True(t, errors.IsRetriableError(err))
+			} else {
+				assert.Nil(t, err)
+				assert.NotNil(t, a)
+			}
+		})
+	}
+}
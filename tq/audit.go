@@ -0,0 +1,166 @@
+package tq
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// These are the outcomes of the objects recorded in the audit log.
+const (
+	auditSuccess = "success"
+	auditSkipped = "skipped"
+	auditFailure = "failure"
+)
+
+// auditMu serializes the writes of every *TransferQueue in the process to
+// audit logs, so that each entry is written whole.
+var auditMu sync.Mutex
+
+// auditEntry is a line of the audit log set by lfs.auditlog, describing the
+// outcome of transferring a single object.
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Oid       string    `json:"oid"`
+	Name      string    `json:"name,omitempty"`
+	Size      int64     `json:"size"`
+	Remote    string    `json:"remote,omitempty"`
+	// URL is the URL from or to which the object was transferred, with
+	// any password and query removed, since signed URLs carry their
+	// credentials in the query.
+	URL string `json:"url,omitempty"`
+	// User is the name of the user as whom the object was transferred,
+	// if the URL or the HTTP Basic credentials given by the server name
+	// one.
+	User    string `json:"user,omitempty"`
+	Adapter string `json:"adapter,omitempty"`
+	Result  string `json:"result"`
+	Error   string `json:"error,omitempty"`
+}
+
+// auditLog appends an entry to the file at "path" for each object which a
+// *TransferQueue finishes with.  The file is only ever opened for appending,
+// and each entry is written with a single write, so that entries from
+// several processes are not interleaved.
+type auditLog struct {
+	path      string
+	operation string
+	remote    string
+
+	warnOnce sync.Once
+}
+
+func newAuditLog(path string, dir Direction, remote string) *auditLog {
+	return &auditLog{path: path, operation: dir.String(), remote: remote}
+}
+
+// entry returns the entry recording the outcome of "o".
+func (l *auditLog) entry(o *ObjectReport) *auditEntry {
+	e := &auditEntry{
+		Time:      o.end,
+		Operation: l.operation,
+		Oid:       o.Oid,
+		Name:      o.Name,
+		Size:      o.size,
+		Remote:    l.remote,
+		URL:       o.url,
+		User:      o.user,
+		Adapter:   o.Adapter,
+		Error:     o.Error,
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+
+	switch {
+	case o.Skipped:
+		e.Result = auditSkipped
+	case o.Success:
+		e.Result = auditSuccess
+	default:
+		e.Result = auditFailure
+	}
+	return e
+}
+
+// Record appends "e" to the log, warning once if it cannot be written.  A
+// transfer does not fail because its entry could not be written.
+func (l *auditLog) Record(e *auditEntry) {
+	if err := l.write(e); err != nil {
+		tracerx.Printf("tq: could not write audit log %q: %s", l.path, err)
+		l.warnOnce.Do(func() {
+			warnings.Warn(warnings.AuditLogFailed, tr.Tr.Get("warning: could not write audit log %q: %s", l.path, err))
+		})
+	}
+}
+
+func (l *auditLog) write(e *auditEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// auditURL returns "href" as it is recorded in the audit log, without the
+// user information, query, or fragment, along with the name of the user it
+// gives, if any.
+func auditURL(href string) (string, string) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", ""
+	}
+
+	var user string
+	if u.User != nil {
+		user = u.User.Username()
+	}
+	stripped := *u
+	stripped.User = nil
+	stripped.RawQuery = ""
+	stripped.ForceQuery = false
+	stripped.Fragment = ""
+	return stripped.String(), user
+}
+
+// auditUser returns the name of the user given by the HTTP Basic credentials
+// in the headers of "a", if any.
+func auditUser(a *Action) string {
+	for k, v := range a.Header {
+		if !strings.EqualFold(k, "Authorization") {
+			continue
+		}
+		fields := strings.SplitN(v, " ", 2)
+		if len(fields) != 2 || !strings.EqualFold(fields[0], "Basic") {
+			return ""
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return ""
+		}
+		return strings.SplitN(string(decoded), ":", 2)[0]
+	}
+	return ""
+}
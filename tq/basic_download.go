@@ -11,6 +11,7 @@ import (
 	"strconv"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
@@ -30,6 +31,10 @@ func (a *basicDownloadAdapter) tempDir() string {
 	return d
 }
 
+// copyToTempFile copies the contents of a download into its temporary file.
+// It is a variable so that tests can simulate a write failing.
+var copyToTempFile = tools.CopyWithCallbackBuffer
+
 func (a *basicDownloadAdapter) WorkerStarting(workerNum int) (interface{}, error) {
 	return nil, nil
 }
@@ -71,7 +76,7 @@ func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progr
 
 	// Read any existing data into hash
 	hash := tools.NewLfsContentHash()
-	fromByte, err := io.Copy(hash, f)
+	fromByte, err := tools.CopyBuffer(hash, f, a.fs.HashBufferSize)
 	if err != nil {
 		return err
 	}
@@ -97,9 +102,13 @@ func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progr
 
 	if err != nil {
 		f.Close()
-		// Rename file so next download can resume from where we stopped.
-		// No error checking here, if rename fails then file will be deleted and there just will be no download resuming
-		tools.RobustRename(f.Name(), a.downloadFilename(t))
+		// If the disk is full, the partial file is deleted to free
+		// the space it holds, rather than kept for resuming.
+		if !fs.IsDiskFullError(err) {
+			// Rename file so next download can resume from where we stopped.
+			// No error checking here, if rename fails then file will be deleted and there just will be no download resuming
+			tools.RobustRename(f.Name(), a.downloadFilename(t))
+		}
 	}
 
 	return err
@@ -151,6 +160,12 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 			return a.download(t, cb, authOkFunc, dlFile, 0, nil)
 		}
 
+		// Status code 403 often means that the action has expired,
+		// which the transfer queue handles, see rebatchForbidden().
+		if res.StatusCode == 403 {
+			return newForbiddenError(err)
+		}
+
 		// Special-cae status code 429 - retry after certain time
 		if res.StatusCode == 429 {
 			retLaterErr := errors.NewRetriableLaterError(err, res.Header["Retry-After"][0])
@@ -223,7 +238,13 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 	}
 
 	var hasher *tools.HashingReader
-	httpReader := tools.NewRetriableReader(res.Body)
+	var body io.Reader = res.Body
+	var lengthReader *contentLengthReader
+	if res.ContentLength >= 0 {
+		lengthReader = &contentLengthReader{r: res.Body, expected: res.ContentLength}
+		body = lengthReader
+	}
+	httpReader := tools.NewRetriableReader(body)
 
 	if fromByte > 0 && hash != nil {
 		// pre-load hashing reader with previous content
@@ -240,20 +261,45 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		}
 		return nil
 	}
-	written, err := tools.CopyWithCallback(dlFile, hasher, res.ContentLength, ccb)
+	written, err := copyToTempFile(dlFile, hasher, res.ContentLength, ccb, a.fs.HashBufferSize)
+	if lengthReader != nil && lengthReader.short {
+		// Check the length before the hash, so that a connection
+		// closed early is retried rather than treated as corruption.
+		tracerx.Printf("xfer: short read of %q: received %d of %d bytes", t.Oid, lengthReader.read, lengthReader.expected)
+		return errors.NewRetriableError(errors.New(tr.Tr.Get("short read: received %d of %d bytes of %s", lengthReader.read, lengthReader.expected, t.Oid)))
+	}
 	if err != nil {
-		return errors.Wrapf(err, tr.Tr.Get("cannot write data to temporary file %q", dlfilename))
+		return a.writeError(dlfilename, err)
+	}
+
+	// A batch response which gives no size leaves it zero, in which case
+	// only the hash can be checked.
+	if received := fromByte + written; t.Size > 0 && received < t.Size {
+		// The data may be only the start of the object, as when the
+		// server stops sending early, so keep it for the retry to
+		// resume from rather than handling it as a mismatch.
+		tracerx.Printf("xfer: incomplete download of %q: received %d of %d bytes", t.Oid, received, t.Size)
+		return errors.NewRetriableError(errors.New(tr.Tr.Get("expected %d bytes of %s, got %d", t.Size, t.Oid, received)))
+	} else if t.Size > 0 && received > t.Size {
+		err := errors.New(tr.Tr.Get("expected %d bytes of %s, got %d", t.Size, t.Oid, received))
+		dlFile.Close()
+		return a.handleMismatch(t, dlfilename, err)
 	}
 
 	if actual := hasher.Hash(); actual != t.Oid {
-		return errors.New(tr.Tr.Get("expected OID %s, got %s after %d bytes written", t.Oid, actual, written))
+		err := errors.New(tr.Tr.Get("expected OID %s, got %s after %d bytes written", t.Oid, actual, written))
+		dlFile.Close()
+		return a.handleMismatch(t, dlfilename, err)
 	}
 
 	if err := dlFile.Close(); err != nil {
+		if fs.IsDiskFullError(err) {
+			return a.fs.DiskFullError(err)
+		}
 		return errors.New(tr.Tr.Get("can't close temporary file %q: %v", dlfilename, err))
 	}
 
-	err = tools.RenameFileCopyPermissions(dlfilename, t.Path)
+	err = a.fs.FinalizeObject(dlfilename, t.Path)
 	if _, err2 := os.Stat(t.Path); err2 == nil {
 		// Target file already exists, possibly was downloaded by other git-lfs process
 		return nil
@@ -284,3 +330,22 @@ func (a *basicDownloadAdapter) makeRequest(t *Transfer, req *http.Request) (*htt
 
 	return res, err
 }
+
+// contentLengthReader counts the bytes read from a response body, and notes
+// whether the body ended before the number of bytes given by its
+// Content-Length header had been read.
+type contentLengthReader struct {
+	r        io.Reader
+	expected int64
+	read     int64
+	short    bool
+}
+
+func (r *contentLengthReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if err != nil && r.read < r.expected {
+		r.short = true
+	}
+	return n, err
+}
@@ -1,6 +1,7 @@
 package tq
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -63,37 +64,49 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 
 	req.ContentLength = t.Size
 
-	f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
-	if err != nil {
-		return errors.Wrap(err, tr.Tr.Get("basic upload"))
-	}
-	defer f.Close()
+	var cbr *tools.BodyWithCallback
+	if t.Size == 0 {
+		// Send no body at all: net/http sends an empty body as a
+		// chunked one, which some servers reject.  The object need
+		// not be in local storage, since there is nothing to read.
+		req.TransferEncoding = nil
+		req.Body = http.NoBody
+		if err := a.setContentTypeFor(req, bytes.NewReader(nil)); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
+		if err != nil {
+			return errors.Wrap(err, tr.Tr.Get("basic upload"))
+		}
+		defer f.Close()
 
-	if err := a.setContentTypeFor(req, f); err != nil {
-		return err
-	}
+		if err := a.setContentTypeFor(req, f); err != nil {
+			return err
+		}
 
-	// Ensure progress callbacks made while uploading
-	// Wrap callback to give name context
-	ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
-		if cb != nil {
-			return cb(t.Name, totalSize, readSoFar, readSinceLast)
+		// Ensure progress callbacks made while uploading
+		// Wrap callback to give name context
+		ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
+			if cb != nil {
+				return cb(t.Name, totalSize, readSoFar, readSinceLast)
+			}
+			return nil
 		}
-		return nil
-	}
 
-	cbr := tools.NewFileBodyWithCallback(f, t.Size, ccb)
-	var reader lfsapi.ReadSeekCloser = cbr
+		cbr = tools.NewFileBodyWithCallback(f, t.Size, ccb)
+		var reader lfsapi.ReadSeekCloser = cbr
 
-	// Signal auth was ok on first read; this frees up other workers to start
-	if authOkFunc != nil {
-		reader = newStartCallbackReader(reader, func() error {
-			authOkFunc()
-			return nil
-		})
-	}
+		// Signal auth was ok on first read; this frees up other workers to start
+		if authOkFunc != nil {
+			reader = newStartCallbackReader(reader, func() error {
+				authOkFunc()
+				return nil
+			})
+		}
 
-	req.Body = reader
+		req.Body = reader
+	}
 
 	req = a.apiClient.LogRequest(req, "lfs.data.upload")
 	res, err := a.makeRequest(t, req)
@@ -115,8 +128,10 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 		// Either way, let's decrement the number of bytes that we've
 		// read _so far_, so that the next iteration doesn't re-transfer
 		// those bytes, according to the progress meter.
-		if perr := cbr.ResetProgress(); perr != nil {
-			err = errors.Wrap(err, perr.Error())
+		if cbr != nil {
+			if perr := cbr.ResetProgress(); perr != nil {
+				err = errors.Wrap(err, perr.Error())
+			}
 		}
 
 		if res == nil {
@@ -152,6 +167,12 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	io.Copy(ioutil.Discard, res.Body)
 	res.Body.Close()
 
+	// With no body to read, auth is only known to be ok from the
+	// response.
+	if cbr == nil && authOkFunc != nil {
+		authOkFunc()
+	}
+
 	return verifyUpload(a.apiClient, a.remote, t)
 }
 
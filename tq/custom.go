@@ -2,10 +2,10 @@ package tq
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -28,28 +28,7 @@ type customAdapter struct {
 	concurrent          bool
 	originalConcurrency int
 	standalone          bool
-}
-
-// Struct to capture stderr and write to trace
-type traceWriter struct {
-	buf         bytes.Buffer
-	processName string
-}
-
-func (t *traceWriter) Write(b []byte) (int, error) {
-	n, err := t.buf.Write(b)
-	t.Flush()
-	return n, err
-}
-func (t *traceWriter) Flush() {
-	var err error
-	for err == nil {
-		var s string
-		s, err = t.buf.ReadString('\n')
-		if len(s) > 0 {
-			tracerx.Printf("xfer[%v]: %v", t.processName, strings.TrimSpace(s))
-		}
-	}
+	stderrMode          CustomStderrMode
 }
 
 type customAdapterWorkerContext struct {
@@ -58,7 +37,7 @@ type customAdapterWorkerContext struct {
 	stdout      io.ReadCloser
 	bufferedOut *bufio.Reader
 	stdin       io.WriteCloser
-	errTracer   *traceWriter
+	errTracer   *stderrWriter
 }
 
 type customAdapterInitRequest struct {
@@ -134,9 +113,13 @@ func (a *customAdapter) WorkerStarting(workerNum int) (interface{}, error) {
 	if err != nil {
 		return nil, errors.New(tr.Tr.Get("failed to get stdin for custom transfer command %q remote: %v", a.path, err))
 	}
-	// Capture stderr to trace
-	tracer := &traceWriter{}
-	tracer.processName = filepath.Base(a.path)
+	// Handle stderr separately from the messages on stdout
+	tracer := &stderrWriter{
+		mode:        a.stderrMode,
+		name:        a.name,
+		processName: filepath.Base(a.path),
+		out:         os.Stderr,
+	}
 	cmd.Stderr = tracer
 	err = cmd.Start()
 	if err != nil {
@@ -313,7 +296,7 @@ func (a *customAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCall
 					return errors.New(tr.Tr.Get("downloaded file failed checks: %v", err))
 				}
 				// Move file to final location
-				if err = tools.RenameFileCopyPermissions(resp.Path, t.Path); err != nil {
+				if err = a.fs.FinalizeObject(resp.Path, t.Path); err != nil {
 					return errors.New(tr.Tr.Get("failed to copy downloaded file: %v", err))
 				}
 			} else if a.direction == Upload {
@@ -337,8 +320,8 @@ func (a *customAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCall
 	return nil
 }
 
-func newCustomAdapter(f *fs.Filesystem, name string, dir Direction, path, args string, concurrent, standalone bool) *customAdapter {
-	c := &customAdapter{newAdapterBase(f, name, dir, nil), path, args, concurrent, 3, standalone}
+func newCustomAdapter(f *fs.Filesystem, name string, dir Direction, path, args string, concurrent, standalone bool, stderrMode CustomStderrMode) *customAdapter {
+	c := &customAdapter{newAdapterBase(f, name, dir, nil), path, args, concurrent, 3, standalone, stderrMode}
 	// self implements impl
 	c.transferImpl = c
 	return c
@@ -351,7 +334,7 @@ const (
 func configureDefaultCustomAdapters(git Env, m *Manifest) {
 	newfunc := func(name string, dir Direction) Adapter {
 		standalone := m.standaloneTransferAgent != ""
-		return newCustomAdapter(m.fs, standaloneFileName, dir, "git-lfs", "standalone-file", false, standalone)
+		return newCustomAdapter(m.fs, standaloneFileName, dir, "git-lfs", "standalone-file", false, standalone, CustomStderrLog)
 	}
 	m.RegisterNewAdapterFunc(standaloneFileName, Download, newfunc)
 	m.RegisterNewAdapterFunc(standaloneFileName, Upload, newfunc)
@@ -379,11 +362,18 @@ func configureCustomAdapters(git Env, m *Manifest) {
 		} else {
 			direction = strings.ToLower(direction)
 		}
+		stderrMode := CustomStderrLog
+		if v, ok := git.Get(fmt.Sprintf("lfs.customtransfer.%s.stderr", name)); ok {
+			var valid bool
+			if stderrMode, valid = parseCustomStderrMode(v); !valid {
+				tracerx.Printf("ignoring invalid lfs.customtransfer.%s.stderr value %q", name, v)
+			}
+		}
 
 		// Separate closure for each since we need to capture vars above
 		newfunc := func(name string, dir Direction) Adapter {
 			standalone := m.standaloneTransferAgent != ""
-			return newCustomAdapter(m.fs, name, dir, path, args, concurrent, standalone)
+			return newCustomAdapter(m.fs, name, dir, path, args, concurrent, standalone, stderrMode)
 		}
 
 		if direction == "download" || direction == "both" {
@@ -0,0 +1,96 @@
+package tq
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rubyist/tracerx"
+)
+
+// CustomStderrMode is what is done with the lines a custom transfer adapter
+// writes to its standard error, as set by lfs.customtransfer.<name>.stderr.
+// Its standard output is always kept for the protocol messages.
+type CustomStderrMode string
+
+const (
+	// CustomStderrLog writes each line to the trace output, as seen with
+	// GIT_TRACE.  It is the default.
+	CustomStderrLog CustomStderrMode = "log"
+	// CustomStderrPass writes each line to Git LFS's own standard error,
+	// prefixed with the name of the adapter.
+	CustomStderrPass CustomStderrMode = "pass"
+	// CustomStderrDiscard drops each line.
+	CustomStderrDiscard CustomStderrMode = "discard"
+)
+
+// parseCustomStderrMode returns the CustomStderrMode named by "s", and
+// whether it is a valid one.
+func parseCustomStderrMode(s string) (CustomStderrMode, bool) {
+	switch m := CustomStderrMode(strings.ToLower(s)); m {
+	case CustomStderrLog, CustomStderrPass, CustomStderrDiscard:
+		return m, true
+	}
+	return CustomStderrLog, false
+}
+
+// stderrWriter is given the standard error of a custom transfer adapter, and
+// handles each complete line written to it as its mode says.
+type stderrWriter struct {
+	mode CustomStderrMode
+	// name is the name of the adapter, with which passed lines are
+	// prefixed.
+	name string
+	// processName is the name of the adapter's program, with which
+	// logged lines are prefixed.
+	processName string
+	// out is where passed lines are written.
+	out io.Writer
+
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (w *stderrWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.mode == CustomStderrDiscard {
+		return len(b), nil
+	}
+
+	n, err := w.buf.Write(b)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(w.buf.Next(i + 1)))
+	}
+	return n, err
+}
+
+// Flush handles the last line written, if it was not ended with a newline.
+func (w *stderrWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.emit(w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+func (w *stderrWriter) emit(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	switch w.mode {
+	case CustomStderrPass:
+		fmt.Fprintf(w.out, "%s: %s\n", w.name, line)
+	case CustomStderrLog:
+		if s := strings.TrimSpace(line); len(s) > 0 {
+			tracerx.Printf("xfer[%v]: %v", w.processName, s)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package tq
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomTransferStderrConfig(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.customtransfer.default.path": "/path/to/binary",
+		"lfs.customtransfer.pass.path":    "/path/to/binary",
+		"lfs.customtransfer.pass.stderr":  "Pass",
+		"lfs.customtransfer.quiet.path":   "/path/to/binary",
+		"lfs.customtransfer.quiet.stderr": "discard",
+		"lfs.customtransfer.bogus.path":   "/path/to/binary",
+		"lfs.customtransfer.bogus.stderr": "bogus",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "", "")
+	for name, expected := range map[string]CustomStderrMode{
+		"default": CustomStderrLog,
+		"pass":    CustomStderrPass,
+		"quiet":   CustomStderrDiscard,
+		"bogus":   CustomStderrLog,
+	} {
+		a, ok := m.NewUploadAdapter(name).(*customAdapter)
+		require.True(t, ok, name)
+		assert.Equal(t, expected, a.stderrMode, name)
+	}
+}
+
+func TestStderrWriterPassPrefixesLines(t *testing.T) {
+	var out bytes.Buffer
+	w := &stderrWriter{mode: CustomStderrPass, name: "testcustom", out: &out}
+
+	w.Write([]byte("first line\nsecond "))
+	assert.Equal(t, "testcustom: first line\n", out.String())
+
+	w.Write([]byte("line\r\nunterminated"))
+	assert.Equal(t, "testcustom: first line\ntestcustom: second line\n", out.String())
+
+	w.Flush()
+	assert.Equal(t, "testcustom: first line\ntestcustom: second line\ntestcustom: unterminated\n", out.String())
+}
+
+func TestStderrWriterDiscard(t *testing.T) {
+	var out bytes.Buffer
+	w := &stderrWriter{mode: CustomStderrDiscard, name: "testcustom", out: &out}
+
+	n, err := w.Write([]byte("some line\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, n)
+	w.Flush()
+	assert.Empty(t, out.String())
+}
+
+func TestStderrWriterLogDoesNotPass(t *testing.T) {
+	var out bytes.Buffer
+	w := &stderrWriter{mode: CustomStderrLog, name: "testcustom", processName: "adapter", out: &out}
+
+	w.Write([]byte("some line\n"))
+	w.Flush()
+	assert.Empty(t, out.String())
+}
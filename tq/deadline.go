@@ -0,0 +1,115 @@
+package tq
+
+import (
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// errDeadlineExceeded is reported for the objects which were not transferred
+// before the deadline given with WithDeadline() passed.
+var errDeadlineExceeded = errors.New(tr.Tr.Get("deadline exceeded"))
+
+// errTransferCanceled is reported for the objects which were not transferred
+// before the queue was canceled with Cancel().
+var errTransferCanceled = errors.New(tr.Tr.Get("transfer canceled"))
+
+// cancelableAdapter is implemented by adapters which can stop the transfers
+// which they have already started, as well as giving up on those which they
+// have not.
+type cancelableAdapter interface {
+	cancel()
+}
+
+// WithDeadline makes the queue stop transferring objects once "deadline" has
+// passed.  No further transfers are started after it, and those in progress
+// are cancelled, so that the objects transferred beforehand are kept and the
+// rest are reported as having failed.  A zero time sets no deadline.
+func WithDeadline(deadline time.Time) Option {
+	return func(tq *TransferQueue) { tq.deadline = deadline }
+}
+
+// startDeadline arranges for the queue to expire once its deadline passes, if
+// it has one.
+func (q *TransferQueue) startDeadline() {
+	if q.deadline.IsZero() {
+		return
+	}
+	if !time.Now().Before(q.deadline) {
+		// Expire now, so that no batch is requested at all.
+		q.expire()
+		return
+	}
+	q.deadlineTimer = time.AfterFunc(time.Until(q.deadline), q.expire)
+}
+
+// stopDeadline stops the queue from expiring once every object has been
+// processed.
+func (q *TransferQueue) stopDeadline() {
+	if q.deadlineTimer != nil {
+		q.deadlineTimer.Stop()
+	}
+}
+
+// expire aborts the queue once its deadline has passed, cancelling the
+// transfers in progress as well.
+func (q *TransferQueue) expire() {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if q.aborted {
+		// The queue has already stopped for another reason, which
+		// is more worth reporting.
+		return
+	}
+
+	tracerx.Printf("tq: deadline passed, cancelling remaining transfers")
+	q.aborted = true
+	q.expired = true
+	q.stopAdapter(q.adapter)
+}
+
+// DeadlineExceeded returns whether the deadline given with WithDeadline() passed
+// before every object was transferred.  It is intended to be called after
+// Wait().
+func (q *TransferQueue) DeadlineExceeded() bool {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	return q.expired
+}
+
+// Cancel stops the queue as if its deadline had passed: no further transfers
+// are started, those in progress are cancelled, and the objects not yet
+// transferred are reported as having failed.  Wait() must still be called.
+// It is safe to call Cancel more than once, and from any goroutine.
+func (q *TransferQueue) Cancel() {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if q.canceled {
+		return
+	}
+
+	tracerx.Printf("tq: canceled, cancelling remaining transfers")
+	q.aborted = true
+	q.canceled = true
+	q.stopAdapter(q.adapter)
+}
+
+// Canceled returns whether Cancel() has been called.
+func (q *TransferQueue) Canceled() bool {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	return q.canceled
+}
+
+// cancel makes the adapter give up on the transfers which it has not yet
+// started, as abort() does, and stops the HTTP requests of those in progress.
+func (a *adapterBase) cancel() {
+	a.abort()
+	a.cancelRequests()
+}
@@ -0,0 +1,230 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDeadlineTestQueue returns a download queue with the given deadline for
+// the objects with the given names, whose contents are their names, served so
+// that those whose names begin with "slow" never finish downloading.  The
+// number of batch requests the server has received is counted in "batches".
+// Any further options are given to the queue.
+func newDeadlineTestQueue(t *testing.T, deadline time.Time, names []string, batches *int32, options ...Option) (*TransferQueue, string, func()) {
+	release := make(chan struct{})
+
+	contents := make(map[string]string)
+	for _, name := range names {
+		contents[deadlineTestOid(name)] = name
+	}
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			atomic.AddInt32(batches, 1)
+
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+
+			var objects []interface{}
+			for _, o := range bReq.Objects {
+				objects = append(objects, map[string]interface{}{
+					"oid":  o.Oid,
+					"size": o.Size,
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, o.Oid),
+						},
+					},
+				})
+			}
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects":  objects,
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			name := contents[strings.TrimPrefix(r.URL.Path, "/storage/")]
+			if !strings.HasPrefix(name, "slow") {
+				w.Write([]byte(name))
+				return
+			}
+
+			// Send part of the object, and then stall until the
+			// client gives up.
+			w.Header().Set("Content-Length", strconv.Itoa(len(name)))
+			w.WriteHeader(200)
+			w.Write([]byte(name[:2]))
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+
+	dir, err := ioutil.TempDir("", "tq-deadline")
+	require.Nil(t, err)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": s.URL + "/api",
+	}))
+	require.Nil(t, err)
+	filesystem := fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644)
+	m := NewManifest(filesystem, c, "download", "origin")
+
+	q := NewTransferQueue(Download, m, "origin", append(options,
+		RemoteRef(&git.Ref{Name: "main"}),
+		WithDeadline(deadline),
+	)...)
+	for _, name := range names {
+		q.Add(name, filepath.Join(dir, name), deadlineTestOid(name), int64(len(name)), false, nil)
+	}
+	return q, dir, func() {
+		close(release)
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func deadlineTestOid(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTransferQueueDeadlineCancelsRemainingTransfers(t *testing.T) {
+	names := []string{"fast-1", "slow-1", "fast-2", "slow-2", "fast-3"}
+
+	var batches int32
+	q, dir, cleanup := newDeadlineTestQueue(t, time.Now().Add(500*time.Millisecond), names, &batches)
+	defer cleanup()
+
+	start := time.Now()
+	q.Wait()
+
+	// The stalled downloads were cancelled soon after the deadline,
+	// rather than waiting for the server.
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assert.True(t, q.DeadlineExceeded())
+	assert.Empty(t, q.Errors())
+
+	results := make(map[string]*ObjectReport)
+	for _, o := range q.Report() {
+		results[o.Name] = o
+	}
+	require.Len(t, results, len(names))
+
+	for _, name := range []string{"fast-1", "fast-2", "fast-3"} {
+		assert.True(t, results[name].Success, name)
+
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.Nil(t, err)
+		assert.Equal(t, name, string(got))
+	}
+	for _, name := range []string{"slow-1", "slow-2"} {
+		assert.False(t, results[name].Success, name)
+		assert.Equal(t, "deadline exceeded", results[name].Error, name)
+
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+}
+
+func TestTransferQueuePassedDeadlineTransfersNothing(t *testing.T) {
+	var batches int32
+	q, _, cleanup := newDeadlineTestQueue(t, time.Now().Add(-time.Second), []string{"fast-1", "fast-2"}, &batches)
+	defer cleanup()
+
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 0, atomic.LoadInt32(&batches))
+
+	reports := q.Report()
+	require.Len(t, reports, 2)
+	for _, o := range reports {
+		assert.False(t, o.Success, o.Name)
+		assert.Equal(t, "deadline exceeded", o.Error, o.Name)
+	}
+}
+
+func TestTransferQueueWithoutDeadline(t *testing.T) {
+	var batches int32
+	q, _, cleanup := newDeadlineTestQueue(t, time.Time{}, []string{"fast-1"}, &batches)
+	defer cleanup()
+
+	q.Wait()
+
+	assert.False(t, q.DeadlineExceeded())
+	assert.Empty(t, q.Errors())
+	require.Len(t, q.Report(), 1)
+	assert.True(t, q.Report()[0].Success)
+}
+
+func TestTransferQueueCancelCancelsRemainingTransfers(t *testing.T) {
+	names := []string{"fast-1", "slow-1"}
+
+	var mu sync.Mutex
+	progress := make(map[string]int64)
+	var batches int32
+	q, _, cleanup := newDeadlineTestQueue(t, time.Time{}, names, &batches,
+		WithTransferProgress(func(name string, total, read int64, current int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			progress[name] = read
+			return nil
+		}),
+	)
+	defer cleanup()
+
+	time.AfterFunc(500*time.Millisecond, q.Cancel)
+
+	start := time.Now()
+	q.Wait()
+
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assert.True(t, q.Canceled())
+	assert.False(t, q.DeadlineExceeded())
+	assert.Empty(t, q.Errors())
+
+	results := make(map[string]*ObjectReport)
+	for _, o := range q.Report() {
+		results[o.Name] = o
+	}
+	require.Len(t, results, len(names))
+	assert.True(t, results["fast-1"].Success)
+	assert.False(t, results["slow-1"].Success)
+	assert.Equal(t, "transfer canceled", results["slow-1"].Error)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.EqualValues(t, len("fast-1"), progress["fast-1"])
+	assert.EqualValues(t, 2, progress["slow-1"])
+}
@@ -0,0 +1,128 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferQueueCoalescesDuplicateOids(t *testing.T) {
+	contents := make(map[string]string)
+	var oids []string
+	for _, s := range []string{"shared object", "other object"} {
+		sum := sha256.Sum256([]byte(s))
+		oid := hex.EncodeToString(sum[:])
+		contents[oid] = s
+		oids = append(oids, oid)
+	}
+
+	var mu sync.Mutex
+	batched := make(map[string]int)
+	downloaded := make(map[string]int)
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+
+			var objects []interface{}
+			mu.Lock()
+			for _, o := range bReq.Objects {
+				batched[o.Oid]++
+				objects = append(objects, map[string]interface{}{
+					"oid":  o.Oid,
+					"size": o.Size,
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, o.Oid),
+						},
+					},
+				})
+			}
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects":  objects,
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			oid := strings.TrimPrefix(r.URL.Path, "/storage/")
+			mu.Lock()
+			downloaded[oid]++
+			mu.Unlock()
+			w.Write([]byte(contents[oid]))
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-dedup")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": s.URL + "/api",
+	}))
+	require.Nil(t, err)
+	filesystem := fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644)
+	m := NewManifest(filesystem, c, "download", "origin")
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	watch := q.Watch()
+
+	var names []string
+	done := make(chan struct{})
+	go func() {
+		for t := range watch {
+			names = append(names, t.Name)
+		}
+		close(done)
+	}()
+
+	// The shared object appears at three paths, and the other at one.
+	dest := filepath.Join(dir, "shared.dat")
+	for _, name := range []string{"a.dat", "b.dat", "c.dat"} {
+		q.Add(name, dest, oids[0], int64(len(contents[oids[0]])), false, nil)
+	}
+	q.Add("d.dat", filepath.Join(dir, "other.dat"), oids[1], int64(len(contents[oids[1]])), false, nil)
+	q.Wait()
+	<-done
+
+	assert.Empty(t, q.Errors())
+
+	// Each object is requested and downloaded once...
+	assert.Equal(t, map[string]int{oids[0]: 1, oids[1]: 1}, batched)
+	assert.Equal(t, map[string]int{oids[0]: 1, oids[1]: 1}, downloaded)
+
+	// ...but every path which refers to it is reported as complete.
+	sort.Strings(names)
+	assert.Equal(t, []string{"a.dat", "b.dat", "c.dat", "d.dat"}, names)
+
+	got, err := ioutil.ReadFile(dest)
+	require.Nil(t, err)
+	assert.Equal(t, contents[oids[0]], string(got))
+}
@@ -0,0 +1,43 @@
+package tq
+
+import (
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// diskFullAbortError is returned for the download which fills the disk, and
+// which causes the queue to be aborted.  It notes how many objects had been
+// downloaded beforehand, so that the user knows how far the operation got.
+type diskFullAbortError struct {
+	error
+	completed int
+}
+
+func newDiskFullAbortError(err error, completed int) error {
+	return &diskFullAbortError{err, completed}
+}
+
+func (e *diskFullAbortError) Error() string {
+	return tr.Tr.GetN(
+		"%s\n%d object was downloaded before the disk filled; run the command again once there is space to download the rest.",
+		"%s\n%d objects were downloaded before the disk filled; run the command again once there is space to download the rest.",
+		e.completed, e.error.Error(), e.completed)
+}
+
+// Cause returns the underlying disk full error, so that fs.IsDiskFullError
+// recognises the error.
+func (e *diskFullAbortError) Cause() error {
+	return e.error
+}
+
+// writeError returns the error with which a download fails after writing its
+// contents to the temporary file at "path" failed with "err".  If the disk is
+// full, the error says so plainly, rather than repeating the low-level error
+// of the write.
+func (a *adapterBase) writeError(path string, err error) error {
+	if fs.IsDiskFullError(err) {
+		return a.fs.DiskFullError(err)
+	}
+	return errors.Wrapf(err, tr.Tr.Get("cannot write data to temporary file %q", path))
+}
@@ -0,0 +1,175 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDiskFullServer returns a server which answers batch requests for any
+// objects, and serves each object's contents as its OID.
+func newDiskFullServer(t *testing.T, contents map[string]string) *httptest.Server {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+
+			var objects []interface{}
+			for _, o := range bReq.Objects {
+				objects = append(objects, map[string]interface{}{
+					"oid":  o.Oid,
+					"size": o.Size,
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, o.Oid),
+						},
+					},
+				})
+			}
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects":  objects,
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			w.Write([]byte(contents[strings.TrimPrefix(r.URL.Path, "/storage/")]))
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	return s
+}
+
+// stubCopyToTempFile makes each write to a temporary file after the first
+// "ok" fail with ENOSPC, once some data has been written, as if the disk had
+// filled.
+func stubCopyToTempFile(ok int32) (restore func()) {
+	var copies int32
+
+	old := copyToTempFile
+	copyToTempFile = func(w io.Writer, r io.Reader, totalSize int64, cb tools.CopyCallback, bufSize int) (int64, error) {
+		if atomic.AddInt32(&copies, 1) <= ok {
+			return old(w, r, totalSize, cb, bufSize)
+		}
+
+		n, _ := w.Write([]byte("partial"))
+		file := w.(*os.File)
+		return int64(n), &os.PathError{Op: "write", Path: file.Name(), Err: syscall.ENOSPC}
+	}
+
+	return func() {
+		copyToTempFile = old
+	}
+}
+
+func TestDownloadDiskFullAborts(t *testing.T) {
+	restore := stubCopyToTempFile(1)
+	defer restore()
+
+	contents := make(map[string]string)
+	var oids []string
+	for _, s := range []string{"first object", "second object", "third object"} {
+		sum := sha256.Sum256([]byte(s))
+		oid := hex.EncodeToString(sum[:])
+		contents[oid] = s
+		oids = append(oids, oid)
+	}
+
+	s := newDiskFullServer(t, contents)
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-disk-full")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                 s.URL + "/api",
+		"lfs.concurrenttransfers": "1",
+	}))
+	require.Nil(t, err)
+	filesystem := fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644)
+	m := NewManifest(filesystem, c, "download", "origin")
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	for i, oid := range oids {
+		name := fmt.Sprintf("%d.dat", i)
+		q.Add(name, filepath.Join(dir, name), oid, int64(len(contents[oid])), false, nil)
+	}
+	q.Wait()
+
+	// Only the failure which filled the disk is reported, along with the
+	// number of objects downloaded beforehand.
+	require.Len(t, q.Errors(), 1)
+	err = q.Errors()[0]
+	assert.True(t, fs.IsDiskFullError(err))
+	assert.Contains(t, err.Error(), "disk full: no space left to write objects to")
+	assert.Contains(t, err.Error(), "1 object was downloaded before the disk filled")
+
+	// Objects may be downloaded in any order, but only the first is
+	// stored.
+	var stored []string
+	for i := range oids {
+		name := fmt.Sprintf("%d.dat", i)
+		if tools.FileExists(filepath.Join(dir, name)) {
+			stored = append(stored, name)
+		}
+	}
+	assert.Len(t, stored, 1)
+
+	// The partial download is removed, rather than kept for resuming.
+	files, err := ioutil.ReadDir(filepath.Join(dir, "lfs", "incomplete"))
+	require.Nil(t, err)
+	assert.Empty(t, files)
+
+	var succeeded, aborted int
+	for _, r := range q.Report() {
+		if r.Success {
+			succeeded++
+		} else if r.Error == errTransferAborted.Error() {
+			aborted++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 1, aborted)
+}
+
+func TestWriteErrorDiskFull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-disk-full")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	a := newAdapterBase(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), BasicAdapterName, Download, nil)
+
+	err = a.writeError("tmp", &os.PathError{Op: "write", Path: "tmp", Err: syscall.ENOSPC})
+	assert.True(t, fs.IsDiskFullError(err))
+	assert.Contains(t, err.Error(), "disk full")
+
+	err = a.writeError("tmp", &os.PathError{Op: "write", Path: "tmp", Err: syscall.EIO})
+	assert.False(t, fs.IsDiskFullError(err))
+	assert.Contains(t, err.Error(), `cannot write data to temporary file "tmp"`)
+}
@@ -0,0 +1,97 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// EmptyObjectMode is how objects with no content are transferred, as set by
+// lfs.transfer.emptyobjects.
+type EmptyObjectMode string
+
+const (
+	// EmptyObjectLocal completes empty objects without contacting the
+	// server, since their content is known: a download writes an empty
+	// object into local storage, and an upload does nothing.  It is the
+	// default.
+	EmptyObjectLocal EmptyObjectMode = "local"
+	// EmptyObjectTransfer sends empty objects to the server like any
+	// other, for servers which need to record them.  Uploads are sent with
+	// no body rather than an empty chunked one.
+	EmptyObjectTransfer EmptyObjectMode = "transfer"
+)
+
+// parseEmptyObjectMode returns the EmptyObjectMode named by "s", and whether it
+// is a valid one.
+func parseEmptyObjectMode(s string) (EmptyObjectMode, bool) {
+	switch m := EmptyObjectMode(s); m {
+	case EmptyObjectLocal, EmptyObjectTransfer:
+		return m, true
+	}
+	return EmptyObjectLocal, false
+}
+
+// isEmptyObject returns whether the given object is the one with no content.
+func isEmptyObject(oid string, size int64) bool {
+	return size == 0 && oid == fs.EmptyObjectSHA256
+}
+
+// completeEmptyObjects completes the empty objects in "b" without sending
+// them to the server, and returns the rest of the batch.
+func (q *TransferQueue) completeEmptyObjects(b batch) batch {
+	rest := q.makeBatch()
+	for _, t := range b {
+		if !isEmptyObject(t.Oid, t.Size) {
+			rest = append(rest, t)
+			continue
+		}
+
+		tracerx.Printf("tq: completing empty object %s without a transfer", t.Oid)
+
+		var err error
+		if q.direction == Download && !q.dryRun {
+			err = writeEmptyObject(q.manifest.fs, t.Path)
+		}
+		if err != nil {
+			q.report.Fail(t.Oid, t.Name, err)
+			q.errorc <- err
+			q.Skip(t.Size)
+			q.wait.Done()
+			continue
+		}
+
+		// A successful result is never retried, so it needs no retry
+		// channel.
+		q.handleTransferResult(TransferResult{Transfer: t.ToTransfer()}, nil)
+	}
+	return rest
+}
+
+// writeEmptyObject puts an empty object at "path", unless there is one there
+// already.
+func writeEmptyObject(f *fs.Filesystem, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := tools.MkdirAll(filepath.Dir(path), f); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("cannot create directory for empty object"))
+	}
+
+	tmp, err := ioutil.TempFile(f.TempDir(), "empty")
+	if err != nil {
+		return errors.Wrap(err, tr.Tr.Get("cannot create empty object"))
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, tr.Tr.Get("cannot create empty object"))
+	}
+	return f.FinalizeObject(tmp.Name(), path)
+}
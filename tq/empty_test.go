@@ -0,0 +1,156 @@
+package tq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyObjectServer is a server which accepts uploads of the empty object,
+// recording the requests made for it.
+type emptyObjectServer struct {
+	*httptest.Server
+
+	batches int32
+	uploads int32
+	// chunked and length are the Transfer-Encoding and Content-Length of
+	// the last upload.
+	chunked []string
+	length  int64
+}
+
+func newEmptyObjectServer(t *testing.T) *emptyObjectServer {
+	s := &emptyObjectServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			atomic.AddInt32(&s.batches, 1)
+
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+			require.Len(t, bReq.Objects, 1)
+			oid := bReq.Objects[0].Oid
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects": []interface{}{map[string]interface{}{
+					"oid":  oid,
+					"size": 0,
+					"actions": map[string]interface{}{
+						"upload": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, oid),
+						},
+					},
+				}},
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			atomic.AddInt32(&s.uploads, 1)
+			s.chunked = r.TransferEncoding
+			s.length = r.ContentLength
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	return s
+}
+
+func newEmptyObjectManifest(t *testing.T, s *emptyObjectServer, dir, mode string) *Manifest {
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                   s.URL + "/api",
+		"lfs.transfer.emptyobjects": mode,
+	}))
+	require.Nil(t, err)
+	return NewManifest(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), c, "", "origin")
+}
+
+func TestDownloadEmptyObjectLocally(t *testing.T) {
+	s := newEmptyObjectServer(t)
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-empty")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lfs", "objects", "e3", "b0", fs.EmptyObjectSHA256)
+
+	q := NewTransferQueue(Download, newEmptyObjectManifest(t, s, dir, "local"), "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("empty.dat", path, fs.EmptyObjectSHA256, 0, false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 0, s.batches)
+
+	fi, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.EqualValues(t, 0, fi.Size())
+}
+
+func TestUploadEmptyObjectLocally(t *testing.T) {
+	s := newEmptyObjectServer(t)
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-empty")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	q := NewTransferQueue(Upload, newEmptyObjectManifest(t, s, dir, ""), "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("empty.dat", filepath.Join(dir, "missing"), fs.EmptyObjectSHA256, 0, false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 0, s.batches)
+	assert.EqualValues(t, 0, s.uploads)
+}
+
+func TestUploadEmptyObjectTransferSendsNoBody(t *testing.T) {
+	s := newEmptyObjectServer(t)
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-empty")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The object need not be in local storage.
+	q := NewTransferQueue(Upload, newEmptyObjectManifest(t, s, dir, "transfer"), "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("empty.dat", filepath.Join(dir, "missing"), fs.EmptyObjectSHA256, 0, false, nil)
+	q.Wait()
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 1, s.batches)
+	assert.EqualValues(t, 1, s.uploads)
+	assert.Empty(t, s.chunked)
+	assert.EqualValues(t, 0, s.length)
+}
+
+func TestParseEmptyObjectMode(t *testing.T) {
+	for s, expected := range map[string]EmptyObjectMode{
+		"local":    EmptyObjectLocal,
+		"transfer": EmptyObjectTransfer,
+	} {
+		mode, ok := parseEmptyObjectMode(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, mode, s)
+	}
+
+	mode, ok := parseEmptyObjectMode("skip")
+	assert.False(t, ok)
+	assert.Equal(t, EmptyObjectLocal, mode)
+}
@@ -0,0 +1,71 @@
+package tq
+
+import (
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/rubyist/tracerx"
+)
+
+// ForbiddenMode is how downloads which the server refuses with HTTP 403 are
+// handled, as set by lfs.transfer.onforbidden.
+type ForbiddenMode string
+
+const (
+	// ForbiddenRebatch requests a fresh action for the object with a new
+	// batch request, once, since a 403 often means that a pre-signed URL
+	// has expired.  If the fresh action is refused too, the refusal is
+	// taken to be persistent and the object fails.  It is the default.
+	ForbiddenRebatch ForbiddenMode = "rebatch"
+	// ForbiddenFail fails the object immediately, without retrying it.
+	ForbiddenFail ForbiddenMode = "fail"
+)
+
+// parseForbiddenMode returns the ForbiddenMode named by "s", and whether it is
+// a valid one.
+func parseForbiddenMode(s string) (ForbiddenMode, bool) {
+	switch m := ForbiddenMode(s); m {
+	case ForbiddenRebatch, ForbiddenFail:
+		return m, true
+	}
+	return ForbiddenRebatch, false
+}
+
+// forbiddenError is returned for a download which the server refused with
+// HTTP 403.  It is not retriable by itself; see
+// (*TransferQueue).rebatchForbidden.
+type forbiddenError struct {
+	error
+}
+
+func newForbiddenError(err error) error {
+	return &forbiddenError{err}
+}
+
+// isForbiddenError returns whether "err" is a download refused with HTTP 403.
+func isForbiddenError(err error) bool {
+	_, ok := errors.Cause(err).(*forbiddenError)
+	return ok
+}
+
+// rebatchForbidden returns whether the object "oid", whose download was
+// refused with HTTP 403, should be retried with an action from a new batch
+// request.  Each object is retried this way at most once, so that a refusal
+// which persists with a fresh action fails the object rather than using up
+// its retries.
+func (q *TransferQueue) rebatchForbidden(oid string) bool {
+	if q.manifest.OnForbidden() != ForbiddenRebatch {
+		return false
+	}
+	if _, ok := q.rc.CanRetry(oid); !ok {
+		return false
+	}
+
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if q.forbidden[oid] {
+		tracerx.Printf("tq: download of %s refused again with a fresh action", oid)
+		return false
+	}
+	q.forbidden[oid] = true
+	return true
+}
@@ -0,0 +1,165 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const forbiddenContents = "pre-signed contents"
+
+// forbiddenServer is a server whose batch responses give download actions
+// with a pre-signed URL naming the batch request which gave it.  URLs from the
+// first "expired" batch requests are refused with HTTP 403, as if they had
+// expired before they were used.
+type forbiddenServer struct {
+	*httptest.Server
+
+	expired   int32
+	batches   int32
+	downloads int32
+}
+
+func newForbiddenServer(t *testing.T, expired int32) *forbiddenServer {
+	s := &forbiddenServer{expired: expired}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			batch := atomic.AddInt32(&s.batches, 1)
+
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+			require.Len(t, bReq.Objects, 1)
+			oid := bReq.Objects[0].Oid
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects": []interface{}{map[string]interface{}{
+					"oid":  oid,
+					"size": len(forbiddenContents),
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s?batch=%d", s.URL, oid, batch),
+						},
+					},
+				}},
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			atomic.AddInt32(&s.downloads, 1)
+			batch, _ := strconv.Atoi(r.URL.Query().Get("batch"))
+			if int32(batch) <= s.expired {
+				w.WriteHeader(403)
+				return
+			}
+			w.Write([]byte(forbiddenContents))
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	return s
+}
+
+func downloadFromForbiddenServer(t *testing.T, s *forbiddenServer, gitConf map[string]string) (*TransferQueue, string) {
+	dir, err := ioutil.TempDir("", "tq-forbidden")
+	require.Nil(t, err)
+
+	conf := map[string]string{
+		"lfs.url":                    s.URL + "/api",
+		"lfs.transfer.maxretrydelay": "1",
+	}
+	for k, v := range gitConf {
+		conf[k] = v
+	}
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, conf))
+	require.Nil(t, err)
+	m := NewManifest(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), c, "download", "origin")
+
+	sum := sha256.Sum256([]byte(forbiddenContents))
+	path := filepath.Join(dir, "object")
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("a.dat", path, hex.EncodeToString(sum[:]), int64(len(forbiddenContents)), false, nil)
+	q.Wait()
+	return q, dir
+}
+
+func TestDownloadForbiddenRebatchesOnce(t *testing.T) {
+	s := newForbiddenServer(t, 1)
+	defer s.Close()
+
+	q, dir := downloadFromForbiddenServer(t, s, nil)
+	defer os.RemoveAll(dir)
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 2, s.batches)
+	assert.EqualValues(t, 2, s.downloads)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "object"))
+	require.Nil(t, err)
+	assert.Equal(t, forbiddenContents, string(data))
+}
+
+func TestDownloadForbiddenWithFreshActionFails(t *testing.T) {
+	s := newForbiddenServer(t, 100)
+	defer s.Close()
+
+	q, dir := downloadFromForbiddenServer(t, s, nil)
+	defer os.RemoveAll(dir)
+
+	// A 403 for the fresh action is persistent, so the object fails
+	// without using up the rest of its retries.
+	require.Len(t, q.Errors(), 1)
+	assert.True(t, isForbiddenError(q.Errors()[0]))
+	assert.EqualValues(t, 2, s.batches)
+	assert.EqualValues(t, 2, s.downloads)
+}
+
+func TestDownloadForbiddenFailMode(t *testing.T) {
+	s := newForbiddenServer(t, 1)
+	defer s.Close()
+
+	q, dir := downloadFromForbiddenServer(t, s, map[string]string{
+		"lfs.transfer.onforbidden": "fail",
+	})
+	defer os.RemoveAll(dir)
+
+	require.Len(t, q.Errors(), 1)
+	assert.EqualValues(t, 1, s.batches)
+	assert.EqualValues(t, 1, s.downloads)
+}
+
+func TestParseForbiddenMode(t *testing.T) {
+	for s, expected := range map[string]ForbiddenMode{
+		"rebatch": ForbiddenRebatch,
+		"fail":    ForbiddenFail,
+	} {
+		mode, ok := parseForbiddenMode(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, mode, s)
+	}
+
+	mode, ok := parseForbiddenMode("retry")
+	assert.False(t, ok)
+	assert.Equal(t, ForbiddenRebatch, mode)
+}
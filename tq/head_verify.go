@@ -0,0 +1,139 @@
+package tq
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// HeadStatus is the result of checking that the remote has an object by making
+// a HEAD request to the URL of its download action.
+type HeadStatus int
+
+const (
+	// HeadPresent means that the URL answered with a successful status
+	// and, if it gave one, a Content-Length equal to the object's size.
+	HeadPresent HeadStatus = iota
+	// HeadMissing means that the batch response gave no download action
+	// for the object, that the URL answered with HTTP 404 or 410, or that
+	// it gave a Content-Length other than the object's size.
+	HeadMissing
+	// HeadUnsupported means that the URL answered with HTTP 405 or 501,
+	// as servers which do not support HEAD requests do, so only the batch
+	// response says that the object is present.
+	HeadUnsupported
+)
+
+// VerifyWithHead checks the objects in "res", the response to a download batch
+// request made to "remote", by making a HEAD request to the URL of the download
+// action of each, with up to m.ConcurrentTransfers() requests at once.  This
+// confirms that objects are present for servers whose batch responses give
+// download actions without checking that the objects exist, such as read-only
+// CDNs.
+//
+// It returns the status of each object by OID, or the first error which
+// prevented an object from being checked.
+func VerifyWithHead(m *Manifest, remote string, res *BatchResponse) (map[string]HeadStatus, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	statuses := make(map[string]HeadStatus, len(res.Objects))
+	sem := make(chan struct{}, m.ConcurrentTransfers())
+	for _, t := range res.Objects {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t *Transfer) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			status, err := headObject(m.APIClient(), remote, t)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			statuses[t.Oid] = status
+		}(t)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return statuses, nil
+}
+
+// headObject makes a HEAD request to the URL of the download action of "t" and
+// returns the status which the response gives for it.
+func headObject(c *lfsapi.Client, remote string, t *Transfer) (HeadStatus, error) {
+	if t.Error != nil {
+		return HeadMissing, nil
+	}
+
+	rel, err := t.Rel("download")
+	if err != nil {
+		return HeadMissing, err
+	}
+	if rel == nil {
+		return HeadMissing, nil
+	}
+
+	href := rel.Href
+	if c.GitEnv().Bool(enableHrefRewriteKey, defaultEnableHrefRewrite) {
+		href = c.Endpoints.NewEndpoint(Download.String(), rel.Href).Url
+	}
+
+	req, err := http.NewRequest("HEAD", href, nil)
+	if err != nil {
+		return HeadMissing, err
+	}
+	for key, value := range rel.Header {
+		req.Header.Set(key, value)
+	}
+	req = c.LogRequest(req, "lfs.data.head")
+
+	var hres *http.Response
+	if t.Authenticated {
+		hres, err = c.Do(req)
+	} else {
+		hres, err = c.DoWithAuthNoRetry(remote, c.Endpoints.AccessFor(endpointURL(href, t.Oid)), req)
+	}
+	if hres != nil {
+		hres.Body.Close()
+	}
+
+	if err != nil {
+		if hres == nil {
+			return HeadMissing, err
+		}
+
+		switch hres.StatusCode {
+		case http.StatusNotFound, http.StatusGone:
+			tracerx.Printf("tq: HEAD %s: object is missing", t.Oid)
+			return HeadMissing, nil
+		case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			tracerx.Printf("tq: HEAD %s: not supported, relying on batch response", t.Oid)
+			return HeadUnsupported, nil
+		}
+		return HeadMissing, errors.Wrap(err, tr.Tr.Get("could not check object %s", t.Oid))
+	}
+
+	if hres.ContentLength >= 0 && hres.ContentLength != t.Size {
+		tracerx.Printf("tq: HEAD %s: expected size %d, got %d", t.Oid, t.Size, hres.ContentLength)
+		return HeadMissing, nil
+	}
+	return HeadPresent, nil
+}
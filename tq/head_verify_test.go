@@ -0,0 +1,113 @@
+package tq
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHeadServer returns a server which answers HEAD requests for
+// /storage/<oid> with the status and Content-Length given for that OID.
+func newHeadServer(t *testing.T, statuses map[string]int, sizes map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "HEAD", r.Method)
+		oid := strings.TrimPrefix(r.URL.Path, "/storage/")
+		if size, ok := sizes[oid]; ok {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+		}
+		w.WriteHeader(statuses[oid])
+	}))
+}
+
+func headManifest(t *testing.T, url string) (*Manifest, string) {
+	dir, err := ioutil.TempDir("", "tq-head")
+	require.Nil(t, err)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url": url + "/api",
+	}))
+	require.Nil(t, err)
+	return NewManifest(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), c, "download", "origin"), dir
+}
+
+func headTransfer(url, oid string, size int64) *Transfer {
+	return &Transfer{
+		Oid:  oid,
+		Size: size,
+		Actions: ActionSet{
+			"download": &Action{Href: url + "/storage/" + oid},
+		},
+	}
+}
+
+func TestVerifyWithHead(t *testing.T) {
+	srv := newHeadServer(t, map[string]int{
+		"present":     200,
+		"missing":     404,
+		"gone":        410,
+		"unsupported": 405,
+		"wrongsize":   200,
+	}, map[string]int{
+		"present":   4,
+		"wrongsize": 3,
+	})
+	defer srv.Close()
+
+	m, dir := headManifest(t, srv.URL)
+	defer os.RemoveAll(dir)
+
+	statuses, err := VerifyWithHead(m, "origin", &BatchResponse{
+		Objects: []*Transfer{
+			headTransfer(srv.URL, "present", 4),
+			headTransfer(srv.URL, "missing", 4),
+			headTransfer(srv.URL, "gone", 4),
+			headTransfer(srv.URL, "unsupported", 4),
+			headTransfer(srv.URL, "wrongsize", 4),
+			{Oid: "noaction", Size: 4},
+			{Oid: "error", Size: 4, Error: &ObjectError{Code: 404, Message: "not found"}},
+		},
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, map[string]HeadStatus{
+		"present":     HeadPresent,
+		"missing":     HeadMissing,
+		"gone":        HeadMissing,
+		"unsupported": HeadUnsupported,
+		"wrongsize":   HeadMissing,
+		"noaction":    HeadMissing,
+		"error":       HeadMissing,
+	}, statuses)
+}
+
+func TestVerifyWithHeadServerError(t *testing.T) {
+	srv := newHeadServer(t, map[string]int{
+		"present": 200,
+		"broken":  500,
+	}, nil)
+	defer srv.Close()
+
+	m, dir := headManifest(t, srv.URL)
+	defer os.RemoveAll(dir)
+
+	statuses, err := VerifyWithHead(m, "origin", &BatchResponse{
+		Objects: []*Transfer{
+			headTransfer(srv.URL, "present", 4),
+			headTransfer(srv.URL, "broken", 4),
+		},
+	})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "could not check object broken")
+	assert.Nil(t, statuses)
+}
@@ -0,0 +1,102 @@
+package tq
+
+import (
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/tr"
+)
+
+// AdapterHealth describes whether a transfer adapter could be used to transfer
+// objects to or from a remote.
+type AdapterHealth struct {
+	Name string
+	// StartErr is the error encountered when starting the adapter locally,
+	// such as a custom transfer agent which could not be run or which
+	// failed to initialize, or nil if it started successfully.
+	StartErr error
+	// Accepted is whether the server chose the adapter when it was offered
+	// alongside "basic".
+	Accepted bool
+	// ProbeErr is the error encountered when asking the server, if any.
+	ProbeErr error
+}
+
+// Healthy returns whether the adapter both started and was accepted by the
+// server.
+func (h *AdapterHealth) Healthy() bool {
+	return h.StartErr == nil && h.ProbeErr == nil && h.Accepted
+}
+
+// CheckAdapters checks each transfer adapter configured for "dir" against the
+// server for "remote", returning one *AdapterHealth per adapter, with "basic"
+// first and the others in order of name.  The "ssh" adapter is omitted unless
+// the remote is accessed over SSH, and the "lfs-standalone-file" adapter is
+// always omitted, since it is only used as a standalone transfer agent.
+//
+// Each adapter is started with a single worker and then stopped again, without
+// transferring any objects, which runs and initializes custom transfer agents.
+// Adapters which start are then offered to the server, alongside "basic", in a
+// batch request for no objects.
+func CheckAdapters(m *Manifest, dir Direction, remote string, remoteRef *git.Ref) []*AdapterHealth {
+	names := m.GetAdapterNames(dir)
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == BasicAdapterName || names[j] == BasicAdapterName {
+			return names[i] == BasicAdapterName && names[j] != BasicAdapterName
+		}
+		return names[i] < names[j]
+	})
+
+	results := make([]*AdapterHealth, 0, len(names))
+	for _, name := range names {
+		if name == standaloneFileName || (name == "ssh" && m.sshTransfer == nil) {
+			continue
+		}
+
+		h := &AdapterHealth{Name: name}
+		results = append(results, h)
+
+		if h.StartErr = startAdapter(m, dir, name, remote); h.StartErr != nil {
+			continue
+		}
+
+		offered := []string{name}
+		if name != BasicAdapterName {
+			offered = append(offered, BasicAdapterName)
+		}
+
+		bRes, err := Probe(m, dir, remote, remoteRef, offered)
+		if err != nil {
+			h.ProbeErr = err
+			continue
+		}
+		h.Accepted = bRes.TransferAdapterName == name
+	}
+	return results
+}
+
+// startAdapter starts the named adapter with a single worker and stops it
+// again, returning any error encountered in doing so.
+func startAdapter(m *Manifest, dir Direction, name, remote string) error {
+	a := m.NewAdapter(name, dir)
+	if a == nil {
+		return errors.New(tr.Tr.Get("transfer adapter %q is not configured", name))
+	}
+
+	err := a.Begin(&adapterConfig{
+		apiClient:           m.APIClient(),
+		concurrentTransfers: 1,
+		rampUpStep:          m.RampUpStep(),
+		httpStack:           m.HTTPStack(),
+		remote:              remote,
+	}, nil)
+	if err != nil {
+		// The only worker failed to start, so there is nothing to
+		// stop.
+		return errors.Wrap(err, tr.Tr.Get("failed to start transfer adapter %q", name))
+	}
+
+	a.End()
+	return nil
+}
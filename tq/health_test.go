@@ -0,0 +1,100 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeMockAdapter writes a shell script which acts as a custom transfer agent
+// that accepts initialization and exits when asked to terminate, returning its
+// path.
+func writeMockAdapter(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "mock-adapter")
+	script := "#!/bin/sh\nread init\necho '{}'\nread terminate\nexit 0\n"
+	require.Nil(t, ioutil.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func healthByName(results []*AdapterHealth) map[string]*AdapterHealth {
+	byName := make(map[string]*AdapterHealth)
+	for _, h := range results {
+		byName[h.Name] = h
+	}
+	return byName
+}
+
+func TestCheckAdapters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock adapter is a shell script")
+	}
+
+	dir, err := ioutil.TempDir("", "tq-health")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := newProbeServer(t, []string{"accepted", "basic"}, "sha256")
+	defer srv.Close()
+
+	mock := writeMockAdapter(t, dir)
+	m := newProbeManifest(t, srv, map[string]string{
+		"lfs.customtransfer.accepted.path": mock,
+		"lfs.customtransfer.rejected.path": mock,
+		"lfs.customtransfer.broken.path":   filepath.Join(dir, "missing-adapter"),
+	})
+
+	results := CheckAdapters(m, Download, "origin", &git.Ref{Name: "main"})
+	require.NotEmpty(t, results)
+	assert.Equal(t, "basic", results[0].Name)
+
+	byName := healthByName(results)
+
+	if basic := byName["basic"]; assert.NotNil(t, basic) {
+		assert.True(t, basic.Healthy())
+	}
+
+	if accepted := byName["accepted"]; assert.NotNil(t, accepted) {
+		assert.Nil(t, accepted.StartErr)
+		assert.Nil(t, accepted.ProbeErr)
+		assert.True(t, accepted.Accepted)
+		assert.True(t, accepted.Healthy())
+	}
+
+	if rejected := byName["rejected"]; assert.NotNil(t, rejected) {
+		assert.Nil(t, rejected.StartErr)
+		assert.Nil(t, rejected.ProbeErr)
+		assert.False(t, rejected.Accepted)
+		assert.False(t, rejected.Healthy())
+	}
+
+	if broken := byName["broken"]; assert.NotNil(t, broken) {
+		if assert.NotNil(t, broken.StartErr) {
+			assert.Contains(t, broken.StartErr.Error(), `failed to start transfer adapter "broken"`)
+		}
+		assert.False(t, broken.Accepted)
+		assert.False(t, broken.Healthy())
+	}
+
+	assert.NotContains(t, byName, "ssh")
+	assert.NotContains(t, byName, "lfs-standalone-file")
+}
+
+func TestCheckAdaptersUnreachableServer(t *testing.T) {
+	srv := newProbeServer(t, nil, "")
+	m := newProbeManifest(t, srv, nil)
+	srv.Close()
+
+	byName := healthByName(CheckAdapters(m, Upload, "origin", &git.Ref{Name: "main"}))
+
+	if basic := byName["basic"]; assert.NotNil(t, basic) {
+		assert.Nil(t, basic.StartErr)
+		assert.NotNil(t, basic.ProbeErr)
+		assert.False(t, basic.Healthy())
+	}
+}
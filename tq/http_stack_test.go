@@ -0,0 +1,60 @@
+package tq
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadWithCurlHTTPStack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("curl stub is a shell script")
+	}
+
+	stubDir, err := ioutil.TempDir("", "tq-curl")
+	require.Nil(t, err)
+	defer os.RemoveAll(stubDir)
+
+	// The stub records the URL it was given in its configuration file, and
+	// answers in place of the server's storage endpoint.
+	log := filepath.Join(stubDir, "log")
+	script := fmt.Sprintf("#!/bin/sh\nfor arg; do config=\"$arg\"; done\ngrep '^url = ' \"$config\" >> %q\nprintf 'HTTP/1.1 200 OK\\r\\nContent-Length: %d\\r\\n\\r\\n%s'\n",
+		log, len(forbiddenContents), forbiddenContents)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(stubDir, "curl"), []byte(script), 0755))
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", stubDir+string(os.PathListSeparator)+path)
+
+	s := newForbiddenServer(t, 0)
+	defer s.Close()
+
+	q, dir := downloadFromForbiddenServer(t, s, map[string]string{
+		"lfs.transfer.httpstack": "curl",
+	})
+	defer os.RemoveAll(dir)
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 1, s.batches)
+	assert.EqualValues(t, 0, s.downloads)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "object"))
+	require.Nil(t, err)
+	assert.Equal(t, forbiddenContents, string(data))
+
+	logged, err := ioutil.ReadFile(log)
+	require.Nil(t, err)
+	assert.Contains(t, string(logged), s.URL+"/storage/")
+}
+
+func TestManifestHTTPStack(t *testing.T) {
+	m := NewManifest(nil, nil, "", "")
+	assert.Equal(t, lfshttp.HTTPStackNative, m.HTTPStack())
+}
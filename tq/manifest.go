@@ -3,11 +3,16 @@ package tq
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/config"
 	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/ssh"
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
+	"github.com/git-lfs/git-lfs/v3/tr"
 	"github.com/rubyist/tracerx"
 )
 
@@ -15,18 +20,58 @@ const (
 	defaultMaxRetries          = 8
 	defaultMaxRetryDelay       = 10
 	defaultConcurrentTransfers = 8
+	defaultRampUpStep          = 1
+
+	defaultNetworkRecoveryFailures = 5
+	defaultNetworkRecoveryDelay    = 5
 )
 
 type Manifest struct {
 	// maxRetries is the maximum number of retries a single object can
 	// attempt to make before it will be dropped. maxRetryDelay is the maximum
 	// time in seconds to wait between retry attempts when using backoff.
-	maxRetries              int
-	maxRetryDelay           int
-	concurrentTransfers     int
+	maxRetries          int
+	maxRetryDelay       int
+	concurrentTransfers int
+	// rampUpInterval is the time to wait before allowing rampUpStep more
+	// concurrent transfers, starting from one, or zero to start all
+	// concurrent transfers at once.
+	rampUpInterval          time.Duration
+	rampUpStep              int
 	basicTransfersOnly      bool
 	standaloneTransferAgent string
 	tusTransfersAllowed     bool
+	dedup                   bool
+	onMismatch              MismatchMode
+	onForbidden             ForbiddenMode
+	// retry404 is whether downloads of objects which the batch API
+	// reports as not found are retried, as set by lfs.transfer.retry404.
+	retry404 bool
+	// verifyLocal is whether each local object is hashed before it is
+	// uploaded, so that one whose content does not match its OID is
+	// reported as corrupt rather than uploaded.
+	verifyLocal bool
+	// httpStack is the implementation of HTTP with which the basic
+	// adapters transfer objects.
+	httpStack lfshttp.HTTPStack
+	// emptyObjects is how objects with no content are transferred.
+	emptyObjects EmptyObjectMode
+	// transferOrder is the order in which the objects of a batch are
+	// transferred.
+	transferOrder TransferOrder
+	// auditLog is the file to which each transfer is appended, as set by
+	// lfs.auditlog, or empty.
+	auditLog string
+	// preferredAdapters maps lower-case file extensions, without a
+	// leading ".", to the name of the adapter preferred for objects with
+	// them, as set by lfs.transferadapter.<name>.extensions.
+	preferredAdapters map[string]string
+	// networkRecoveryFailures is the number of consecutive connection
+	// failures after which the queue pauses for networkRecoveryDelay,
+	// reconnects, and retries the remaining objects, or zero if network
+	// recovery is disabled.
+	networkRecoveryFailures int
+	networkRecoveryDelay    time.Duration
 	downloadAdapterFuncs    map[string]NewAdapterFunc
 	uploadAdapterFuncs      map[string]NewAdapterFunc
 	fs                      *fs.Filesystem
@@ -48,16 +93,105 @@ func (m *Manifest) MaxRetryDelay() int {
 	return m.maxRetryDelay
 }
 
+// OnMismatch returns how downloads whose contents do not match their OID are
+// handled.
+func (m *Manifest) OnMismatch() MismatchMode {
+	if len(m.onMismatch) == 0 {
+		return MismatchRetry
+	}
+	return m.onMismatch
+}
+
+// OnForbidden returns how downloads which the server refuses with HTTP 403 are
+// handled.
+func (m *Manifest) OnForbidden() ForbiddenMode {
+	if len(m.onForbidden) == 0 {
+		return ForbiddenRebatch
+	}
+	return m.onForbidden
+}
+
+// Retry404 returns whether downloads of objects which the batch API reports as
+// not found are retried, rather than failing at once.
+func (m *Manifest) Retry404() bool {
+	return m.retry404
+}
+
+// TransferOrder returns the order in which the objects of a batch with the same
+// priority are transferred.
+func (m *Manifest) TransferOrder() TransferOrder {
+	if len(m.transferOrder) == 0 {
+		return OrderLargestFirst
+	}
+	return m.transferOrder
+}
+
+// EmptyObjects returns how objects with no content are transferred.
+func (m *Manifest) EmptyObjects() EmptyObjectMode {
+	if len(m.emptyObjects) == 0 {
+		return EmptyObjectLocal
+	}
+	return m.emptyObjects
+}
+
+// VerifyLocal returns whether local objects are checked against their OIDs
+// before they are uploaded.
+func (m *Manifest) VerifyLocal() bool {
+	return m.verifyLocal
+}
+
+// AuditLog returns the file to which each transfer is appended, or an empty
+// string if there is none.
+func (m *Manifest) AuditLog() string {
+	return m.auditLog
+}
+
+// HTTPStack returns the implementation of HTTP with which the basic adapters
+// transfer objects.
+func (m *Manifest) HTTPStack() lfshttp.HTTPStack {
+	if len(m.httpStack) == 0 {
+		return lfshttp.HTTPStackNative
+	}
+	return m.httpStack
+}
+
 func (m *Manifest) ConcurrentTransfers() int {
 	return m.concurrentTransfers
 }
 
+func (m *Manifest) RampUpInterval() time.Duration {
+	return m.rampUpInterval
+}
+
+func (m *Manifest) RampUpStep() int {
+	return m.rampUpStep
+}
+
+func (m *Manifest) NetworkRecoveryFailures() int {
+	return m.networkRecoveryFailures
+}
+
+func (m *Manifest) NetworkRecoveryDelay() time.Duration {
+	return m.networkRecoveryDelay
+}
+
 func (m *Manifest) IsStandaloneTransfer() bool {
 	return m.standaloneTransferAgent != ""
 }
 
+// ConcurrentBatches returns the number of batch requests which may be made at
+// once with this manifest.  Batch requests over SSH are made one at a time.
+func (m *Manifest) ConcurrentBatches() int {
+	if m.sshTransfer != nil {
+		return 1
+	}
+	return m.concurrentTransfers
+}
+
 func (m *Manifest) batchClient() BatchClient {
-	if r := m.MaxRetries(); r > 0 {
+	// The batch client may be shared by batches made concurrently, so is
+	// only changed if it needs to be.
+	if r := m.MaxRetries(); r > 0 && m.batchClientAdapter.MaxRetries() != r {
 		m.batchClientAdapter.SetMaxRetries(r)
 	}
 	return m.batchClientAdapter
@@ -95,12 +229,75 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 		if v := git.Int("lfs.concurrenttransfers", 0); v > 0 {
 			m.concurrentTransfers = v
 		}
+		if v := git.Int("lfs.transfer.rampupinterval", 0); v > 0 {
+			m.rampUpInterval = time.Duration(v) * time.Millisecond
+		}
+		if v := git.Int("lfs.transfer.rampupstep", 0); v > 0 {
+			m.rampUpStep = v
+		}
+		if git.Bool("lfs.transfer.networkrecovery", false) {
+			m.networkRecoveryFailures = defaultNetworkRecoveryFailures
+			if v := git.Int("lfs.transfer.networkrecoveryfailures", 0); v > 0 {
+				m.networkRecoveryFailures = v
+			}
+			m.networkRecoveryDelay = defaultNetworkRecoveryDelay * time.Second
+			if v := git.Int("lfs.transfer.networkrecoverydelay", -1); v > -1 {
+				m.networkRecoveryDelay = time.Duration(v) * time.Second
+			}
+		}
 		m.basicTransfersOnly = git.Bool("lfs.basictransfersonly", false)
 		m.standaloneTransferAgent = findStandaloneTransfer(
 			apiClient, operation, remote,
 		)
 		tusAllowed = git.Bool("lfs.tustransfers", false)
+		m.dedup = git.Bool("lfs.transfer.dedup", false)
+		if v, ok := git.Get("lfs.transfer.onmismatch"); ok {
+			mode, valid := parseMismatchMode(v)
+			if !valid {
+				warnings.Warn(warnings.InvalidConfigValue, tr.Tr.Get("warning: ignoring invalid lfs.transfer.onmismatch value %q; using %q", v, mode))
+			}
+			m.onMismatch = mode
+		}
+		if v, ok := git.Get("lfs.transfer.onforbidden"); ok {
+			mode, valid := parseForbiddenMode(v)
+			if !valid {
+				tracerx.Printf("tq: ignoring invalid lfs.transfer.onforbidden value %q", v)
+			}
+			m.onForbidden = mode
+		}
+		m.retry404 = git.Bool("lfs.transfer.retry404", false)
+		m.verifyLocal = git.Bool("lfs.upload.verifylocal", false)
+		if v, ok := git.Get("lfs.auditlog"); ok && len(v) > 0 {
+			path, err := tools.ExpandPath(v, false)
+			if err != nil {
+				tracerx.Printf("tq: ignoring invalid lfs.auditlog value %q: %s", v, err)
+			} else {
+				m.auditLog = path
+			}
+		}
+		if v, ok := git.Get("lfs.transfer.emptyobjects"); ok {
+			mode, valid := parseEmptyObjectMode(v)
+			if !valid {
+				tracerx.Printf("tq: ignoring invalid lfs.transfer.emptyobjects value %q", v)
+			}
+			m.emptyObjects = mode
+		}
+		if v, ok := git.Get("lfs.transfer.order"); ok {
+			order, valid := parseTransferOrder(v)
+			if !valid {
+				tracerx.Printf("tq: ignoring invalid lfs.transfer.order value %q", v)
+			}
+			m.transferOrder = order
+		}
+		if v, ok := git.Get("lfs.transfer.httpstack"); ok {
+			stack, valid := lfshttp.ParseHTTPStack(v)
+			if !valid {
+				tracerx.Printf("tq: ignoring invalid lfs.transfer.httpstack value %q", v)
+			}
+			m.httpStack = stack
+		}
 		configureCustomAdapters(git, m)
+		configurePreferredAdapters(git, m)
 	}
 
 	if m.maxRetries < 1 {
@@ -113,6 +310,12 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 	if m.concurrentTransfers < 1 {
 		m.concurrentTransfers = defaultConcurrentTransfers
 	}
+	if m.rampUpStep < 1 {
+		m.rampUpStep = defaultRampUpStep
+	}
+	if operation == "upload" && requiresSerialUploads(apiClient, remote) {
+		m.concurrentTransfers = 1
+	}
 
 	if sshTransfer != nil {
 		// Multiple concurrent transfers are not yet supported.
@@ -120,6 +323,8 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 			maxRetries: m.maxRetries,
 			transfer:   sshTransfer,
 		}
+	} else {
+		m.batchClientAdapter.SetMaxRetries(m.maxRetries)
 	}
 
 	configureBasicDownloadAdapter(m)
@@ -155,6 +360,24 @@ func findStandaloneTransfer(client *lfsapi.Client, operation, remote string) str
 	return v
 }
 
+// requiresSerialUploads returns whether objects must be uploaded to the upload
+// endpoint of "remote" one at a time, as set by lfs.<url>.transfer.serial,
+// such as for servers which fail when several uploads are made at once.
+func requiresSerialUploads(client *lfsapi.Client, remote string) bool {
+	if client.GitEnv() == nil {
+		return false
+	}
+
+	ep := client.Endpoints.Endpoint("upload", remote)
+	uc := config.NewURLConfig(client.GitEnv())
+	if !uc.Bool("lfs", ep.Url, "transfer.serial", false) {
+		return false
+	}
+
+	tracerx.Printf("tq: uploading to %s one object at a time", ep.Url)
+	return true
+}
+
 // GetAdapterNames returns a list of the names of adapters available to be created
 func (m *Manifest) GetAdapterNames(dir Direction) []string {
 	switch dir {
@@ -1,10 +1,14 @@
 package tq
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/git-lfs/git-lfs/v3/tools/warnings"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,3 +42,88 @@ func TestManifestIgnoresNonInts(t *testing.T) {
 	m := NewManifest(nil, cli, "", "")
 	assert.Equal(t, 8, m.MaxRetries())
 }
+
+func TestManifestNetworkRecoveryDisabledByDefault(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.networkrecoveryfailures": "2",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "", "")
+	assert.Equal(t, 0, m.NetworkRecoveryFailures())
+}
+
+func TestManifestNetworkRecoveryIsConfigurable(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.networkrecovery":         "true",
+		"lfs.transfer.networkrecoveryfailures": "2",
+		"lfs.transfer.networkrecoverydelay":    "0",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "", "")
+	assert.Equal(t, 2, m.NetworkRecoveryFailures())
+	assert.Equal(t, time.Duration(0), m.NetworkRecoveryDelay())
+}
+
+func TestManifestOnMismatch(t *testing.T) {
+	for value, expected := range map[string]MismatchMode{
+		"":           MismatchRetry,
+		"retry":      MismatchRetry,
+		"quarantine": MismatchQuarantine,
+		"fail":       MismatchFail,
+	} {
+		conf := map[string]string{}
+		if len(value) > 0 {
+			conf["lfs.transfer.onmismatch"] = value
+		}
+		cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, conf))
+		require.Nil(t, err)
+
+		m := NewManifest(nil, cli, "", "")
+		assert.Equal(t, expected, m.OnMismatch(), "value %q", value)
+	}
+}
+
+func TestManifestOnMismatchWarnsOnInvalidValue(t *testing.T) {
+	warnings.SetOutput(ioutil.Discard)
+	warnings.Reset()
+	defer func() {
+		warnings.SetOutput(os.Stderr)
+		warnings.Reset()
+	}()
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.onmismatch": "bogus",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "", "")
+	assert.Equal(t, MismatchRetry, m.OnMismatch())
+
+	all := warnings.All()
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, warnings.InvalidConfigValue, all[0].Code)
+		assert.Contains(t, all[0].Message, `"bogus"`)
+	}
+}
+
+func TestManifestTransferOrder(t *testing.T) {
+	for value, expected := range map[string]TransferOrder{
+		"":            OrderLargestFirst,
+		"largest":     OrderLargestFirst,
+		"smallest":    OrderSmallestFirst,
+		"interleaved": OrderInterleaved,
+		"bogus":       OrderLargestFirst,
+	} {
+		conf := map[string]string{}
+		if len(value) > 0 {
+			conf["lfs.transfer.order"] = value
+		}
+		cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, conf))
+		require.Nil(t, err)
+
+		m := NewManifest(nil, cli, "", "")
+		assert.Equal(t, expected, m.TransferOrder(), "value %q", value)
+	}
+}
@@ -0,0 +1,95 @@
+package tq
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/tr"
+	"github.com/rubyist/tracerx"
+)
+
+// MismatchMode is how downloads whose contents do not match their OID are
+// handled, as set by lfs.transfer.onmismatch.
+type MismatchMode string
+
+const (
+	// MismatchRetry discards the bad data and retries the download, as
+	// for any other retriable error.  It is the default.
+	MismatchRetry MismatchMode = "retry"
+	// MismatchQuarantine moves the bad data into the quarantine directory
+	// for inspection, and fails the object without retrying it.
+	MismatchQuarantine MismatchMode = "quarantine"
+	// MismatchFail discards the bad data and aborts the whole operation,
+	// so that no further objects are transferred.
+	MismatchFail MismatchMode = "fail"
+)
+
+// parseMismatchMode returns the MismatchMode named by "s", and whether it is
+// a valid one.
+func parseMismatchMode(s string) (MismatchMode, bool) {
+	switch m := MismatchMode(s); m {
+	case MismatchRetry, MismatchQuarantine, MismatchFail:
+		return m, true
+	}
+	return MismatchRetry, false
+}
+
+// errTransferAborted is reported for the transfers which an adapter gives up
+// on without starting, after the queue has been aborted.
+var errTransferAborted = errors.New(tr.Tr.Get("transfer aborted"))
+
+// abortableAdapter is implemented by adapters which can give up on the
+// transfers which they have been given but have not yet started.
+type abortableAdapter interface {
+	abort()
+}
+
+// mismatchError is returned for a download whose contents did not match its
+// OID, when lfs.transfer.onmismatch is "fail".
+type mismatchError struct {
+	error
+}
+
+// isMismatchAbort returns whether "err" is a mismatch which should abort the
+// whole operation.
+func isMismatchAbort(err error) bool {
+	_, ok := errors.Cause(err).(*mismatchError)
+	return ok
+}
+
+// handleMismatch handles the download of "t" into the file at "path" having
+// failed verification with "err", according to the adapter's MismatchMode,
+// and returns the error with which the transfer should fail.  The file is
+// never left in place, so that no later download resumes from the bad data.
+func (a *adapterBase) handleMismatch(t *Transfer, path string, err error) error {
+	switch a.onMismatch {
+	case MismatchQuarantine:
+		if a.fs == nil {
+			return err
+		}
+		dest, qerr := a.fs.QuarantineObject(path, t.Oid)
+		if qerr != nil {
+			tracerx.Printf("xfer: unable to quarantine %q: %v", path, qerr)
+			return err
+		}
+		return errors.New(tr.Tr.Get("%v; the data was quarantined in %q", err, dest))
+	case MismatchFail:
+		os.Remove(path)
+		return &mismatchError{err}
+	default:
+		// Don't let the retry resume from the bad data.
+		os.Remove(path)
+		return errors.NewRetriableError(err)
+	}
+}
+
+// abort makes the adapter's workers fail the jobs which they have not yet
+// started with errTransferAborted.
+func (a *adapterBase) abort() {
+	atomic.StoreInt32(&a.aborted, 1)
+}
+
+func (a *adapterBase) isAborted() bool {
+	return atomic.LoadInt32(&a.aborted) != 0
+}
@@ -0,0 +1,104 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyEnvironment is an fs.Environment with nothing set.
+type emptyEnvironment struct{}
+
+func (emptyEnvironment) Get(key string) (string, bool) { return "", false }
+
+const mismatchOid = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+// newMismatchAdapter returns an adapter with the given MismatchMode, storing
+// objects in "dir", and the path of a download in it which failed
+// verification.
+func newMismatchAdapter(t *testing.T, dir string, mode MismatchMode) (*adapterBase, string) {
+	a := newAdapterBase(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), BasicAdapterName, Download, nil)
+	a.onMismatch = mode
+
+	path := filepath.Join(dir, "download")
+	require.Nil(t, ioutil.WriteFile(path, []byte("bad data"), 0644))
+	return a, path
+}
+
+func TestHandleMismatchRetry(t *testing.T) {
+	for _, mode := range []MismatchMode{"", MismatchRetry} {
+		dir, err := ioutil.TempDir("", "tq-mismatch")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		a, path := newMismatchAdapter(t, dir, mode)
+
+		err = a.handleMismatch(&Transfer{Oid: mismatchOid}, path, errors.New("bad hash"))
+		assert.True(t, errors.IsRetriableError(err))
+		assert.False(t, isMismatchAbort(err))
+
+		// The retry must not resume from the bad data.
+		assert.NoFileExists(t, path)
+		assert.NoDirExists(t, a.fs.QuarantineDir())
+	}
+}
+
+func TestHandleMismatchQuarantine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-mismatch")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	a, path := newMismatchAdapter(t, dir, MismatchQuarantine)
+
+	err = a.handleMismatch(&Transfer{Oid: mismatchOid}, path, errors.New("bad hash"))
+	require.NotNil(t, err)
+	assert.False(t, errors.IsRetriableError(err))
+	assert.False(t, isMismatchAbort(err))
+	assert.Contains(t, err.Error(), "bad hash")
+
+	assert.NoFileExists(t, path)
+	matches, err := filepath.Glob(filepath.Join(a.fs.QuarantineDir(), mismatchOid+"-*"))
+	require.Nil(t, err)
+	require.Len(t, matches, 1)
+
+	data, err := ioutil.ReadFile(matches[0])
+	require.Nil(t, err)
+	assert.Equal(t, "bad data", string(data))
+}
+
+func TestHandleMismatchFail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-mismatch")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	a, path := newMismatchAdapter(t, dir, MismatchFail)
+
+	err = a.handleMismatch(&Transfer{Oid: mismatchOid}, path, errors.New("bad hash"))
+	assert.EqualError(t, err, "bad hash")
+	assert.False(t, errors.IsRetriableError(err))
+	assert.True(t, isMismatchAbort(err))
+	assert.NoFileExists(t, path)
+	assert.NoDirExists(t, a.fs.QuarantineDir())
+}
+
+func TestAdapterAbort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-mismatch")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	a, _ := newMismatchAdapter(t, dir, MismatchFail)
+	assert.False(t, a.isAborted())
+
+	var adapter interface{} = a
+	abortable, ok := adapter.(abortableAdapter)
+	require.True(t, ok)
+
+	abortable.abort()
+	assert.True(t, a.isAborted())
+}
@@ -0,0 +1,75 @@
+package tq
+
+import "sort"
+
+// TransferOrder is the order in which the objects of a batch with the same
+// priority are transferred, as set by lfs.transfer.order.
+type TransferOrder string
+
+const (
+	// OrderLargestFirst transfers the largest objects first, so that no
+	// worker is left with a large object at the end of a batch while the
+	// others sit idle.  It is the default.
+	OrderLargestFirst TransferOrder = "largest"
+	// OrderSmallestFirst transfers the smallest objects first, so that
+	// as many objects as possible are finished early.
+	OrderSmallestFirst TransferOrder = "smallest"
+	// OrderInterleaved alternates between the largest and the smallest
+	// objects remaining, so that small objects keep being finished while
+	// large ones are transferred.
+	OrderInterleaved TransferOrder = "interleaved"
+)
+
+// parseTransferOrder returns the TransferOrder named by "s", and whether it is
+// a valid one.
+func parseTransferOrder(s string) (TransferOrder, bool) {
+	switch o := TransferOrder(s); o {
+	case OrderLargestFirst, OrderSmallestFirst, OrderInterleaved:
+		return o, true
+	}
+	return OrderLargestFirst, false
+}
+
+// sizeOrder returns the indexes of "n" objects, whose priorities and sizes are
+// given by "priority" and "size", in the order in which they are transferred:
+// those with a higher priority first, and those with the same priority as
+// given by "order".  Objects which would otherwise tie keep their relative
+// order.
+func sizeOrder(n int, priority func(i int) int, size func(i int) int64, order TransferOrder) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		i, j := idx[a], idx[b]
+		if pi, pj := priority(i), priority(j); pi != pj {
+			return pi > pj
+		}
+		if order == OrderSmallestFirst {
+			return size(i) < size(j)
+		}
+		return size(i) > size(j)
+	})
+	if order != OrderInterleaved {
+		return idx
+	}
+
+	// Each run of objects with the same priority is now ordered from
+	// largest to smallest, so take from each end of it in turn.
+	interleaved := make([]int, 0, n)
+	for start := 0; start < n; {
+		end := start + 1
+		for end < n && priority(idx[end]) == priority(idx[start]) {
+			end++
+		}
+		for lo, hi := start, end-1; lo <= hi; lo++ {
+			interleaved = append(interleaved, idx[lo])
+			if lo < hi {
+				interleaved = append(interleaved, idx[hi])
+				hi--
+			}
+		}
+		start = end
+	}
+	return interleaved
+}
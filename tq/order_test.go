@@ -0,0 +1,79 @@
+package tq
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrderedTransferQueue(t *testing.T, order string) *TransferQueue {
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.order": order,
+	}))
+	require.Nil(t, err)
+	return NewTransferQueue(Download, NewManifest(nil, cli, "", ""), "origin")
+}
+
+func orderTestBatch() batch {
+	return batch{
+		{Oid: "b", Size: 20},
+		{Oid: "e", Size: 50},
+		{Oid: "a", Size: 10},
+		{Oid: "d", Size: 40},
+		{Oid: "c", Size: 30},
+	}
+}
+
+func TestSortBatchLargestFirst(t *testing.T) {
+	q := newOrderedTransferQueue(t, "largest")
+	b := orderTestBatch()
+	q.sortBatch(b)
+
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, oidsOf(b))
+}
+
+func TestSortBatchSmallestFirst(t *testing.T) {
+	q := newOrderedTransferQueue(t, "smallest")
+	b := orderTestBatch()
+	q.sortBatch(b)
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, oidsOf(b))
+}
+
+func TestSortBatchInterleaved(t *testing.T) {
+	q := newOrderedTransferQueue(t, "interleaved")
+	b := orderTestBatch()
+	q.sortBatch(b)
+
+	assert.Equal(t, []string{"e", "a", "d", "b", "c"}, oidsOf(b))
+}
+
+func TestSortBatchInterleavedWithinPriority(t *testing.T) {
+	q := newOrderedTransferQueue(t, "interleaved")
+	q.SetPriority("a", 1)
+	q.SetPriority("d", 1)
+	b := orderTestBatch()
+	q.sortBatch(b)
+
+	assert.Equal(t, []string{"d", "a", "e", "b", "c"}, oidsOf(b))
+}
+
+func TestSortTransfersInterleaved(t *testing.T) {
+	q := newOrderedTransferQueue(t, "interleaved")
+	transfers := []*Transfer{
+		{Oid: "small", Size: 1},
+		{Oid: "large", Size: 100},
+		{Oid: "medium", Size: 50},
+		{Oid: "tiny", Size: 0},
+	}
+	q.sortTransfers(transfers)
+
+	oids := make([]string, 0, len(transfers))
+	for _, t := range transfers {
+		oids = append(oids, t.Oid)
+	}
+	assert.Equal(t, []string{"large", "tiny", "medium", "small"}, oids)
+}
@@ -0,0 +1,118 @@
+package tq
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/v3/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// configurePreferredAdapters reads the file extensions of the objects for
+// which each transfer adapter is preferred, as set by
+// lfs.transferadapter.<name>.extensions.
+func configurePreferredAdapters(git Env, m *Manifest) {
+	extRegex := regexp.MustCompile(`\Alfs\.transferadapter\.(.+)\.extensions\z`)
+	for k := range git.All() {
+		match := extRegex.FindStringSubmatch(k)
+		if match == nil {
+			continue
+		}
+
+		name := match[1]
+		exts, _ := git.Get(k)
+		for _, ext := range tools.CleanPaths(exts, ",") {
+			// Accept "mp4", ".mp4", and "*.mp4" alike.
+			ext = strings.ToLower(strings.TrimLeft(strings.TrimPrefix(ext, "*"), "."))
+			if len(ext) == 0 {
+				continue
+			}
+			if m.preferredAdapters == nil {
+				m.preferredAdapters = make(map[string]string)
+			}
+			if prev, ok := m.preferredAdapters[ext]; ok && prev != name {
+				tracerx.Printf("tq: preferring %q over %q for %q objects", name, prev, ext)
+			}
+			m.preferredAdapters[ext] = name
+		}
+	}
+}
+
+// PreferredAdapter returns the name of the transfer adapter preferred for
+// transferring the object with the given name in direction "dir", or the empty
+// string if there is none, in which case the adapter negotiated with the server
+// is used.  The longest extension configured for an adapter with which the
+// name ends is used, so that an adapter may be preferred for ".tar.gz" objects
+// but not for other ".gz" ones.  Adapters which are not configured for "dir"
+// are not preferred.
+func (m *Manifest) PreferredAdapter(name string, dir Direction) string {
+	if len(m.preferredAdapters) == 0 || m.basicTransfersOnly || m.IsStandaloneTransfer() {
+		return ""
+	}
+
+	name = strings.ToLower(name)
+	var preferred, longest string
+	for ext, adapter := range m.preferredAdapters {
+		if len(ext) > len(longest) && strings.HasSuffix(name, "."+ext) {
+			preferred, longest = adapter, ext
+		}
+	}
+
+	if len(preferred) > 0 && !m.hasAdapter(preferred, dir) {
+		tracerx.Printf("tq: ignoring preferred adapter %q for %q: not configured for %s", preferred, name, dir)
+		return ""
+	}
+	return preferred
+}
+
+// hasAdapter returns whether an adapter with the given name is configured for
+// "dir".
+func (m *Manifest) hasAdapter(name string, dir Direction) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	adapters := m.downloadAdapterFuncs
+	if dir == Upload {
+		adapters = m.uploadAdapterFuncs
+	}
+	_, ok := adapters[name]
+	return ok
+}
+
+// adapterNamesPreferring returns the names of the adapters to offer the server
+// for "dir", with "preferred" first, so that the server chooses it if it
+// supports it, and the others after it in order of name.
+func (m *Manifest) adapterNamesPreferring(preferred string, dir Direction) []string {
+	names := []string{preferred}
+	others := m.GetAdapterNames(dir)
+	sort.Strings(others)
+	for _, name := range others {
+		if name != preferred {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// splitByPreferredAdapter divides "b" into batches of the objects which prefer
+// the same transfer adapter, keeping their order, and returns them along with
+// the name of that adapter, or the empty string for the objects which prefer
+// none.
+func (q *TransferQueue) splitByPreferredAdapter(b batch) ([]batch, []string) {
+	var batches []batch
+	var preferred []string
+	index := make(map[string]int)
+	for _, t := range b {
+		adapter := q.manifest.PreferredAdapter(t.Name, q.direction)
+		i, ok := index[adapter]
+		if !ok {
+			i = len(batches)
+			index[adapter] = i
+			batches = append(batches, nil)
+			preferred = append(preferred, adapter)
+		}
+		batches[i] = append(batches[i], t)
+	}
+	return batches, preferred
+}
@@ -0,0 +1,180 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPreferredAdapterManifest(t *testing.T, f *fs.Filesystem, gitConf map[string]string) *Manifest {
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, gitConf))
+	require.Nil(t, err)
+
+	m := NewManifest(f, c, "", "")
+	// "video" transfers objects as "basic" does, but under its own name.
+	m.RegisterNewAdapterFunc("video", Download, m.downloadAdapterFuncs[BasicAdapterName])
+	return m
+}
+
+func TestPreferredAdapter(t *testing.T) {
+	m := newPreferredAdapterManifest(t, nil, map[string]string{
+		"lfs.transferadapter.video.extensions": "mp4, .MOV,*.tar.gz",
+		"lfs.transferadapter.basic.extensions": "gz",
+	})
+
+	assert.Equal(t, "video", m.PreferredAdapter("a/b.mp4", Download))
+	assert.Equal(t, "video", m.PreferredAdapter("c.mov", Download))
+	assert.Equal(t, "video", m.PreferredAdapter("d.tar.gz", Download))
+	assert.Equal(t, "basic", m.PreferredAdapter("e.gz", Download))
+	assert.Equal(t, "", m.PreferredAdapter("f.txt", Download))
+	assert.Equal(t, "", m.PreferredAdapter("mp4", Download))
+
+	// "video" is only configured for downloads.
+	assert.Equal(t, "", m.PreferredAdapter("a/b.mp4", Upload))
+	assert.Equal(t, "basic", m.PreferredAdapter("e.gz", Upload))
+
+	names := m.adapterNamesPreferring("video", Download)
+	assert.Equal(t, "video", names[0])
+	assert.Equal(t, sortedStrings(m.GetAdapterNames(Download)), sortedStrings(names))
+}
+
+func TestPreferredAdapterWithBasicTransfersOnly(t *testing.T) {
+	m := newPreferredAdapterManifest(t, nil, map[string]string{
+		"lfs.transferadapter.video.extensions": "mp4",
+		"lfs.basictransfersonly":               "true",
+	})
+
+	assert.Equal(t, "", m.PreferredAdapter("a.mp4", Download))
+}
+
+func TestTransferQueuePrefersAdapterByExtension(t *testing.T) {
+	contents := make(map[string]string)
+	oids := make(map[string]string)
+	for _, name := range []string{"a.mp4", "b.mp4", "c.txt", "d.dat"} {
+		sum := sha256.Sum256([]byte(name))
+		oid := hex.EncodeToString(sum[:])
+		contents[oid] = name
+		oids[name] = oid
+	}
+
+	var mu sync.Mutex
+	// offered holds the adapters offered by each batch request, keyed by
+	// the sorted names of the objects it requested.
+	offered := make(map[string][]string)
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+
+			var names []string
+			var objects []interface{}
+			for _, o := range bReq.Objects {
+				names = append(names, contents[o.Oid])
+				objects = append(objects, map[string]interface{}{
+					"oid":  o.Oid,
+					"size": o.Size,
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, o.Oid),
+						},
+					},
+				})
+			}
+
+			mu.Lock()
+			offered[strings.Join(sortedStrings(names), ",")] = bReq.TransferAdapterNames
+			mu.Unlock()
+
+			// Choose the first adapter offered which the server
+			// supports.
+			transfer := BasicAdapterName
+			for _, name := range bReq.TransferAdapterNames {
+				if name == BasicAdapterName || name == "video" {
+					transfer = name
+					break
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": transfer,
+				"objects":  objects,
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			w.Write([]byte(contents[strings.TrimPrefix(r.URL.Path, "/storage/")]))
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-preferred-adapter")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filesystem := fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644)
+	m := newPreferredAdapterManifest(t, filesystem, map[string]string{
+		"lfs.url":                              s.URL + "/api",
+		"lfs.transferadapter.video.extensions": "mp4",
+		"lfs.transferadapter.basic.extensions": "txt",
+	})
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	for _, name := range []string{"a.mp4", "c.txt", "d.dat", "b.mp4"} {
+		oid := oids[name]
+		q.Add(name, filepath.Join(dir, name), oid, int64(len(contents[oid])), false, nil)
+	}
+	q.Wait()
+	require.Empty(t, q.Errors())
+
+	// Each group of objects is requested separately, offering the adapter
+	// preferred for it first.
+	require.Len(t, offered, 3)
+	assert.Equal(t, "video", offered["a.mp4,b.mp4"][0])
+	assert.Equal(t, "basic", offered["c.txt"][0])
+	assert.Equal(t, sortedStrings(m.GetAdapterNames(Download)), sortedStrings(offered["d.dat"]))
+
+	adapters := make(map[string]string)
+	for _, o := range q.Report() {
+		assert.True(t, o.Success, o.Name)
+		adapters[o.Name] = o.Adapter
+	}
+	assert.Equal(t, "video", adapters["a.mp4"])
+	assert.Equal(t, "video", adapters["b.mp4"])
+	assert.Equal(t, "basic", adapters["c.txt"])
+
+	for name := range oids {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.Nil(t, err)
+		assert.Equal(t, name, string(got))
+	}
+}
+
+func sortedStrings(s []string) []string {
+	sorted := append([]string(nil), s...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,65 @@
+package tq
+
+import (
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+)
+
+// Probe makes a batch request for no objects to the server for "remote", to
+// discover which transfer adapter and hash algorithm it chooses for the
+// operation in "dir".  The request offers "adapterNames", or every adapter
+// configured for "dir" if none are given.
+//
+// A server which does not name a transfer adapter in its response is taken to
+// have chosen the "basic" adapter, as the API specifies.
+func Probe(m *Manifest, dir Direction, remote string, remoteRef *git.Ref, adapterNames []string) (*BatchResponse, error) {
+	if len(adapterNames) == 0 {
+		adapterNames = m.GetAdapterNames(dir)
+	}
+
+	bRes, err := m.batchClient().Batch(remote, &batchRequest{
+		Operation:            dir.String(),
+		Objects:              []*Transfer{},
+		TransferAdapterNames: adapterNames,
+		Ref:                  &batchRef{Name: remoteRef.Refspec()},
+		HashAlgorithm:        "sha256",
+		probe:                true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bRes.TransferAdapterName) == 0 {
+		bRes.TransferAdapterName = BasicAdapterName
+	}
+	if len(bRes.HashAlgorithm) == 0 {
+		bRes.HashAlgorithm = "sha256"
+	}
+	return bRes, nil
+}
+
+// ProbeAdapters returns the names of the transfer adapters configured for
+// "dir" which the server for "remote" accepts, by offering them one at a time
+// alongside "basic".  The "basic" adapter is always included, first, and the
+// others follow in order of name.
+func ProbeAdapters(m *Manifest, dir Direction, remote string, remoteRef *git.Ref) ([]string, error) {
+	names := m.GetAdapterNames(dir)
+	sort.Strings(names)
+
+	supported := []string{BasicAdapterName}
+	for _, name := range names {
+		if name == BasicAdapterName {
+			continue
+		}
+
+		bRes, err := Probe(m, dir, remote, remoteRef, []string{name, BasicAdapterName})
+		if err != nil {
+			return supported, err
+		}
+		if bRes.TransferAdapterName == name {
+			supported = append(supported, name)
+		}
+	}
+	return supported, nil
+}
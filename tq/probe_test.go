@@ -0,0 +1,122 @@
+package tq
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProbeServer returns a server which chooses the first adapter offered in
+// each batch request that is among "advertised", or names no adapter at all
+// if none are.  It omits the hash algorithm when "hashAlgo" is empty.
+func newProbeServer(t *testing.T, advertised []string, hashAlgo string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/objects/batch" {
+			w.WriteHeader(404)
+			return
+		}
+
+		bReq := &batchRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+		assert.NotNil(t, bReq.Objects)
+		assert.Empty(t, bReq.Objects)
+
+		res := map[string]interface{}{"objects": []interface{}{}}
+		if len(hashAlgo) > 0 {
+			res["hash_algo"] = hashAlgo
+		}
+
+	offered:
+		for _, name := range bReq.TransferAdapterNames {
+			for _, a := range advertised {
+				if name == a {
+					res["transfer"] = name
+					break offered
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func newProbeManifest(t *testing.T, srv *httptest.Server, gitConf map[string]string) *Manifest {
+	conf := map[string]string{"lfs.url": srv.URL + "/api"}
+	for k, v := range gitConf {
+		conf[k] = v
+	}
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, conf))
+	require.Nil(t, err)
+	return NewManifest(nil, c, "", "")
+}
+
+func TestProbeDefaultsToBasic(t *testing.T) {
+	srv := newProbeServer(t, nil, "")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, nil)
+	bRes, err := Probe(m, Download, "origin", &git.Ref{Name: "main"}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "basic", bRes.TransferAdapterName)
+	assert.Equal(t, "sha256", bRes.HashAlgorithm)
+	assert.Empty(t, bRes.Objects)
+
+	adapters, err := ProbeAdapters(m, Download, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"basic"}, adapters)
+}
+
+func TestProbeAdvertisedAdapters(t *testing.T) {
+	srv := newProbeServer(t, []string{"tus", "basic"}, "sha256")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, map[string]string{
+		"lfs.tustransfers":                   "true",
+		"lfs.customtransfer.other.path":      "other",
+		"lfs.customtransfer.other.direction": "both",
+		"lfs.customtransfer.zzz.path":        "zzz",
+		"lfs.customtransfer.zzz.direction":   "upload",
+	})
+
+	bRes, err := Probe(m, Upload, "origin", &git.Ref{Name: "main"}, []string{"tus", "basic"})
+	require.Nil(t, err)
+	assert.Equal(t, "tus", bRes.TransferAdapterName)
+
+	adapters, err := ProbeAdapters(m, Upload, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"basic", "tus"}, adapters)
+
+	adapters, err = ProbeAdapters(m, Download, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"basic"}, adapters)
+}
+
+func TestProbeUnsupportedHashAlgorithm(t *testing.T) {
+	srv := newProbeServer(t, nil, "sha512")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, nil)
+	_, err := Probe(m, Download, "origin", &git.Ref{Name: "main"}, nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unsupported hash algorithm")
+}
+
+func TestProbeWithoutBatchAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, nil)
+	_, err := Probe(m, Download, "origin", &git.Ref{Name: "main"}, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package tq
+
+import "time"
+
+// Profile describes where a *TransferQueue spent its time.
+type Profile struct {
+	// BatchRequests is the number of batch API requests made, and
+	// BatchDuration the time spent waiting for their responses.
+	BatchRequests int
+	BatchDuration time.Duration
+	// TransferDuration is the time from the start of the first transfer
+	// until the end of the last one.
+	TransferDuration time.Duration
+	// Adapters holds the throughput of each transfer adapter, by name.
+	Adapters map[string]*AdapterProfile
+}
+
+// AdapterProfile describes the transfers made with a single transfer adapter.
+type AdapterProfile struct {
+	// Objects and Bytes count the objects transferred successfully, and
+	// their size.
+	Objects int
+	Bytes   int64
+	// Duration is the time from the start of the first transfer made with
+	// the adapter until the end of the last one.
+	Duration time.Duration
+
+	start, end time.Time
+}
+
+// BytesPerSecond returns the rate at which the adapter transferred data, or
+// zero if it took no measurable time.
+func (a *AdapterProfile) BytesPerSecond() float64 {
+	if a.Duration <= 0 {
+		return 0
+	}
+	return float64(a.Bytes) / a.Duration.Seconds()
+}
+
+// Add includes the profile "o" in "p", as though the work they describe were
+// done by one queue. Durations are summed, since queues run one at a time.
+func (p *Profile) Add(o *Profile) {
+	p.BatchRequests += o.BatchRequests
+	p.BatchDuration += o.BatchDuration
+	p.TransferDuration += o.TransferDuration
+
+	if p.Adapters == nil {
+		p.Adapters = make(map[string]*AdapterProfile)
+	}
+	for name, a := range o.Adapters {
+		pa, ok := p.Adapters[name]
+		if !ok {
+			pa = &AdapterProfile{}
+			p.Adapters[name] = pa
+		}
+		pa.Objects += a.Objects
+		pa.Bytes += a.Bytes
+		pa.Duration += a.Duration
+	}
+}
+
+// Profile summarizes the batch requests and objects recorded so far.
+func (r *transferReport) Profile() *Profile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &Profile{
+		BatchRequests: r.batches,
+		BatchDuration: r.batchTime,
+		Adapters:      make(map[string]*AdapterProfile),
+	}
+
+	var start, end time.Time
+	for _, oid := range r.order {
+		o := r.objects[oid]
+		if o.start.IsZero() || o.end.IsZero() {
+			continue
+		}
+		if start.IsZero() || o.start.Before(start) {
+			start = o.start
+		}
+		if o.end.After(end) {
+			end = o.end
+		}
+
+		a, ok := p.Adapters[o.Adapter]
+		if !ok {
+			a = &AdapterProfile{start: o.start, end: o.end}
+			p.Adapters[o.Adapter] = a
+		}
+		if o.start.Before(a.start) {
+			a.start = o.start
+		}
+		if o.end.After(a.end) {
+			a.end = o.end
+		}
+		if o.Success {
+			a.Objects++
+			a.Bytes += o.Bytes
+		}
+	}
+
+	if !start.IsZero() {
+		p.TransferDuration = end.Sub(start)
+	}
+	for _, a := range p.Adapters {
+		a.Duration = a.end.Sub(a.start)
+	}
+	return p
+}
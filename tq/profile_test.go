@@ -0,0 +1,66 @@
+package tq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferReportProfile(t *testing.T) {
+	r := newTransferReport()
+	r.Batch(20 * time.Millisecond)
+	r.Batch(10 * time.Millisecond)
+
+	r.Start(&Transfer{Oid: "a", Name: "a.dat", Size: 3}, "basic", nil)
+	r.Start(&Transfer{Oid: "b", Name: "b.dat", Size: 4}, "basic", nil)
+	r.Start(&Transfer{Oid: "c", Name: "c.dat", Size: 5}, "custom", nil)
+	r.Skip("d", "d.dat")
+	r.Succeed("a", "a.dat", 3)
+	r.Fail("b", "b.dat", errors.New("boom"))
+	r.Succeed("c", "c.dat", 5)
+
+	p := r.Profile()
+	assert.Equal(t, 2, p.BatchRequests)
+	assert.Equal(t, 30*time.Millisecond, p.BatchDuration)
+	assert.True(t, p.TransferDuration >= 0)
+
+	require.Len(t, p.Adapters, 2)
+	assert.Equal(t, 1, p.Adapters["basic"].Objects)
+	assert.Equal(t, int64(3), p.Adapters["basic"].Bytes)
+	assert.Equal(t, 1, p.Adapters["custom"].Objects)
+	assert.Equal(t, int64(5), p.Adapters["custom"].Bytes)
+}
+
+func TestProfileAdd(t *testing.T) {
+	p := &Profile{}
+	p.Add(&Profile{
+		BatchRequests:    1,
+		BatchDuration:    time.Second,
+		TransferDuration: 2 * time.Second,
+		Adapters: map[string]*AdapterProfile{
+			"basic": {Objects: 1, Bytes: 100, Duration: 2 * time.Second},
+		},
+	})
+	p.Add(&Profile{
+		BatchRequests:    2,
+		BatchDuration:    time.Second,
+		TransferDuration: 2 * time.Second,
+		Adapters: map[string]*AdapterProfile{
+			"basic": {Objects: 2, Bytes: 300, Duration: 2 * time.Second},
+		},
+	})
+
+	assert.Equal(t, 3, p.BatchRequests)
+	assert.Equal(t, 2*time.Second, p.BatchDuration)
+	assert.Equal(t, 4*time.Second, p.TransferDuration)
+	assert.Equal(t, 3, p.Adapters["basic"].Objects)
+	assert.Equal(t, int64(400), p.Adapters["basic"].Bytes)
+	assert.Equal(t, float64(100), p.Adapters["basic"].BytesPerSecond())
+}
+
+func TestAdapterProfileBytesPerSecondWithoutDuration(t *testing.T) {
+	assert.Equal(t, float64(0), (&AdapterProfile{Bytes: 100}).BytesPerSecond())
+}
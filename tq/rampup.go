@@ -0,0 +1,157 @@
+package tq
+
+import (
+	"sync"
+	"time"
+)
+
+// rampUp gradually raises the number of workers allowed to process jobs
+// concurrently, so that an adapter does not open all of its connections at
+// once.  Starting from a single active worker, "step" more workers are allowed
+// every "interval", up to "max".  Whenever a transfer fails, the number of
+// active workers is halved, and the ramp continues from there.
+type rampUp struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+	max    int
+	step   int
+
+	// schedule records the number of active workers after each change,
+	// for inspection in tests.
+	schedule []int
+
+	ticker  <-chan time.Time
+	done    chan struct{}
+	stopFn  func()
+	stopped bool
+}
+
+// newRampUp returns a *rampUp which allows "step" additional workers every
+// "interval", up to "max", or nil if ramping is disabled because the interval
+// is not positive or there is only one worker.
+func newRampUp(max, step int, interval time.Duration) *rampUp {
+	if interval <= 0 || max <= 1 {
+		return nil
+	}
+
+	t := time.NewTicker(interval)
+	r := newRampUpWithTicker(max, step, t.C)
+	r.stopFn = t.Stop
+	return r
+}
+
+// newRampUpWithTicker returns a *rampUp which allows "step" additional workers
+// each time a value is received on "ticker", up to "max".
+func newRampUpWithTicker(max, step int, ticker <-chan time.Time) *rampUp {
+	if step < 1 {
+		step = 1
+	}
+
+	r := &rampUp{
+		active:   1,
+		max:      max,
+		step:     step,
+		schedule: []int{1},
+		ticker:   ticker,
+		done:     make(chan struct{}),
+		stopFn:   func() {},
+	}
+	r.cond = sync.NewCond(&r.mu)
+
+	go r.run()
+	return r
+}
+
+func (r *rampUp) run() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-r.ticker:
+			r.advance()
+		}
+	}
+}
+
+func (r *rampUp) advance() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active >= r.max {
+		return
+	}
+
+	r.active += r.step
+	if r.active > r.max {
+		r.active = r.max
+	}
+	r.schedule = append(r.schedule, r.active)
+	r.cond.Broadcast()
+}
+
+// Wait blocks until the worker numbered "workerNum" (counting from zero) is
+// allowed to process a job.  A nil *rampUp never blocks.
+func (r *rampUp) Wait(workerNum int) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for workerNum >= r.active && !r.stopped {
+		r.cond.Wait()
+	}
+}
+
+// Backoff halves the number of active workers in response to a failed
+// transfer, leaving at least one.
+func (r *rampUp) Backoff() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active <= 1 {
+		return
+	}
+	r.active = r.active / 2
+	r.schedule = append(r.schedule, r.active)
+}
+
+// Active returns the number of workers currently allowed to process jobs.
+func (r *rampUp) Active() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.active
+}
+
+// Schedule returns the number of active workers after each change so far.
+func (r *rampUp) Schedule() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int(nil), r.schedule...)
+}
+
+// Stop releases any waiting workers and stops the ramp.
+func (r *rampUp) Stop() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+	r.stopFn()
+	close(r.done)
+	r.cond.Broadcast()
+}
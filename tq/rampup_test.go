@@ -0,0 +1,90 @@
+package tq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRampUpDisabled(t *testing.T) {
+	assert.Nil(t, newRampUp(8, 1, 0))
+	assert.Nil(t, newRampUp(1, 1, time.Millisecond))
+
+	// A nil ramp never blocks.
+	var r *rampUp
+	r.Wait(7)
+	r.Backoff()
+	r.Stop()
+}
+
+func TestRampUpSchedule(t *testing.T) {
+	ticker := make(chan time.Time)
+	r := newRampUpWithTicker(5, 2, ticker)
+	defer r.Stop()
+
+	assert.Equal(t, 1, r.Active())
+	for i := 0; i < 4; i++ {
+		ticker <- time.Now()
+	}
+
+	// The final tick is consumed before the ramp is inspected.
+	r.Wait(4)
+	assert.Equal(t, []int{1, 3, 5}, r.Schedule())
+}
+
+func TestRampUpBacksOffOnError(t *testing.T) {
+	ticker := make(chan time.Time)
+	r := newRampUpWithTicker(8, 4, ticker)
+	defer r.Stop()
+
+	ticker <- time.Now()
+	ticker <- time.Now()
+	r.Wait(7)
+
+	r.Backoff()
+	r.Backoff()
+	assert.Equal(t, 2, r.Active())
+
+	ticker <- time.Now()
+	r.Wait(5)
+
+	r.Backoff()
+	r.Backoff()
+	r.Backoff()
+	assert.Equal(t, []int{1, 5, 8, 4, 2, 6, 3, 1}, r.Schedule())
+}
+
+func TestRampUpWaitBlocksUntilAllowed(t *testing.T) {
+	ticker := make(chan time.Time)
+	r := newRampUpWithTicker(2, 1, ticker)
+	defer r.Stop()
+
+	released := make(chan struct{})
+	go func() {
+		r.Wait(1)
+		close(released)
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("worker 1 released before ramping up")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	ticker <- time.Now()
+	<-released
+}
+
+func TestRampUpStopReleasesWorkers(t *testing.T) {
+	r := newRampUpWithTicker(4, 1, make(chan time.Time))
+
+	released := make(chan struct{})
+	go func() {
+		r.Wait(3)
+		close(released)
+	}()
+
+	r.Stop()
+	<-released
+}
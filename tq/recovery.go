@@ -0,0 +1,119 @@
+package tq
+
+import (
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/rubyist/tracerx"
+)
+
+// networkRecovery watches transfer results for a burst of connection
+// failures, which usually means that the network has changed underneath us
+// (e.g., a laptop moving between networks, or a VPN coming up).  Once
+// "threshold" connection failures have been seen in a row, it pauses for
+// "pause", drops any pooled connections so that hosts are resolved and dialed
+// again, and gives the remaining objects a fresh retry budget.  Recovery
+// happens at most once per transfer queue.
+type networkRecovery struct {
+	mu          sync.Mutex
+	threshold   int
+	pause       time.Duration
+	consecutive int
+	recovered   bool
+
+	// reset is called to drop pooled connections, and sleep to wait
+	// before reconnecting.  Both may be replaced in tests.
+	reset func()
+	sleep func(time.Duration)
+}
+
+// newNetworkRecovery returns a *networkRecovery which recovers after
+// "threshold" consecutive connection failures, or nil if recovery is disabled
+// because the threshold is not positive.
+func newNetworkRecovery(threshold int, pause time.Duration, reset func()) *networkRecovery {
+	if threshold < 1 {
+		return nil
+	}
+	if reset == nil {
+		reset = func() {}
+	}
+
+	return &networkRecovery{
+		threshold: threshold,
+		pause:     pause,
+		reset:     reset,
+		sleep:     time.Sleep,
+	}
+}
+
+// Succeeded records a successful transfer, ending any run of connection
+// failures.  A nil *networkRecovery does nothing.
+func (n *networkRecovery) Succeeded() {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.consecutive = 0
+}
+
+// Failed records a failed transfer.  If "err" is a connection failure that
+// completes a burst of them, it pauses, resets connections, and returns true
+// to indicate that the caller should give the remaining objects a fresh retry
+// budget.  A nil *networkRecovery always returns false.
+func (n *networkRecovery) Failed(err error) bool {
+	if n == nil {
+		return false
+	}
+
+	if !n.burst(err) {
+		return false
+	}
+
+	// Pause without holding the lock, so that other workers recording
+	// their own results are not held up for the length of it.
+	n.sleep(n.pause)
+	n.reset()
+	return true
+}
+
+// burst records the failure "err", and returns whether it completes the first
+// burst of connection failures, in which case the caller should recover.
+func (n *networkRecovery) burst(err error) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !isConnectionError(err) {
+		n.consecutive = 0
+		return false
+	}
+
+	n.consecutive++
+	if n.recovered || n.consecutive < n.threshold {
+		return false
+	}
+
+	tracerx.Printf("tq: %d consecutive connection failures, reconnecting after %s", n.consecutive, n.pause)
+	n.recovered = true
+	n.consecutive = 0
+	return true
+}
+
+// isConnectionError returns whether "err" was caused by a failure to reach
+// the remote host at all, rather than by a response from it.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch errors.Cause(err).(type) {
+	case *url.Error, net.Error:
+		return true
+	}
+	return false
+}
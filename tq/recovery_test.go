@@ -0,0 +1,104 @@
+package tq
+
+import (
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNetworkRecovery(threshold int) (*networkRecovery, *int, *[]time.Duration) {
+	var resets int
+	var sleeps []time.Duration
+
+	n := newNetworkRecovery(threshold, time.Second, func() { resets++ })
+	n.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	return n, &resets, &sleeps
+}
+
+func connectionError() error {
+	return errors.NewRetriableError(&url.Error{
+		Op:  "Put",
+		URL: "https://example.com/storage/oid",
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+	})
+}
+
+func TestNetworkRecoveryDisabled(t *testing.T) {
+	assert.Nil(t, newNetworkRecovery(0, time.Second, nil))
+
+	// A nil recovery never recovers.
+	var n *networkRecovery
+	n.Succeeded()
+	assert.False(t, n.Failed(connectionError()))
+}
+
+func TestNetworkRecoveryAfterBurstOfConnectionErrors(t *testing.T) {
+	n, resets, sleeps := newTestNetworkRecovery(3)
+
+	assert.False(t, n.Failed(connectionError()))
+	assert.False(t, n.Failed(connectionError()))
+	assert.Equal(t, 0, *resets)
+
+	assert.True(t, n.Failed(connectionError()))
+	assert.Equal(t, 1, *resets)
+	assert.Equal(t, []time.Duration{time.Second}, *sleeps)
+}
+
+func TestNetworkRecoveryOnlyOnce(t *testing.T) {
+	n, resets, _ := newTestNetworkRecovery(2)
+
+	assert.False(t, n.Failed(connectionError()))
+	assert.True(t, n.Failed(connectionError()))
+
+	// Once recovered, a second wave of failures is left to the normal
+	// retry budget.
+	for i := 0; i < 4; i++ {
+		assert.False(t, n.Failed(connectionError()))
+	}
+	assert.Equal(t, 1, *resets)
+}
+
+func TestNetworkRecoverySuccessEndsBurst(t *testing.T) {
+	n, resets, _ := newTestNetworkRecovery(2)
+
+	assert.False(t, n.Failed(connectionError()))
+	n.Succeeded()
+	assert.False(t, n.Failed(connectionError()))
+	assert.Equal(t, 0, *resets)
+}
+
+func TestNetworkRecoveryIgnoresOtherErrors(t *testing.T) {
+	n, resets, _ := newTestNetworkRecovery(2)
+
+	assert.False(t, n.Failed(connectionError()))
+	assert.False(t, n.Failed(errors.NewRetriableError(errors.New("Received status 500"))))
+	assert.False(t, n.Failed(connectionError()))
+	assert.Equal(t, 0, *resets)
+}
+
+func TestNetworkRecoveryPausesWithoutBlockingOthers(t *testing.T) {
+	n, _, _ := newTestNetworkRecovery(1)
+
+	// Other workers must be able to record their results while the
+	// recovering worker is paused.
+	n.sleep = func(time.Duration) {
+		done := make(chan struct{})
+		go func() {
+			n.Succeeded()
+			assert.False(t, n.Failed(connectionError()))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("other workers were blocked during the pause")
+		}
+	}
+
+	assert.True(t, n.Failed(connectionError()))
+}
@@ -0,0 +1,177 @@
+package tq
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ObjectReport describes the outcome of transferring a single object through a
+// *TransferQueue.
+type ObjectReport struct {
+	Oid  string `json:"oid"`
+	Name string `json:"name"`
+	// Bytes is the number of bytes transferred, which is zero for objects
+	// which failed or did not need to be transferred.
+	Bytes int64 `json:"bytes"`
+	// DurationMs is the time in milliseconds from the first attempt to
+	// transfer the object until its final success or failure.
+	DurationMs int64  `json:"duration_ms"`
+	Adapter    string `json:"adapter,omitempty"`
+	// Host is the host the object was transferred to or from.
+	Host    string `json:"host,omitempty"`
+	Success bool   `json:"success"`
+	// Skipped is true for objects which did not need to be transferred,
+	// e.g., because the server already had them.
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	start time.Time
+	end   time.Time
+	// size, url, and user are the size of the object, and the URL and
+	// user given by the action with which it was transferred, as recorded
+	// in the audit log.
+	size int64
+	url  string
+	user string
+}
+
+// transferReport records an *ObjectReport for each object that a
+// *TransferQueue finishes with, in the order in which they were first seen.
+type transferReport struct {
+	mu      sync.Mutex
+	objects map[string]*ObjectReport
+	order   []string
+
+	// batches and batchTime count the batch API requests made, and the
+	// time spent waiting for their responses.
+	batches   int
+	batchTime time.Duration
+
+	// audit is the log to which an entry is appended for each object
+	// finished with, or nil if lfs.auditlog is not set.
+	audit *auditLog
+}
+
+func newTransferReport() *transferReport {
+	return &transferReport{objects: make(map[string]*ObjectReport)}
+}
+
+// object returns the *ObjectReport for the given OID, creating it if needed.
+// The caller must hold r.mu.
+func (r *transferReport) object(oid, name string) *ObjectReport {
+	o, ok := r.objects[oid]
+	if !ok {
+		o = &ObjectReport{Oid: oid, Name: name}
+		r.objects[oid] = o
+		r.order = append(r.order, oid)
+	}
+	return o
+}
+
+// Start records that the given transfer is about to be sent to the named
+// adapter. Only the first attempt starts the clock; retries keep it running.
+func (r *transferReport) Start(t *Transfer, adapter string, a *Action) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o := r.object(t.Oid, t.Name)
+	if o.start.IsZero() {
+		o.start = time.Now()
+	}
+	o.Adapter = adapter
+	o.size = t.Size
+	if a != nil {
+		if u, err := url.Parse(a.Href); err == nil {
+			o.Host = u.Host
+		}
+		o.url, o.user = auditURL(a.Href)
+		if len(o.user) == 0 {
+			o.user = auditUser(a)
+		}
+	}
+}
+
+// Succeed records that the object was transferred, with "size" bytes.
+func (r *transferReport) Succeed(oid, name string, size int64) {
+	r.finish(oid, name, func(o *ObjectReport) {
+		o.Success = true
+		o.Bytes = size
+	})
+}
+
+// Skip records that the object did not need to be transferred.
+func (r *transferReport) Skip(oid, name string) {
+	r.finish(oid, name, func(o *ObjectReport) {
+		o.Success = true
+		o.Skipped = true
+	})
+}
+
+// Fail records that the object could not be transferred because of "err".
+func (r *transferReport) Fail(oid, name string, err error) {
+	r.finish(oid, name, func(o *ObjectReport) {
+		o.Success = false
+		if err != nil {
+			o.Error = err.Error()
+		}
+	})
+}
+
+func (r *transferReport) finish(oid, name string, fn func(o *ObjectReport)) {
+	r.mu.Lock()
+	o := r.object(oid, name)
+	if !o.start.IsZero() {
+		o.end = time.Now()
+		o.DurationMs = int64(o.end.Sub(o.start) / time.Millisecond)
+	}
+	fn(o)
+
+	var entry *auditEntry
+	if r.audit != nil {
+		entry = r.audit.entry(o)
+	}
+	r.mu.Unlock()
+
+	if entry != nil {
+		r.audit.Record(entry)
+	}
+}
+
+// Batch records that a batch API request was made, which took "d" to be
+// answered.
+func (r *transferReport) Batch(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches++
+	r.batchTime += d
+}
+
+// Transferred returns the number of objects transferred successfully so far,
+// not counting those which were skipped.
+func (r *transferReport) Transferred() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, o := range r.objects {
+		if o.Success && !o.Skipped {
+			n++
+		}
+	}
+	return n
+}
+
+// Objects returns a copy of the reports recorded so far.
+func (r *transferReport) Objects() []*ObjectReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	objects := make([]*ObjectReport, 0, len(r.order))
+	for _, oid := range r.order {
+		o := *r.objects[oid]
+		objects = append(objects, &o)
+	}
+	return objects
+}
@@ -0,0 +1,53 @@
+package tq
+
+import (
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferReportRecordsOutcomes(t *testing.T) {
+	r := newTransferReport()
+
+	r.Start(&Transfer{Oid: "a", Name: "a.dat", Size: 3}, "basic", &Action{Href: "https://example.com:8443/storage/a"})
+	r.Start(&Transfer{Oid: "b", Name: "b.dat", Size: 4}, "basic", &Action{Href: "https://example.com/storage/b"})
+	r.Skip("c", "c.dat")
+	r.Succeed("a", "a.dat", 3)
+	r.Fail("b", "b.dat", errors.New("boom"))
+
+	objects := r.Objects()
+	require.Len(t, objects, 3)
+
+	assert.Equal(t, "a", objects[0].Oid)
+	assert.Equal(t, "a.dat", objects[0].Name)
+	assert.Equal(t, int64(3), objects[0].Bytes)
+	assert.Equal(t, "basic", objects[0].Adapter)
+	assert.Equal(t, "example.com:8443", objects[0].Host)
+	assert.True(t, objects[0].Success)
+	assert.Empty(t, objects[0].Error)
+
+	assert.Equal(t, "b", objects[1].Oid)
+	assert.Equal(t, int64(0), objects[1].Bytes)
+	assert.Equal(t, "example.com", objects[1].Host)
+	assert.False(t, objects[1].Success)
+	assert.Equal(t, "boom", objects[1].Error)
+
+	assert.Equal(t, "c", objects[2].Oid)
+	assert.True(t, objects[2].Success)
+	assert.True(t, objects[2].Skipped)
+	assert.Empty(t, objects[2].Adapter)
+}
+
+func TestTransferReportKeepsFirstStartAcrossRetries(t *testing.T) {
+	r := newTransferReport()
+
+	tr := &Transfer{Oid: "a", Name: "a.dat", Size: 3}
+	r.Start(tr, "basic", nil)
+	first := r.objects["a"].start
+	r.Start(tr, "basic", nil)
+
+	assert.Equal(t, first, r.objects["a"].start)
+	assert.Empty(t, r.objects["a"].Host)
+}
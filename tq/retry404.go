@@ -0,0 +1,25 @@
+package tq
+
+import (
+	"github.com/rubyist/tracerx"
+)
+
+// retryNotFound returns whether the object "o", which the batch API reported
+// as not found, should be requested again in a later batch, as it is when
+// lfs.transfer.retry404 is set, since an eventually consistent store may not
+// yet have an object which was uploaded moments ago.  Each object is retried
+// this way with the same backoff and limit as other failures, after which it
+// fails with the error the server gave.
+func (q *TransferQueue) retryNotFound(o *Transfer) bool {
+	if q.direction != Download || !q.manifest.Retry404() {
+		return false
+	}
+	if o.Error == nil || o.Error.Code != 404 {
+		return false
+	}
+	if count, ok := q.rc.CanRetry(o.Oid); !ok {
+		tracerx.Printf("tq: %s still not found after %d retries", o.Oid, count)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package tq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRetry404 downloads an object whose contents are "contents" from a server
+// whose batch API reports it as not found for the first "missing" requests,
+// with the given configuration, and returns the queue once it has finished,
+// along with the number of batch requests made.
+func testRetry404(t *testing.T, missing int, config map[string]string) (*TransferQueue, int) {
+	contents := "eventually consistent"
+	oid := deadlineTestOid(contents)
+
+	var mu sync.Mutex
+	var batches int
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/storage/"+oid {
+			w.Write([]byte(contents))
+			return
+		}
+		if r.URL.Path != "/api/objects/batch" {
+			w.WriteHeader(404)
+			return
+		}
+
+		mu.Lock()
+		batches++
+		found := batches > missing
+		mu.Unlock()
+
+		object := map[string]interface{}{"oid": oid, "size": len(contents)}
+		if found {
+			object["actions"] = map[string]interface{}{
+				"download": map[string]interface{}{
+					"href": fmt.Sprintf("%s/storage/%s", s.URL, oid),
+				},
+			}
+		} else {
+			object["error"] = map[string]interface{}{
+				"code":    404,
+				"message": "Object does not exist",
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transfer": "basic",
+			"objects":  []interface{}{object},
+		})
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-retry404")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config["lfs.url"] = s.URL + "/api"
+	config["lfs.transfer.maxretrydelay"] = "1"
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, config))
+	require.Nil(t, err)
+	filesystem := fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644)
+	m := NewManifest(filesystem, c, "download", "origin")
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("a.dat", filepath.Join(dir, "a.dat"), oid, int64(len(contents)), false, nil)
+	q.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return q, batches
+}
+
+func TestTransferQueueRetry404(t *testing.T) {
+	q, batches := testRetry404(t, 2, map[string]string{
+		"lfs.transfer.retry404": "true",
+	})
+
+	assert.Empty(t, q.Errors())
+	assert.Equal(t, 3, batches)
+	require.Len(t, q.Report(), 1)
+	assert.True(t, q.Report()[0].Success)
+}
+
+func TestTransferQueueRetry404GivesUp(t *testing.T) {
+	q, batches := testRetry404(t, 10, map[string]string{
+		"lfs.transfer.retry404":   "true",
+		"lfs.transfer.maxretries": "2",
+	})
+
+	assert.Equal(t, 3, batches)
+	require.Len(t, q.Errors(), 1)
+	assert.Contains(t, q.Errors()[0].Error(), "Object does not exist")
+	require.Len(t, q.Report(), 1)
+	assert.False(t, q.Report()[0].Success)
+}
+
+func TestTransferQueueWithoutRetry404(t *testing.T) {
+	q, batches := testRetry404(t, 1, map[string]string{})
+
+	assert.Equal(t, 1, batches)
+	require.Len(t, q.Errors(), 1)
+	assert.Contains(t, q.Errors()[0].Error(), "Object does not exist")
+	require.Len(t, q.Report(), 1)
+	assert.False(t, q.Report()[0].Success)
+}
@@ -0,0 +1,188 @@
+package tq
+
+import (
+	"sort"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+)
+
+// SelectionReason is why a transfer adapter was, or was not, used for an
+// operation, as found by ExplainAdapterSelection.
+type SelectionReason int
+
+const (
+	// SelectedByServer means that the server chose the adapter from
+	// those offered in the batch request, or named no adapter, in which
+	// case "basic" is used, as the API specifies.
+	SelectedByServer SelectionReason = iota
+	// SelectedByDefault means that "basic" is used because the server
+	// chose an adapter which is not configured locally.
+	SelectedByDefault
+	// SelectedStandalone means that the adapter is the standalone
+	// transfer agent set by lfs.standalonetransferagent, so the server is
+	// not asked at all.
+	SelectedStandalone
+	// NotOfferedBasicOnly means that the adapter was not offered to the
+	// server because lfs.basictransfersonly is set.
+	NotOfferedBasicOnly
+	// NotOfferedDirection means that the adapter was not offered to the
+	// server because it is only configured for the other direction.
+	NotOfferedDirection
+	// NotSelectedPreferred means that the server accepts the adapter when
+	// it is offered alongside "basic", but chose another one when every
+	// adapter was offered.
+	NotSelectedPreferred
+	// NotSelectedUnsupported means that the server does not accept the
+	// adapter, even when it is offered alongside "basic" only.
+	NotSelectedUnsupported
+	// NotConfigured means that the server chose the adapter, but it is not
+	// configured locally.
+	NotConfigured
+)
+
+// AdapterDecision is why a single transfer adapter was, or was not, used.
+type AdapterDecision struct {
+	Name   string
+	Reason SelectionReason
+	// Direction is the only direction for which the adapter is configured,
+	// if Reason is NotOfferedDirection.
+	Direction Direction
+}
+
+// AdapterSelection is the outcome of negotiating a transfer adapter with the
+// server for a remote, along with why each adapter which might have been used
+// was, or was not.
+type AdapterSelection struct {
+	// Selected is the name of the adapter which a transfer queue would
+	// use.
+	Selected string
+	// Offered is the names of the adapters offered to the server, in
+	// order of name.
+	Offered []string
+	// Decisions holds one *AdapterDecision for each adapter, with that of
+	// the selected adapter first and the others in order of name.
+	Decisions []*AdapterDecision
+}
+
+// ExplainAdapterSelection offers the server for "remote" the transfer adapters
+// configured for "dir", as a transfer queue would, and returns which one would
+// be used and why the others would not.  Each adapter which the server did not
+// choose is then offered again alongside "basic" only, to tell those which the
+// server does not accept from those to which it preferred another.
+//
+// The "lfs-standalone-file" adapter, which is only used as a standalone
+// transfer agent, and the "ssh" adapter, unless the remote is accessed over
+// SSH, are left out.
+func ExplainAdapterSelection(m *Manifest, dir Direction, remote string, remoteRef *git.Ref) (*AdapterSelection, error) {
+	if m.IsStandaloneTransfer() {
+		return &AdapterSelection{
+			Selected: m.standaloneTransferAgent,
+			Decisions: []*AdapterDecision{
+				{Name: m.standaloneTransferAgent, Reason: SelectedStandalone},
+			},
+		}, nil
+	}
+
+	configured, otherOnly := m.selectableAdapterNames(dir)
+
+	offered := []string{BasicAdapterName}
+	if !m.basicTransfersOnly {
+		offered = configured
+	}
+	sort.Strings(offered)
+
+	bRes, err := Probe(m, dir, remote, remoteRef, offered)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &AdapterSelection{Selected: bRes.TransferAdapterName, Offered: offered}
+	var others []*AdapterDecision
+	if m.NewAdapter(sel.Selected, dir) == nil {
+		others = append(others, &AdapterDecision{Name: sel.Selected, Reason: NotConfigured})
+		sel.Selected = BasicAdapterName
+		sel.Decisions = append(sel.Decisions, &AdapterDecision{Name: BasicAdapterName, Reason: SelectedByDefault})
+	} else {
+		sel.Decisions = append(sel.Decisions, &AdapterDecision{Name: sel.Selected, Reason: SelectedByServer})
+	}
+
+	for _, name := range configured {
+		if name == sel.Selected {
+			continue
+		}
+
+		d := &AdapterDecision{Name: name}
+		others = append(others, d)
+
+		if m.basicTransfersOnly && name != BasicAdapterName {
+			d.Reason = NotOfferedBasicOnly
+			continue
+		}
+		if name == BasicAdapterName {
+			// Every server accepts "basic".
+			d.Reason = NotSelectedPreferred
+			continue
+		}
+
+		bRes, err := Probe(m, dir, remote, remoteRef, []string{name, BasicAdapterName})
+		if err != nil {
+			return nil, err
+		}
+		if bRes.TransferAdapterName == name {
+			d.Reason = NotSelectedPreferred
+		} else {
+			d.Reason = NotSelectedUnsupported
+		}
+	}
+
+	other := Upload
+	if dir == Upload {
+		other = Download
+	}
+	for _, name := range otherOnly {
+		others = append(others, &AdapterDecision{
+			Name:      name,
+			Reason:    NotOfferedDirection,
+			Direction: other,
+		})
+	}
+
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].Name < others[j].Name
+	})
+	sel.Decisions = append(sel.Decisions, others...)
+	return sel, nil
+}
+
+// selectableAdapterNames returns the names of the adapters configured for
+// "dir", whether or not lfs.basictransfersonly is set, and those configured
+// only for the other direction, each in order of name.
+func (m *Manifest) selectableAdapterNames(dir Direction) ([]string, []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ours, theirs := m.downloadAdapterFuncs, m.uploadAdapterFuncs
+	if dir == Upload {
+		ours, theirs = theirs, ours
+	}
+
+	selectable := func(name string) bool {
+		return name != standaloneFileName && (name != "ssh" || m.sshTransfer != nil)
+	}
+
+	var configured, otherOnly []string
+	for name := range ours {
+		if selectable(name) {
+			configured = append(configured, name)
+		}
+	}
+	for name := range theirs {
+		if _, ok := ours[name]; !ok && selectable(name) {
+			otherOnly = append(otherOnly, name)
+		}
+	}
+
+	sort.Strings(configured)
+	sort.Strings(otherOnly)
+	return configured, otherOnly
+}
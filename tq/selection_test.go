@@ -0,0 +1,120 @@
+package tq
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decisionsByName(sel *AdapterSelection) map[string]*AdapterDecision {
+	byName := make(map[string]*AdapterDecision)
+	for _, d := range sel.Decisions {
+		byName[d.Name] = d
+	}
+	return byName
+}
+
+func TestExplainAdapterSelectionServerChoice(t *testing.T) {
+	srv := newProbeServer(t, []string{"accepted"}, "sha256")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, map[string]string{
+		"lfs.customtransfer.accepted.path": "accepted",
+		"lfs.customtransfer.rejected.path": "rejected",
+	})
+
+	sel, err := ExplainAdapterSelection(m, Download, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, "accepted", sel.Selected)
+	assert.Equal(t, []string{"accepted", "basic", "rejected"}, sel.Offered)
+
+	require.Len(t, sel.Decisions, 3)
+	assert.Equal(t, &AdapterDecision{Name: "accepted", Reason: SelectedByServer}, sel.Decisions[0])
+	assert.Equal(t, &AdapterDecision{Name: "basic", Reason: NotSelectedPreferred}, sel.Decisions[1])
+	assert.Equal(t, &AdapterDecision{Name: "rejected", Reason: NotSelectedUnsupported}, sel.Decisions[2])
+}
+
+func TestExplainAdapterSelectionPreferred(t *testing.T) {
+	srv := newProbeServer(t, []string{"first", "second"}, "sha256")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, map[string]string{
+		"lfs.customtransfer.first.path":  "first",
+		"lfs.customtransfer.second.path": "second",
+	})
+
+	sel, err := ExplainAdapterSelection(m, Download, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, "first", sel.Selected)
+
+	byName := decisionsByName(sel)
+	assert.Equal(t, NotSelectedPreferred, byName["second"].Reason)
+}
+
+func TestExplainAdapterSelectionBasicOnly(t *testing.T) {
+	srv := newProbeServer(t, []string{"custom"}, "sha256")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, map[string]string{
+		"lfs.customtransfer.custom.path": "custom",
+		"lfs.basictransfersonly":         "true",
+	})
+
+	sel, err := ExplainAdapterSelection(m, Upload, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, "basic", sel.Selected)
+	assert.Equal(t, []string{"basic"}, sel.Offered)
+
+	byName := decisionsByName(sel)
+	assert.Equal(t, SelectedByServer, byName["basic"].Reason)
+	assert.Equal(t, NotOfferedBasicOnly, byName["custom"].Reason)
+}
+
+func TestExplainAdapterSelectionDirection(t *testing.T) {
+	srv := newProbeServer(t, []string{"uploader"}, "sha256")
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, map[string]string{
+		"lfs.customtransfer.uploader.path":      "uploader",
+		"lfs.customtransfer.uploader.direction": "upload",
+	})
+
+	sel, err := ExplainAdapterSelection(m, Download, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, "basic", sel.Selected)
+	assert.Equal(t, []string{"basic"}, sel.Offered)
+
+	byName := decisionsByName(sel)
+	assert.Equal(t, SelectedByServer, byName["basic"].Reason)
+	assert.Equal(t, &AdapterDecision{Name: "uploader", Reason: NotOfferedDirection, Direction: Upload}, byName["uploader"])
+
+	sel, err = ExplainAdapterSelection(m, Upload, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, "uploader", sel.Selected)
+}
+
+func TestExplainAdapterSelectionNotConfigured(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transfer": "mystery",
+			"objects":  []interface{}{},
+		})
+	}))
+	defer srv.Close()
+
+	m := newProbeManifest(t, srv, nil)
+
+	sel, err := ExplainAdapterSelection(m, Download, "origin", &git.Ref{Name: "main"})
+	require.Nil(t, err)
+	assert.Equal(t, "basic", sel.Selected)
+
+	require.Len(t, sel.Decisions, 2)
+	assert.Equal(t, &AdapterDecision{Name: "basic", Reason: SelectedByDefault}, sel.Decisions[0])
+	assert.Equal(t, &AdapterDecision{Name: "mystery", Reason: NotConfigured}, sel.Decisions[1])
+}
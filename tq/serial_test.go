@@ -0,0 +1,143 @@
+package tq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSerialUploads uploads several objects to a server which takes a while
+// to store each of them, with the given configuration, in which "%s" in keys
+// is replaced by the URL of the server's API, and returns the queue once it
+// has finished, along with the largest number of uploads the server handled
+// at once.
+func testSerialUploads(t *testing.T, config map[string]string) (*TransferQueue, int) {
+	names := []string{"a", "b", "c", "d"}
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/objects/batch" {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			ioutil.ReadAll(r.Body)
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return
+		}
+
+		var req struct {
+			Objects []struct {
+				Oid  string `json:"oid"`
+				Size int64  `json:"size"`
+			} `json:"objects"`
+		}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+
+		objects := make([]interface{}, 0, len(req.Objects))
+		for _, o := range req.Objects {
+			objects = append(objects, map[string]interface{}{
+				"oid":  o.Oid,
+				"size": o.Size,
+				"actions": map[string]interface{}{
+					"upload": map[string]interface{}{
+						"href": fmt.Sprintf("%s/storage/%s", s.URL, o.Oid),
+					},
+				},
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transfer": "basic",
+			"objects":  objects,
+		})
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "tq-serial")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	gitConfig := map[string]string{
+		"lfs.url":                 s.URL + "/api",
+		"lfs.concurrenttransfers": "4",
+	}
+	for k, v := range config {
+		if k == "lfs.%s.transfer.serial" {
+			k = fmt.Sprintf(k, s.URL+"/api")
+		}
+		gitConfig[k] = v
+	}
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, gitConfig))
+	require.Nil(t, err)
+	filesystem := fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644)
+	m := NewManifest(filesystem, c, "upload", "origin")
+
+	q := NewTransferQueue(Upload, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(name), 0644))
+		q.Add(name, path, deadlineTestOid(name), int64(len(name)), false, nil)
+	}
+	q.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return q, maxInFlight
+}
+
+func TestTransferQueueSerialUploads(t *testing.T) {
+	q, maxInFlight := testSerialUploads(t, map[string]string{
+		"lfs.%s.transfer.serial": "true",
+	})
+
+	assert.Empty(t, q.Errors())
+	assert.Len(t, q.Report(), 4)
+	assert.Equal(t, 1, maxInFlight)
+}
+
+func TestTransferQueueSerialUploadsOtherEndpoint(t *testing.T) {
+	q, _ := testSerialUploads(t, map[string]string{
+		"lfs.https://example.com/api.transfer.serial": "true",
+	})
+
+	assert.Empty(t, q.Errors())
+	assert.Equal(t, 4, q.manifest.ConcurrentTransfers())
+}
+
+func TestManifestSerialUploadsAllowsConcurrentDownloads(t *testing.T) {
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                 "https://example.com/api",
+		"lfs.concurrenttransfers": "4",
+		"lfs.https://example.com/api.transfer.serial": "true",
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, 1, NewManifest(nil, c, "upload", "origin").ConcurrentTransfers())
+	assert.Equal(t, 4, NewManifest(nil, c, "download", "origin").ConcurrentTransfers())
+}
@@ -0,0 +1,129 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const shortReadContents = "contents which are cut short"
+
+// shortReadServer is a server whose first "short" downloads give the full
+// Content-Length of the object but close the connection after sending only
+// half of its contents.
+type shortReadServer struct {
+	*httptest.Server
+
+	short     int32
+	downloads int32
+}
+
+func newShortReadServer(t *testing.T, short int32) *shortReadServer {
+	s := &shortReadServer{short: short}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+			require.Len(t, bReq.Objects, 1)
+			oid := bReq.Objects[0].Oid
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects": []interface{}{map[string]interface{}{
+					"oid":  oid,
+					"size": len(shortReadContents),
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, oid),
+						},
+					},
+				}},
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			if atomic.AddInt32(&s.downloads, 1) > s.short {
+				w.Write([]byte(shortReadContents))
+				return
+			}
+
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			require.Nil(t, err)
+			defer conn.Close()
+
+			fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n", len(shortReadContents))
+			buf.WriteString(shortReadContents[:len(shortReadContents)/2])
+			buf.Flush()
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	return s
+}
+
+func downloadFromShortReadServer(t *testing.T, s *shortReadServer, retries int) (*TransferQueue, string) {
+	dir, err := ioutil.TempDir("", "tq-short-read")
+	require.Nil(t, err)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                    s.URL + "/api",
+		"lfs.transfer.maxretries":    fmt.Sprintf("%d", retries),
+		"lfs.transfer.maxretrydelay": "1",
+	}))
+	require.Nil(t, err)
+	m := NewManifest(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), c, "download", "origin")
+
+	sum := sha256.Sum256([]byte(shortReadContents))
+	path := filepath.Join(dir, "object")
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("a.dat", path, hex.EncodeToString(sum[:]), int64(len(shortReadContents)), false, nil)
+	q.Wait()
+	return q, dir
+}
+
+func TestDownloadShortReadRetries(t *testing.T) {
+	s := newShortReadServer(t, 1)
+	defer s.Close()
+
+	q, dir := downloadFromShortReadServer(t, s, 3)
+	defer os.RemoveAll(dir)
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 2, s.downloads)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "object"))
+	require.Nil(t, err)
+	assert.Equal(t, shortReadContents, string(data))
+}
+
+func TestDownloadShortReadFailsAfterRetries(t *testing.T) {
+	s := newShortReadServer(t, 100)
+	defer s.Close()
+
+	q, dir := downloadFromShortReadServer(t, s, 2)
+	defer os.RemoveAll(dir)
+
+	require.Len(t, q.Errors(), 1)
+	assert.Contains(t, q.Errors()[0].Error(), fmt.Sprintf("short read: received %d of %d bytes", len(shortReadContents)/2, len(shortReadContents)))
+	assert.EqualValues(t, 3, s.downloads)
+}
@@ -0,0 +1,138 @@
+package tq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/v3/fs"
+	"github.com/git-lfs/git-lfs/v3/git"
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sizeCheckContents = "contents of the expected size"
+
+// newWrongSizeServer returns a server whose batch responses give "size" as the
+// size of each object, and whose first "wrong" downloads send "body" rather
+// than sizeCheckContents.
+func newWrongSizeServer(t *testing.T, size int, wrong int32, body string, downloads *int32) *httptest.Server {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/objects/batch" {
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+			require.Len(t, bReq.Objects, 1)
+			oid := bReq.Objects[0].Oid
+
+			w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer": "basic",
+				"objects": []interface{}{map[string]interface{}{
+					"oid":  oid,
+					"size": size,
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href": fmt.Sprintf("%s/storage/%s", s.URL, oid),
+						},
+					},
+				}},
+			})
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/storage/") {
+			if atomic.AddInt32(downloads, 1) > wrong {
+				w.Write([]byte(sizeCheckContents))
+			} else {
+				w.Write([]byte(body))
+			}
+			return
+		}
+
+		w.WriteHeader(404)
+	}))
+	return s
+}
+
+func downloadFromWrongSizeServer(t *testing.T, s *httptest.Server, size, retries int) (*TransferQueue, string) {
+	dir, err := ioutil.TempDir("", "tq-size-check")
+	require.Nil(t, err)
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.url":                    s.URL + "/api",
+		"lfs.transfer.maxretries":    fmt.Sprintf("%d", retries),
+		"lfs.transfer.maxretrydelay": "1",
+	}))
+	require.Nil(t, err)
+	m := NewManifest(fs.New(emptyEnvironment{}, dir, "", filepath.Join(dir, "lfs"), 0644), c, "download", "origin")
+
+	sum := sha256.Sum256([]byte(sizeCheckContents))
+	path := filepath.Join(dir, "object")
+
+	q := NewTransferQueue(Download, m, "origin", RemoteRef(&git.Ref{Name: "main"}))
+	q.Add("a.dat", path, hex.EncodeToString(sum[:]), int64(size), false, nil)
+	q.Wait()
+	return q, dir
+}
+
+func TestDownloadWrongSizeRetries(t *testing.T) {
+	var downloads int32
+	s := newWrongSizeServer(t, len(sizeCheckContents), 1, "another object", &downloads)
+	defer s.Close()
+
+	q, dir := downloadFromWrongSizeServer(t, s, len(sizeCheckContents), 3)
+	defer os.RemoveAll(dir)
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 2, downloads)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "object"))
+	require.Nil(t, err)
+	assert.Equal(t, sizeCheckContents, string(data))
+}
+
+func TestDownloadWrongSizeFailsAfterRetries(t *testing.T) {
+	var downloads int32
+	s := newWrongSizeServer(t, len(sizeCheckContents), 100, sizeCheckContents+" and some more", &downloads)
+	defer s.Close()
+
+	q, dir := downloadFromWrongSizeServer(t, s, len(sizeCheckContents), 2)
+	defer os.RemoveAll(dir)
+
+	sum := sha256.Sum256([]byte(sizeCheckContents))
+	require.Len(t, q.Errors(), 1)
+	assert.Contains(t, q.Errors()[0].Error(), fmt.Sprintf("expected %d bytes of %s, got %d",
+		len(sizeCheckContents), hex.EncodeToString(sum[:]), len(sizeCheckContents+" and some more")))
+	assert.EqualValues(t, 3, downloads)
+
+	_, err := os.Stat(filepath.Join(dir, "object"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadWithoutSizeInBatchResponse(t *testing.T) {
+	var downloads int32
+	s := newWrongSizeServer(t, 0, 0, "", &downloads)
+	defer s.Close()
+
+	q, dir := downloadFromWrongSizeServer(t, s, len(sizeCheckContents), 1)
+	defer os.RemoveAll(dir)
+
+	assert.Empty(t, q.Errors())
+	assert.EqualValues(t, 1, downloads)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "object"))
+	require.Nil(t, err)
+	assert.Equal(t, sizeCheckContents, string(data))
+}
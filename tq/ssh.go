@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/ssh"
 	"github.com/git-lfs/git-lfs/v3/tools"
@@ -43,7 +44,7 @@ func (a *SSHBatchClient) batchInternal(args []string, batchLines []string) (int,
 
 func (a *SSHBatchClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, error) {
 	bRes := &BatchResponse{TransferAdapterName: "ssh"}
-	if len(bReq.Objects) == 0 {
+	if len(bReq.Objects) == 0 && !bReq.probe {
 		return bRes, nil
 	}
 
@@ -261,18 +262,23 @@ func (a *SSHAdapter) doDownload(t *Transfer, conn *ssh.PktlineConnection, f *os.
 	hasher := tools.NewHashingReader(data)
 	written, err := tools.CopyWithCallback(f, hasher, t.Size, ccb)
 	if err != nil {
-		return errors.Wrapf(err, tr.Tr.Get("cannot write data to temporary file %q", dlfilename))
+		return a.writeError(dlfilename, err)
 	}
 
 	if actual := hasher.Hash(); actual != t.Oid {
-		return errors.New(tr.Tr.Get("expected OID %s, got %s after %d bytes written", t.Oid, actual, written))
+		err := errors.New(tr.Tr.Get("expected OID %s, got %s after %d bytes written", t.Oid, actual, written))
+		f.Close()
+		return a.handleMismatch(t, dlfilename, err)
 	}
 
 	if err := f.Close(); err != nil {
+		if fs.IsDiskFullError(err) {
+			return a.fs.DiskFullError(err)
+		}
 		return errors.New(tr.Tr.Get("can't close temporary file %q: %v", dlfilename, err))
 	}
 
-	err = tools.RenameFileCopyPermissions(dlfilename, t.Path)
+	err = a.fs.FinalizeObject(dlfilename, t.Path)
 	if _, err2 := os.Stat(t.Path); err2 == nil {
 		// Target file already exists, possibly was downloaded by other git-lfs process
 		return nil
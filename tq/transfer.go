@@ -4,10 +4,13 @@ package tq
 
 import (
 	"fmt"
+	"net/textproto"
+	"sort"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
 	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/tools"
 	"github.com/git-lfs/git-lfs/v3/tr"
 )
@@ -57,6 +60,9 @@ type Transfer struct {
 	Error         *ObjectError `json:"error,omitempty"`
 	Path          string       `json:"path,omitempty"`
 	Missing       bool         `json:"-"`
+	// Present is set by servers honouring a dedup request when they
+	// already have the object's content and no upload is needed.
+	Present bool `json:"present,omitempty"`
 }
 
 func (t *Transfer) Rel(name string) (*Action, error) {
@@ -106,10 +112,11 @@ func newTransfer(tr *Transfer, name string, path string) *Transfer {
 	for rel, action := range tr.Actions {
 		t.Actions[rel] = &Action{
 			Href:      action.Href,
-			Header:    action.Header,
+			Header:    canonicalActionHeader(action.Header),
 			ExpiresAt: action.ExpiresAt,
 			ExpiresIn: action.ExpiresIn,
 			createdAt: action.createdAt,
+			clockSkew: action.clockSkew,
 		}
 	}
 
@@ -119,10 +126,11 @@ func newTransfer(tr *Transfer, name string, path string) *Transfer {
 		for rel, link := range tr.Links {
 			t.Links[rel] = &Action{
 				Href:      link.Href,
-				Header:    link.Header,
+				Header:    canonicalActionHeader(link.Header),
 				ExpiresAt: link.ExpiresAt,
 				ExpiresIn: link.ExpiresIn,
 				createdAt: link.createdAt,
+				clockSkew: link.clockSkew,
 			}
 		}
 	}
@@ -130,6 +138,34 @@ func newTransfer(tr *Transfer, name string, path string) *Transfer {
 	return t
 }
 
+// canonicalActionHeader returns a copy of the headers given by a batch action
+// with each name in canonical form, so that headers a server sends with
+// nonstandard casing (e.g., "authorization") are treated like any other. If
+// the same header is given more than once with different casings, the value
+// given under the canonical name wins, and otherwise the one whose name sorts
+// last, so that the result does not depend on map iteration order.
+func canonicalActionHeader(header map[string]string) map[string]string {
+	if header == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	canonical := make(map[string]string, len(header))
+	for _, key := range keys {
+		ckey := textproto.CanonicalMIMEHeaderKey(key)
+		if _, ok := header[ckey]; ok && ckey != key {
+			continue
+		}
+		canonical[ckey] = header[key]
+	}
+	return canonical
+}
+
 type Action struct {
 	Href      string            `json:"href"`
 	Header    map[string]string `json:"header,omitempty"`
@@ -139,10 +175,14 @@ type Action struct {
 	Token     string            `json:"-"`
 
 	createdAt time.Time
+	// clockSkew is how far the clock of the server which gave the action
+	// was ahead of the local clock, so that ExpiresAt can be compared
+	// with the local time.
+	clockSkew time.Duration
 }
 
 func (a *Action) IsExpiredWithin(d time.Duration) (time.Time, bool) {
-	return tools.IsExpiredAtOrIn(a.createdAt, d, a.ExpiresAt, time.Duration(a.ExpiresIn)*time.Second)
+	return tools.IsExpiredAtOrInWithSkew(a.createdAt, d, a.ExpiresAt, time.Duration(a.ExpiresIn)*time.Second, a.clockSkew)
 }
 
 type ActionSet map[string]*Action
@@ -195,12 +235,20 @@ type ProgressCallback func(name string, totalSize, readSoFar int64, readSinceLas
 type AdapterConfig interface {
 	APIClient() *lfsapi.Client
 	ConcurrentTransfers() int
+	RampUpStep() int
+	RampUpInterval() time.Duration
+	OnMismatch() MismatchMode
+	HTTPStack() lfshttp.HTTPStack
 	Remote() string
 }
 
 type adapterConfig struct {
 	apiClient           *lfsapi.Client
 	concurrentTransfers int
+	rampUpStep          int
+	rampUpInterval      time.Duration
+	onMismatch          MismatchMode
+	httpStack           lfshttp.HTTPStack
 	remote              string
 }
 
@@ -208,6 +256,25 @@ func (c *adapterConfig) ConcurrentTransfers() int {
 	return c.concurrentTransfers
 }
 
+func (c *adapterConfig) RampUpStep() int {
+	return c.rampUpStep
+}
+
+func (c *adapterConfig) RampUpInterval() time.Duration {
+	return c.rampUpInterval
+}
+
+func (c *adapterConfig) OnMismatch() MismatchMode {
+	return c.onMismatch
+}
+
+func (c *adapterConfig) HTTPStack() lfshttp.HTTPStack {
+	if len(c.httpStack) == 0 {
+		return lfshttp.HTTPStackNative
+	}
+	return c.httpStack
+}
+
 func (c *adapterConfig) APIClient() *lfsapi.Client {
 	return c.apiClient
 }
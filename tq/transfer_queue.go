@@ -2,12 +2,15 @@ package tq
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/git-lfs/git-lfs/v3/errors"
+	"github.com/git-lfs/git-lfs/v3/fs"
 	"github.com/git-lfs/git-lfs/v3/git"
 	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/git-lfs/git-lfs/v3/tools"
@@ -39,6 +42,15 @@ func newRetryCounter() *retryCounter {
 	}
 }
 
+// Reset forgets the number of retries made for every OID, giving each a fresh
+// retry budget. It is safe to call across multiple goroutines.
+func (r *retryCounter) Reset() {
+	r.cmu.Lock()
+	defer r.cmu.Unlock()
+
+	r.count = make(map[string]int)
+}
+
 // Increment increments the number of retries for a given OID and returns the
 // new value. It is safe to call across multiple goroutines.
 func (r *retryCounter) Increment(oid string) int {
@@ -205,6 +217,44 @@ type TransferQueue struct {
 	wait     *abortableWaitGroup
 	manifest *Manifest
 	rc       *retryCounter
+	// recovery reconnects after a burst of connection failures, or is nil
+	// if network recovery is disabled.
+	recovery *networkRecovery
+	// report records the outcome of each object, see Report().
+	report *transferReport
+	// priorities holds the scheduling priority of objects given with
+	// AddWithPriority() or SetPriority(), keyed by OID.  Objects which
+	// are not present have priority zero.  It is guarded by trMutex.
+	priorities map[string]int
+
+	// forbidden holds the OIDs of the objects which have been retried
+	// with a fresh action after their download was refused with HTTP 403,
+	// see rebatchForbidden().  It is guarded by trMutex.
+	forbidden map[string]bool
+
+	// aborted is set once a download fails verification and
+	// lfs.transfer.onmismatch is "fail", or fills the disk, after which no
+	// more objects are transferred.  It is guarded by trMutex.
+	aborted bool
+
+	// deadline is the time given with WithDeadline(), if any, once which
+	// has passed the queue expires, and deadlineTimer is what expires it.
+	deadline      time.Time
+	deadlineTimer *time.Timer
+	// expired is set once the deadline has passed, aborting the queue.
+	// It is guarded by trMutex.
+	expired bool
+	// canceled is set once Cancel() has been called, aborting the queue.
+	// It is guarded by trMutex.
+	canceled bool
+
+	// transferProgress, if set, is called as each object's bytes are
+	// transferred, see WithTransferProgress().
+	transferProgress ProgressCallback
+
+	// actionHook, if set, may replace the action used to transfer each
+	// object, see WithActionHook().
+	actionHook ActionHook
 
 	// unsupportedContentType indicates whether the transfer queue ever saw
 	// an HTTP 422 response indicating that their upload destination does
@@ -259,6 +309,12 @@ func (o *objectTuple) ToTransfer() *Transfer {
 
 type Option func(*TransferQueue)
 
+// ActionHook is called with the OID of an object and the action which the
+// server gave to transfer it, and returns the action to use instead, or nil to
+// use the given one.  The action is a copy, which the hook may modify and
+// return.
+type ActionHook func(oid string, action *Action) *Action
+
 func DryRun(dryRun bool) Option {
 	return func(tq *TransferQueue) {
 		tq.dryRun = dryRun
@@ -283,6 +339,23 @@ func WithProgressCallback(cb tools.CopyCallback) Option {
 	}
 }
 
+// WithTransferProgress calls "cb" with the name of each object, along with its
+// size and the number of bytes transferred, as its bytes are transferred, so
+// that the progress of each object may be followed separately.
+func WithTransferProgress(cb ProgressCallback) Option {
+	return func(tq *TransferQueue) {
+		tq.transferProgress = cb
+	}
+}
+
+// WithActionHook calls "hook" for each object just before the queue passes it
+// to the transfer adapter, so that programs embedding Git LFS may change the
+// URL or headers used to transfer it, such as to add a short-lived token for a
+// content delivery network.  The object is still verified against its own OID.
+func WithActionHook(hook ActionHook) Option {
+	return func(tq *TransferQueue) { tq.actionHook = hook }
+}
+
 func WithBatchSize(size int) Option {
 	return func(tq *TransferQueue) { tq.batchSize = size }
 }
@@ -294,24 +367,38 @@ func WithBufferDepth(depth int) Option {
 // NewTransferQueue builds a TransferQueue, direction and underlying mechanism determined by adapter
 func NewTransferQueue(dir Direction, manifest *Manifest, remote string, options ...Option) *TransferQueue {
 	q := &TransferQueue{
-		direction: dir,
-		client:    &tqClient{Client: manifest.APIClient()},
-		remote:    remote,
-		errorc:    make(chan error),
-		transfers: make(map[string]*objects),
-		trMutex:   &sync.Mutex{},
-		manifest:  manifest,
-		rc:        newRetryCounter(),
-		wait:      newAbortableWaitGroup(),
+		direction:  dir,
+		client:     &tqClient{Client: manifest.APIClient()},
+		remote:     remote,
+		errorc:     make(chan error),
+		transfers:  make(map[string]*objects),
+		trMutex:    &sync.Mutex{},
+		manifest:   manifest,
+		rc:         newRetryCounter(),
+		report:     newTransferReport(),
+		wait:       newAbortableWaitGroup(),
+		priorities: make(map[string]int),
+		forbidden:  make(map[string]bool),
 	}
 
 	for _, opt := range options {
 		opt(q)
 	}
 
+	if path := q.manifest.AuditLog(); len(path) > 0 {
+		q.report.audit = newAuditLog(path, q.direction, q.remote)
+	}
+
 	q.rc.MaxRetries = q.manifest.maxRetries
 	q.rc.MaxRetryDelay = q.manifest.maxRetryDelay
-	q.client.SetMaxRetries(q.manifest.maxRetries)
+	if q.client.MaxRetries() != q.manifest.maxRetries {
+		q.client.SetMaxRetries(q.manifest.maxRetries)
+	}
+	q.recovery = newNetworkRecovery(
+		q.manifest.NetworkRecoveryFailures(),
+		q.manifest.NetworkRecoveryDelay(),
+		q.resetConnections,
+	)
 
 	if q.batchSize <= 0 {
 		q.batchSize = defaultBatchSize
@@ -326,6 +413,7 @@ func NewTransferQueue(dir Direction, manifest *Manifest, remote string, options
 	q.incoming = make(chan *objectTuple, q.bufferDepth)
 	q.collectorWait.Add(1)
 	q.errorwait.Add(1)
+	q.startDeadline()
 	q.run()
 
 	return q
@@ -373,6 +461,96 @@ func (q *TransferQueue) Add(name, path, oid string, size int64, missing bool, er
 	q.incoming <- t
 }
 
+// AddWithPriority adds a *Transfer to the transfer queue like Add(), but with
+// the given scheduling priority.  Among the objects waiting to be transferred,
+// those with a higher priority are sent to the server and to the transfer
+// adapter ahead of those with a lower one.  Objects added with Add() have a
+// priority of zero.
+//
+// If an object with the same OID has already been added, its priority is
+// raised to "priority" if that is higher, but never lowered.
+func (q *TransferQueue) AddWithPriority(name, path, oid string, size int64, missing bool, priority int, err error) {
+	if err == nil {
+		q.trMutex.Lock()
+		if current, ok := q.priorities[oid]; !ok || priority > current {
+			q.priorities[oid] = priority
+		}
+		q.trMutex.Unlock()
+	}
+
+	q.Add(name, path, oid, size, missing, err)
+}
+
+// SetPriority changes the scheduling priority of the object with the given OID,
+// for example to move an object which the user is waiting on ahead of the rest
+// of the queue.  It takes effect for objects which have not yet been sent to
+// the server; those already handed to the transfer adapter keep their place.
+func (q *TransferQueue) SetPriority(oid string, priority int) {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	q.priorities[oid] = priority
+}
+
+// priorityOf returns a function giving the current priority of each OID, from
+// a snapshot taken so that sorting need not hold trMutex.
+func (q *TransferQueue) priorityOf() func(oid string) int {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if len(q.priorities) == 0 {
+		return func(string) int { return 0 }
+	}
+
+	snapshot := make(map[string]int, len(q.priorities))
+	for oid, p := range q.priorities {
+		snapshot[oid] = p
+	}
+	return func(oid string) int { return snapshot[oid] }
+}
+
+// schedule orders "b" in place so that objects with a higher priority come
+// first.  Objects with the same priority keep their relative order, so that
+// retries stay ahead of newly-collected objects.
+func (q *TransferQueue) schedule(b batch) {
+	priority := q.priorityOf()
+	sort.SliceStable(b, func(i, j int) bool {
+		return priority(b[i].Oid) > priority(b[j].Oid)
+	})
+}
+
+// sortBatch orders a batch which is about to be sent to the server by
+// descending priority, and then by object size as lfs.transfer.order says.
+func (q *TransferQueue) sortBatch(b batch) {
+	priority := q.priorityOf()
+	idx := sizeOrder(len(b),
+		func(i int) int { return priority(b[i].Oid) },
+		func(i int) int64 { return b[i].Size },
+		q.manifest.TransferOrder())
+
+	sorted := make(batch, 0, len(b))
+	for _, i := range idx {
+		sorted = append(sorted, b[i])
+	}
+	copy(b, sorted)
+}
+
+// sortTransfers orders the transfers which are about to be given to the
+// adapter as sortBatch orders a batch.
+func (q *TransferQueue) sortTransfers(transfers []*Transfer) {
+	priority := q.priorityOf()
+	idx := sizeOrder(len(transfers),
+		func(i int) int { return priority(transfers[i].Oid) },
+		func(i int) int64 { return transfers[i].Size },
+		q.manifest.TransferOrder())
+
+	sorted := make([]*Transfer, 0, len(transfers))
+	for _, i := range idx {
+		sorted = append(sorted, transfers[i])
+	}
+	copy(transfers, sorted)
+}
+
 // remember remembers the *Transfer "t" if the *TransferQueue doesn't already
 // know about a Transfer with the same OID.
 //
@@ -434,9 +612,9 @@ func (q *TransferQueue) collectBatches() {
 			next = append(next, t)
 		}
 
-		// Before enqueuing the next batch, sort by descending object
-		// size.
-		sort.Sort(sort.Reverse(next))
+		// Before enqueuing the next batch, sort by descending priority
+		// and object size.
+		q.sortBatch(next)
 
 		done := make(chan struct{})
 
@@ -468,13 +646,16 @@ func (q *TransferQueue) collectBatches() {
 			break
 		}
 
-		// Ensure the next batch is filled with, in order:
+		// Ensure the next batch is filled with, in order of priority
+		// and then:
 		//
 		// - retries from the previous batch,
 		// - new additions that were enqueued behind retries, &
 		// - items collected while the batch was processing.
 		var minWaitTime time.Duration
-		next, pending, minWaitTime = retries.Concat(append(pending, collected...), q.batchSize)
+		waiting := append(retries, append(pending, collected...)...)
+		q.schedule(waiting)
+		next, pending, minWaitTime = waiting.Concat(nil, q.batchSize)
 		if len(next) == 0 && len(pending) != 0 {
 			// There are some pending that could not be queued.
 			// Wait the requested time before resuming loop.
@@ -518,8 +699,54 @@ func (q *TransferQueue) collectPendingUntil(done <-chan struct{}) (pending batch
 //
 // enqueueAndCollectRetriesFor blocks until the entire Batch "batch" has been
 // processed.
-func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error) {
+//
+// Objects for which a transfer adapter is preferred are sent in a batch
+// request of their own, which offers the server that adapter first.
+func (q *TransferQueue) enqueueAndCollectRetriesFor(b batch) (batch, error) {
+	batches, preferred := q.splitByPreferredAdapter(b)
+	if len(batches) == 1 {
+		return q.enqueueAndCollectRetriesForAdapter(b, preferred[0])
+	}
+
 	next := q.makeBatch()
+	var retriableErr error
+	for i, pb := range batches {
+		retries, err := q.enqueueAndCollectRetriesForAdapter(pb, preferred[i])
+		next = append(next, retries...)
+		if err != nil {
+			if !errors.IsRetriableError(err) {
+				return next, err
+			}
+			if retriableErr == nil {
+				retriableErr = err
+			}
+		}
+	}
+	return next, retriableErr
+}
+
+// enqueueAndCollectRetriesForAdapter processes "batch" as
+// enqueueAndCollectRetriesFor does, offering the server the adapter named by
+// "preferred" first, unless it is empty.
+func (q *TransferQueue) enqueueAndCollectRetriesForAdapter(batch batch, preferred string) (batch, error) {
+	next := q.makeBatch()
+
+	if abortErr := q.abortError(); abortErr != nil {
+		tracerx.Printf("tq: skipping batch of size %d after abort", len(batch))
+		for _, t := range batch {
+			q.report.Fail(t.Oid, t.Name, abortErr)
+			q.Skip(t.Size)
+			q.wait.Done()
+		}
+		return next, nil
+	}
+
+	if q.manifest.EmptyObjects() == EmptyObjectLocal {
+		if batch = q.completeEmptyObjects(batch); len(batch) == 0 {
+			return next, nil
+		}
+	}
+
 	tracerx.Printf("tq: sending batch of size %d", len(batch))
 
 	enqueueRetry := func(t *objectTuple, err error, readyTime *time.Time) {
@@ -556,8 +783,18 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		// Query the Git LFS server for what transfer method to use and
 		// details such as URLs, authentication, etc.
 		var err error
-		bRes, err = Batch(q.manifest, q.direction, q.remote, q.ref, batch.ToTransfers())
+		requested := time.Now()
+		adapterNames := q.manifest.GetAdapterNames(q.direction)
+		if len(preferred) > 0 {
+			adapterNames = q.manifest.adapterNamesPreferring(preferred, q.direction)
+		}
+		bRes, err = batchOffering(q.manifest, q.direction, q.remote, q.ref, batch.ToTransfers(), adapterNames)
+		q.report.Batch(time.Since(requested))
 		if err != nil {
+			if q.recovery.Failed(err) {
+				q.rc.Reset()
+			}
+
 			var hasNonScheduledErrors = false
 			// If there was an error making the batch API call, mark all of
 			// the objects for retry, and return them along with the error
@@ -571,6 +808,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 					enqueueRetry(t, err, &readyTime)
 				} else {
 					hasNonScheduledErrors = true
+					q.report.Fail(t.Oid, t.Name, err)
 					q.wait.Done()
 				}
 			}
@@ -599,7 +837,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 			// actions will be empty. It's fine if the file is
 			// missing in that case, since we don't need to upload
 			// it.
-			if o.Missing && len(o.Actions) != 0 {
+			if o.Missing && len(o.Actions) != 0 && !o.Present {
 				return nil, errors.New(tr.Tr.Get("Unable to find source for object %v (try running `git lfs fetch --all`)", o.Oid))
 			}
 		}
@@ -612,7 +850,19 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 
 	for _, o := range bRes.Objects {
 		if o.Error != nil {
-			q.errorc <- errors.Wrapf(o.Error, "[%v] %v", o.Oid, o.Error.Message)
+			err := errors.Wrapf(o.Error, "[%v] %v", o.Oid, o.Error.Message)
+			if q.retryNotFound(o) {
+				q.trMutex.Lock()
+				objects, ok := q.transfers[o.Oid]
+				q.trMutex.Unlock()
+				if ok {
+					enqueueRetry(objects.First(), err, nil)
+					continue
+				}
+			}
+
+			q.report.Fail(o.Oid, q.objectName(o.Oid), err)
+			q.errorc <- err
 			q.Skip(o.Size)
 			q.wait.Done()
 
@@ -627,7 +877,9 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 			// Transfer object, then we give up on the
 			// transfer by telling the progress meter to
 			// skip the number of bytes in "o".
-			q.errorc <- errors.Errorf(tr.Tr.Get("[%v] The server returned an unknown OID.", o.Oid))
+			err := errors.Errorf(tr.Tr.Get("[%v] The server returned an unknown OID.", o.Oid))
+			q.report.Fail(o.Oid, "", err)
+			q.errorc <- err
 
 			q.Skip(o.Size)
 			q.wait.Done()
@@ -636,25 +888,40 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 			// same OID.
 			tr := newTransfer(o, objects.First().Name, objects.First().Path)
 
-			if a, err := tr.Rel(q.direction.String()); err != nil {
+			if q.direction == Upload && o.Present {
+				tracerx.Printf("tq: server already has %s, skipping upload", o.Oid)
+				q.report.Skip(tr.Oid, tr.Name)
+				q.Skip(o.Size)
+				q.wait.Done()
+			} else if a, err := tr.Rel(q.direction.String()); err != nil {
 				if q.canRetryObject(tr.Oid, err) {
 					enqueueRetry(objects.First(), err, nil)
 				} else {
+					q.report.Fail(tr.Oid, tr.Name, err)
 					q.errorc <- errors.Errorf("[%v] %v", tr.Name, err)
 
 					q.Skip(o.Size)
 					q.wait.Done()
 				}
 			} else if a == nil && q.manifest.standaloneTransferAgent == "" {
+				q.report.Skip(tr.Oid, tr.Name)
 				q.Skip(o.Size)
 				q.wait.Done()
 			} else {
+				if a != nil && q.actionHook != nil {
+					a = q.hookAction(tr, a)
+				}
 				q.meter.StartTransfer(objects.First().Name)
+				q.report.Start(tr, q.adapter.Name(), a)
 				toTransfer = append(toTransfer, tr)
 			}
 		}
 	}
 
+	// The server may not return objects in the order they were requested,
+	// so order them again for the adapter's workers.
+	q.sortTransfers(toTransfer)
+
 	retries := q.addToAdapter(bRes.endpoint, toTransfer)
 	for t := range retries {
 		enqueueRetry(t, nil, nil)
@@ -663,6 +930,27 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 	return next, nil
 }
 
+// hookAction passes the action "a" with which "t" is to be transferred to the
+// queue's action hook, and makes the action the hook returns, if any, the one
+// the adapter uses.
+func (q *TransferQueue) hookAction(t *Transfer, a *Action) *Action {
+	given := *a
+	given.Header = make(map[string]string, len(a.Header))
+	for k, v := range a.Header {
+		given.Header[k] = v
+	}
+
+	hooked := q.actionHook(t.Oid, &given)
+	if hooked == nil {
+		return a
+	}
+	hooked.Header = canonicalActionHeader(hooked.Header)
+
+	tracerx.Printf("tq: action hook changed %s action for %s", q.direction, t.Oid)
+	t.Actions[q.direction.String()] = hooked
+	return hooked
+}
+
 // makeBatch returns a new, empty batch, with a capacity equal to the maximum
 // batch size designated by the `*TransferQueue`.
 func (q *TransferQueue) makeBatch() batch { return make(batch, 0, q.batchSize) }
@@ -681,6 +969,7 @@ func (q *TransferQueue) addToAdapter(e lfshttp.Endpoint, pending []*Transfer) <-
 
 		q.errorc <- err
 		for _, t := range pending {
+			q.report.Fail(t.Oid, t.Name, err)
 			q.Skip(t.Size)
 			q.wait.Done()
 		}
@@ -724,7 +1013,9 @@ func (q *TransferQueue) partitionTransfers(transfers []*Transfer) (present []*Tr
 
 		if t.Size < 0 {
 			err = errors.Errorf(tr.Tr.Get("object %q has invalid size (got: %d)", t.Oid, t.Size))
-		} else {
+		} else if !isEmptyObject(t.Oid, t.Size) {
+			// An empty object has nothing to read, so it need not
+			// be in local storage.
 			fd, serr := os.Stat(t.Path)
 			if serr != nil {
 				if os.IsNotExist(serr) {
@@ -734,6 +1025,8 @@ func (q *TransferQueue) partitionTransfers(transfers []*Transfer) (present []*Tr
 				}
 			} else if t.Size != fd.Size() {
 				err = newCorruptObjectError(t.Name, t.Oid)
+			} else if q.manifest.VerifyLocal() {
+				err = verifyLocalObject(t)
 			}
 		}
 
@@ -770,7 +1063,46 @@ func (q *TransferQueue) handleTransferResult(
 ) {
 	oid := res.Transfer.Oid
 
+	if res.Error != nil && fs.IsDiskFullError(res.Error) && !q.isAborted() {
+		// No further downloads can succeed, so stop now, noting
+		// how many objects were stored before the disk filled.
+		res.Error = newDiskFullAbortError(res.Error, q.report.Transferred())
+		q.abort()
+	} else if res.Error != nil && isMismatchAbort(res.Error) {
+		q.abort()
+	} else if abortErr := q.abortError(); res.Error != nil && abortErr != nil {
+		// Transfers which fail after the queue is aborted, or
+		// which the adapter gave up on, are not worth reporting
+		// alongside the error which caused the abort.
+		q.report.Fail(oid, res.Transfer.Name, abortErr)
+		q.wait.Done()
+		return
+	}
+
+	if res.Error != nil && isForbiddenError(res.Error) && q.rebatchForbidden(oid) {
+		// The action may have expired, so send the object to be
+		// retried in the next batch, from which it will get a fresh
+		// one.
+		tracerx.Printf("tq: requesting a fresh action for %s: %s", oid, res.Error)
+
+		q.trMutex.Lock()
+		objects, ok := q.transfers[oid]
+		q.trMutex.Unlock()
+
+		if ok {
+			retries <- objects.First()
+			return
+		}
+	}
+
 	if res.Error != nil {
+		if q.recovery.Failed(res.Error) {
+			// The network appears to have changed underneath us,
+			// so give every remaining object a fresh retry budget
+			// now that we have reconnected.
+			q.rc.Reset()
+		}
+
 		// If there was an error encountered when processing the
 		// transfer (res.Transfer), handle the error as is appropriate:
 		if readyTime, canRetry := q.canRetryObjectLater(oid, res.Error); canRetry {
@@ -815,9 +1147,13 @@ func (q *TransferQueue) handleTransferResult(
 			} else {
 				q.errorc <- res.Error
 			}
+			q.report.Fail(oid, res.Transfer.Name, res.Error)
 			q.wait.Done()
 		}
 	} else {
+		q.recovery.Succeeded()
+		q.report.Succeed(oid, res.Transfer.Name, res.Transfer.Size)
+
 		q.trMutex.Lock()
 		objects := q.transfers[oid]
 		objects.completed = true
@@ -859,14 +1195,26 @@ func (q *TransferQueue) useAdapter(name string) {
 		// changing adapter support in between batches
 		q.finishAdapter()
 	}
-	q.adapter = q.manifest.NewAdapterOrDefault(name, q.direction)
+	adapter := q.manifest.NewAdapterOrDefault(name, q.direction)
+
+	// The adapter is also stopped by expire(), which holds trMutex, and
+	// the queue may have been aborted while the batch for which it is to
+	// be used was being requested.
+	q.trMutex.Lock()
+	q.adapter = adapter
+	if q.aborted {
+		q.stopAdapter(q.adapter)
+	}
+	q.trMutex.Unlock()
 }
 
 func (q *TransferQueue) finishAdapter() {
 	if q.adapterInProgress {
 		q.adapter.End()
 		q.adapterInProgress = false
+		q.trMutex.Lock()
 		q.adapter = nil
+		q.trMutex.Unlock()
 	}
 }
 
@@ -898,6 +1246,9 @@ func (q *TransferQueue) ensureAdapterBegun(e lfshttp.Endpoint) error {
 			// See: lfs.downloadFile() for more.
 			q.cb(total, read, current)
 		}
+		if q.transferProgress != nil {
+			q.transferProgress(name, total, read, current)
+		}
 		return nil
 	}
 
@@ -917,6 +1268,10 @@ func (q *TransferQueue) toAdapterCfg(e lfshttp.Endpoint) AdapterConfig {
 
 	return &adapterConfig{
 		concurrentTransfers: concurrency,
+		rampUpStep:          q.manifest.RampUpStep(),
+		rampUpInterval:      q.manifest.RampUpInterval(),
+		onMismatch:          q.manifest.OnMismatch(),
+		httpStack:           q.manifest.HTTPStack(),
 		apiClient:           apiClient,
 		remote:              q.remote,
 	}
@@ -930,6 +1285,7 @@ func (q *TransferQueue) Wait() {
 
 	q.wait.Wait()
 	q.collectorWait.Wait()
+	q.stopDeadline()
 
 	q.finishAdapter()
 	close(q.errorc)
@@ -953,6 +1309,34 @@ info:   $ git config lfs.contenttype false`))
 	}
 }
 
+// Report returns the outcome of each object the queue has finished with,
+// whether it was transferred, skipped, or failed. It is intended to be called
+// after Wait().
+func (q *TransferQueue) Report() []*ObjectReport {
+	return q.report.Objects()
+}
+
+// Profile returns the time the queue has spent on batch API requests and on
+// transfers, and the throughput of each transfer adapter it has used. It is
+// intended to be called after Wait().
+func (q *TransferQueue) Profile() *Profile {
+	return q.report.Profile()
+}
+
+// objectName returns the name of the first transfer added for the given OID,
+// or the empty string if there is none.
+func (q *TransferQueue) objectName(oid string) string {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if objects, ok := q.transfers[oid]; ok {
+		if t := objects.First(); t != nil {
+			return t.Name
+		}
+	}
+	return ""
+}
+
 // Watch returns a channel where the queue will write the value of each transfer
 // as it completes. If multiple transfers exist with the same OID, they will all
 // be recorded here, even though only one actual transfer took place. The
@@ -991,6 +1375,69 @@ func (q *TransferQueue) canRetryLater(err error) (time.Time, bool) {
 	return errors.IsRetriableLaterError(err)
 }
 
+// resetConnections drops any pooled HTTP connections, so that the next
+// request resolves and dials its host again.
+func (q *TransferQueue) resetConnections() {
+	if client := q.manifest.APIClient(); client != nil {
+		client.ResetConnections()
+	}
+}
+
+// abort stops the queue from transferring any more objects, after a download
+// has failed verification and lfs.transfer.onmismatch is "fail", or has filled
+// the disk.  Transfers which the adapter has already started are allowed to
+// finish.
+func (q *TransferQueue) abort() {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if !q.aborted {
+		tracerx.Printf("tq: aborting after an object failed")
+	}
+	q.aborted = true
+	q.stopAdapter(q.adapter)
+}
+
+// stopAdapter makes "a" give up on the transfers which it has not yet started,
+// and, if the queue has expired or been canceled, cancel those in progress.  It must be called
+// with trMutex held.
+func (q *TransferQueue) stopAdapter(a Adapter) {
+	if q.expired || q.canceled {
+		if ca, ok := a.(cancelableAdapter); ok {
+			ca.cancel()
+			return
+		}
+	}
+	if aa, ok := a.(abortableAdapter); ok {
+		aa.abort()
+	}
+}
+
+func (q *TransferQueue) isAborted() bool {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	return q.aborted
+}
+
+// abortError returns the error to report for the objects which are not
+// transferred because the queue has been aborted, or nil if it has not.
+func (q *TransferQueue) abortError() error {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+
+	if !q.aborted {
+		return nil
+	}
+	if q.canceled {
+		return errTransferCanceled
+	}
+	if q.expired {
+		return errDeadlineExceeded
+	}
+	return errTransferAborted
+}
+
 // canRetryObject returns whether the given error is retriable for the object
 // given by "oid". If the an OID has met its retry limit, then it will not be
 // able to be retried again. If so, canRetryObject returns whether or not that
@@ -1017,3 +1464,23 @@ func (q *TransferQueue) canRetryObjectLater(oid string, err error) (time.Time, b
 func (q *TransferQueue) Errors() []error {
 	return q.errors
 }
+
+// verifyLocalObject hashes the local copy of the given object, returning a
+// MalformedObjectError if its content does not match its OID.
+func verifyLocalObject(t *Transfer) error {
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := tools.NewHashingReader(f)
+	if _, err := io.Copy(ioutil.Discard, hasher); err != nil {
+		return errors.Wrap(err, tr.Tr.Get("could not verify local object %s", t.Oid))
+	}
+	if oid := hasher.Hash(); oid != t.Oid {
+		tracerx.Printf("tq: local object %s has content with OID %s", t.Oid, oid)
+		return newCorruptObjectError(t.Name, t.Oid)
+	}
+	return nil
+}
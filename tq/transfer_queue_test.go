@@ -1,10 +1,16 @@
 package tq
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/git-lfs/git-lfs/v3/lfsapi"
+	"github.com/git-lfs/git-lfs/v3/lfshttp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestManifestDefaultsToFixedRetries(t *testing.T) {
@@ -34,6 +40,17 @@ func TestRetryCounterIncrementsObjects(t *testing.T) {
 	assert.Equal(t, 2, rc.CountFor("oid"))
 }
 
+func TestRetryCounterResetForgetsRetries(t *testing.T) {
+	rc := newRetryCounter()
+	rc.MaxRetries = 1
+	rc.Increment("oid")
+	rc.Reset()
+
+	count, canRetry := rc.CanRetry("oid")
+	assert.Equal(t, 0, count)
+	assert.True(t, canRetry)
+}
+
 func TestRetryCounterCanNotRetryAfterExceedingRetryCount(t *testing.T) {
 	rc := newRetryCounter()
 	rc.MaxRetries = 1
@@ -80,3 +97,90 @@ func TestBatchSizeReturnsBatchSize(t *testing.T) {
 
 	assert.Equal(t, 3, q.BatchSize())
 }
+
+func oidsOf(b batch) []string {
+	oids := make([]string, 0, len(b))
+	for _, t := range b {
+		oids = append(oids, t.Oid)
+	}
+	return oids
+}
+
+func TestSortBatchByPriorityThenSize(t *testing.T) {
+	q := NewTransferQueue(Download, NewManifest(nil, nil, "", ""), "origin")
+	q.SetPriority("urgent", 10)
+	q.SetPriority("later", -1)
+
+	b := batch{
+		{Oid: "later", Size: 500},
+		{Oid: "small", Size: 1},
+		{Oid: "urgent", Size: 2},
+		{Oid: "large", Size: 100},
+	}
+	q.sortBatch(b)
+
+	assert.Equal(t, []string{"urgent", "large", "small", "later"}, oidsOf(b))
+}
+
+func TestScheduleKeepsOrderWithinPriority(t *testing.T) {
+	q := NewTransferQueue(Download, NewManifest(nil, nil, "", ""), "origin")
+
+	b := batch{
+		{Oid: "retry", Size: 1},
+		{Oid: "pending", Size: 100},
+		{Oid: "collected", Size: 50},
+	}
+	q.schedule(b)
+	assert.Equal(t, []string{"retry", "pending", "collected"}, oidsOf(b))
+
+	// Raising the priority of an object which is already queued moves it
+	// ahead the next time the queue is scheduled.
+	q.SetPriority("collected", 1)
+	q.schedule(b)
+	assert.Equal(t, []string{"collected", "retry", "pending"}, oidsOf(b))
+
+	next, pending, _ := b.Concat(nil, 1)
+	assert.Equal(t, []string{"collected"}, oidsOf(next))
+	assert.Equal(t, []string{"retry", "pending"}, oidsOf(pending))
+}
+
+func TestPartitionTransfersVerifiesLocalObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-verifylocal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The OID of "good", and a file of the same size with other content.
+	const oid = "770e607624d689265ca6c44884d0807d9b054d23c473c106c72be9de08b7376c"
+	good := filepath.Join(dir, "good")
+	require.Nil(t, ioutil.WriteFile(good, []byte("good"), 0644))
+	bad := filepath.Join(dir, "bad")
+	require.Nil(t, ioutil.WriteFile(bad, []byte("evil"), 0644))
+
+	transfers := func() []*Transfer {
+		return []*Transfer{
+			{Name: "good.dat", Oid: oid, Size: 4, Path: good},
+			{Name: "bad.dat", Oid: oid, Size: 4, Path: bad},
+		}
+	}
+
+	q := NewTransferQueue(Upload, NewManifest(nil, nil, "", ""), "origin")
+	present, results := q.partitionTransfers(transfers())
+	assert.Len(t, present, 2)
+	assert.Empty(t, results)
+
+	cli, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.upload.verifylocal": "true",
+	}))
+	require.Nil(t, err)
+
+	q = NewTransferQueue(Upload, NewManifest(nil, cli, "", ""), "origin")
+	present, results = q.partitionTransfers(transfers())
+	require.Len(t, present, 1)
+	assert.Equal(t, "good.dat", present[0].Name)
+	require.Len(t, results, 1)
+	assert.Equal(t, "bad.dat", results[0].Transfer.Name)
+
+	malformed, ok := results[0].Error.(*MalformedObjectError)
+	require.True(t, ok)
+	assert.True(t, malformed.Corrupt())
+}
@@ -136,3 +136,58 @@ func testAdapterRegButBasicOnly(t *testing.T) {
 	lu := m.GetUploadAdapterNames()
 	assert.Equal([]string{BasicAdapterName}, lu)
 }
+
+func TestCanonicalActionHeader(t *testing.T) {
+	assert.Nil(t, canonicalActionHeader(nil))
+
+	assert.Equal(t, map[string]string{
+		"Authorization": "Basic abc",
+		"X-Custom-Id":   "1",
+		"Content-Type":  "text/plain",
+	}, canonicalActionHeader(map[string]string{
+		"authorization": "Basic abc",
+		"X-CUSTOM-id":   "1",
+		"Content-Type":  "text/plain",
+	}))
+}
+
+func TestCanonicalActionHeaderPrefersCanonicalName(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, map[string]string{
+			"Authorization": "Basic canonical",
+		}, canonicalActionHeader(map[string]string{
+			"AUTHORIZATION": "Basic upper",
+			"Authorization": "Basic canonical",
+			"authorization": "Basic lower",
+		}))
+	}
+
+	assert.Equal(t, map[string]string{
+		"Authorization": "Basic lower",
+	}, canonicalActionHeader(map[string]string{
+		"AUTHORIZATION": "Basic upper",
+		"authorization": "Basic lower",
+	}))
+}
+
+func TestNewTransferCanonicalizesActionHeaders(t *testing.T) {
+	tr := newTransfer(&Transfer{
+		Oid:  "abc",
+		Size: 123,
+		Actions: ActionSet{
+			"download": &Action{
+				Href:   "https://example.com/abc",
+				Header: map[string]string{"authorization": "Basic abc"},
+			},
+		},
+		Links: ActionSet{
+			"verify": &Action{
+				Href:   "https://example.com/verify",
+				Header: map[string]string{"AUTHORIZATION": "Basic def"},
+			},
+		},
+	}, "abc.dat", "abc.dat")
+
+	assert.Equal(t, map[string]string{"Authorization": "Basic abc"}, tr.Actions["download"].Header)
+	assert.Equal(t, map[string]string{"Authorization": "Basic def"}, tr.Links["verify"].Header)
+}
@@ -70,3 +70,39 @@ func TestVerifySuccess(t *testing.T) {
 	assert.Nil(t, verifyUpload(c, "origin", tr))
 	assert.EqualValues(t, 1, called)
 }
+
+func TestVerifyWithMixedCaseActionHeaders(t *testing.T) {
+	var called uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+
+		assert.Equal(t, "/verify", r.URL.String())
+		assert.Equal(t, "Basic dXNlcjpwYXNz", r.Header.Get("Authorization"))
+		assert.Equal(t, "1", r.Header.Get("X-Custom-Id"))
+	}))
+	defer srv.Close()
+
+	c, err := lfsapi.NewClient(lfshttp.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.maxverifies":          "1",
+		"lfs." + srv.URL + "/verify.access": "Basic",
+	}))
+	require.Nil(t, err)
+
+	tr := newTransfer(&Transfer{
+		Oid:  "abcd1234",
+		Size: 123,
+		Actions: ActionSet{
+			"verify": &Action{
+				Href: srv.URL + "/verify",
+				Header: map[string]string{
+					"AUTHORIZATION": "Basic stale",
+					"authorization": "Basic dXNlcjpwYXNz",
+					"x-CUSTOM-id":   "1",
+				},
+			},
+		},
+	}, "abcd1234", "abcd1234")
+
+	assert.Nil(t, verifyUpload(c, "origin", tr))
+	assert.EqualValues(t, 1, called)
+}